@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// showDecisionRequest is the POST /admin/shows/{id}/decision body.
+type showDecisionRequest struct {
+	Decision string `json:"decision"`
+}
+
+func (d *showDecisionRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if d.Decision != models.ShowDropped && d.Decision != models.ShowKept {
+		errs.Add("decision", `must be "dropped" or "kept"`)
+	}
+	return errs
+}
+
+// HandleAbandonedShows lists shows started and then left alone for months
+// as {"shows": [...]}, the suggestions to drop or keep.
+func HandleAbandonedShows(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		shows, err := r.AbandonedShows(ctx, time.Now())
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to find abandoned shows", zap.Error(err))
+			writeJSONError(ctx, w, "failed to find abandoned shows", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(ctx, w, map[string][]v1.AbandonedShow{"shows": v1.NewAbandonedShows(shows)})
+	}
+}
+
+// HandleShowDecision records {"decision": "dropped"} or {"decision": "kept"}
+// for /admin/shows/{id}/decision. Dropped shows are no longer recommended.
+func HandleShowDecision(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeJSONError(ctx, w, "invalid show id", http.StatusBadRequest)
+			return
+		}
+		var body showDecisionRequest
+		if err := validation.DecodeJSON(w, req, 1024, &body); err != nil {
+			validation.WriteRequestError(ctx, w, err)
+			return
+		}
+		prev, err := r.ShowDecisionFor(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to load show decision", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to save decision", http.StatusInternalServerError)
+			return
+		}
+		d, err := r.DecideShow(ctx, uint(id), body.Decision)
+		switch {
+		case errors.Is(err, recommend.ErrShowNotFound):
+			writeJSONError(ctx, w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(ctx).Errorw("Failed to save show decision", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to save decision", http.StatusInternalServerError)
+			return
+		}
+		var before any
+		if prev != nil {
+			before = map[string]any{"tv_show_id": prev.TVShowID, "decision": prev.Decision}
+		}
+		after := map[string]any{"tv_show_id": d.TVShowID, "decision": d.Decision}
+		recordAudit(ctx, "show.decision", fmt.Sprintf("tv show %d", d.TVShowID), before, after)
+		writeJSON(ctx, w, after)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/apikey"
+	"github.com/icco/recommender/lib/audit"
+	"github.com/icco/recommender/lib/cachestats"
+	"github.com/icco/recommender/lib/explorer"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RequireAdmin guards operator endpoints: see RequireScope.
+func RequireAdmin(token string, keys *apikey.Store) func(http.Handler) http.Handler {
+	return RequireScope(apikey.ScopeAdmin, token, keys)
+}
+
+// RequireScope guards endpoints with "Authorization: Bearer <key>", where the
+// key is either the shared admin token, which can do anything, or an API key
+// from keys holding scope. With neither configured the endpoints are
+// disabled.
+func RequireScope(scope, token string, keys *apikey.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if token == "" && keys == nil {
+				writeJSONError(ctx, w, "endpoint disabled; set ADMIN_TOKEN to enable", http.StatusServiceUnavailable)
+				return
+			}
+			got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || got == "" {
+				writeJSONError(ctx, w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				next.ServeHTTP(w, req.WithContext(context.WithValue(ctx, actorKey{}, "admin token")))
+				return
+			}
+			if keys == nil {
+				writeJSONError(ctx, w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			key, err := keys.Authenticate(ctx, got)
+			switch {
+			case errors.Is(err, apikey.ErrInvalid):
+				writeJSONError(ctx, w, "unauthorized", http.StatusUnauthorized)
+				return
+			case err != nil:
+				logging.FromContext(ctx).Errorw("Failed to check API key", zap.Error(err))
+				writeJSONError(ctx, w, "failed to check API key", http.StatusInternalServerError)
+				return
+			case !apikey.Allows(key, scope):
+				writeJSONError(ctx, w, fmt.Sprintf("this key lacks the %q scope", scope), http.StatusForbidden)
+				return
+			}
+			actor := fmt.Sprintf("key %q (%s…)", key.Name, key.Prefix)
+			next.ServeHTTP(w, req.WithContext(context.WithValue(ctx, actorKey{}, actor)))
+		})
+	}
+}
+
+// actorKey carries who RequireScope let through, for the audit log.
+type actorKey struct{}
+
+// auditKey carries the audit log installed by Audit.
+type auditKey struct{}
+
+// Audit gives the handlers it wraps an audit log, which they record each
+// change they make to, attributed to the caller RequireScope identified.
+func Audit(log *audit.Log) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), auditKey{}, log)))
+		})
+	}
+}
+
+// recordAudit notes in the request's audit log, if it has one, that the
+// caller performed action on target. The change has already happened, so a
+// failure to record it is logged rather than returned.
+func recordAudit(ctx context.Context, action, target string, before, after any) {
+	log, ok := ctx.Value(auditKey{}).(*audit.Log)
+	if !ok {
+		return
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	if actor == "" {
+		actor = "unknown"
+	}
+	if err := log.Record(ctx, actor, action, target, before, after); err != nil {
+		logging.FromContext(ctx).Errorw("Failed to record audit entry", "action", action, "target", target, zap.Error(err))
+	}
+}
+
+// HandleReload re-reads reloadable configuration via reload, which returns
+// the settings in force before and after. On failure the running
+// configuration is left as it was.
+func HandleReload(reload func() (before, after map[string]string, err error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		before, after, err := reload()
+		if err != nil {
+			writeJSONError(req.Context(), w, "reload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		recordAudit(req.Context(), "config.reload", "", before, after)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"reloaded"}` + "\n")); err != nil {
+			logging.FromContext(req.Context()).Errorw("write reload response", zap.Error(err))
+		}
+	}
+}
+
+// backfilledDay is one day HandleBackfill queued generation for.
+type backfilledDay struct {
+	Date    string `json:"date"`
+	JobID   uint   `json:"job_id"`
+	Created bool   `json:"created"` // false when a job for the day was already queued
+}
+
+// HandleBackfill queues daily generation for every day Recommender.MissingDays
+// flags, answering with the jobs: {"days": [{date, job_id, created}]}.
+func HandleBackfill(r *recommend.Recommender, q *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		missing, err := r.MissingDays(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to find missing days", zap.Error(err))
+			writeJSONError(ctx, w, "failed to find missing days", http.StatusInternalServerError)
+			return
+		}
+		out := make([]backfilledDay, 0, len(missing))
+		for _, d := range missing {
+			payload := generatePayload{Date: d.Format("2006-01-02")}
+			job, created, err := q.Enqueue(ctx, JobGenerate, payload, jobs.Options{Key: payload.key()})
+			if err != nil {
+				logging.FromContext(ctx).Errorw("Failed to enqueue backfill", "date", payload.Date, zap.Error(err))
+				writeJSONError(ctx, w, "failed to enqueue generation for "+payload.Date, http.StatusInternalServerError)
+				return
+			}
+			out = append(out, backfilledDay{Date: payload.Date, JobID: job.ID, Created: created})
+		}
+		if len(out) > 0 {
+			recordAudit(ctx, "generation.backfill", "", nil, out)
+		}
+		writeJSON(ctx, w, map[string][]backfilledDay{"days": out})
+	}
+}
+
+// HandleRegenerate queues generation of the {date} path day again, replacing
+// picks that already exist once the new set is saved; if generation fails
+// they are kept (recommend.WithRegenerate). ?slot= picks the slot (daily by
+// default) and ?override_cap=true lets it past the daily LLM cap.
+func HandleRegenerate(r *recommend.Recommender, q *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		s := chi.URLParam(req, "date")
+		if err := validation.ValidateGenerationDate(s); err != nil {
+			writeJSONError(ctx, w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		date, _ := time.Parse("2006-01-02", s) // validated above
+		slot, ok := recommend.LookupSlot(req.URL.Query().Get("slot"))
+		if !ok {
+			writeJSONError(ctx, w, "unknown slot", http.StatusBadRequest)
+			return
+		}
+		// GenerateSlot would skip the day and leave the old picks in place.
+		if b, ok := r.Paused(date); ok {
+			writeJSONError(ctx, w, "generation is paused until "+b.To.Format("2006-01-02"), http.StatusConflict)
+			return
+		}
+
+		payload := generatePayload{Date: s, Regenerate: true}
+		if slot != recommend.DailySlot {
+			payload.Slot = slot.Name
+		}
+		payload.OverrideCap, _ = strconv.ParseBool(req.URL.Query().Get("override_cap"))
+		key := payload.key()
+		job, created, err := q.Enqueue(ctx, JobGenerate, payload, jobs.Options{Key: key})
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to enqueue regeneration", "date", s, zap.Error(err))
+			writeJSONError(ctx, w, "failed to enqueue regeneration", http.StatusInternalServerError)
+			return
+		}
+		if created {
+			recordAudit(ctx, "generation.regenerate", "recommendations "+key, nil, payload)
+		}
+		writeEnqueued(ctx, w, "Regeneration of "+key, job.ID, created)
+	}
+}
+
+// HandleRebuildVectors queues a rebuild of the title vector index from the
+// stored embeddings, for after switching VECTOR_STORE or EMBEDDING_MODEL.
+func HandleRebuildVectors(q *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		job, created, err := q.Enqueue(ctx, JobRebuildVectors, struct{}{}, jobs.Options{Key: JobRebuildVectors})
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to enqueue vector rebuild", zap.Error(err))
+			writeJSONError(ctx, w, "failed to enqueue vector rebuild", http.StatusInternalServerError)
+			return
+		}
+		writeEnqueued(ctx, w, "Vector index rebuild", job.ID, created)
+	}
+}
+
+// HandleCaches reports hit, miss, and eviction counts for the in-memory and
+// on-disk caches as {"caches": [...]}, for sizing them.
+func HandleCaches() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(req.Context(), w, map[string][]cachestats.Stats{"caches": cachestats.All()})
+	}
+}
+
+// HandleDataTables lists the tables the data explorer can browse, with the
+// columns each accepts as filters.
+func HandleDataTables() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string][]explorer.Table{"tables": explorer.Tables}); err != nil {
+			logging.FromContext(req.Context()).Errorw("Failed to encode data tables", zap.Error(err))
+		}
+	}
+}
+
+// HandleDataBrowse serves a read-only page of rows from /admin/data/{table},
+// newest first. ?page= and ?size= paginate, ?q= searches the table's search
+// column, and any other parameter is an exact-match filter on that column.
+func HandleDataBrowse(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+		defer cancel()
+
+		q := explorer.Query{Filters: map[string]string{}, Page: 1, Size: 50}
+		for key, vals := range req.URL.Query() {
+			v := vals[0]
+			switch key {
+			case "page":
+				if _, err := fmt.Sscanf(v, "%d", &q.Page); err != nil {
+					writeJSONError(ctx, w, "invalid page parameter", http.StatusBadRequest)
+					return
+				}
+			case "size":
+				if _, err := fmt.Sscanf(v, "%d", &q.Size); err != nil {
+					writeJSONError(ctx, w, "invalid size parameter", http.StatusBadRequest)
+					return
+				}
+			case "q":
+				q.Search = v
+			default:
+				q.Filters[key] = v
+			}
+		}
+		if err := validation.ValidatePagination(q.Page, q.Size); err != nil {
+			writeJSONError(ctx, w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := explorer.Browse(ctx, db, chi.URLParam(req, "table"), q)
+		switch {
+		case errors.Is(err, explorer.ErrUnknownTable):
+			writeJSONError(ctx, w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, explorer.ErrBadFilter):
+			writeJSONError(ctx, w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logging.FromContext(ctx).Errorw("Failed to browse table", "table", chi.URLParam(req, "table"), zap.Error(err))
+			writeJSONError(ctx, w, "failed to browse table", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to encode table page", zap.Error(err))
+		}
+	}
+}
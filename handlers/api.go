@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/handlers/templates"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+func toAPIRecommendation(rec models.Recommendation) v1.Recommendation {
+	return v1.NewRecommendation(rec, templates.PublicPosterURL(rec.PosterURL))
+}
+
+// HandleAPIRecommendations serves a day's recommendations as JSON, including
+// each pick's scoring breakdown. ?date=YYYY-MM-DD defaults to today (UTC).
+func HandleAPIRecommendations(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		l := logging.FromContext(ctx)
+
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		if s := req.URL.Query().Get("date"); s != "" {
+			if err := validation.ValidateDate(s); err != nil {
+				writeJSONError(ctx, w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			parsed, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				writeJSONError(ctx, w, "invalid date", http.StatusBadRequest)
+				return
+			}
+			date = parsed
+		}
+
+		recs, err := r.GetRecommendationsForDate(ctx, date)
+		if err != nil {
+			l.Errorw("Failed to get recommendations", "date", date, zap.Error(err))
+			writeJSONError(ctx, w, "failed to get recommendations", http.StatusInternalServerError)
+			return
+		}
+		theme, err := r.ThemeForDate(ctx, date)
+		if err != nil {
+			l.Warnw("Failed to get theme for date", "date", date, zap.Error(err))
+		}
+
+		out := v1.Recommendations{
+			Date:            v1.Date(date),
+			Theme:           theme,
+			Recommendations: make([]v1.Recommendation, 0, len(recs)),
+		}
+		for _, rec := range recs {
+			out.Recommendations = append(out.Recommendations, toAPIRecommendation(rec))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			l.Errorw("Failed to encode recommendations", zap.Error(err))
+		}
+	}
+}
+
+// writeJSONError writes {"error": message} with status.
+func writeJSONError(ctx context.Context, w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		logging.FromContext(ctx).Errorw("Failed to encode JSON error response", zap.Error(err))
+	}
+}
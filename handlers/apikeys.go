@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/apikey"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// apiKey is an API key as /admin/keys reports it. Key, the token itself, is
+// only set in the response that creates it.
+type apiKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func toAPIKey(k models.APIKey) apiKey {
+	return apiKey{
+		ID: k.ID, Name: k.Name, Prefix: k.Prefix, Scopes: apikey.ScopesOf(k),
+		CreatedAt: k.CreatedAt, LastUsedAt: k.LastUsedAt,
+	}
+}
+
+// apiKeyRequest is the POST /admin/keys body.
+type apiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (k *apiKeyRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if k.Name == "" {
+		errs.Add("name", "is required")
+	} else if len(k.Name) > 64 {
+		errs.Add("name", "must be at most 64 bytes")
+	}
+	if len(k.Scopes) == 0 {
+		errs.Add("scopes", "must list at least one scope")
+	}
+	for _, s := range k.Scopes {
+		if !slices.Contains(apikey.Scopes, s) {
+			errs.Add("scopes", `must each be "read", "feedback", "cron", or "admin"`)
+			break
+		}
+	}
+	return errs
+}
+
+// HandleAPIKeys lists API keys on GET as {"keys": [...]}, and on POST issues
+// one with {"name": "home assistant", "scopes": ["read", "cron"]}. The new
+// key's token is in the response's "key" and can't be shown again.
+func HandleAPIKeys(keys *apikey.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		if req.Method == http.MethodPost {
+			var body apiKeyRequest
+			if err := validation.DecodeJSON(w, req, 4096, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+			token, key, err := keys.Create(ctx, body.Name, body.Scopes)
+			if err != nil {
+				l.Errorw("Failed to create API key", zap.Error(err))
+				writeJSONError(ctx, w, "failed to create API key", http.StatusInternalServerError)
+				return
+			}
+			l.Infow("API key created", "key", key.Prefix, "name", key.Name, "scopes", key.Scopes)
+			out := toAPIKey(key)
+			recordAudit(ctx, "apikey.create", fmt.Sprintf("key %d", key.ID), nil, out)
+			out.Key = token
+			writeJSON(ctx, w, out)
+			return
+		}
+
+		list, err := keys.List(ctx)
+		if err != nil {
+			l.Errorw("Failed to list API keys", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list API keys", http.StatusInternalServerError)
+			return
+		}
+		out := make([]apiKey, 0, len(list))
+		for _, k := range list {
+			out = append(out, toAPIKey(k))
+		}
+		writeJSON(ctx, w, map[string][]apiKey{"keys": out})
+	}
+}
+
+// HandleRevokeAPIKey deletes the API key /admin/keys/{id}; it stops working
+// at once.
+func HandleRevokeAPIKey(keys *apikey.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeJSONError(ctx, w, "invalid key id", http.StatusBadRequest)
+			return
+		}
+		key, found, err := keys.Revoke(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to revoke API key", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to revoke API key", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeJSONError(ctx, w, "API key not found", http.StatusNotFound)
+			return
+		}
+		recordAudit(ctx, "apikey.revoke", fmt.Sprintf("key %d", key.ID), toAPIKey(key), nil)
+		writeJSON(ctx, w, map[string]string{"status": "revoked"})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+type archiveData struct {
+	Recommendations []models.Recommendation
+	Genres          []string
+	Decades         []int
+	Genre           string
+	Decade          int
+	Page            int
+	PageSize        int
+	Total           int64
+	TotalPages      int
+	PrevURL         string
+	NextURL         string
+}
+
+// HandleArchive serves /archive: every past pick, newest first, filtered by
+// ?genre= (any of a pick's genres) and ?decade= (e.g. 1980s), paginated
+// with ?page= and ?size=.
+func HandleArchive(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		l := logging.FromContext(ctx)
+		q := req.URL.Query()
+
+		page := 1
+		pageSize := 24
+		if pageStr := q.Get("page"); pageStr != "" {
+			if _, err := fmt.Sscanf(pageStr, "%d", &page); err != nil {
+				writeError(w, req, "invalid page parameter", http.StatusBadRequest)
+				return
+			}
+		}
+		if sizeStr := q.Get("size"); sizeStr != "" {
+			if _, err := fmt.Sscanf(sizeStr, "%d", &pageSize); err != nil {
+				writeError(w, req, "invalid size parameter", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := validation.ValidatePagination(page, pageSize); err != nil {
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
+		decade, err := recommend.ParseDecade(q.Get("decade"))
+		if err != nil {
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter := recommend.ArchiveFilter{Genre: q.Get("genre"), Decade: decade}
+
+		recs, total, err := r.GetArchive(ctx, filter, page, pageSize)
+		if err != nil {
+			l.Errorw("Failed to get archive", "filter", filter, zap.Error(err))
+			writeError(w, req, "We couldn't load the archive.", http.StatusInternalServerError)
+			return
+		}
+		genres, err := r.ArchiveGenres(ctx)
+		if err != nil {
+			l.Warnw("Failed to get archive genres", zap.Error(err))
+		}
+		decades, err := r.ArchiveDecades(ctx)
+		if err != nil {
+			l.Warnw("Failed to get archive decades", zap.Error(err))
+		}
+
+		data := archiveData{
+			Recommendations: recs,
+			Genres:          genres,
+			Decades:         decades,
+			Genre:           filter.Genre,
+			Decade:          filter.Decade,
+			Page:            page,
+			PageSize:        pageSize,
+			Total:           total,
+			TotalPages:      int((total + int64(pageSize) - 1) / int64(pageSize)),
+		}
+		if page > 1 {
+			data.PrevURL = archivePageURL(filter, page-1, pageSize)
+		}
+		if page < data.TotalPages {
+			data.NextURL = archivePageURL(filter, page+1, pageSize)
+		}
+
+		if !renderTemplate(ctx, w, []string{baseTemplate, "archive.html", "card.html"}, data) {
+			return
+		}
+	}
+}
+
+// archivePageURL links to another page of the archive under the same filter.
+func archivePageURL(f recommend.ArchiveFilter, page, pageSize int) string {
+	q := url.Values{"page": {strconv.Itoa(page)}, "size": {strconv.Itoa(pageSize)}}
+	if f.Genre != "" {
+		q.Set("genre", f.Genre)
+	}
+	if f.Decade > 0 {
+		q.Set("decade", fmt.Sprintf("%ds", f.Decade))
+	}
+	return "/archive?" + q.Encode()
+}
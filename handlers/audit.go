@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/audit"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// apiAuditEntry is an audit entry as /admin/audit reports it.
+type apiAuditEntry struct {
+	ID     uint            `json:"id"`
+	At     time.Time       `json:"at"`
+	Actor  string          `json:"actor"`
+	Action string          `json:"action"`
+	Target string          `json:"target,omitempty"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func toAPIAuditEntry(e models.AuditEntry) apiAuditEntry {
+	out := apiAuditEntry{ID: e.ID, At: e.CreatedAt, Actor: e.Actor, Action: e.Action, Target: e.Target, Before: e.Before, After: e.After}
+	if out.Before == nil {
+		out.Before = json.RawMessage("null")
+	}
+	if out.After == nil {
+		out.After = json.RawMessage("null")
+	}
+	return out
+}
+
+// apiAuditPage is the JSON view of /admin/audit.
+type apiAuditPage struct {
+	Entries []apiAuditEntry `json:"entries"`
+	Page    int             `json:"page"`
+	Size    int             `json:"size"`
+	Total   int64           `json:"total"`
+}
+
+// HandleAudit lists changes made through the operator endpoints, newest
+// first: who made each, what it was, and the record before and after.
+// ?action= (e.g. pin.add) filters, and ?page= and ?size= paginate.
+func HandleAudit(log *audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		q := audit.Query{Action: req.URL.Query().Get("action"), Page: 1, Size: 50}
+		for _, p := range []struct {
+			name string
+			dst  *int
+		}{{"page", &q.Page}, {"size", &q.Size}} {
+			if v := req.URL.Query().Get(p.name); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					writeJSONError(ctx, w, "invalid "+p.name+" parameter", http.StatusBadRequest)
+					return
+				}
+				*p.dst = n
+			}
+		}
+		if err := validation.ValidatePagination(q.Page, q.Size); err != nil {
+			writeJSONError(ctx, w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, total, err := log.List(ctx, q)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to list audit entries", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list audit entries", http.StatusInternalServerError)
+			return
+		}
+		out := apiAuditPage{Entries: make([]apiAuditEntry, 0, len(entries)), Page: q.Page, Size: q.Size, Total: total}
+		for _, e := range entries {
+			out.Entries = append(out.Entries, toAPIAuditEntry(e))
+		}
+		writeJSON(ctx, w, out)
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/icco/recommender/handlers/templates"
+	"github.com/icco/recommender/lib/recommend"
+)
+
+// calendarDay is one cell of a month calendar.
+type calendarDay struct {
+	Date     time.Time
+	InMonth  bool // false for the neighbouring months' days that pad the grid
+	HasPicks bool
+	Today    bool
+}
+
+// monthCalendar is a month grid of which days have recommendations, in
+// whole weeks starting on templates.WeekStart.
+type monthCalendar struct {
+	Month     time.Time // first of the month
+	WeekStart time.Weekday
+	Weekdays  []string // column headings
+	Weeks     [][]calendarDay
+	PrevURL   string
+	NextURL   string // "" for the current month
+}
+
+// dateWeek is one week of a list of dates.
+type dateWeek struct {
+	Start time.Time
+	Dates []time.Time
+}
+
+// parseMonth parses ?month=YYYY-MM, defaulting to today's month. Months
+// after today's are rejected.
+func parseMonth(s string, today time.Time) (time.Time, error) {
+	current := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if s == "" {
+		return current, nil
+	}
+	month, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", s)
+	}
+	if month.After(current) {
+		return time.Time{}, fmt.Errorf("month %s hasn't started", s)
+	}
+	return month, nil
+}
+
+// calendarRange is the span of days month's grid shows: whole weeks from
+// the one holding the 1st to the one holding the last day.
+func calendarRange(month time.Time) (from, to time.Time) {
+	from = templates.StartOfWeek(month)
+	to = templates.StartOfWeek(month.AddDate(0, 1, -1)).AddDate(0, 0, 7)
+	return from, to
+}
+
+// buildCalendar lays out month's grid, marking the days in have. Its links
+// page through months on the page at u.
+func buildCalendar(u *url.URL, month, today time.Time, have []time.Time) monthCalendar {
+	picked := make(map[string]bool, len(have))
+	for _, d := range have {
+		picked[d.Format("2006-01-02")] = true
+	}
+	cal := monthCalendar{Month: month, WeekStart: templates.WeekStart, PrevURL: monthURL(u, month.AddDate(0, -1, 0))}
+	if next := month.AddDate(0, 1, 0); !next.After(today) {
+		cal.NextURL = monthURL(u, next)
+	}
+	for i := range 7 {
+		cal.Weekdays = append(cal.Weekdays, time.Weekday((int(templates.WeekStart) + i) % 7).String()[:3])
+	}
+	from, to := calendarRange(month)
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 7) {
+		week := make([]calendarDay, 0, 7)
+		for i := range 7 {
+			day := d.AddDate(0, 0, i)
+			week = append(week, calendarDay{
+				Date:     day,
+				InMonth:  day.Month() == month.Month(),
+				HasPicks: picked[day.Format("2006-01-02")],
+				Today:    day.Equal(today),
+			})
+		}
+		cal.Weeks = append(cal.Weeks, week)
+	}
+	return cal
+}
+
+// monthURL links to month's calendar on the page at u, keeping u's other
+// parameters.
+func monthURL(u *url.URL, month time.Time) string {
+	q := u.Query()
+	q.Set("month", month.Format("2006-01"))
+	return u.Path + "?" + q.Encode()
+}
+
+// groupByWeek splits dates (newest first) into the weeks that hold them.
+func groupByWeek(dates []time.Time) []dateWeek {
+	var out []dateWeek
+	for _, d := range dates {
+		start := templates.StartOfWeek(d)
+		if n := len(out); n == 0 || !out[n-1].Start.Equal(start) {
+			out = append(out, dateWeek{Start: start})
+		}
+		out[len(out)-1].Dates = append(out[len(out)-1].Dates, d)
+	}
+	return out
+}
+
+// heatmapWeeks is how many weeks the coverage heatmap spans (a year).
+const heatmapWeeks = 53
+
+// heatCell is one day of the coverage heatmap.
+type heatCell struct {
+	Date   time.Time
+	Picks  int
+	Failed bool
+	Level  int  // 0 (no picks) to 4 (the busiest day's count)
+	Future bool // after today; drawn blank
+}
+
+// heatmap is a year of days as week columns of weekday rows, GitHub style.
+type heatmap struct {
+	Weekdays []string // row labels
+	Weeks    [][]heatCell
+	Days     int // days with picks
+	Failed   int // days whose generation is failing
+}
+
+// heatmapRange is the span of days the heatmap ending today shows.
+func heatmapRange(today time.Time) (from, to time.Time) {
+	from = templates.StartOfWeek(today).AddDate(0, 0, -7*(heatmapWeeks-1))
+	return from, today.AddDate(0, 0, 1)
+}
+
+// buildHeatmap lays out the year to today from activity, shading each day
+// by its pick count relative to the busiest day.
+func buildHeatmap(today time.Time, activity []recommend.DayActivity) heatmap {
+	byDay := make(map[string]recommend.DayActivity, len(activity))
+	most := 0
+	for _, a := range activity {
+		byDay[a.Date.Format("2006-01-02")] = a
+		most = max(most, a.Picks)
+	}
+	var h heatmap
+	for i := range 7 {
+		h.Weekdays = append(h.Weekdays, time.Weekday((int(templates.WeekStart) + i) % 7).String()[:3])
+	}
+	from, _ := heatmapRange(today)
+	for w := range heatmapWeeks {
+		week := make([]heatCell, 0, 7)
+		for i := range 7 {
+			day := from.AddDate(0, 0, 7*w+i)
+			a := byDay[day.Format("2006-01-02")]
+			cell := heatCell{Date: day, Picks: a.Picks, Failed: a.Failed, Future: day.After(today)}
+			if a.Picks > 0 {
+				cell.Level = (4*a.Picks + most - 1) / most
+				h.Days++
+			}
+			if a.Failed {
+				h.Failed++
+			}
+			week = append(week, cell)
+		}
+		h.Weeks = append(h.Weeks, week)
+	}
+	return h
+}
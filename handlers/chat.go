@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// chatCookie keeps a guest's conversation when sign-in is off.
+const chatCookie = "chat"
+
+// chatData is the view model for chat.html.
+type chatData struct {
+	Messages  []models.ChatMessage
+	Saved     string // "watchlist" or "pin" after an action
+	CSRFToken string
+}
+
+// chatOwner names whose conversation req belongs to: the signed-in user, or
+// a random guest token kept in a cookie (set here if missing).
+func chatOwner(w http.ResponseWriter, req *http.Request) string {
+	if u := currentUser(req.Context()); u != nil {
+		return fmt.Sprintf("user:%d", u.ID)
+	}
+	if c, err := req.Cookie(chatCookie); err == nil && c.Value != "" && len(c.Value) <= 64 {
+		return "guest:" + c.Value
+	}
+	b := make([]byte, 24)
+	_, _ = rand.Read(b) // never fails
+	token := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     chatCookie,
+		Value:    token,
+		Path:     "/chat",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		Secure:   req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "guest:" + token
+}
+
+// HandleChat serves the chat page (GET) and answers a message (POST, form
+// field message). Scripts that ask for text/event-stream get the reply as
+// it is written, as "text" events ({"text": "..."}) then a "done" event, or
+// an "error" event; other posts wait for the reply and are sent back to the
+// page. Follow-ups refine the earlier answers in the same conversation.
+func HandleChat(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		owner := chatOwner(w, req)
+
+		if req.Method != http.MethodPost {
+			msgs, err := r.ChatHistory(ctx, owner)
+			if err != nil {
+				l.Errorw("Failed to load chat history", zap.Error(err))
+				writeError(w, req, "We couldn't load your conversation. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			renderTemplate(ctx, w, []string{baseTemplate, "chat.html"}, chatData{
+				Messages: msgs, Saved: req.URL.Query().Get("saved"), CSRFToken: csrfToken(req),
+			})
+			return
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+		message := strings.TrimSpace(req.PostFormValue("message"))
+		if message == "" || len(message) > recommend.MaxChatMessageLen {
+			writeError(w, req, "Ask something in up to 1000 characters.", http.StatusBadRequest)
+			return
+		}
+
+		if req.Header.Get("Accept") != "text/event-stream" {
+			if _, err := r.Ask(ctx, owner, message, time.Now(), func(string) {}); err != nil {
+				l.Errorw("Failed to answer chat message", zap.Error(err))
+				writeError(w, req, "We couldn't answer that. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, req, "/chat#latest", http.StatusSeeOther)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no") // let proxies pass events through
+		send := func(event string, v any) {
+			b, _ := json.Marshal(v) // maps of strings always marshal
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err == nil {
+				_ = rc.Flush()
+			}
+		}
+		if _, err := r.Ask(ctx, owner, message, time.Now(), func(text string) {
+			send("text", map[string]string{"text": text})
+		}); err != nil {
+			l.Errorw("Failed to answer chat message", zap.Error(err))
+			send("error", map[string]string{"error": "We couldn't answer that. Please try again later."})
+			return
+		}
+		send("done", map[string]string{})
+	}
+}
+
+// HandleChatClear starts a new conversation.
+func HandleChatClear(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if err := r.ClearChat(ctx, chatOwner(w, req)); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to clear chat", zap.Error(err))
+			writeError(w, req, "We couldn't clear your conversation. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, req, "/chat", http.StatusSeeOther)
+	}
+}
+
+// HandleChatAction acts on a title suggested in chat (form fields type and
+// id): action "watchlist" adds it to the household watchlist, and "pin"
+// pins it for tomorrow with reason (the suggestion's) as its explanation.
+func HandleChatAction(r *recommend.Recommender, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+		typ := req.PostFormValue("type")
+		id, err := strconv.ParseUint(req.PostFormValue("id"), 10, 64)
+		if err != nil || (typ != models.TypeMovie && typ != models.TypeTVShow) {
+			writeError(w, req, "That title doesn't exist.", http.StatusBadRequest)
+			return
+		}
+
+		switch action {
+		case "watchlist":
+			var title string
+			if title, err = r.AddToWatchlist(ctx, typ, uint(id)); err == nil {
+				l.Infow("Added to watchlist from chat", "title", title)
+			}
+		case "pin":
+			tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+			reason := strings.TrimSpace(req.PostFormValue("reason"))
+			if len(reason) > 1000 {
+				writeError(w, req, "That note is too long.", http.StatusBadRequest)
+				return
+			}
+			var pin models.Pin
+			if pin, err = r.PinTitle(ctx, tomorrow, typ, uint(id), reason); err == nil {
+				l.Infow("Pinned from chat", "title", pin.Title, "date", tomorrow)
+			}
+			if errors.Is(err, recommend.ErrPinExists) {
+				err = nil
+			}
+		}
+		switch {
+		case errors.Is(err, recommend.ErrTitleNotFound):
+			writeError(w, req, "That title is no longer in the library.", http.StatusNotFound)
+			return
+		case err != nil:
+			l.Errorw("Failed to act on chat suggestion", "action", action, zap.Error(err))
+			writeError(w, req, "We couldn't do that. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, req, "/chat?saved="+action+"#latest", http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/icco/recommender/lib/cachestats"
+)
+
+// compressibleTypes are the response types worth compressing: pages, API
+// and export bodies, scripts, and SVG posters.
+var compressibleTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/csv",
+	"text/markdown",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"image/svg+xml",
+}
+
+// Compress gzips (or deflates) compressible responses for clients that
+// accept it. Responses that already set Content-Encoding, such as
+// StaticFiles' precompressed assets, pass through untouched.
+func Compress() func(http.Handler) http.Handler {
+	return middleware.Compress(5, compressibleTypes...)
+}
+
+// StaticFiles serves fsys like http.FileServer, but answers clients that
+// accept compression with a compressed copy: a precompressed name.br or
+// name.gz beside the file when fsys has one, else a gzip copy made on first
+// request and kept, since assets don't change while the server runs (a
+// changed modification time, as in DEV_MODE, replaces the copy). Lookups
+// count toward the "static_gzip" cache stats.
+func StaticFiles(fsys fs.FS) http.Handler {
+	s := &staticFiles{fsys: fsys, files: http.FileServer(http.FS(fsys))}
+	s.stats = cachestats.New("static_gzip", s.entries)
+	return s
+}
+
+type staticFiles struct {
+	fsys  fs.FS
+	files http.Handler
+	gz    sync.Map // gzipKey -> []byte
+	stats *cachestats.Counter
+}
+
+// entries counts the gzip copies held.
+func (s *staticFiles) entries() int {
+	n := 0
+	s.gz.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+type gzipKey struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+func (s *staticFiles) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+req.URL.Path), "/")
+	info, err := fs.Stat(s.fsys, name)
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if err != nil || info.IsDir() || ctype == "" {
+		s.files.ServeHTTP(w, req)
+		return
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	accepted := acceptedEncodings(req)
+
+	for _, pre := range []struct{ encoding, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+		if !accepted[pre.encoding] {
+			continue
+		}
+		if b, err := fs.ReadFile(s.fsys, name+pre.ext); err == nil {
+			serveEncoded(w, req, name, info.ModTime(), ctype, pre.encoding, b)
+			return
+		}
+	}
+	if accepted["gzip"] && compressible(ctype) {
+		if b, err := s.gzipped(name, info); err == nil {
+			serveEncoded(w, req, name, info.ModTime(), ctype, "gzip", b)
+			return
+		}
+	}
+	s.files.ServeHTTP(w, req)
+}
+
+// gzipped returns name's gzip copy, compressing it on first use.
+func (s *staticFiles) gzipped(name string, info fs.FileInfo) ([]byte, error) {
+	key := gzipKey{name: name, modTime: info.ModTime(), size: info.Size()}
+	if b, ok := s.gz.Load(key); ok {
+		s.stats.Hit()
+		return b.([]byte), nil
+	}
+	s.stats.Miss()
+	raw, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression) // the level is valid
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	// A changed file's older copies won't be asked for again.
+	s.gz.Range(func(k, _ any) bool {
+		if k.(gzipKey).name == name {
+			s.gz.Delete(k)
+			s.stats.Evict(1)
+		}
+		return true
+	})
+	s.gz.Store(key, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+func serveEncoded(w http.ResponseWriter, req *http.Request, name string, modTime time.Time, ctype, encoding string, b []byte) {
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, req, name, modTime, bytes.NewReader(b))
+}
+
+// compressible reports whether ctype is one of compressibleTypes.
+func compressible(ctype string) bool {
+	ctype, _, _ = strings.Cut(ctype, ";")
+	for _, t := range compressibleTypes {
+		if strings.EqualFold(strings.TrimSpace(ctype), t) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedEncodings parses Accept-Encoding, leaving out encodings the
+// client refuses with q=0.
+func acceptedEncodings(req *http.Request) map[string]bool {
+	out := map[string]bool{}
+	for part := range strings.SplitSeq(req.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(part, ";")
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok && strings.Trim(q, "0.") == "" {
+			continue
+		}
+		if enc != "" {
+			out[enc] = true
+		}
+	}
+	return out
+}
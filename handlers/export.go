@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultExportDays is the range exported when ?from is omitted.
+	defaultExportDays = 7
+	// maxExportDays bounds one export.
+	maxExportDays = 366
+)
+
+// HandleExport serves recommendations for a date range as a CSV spreadsheet
+// or a Markdown digest: GET /api/v1/export?from=YYYY-MM-DD&to=YYYY-MM-DD&format=csv|md.
+// to defaults to today and from to a week before it.
+func HandleExport(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+		defer cancel()
+		q := req.URL.Query()
+
+		format := q.Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "md" {
+			writeJSONError(ctx, w, "format must be csv or md", http.StatusBadRequest)
+			return
+		}
+		to, err := exportDate(q.Get("to"), time.Now().UTC().Truncate(24*time.Hour))
+		if err != nil {
+			writeJSONError(ctx, w, "to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, err := exportDate(q.Get("from"), to.AddDate(0, 0, -(defaultExportDays-1)))
+		if err != nil {
+			writeJSONError(ctx, w, "from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if from.After(to) {
+			writeJSONError(ctx, w, "from must not be after to", http.StatusBadRequest)
+			return
+		}
+		if to.Sub(from) >= maxExportDays*24*time.Hour {
+			writeJSONError(ctx, w, fmt.Sprintf("range must be at most %d days", maxExportDays), http.StatusBadRequest)
+			return
+		}
+
+		recs, err := r.GetRecommendationsInRange(ctx, from, to)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to get recommendations for export", "from", from, "to", to, zap.Error(err))
+			writeJSONError(ctx, w, "failed to get recommendations", http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		contentType := "text/csv; charset=utf-8"
+		if format == "md" {
+			contentType = "text/markdown; charset=utf-8"
+			writeMarkdownExport(&buf, from, to, recs)
+		} else if err := writeCSVExport(&buf, recs); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to write CSV export", zap.Error(err))
+			writeJSONError(ctx, w, "failed to build export", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="recommendations-%s-to-%s.%s"`,
+			from.Format("2006-01-02"), to.Format("2006-01-02"), format))
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to write export", zap.Error(err))
+		}
+	}
+}
+
+// exportDate parses s (YYYY-MM-DD, not in the future), or returns def when s
+// is empty.
+func exportDate(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if err := validation.ValidateDate(s); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// writeCSVExport writes one row per recommendation. seasons comes last so
+// spreadsheets built on the older columns keep working.
+func writeCSVExport(buf *bytes.Buffer, recs []models.Recommendation) error {
+	cw := csv.NewWriter(buf)
+	if err := cw.Write([]string{"date", "slot", "type", "title", "year", "rating", "genre", "runtime", "tmdb_id", "explanation", "seasons"}); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		tmdbID := ""
+		if rec.TMDbID > 0 {
+			tmdbID = strconv.Itoa(rec.TMDbID)
+		}
+		rating := "" // unrated
+		if rec.Rating != nil {
+			rating = strconv.FormatFloat(*rec.Rating, 'f', 1, 64)
+		}
+		if err := cw.Write([]string{
+			rec.Date.UTC().Format("2006-01-02"), slotName(rec), rec.Type, rec.Title, strconv.Itoa(rec.Year),
+			rating, rec.Genre, strconv.Itoa(rec.Runtime), tmdbID, rec.Explanation, strconv.Itoa(rec.Seasons),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMarkdownExport writes a digest with a heading per day.
+func writeMarkdownExport(buf *bytes.Buffer, from, to time.Time, recs []models.Recommendation) {
+	fmt.Fprintf(buf, "# Recommendations, %s – %s\n", from.Format("Jan 2, 2006"), to.Format("Jan 2, 2006"))
+	if len(recs) == 0 {
+		buf.WriteString("\nNo recommendations in this range.\n")
+		return
+	}
+	day := ""
+	for _, rec := range recs {
+		if d := rec.Date.UTC().Format("Monday, January 2, 2006"); d != day {
+			day = d
+			fmt.Fprintf(buf, "\n## %s\n\n", day)
+		}
+		fmt.Fprintf(buf, "- **%s**", markdownEscape(rec.Title))
+		if rec.Year > 0 {
+			fmt.Fprintf(buf, " (%d)", rec.Year)
+		}
+		var details []string
+		if rec.Type == models.TypeTVShow {
+			details = append(details, "TV")
+		}
+		if rec.Genre != "" {
+			details = append(details, rec.Genre)
+		}
+		if rec.Rating != nil {
+			details = append(details, fmt.Sprintf("%.1f★", *rec.Rating))
+		}
+		if s := slotName(rec); s != recommend.DailySlot.Name {
+			details = append(details, s)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(buf, " — %s", markdownEscape(strings.Join(details, " · ")))
+		}
+		buf.WriteString("\n")
+		if rec.Explanation != "" {
+			fmt.Fprintf(buf, "  %s\n", markdownEscape(strings.Join(strings.Fields(rec.Explanation), " ")))
+		}
+	}
+}
+
+// slotName is rec's slot, treating the legacy empty slot as daily.
+func slotName(rec models.Recommendation) string {
+	if rec.Slot == "" {
+		return recommend.DailySlot.Name
+	}
+	return rec.Slot
+}
+
+// markdownEscape backslash-escapes characters that would otherwise format
+// titles and explanations.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`,
+).Replace
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// bulkFeedbackRequest is the POST /api/v1/feedback/bulk body.
+type bulkFeedbackRequest struct {
+	Before   string `json:"before"`
+	Feedback string `json:"feedback"`
+
+	before time.Time
+}
+
+func (b *bulkFeedbackRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if d, err := time.Parse("2006-01-02", b.Before); err != nil {
+		errs.Add("before", "must be YYYY-MM-DD")
+	} else if d.After(time.Now().UTC().Truncate(24 * time.Hour)) {
+		errs.Add("before", "must be today or earlier")
+	} else {
+		b.before = d
+	}
+	if b.Feedback != models.FeedbackWatched && b.Feedback != models.FeedbackIgnored {
+		errs.Add("feedback", `must be "watched" or "ignored"`)
+	}
+	return errs
+}
+
+// HandleBulkFeedback marks every pick dated before a day that has no
+// feedback yet, given {"before": "2026-01-01", "feedback": "watched"} (or
+// "ignored"), and answers with how many it marked. It clears the backlog of
+// old picks nobody reported on, so the stats reflect what was watched.
+func HandleBulkFeedback(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		var body bulkFeedbackRequest
+		if err := validation.DecodeJSON(w, req, 1024, &body); err != nil {
+			validation.WriteRequestError(ctx, w, err)
+			return
+		}
+		n, err := r.MarkFeedbackBefore(ctx, body.before, body.Feedback)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to mark feedback", "before", body.Before, "feedback", body.Feedback, zap.Error(err))
+			writeJSONError(ctx, w, "failed to mark feedback", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(ctx).Infow("Marked old picks", "before", body.Before, "feedback", body.Feedback, "updated", n)
+		out := map[string]any{"before": body.Before, "feedback": body.Feedback, "updated": n}
+		recordAudit(ctx, "feedback.bulk", "picks before "+body.Before, nil, out)
+		writeJSON(ctx, w, out)
+	}
+}
@@ -3,23 +3,33 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/icco/gutil/logging"
 	"github.com/icco/recommender/handlers/templates"
-	"github.com/icco/recommender/lib/lock"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/errreport"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/leader"
+	"github.com/icco/recommender/lib/placeholder"
 	"github.com/icco/recommender/lib/plex"
 	"github.com/icco/recommender/lib/recommend"
 	"github.com/icco/recommender/lib/sanitize"
 	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -72,72 +82,129 @@ func wantsJSON(r *http.Request) bool {
 	return false
 }
 
-// renderError renders an error page using the error template.
+// renderError renders an error page using the error template. It does
+// nothing but log if a response has already been started on w.
 func renderError(ctx context.Context, w http.ResponseWriter, message string, status int) {
 	l := logging.FromContext(ctx)
+	ww := trackResponse(w)
+	if ww.Status() != 0 {
+		l.Warnw("Response already started; dropping error page", "status", ww.Status(), "message", message)
+		return
+	}
 	tmpl, err := templates.ParseTemplates(baseTemplate, "error.html")
 	if err != nil {
 		l.Errorw("Failed to parse error template", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		http.Error(ww, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(status)
-	if err := tmpl.ExecuteTemplate(w, baseTemplate, errorData{Message: message}); err != nil {
-		l.Errorw("Failed to execute error template", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if err := writeHTML(ww, tmpl, baseTemplate, errorData{Message: message}, status); err != nil {
+		l.Errorw("Failed to render error template", zap.Error(err))
+		if ww.Status() == 0 {
+			http.Error(ww, "Internal server error", http.StatusInternalServerError)
+		}
 	}
 }
 
 // renderTemplate renders a template with the given data and handles errors.
 // Returns true if rendering was successful, false otherwise.
 func renderTemplate(ctx context.Context, w http.ResponseWriter, files []string, data interface{}) bool {
+	return renderFragment(ctx, w, files, baseTemplate, data)
+}
+
+// renderFragment is renderTemplate executing the named template from files
+// instead of the base layout, for pages that serve a part of themselves.
+func renderFragment(ctx context.Context, w http.ResponseWriter, files []string, name string, data interface{}) bool {
 	l := logging.FromContext(ctx)
+	ww := trackResponse(w)
 	tmpl, err := templates.ParseTemplates(files...)
 	if err != nil {
 		l.Errorw("Failed to parse template", zap.Error(err))
-		renderError(ctx, w, "Something went wrong while loading the page.", http.StatusInternalServerError)
+		renderError(ctx, ww, "Something went wrong while loading the page.", http.StatusInternalServerError)
 		return false
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	if err := tmpl.ExecuteTemplate(w, baseTemplate, data); err != nil {
-		l.Errorw("Failed to execute template", zap.Error(err))
-		if !isResponseStarted(w) {
-			renderError(ctx, w, "Something went wrong while displaying the page.", http.StatusInternalServerError)
-		}
+	if err := writeHTML(ww, tmpl, name, data, http.StatusOK); err != nil {
+		l.Errorw("Failed to render template", zap.Error(err))
+		renderError(ctx, ww, "Something went wrong while displaying the page.", http.StatusInternalServerError)
 		return false
 	}
 
 	return true
 }
 
-// isResponseStarted checks if the response has already been started (headers sent)
-func isResponseStarted(w http.ResponseWriter) bool {
-	// Try to set a dummy header - if response has started, this will be ignored
-	// but won't cause an error. We check by seeing if we can still modify headers.
-	beforeLen := len(w.Header())
-	w.Header().Set("X-Check-Response-Started", "test")
-	afterLen := len(w.Header())
-	w.Header().Del("X-Check-Response-Started")
-
-	// If header was added and removed successfully, response hasn't started
-	return beforeLen == afterLen
+// writeHTML executes the named template into a buffer and only then sends it
+// with status, so a template that fails partway through leaves w untouched
+// and the caller free to send an error page instead.
+func writeHTML(w http.ResponseWriter, tmpl *template.Template, name string, data interface{}, status int) error {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("failed to execute %s: %w", name, err)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// trackResponse returns w as a chi WrapResponseWriter, whose Status is
+// non-zero once headers have been sent. Requests through the router arrive
+// already wrapped by the logging middleware, so this usually returns w.
+func trackResponse(w http.ResponseWriter) middleware.WrapResponseWriter {
+	if ww, ok := w.(middleware.WrapResponseWriter); ok {
+		return ww
+	}
+	return middleware.NewWrapResponseWriter(w, 1)
+}
+
+// homeData is the view model for home.html.
+type homeData struct {
+	Date           time.Time
+	Sections       []pageSection // daily movies and shows, then time-of-day slots
+	Section        string        // set when showing one page of a single section
+	DayURL         string        // the whole day, keeping the mood
+	Theme          string        // holiday the day was themed for, if any
+	PausedUntil    time.Time     // last day of the blackout covering Date; zero if none
+	Note           string        // the day's journal note, if any
+	CSRFToken      string        // for the note and share forms; empty hides them
+	SharedUntil    time.Time     // when the share link this was opened from expires; shown read-only, without the journal
+	ShowOnboarding bool
+	Because        []recommend.WatchedRow // "Because you watched …" rows; today's page only
+	Jobs           []v1.Job               // library syncs and generation under way; today's page only
+	// Mood picker state; only offered on today's page.
+	Moods    []recommend.Mood
+	Mood     recommend.Mood
+	MoodByAI bool
+	MoodLLM  bool // a model is configured to re-rank with
+	// Movie night: the day's picks ranked for everyone in ?with=.
+	With        []string
+	WithUnknown []string // of those, people without votes to learn from
 }
 
 // HandleHome serves the home page with today's recommendations.
 // It takes a database connection and recommender instance, and returns an HTTP handler.
-func HandleHome(r *recommend.Recommender) http.HandlerFunc {
+// While q has a library sync or generation under way, a banner says so; if
+// today's picks aren't ready yet, the page waits on them instead of a 404.
+func HandleHome(r *recommend.Recommender, q *jobs.Queue) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
 		defer cancel()
 
 		today := time.Now().UTC().Truncate(24 * time.Hour)
+		running, err := activeJobs(ctx, q)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to list active jobs", zap.Error(err))
+		}
 
 		recommendations, err := r.GetRecommendationsForDate(ctx, today)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+			if b, ok := r.Paused(today); ok && errors.Is(err, gorm.ErrRecordNotFound) {
+				renderDay(w, req.WithContext(ctx), homeData{Date: today, PausedUntil: b.To}, nil, nil)
+			} else if errors.Is(err, gorm.ErrRecordNotFound) && len(running) > 0 {
+				renderDay(w, req.WithContext(ctx), homeData{Date: today, Jobs: running}, nil, nil)
+			} else if errors.Is(err, gorm.ErrRecordNotFound) {
 				writeError(w, req, "No recommendations available for today. Please check back later or visit the Past Recommendations page.", http.StatusNotFound)
 			} else {
 				logging.FromContext(ctx).Errorw("Failed to get today's recommendations", zap.Error(err))
@@ -146,69 +213,154 @@ func HandleHome(r *recommend.Recommender) http.HandlerFunc {
 			return
 		}
 
-		if !renderTemplate(ctx, w, []string{baseTemplate, "home.html"}, recommendations) {
-			return
+		needsOnboarding, err := r.NeedsOnboarding(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to check onboarding state", zap.Error(err))
 		}
+
+		theme, err := r.ThemeForDate(ctx, today)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to get today's theme", zap.Error(err))
+		}
+
+		note, err := r.DateNote(ctx, today)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to get today's note", zap.Error(err))
+		}
+
+		because, err := r.BecauseYouWatched(ctx, today)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to get because-you-watched rows", zap.Error(err))
+		}
+
+		daily, slots := recommend.SplitSlots(recommendations)
+		data := homeData{
+			Date: today, Theme: theme, Note: note, CSRFToken: csrfToken(req),
+			ShowOnboarding: needsOnboarding, Because: because, Jobs: running, Moods: recommend.Moods, MoodLLM: r.LLMEnabled(),
+		}
+		// ?with=alice,bob re-ranks the day's picks for everyone watching;
+		// otherwise ?mood=… re-ranks them, and &ai=1 asks the model for the
+		// order.
+		if with := recommend.ParseGroup(req.URL.Query().Get("with")); len(with) > 0 {
+			group, err := r.GroupTaste(ctx, with)
+			if err != nil {
+				logging.FromContext(ctx).Warnw("Failed to load group taste", "with", with, zap.Error(err))
+			}
+			data.With, data.WithUnknown = with, group.Unknown
+			daily = recommend.RankForGroup(daily, group.Taste)
+		} else if mood, ok := recommend.ParseMood(req.URL.Query().Get("mood")); ok {
+			data.Mood = mood
+			data.MoodByAI, _ = strconv.ParseBool(req.URL.Query().Get("ai"))
+			data.MoodByAI = data.MoodByAI && data.MoodLLM
+			daily = r.RerankForMood(ctx, daily, mood, data.MoodByAI)
+		}
+
+		renderDay(w, req.WithContext(ctx), data, daily, slots)
 	}
 }
 
 // HandleDate serves recommendations for a specific date.
 // It takes a database connection and recommender instance, and returns an HTTP handler.
-// The date should be provided in the URL path parameter.
+// The date should be provided in the URL path parameter. A day that hasn't
+// begun redirects to today, so picks generated ahead of time stay a surprise.
 func HandleDate(r *recommend.Recommender) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
-		defer cancel()
-		l := logging.FromContext(ctx)
+		serveDate(w, req, r, false)
+	}
+}
 
-		date := chi.URLParam(req, "date")
-		if date == "" {
-			l.Errorw("Missing date parameter")
-			writeError(w, req, "date parameter is required", http.StatusBadRequest)
-			return
-		}
+// serveDate renders the day in the URL's date parameter. A shared day is
+// shown read-only, without its journal.
+func serveDate(w http.ResponseWriter, req *http.Request, r *recommend.Recommender, shared bool) {
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	l := logging.FromContext(ctx)
 
-		if err := validation.ValidateDate(date); err != nil {
-			l.Errorw("Invalid date format", "date", date, zap.Error(err))
-			writeError(w, req, err.Error(), http.StatusBadRequest)
-			return
-		}
+	date := chi.URLParam(req, "date")
+	if date == "" {
+		l.Errorw("Missing date parameter")
+		writeError(w, req, "date parameter is required", http.StatusBadRequest)
+		return
+	}
 
-		parsedDate, err := time.Parse("2006-01-02", date)
-		if err != nil {
-			l.Errorw("Failed to parse date", "date", date, zap.Error(err))
-			writeError(w, req, fmt.Sprintf("invalid date format: %v", err), http.StatusBadRequest)
+	if err := validation.ValidateDate(date); errors.Is(err, validation.ErrFutureDate) {
+		if wantsJSON(req) {
+			writeError(w, req, "That day hasn't begun yet.", http.StatusNotFound)
 			return
 		}
-		parsedDate = parsedDate.UTC()
+		http.Redirect(w, req, "/", http.StatusFound)
+		return
+	} else if err != nil {
+		l.Errorw("Invalid date format", "date", date, zap.Error(err))
+		writeError(w, req, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		recommendations, err := r.GetRecommendationsForDate(ctx, parsedDate)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				l.Infow("No recommendations found for date", "date", date)
-				writeError(w, req, "We couldn't find recommendations for this date.", http.StatusNotFound)
-			} else {
-				l.Errorw("Database error while fetching recommendations",
-					"date", date,
-					zap.Error(err))
-				writeError(w, req, "We encountered an error while fetching recommendations. Please try again later.", http.StatusInternalServerError)
-			}
-			return
-		}
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		l.Errorw("Failed to parse date", "date", date, zap.Error(err))
+		writeError(w, req, fmt.Sprintf("invalid date format: %v", err), http.StatusBadRequest)
+		return
+	}
+	parsedDate = parsedDate.UTC()
 
-		if !renderTemplate(ctx, w, []string{baseTemplate, "home.html"}, recommendations) {
-			return
+	recommendations, err := r.GetRecommendationsForDate(ctx, parsedDate)
+	if err != nil {
+		if b, ok := r.Paused(parsedDate); ok && errors.Is(err, gorm.ErrRecordNotFound) {
+			renderDay(w, req.WithContext(ctx), homeData{Date: parsedDate, PausedUntil: b.To}, nil, nil)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			l.Infow("No recommendations found for date", "date", date)
+			writeError(w, req, "We couldn't find recommendations for this date.", http.StatusNotFound)
+		} else {
+			l.Errorw("Database error while fetching recommendations",
+				"date", date,
+				zap.Error(err))
+			writeError(w, req, "We encountered an error while fetching recommendations. Please try again later.", http.StatusInternalServerError)
 		}
+		return
+	}
+
+	theme, err := r.ThemeForDate(ctx, parsedDate)
+	if err != nil {
+		l.Warnw("Failed to get theme for date", "date", date, zap.Error(err))
+	}
+
+	daily, slots := recommend.SplitSlots(recommendations)
+	if shared {
+		renderDay(w, req.WithContext(ctx), homeData{Date: parsedDate, Theme: theme, SharedUntil: shareExpiry(req)}, daily, slots)
+		return
 	}
+
+	note, err := r.DateNote(ctx, parsedDate)
+	if err != nil {
+		l.Warnw("Failed to get note for date", "date", date, zap.Error(err))
+	}
+	renderDay(w, req.WithContext(ctx), homeData{Date: parsedDate, Theme: theme, Note: note, CSRFToken: csrfToken(req)}, daily, slots)
+}
+
+// datesData is the view model for dates.html.
+type datesData struct {
+	Dates      []time.Time
+	Weeks      []dateWeek // Dates grouped by week
+	Calendar   monthCalendar
+	Heatmap    heatmap
+	Page       int
+	PageSize   int
+	Total      int64
+	TotalPages int
 }
 
 // HandleDates serves a paginated list of dates with recommendations.
 // It takes a database connection and recommender instance, and returns an HTTP handler.
 // Pagination parameters can be provided via query parameters 'page' and 'size'.
+// Above the list, a heatmap shades the past year's days by how many picks
+// each got, and a month calendar (?month=YYYY-MM, default this month) links
+// the days with recommendations.
 func HandleDates(r *recommend.Recommender) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
 		defer cancel()
+		asJSON := negotiate(w, req)
 
 		// Get and validate pagination parameters
 		page := 1
@@ -230,6 +382,12 @@ func HandleDates(r *recommend.Recommender) http.HandlerFunc {
 			writeError(w, req, err.Error(), http.StatusBadRequest)
 			return
 		}
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		month, err := parseMonth(req.URL.Query().Get("month"), today)
+		if err != nil {
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		dates, total, err := r.GetRecommendationDates(ctx, page, pageSize)
 		if err != nil {
@@ -237,249 +395,318 @@ func HandleDates(r *recommend.Recommender) http.HandlerFunc {
 			writeError(w, req, "We couldn't load the list of dates.", http.StatusInternalServerError)
 			return
 		}
+		from, to := calendarRange(month)
+		days, err := r.RecommendationDaysBetween(ctx, from, to)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to get calendar days", zap.Error(err))
+			writeError(w, req, "We couldn't load the calendar.", http.StatusInternalServerError)
+			return
+		}
 
-		data := struct {
-			Dates      []time.Time
-			Page       int
-			PageSize   int
-			Total      int64
-			TotalPages int
-		}{
+		from, to = heatmapRange(today)
+		activity, err := r.DailyActivity(ctx, from, to)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to get daily activity", zap.Error(err))
+			writeError(w, req, "We couldn't load the calendar.", http.StatusInternalServerError)
+			return
+		}
+
+		data := datesData{
 			Dates:      dates,
+			Weeks:      groupByWeek(dates),
+			Calendar:   buildCalendar(req.URL, month, today, days),
+			Heatmap:    buildHeatmap(today, activity),
 			Page:       page,
 			PageSize:   pageSize,
 			Total:      total,
 			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
 		}
 
+		if asJSON {
+			writeJSON(ctx, w, data.api())
+			return
+		}
 		if !renderTemplate(ctx, w, []string{baseTemplate, "dates.html"}, data) {
 			return
 		}
 	}
 }
 
-// cronBackgroundLockKey serializes all heavy cron work (cache refresh and recommendation
-// generation) so they never run concurrently. Otherwise a cache rebuild can delete
-// movie/tv rows while recommendation generation is reading them.
-const cronBackgroundLockKey = "cron-serial"
-
-// HandleCron handles the recommendation generation cron job.
-// It takes a recommender instance and file lock, and returns an HTTP handler.
-// The job runs asynchronously and generates recommendations for the current day.
-//
-// fresh context.Background() rather than the request context, because the work
-// must outlive the inbound HTTP request and the lock must release even if the
-// background timeout fires.
-//
-//nolint:contextcheck // background cron job + deferred Unlock intentionally use a
-func HandleCron(r *recommend.Recommender, fl *lock.FileLock) http.HandlerFunc {
+// NoStore marks every response uncacheable (DEV_MODE), so browsers refetch
+// pages and assets after each edit. Handlers that set their own
+// Cache-Control still override it.
+func NoStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Recover turns a handler panic into a 500, logs it, and reports it with
+// the request's method and route via lib/errreport. http.ErrAbortHandler
+// is re-panicked, since net/http uses it to abort a response on purpose.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tw := trackResponse(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler { //nolint:errorlint // panic values are compared, not wrapped
+				panic(v)
+			}
+			ctx := req.Context()
+			route := req.URL.Path
+			if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+				route = rc.RoutePattern()
+			}
+			logging.FromContext(ctx).Errorw("Handler panicked", "panic", v, "route", route, "stack", string(debug.Stack()))
+			errreport.CapturePanic(ctx, v, "method", req.Method, "route", route)
+			if tw.Status() == 0 {
+				writeError(tw, req, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(tw, req)
+	})
+}
+
+// timeoutSlack is how long past a route's timeout the connection stays
+// writable, so the 504 (or a handler finishing up) still reaches the client.
+const timeoutSlack = 5 * time.Second
+
+// Timeout bounds a route group's requests: the request context is cancelled
+// after d, a handler that gives up because of it answers 504, and the
+// connection's write deadline moves to match, overriding the server-wide
+// WriteTimeout in either direction. A d of 0 removes both limits, for
+// streaming responses.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		bounded := middleware.Timeout(d)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Writers that can't move deadlines (such as test recorders) have
+			// none to move, so failures are ignored.
+			rc := http.NewResponseController(w)
+			if d <= 0 {
+				_ = rc.SetWriteDeadline(time.Time{})
+				next.ServeHTTP(w, req)
+				return
+			}
+			_ = rc.SetWriteDeadline(time.Now().Add(d + timeoutSlack))
+			bounded.ServeHTTP(w, req)
+		})
+	}
+}
+
+// RequireLeader rejects requests on replicas that don't hold the leader lease,
+// so scheduled work triggered through /cron/* runs on exactly one replica.
+// Callers get a 503 and should retry (a load balancer will usually route the
+// retry to another replica).
+func RequireLeader(el *leader.Elector) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !el.IsLeader() {
+				w.Header().Set("Retry-After", "10")
+				writeError(w, req, "this replica is not the leader; retry later", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// HandleCron enqueues recommendation generation for the current day, or for
+// ?date=YYYY-MM-DD up to validation.MaxFutureDays ahead. The work
+// itself runs on the job queue (see RegisterJobs), so it is retried on failure
+// and survives restarts; repeated calls while a run is queued are no-ops.
+// ?slot=… generates a time-of-day slot instead of the daily set, and
+// ?override_cap=true bypasses the daily LLM call cap for this run. Days in a
+// blackout (BLACKOUT_DATES) answer with paused_until and queue nothing.
+func HandleCron(r *recommend.Recommender, q *jobs.Queue) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		l := logging.FromContext(ctx)
-		startTime := time.Now()
-		today := time.Now().UTC().Truncate(24 * time.Hour)
-		lockKey := cronBackgroundLockKey
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		// ?date=YYYY-MM-DD generates another day, such as tomorrow's picks
+		// the night before.
+		if s := req.URL.Query().Get("date"); s != "" {
+			if err := validation.ValidateGenerationDate(s); err != nil {
+				writeError(w, req, err.Error(), http.StatusBadRequest)
+				return
+			}
+			date, _ = time.Parse("2006-01-02", s) // validated above
+		}
 
-		sanitize.LogRecommendationCronStart(ctx, startTime, req.RemoteAddr, lockKey)
+		sanitize.LogRecommendationCronStart(ctx, time.Now(), req.RemoteAddr, cronBackgroundLockKey)
 
-		acquired, err := fl.TryLock(ctx, lockKey, 10*time.Second)
-		if err != nil {
-			l.Errorw("Failed to acquire lock for cron job",
-				"lock_key", lockKey,
-				zap.Error(err),
-			)
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error": "Failed to acquire lock", "timestamp": "`+time.Now().Format(time.RFC3339)+`"}`, http.StatusInternalServerError)
+		slot, ok := recommend.LookupSlot(req.URL.Query().Get("slot"))
+		if !ok {
+			writeError(w, req, "unknown slot", http.StatusBadRequest)
 			return
 		}
 
-		if !acquired {
-			l.Infow("Cron job already in progress (cache or recommendations); try again later",
-				"lock_key", lockKey,
-				"date", today,
-			)
-			w.Header().Set("Content-Type", "application/json")
-			if _, err := fmt.Fprintf(w, `{"message": "Another cron job is already running (cache or recommendations); try again later", "timestamp": "%s"}`,
-				time.Now().Format(time.RFC3339)); err != nil {
-				l.Errorw("Failed to write response", zap.Error(err))
-			}
+		// Blackout days (BLACKOUT_DATES) are skipped on purpose, not failed.
+		if b, ok := r.Paused(date); ok {
+			l.Infow("Generation paused for date", "date", date, "until", b.To)
+			writeJSON(ctx, w, map[string]string{
+				"message":      "Generation paused for " + date.Format("2006-01-02"),
+				"paused_until": b.To.Format("2006-01-02"),
+			})
 			return
 		}
 
-		exists, err := r.DidRunToday(ctx, today)
+		exists, err := r.DidRun(ctx, date, slot)
 		if err != nil {
-			if unlockErr := fl.Unlock(ctx, lockKey); unlockErr != nil {
-				l.Errorw("Failed to unlock after error", zap.Error(unlockErr))
-			}
 			l.Errorw("Failed to check existing recommendations",
-				"date", today,
+				"date", date,
 				zap.Error(err),
 			)
 			w.Header().Set("Content-Type", "application/json")
 			http.Error(w, `{"error": "Failed to check existing recommendations", "timestamp": "`+time.Now().Format(time.RFC3339)+`"}`, http.StatusInternalServerError)
 			return
 		}
-
 		if exists {
-			if unlockErr := fl.Unlock(ctx, lockKey); unlockErr != nil {
-				l.Errorw("Failed to unlock after exists check", zap.Error(unlockErr))
-			}
-			l.Infow("Recommendations already exist for today (double-check within lock)",
-				"date", today,
-			)
+			l.Infow("Recommendations already exist", "date", date)
 			w.Header().Set("Content-Type", "application/json")
 			if _, err := fmt.Fprintf(w, `{"message": "Recommendations already exist for %s", "timestamp": "%s"}`,
-				today.Format("2006-01-02"), time.Now().Format(time.RFC3339)); err != nil {
+				date.Format("2006-01-02"), time.Now().Format(time.RFC3339)); err != nil {
 				l.Errorw("Failed to write response", zap.Error(err))
 			}
 			return
 		}
 
-		// Background work must outlive the inbound HTTP request, so we deliberately
-		// detach from req.Context() and start a fresh context that only carries the
-		// scoped logger. The request context would otherwise be canceled the moment
-		// we return the 200 response, killing the generation job mid-flight.
-		//nolint:contextcheck // intentional detach: background cron must outlive the request
-		genCtx, genCancel := context.WithTimeout(logging.NewContext(context.Background(), l), 5*time.Minute)
-		l.Infow("Dispatching recommendation generation to background",
-			"date", today,
-			"lock_key", lockKey,
-		)
-		go func() {
-			defer func() {
-				genCancel()
-				// Unlock must succeed even if the background context has timed out,
-				// so we use a fresh context.Background() rather than genCtx here.
-				//nolint:contextcheck // intentional detach: unlock must run even after genCtx timeout
-				if err := fl.Unlock(context.Background(), lockKey); err != nil {
-					l.Errorw("Failed to release lock after recommendation generation",
-						"lock_key", lockKey,
-						zap.Error(err),
-					)
-				}
-			}()
-			l.Infow("Starting recommendation generation in background",
-				"date", today,
-				"timeout", 5*time.Minute,
-				"lock_key", lockKey,
-			)
-			if err := r.GenerateRecommendations(genCtx, today); err != nil {
-				l.Errorw("Failed to generate recommendations",
-					"date", today,
-					zap.Error(err),
-				)
-			} else {
-				l.Infow("Recommendation generation completed successfully",
-					"date", today,
-					"duration", time.Since(startTime),
-				)
-			}
-		}()
-
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintf(w, `{"message": "Recommendation generation started for %s", "timestamp": "%s"}`,
-			today.Format("2006-01-02"), time.Now().Format(time.RFC3339)); err != nil {
-			l.Errorw("Failed to write response", zap.Error(err))
+		payload := generatePayload{Date: date.Format("2006-01-02")}
+		if slot != recommend.DailySlot {
+			payload.Slot = slot.Name
+		}
+		// ?override_cap=true lets an operator regenerate past the daily LLM
+		// cap.
+		payload.OverrideCap, _ = strconv.ParseBool(req.URL.Query().Get("override_cap"))
+		key := payload.key()
+		job, created, err := q.Enqueue(ctx, JobGenerate, payload, jobs.Options{Key: key})
+		if err != nil {
+			l.Errorw("Failed to enqueue recommendation generation", "date", date, zap.Error(err))
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error": "Failed to enqueue recommendation generation", "timestamp": "`+time.Now().Format(time.RFC3339)+`"}`, http.StatusInternalServerError)
+			return
 		}
+		writeEnqueued(ctx, w, "Recommendation generation for "+key, job.ID, created)
 	}
 }
 
-// HandleCache handles the Plex cache update cron job.
-// It takes a Plex client instance and file lock, and returns an HTTP handler.
-// The job runs asynchronously and updates the cache of available media.
-//
-// fresh context.Background() rather than the request context, because the work
-// must outlive the inbound HTTP request and the lock must release even if the
-// background timeout fires.
-//
-//nolint:contextcheck // background cache job + deferred Unlock intentionally use a
-func HandleCache(p *plex.Client, rec *recommend.Recommender, fl *lock.FileLock) http.HandlerFunc {
+// HandleCache enqueues a Plex cache update followed by an external signal
+// sync. Like HandleCron, the work runs on the job queue.
+func HandleCache(q *jobs.Queue) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		l := logging.FromContext(ctx)
-		startTime := time.Now()
-		lockKey := cronBackgroundLockKey
 
-		sanitize.LogCacheUpdateJobStart(ctx, startTime, req.RemoteAddr, lockKey)
+		sanitize.LogCacheUpdateJobStart(ctx, time.Now(), req.RemoteAddr, cronBackgroundLockKey)
 
-		acquired, err := fl.TryLock(ctx, lockKey, 10*time.Second)
+		job, created, err := q.Enqueue(ctx, JobCacheUpdate, struct{}{}, jobs.Options{Key: JobCacheUpdate})
 		if err != nil {
-			l.Errorw("Failed to acquire lock for cache update",
-				"lock_key", lockKey,
-				zap.Error(err),
-			)
+			l.Errorw("Failed to enqueue cache update", zap.Error(err))
 			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error": "Failed to acquire lock", "timestamp": "`+time.Now().Format(time.RFC3339)+`"}`, http.StatusInternalServerError)
+			http.Error(w, `{"error": "Failed to enqueue cache update", "timestamp": "`+time.Now().Format(time.RFC3339)+`"}`, http.StatusInternalServerError)
 			return
 		}
+		writeEnqueued(ctx, w, "Cache update", job.ID, created)
+	}
+}
 
-		if !acquired {
-			l.Infow("Cron job already in progress (cache or recommendations); try again later",
-				"lock_key", lockKey,
-			)
-			w.Header().Set("Content-Type", "application/json")
-			if _, err := fmt.Fprintf(w, `{"message": "Another cron job is already running (cache or recommendations); try again later", "timestamp": "%s"}`,
-				time.Now().Format(time.RFC3339)); err != nil {
-				l.Errorw("Failed to write response", zap.Error(err))
-			}
-			return
-		}
+// writeEnqueued reports the queued (or already-queued) job for a cron call.
+func writeEnqueued(ctx context.Context, w http.ResponseWriter, what string, jobID uint, created bool) {
+	msg := what + " queued"
+	if !created {
+		msg = what + " is already queued or running"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := fmt.Fprintf(w, `{"message": %q, "job_id": %d, "timestamp": "%s"}`,
+		msg, jobID, time.Now().Format(time.RFC3339)); err != nil {
+		logging.FromContext(ctx).Errorw("Failed to write response", zap.Error(err))
+	}
+}
 
-		// See HandleCron above: background cache work must outlive the request, so
-		// the context is intentionally detached.
-		//nolint:contextcheck // intentional detach: background cache job must outlive the request
-		bgCtx, cancel := context.WithTimeout(logging.NewContext(context.Background(), l), 5*time.Minute)
-		l.Infow("Dispatching Plex cache update to background",
-			"lock_key", lockKey,
-		)
-		go func() {
-			defer func() {
-				cancel()
-				//nolint:contextcheck // intentional detach: unlock must run even after bgCtx timeout
-				if err := fl.Unlock(context.Background(), lockKey); err != nil {
-					l.Errorw("Failed to release lock after cache update",
-						"lock_key", lockKey,
-						zap.Error(err),
-					)
-				}
-			}()
-			l.Infow("Starting cache update in background",
-				"timeout", 5*time.Minute,
-				"lock_key", lockKey,
-			)
-			if err := p.UpdateCache(bgCtx); err != nil {
-				l.Errorw("Failed to update cache", zap.Error(err))
-			} else {
-				l.Infow("Cache update completed successfully",
-					"duration", time.Since(startTime),
-				)
-				rec.SyncSignals(bgCtx)
-			}
-		}()
+// statsPage is the stats template's data: the database statistics plus
+// Plex reachability, missed days and shows to drop for the status banners.
+type statsPage struct {
+	*recommend.StatsData
+	From, To    string // the ?from= and ?to= range, "" when open
+	Plex        plex.Availability
+	MissingDays []time.Time
+	Abandoned   []models.TVShow
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintf(w, `{"message": "Cache update started", "timestamp": "%s"}`,
-			time.Now().Format(time.RFC3339)); err != nil {
-			l.Errorw("Failed to write response", zap.Error(err))
+// genreTable is one titled genre distribution on the stats page.
+type genreTable struct {
+	Title  string
+	Genres []recommend.GenreCount
+}
+
+// GenreTables lists the per-type genre distributions for the template.
+func (p statsPage) GenreTables() []genreTable {
+	return []genreTable{{"Movies", p.MovieGenres}, {"TV Shows", p.TVShowGenres}}
+}
+
+// statsFilter parses /stats' ?from= and ?to= (YYYY-MM-DD, both inclusive).
+func statsFilter(req *http.Request) (recommend.StatsFilter, error) {
+	var f recommend.StatsFilter
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		s := req.URL.Query().Get(p.name)
+		if s == "" {
+			continue
+		}
+		if err := validation.ValidateDate(s); err != nil && !errors.Is(err, validation.ErrFutureDate) {
+			return f, fmt.Errorf("%s: %w", p.name, err)
 		}
+		*p.dst, _ = time.Parse("2006-01-02", s) // validated above
+	}
+	if !f.To.IsZero() {
+		f.To = f.To.AddDate(0, 0, 1)
 	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, errors.New("from must not be after to")
+	}
+	return f, nil
 }
 
-// HandleStats serves statistics about the recommendations database.
-// It takes a recommender instance and returns an HTTP handler.
-func HandleStats(r *recommend.Recommender) http.HandlerFunc {
+// HandleStats serves statistics about the recommendations database, with
+// banners while the Plex server is unreachable or recent days are missing
+// their picks. ?from= and ?to= limit the recommendation figures, such as the
+// genre distributions, to a date range.
+func HandleStats(r *recommend.Recommender, p *plex.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
 		defer cancel()
 
-		stats, err := r.GetStats(ctx)
+		filter, err := statsFilter(req)
+		if err != nil {
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
+		stats, err := r.GetStats(ctx, filter)
 		if err != nil {
 			logging.FromContext(ctx).Errorw("Failed to get stats", zap.Error(err))
 			writeError(w, req, "We couldn't load the statistics. Please try again later.", http.StatusInternalServerError)
 			return
 		}
 
-		if !renderTemplate(ctx, w, []string{baseTemplate, "stats.html"}, stats) {
+		data := statsPage{StatsData: stats, From: req.URL.Query().Get("from"), To: req.URL.Query().Get("to"), Plex: p.Availability()}
+		// The check is advisory; the page still renders without it.
+		if data.MissingDays, err = r.MissingDays(ctx); err != nil {
+			logging.FromContext(ctx).Warnw("Failed to find missing days", zap.Error(err))
+		}
+		if data.Abandoned, err = r.AbandonedShows(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warnw("Failed to find abandoned shows", zap.Error(err))
+		}
+		if negotiate(w, req) {
+			writeJSON(ctx, w, data.api())
+			return
+		}
+		if !renderTemplate(ctx, w, []string{baseTemplate, "stats.html"}, data) {
 			return
 		}
 	}
@@ -513,3 +740,22 @@ func HandleTraktConnect(r *recommend.Recommender, connectToken string) http.Hand
 		}
 	}
 }
+
+// HandlePlaceholder serves a generated text-on-color SVG poster for a title
+// (?title=…&year=…). Cards link here for titles without artwork; output
+// depends only on the query, so it is cached aggressively.
+func HandlePlaceholder() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		title := req.URL.Query().Get("title")
+		if r := []rune(title); len(r) > 200 {
+			title = string(r[:200])
+		}
+		year, _ := strconv.Atoi(req.URL.Query().Get("year"))
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		if _, err := w.Write(placeholder.SVG(title, year)); err != nil {
+			logging.FromContext(req.Context()).Errorw("Failed to write placeholder", zap.Error(err))
+		}
+	}
+}
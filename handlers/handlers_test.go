@@ -1,12 +1,35 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/recommender/handlers/templates"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/leader"
+	"github.com/icco/recommender/lib/letterboxd"
+	"github.com/icco/recommender/lib/maintenance"
+	"github.com/icco/recommender/lib/plex"
 	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/signedurl"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
 )
 
 func TestHandleTraktConnect_gate(t *testing.T) {
@@ -31,3 +54,1211 @@ func TestHandleTraktConnect_gate(t *testing.T) {
 		t.Errorf("wrong token: got %d, want 401", w.Code)
 	}
 }
+
+func TestRequireLeader(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	RequireLeader(leader.Disabled())(ok).ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/cron/cache", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("leader: got %d, want 204", w.Code)
+	}
+
+	// An elector that has never acquired its lease is a follower.
+	w = httptest.NewRecorder()
+	RequireLeader(leader.New(nil, "scheduler", 0))(ok).ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/cron/cache", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("follower: got %d, want 503", w.Code)
+	}
+}
+
+func TestHandleAPIRecommendations_badDate(t *testing.T) {
+	rec, err := recommend.New(nil, nil, nil, nil, "test", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	HandleAPIRecommendations(rec)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/recommendations?date=2026-13-40", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, want 400", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestHandleCron_blackout(t *testing.T) {
+	rec, err := recommend.New(nil, nil, nil, nil, "test", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC()
+	bs, err := recommend.ParseBlackouts(today.Format("2006-01-02") + ".." + today.AddDate(0, 0, 3).Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.ApplySettings(recommend.Settings{Blackouts: bs})
+
+	// The paused day answers before touching the database or queue.
+	w := httptest.NewRecorder()
+	HandleCron(rec, nil)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/cron/recommend", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+	var resp struct {
+		PausedUntil string `json:"paused_until"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := today.AddDate(0, 0, 3).Format("2006-01-02"); resp.PausedUntil != want {
+		t.Errorf("paused_until = %q, want %q", resp.PausedUntil, want)
+	}
+}
+
+func TestHandleRegenerate_rejects(t *testing.T) {
+	rec, err := recommend.New(nil, nil, nil, nil, "test", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC()
+	bs, err := recommend.ParseBlackouts(today.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.ApplySettings(recommend.Settings{Blackouts: bs})
+
+	// Each is refused before any picks are deleted or a job is queued.
+	post := func(date, query string) int {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/admin/recommendations/"+date+"/regenerate"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("date", date)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		HandleRegenerate(rec, nil)(w, req)
+		return w.Code
+	}
+	for _, tc := range []struct {
+		name, date, query string
+		want              int
+	}{
+		{"bad date", "2026-13-01", "", http.StatusBadRequest},
+		{"past the window", today.AddDate(0, 0, validation.MaxFutureDays+2).Format("2006-01-02"), "", http.StatusBadRequest},
+		{"unknown slot", "2026-01-01", "?slot=brunch", http.StatusBadRequest},
+		{"paused day", today.Format("2006-01-02"), "", http.StatusConflict},
+	} {
+		if got := post(tc.date, tc.query); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHandleDateNote_badRequest(t *testing.T) {
+	post := func(date, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/date/"+date+"/note", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("date", date)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		HandleDateNote(nil)(w, req)
+		return w
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	if w := post(tomorrow, "application/x-www-form-urlencoded", "note=hi"); w.Code != http.StatusBadRequest {
+		t.Errorf("future day: got %d, want 400", w.Code)
+	}
+	if w := post("2026-01-01", "application/x-www-form-urlencoded", "note="+strings.Repeat("x", 2001)); w.Code != http.StatusBadRequest {
+		t.Errorf("overlong form note: got %d, want 400", w.Code)
+	}
+	if w := post("2026-01-01", "application/json", `{"note": "`+strings.Repeat("x", 2001)+`"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("overlong JSON note: got %d, want 422", w.Code)
+	}
+}
+
+func TestHandleShowDecision_badRequest(t *testing.T) {
+	post := func(id, body string) int {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/shows/"+id+"/decision", strings.NewReader(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		HandleShowDecision(nil)(w, req)
+		return w.Code
+	}
+	if code := post("abc", `{"decision": "dropped"}`); code != http.StatusBadRequest {
+		t.Errorf("bad id: got %d, want 400", code)
+	}
+	if code := post("12", `{"decision": "maybe"}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("bad decision: got %d, want 422", code)
+	}
+}
+
+func TestHandleBulkFeedback_badBody(t *testing.T) {
+	for _, body := range []string{
+		`{"before": "2026-01-01", "feedback": "loved"}`,
+		`{"before": "01/01/2026", "feedback": "watched"}`,
+		`{"before": "2999-01-01", "feedback": "ignored"}`,
+	} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/feedback/bulk", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		HandleBulkFeedback(nil)(w, req)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got %d, want 422", body, w.Code)
+		}
+	}
+}
+
+func TestHandleLetterboxdImport_badBody(t *testing.T) {
+	for _, body := range []string{"", "title,year\nHeat,1995\n", "Name,Year,Rating\nHeat,1995,9\n"} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/import/letterboxd", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		HandleLetterboxdImport(nil)(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: got %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestHandleLetterboxdImport_zipBomb(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("watched.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(f, io.LimitReader(zeros{}, letterboxd.MaxFileBytes+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/import/letterboxd", &buf)
+	w := httptest.NewRecorder()
+	HandleLetterboxdImport(nil)(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %d, want 413 for a zip that inflates past the cap", w.Code)
+	}
+}
+
+// zeros reads as an endless run of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestHandleSnooze_badRequest(t *testing.T) {
+	post := func(id, body, contentType string) int {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/picks/"+id+"/snooze", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		HandleSnooze(nil)(w, req)
+		return w.Code
+	}
+	if code := post("abc", "days=7", "application/x-www-form-urlencoded"); code != http.StatusNotFound {
+		t.Errorf("bad id: got %d, want 404", code)
+	}
+	if code := post("12", "days=365", "application/x-www-form-urlencoded"); code != http.StatusBadRequest {
+		t.Errorf("form, too long: got %d, want 400", code)
+	}
+	if code := post("12", `{"days": -1}`, "application/json"); code != http.StatusUnprocessableEntity {
+		t.Errorf("json, negative: got %d, want 422", code)
+	}
+}
+
+func TestHandleVote_badForm(t *testing.T) {
+	for _, body := range []string{"pick=1", "voter=Sam&pick=heat", "voter=" + strings.Repeat("x", 65) + "&pick=1"} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/vote", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		HandleVote(nil)(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: got %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestSimilarPage_render(t *testing.T) {
+	heat, alien := uint(7), uint(8)
+	data := similarData{
+		Like: models.Recommendation{Title: "Heat", Type: models.TypeMovie, Year: 1995, MovieID: &heat},
+		Titles: []models.Recommendation{
+			{Title: "Alien", Type: models.TypeMovie, Year: 1979, MovieID: &alien, Explanation: "Also tense."},
+		},
+	}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "similar.html", "card.html"}, data)
+	body := w.Body.String()
+	for _, want := range []string{"More like Heat", "Also tense.", `href="/similar/movie/8"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("similar page missing %s", want)
+		}
+	}
+}
+
+func TestHandlePreferences_badBody(t *testing.T) {
+	for _, body := range []string{
+		`{"moods": ["Gloomy"]}`,
+		`{"lengths": ["epic"]}`,
+		`{"favorite_genres": ["` + strings.Repeat("x", 65) + `"]}`,
+		`{"languages": ["a", "b", "c", "d", "e", "f"]}`,
+	} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPut, "/api/v1/preferences", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		HandlePreferences(nil)(w, req)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got %d, want 422", body, w.Code)
+		}
+	}
+}
+
+func TestSettingsPage_render(t *testing.T) {
+	data := settingsData{
+		Genres:  "Crime, Science Fiction",
+		Moods:   settingsOptions(recommend.OnboardingMoods, []string{recommend.OnboardingMoods[0]}),
+		Lengths: settingsOptions(recommend.PreferenceLengths, []string{"short"}),
+		Saved:   true, CSRFToken: "tok",
+	}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "settings.html"}, data)
+	body := w.Body.String()
+	for _, want := range []string{`value="Crime, Science Fiction"`, `name="length" value="short" class="sr-only" checked`, `value="tok"`, "Saved."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("settings page missing %s", want)
+		}
+	}
+	if strings.Contains(body, `value="long" class="sr-only" checked`) {
+		t.Error("unchosen length is checked")
+	}
+}
+
+func TestHandleChat_badRequest(t *testing.T) {
+	post := func(h http.HandlerFunc, body string) int {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/chat", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w.Code
+	}
+	for _, body := range []string{"message=", "message=" + strings.Repeat("x", 1001)} {
+		if code := post(HandleChat(nil), body); code != http.StatusBadRequest {
+			t.Errorf("chat %.20q: got %d, want 400", body, code)
+		}
+	}
+	for _, body := range []string{"type=movie&id=x", "type=song&id=1"} {
+		if code := post(HandleChatAction(nil, "pin"), body); code != http.StatusBadRequest {
+			t.Errorf("pin %q: got %d, want 400", body, code)
+		}
+	}
+}
+
+func TestChatPage_render(t *testing.T) {
+	data := chatData{
+		Messages: []models.ChatMessage{
+			{Role: models.ChatRoleUser, Content: "Something cozy?"},
+			{Role: models.ChatRoleAssistant, Content: "Try these.", Titles: []models.ChatTitle{
+				{Type: models.TypeMovie, ID: 8, Title: "Paddington", Year: 2014, Reason: "Gentle."},
+			}},
+		},
+		Saved: "pin", CSRFToken: "tok",
+	}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "chat.html"}, data)
+	body := w.Body.String()
+	for _, want := range []string{"Something cozy?", `id="latest"`, `href="/similar/movie/8"`, `action="/chat/watchlist"`, `name="reason" value="Gentle."`, "Pinned for tomorrow.", "data-chat"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("chat page missing %s", want)
+		}
+	}
+}
+
+func TestVotePage_render(t *testing.T) {
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	data := voteData{
+		Date: day, Voter: "Sam", Mine: 7, Total: 3, CSRFToken: "tok",
+		Picks: []votePick{
+			{Recommendation: models.Recommendation{ID: 7, Title: "Heat", Type: models.TypeMovie, Year: 1995}, Votes: 2},
+			{Recommendation: models.Recommendation{ID: 8, Title: "Severance", Type: models.TypeTVShow, Year: 2022}, Votes: 1},
+		},
+	}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "vote.html"}, data)
+	body := w.Body.String()
+	for _, want := range []string{`data-vote-stream="/vote/stream"`, `name="pick" value="7"`, `data-votes-for="7">2<`, "your pick", `value="Sam"`, `value="tok"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("vote page missing %s", want)
+		}
+	}
+	b, _ := json.Marshal(toAPITally(day, recommend.Tally{Votes: map[uint]int{7: 2, 8: 1}, Total: 3}))
+	if string(b) != `{"date":"2026-10-16","total":3,"votes":{"7":2,"8":1}}` {
+		t.Errorf("tally event = %s", b)
+	}
+}
+
+func TestSpinPage_render(t *testing.T) {
+	picks := []models.Recommendation{
+		{ID: 7, Title: "Heat", Type: models.TypeMovie, Year: 1995},
+		{ID: 8, Title: "Severance", Type: models.TypeTVShow, Year: 2022},
+	}
+	data := spinData{Date: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Picks: picks, Landed: &picks[1], Weighted: true, CSRFToken: "tok"}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "spin.html"}, data)
+	body := w.Body.String()
+	for _, want := range []string{`data-spin-landed="8"`, `data-spin-slot="7"`, "Tonight: Severance (2022)", "Spin again", `value="1" checked`, `value="tok"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("spin page missing %s", want)
+		}
+	}
+	if strings.Count(body, "ring-indigo-500") != 1 {
+		t.Error("spin page should highlight only the landed pick")
+	}
+}
+
+func TestFutureDates(t *testing.T) {
+	day := func(days int) string { return time.Now().UTC().AddDate(0, 0, days).Format("2006-01-02") }
+
+	// Viewing a day that hasn't begun redirects home.
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/date/"+day(1), nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("date", day(1))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	HandleDate(nil)(w, req)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/" {
+		t.Errorf("future day page: got %d to %q, want 302 to /", w.Code, w.Header().Get("Location"))
+	}
+
+	// JSON clients, like the /api/v1 routes, get a 404 instead.
+	w = httptest.NewRecorder()
+	JSONOnly(HandleDate(nil)).ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("future day via the API: got %d %q, want a 404 JSON error", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// Generation may run ahead, but only within the window.
+	w = httptest.NewRecorder()
+	HandleCron(nil, nil)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/cron/recommend?date="+day(validation.MaxFutureDays+2), nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("cron past the window: got %d, want 400", w.Code)
+	}
+	if err := validation.ValidateGenerationDate(day(1)); err != nil {
+		t.Errorf("tomorrow should be generatable: %v", err)
+	}
+	if err := validation.ValidateDate(day(1)); !errors.Is(err, validation.ErrFutureDate) {
+		t.Errorf("ValidateDate(tomorrow) = %v, want ErrFutureDate", err)
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	for _, tc := range []struct {
+		token, auth string
+		want        int
+	}{
+		{"", "Bearer anything", http.StatusServiceUnavailable},
+		{"secret", "", http.StatusUnauthorized},
+		{"secret", "Bearer nope", http.StatusUnauthorized},
+		{"secret", "secret", http.StatusUnauthorized},
+		{"secret", "Bearer secret", http.StatusNoContent},
+	} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/reload", nil)
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		w := httptest.NewRecorder()
+		RequireAdmin(tc.token, nil)(ok).ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("token %q, auth %q: got %d, want %d", tc.token, tc.auth, w.Code, tc.want)
+		}
+	}
+}
+
+func TestRequireAdmin_setsAuditActor(t *testing.T) {
+	var actor string
+	h := RequireAdmin("secret", nil)(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		actor, _ = req.Context().Value(actorKey{}).(string)
+		recordAudit(req.Context(), "pin.add", "pin 1", nil, nil) // no audit log installed: a no-op
+	}))
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/pins", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if actor != "admin token" {
+		t.Errorf("actor = %q, want admin token", actor)
+	}
+
+	b, _ := json.Marshal(toAPIAuditEntry(models.AuditEntry{ID: 1, Actor: "admin token", Action: "pin.delete", After: nil, Before: json.RawMessage(`{"id":3}`)}))
+	if !strings.Contains(string(b), `"before":{"id":3},"after":null`) {
+		t.Errorf("audit entry JSON = %s", b)
+	}
+}
+
+func TestAPIKeyRequest_validate(t *testing.T) {
+	for _, tc := range []struct {
+		body apiKeyRequest
+		bad  []string
+	}{
+		{apiKeyRequest{Name: "tv", Scopes: []string{"read", "cron"}}, nil},
+		{apiKeyRequest{Scopes: []string{"read"}}, []string{"name"}},
+		{apiKeyRequest{Name: strings.Repeat("x", 65), Scopes: []string{"admin"}}, []string{"name"}},
+		{apiKeyRequest{Name: "tv"}, []string{"scopes"}},
+		{apiKeyRequest{Name: "tv", Scopes: []string{"read", "write"}}, []string{"scopes"}},
+	} {
+		var got []string
+		for _, e := range tc.body.Validate() {
+			got = append(got, e.Field)
+		}
+		if !slices.Equal(got, tc.bad) {
+			t.Errorf("%+v: invalid fields %v, want %v", tc.body, got, tc.bad)
+		}
+	}
+}
+
+type fixedMaintenance maintenance.State
+
+func (m fixedMaintenance) State() maintenance.State { return maintenance.State(m) }
+
+func TestPauseWrites(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	on := fixedMaintenance{Enabled: true, Reason: "Moving Plex to new hardware"}
+	for _, tc := range []struct {
+		sw           fixedMaintenance
+		method, path string
+		want         int
+	}{
+		{fixedMaintenance{}, http.MethodPost, "/onboarding", http.StatusNoContent},
+		{on, http.MethodGet, "/", http.StatusNoContent},
+		{on, http.MethodGet, "/api/recommendations", http.StatusNoContent},
+		{on, http.MethodPost, "/onboarding", http.StatusServiceUnavailable},
+		{on, http.MethodGet, "/cron/recommend", http.StatusServiceUnavailable},
+		{on, http.MethodPost, "/admin/maintenance", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		PauseWrites(tc.sw)(ok).ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s %s (maintenance %v): got %d, want %d", tc.method, tc.path, tc.sw.Enabled, w.Code, tc.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	PauseWrites(on)(ok).ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/onboarding", nil))
+	if body := w.Body.String(); !strings.Contains(body, "Down for maintenance") || !strings.Contains(body, on.Reason) {
+		t.Errorf("maintenance page missing heading or reason:\n%s", body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+}
+
+func TestHandleMaintenance_badBody(t *testing.T) {
+	h := HandleMaintenance(maintenance.New(nil))
+	for _, tc := range []struct {
+		body   string
+		want   int
+		fields []string
+	}{
+		{"", http.StatusBadRequest, nil},
+		{`{"enabled": true} {}`, http.StatusBadRequest, nil},
+		{"{}", http.StatusUnprocessableEntity, []string{"enabled"}},
+		{`{"enabled": "yes"}`, http.StatusUnprocessableEntity, []string{"enabled"}},
+		{`{"enabled": true, "reson": "typo"}`, http.StatusUnprocessableEntity, []string{"reson"}},
+		{`{"reason": "` + strings.Repeat("x", 300) + `"}`, http.StatusUnprocessableEntity, []string{"enabled", "reason"}},
+		{`{"enabled": true, "reason": "` + strings.Repeat("x", 5000) + `"}`, http.StatusRequestEntityTooLarge, nil},
+	} {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/maintenance", strings.NewReader(tc.body)))
+		if w.Code != tc.want {
+			t.Errorf("body %.40q: got %d, want %d", tc.body, w.Code, tc.want)
+			continue
+		}
+		var resp struct {
+			Fields []struct{ Field string }
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("body %.40q: %v", tc.body, err)
+		}
+		var got []string
+		for _, f := range resp.Fields {
+			got = append(got, f.Field)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.fields) {
+			t.Errorf("body %.40q: fields %v, want %v", tc.body, got, tc.fields)
+		}
+	}
+}
+
+func TestHandlePins_badBody(t *testing.T) {
+	h := HandlePins(nil)
+	for _, tc := range []struct {
+		body   string
+		fields []string
+	}{
+		{"{}", []string{"date", "type", "title"}},
+		{`{"date": "2020-01-01", "type": "movie", "title": "Die Hard"}`, []string{"date"}},
+		{`{"date": "2099-12-24", "type": "film", "title": "Die Hard"}`, []string{"type"}},
+		{`{"date": "12/24", "type": "movie", "title": "Die Hard", "year": -1}`, []string{"date", "year"}},
+	} {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/pins", strings.NewReader(tc.body)))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("body %.40q: got %d, want 422", tc.body, w.Code)
+			continue
+		}
+		var resp struct {
+			Fields []struct{ Field string }
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("body %.40q: %v", tc.body, err)
+		}
+		var got []string
+		for _, f := range resp.Fields {
+			got = append(got, f.Field)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.fields) {
+			t.Errorf("body %.40q: fields %v, want %v", tc.body, got, tc.fields)
+		}
+	}
+}
+
+func TestPlexDeferral(t *testing.T) {
+	prev := time.Duration(0)
+	for _, down := range []time.Duration{0, time.Hour, 6 * time.Hour} {
+		wait, ok := plexDeferral(down)
+		if !ok || wait < prev {
+			t.Errorf("down %s: wait %s, ok %v; want growing waits", down, wait, ok)
+		}
+		prev = wait
+	}
+	if _, ok := plexDeferral(13 * time.Hour); ok {
+		t.Error("should stop deferring after 12h down")
+	}
+}
+
+func TestHandleExport_badParams(t *testing.T) {
+	rec, err := recommend.New(nil, nil, nil, nil, "test", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		"format=pdf",
+		"from=2026-02-30",
+		"from=2026-03-02&to=2026-03-01",
+		"from=2024-01-01&to=2026-01-01",
+	} {
+		w := httptest.NewRecorder()
+		HandleExport(rec)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/export?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, w.Code)
+		}
+	}
+}
+
+func TestHandleArchive_badParams(t *testing.T) {
+	rec, err := recommend.New(nil, nil, nil, nil, "test", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{"decade=80s", "decade=1985", "page=0", "size=500"} {
+		w := httptest.NewRecorder()
+		HandleArchive(rec)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/archive?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, w.Code)
+		}
+	}
+}
+
+func TestHandleDataBrowse_badParams(t *testing.T) {
+	for q, want := range map[string]int{
+		"/admin/data/oauth_tokens":       http.StatusNotFound,
+		"/admin/data/jobs?payload=x":     http.StatusBadRequest,
+		"/admin/data/taste_profiles?q=x": http.StatusBadRequest,
+		"/admin/data/jobs?size=1000":     http.StatusBadRequest,
+	} {
+		r := chi.NewRouter()
+		r.Get("/admin/data/{table}", HandleDataBrowse(nil))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, q, nil))
+		if w.Code != want {
+			t.Errorf("%s: got %d, want %d", q, w.Code, want)
+		}
+	}
+}
+
+func TestDatesCalendar(t *testing.T) {
+	templates.WeekStart, templates.DateLayout = time.Monday, "2 January 2006"
+	t.Cleanup(func() { templates.WeekStart, templates.DateLayout = time.Sunday, "January 2, 2006" })
+
+	month := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC) // a Thursday
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	picks := []time.Time{today, today.AddDate(0, 0, -1), time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC)}
+	u, _ := url.Parse("/dates?size=5")
+	cal := buildCalendar(u, month, today, picks)
+
+	if len(cal.Weeks) != 5 || cal.Weekdays[0] != "Mon" || !cal.Weeks[0][0].Date.Equal(picks[2]) {
+		t.Fatalf("grid = %d weeks from %v (%v), want 5 from Monday Sep 28", len(cal.Weeks), cal.Weeks[0][0].Date, cal.Weekdays)
+	}
+	if d := cal.Weeks[0][0]; d.InMonth || !d.HasPicks {
+		t.Errorf("Sep 28 = %+v, want a padding day with picks", d)
+	}
+	if d := cal.Weeks[2][4]; !d.Today || !d.HasPicks || d.Date.Day() != 16 {
+		t.Errorf("third Friday = %+v, want today with picks", d)
+	}
+	if cal.NextURL != "" || cal.PrevURL != "/dates?month=2026-09&size=5" {
+		t.Errorf("links = %q, %q", cal.PrevURL, cal.NextURL)
+	}
+	if _, err := parseMonth("2026-11", today); err == nil {
+		t.Error("next month should be rejected")
+	}
+
+	heat := buildHeatmap(today, []recommend.DayActivity{
+		{Date: picks[2], Picks: 8},
+		{Date: picks[1], Picks: 2, Failed: true},
+		{Date: today, Picks: 1},
+	})
+	last := heat.Weeks[len(heat.Weeks)-1]
+	if len(heat.Weeks) != heatmapWeeks || !last[0].Date.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("heatmap = %d weeks ending the week of %v", len(heat.Weeks), last[0].Date)
+	}
+	if last[4].Level != 1 || !last[3].Failed || last[3].Level != 1 || !last[5].Future || heat.Weeks[len(heat.Weeks)-3][0].Level != 4 {
+		t.Errorf("heatmap cells = %+v", last)
+	}
+	if heat.Days != 3 || heat.Failed != 1 {
+		t.Errorf("heatmap counts = %d days, %d failed", heat.Days, heat.Failed)
+	}
+
+	data := datesData{Dates: picks, Weeks: groupByWeek(picks), Calendar: cal, Heatmap: heat, Page: 1, PageSize: 5, Total: 3, TotalPages: 1}
+	if len(data.Weeks) != 2 {
+		t.Fatalf("weeks = %+v, want two", data.Weeks)
+	}
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "dates.html"}, data)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Week of 12 October 2026") {
+		t.Errorf("dates page: %d, missing intl week heading", w.Code)
+	}
+	if got := data.api(); got.Calendar.WeekStart != "Monday" || len(got.Calendar.Days) != 2 || len(got.Activity) != 3 {
+		t.Errorf("api calendar = %+v, activity = %+v", got.Calendar, got.Activity)
+	}
+}
+
+func TestRenderDay_sections(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var daily []models.Recommendation
+	for i := range sectionPageSize + 3 {
+		daily = append(daily, models.Recommendation{Date: day, Type: models.TypeMovie, Title: fmt.Sprintf("Movie %02d", i)})
+	}
+	daily = append(daily, models.Recommendation{Date: day, Type: models.TypeTVShow, Title: "Show"})
+	render := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/date/2026-03-01"+query, nil)
+		renderDay(w, req, homeData{Date: day}, daily, nil)
+		return w
+	}
+
+	w := render("")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "Movie 11") || strings.Contains(body, "Movie 12") || !strings.Contains(body, "Show") {
+		t.Fatalf("full page should hold the first %d movies and the show (status %d)", sectionPageSize, w.Code)
+	}
+	if !strings.Contains(body, `href="/date/2026-03-01?page=2&amp;section=movies"`) {
+		t.Error("full page should link the movies' next page")
+	}
+
+	w = render("?section=movies&page=2&partial=1")
+	body = w.Body.String()
+	if w.Code != http.StatusOK || strings.Contains(body, "<html") || !strings.Contains(body, "Movie 14") || strings.Contains(body, "Movie 11") || strings.Contains(body, "data-more") {
+		t.Errorf("partial page 2 should be a bare fragment of the last movies (status %d):\n%s", w.Code, body)
+	}
+
+	for query, want := range map[string]int{
+		"?section=anime":          http.StatusNotFound,
+		"?section=tvshows&page=2": http.StatusNotFound,
+		"?section=movies&page=0":  http.StatusBadRequest,
+	} {
+		if w := render(query); w.Code != want {
+			t.Errorf("%s: got %d, want %d", query, w.Code, want)
+		}
+	}
+}
+
+func TestRenderDay_json(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	daily := []models.Recommendation{
+		{Date: day, Type: models.TypeMovie, Title: "Heat"},
+		{Date: day, Type: models.TypeTVShow, Title: "Taskmaster"},
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/date/2026-03-01?section=tvshows", nil)
+	req.Header.Set("Accept", "application/json")
+	renderDay(w, req, homeData{Date: day, Theme: "Spring"}, daily, nil)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if w.Header().Get("Vary") != "Accept" {
+		t.Error("negotiated responses should vary by Accept")
+	}
+	var got v1.Day
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Date != "2026-03-01" || got.Theme != "Spring" || len(got.Sections) != 1 ||
+		got.Sections[0].Key != "tvshows" || got.Sections[0].Recommendations[0].Title != "Taskmaster" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestRenderDay_becauseRows(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	daily := []models.Recommendation{{Date: day, Type: models.TypeMovie, Title: "Heat"}}
+	data := homeData{Date: day, Because: []recommend.WatchedRow{{
+		Seed: "Arrival", Recommendations: []models.Recommendation{{Type: models.TypeMovie, Title: "Dune", Year: 2021}},
+	}}}
+	render := func(query string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/"+query, nil)
+		renderDay(w, req, data, daily, nil)
+		return w.Body.String()
+	}
+
+	if body := render(""); !strings.Contains(body, "Because you watched Arrival") || !strings.Contains(body, "Dune") {
+		t.Error("home page should show the because-you-watched row")
+	}
+	if body := render("?section=movies"); strings.Contains(body, "Because you watched") {
+		t.Error("a single section's page shouldn't show the rows")
+	}
+}
+
+func TestRenderDay_jobBanner(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	active := []v1.Job{
+		{Kind: JobCacheUpdate, Label: "Updating library", Status: models.JobStatusRunning, Progress: 62},
+		{Kind: JobGenerate, Label: "Picking recommendations", Status: models.JobStatusPending},
+	}
+	render := func(data homeData, daily []models.Recommendation) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+		renderDay(w, req, data, daily, nil)
+		return w.Body.String()
+	}
+
+	body := render(homeData{Date: day, Jobs: active}, []models.Recommendation{{Date: day, Type: models.TypeMovie, Title: "Heat"}})
+	if !strings.Contains(body, "Updating library… 62%") || !strings.Contains(body, "Picking recommendations… queued") {
+		t.Errorf("banner missing from page:\n%s", body)
+	}
+	if strings.Contains(body, "data-job-reload") {
+		t.Error("a page with picks shouldn't reload when the jobs finish")
+	}
+	body = render(homeData{Date: day, Jobs: active}, nil)
+	if !strings.Contains(body, "on Their Way") || !strings.Contains(body, "data-job-reload") {
+		t.Error("a day still being generated should say so and reload when done")
+	}
+	if body := render(homeData{Date: day}, nil); strings.Contains(body, "data-job-status") {
+		t.Error("no banner without jobs")
+	}
+}
+
+func TestStatsPage_api(t *testing.T) {
+	checked := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	page := statsPage{
+		StatsData:   &recommend.StatsData{TotalRecommendations: 7, MovieGenres: []recommend.GenreCount{{Genre: "Film-Noir", Count: 2}}},
+		From:        "2026-01-01",
+		Plex:        plex.Availability{CheckedAt: checked, Since: checked, Err: "connection refused"},
+		MissingDays: []time.Time{time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC)},
+		Abandoned:   []models.TVShow{{ID: 12, Title: "Lost", Episodes: 121, WatchedEpisodes: 9, LastViewedAt: &checked}},
+	}
+	b, err := json.Marshal(page.api())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(b)
+	for _, want := range []string{`"total_recommendations":7`, `"genres":[]`, `"error":"connection refused"`, `"checked_at":"2026-03-01T08:00:00Z"`, `"missing_days":["2026-02-27"]`, `"movie_genres":[{"genre":"Film-Noir","count":2}]`, `"tvshow_genres":[]`, `"from":"2026-01-01"`, `"abandoned_shows":[{"id":12,"title":"Lost"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("%s missing %s", body, want)
+		}
+	}
+	if strings.Contains(body, "first_date") {
+		t.Errorf("unknown dates should be omitted: %s", body)
+	}
+
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "stats.html"}, page)
+	if !strings.Contains(w.Body.String(), `href="/cron/recommend?date=2026-02-27"`) {
+		t.Errorf("stats page should offer to generate the missing day: %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Film-Noir") || !strings.Contains(w.Body.String(), `value="2026-01-01"`) {
+		t.Errorf("stats page should show the genres and the range: %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Lost") || !strings.Contains(w.Body.String(), "9 of 121 episodes") {
+		t.Errorf("stats page should suggest dropping the abandoned show: %d", w.Code)
+	}
+
+	for _, q := range []string{"from=2026-13-01", "from=2026-03-02&to=2026-03-01"} {
+		if _, err := statsFilter(httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/stats?"+q, nil)); err == nil {
+			t.Errorf("%s should be rejected", q)
+		}
+	}
+	f, err := statsFilter(httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/stats?from=2026-03-01&to=2026-03-01", nil))
+	if err != nil || !f.To.Equal(f.From.AddDate(0, 0, 1)) {
+		t.Errorf("a one-day range = %+v, %v", f, err)
+	}
+}
+
+func TestRenderTemplate_failsCleanly(t *testing.T) {
+	// error.html reads .Message, which an int lacks: execution fails after
+	// the layout's head has rendered.
+	w := httptest.NewRecorder()
+	if renderTemplate(context.Background(), w, []string{baseTemplate, "error.html"}, 42) {
+		t.Fatal("render should fail")
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if n := strings.Count(w.Body.String(), "<html"); n != 1 {
+		t.Errorf("body holds %d documents, want only the error page", n)
+	}
+
+	// Once a response has started, no error page is appended.
+	w = httptest.NewRecorder()
+	ww := trackResponse(w)
+	ww.WriteHeader(http.StatusAccepted)
+	renderError(context.Background(), ww, "late", http.StatusInternalServerError)
+	if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("started response got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	var seen string
+	h := CSRF(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { seen = csrfToken(req) }))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/onboarding", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookie || cookies[0].Value != seen || seen == "" {
+		t.Fatalf("GET should issue a token cookie matching the form token; cookies %v, token %q", cookies, seen)
+	}
+	token := cookies[0].Value
+
+	post := func(form string, cookie bool) int {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/onboarding", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie {
+			req.AddCookie(&http.Cookie{Name: csrfCookie, Value: token})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, tc := range []struct {
+		name   string
+		form   string
+		cookie bool
+		want   int
+	}{
+		{"matching token", "mood=cozy&csrf_token=" + token, true, http.StatusOK},
+		{"missing field", "mood=cozy", true, http.StatusForbidden},
+		{"wrong token", "csrf_token=forged", true, http.StatusForbidden},
+		{"no cookie", "csrf_token=" + token, false, http.StatusForbidden},
+	} {
+		if got := post(tc.form, tc.cookie); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	csp := SecurityConfig{ImgSources: []string{"https://plex.example"}, FrameOptions: "sameorigin"}.policy()
+	for _, want := range []string{"img-src 'self' data: https://image.tmdb.org https://plex.example", "frame-ancestors 'self'", "script-src 'self' https://cdn.tailwindcss.com;"} {
+		if !strings.Contains(csp, want) {
+			t.Errorf("policy %q missing %q", csp, want)
+		}
+	}
+	if got := (SecurityConfig{CSP: "default-src 'none'"}).policy(); got != "default-src 'none'" {
+		t.Errorf("override = %q", got)
+	}
+	if _, err := SecurityHeaders(SecurityConfig{FrameOptions: "ALLOW-FROM x"}); err == nil {
+		t.Error("unknown frame options should fail")
+	}
+
+	mw, err := SecurityHeaders(SecurityConfig{HSTSSeconds: DefaultHSTSSeconds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, req)
+	if w.Header().Get("X-Frame-Options") != "DENY" || w.Header().Get("Content-Security-Policy") == "" ||
+		!strings.HasPrefix(w.Header().Get("Strict-Transport-Security"), "max-age=63072000") {
+		t.Errorf("headers = %v", w.Header())
+	}
+}
+
+func TestStaticFiles_compression(t *testing.T) {
+	js := strings.Repeat("console.log('poster');\n", 50)
+	h := StaticFiles(fstest.MapFS{
+		"app.js":      {Data: []byte(js)},
+		"site.css":    {Data: []byte("body{}")},
+		"site.css.br": {Data: []byte("brotli bytes")},
+		"favicon.png": {Data: []byte("png")},
+	})
+	get := func(name, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/"+name, nil)
+		if accept != "" {
+			req.Header.Set("Accept-Encoding", accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("app.js", "gzip, deflate, br")
+	if w.Header().Get("Content-Encoding") != "gzip" || !strings.Contains(w.Header().Get("Content-Type"), "javascript") {
+		t.Fatalf("app.js headers = %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != js {
+		t.Error("gzip copy should decompress to the asset")
+	}
+	before := h.(*staticFiles).stats.Stats()
+	get("app.js", "gzip")
+	if s := h.(*staticFiles).stats.Stats(); s.Hits != before.Hits+1 || s.Entries != 1 {
+		t.Errorf("second request should reuse the gzip copy: %+v", s)
+	}
+
+	if w := get("site.css", "gzip, br"); w.Header().Get("Content-Encoding") != "br" || w.Body.String() != "brotli bytes" {
+		t.Errorf("site.css should use its precompressed .br: %v %q", w.Header(), w.Body.String())
+	}
+	if w := get("site.css", "br;q=0, identity"); w.Header().Get("Content-Encoding") != "" || w.Body.String() != "body{}" {
+		t.Errorf("refused encodings should get the plain file: %v", w.Header())
+	}
+	if w := get("favicon.png", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("images other than SVG should not be compressed")
+	}
+}
+
+func TestCompress(t *testing.T) {
+	h := Compress()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(strings.Repeat("<p>pick</p>", 200)))
+	}))
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/dates", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Body.Len() >= 2200 {
+		t.Errorf("page should be gzipped: %v, %d bytes", w.Header(), w.Body.Len())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := req.Context().Deadline(); !ok {
+			w.WriteHeader(http.StatusNoContent) // unbounded
+			return
+		}
+		<-req.Context().Done()
+	})
+	for _, tc := range []struct {
+		d    time.Duration
+		want int
+	}{{20 * time.Millisecond, http.StatusGatewayTimeout}, {0, http.StatusNoContent}} {
+		w := httptest.NewRecorder()
+		Timeout(tc.d)(slow).ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/admin/reload", nil))
+		if w.Code != tc.want {
+			t.Errorf("Timeout(%v) = %d, want %d", tc.d, w.Code, tc.want)
+		}
+	}
+}
+
+func TestHandleOnboarding_withoutTMDb(t *testing.T) {
+	r, err := recommend.New(nil, nil, nil, nil, "", recommend.SignalConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	HandleOnboarding(r)(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/onboarding", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "Favorite films") || !strings.Contains(body, `name="mood"`) {
+		t.Errorf("without TMDb the quiz should offer only moods:\n%s", body)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("nil map")
+	}))
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/today", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "internal server error") {
+		t.Errorf("panic = %d %q, want a 500", w.Code, w.Body.String())
+	}
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler { //nolint:errorlint // panic values are compared, not wrapped
+			t.Errorf("ErrAbortHandler should propagate, got %v", v)
+		}
+	}()
+	Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestExportFormats(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	recs := []models.Recommendation{
+		{Date: day, Slot: "daily", Type: models.TypeMovie, Title: "Heat", Year: 1995, Rating: new(8.3), Genre: "Crime", TMDbID: 949, Explanation: "A taut,\nlong heist."},
+		{Date: day, Slot: "late", Type: models.TypeTVShow, Title: "*Starred*", Genre: "Comedy"},
+	}
+
+	var csvBuf bytes.Buffer
+	if err := writeCSVExport(&csvBuf, recs); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&csvBuf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][3] != "Heat" || rows[1][8] != "949" || rows[1][9] != "A taut,\nlong heist." || rows[2][8] != "" {
+		t.Errorf("csv rows = %q", rows)
+	}
+
+	var md bytes.Buffer
+	writeMarkdownExport(&md, day, day, recs)
+	for _, want := range []string{
+		"## Sunday, March 1, 2026",
+		"- **Heat** (1995) — Crime · 8.3★\n  A taut, long heist.\n",
+		`- **\*Starred\*** — TV · Comedy · late`,
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestRequireLogin(t *testing.T) {
+	h := RequireLogin(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("reached the page without signing in")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/date/2026-10-16?x=1", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login?next=%2Fdate%2F2026-10-16%3Fx%3D1" {
+		t.Errorf("page: got %d to %q, want 303 to /login", w.Code, w.Header().Get("Location"))
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/vote", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("form post: got %d, want 401", w.Code)
+	}
+
+	for next, want := range map[string]string{"/stats": "/stats", "//evil.example": "/", `/\evil.example`: "/", "https://evil.example": "/", "": "/"} {
+		if got := safeNext(next); got != want {
+			t.Errorf("safeNext(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
+
+func TestLoginPage_render(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "login.html"}, loginData{Name: "sam", Next: "/stats", Plex: true, Error: "That name and password don't match.", CSRFToken: "tok"})
+	body := w.Body.String()
+	for _, want := range []string{`action="/login"`, `value="sam"`, `value="/stats"`, `value="tok"`, `action="/login/plex"`, "don&#39;t match"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("login page missing %s", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	renderTemplate(context.Background(), w, []string{baseTemplate, "login.html"}, loginData{User: &models.User{Name: "sam"}, CSRFToken: "tok"})
+	body = w.Body.String()
+	if !strings.Contains(body, `action="/logout"`) || strings.Contains(body, `action="/login/plex"`) {
+		t.Error("signed-in page should offer sign-out only")
+	}
+}
+
+func TestPlexImageProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpeg"))
+	}))
+	defer srv.Close()
+	p := plex.NewClient(srv.URL, "tok", nil, nil)
+	signer := signedurl.New([]byte("key"))
+	sign := PosterSigner(signer, p)
+
+	if got := sign("https://image.tmdb.org/t/p/w500/x.jpg"); got != "https://image.tmdb.org/t/p/w500/x.jpg" {
+		t.Errorf("off-Plex poster rewritten to %s", got)
+	}
+	if got := sign("/library/metadata/1/thumb/2"); !strings.HasPrefix(got, "/plex/image/library/metadata/1/thumb/2?") {
+		t.Errorf("stored Plex path signed as %s", got)
+	}
+	link := sign(srv.URL + "/library/metadata/1/thumb/2?X-Plex-Token=tok")
+	if !strings.HasPrefix(link, "/plex/image/library/metadata/1/thumb/2?") || strings.Contains(link, "tok") || strings.Contains(link, srv.URL) {
+		t.Fatalf("signed poster link = %s", link)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/plex/image/*", HandlePlexImage(signer, p))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, link, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "jpeg" {
+		t.Errorf("signed link: got %d %q", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, strings.Replace(link, "thumb/2", "thumb/3", 1), nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("tampered link: got %d, want 403", w.Code)
+	}
+}
+
+func TestShareLinks(t *testing.T) {
+	signer := signedurl.New([]byte("key"))
+	r := chi.NewRouter()
+	r.Post("/date/{date}/share", HandleShareDate(signer))
+	r.Get("/share/{date}", HandleShare(nil, signer))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/date/2026-01-02/share", nil))
+	link := w.Header().Get("Location")
+	if w.Code != http.StatusSeeOther || !strings.HasPrefix(link, "/share/2026-01-02?") {
+		t.Fatalf("share: got %d to %q", w.Code, link)
+	}
+	u, _ := url.Parse(link)
+	if err := signer.Verify(u.Path, u.Query(), time.Now().Add(shareFor-time.Minute)); err != nil {
+		t.Errorf("share link should last a week: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/date/2999-01-02/share", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("sharing a future day: got %d, want 400", w.Code)
+	}
+
+	for path, want := range map[string]int{
+		"/share/2026-01-03" + link[len("/share/2026-01-02"):]: http.StatusForbidden,
+		"/share/2026-01-02": http.StatusForbidden,
+		signer.Sign("/share/2026-01-02", time.Now().Add(-time.Hour)): http.StatusGone,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/letterboxd"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+)
+
+// maxImportBytes bounds an uploaded Letterboxd export.
+const maxImportBytes = 32 << 20
+
+// HandleLetterboxdImport takes a Letterboxd export as the request body (the
+// zip, or its watched.csv, ratings.csv, or diary.csv), marks the films owned
+// in Plex as watched and records their ratings, and answers with the counts.
+func HandleLetterboxdImport(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		b, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxImportBytes))
+		if err != nil {
+			validation.WriteRequestError(ctx, w, err)
+			return
+		}
+		films, err := letterboxd.Parse(b)
+		if errors.Is(err, letterboxd.ErrTooLarge) {
+			validation.WriteError(ctx, w, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			validation.WriteRequestError(ctx, w, err)
+			return
+		}
+		res, err := r.ImportLetterboxd(ctx, films)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to import Letterboxd export", "films", len(films), zap.Error(err))
+			writeJSONError(ctx, w, "failed to import export", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, "import.letterboxd", "letterboxd export", nil,
+			map[string]int{"films": res.Films, "matched": res.Matched, "rated": res.Rated})
+		writeJSON(ctx, w, res)
+	}
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/health"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/lock"
+	"github.com/icco/recommender/lib/notify"
+	"github.com/icco/recommender/lib/plex"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// Job kinds run by the background queue.
+const (
+	JobGenerate          = "generate_recommendations"
+	JobCacheUpdate       = "cache_update"
+	JobLearnTaste        = "learn_taste_profile"
+	JobEnrichCollections = "enrich_collections"
+	JobExportLists       = "export_lists"
+	JobBecauseWatched    = "because_watched"
+	JobRebuildVectors    = "rebuild_vectors"
+	JobColdStart         = "cold_start"
+	JobNotify            = "notify"
+)
+
+// cronBackgroundLockKey serializes all heavy cron work (cache refresh and recommendation
+// generation) so they never run concurrently. Otherwise a cache rebuild can delete
+// movie/tv rows while recommendation generation is reading them.
+const cronBackgroundLockKey = "cron-serial"
+
+// cronJobTimeout bounds a single cache update or generation attempt.
+const cronJobTimeout = 5 * time.Minute
+
+type generatePayload struct {
+	Date        string `json:"date"`           // YYYY-MM-DD
+	Slot        string `json:"slot,omitempty"` // "" for the daily slot
+	OverrideCap bool   `json:"override_cap,omitempty"`
+	// Regenerate generates a day that already generated again, replacing
+	// its picks only if that succeeds (recommend.WithRegenerate).
+	Regenerate bool `json:"regenerate,omitempty"`
+}
+
+// key is the job key that folds repeated requests for the same generation
+// into one job. An override gets its own key so it isn't folded into a
+// capped job that is still backing off, and a regeneration its own so it
+// isn't folded into a plain job that would find the day done.
+func (p generatePayload) key() string {
+	key := p.Date
+	if p.Slot != "" {
+		key += ":" + p.Slot
+	}
+	if p.OverrideCap {
+		key += ":override"
+	}
+	if p.Regenerate {
+		key += ":regenerate"
+	}
+	return key
+}
+
+// coldStartPayload names the sync whose new titles a cold-start job prepares.
+// A job folded into a pending one is covered by its earlier Since; titles
+// missed while one runs still reach the library-wide follow-up jobs.
+type coldStartPayload struct {
+	Since time.Time `json:"since"`
+}
+
+type exportPayload struct {
+	Date string `json:"date"` // YYYY-MM-DD
+}
+
+type notifyPayload struct {
+	Date string `json:"date"` // YYYY-MM-DD
+}
+
+// RegisterJobs binds the cron job kinds to q. Both take the serial file lock,
+// deferring (without using up an attempt) while the other is running. A
+// newly generated daily set is announced through n.
+func RegisterJobs(q *jobs.Queue, p *plex.Client, rec *recommend.Recommender, fl *lock.FileLock, n *notify.Notifier) {
+	q.Register(JobGenerate, cronJobTimeout, func(ctx context.Context, raw json.RawMessage) error {
+		var in generatePayload
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			return fmt.Errorf("parse date %q: %w", in.Date, err)
+		}
+		slot, ok := recommend.LookupSlot(in.Slot)
+		if !ok {
+			return fmt.Errorf("unknown slot %q", in.Slot)
+		}
+		if in.OverrideCap {
+			ctx = recommend.WithLLMCapOverride(ctx)
+		}
+		if in.Regenerate {
+			ctx = recommend.WithRegenerate(ctx)
+		}
+		// Only the job that actually generates the day announces it.
+		var generated bool
+		err = withSerialLock(ctx, fl, func() error {
+			before, err := rec.DidRun(ctx, date, slot)
+			if err != nil {
+				return err
+			}
+			if err := rec.GenerateSlot(ctx, date, slot); err != nil {
+				return err
+			}
+			after, err := rec.DidRun(ctx, date, slot)
+			generated = !before && after
+			return err
+		})
+		// A model failure with GENERATION_RETRY_INTERVAL set waits for the
+		// next try without using up an attempt.
+		var later *recommend.RetryLaterError
+		if errors.As(err, &later) {
+			return jobs.Defer(time.Until(later.At), later.Error())
+		}
+		if err == nil && slot.Name == recommend.DailySlot.Name && rec.ExportEnabled() {
+			key := JobExportLists + ":" + in.Date
+			if _, _, err := q.Enqueue(ctx, JobExportLists, exportPayload{Date: in.Date}, jobs.Options{Key: key}); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to enqueue list export", "date", in.Date, zap.Error(err))
+			}
+		}
+		// Sinks retry on their own, so a failed notification isn't re-run:
+		// that would repeat it to the sinks that did get it.
+		// A regeneration corrects a day that was already announced, so it
+		// isn't announced again.
+		if generated && !in.Regenerate && slot.Name == recommend.DailySlot.Name && n.Enabled() {
+			key := JobNotify + ":" + in.Date
+			if _, _, err := q.Enqueue(ctx, JobNotify, notifyPayload{Date: in.Date}, jobs.Options{Key: key, MaxAttempts: 1}); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to enqueue notification", "date", in.Date, zap.Error(err))
+			}
+		}
+		return err
+	})
+	q.Register(JobCacheUpdate, cronJobTimeout, func(ctx context.Context, _ json.RawMessage) error {
+		// A sleeping or rebooting Plex server would otherwise fail the whole
+		// sync; check first (waking it if configured) and wait for it instead.
+		if err := p.EnsureAwake(ctx); err != nil {
+			down := time.Since(p.Availability().Since)
+			if wait, ok := plexDeferral(down); ok {
+				return jobs.Defer(wait, err.Error())
+			}
+			return fmt.Errorf("plex down for %s: %w", down.Round(time.Minute), err)
+		}
+		return withSerialLock(ctx, fl, func() error {
+			started := time.Now()
+			if err := p.UpdateCache(ctx); err != nil {
+				return err
+			}
+			rec.SyncSignals(ctx)
+			// Follow-up work on the fresh library runs as separate jobs so a
+			// failure there doesn't retry the whole sync. Titles the sync
+			// added are prepared first, ahead of the library-wide passes.
+			if _, _, err := q.Enqueue(ctx, JobColdStart, coldStartPayload{Since: started}, jobs.Options{Key: JobColdStart}); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to enqueue follow-up job", "kind", JobColdStart, zap.Error(err))
+			}
+			kinds := []string{JobLearnTaste, JobBecauseWatched}
+			if rec.TMDbEnabled() {
+				kinds = append(kinds, JobEnrichCollections)
+			}
+			for _, kind := range kinds {
+				if _, _, err := q.Enqueue(ctx, kind, struct{}{}, jobs.Options{Key: kind}); err != nil {
+					logging.FromContext(ctx).Warnw("Failed to enqueue follow-up job", "kind", kind, zap.Error(err))
+				}
+			}
+			return nil
+		})
+	})
+	q.Register(JobLearnTaste, time.Minute, func(ctx context.Context, _ json.RawMessage) error {
+		return rec.LearnTasteProfile(ctx)
+	})
+	q.Register(JobBecauseWatched, cronJobTimeout, func(ctx context.Context, _ json.RawMessage) error {
+		return rec.RefreshBecauseRows(ctx, time.Now())
+	})
+	q.Register(JobColdStart, cronJobTimeout, func(ctx context.Context, raw json.RawMessage) error {
+		var in coldStartPayload
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		_, err := rec.ColdStart(ctx, in.Since)
+		return err
+	})
+	q.Register(JobRebuildVectors, cronJobTimeout, func(ctx context.Context, _ json.RawMessage) error {
+		return rec.RebuildVectors(ctx)
+	})
+	q.Register(JobExportLists, time.Minute, func(ctx context.Context, raw json.RawMessage) error {
+		var in exportPayload
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			return fmt.Errorf("parse date %q: %w", in.Date, err)
+		}
+		return rec.ExportDay(ctx, date)
+	})
+	q.Register(JobNotify, cronJobTimeout, func(ctx context.Context, raw json.RawMessage) error {
+		var in notifyPayload
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			return fmt.Errorf("parse date %q: %w", in.Date, err)
+		}
+		day, err := notifyDay(ctx, rec, date)
+		if err != nil {
+			return err
+		}
+		return n.Notify(ctx, day)
+	})
+	q.Register(JobEnrichCollections, cronJobTimeout, func(ctx context.Context, _ json.RawMessage) error {
+		n, err := rec.EnrichCollections(ctx)
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Infow("Checked TMDb collections", "movies", n)
+		return nil
+	})
+}
+
+// notifyDay is the JSON notification sinks get for date: its daily picks
+// in the /api/recommendations shape.
+func notifyDay(ctx context.Context, rec *recommend.Recommender, date time.Time) (v1.Recommendations, error) {
+	recs, err := rec.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		return v1.Recommendations{}, fmt.Errorf("load picks: %w", err)
+	}
+	theme, err := rec.ThemeForDate(ctx, date)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to get theme for notification", "date", date, zap.Error(err))
+	}
+	daily, _ := recommend.SplitSlots(recs)
+	out := v1.Recommendations{Date: v1.Date(date), Theme: theme, Recommendations: make([]v1.Recommendation, 0, len(daily))}
+	for _, r := range daily {
+		out.Recommendations = append(out.Recommendations, toAPIRecommendation(r))
+	}
+	return out, nil
+}
+
+// plexDeferral is how long a cache sync waits before checking again when Plex
+// has been unreachable for down: quick retries through a brief outage, hourly
+// once the server has clearly gone to sleep. After 12 hours ok is false and
+// the sync fails (and retries) like any other error.
+func plexDeferral(down time.Duration) (wait time.Duration, ok bool) {
+	switch {
+	case down < 30*time.Minute:
+		return 5 * time.Minute, true
+	case down < 2*time.Hour:
+		return 15 * time.Minute, true
+	case down < 12*time.Hour:
+		return time.Hour, true
+	}
+	return 0, false
+}
+
+// withSerialLock runs fn while holding cronBackgroundLockKey.
+func withSerialLock(ctx context.Context, fl *lock.FileLock, fn func() error) error {
+	acquired, err := fl.TryLock(ctx, cronBackgroundLockKey, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("acquire %s lock: %w", cronBackgroundLockKey, err)
+	}
+	if !acquired {
+		return jobs.Defer(time.Minute, "another cron job holds the lock")
+	}
+	defer func() {
+		// Unlock must run even when ctx has timed out.
+		//nolint:contextcheck // intentional detach: unlock must outlive the job context
+		if err := fl.Unlock(context.Background(), cronBackgroundLockKey); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to release cron lock", "lock_key", cronBackgroundLockKey, zap.Error(err))
+		}
+	}()
+	return fn()
+}
+
+// ScheduledGenerate is the internal scheduler's /cron/recommend: it queues
+// today's picks for slot unless the day is in a blackout or already
+// generated.
+func ScheduledGenerate(r *recommend.Recommender, q *jobs.Queue, slot recommend.Slot) func(context.Context) error {
+	return func(ctx context.Context) error {
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		if b, ok := r.Paused(date); ok {
+			logging.FromContext(ctx).Infow("Generation paused for date", "date", date, "until", b.To)
+			return nil
+		}
+		exists, err := r.DidRun(ctx, date, slot)
+		if err != nil {
+			return fmt.Errorf("check existing recommendations: %w", err)
+		}
+		if exists {
+			return nil
+		}
+		payload := generatePayload{Date: date.Format("2006-01-02")}
+		if slot.Name != recommend.DailySlot.Name {
+			payload.Slot = slot.Name
+		}
+		if _, _, err := q.Enqueue(ctx, JobGenerate, payload, jobs.Options{Key: payload.key()}); err != nil {
+			return fmt.Errorf("enqueue generation: %w", err)
+		}
+		return nil
+	}
+}
+
+// ScheduledCache is the internal scheduler's /cron/cache.
+func ScheduledCache(q *jobs.Queue) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if _, _, err := q.Enqueue(ctx, JobCacheUpdate, struct{}{}, jobs.Options{Key: JobCacheUpdate}); err != nil {
+			return fmt.Errorf("enqueue cache update: %w", err)
+		}
+		return nil
+	}
+}
+
+// PipelineHealth loads the pipeline state /health and /metrics report: the
+// last successful generation and candidate counts from r, and the last
+// finished cache sync from q. Finished jobs are pruned after a week, so an
+// older sync shows as unknown.
+func PipelineHealth(r *recommend.Recommender, q *jobs.Queue) health.PipelineFunc {
+	return func(ctx context.Context) (health.Pipeline, error) {
+		var out health.Pipeline
+		st, err := r.PipelineStatus(ctx)
+		if err != nil {
+			return out, err
+		}
+		synced, err := q.LastDone(ctx, JobCacheUpdate)
+		if err != nil {
+			return out, err
+		}
+		if !st.LastGeneratedDate.IsZero() {
+			out.LastGeneratedDate = st.LastGeneratedDate.UTC().Format(time.DateOnly)
+		}
+		out.LastGeneratedAt, out.LastCacheSync = v1.Time(st.LastGeneratedAt), v1.Time(synced)
+		out.Candidates.Movies, out.Candidates.TVShows = st.CandidateMovies, st.CandidateTVShows
+		return out, nil
+	}
+}
+
+// jobLabels names the job kinds reported while queued or running: the ones
+// today's picks wait on.
+var jobLabels = map[string]string{
+	JobCacheUpdate: "Updating library",
+	JobGenerate:    "Picking recommendations",
+}
+
+// activeJobs returns the queued and running jobs in jobLabels, running ones
+// first. A nil q has none.
+func activeJobs(ctx context.Context, q *jobs.Queue) ([]v1.Job, error) {
+	running, queued := []v1.Job{}, []v1.Job{}
+	if q == nil {
+		return running, nil
+	}
+	active, err := q.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range active {
+		label, ok := jobLabels[j.Kind]
+		switch {
+		case !ok:
+		case j.Status == models.JobStatusRunning:
+			running = append(running, v1.NewJob(j, label))
+		default:
+			queued = append(queued, v1.NewJob(j, label))
+		}
+	}
+	return append(running, queued...), nil
+}
+
+// HandleJobStatus serves the library syncs and generation runs queued or
+// running as {"jobs": [{kind, label, status, progress, run_at}]}, for the
+// home page's banner and API clients.
+func HandleJobStatus(q *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		active, err := activeJobs(ctx, q)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to list active jobs", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list jobs", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(ctx, w, v1.Jobs{Jobs: active})
+	}
+}
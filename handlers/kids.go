@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// kidsData is the view model for kids.html.
+type kidsData struct {
+	Date  time.Time
+	Picks []models.Recommendation
+}
+
+// HandleKids serves today's kids' picks (recommend.KidsSlot) on a page of
+// big posters and short reasons, without the scores, ratings, and feedback
+// controls of the home page. JSON clients get them in the
+// /api/recommendations shape.
+func HandleKids(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		recs, err := r.GetSlotRecommendations(ctx, today, recommend.KidsSlot)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to get kids' picks", zap.Error(err))
+			writeError(w, req, "We couldn't load today's kids' picks. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		if wantsJSON(req) {
+			out := v1.Recommendations{Date: v1.Date(today), Recommendations: make([]v1.Recommendation, 0, len(recs))}
+			for _, rec := range recs {
+				out.Recommendations = append(out.Recommendations, toAPIRecommendation(rec))
+			}
+			writeJSON(ctx, w, out)
+			return
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "kids.html"}, kidsData{Date: today, Picks: recs})
+	}
+}
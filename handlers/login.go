@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/auth"
+	"github.com/icco/recommender/lib/plextv"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionCookie holds a signed-in browser's session token.
+	sessionCookie = "session"
+	// plexPinCookie remembers the plex.tv PIN a Plex sign-in is waiting on,
+	// and whether to remember the session it starts ("<pin id>:<0|1>").
+	plexPinCookie = "plex_pin"
+)
+
+type userKey struct{}
+
+// loginData is the view model for login.html.
+type loginData struct {
+	User      *models.User // already signed in
+	Name      string
+	Next      string
+	Error     string
+	Plex      bool // Plex sign-in is available
+	CSRFToken string
+}
+
+// RequireLogin sends visitors who aren't signed in to /login, or answers
+// 401 for requests that aren't for pages. Handlers behind it can read the
+// user with currentUser.
+func RequireLogin(store *auth.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			u, err := sessionUser(req, store)
+			if err == nil {
+				next.ServeHTTP(w, req.WithContext(context.WithValue(ctx, userKey{}, &u)))
+				return
+			}
+			if !errors.Is(err, auth.ErrNoSession) {
+				logging.FromContext(ctx).Errorw("Failed to check session", zap.Error(err))
+				writeError(w, req, "We couldn't check your sign-in. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			if req.Method != http.MethodGet || wantsJSON(req) || req.Header.Get("Accept") == "text/event-stream" {
+				writeError(w, req, "Please sign in.", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, req, "/login?next="+url.QueryEscape(req.URL.RequestURI()), http.StatusSeeOther)
+		})
+	}
+}
+
+// currentUser returns who RequireLogin signed in, or nil.
+func currentUser(ctx context.Context) *models.User {
+	u, _ := ctx.Value(userKey{}).(*models.User)
+	return u
+}
+
+func sessionUser(req *http.Request, store *auth.Store) (models.User, error) {
+	c, err := req.Cookie(sessionCookie)
+	if err != nil {
+		return models.User{}, auth.ErrNoSession
+	}
+	return store.SessionUser(req.Context(), c.Value)
+}
+
+// safeNext returns next if it is a path on this site, else "/", so the
+// sign-in redirect can't be pointed elsewhere.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func secureRequest(req *http.Request) bool {
+	return req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// startSession signs user in on this browser and sends it on to next. A
+// remembered session's cookie persists; any other ends with the browser.
+func startSession(w http.ResponseWriter, req *http.Request, store *auth.Store, user models.User, remember bool, next string) {
+	ctx := req.Context()
+	token, expires, err := store.StartSession(ctx, user, remember, req.UserAgent())
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to start session", zap.Error(err))
+		writeError(w, req, "We couldn't sign you in. Please try again later.", http.StatusInternalServerError)
+		return
+	}
+	c := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secureRequest(req),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if remember {
+		c.Expires = expires
+	}
+	http.SetCookie(w, c)
+	logging.FromContext(ctx).Infow("Signed in", "user", user.Name, "remember", remember)
+	http.Redirect(w, req, safeNext(next), http.StatusSeeOther)
+}
+
+// HandleLogin serves the sign-in page (GET) and signs in with a name and
+// password (POST, form fields name, password, remember, and next). Signed-in
+// visitors see who they are and can sign out. plex is nil when Plex sign-in
+// isn't configured.
+func HandleLogin(store *auth.Store, plex *plextv.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		req = req.WithContext(ctx)
+		data := loginData{Next: safeNext(req.FormValue("next")), Plex: plex != nil, CSRFToken: csrfToken(req), Error: req.URL.Query().Get("error")}
+
+		if req.Method == http.MethodPost {
+			data.Name = strings.TrimSpace(req.PostFormValue("name"))
+			user, err := store.CheckPassword(ctx, data.Name, req.PostFormValue("password"))
+			switch {
+			case errors.Is(err, auth.ErrBadCredentials):
+				data.Error = "That name and password don't match."
+				w.WriteHeader(http.StatusUnauthorized)
+				renderTemplate(ctx, w, []string{baseTemplate, "login.html"}, data)
+				return
+			case err != nil:
+				logging.FromContext(ctx).Errorw("Failed to check password", zap.Error(err))
+				writeError(w, req, "We couldn't sign you in. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			startSession(w, req, store, user, req.PostFormValue("remember") != "", data.Next)
+			return
+		}
+
+		if u, err := sessionUser(req, store); err == nil {
+			data.User = &u
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "login.html"}, data)
+	}
+}
+
+// HandleLogout signs this browser out (POST), or every browser the user is
+// signed in on with the form field all set.
+func HandleLogout(store *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		if c, err := req.Cookie(sessionCookie); err == nil {
+			if req.PostFormValue("all") != "" {
+				if u, err := store.SessionUser(ctx, c.Value); err == nil {
+					if _, err := store.EndUserSessions(ctx, u.ID); err != nil {
+						l.Errorw("Failed to end sessions", zap.Error(err))
+					}
+				}
+			}
+			if err := store.EndSession(ctx, c.Value); err != nil {
+				l.Errorw("Failed to end session", zap.Error(err))
+				writeError(w, req, "We couldn't sign you out. Please try again.", http.StatusInternalServerError)
+				return
+			}
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name: sessionCookie, Value: "", Path: "/", MaxAge: -1,
+			HttpOnly: true, Secure: secureRequest(req), SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, req, "/login", http.StatusSeeOther)
+	}
+}
+
+// HandlePlexLogin starts a Plex sign-in: it asks plex.tv for a PIN and
+// sends the browser to approve it, to come back to /login/plex/callback.
+// Query parameters remember and next carry over from the sign-in page.
+func HandlePlexLogin(plex *plextv.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		pin, err := plex.CreatePin(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to start Plex sign-in", zap.Error(err))
+			writeError(w, req, "Plex sign-in is unavailable right now. Please try again later.", http.StatusBadGateway)
+			return
+		}
+		remember := "0"
+		if req.URL.Query().Get("remember") != "" {
+			remember = "1"
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     plexPinCookie,
+			Value:    strconv.Itoa(pin.ID) + ":" + remember,
+			Path:     "/login/plex",
+			MaxAge:   10 * 60,
+			HttpOnly: true,
+			Secure:   secureRequest(req),
+			SameSite: http.SameSiteLaxMode,
+		})
+		scheme := "http"
+		if secureRequest(req) {
+			scheme = "https"
+		}
+		forward := scheme + "://" + req.Host + "/login/plex/callback?next=" + url.QueryEscape(safeNext(req.URL.Query().Get("next")))
+		http.Redirect(w, req, plex.SignInURL(pin, forward), http.StatusSeeOther)
+	}
+}
+
+// HandlePlexCallback finishes a Plex sign-in once plex.tv sends the browser
+// back, signing in the user linked to the Plex account.
+func HandlePlexCallback(store *auth.Store, plex *plextv.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		fail := func(msg string) {
+			http.Redirect(w, req, "/login?error="+url.QueryEscape(msg), http.StatusSeeOther)
+		}
+		c, err := req.Cookie(plexPinCookie)
+		if err != nil {
+			fail("Your Plex sign-in expired. Please try again.")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: plexPinCookie, Value: "", Path: "/login/plex", MaxAge: -1, HttpOnly: true, Secure: secureRequest(req)})
+		idStr, remember, _ := strings.Cut(c.Value, ":")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			fail("Your Plex sign-in expired. Please try again.")
+			return
+		}
+		token, err := plex.PinToken(ctx, id)
+		if err != nil {
+			l.Errorw("Failed to check Plex sign-in", zap.Error(err))
+			fail("Plex sign-in is unavailable right now. Please try again later.")
+			return
+		}
+		if token == "" {
+			fail("Plex sign-in wasn't approved.")
+			return
+		}
+		name, err := plex.Username(ctx, token)
+		if err != nil {
+			l.Errorw("Failed to look up Plex account", zap.Error(err))
+			fail("Plex sign-in is unavailable right now. Please try again later.")
+			return
+		}
+		user, err := store.UserByPlex(ctx, name)
+		switch {
+		case errors.Is(err, auth.ErrNotLinked):
+			l.Infow("Plex sign-in by an unlinked account", "plex_username", name)
+			fail("Your Plex account isn't linked to anyone here. Ask an admin to link it.")
+			return
+		case err != nil:
+			l.Errorw("Failed to find Plex user", zap.Error(err))
+			fail("We couldn't sign you in. Please try again later.")
+			return
+		}
+		startSession(w, req, store, user, remember == "1", req.URL.Query().Get("next"))
+	}
+}
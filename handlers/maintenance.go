@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/handlers/templates"
+	"github.com/icco/recommender/lib/maintenance"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+)
+
+// maintenanceSource reports maintenance mode; *maintenance.Switch in
+// production.
+type maintenanceSource interface {
+	State() maintenance.State
+}
+
+// PauseWrites refuses writes with a 503 maintenance page while maintenance
+// mode is on. Reads stay up. /cron/* counts as a write, since it starts
+// background work; /admin/* stays open so the switch can be turned off.
+func PauseWrites(sw maintenanceSource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			st := sw.State()
+			if !st.Enabled || !isWrite(req) || strings.HasPrefix(req.URL.Path, "/admin/") {
+				next.ServeHTTP(w, req)
+				return
+			}
+			w.Header().Set("Retry-After", "300")
+			if wantsJSON(req) || strings.HasPrefix(req.URL.Path, "/cron/") {
+				writeJSONError(req.Context(), w, "down for maintenance", http.StatusServiceUnavailable)
+				return
+			}
+			renderMaintenance(w, req, st)
+		})
+	}
+}
+
+// isWrite reports whether req changes state.
+func isWrite(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return strings.HasPrefix(req.URL.Path, "/cron/")
+	}
+	return true
+}
+
+// renderMaintenance renders the maintenance page with a 503.
+func renderMaintenance(w http.ResponseWriter, req *http.Request, st maintenance.State) {
+	l := logging.FromContext(req.Context())
+	tmpl, err := templates.ParseTemplates(baseTemplate, "maintenance.html")
+	if err != nil {
+		l.Errorw("Failed to parse maintenance template", zap.Error(err))
+		http.Error(w, "Down for maintenance", http.StatusServiceUnavailable)
+		return
+	}
+	ww := trackResponse(w)
+	if err := writeHTML(ww, tmpl, baseTemplate, st, http.StatusServiceUnavailable); err != nil {
+		l.Errorw("Failed to render maintenance template", zap.Error(err))
+		if ww.Status() == 0 {
+			http.Error(ww, "Down for maintenance", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// maintenanceRequest is the POST /admin/maintenance body.
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+func (m *maintenanceRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if m.Enabled == nil {
+		errs.Add("enabled", "is required")
+	}
+	if len(m.Reason) > 255 {
+		errs.Add("reason", "must be at most 255 bytes")
+	}
+	return errs
+}
+
+// HandleMaintenance reports maintenance mode on GET and sets it on POST with
+// {"enabled": true, "reason": "..."}. While it is on, the job queue claims
+// nothing (jobs already running finish) and PauseWrites refuses writes.
+func HandleMaintenance(sw *maintenance.Switch) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		st := sw.State()
+		if req.Method == http.MethodPost {
+			var body maintenanceRequest
+			if err := validation.DecodeJSON(w, req, 4096, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+			before := st
+			var err error
+			st, err = sw.Set(ctx, *body.Enabled, body.Reason)
+			if err != nil {
+				logging.FromContext(ctx).Errorw("Failed to set maintenance mode", zap.Error(err))
+				writeJSONError(ctx, w, "failed to set maintenance mode", http.StatusInternalServerError)
+				return
+			}
+			recordAudit(ctx, "maintenance.set", "", before, st)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(st); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to encode maintenance state", zap.Error(err))
+		}
+	}
+}
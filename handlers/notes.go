@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+)
+
+// dateNoteRequest is the JSON body of POST /date/{date}/note.
+type dateNoteRequest struct {
+	Note string `json:"note"`
+}
+
+func (n *dateNoteRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if len(n.Note) > recommend.MaxDateNoteLen {
+		errs.Add("note", "must be at most 2000 bytes")
+	}
+	return errs
+}
+
+// HandleDateNote attaches a journal note ("watched the thriller, loved it")
+// to /date/{date}/note, replacing any earlier one; an empty note removes it.
+// Forms post a note field and are sent back to the day's page; JSON clients
+// post {"note": "..."} and get {"date", "note"} back. Notes are shown on the
+// day's page and fed to the next two weeks' prompts.
+func HandleDateNote(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+
+		date := chi.URLParam(req, "date")
+		if err := validation.ValidateDate(date); err != nil {
+			if errors.Is(err, validation.ErrFutureDate) {
+				err = errors.New("notes can only be added to days that have begun")
+			}
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
+		day, _ := time.Parse("2006-01-02", date) // validated above
+
+		asJSON := strings.Contains(req.Header.Get("Content-Type"), "application/json")
+		var body dateNoteRequest
+		if asJSON {
+			if err := validation.DecodeJSON(w, req, 8192, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+		} else {
+			req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+			if err := req.ParseForm(); err != nil {
+				writeError(w, req, "We couldn't read your note.", http.StatusBadRequest)
+				return
+			}
+			body.Note = req.PostForm.Get("note")
+			if errs := body.Validate(); len(errs) > 0 {
+				writeError(w, req, "That note is too long; keep it under 2000 characters.", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := r.SetDateNote(ctx, day, body.Note); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to save note", "date", date, zap.Error(err))
+			writeError(w, req, "We couldn't save your note. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		if asJSON {
+			writeJSON(ctx, w, map[string]string{"date": date, "note": strings.TrimSpace(body.Note)})
+			return
+		}
+		http.Redirect(w, req, "/date/"+date, http.StatusSeeOther)
+	}
+}
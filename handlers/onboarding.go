@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"go.uber.org/zap"
+)
+
+// onboardingData is the view model for onboarding.html.
+type onboardingData struct {
+	Films     []recommend.OnboardingFilm
+	TMDb      bool // offer the popular-films grid; false hides it
+	Moods     []string
+	CSRFToken string
+}
+
+// HandleOnboarding serves the cold-start taste quiz (GET) and stores its
+// answers (POST), which seed the taste profile used by generation.
+func HandleOnboarding(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+		defer cancel()
+		l := logging.FromContext(ctx)
+
+		if req.Method == http.MethodPost {
+			req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+			if err := req.ParseForm(); err != nil {
+				writeError(w, req, "We couldn't read your answers.", http.StatusBadRequest)
+				return
+			}
+			if err := r.SaveOnboarding(ctx, req.PostForm["favorite"], req.PostForm["mood"]); err != nil {
+				l.Warnw("Failed to save onboarding answers", zap.Error(err))
+				writeError(w, req, "Please pick at least one film or mood.", http.StatusBadRequest)
+				return
+			}
+			http.Redirect(w, req, "/", http.StatusSeeOther)
+			return
+		}
+
+		// The grid is a nicety: without TMDb the quiz still offers moods.
+		films, err := r.OnboardingFilms(ctx)
+		if err != nil {
+			l.Warnw("Failed to load onboarding films", zap.Error(err))
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "onboarding.html"}, onboardingData{
+			Films: films, TMDb: r.TMDbEnabled(), Moods: recommend.OnboardingMoods, CSRFToken: csrfToken(req),
+		})
+	}
+}
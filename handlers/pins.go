@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// apiPin is a pin as /admin/pins reports it.
+type apiPin struct {
+	ID       uint   `json:"id"`
+	Date     string `json:"date"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Year     int    `json:"year"`
+	MovieID  *uint  `json:"movie_id,omitempty"`
+	TVShowID *uint  `json:"tv_show_id,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+func toAPIPin(p models.Pin) apiPin {
+	return apiPin{
+		ID: p.ID, Date: p.Date.UTC().Format("2006-01-02"), Type: p.Type, Title: p.Title, Year: p.Year,
+		MovieID: p.MovieID, TVShowID: p.TVShowID, Note: p.Note,
+	}
+}
+
+// pinRequest is the POST /admin/pins body.
+type pinRequest struct {
+	Date  string `json:"date"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	Note  string `json:"note"`
+
+	date time.Time
+}
+
+func (p *pinRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if d, err := time.Parse("2006-01-02", p.Date); err != nil {
+		errs.Add("date", "must be YYYY-MM-DD")
+	} else if d.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		errs.Add("date", "must be today or later")
+	} else {
+		p.date = d
+	}
+	if p.Type != models.TypeMovie && p.Type != models.TypeTVShow {
+		errs.Add("type", `must be "movie" or "tvshow"`)
+	}
+	if p.Title == "" {
+		errs.Add("title", "is required")
+	} else if len(p.Title) > 500 {
+		errs.Add("title", "must be at most 500 bytes")
+	}
+	if p.Year < 0 {
+		errs.Add("year", "must not be negative")
+	}
+	if len(p.Note) > 1000 {
+		errs.Add("note", "must be at most 1000 bytes")
+	}
+	return errs
+}
+
+// HandlePins lists today's and later pins on GET as {"pins": [...]}, and on
+// POST pins a library title to a day with {"date": "2026-12-24", "type":
+// "movie", "title": "Die Hard", "year": 1988, "note": "..."}. year is only
+// needed when several library items share the title.
+func HandlePins(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		if req.Method == http.MethodPost {
+			var body pinRequest
+			if err := validation.DecodeJSON(w, req, 4096, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+			pin, err := r.AddPin(ctx, body.date, body.Type, body.Title, body.Year, body.Note)
+			switch {
+			case errors.Is(err, recommend.ErrPinNotFound):
+				writeJSONError(ctx, w, err.Error(), http.StatusNotFound)
+				return
+			case errors.Is(err, recommend.ErrPinAmbiguous), errors.Is(err, recommend.ErrPinExists):
+				writeJSONError(ctx, w, err.Error(), http.StatusConflict)
+				return
+			case err != nil:
+				l.Errorw("Failed to add pin", zap.Error(err))
+				writeJSONError(ctx, w, "failed to add pin", http.StatusInternalServerError)
+				return
+			}
+			recordAudit(ctx, "pin.add", fmt.Sprintf("pin %d", pin.ID), nil, toAPIPin(pin))
+			writeJSON(ctx, w, toAPIPin(pin))
+			return
+		}
+
+		pins, err := r.Pins(ctx, time.Now())
+		if err != nil {
+			l.Errorw("Failed to list pins", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list pins", http.StatusInternalServerError)
+			return
+		}
+		out := make([]apiPin, 0, len(pins))
+		for _, p := range pins {
+			out = append(out, toAPIPin(p))
+		}
+		writeJSON(ctx, w, map[string][]apiPin{"pins": out})
+	}
+}
+
+// HandleDeletePin removes the pin /admin/pins/{id}.
+func HandleDeletePin(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeJSONError(ctx, w, "invalid pin id", http.StatusBadRequest)
+			return
+		}
+		pin, found, err := r.DeletePin(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to delete pin", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to delete pin", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeJSONError(ctx, w, "pin not found", http.StatusNotFound)
+			return
+		}
+		recordAudit(ctx, "pin.delete", fmt.Sprintf("pin %d", pin.ID), toAPIPin(pin), nil)
+		writeJSON(ctx, w, map[string]string{"status": "deleted"})
+	}
+}
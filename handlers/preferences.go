@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// preferencesRequest is the PUT /api/preferences body.
+type preferencesRequest struct {
+	FavoriteGenres []string `json:"favorite_genres"`
+	Moods          []string `json:"moods"`
+	Lengths        []string `json:"lengths"`
+	Languages      []string `json:"languages"`
+}
+
+func (p *preferencesRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	for _, f := range []struct {
+		name string
+		list []string
+		max  int
+	}{{"favorite_genres", p.FavoriteGenres, recommend.MaxPreferenceGenres}, {"languages", p.Languages, recommend.MaxPreferenceLanguages}} {
+		if len(f.list) > f.max {
+			errs.Add(f.name, fmt.Sprintf("must list at most %d", f.max))
+		}
+		for _, s := range f.list {
+			if len(s) > recommend.MaxPreferenceLen {
+				errs.Add(f.name, "must each be at most 64 bytes")
+				break
+			}
+		}
+	}
+	for _, m := range p.Moods {
+		if !slices.Contains(recommend.OnboardingMoods, m) {
+			errs.Add("moods", "must each be one of: "+strings.Join(recommend.OnboardingMoods, ", "))
+			break
+		}
+	}
+	for _, l := range p.Lengths {
+		if !slices.Contains(recommend.PreferenceLengths, l) {
+			errs.Add("lengths", `must each be "short", "standard", or "long"`)
+			break
+		}
+	}
+	return errs
+}
+
+func (p preferencesRequest) model() models.UserPreference {
+	return models.UserPreference{FavoriteGenres: p.FavoriteGenres, Moods: p.Moods, Lengths: p.Lengths, Languages: p.Languages}
+}
+
+// HandlePreferences returns the household's preferences on GET and replaces
+// them on PUT with {"favorite_genres": [...], "moods": [...], "lengths":
+// [...], "languages": [...]}, answering with what was stored. They are given
+// to the model with every prompt.
+func HandlePreferences(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		var body preferencesRequest
+		if req.Method == http.MethodPut {
+			if err := validation.DecodeJSON(w, req, 8192, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+		}
+		before, err := r.Preferences(ctx)
+		if err != nil {
+			l.Errorw("Failed to load preferences", zap.Error(err))
+			writeJSONError(ctx, w, "failed to load preferences", http.StatusInternalServerError)
+			return
+		}
+		if req.Method != http.MethodPut {
+			writeJSON(ctx, w, v1.NewPreferences(before))
+			return
+		}
+		after, err := r.SavePreferences(ctx, body.model())
+		if err != nil {
+			l.Errorw("Failed to save preferences", zap.Error(err))
+			writeJSONError(ctx, w, "failed to save preferences", http.StatusInternalServerError)
+			return
+		}
+		out := v1.NewPreferences(after)
+		recordAudit(ctx, "preferences.update", "preferences", v1.NewPreferences(before), out)
+		writeJSON(ctx, w, out)
+	}
+}
+
+// settingsData is the view model for settings.html.
+type settingsData struct {
+	Genres    string // FavoriteGenres, comma-separated for the text field
+	Languages string
+	Moods     []settingsOption
+	Lengths   []settingsOption
+	Saved     bool
+	CSRFToken string
+}
+
+// settingsOption is one checkbox on the settings page.
+type settingsOption struct {
+	Value   string
+	Checked bool
+}
+
+func settingsOptions(all, checked []string) []settingsOption {
+	out := make([]settingsOption, 0, len(all))
+	for _, v := range all {
+		out = append(out, settingsOption{Value: v, Checked: slices.Contains(checked, v)})
+	}
+	return out
+}
+
+// HandleSettings serves the preferences form (GET) and saves it (POST, with
+// comma-separated genres and languages fields and repeated mood and length
+// fields).
+func HandleSettings(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+
+		if req.Method == http.MethodPost {
+			req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+			if err := req.ParseForm(); err != nil {
+				writeError(w, req, "We couldn't read your settings.", http.StatusBadRequest)
+				return
+			}
+			body := preferencesRequest{
+				FavoriteGenres: strings.Split(req.PostForm.Get("genres"), ","),
+				Moods:          req.PostForm["mood"],
+				Lengths:        req.PostForm["length"],
+				Languages:      strings.Split(req.PostForm.Get("languages"), ","),
+			}
+			if _, err := r.SavePreferences(ctx, body.model()); err != nil {
+				l.Errorw("Failed to save preferences", zap.Error(err))
+				writeError(w, req, "We couldn't save your settings. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, req, "/settings?saved=1", http.StatusSeeOther)
+			return
+		}
+
+		prefs, err := r.Preferences(ctx)
+		if err != nil {
+			l.Errorw("Failed to load preferences", zap.Error(err))
+			writeError(w, req, "We couldn't load your settings. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "settings.html"}, settingsData{
+			Genres: strings.Join(prefs.FavoriteGenres, ", "), Languages: strings.Join(prefs.Languages, ", "),
+			Moods:   settingsOptions(recommend.OnboardingMoods, prefs.Moods),
+			Lengths: settingsOptions(recommend.PreferenceLengths, prefs.Lengths),
+			Saved:   req.URL.Query().Get("saved") != "", CSRFToken: csrfToken(req),
+		})
+	}
+}
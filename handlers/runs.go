@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"go.uber.org/zap"
+)
+
+// recentRuns is how many runs GET /admin/runs lists without a date.
+const recentRuns = 30
+
+// HandleRuns lists generation runs with their provenance (provider, model,
+// prompt version, strategy, build, and settings snapshot) as {"runs": [...]}:
+// the runs of ?date=YYYY-MM-DD, or the latest ones without it.
+func HandleRuns(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		var date time.Time
+		if s := req.URL.Query().Get("date"); s != "" {
+			d, err := time.Parse(v1.DateLayout, s)
+			if err != nil {
+				writeJSONError(ctx, w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			date = d
+		}
+		runs, err := r.Runs(ctx, date, recentRuns)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to load runs", zap.Error(err))
+			writeJSONError(ctx, w, "failed to load runs", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(ctx, w, map[string][]v1.Run{"runs": v1.NewRuns(runs)})
+	}
+}
+
+// HandlePromptRollouts lists canary prompt sets (PROMPT_CANARY_DIR) and
+// whether each was rolled back, as {"rollouts": [...]}.
+func HandlePromptRollouts(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		rollouts, err := r.PromptRollouts(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to load prompt rollouts", zap.Error(err))
+			writeJSONError(ctx, w, "failed to load prompt rollouts", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(ctx, w, map[string][]v1.PromptRollout{"rollouts": v1.NewPromptRollouts(rollouts)})
+	}
+}
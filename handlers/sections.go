@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+)
+
+// sectionPageSize is the soft cap on cards rendered per section of a day
+// page. Longer sections are paged, so a day with many accumulated picks
+// still renders a bounded response.
+const sectionPageSize = 12
+
+// pageSection is one titled group of cards on a day page.
+type pageSection struct {
+	Key             string // "movies", "tvshows", or a time-of-day slot name
+	Title           string
+	Wide            bool                    // four columns (movie posters) instead of three
+	Recommendations []models.Recommendation // the current page only
+	Total           int
+	Page            int
+	TotalPages      int
+	PrevURL         string // previous page of this section, "" on the first
+	MoreURL         string // next page of this section, "" on the last
+}
+
+// daySections groups a day's picks for display: the daily movies and shows,
+// then each time-of-day slot. Empty sections are left out.
+func daySections(daily []models.Recommendation, slots []recommend.SlotSection) []pageSection {
+	var movies, shows []models.Recommendation
+	for _, rec := range daily {
+		if rec.Type == models.TypeMovie {
+			movies = append(movies, rec)
+		} else {
+			shows = append(shows, rec)
+		}
+	}
+	var out []pageSection
+	if len(movies) > 0 {
+		out = append(out, pageSection{Key: "movies", Title: "Movies", Wide: true, Recommendations: movies})
+	}
+	if len(shows) > 0 {
+		out = append(out, pageSection{Key: "tvshows", Title: "TV Shows", Recommendations: shows})
+	}
+	for _, s := range slots {
+		out = append(out, pageSection{Key: s.Slot.Name, Title: s.Slot.Title, Recommendations: s.Recommendations})
+	}
+	return out
+}
+
+// paginate narrows s to page (1-based) of size cards, linking the
+// neighbouring pages on the day page at u.
+func (s *pageSection) paginate(u *url.URL, page, size int) {
+	s.Total = len(s.Recommendations)
+	s.Page = page
+	s.TotalPages = (s.Total + size - 1) / size
+	start := min((page-1)*size, s.Total)
+	end := min(start+size, s.Total)
+	s.Recommendations = s.Recommendations[start:end]
+	if page > 1 {
+		s.PrevURL = sectionURL(u, s.Key, page-1)
+	}
+	if page < s.TotalPages {
+		s.MoreURL = sectionURL(u, s.Key, page+1)
+	}
+}
+
+// sectionURL links to one page of a section of the day page at u, keeping
+// u's other parameters (such as the mood).
+func sectionURL(u *url.URL, section string, page int) string {
+	q := u.Query()
+	q.Del("partial")
+	q.Set("section", section)
+	q.Set("page", strconv.Itoa(page))
+	return u.Path + "?" + q.Encode()
+}
+
+// dayURL links back to the whole day page at u.
+func dayURL(u *url.URL) string {
+	q := u.Query()
+	for _, k := range []string{"section", "page", "partial"} {
+		q.Del(k)
+	}
+	if len(q) == 0 {
+		return u.Path
+	}
+	return u.Path + "?" + q.Encode()
+}
+
+// renderDay renders a day page from its daily picks and time-of-day slots.
+// Every section shows its first page; ?section=…&page=N narrows the page to
+// one page of one section, and &partial=1 renders only that fragment, which
+// the page's "Show more" links fetch and append in place. JSON clients get
+// the same sections as a v1.Day.
+func renderDay(w http.ResponseWriter, req *http.Request, data homeData, daily []models.Recommendation, slots []recommend.SlotSection) {
+	ctx := req.Context()
+	q := req.URL.Query()
+	asJSON := negotiate(w, req)
+	sections := daySections(daily, slots)
+
+	page := 1
+	if data.Section = q.Get("section"); data.Section != "" {
+		i := slices.IndexFunc(sections, func(s pageSection) bool { return s.Key == data.Section })
+		if i < 0 {
+			writeError(w, req, "There is no such section on this day.", http.StatusNotFound)
+			return
+		}
+		sections = sections[i : i+1]
+		if p := q.Get("page"); p != "" {
+			if _, err := fmt.Sscanf(p, "%d", &page); err != nil || page < 1 {
+				writeError(w, req, "invalid page parameter", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	for i := range sections {
+		sections[i].paginate(req.URL, page, sectionPageSize)
+	}
+	if data.Section != "" && page > sections[0].TotalPages {
+		writeError(w, req, "That page is past the end of this section.", http.StatusNotFound)
+		return
+	}
+	data.Sections = sections
+	data.DayURL = dayURL(req.URL)
+	if len(daily) == 0 {
+		data.Moods = nil // nothing to re-rank
+	}
+
+	if asJSON {
+		writeJSON(ctx, w, data.api())
+		return
+	}
+	files := []string{"home.html", "card.html"}
+	if partial, _ := strconv.ParseBool(q.Get("partial")); partial {
+		renderFragment(ctx, w, files, "sections", data)
+		return
+	}
+	renderTemplate(ctx, w, append([]string{baseTemplate}, files...), data)
+}
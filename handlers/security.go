@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/unrolled/secure"
+)
+
+// DefaultHSTSSeconds is the Strict-Transport-Security max-age (two years)
+// when HSTS_MAX_AGE is unset.
+const DefaultHSTSSeconds = 63072000
+
+// SecurityConfig tunes the security headers sent with every response.
+type SecurityConfig struct {
+	// CSP replaces the generated Content-Security-Policy when non-empty.
+	CSP string
+	// ImgSources are extra origins posters may load from (e.g. a Plex host
+	// or the FALLBACK_POSTER_URL origin), added to the generated policy.
+	ImgSources []string
+	// HSTSSeconds is the HSTS max-age; 0 sends no HSTS header.
+	HSTSSeconds int64
+	// FrameOptions is "DENY" or "SAMEORIGIN"; empty means DENY.
+	FrameOptions string
+	// Development relaxes host and HSTS checks for localhost (DEV_MODE).
+	Development bool
+}
+
+// policy is the Content-Security-Policy to send. The generated one allows
+// the page's own scripts and the Tailwind CDN (whose generated <style> tags
+// need inline styles), and posters from this server's /posters proxy, TMDb,
+// and ImgSources.
+func (c SecurityConfig) policy() string {
+	if c.CSP != "" {
+		return c.CSP
+	}
+	ancestors := "'none'"
+	if c.frameOptions() == "SAMEORIGIN" {
+		ancestors = "'self'"
+	}
+	img := append([]string{"'self'", "data:", "https://image.tmdb.org"}, c.ImgSources...)
+	return strings.Join([]string{
+		"default-src 'self'",
+		"img-src " + strings.Join(img, " "),
+		"script-src 'self' https://cdn.tailwindcss.com",
+		"style-src 'self' 'unsafe-inline'",
+		"connect-src 'self'",
+		"form-action 'self'",
+		"base-uri 'self'",
+		"object-src 'none'",
+		"frame-ancestors " + ancestors,
+	}, "; ")
+}
+
+func (c SecurityConfig) frameOptions() string {
+	if c.FrameOptions == "" {
+		return "DENY"
+	}
+	return strings.ToUpper(c.FrameOptions)
+}
+
+// SecurityHeaders returns middleware setting CSP, HSTS, X-Frame-Options, and
+// the other hardening headers per cfg.
+func SecurityHeaders(cfg SecurityConfig) (func(http.Handler) http.Handler, error) {
+	switch cfg.frameOptions() {
+	case "DENY", "SAMEORIGIN":
+	default:
+		return nil, fmt.Errorf("invalid frame options %q (want DENY or SAMEORIGIN)", cfg.FrameOptions)
+	}
+	if cfg.HSTSSeconds < 0 {
+		return nil, fmt.Errorf("invalid HSTS max-age %d", cfg.HSTSSeconds)
+	}
+	s := secure.New(secure.Options{
+		IsDevelopment:           cfg.Development,
+		SSLRedirect:             false,
+		SSLProxyHeaders:         map[string]string{"X-Forwarded-Proto": "https"},
+		STSSeconds:              cfg.HSTSSeconds,
+		STSIncludeSubdomains:    true,
+		STSPreload:              true,
+		CustomFrameOptionsValue: cfg.frameOptions(),
+		ContentTypeNosniff:      true,
+		BrowserXssFilter:        true,
+		ContentSecurityPolicy:   cfg.policy(),
+		ReferrerPolicy:          "no-referrer",
+		PermissionsPolicy:       "geolocation=(), midi=(), sync-xhr=(), microphone=(), camera=(), magnetometer=(), gyroscope=(), fullscreen=(), payment=(), usb=()",
+	})
+	return s.Handler, nil
+}
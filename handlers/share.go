@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/plex"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/signedurl"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	// shareFor is how long a share link works.
+	shareFor = 7 * 24 * time.Hour
+	// posterLinkFor is how long a signed poster link works at least. Links
+	// expire on the hour, so a page rendered twice in the same hour gets the
+	// same, browser-cacheable, poster URLs.
+	posterLinkFor = 24 * time.Hour
+	// plexImagePrefix is where the image proxy is mounted.
+	plexImagePrefix = "/plex/image"
+)
+
+// PosterSigner returns a templates.SignPoster that sends posters hosted on
+// the Plex server through the image proxy with signed links, so pages never
+// carry the Plex host or token. Other posters pass through unchanged.
+func PosterSigner(signer *signedurl.Signer, p *plex.Client) func(string) string {
+	return func(poster string) string {
+		path := p.PlexPath(poster)
+		if path == "" {
+			return poster
+		}
+		expires := time.Now().Truncate(time.Hour).Add(time.Hour + posterLinkFor)
+		return signer.Sign(plexImagePrefix+path, expires)
+	}
+}
+
+// HandlePlexImage serves an image from the Plex server at the path after
+// /plex/image, for a link signed by PosterSigner.
+func HandlePlexImage(signer *signedurl.Signer, p *plex.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if err := signer.Verify(req.URL.Path, req.URL.Query(), time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		body, contentType, err := p.Image(ctx, "/"+chi.URLParam(req, "*"))
+		if errors.Is(err, plex.ErrNotImage) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to proxy Plex image", "path", req.URL.Path, zap.Error(err))
+			http.Error(w, "image unavailable", http.StatusBadGateway)
+			return
+		}
+		defer func() { _ = body.Close() }()
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		if _, err := io.Copy(w, body); err != nil {
+			logging.FromContext(ctx).Debugw("Plex image copy ended early", zap.Error(err))
+		}
+	}
+}
+
+// HandleShareDate issues a share link to a day's picks that works for a week
+// without signing in. Forms are sent to the link, where it can be copied from
+// the address bar; JSON clients get {"date", "url", "expires"}.
+func HandleShareDate(signer *signedurl.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		date := chi.URLParam(req, "date")
+		if err := validation.ValidateDate(date); err != nil {
+			if errors.Is(err, validation.ErrFutureDate) {
+				err = errors.New("only days that have begun can be shared")
+			}
+			writeError(w, req, err.Error(), http.StatusBadRequest)
+			return
+		}
+		expires := time.Now().Add(shareFor).Truncate(time.Second)
+		link := signer.Sign("/share/"+date, expires)
+		logging.FromContext(req.Context()).Infow("Share link issued", "date", date, "expires", expires)
+		if wantsJSON(req) {
+			writeJSON(req.Context(), w, v1.Share{Date: date, URL: link, Expires: expires})
+			return
+		}
+		http.Redirect(w, req, link, http.StatusSeeOther)
+	}
+}
+
+// HandleShare shows a shared day's picks, read-only, to anyone holding an
+// unexpired link from HandleShareDate.
+func HandleShare(r *recommend.Recommender, signer *signedurl.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch err := signer.Verify(req.URL.Path, req.URL.Query(), time.Now()); {
+		case errors.Is(err, signedurl.ErrExpired):
+			writeError(w, req, "This share link has expired. Ask for a new one.", http.StatusGone)
+			return
+		case err != nil:
+			writeError(w, req, "This share link isn't valid.", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Referrer-Policy", "no-referrer") // keep the link out of other sites' logs
+		w.Header().Set("X-Robots-Tag", "noindex")
+		serveDate(w, req, r, true)
+	}
+}
+
+// shareExpiry reads a share link's expiry for display.
+func shareExpiry(req *http.Request) time.Time {
+	unix, _ := strconv.ParseInt(req.URL.Query().Get(signedurl.ExpiresParam), 10, 64)
+	return time.Unix(unix, 0).UTC()
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// similarData is the view model for similar.html.
+type similarData struct {
+	Like   models.Recommendation
+	Titles []models.Recommendation
+}
+
+// HandleMoreLikeThis shows up to five library titles like the movie or show
+// /similar/{type}/{id} (type "movie" or "tvshow", id its library ID), each
+// with a reason. They are worked out on request and not saved as picks.
+func HandleMoreLikeThis(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		typ := chi.URLParam(req, "type")
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil || (typ != models.TypeMovie && typ != models.TypeTVShow) {
+			writeError(w, req, "That title isn't in the library.", http.StatusNotFound)
+			return
+		}
+		like, titles, err := r.MoreLikeThis(ctx, typ, uint(id), time.Now())
+		switch {
+		case errors.Is(err, recommend.ErrTitleNotFound):
+			writeError(w, req, "That title isn't in the library.", http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(ctx).Errorw("Failed to find similar titles", "type", typ, "id", id, zap.Error(err))
+			writeError(w, req, "We couldn't find similar titles. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		if negotiate(w, req) {
+			out := v1.Similar{Like: toAPIRecommendation(like), Titles: make([]v1.Recommendation, 0, len(titles))}
+			for _, t := range titles {
+				out.Titles = append(out.Titles, toAPIRecommendation(t))
+			}
+			writeJSON(ctx, w, out)
+			return
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "similar.html", "card.html"}, similarData{Like: like, Titles: titles})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"go.uber.org/zap"
+)
+
+// snoozeRequest is the JSON body of POST /picks/{id}/snooze.
+type snoozeRequest struct {
+	Days int `json:"days"`
+}
+
+func (s *snoozeRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if s.Days == 0 {
+		s.Days = recommend.DefaultSnoozeDays
+	}
+	if s.Days < 1 || s.Days > recommend.MaxSnoozeDays {
+		errs.Add("days", "must be between 1 and 90")
+	}
+	return errs
+}
+
+// HandleSnooze is "not tonight, remind me later" for the pick
+// /picks/{id}/snooze: its title sits out for the given number of days
+// (default a week) and is favored once they pass. Forms post a days field
+// and are sent back to the pick's day; JSON clients post {"days": 3} and get
+// {"title", "snoozed_until"} back.
+func HandleSnooze(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeError(w, req, "That pick doesn't exist.", http.StatusNotFound)
+			return
+		}
+
+		asJSON := strings.Contains(req.Header.Get("Content-Type"), "application/json")
+		var body snoozeRequest
+		if asJSON {
+			if err := validation.DecodeJSON(w, req, 1024, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+		} else {
+			if v := req.PostFormValue("days"); v != "" {
+				if body.Days, err = strconv.Atoi(v); err != nil {
+					writeError(w, req, "Choose how many days to snooze for.", http.StatusBadRequest)
+					return
+				}
+			}
+			if errs := body.Validate(); len(errs) > 0 {
+				writeError(w, req, "Snooze for between 1 and 90 days.", http.StatusBadRequest)
+				return
+			}
+		}
+
+		rec, err := r.SnoozePick(ctx, uint(id), body.Days, time.Now())
+		switch {
+		case errors.Is(err, recommend.ErrPickNotFound):
+			writeError(w, req, "That pick doesn't exist.", http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(ctx).Errorw("Failed to snooze pick", "id", id, zap.Error(err))
+			writeError(w, req, "We couldn't snooze that pick. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(ctx).Infow("Snoozed pick", "title", rec.Title, "days", body.Days)
+		if asJSON {
+			writeJSON(ctx, w, map[string]string{"title": rec.Title, "snoozed_until": v1.Date(*rec.SnoozedUntil)})
+			return
+		}
+		http.Redirect(w, req, "/date/"+v1.Date(rec.Date), http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// spinData is the view model for spin.html.
+type spinData struct {
+	Date      time.Time
+	Picks     []models.Recommendation
+	Landed    *models.Recommendation // where the last spin landed; nil before a spin
+	Weighted  bool
+	CSRFToken string
+}
+
+// HandleSpin serves the spin-the-wheel page for today's picks (GET) and spins
+// it (POST). A spin lands on one pick at random, in proportion to the picks'
+// scores when the weighted field or query parameter is set, and counts as a
+// strong household signal for that title. Form posts redirect to the page,
+// which reveals the pick with an animation; JSON clients get the pick.
+func HandleSpin(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		l := logging.FromContext(ctx)
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		weighted := req.FormValue("weighted") != ""
+
+		if req.Method == http.MethodPost {
+			rec, err := r.Spin(ctx, today, weighted)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeError(w, req, "There are no picks to spin yet today. Please check back later.", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				l.Errorw("Failed to spin", zap.Error(err))
+				writeError(w, req, "We couldn't spin the wheel. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			if wantsJSON(req) {
+				writeJSON(ctx, w, v1.Spin{Date: v1.Date(today), Weighted: weighted, Pick: toAPIRecommendation(rec)})
+				return
+			}
+			to := "/spin?landed=" + strconv.FormatUint(uint64(rec.ID), 10)
+			if weighted {
+				to += "&weighted=1"
+			}
+			http.Redirect(w, req, to, http.StatusSeeOther)
+			return
+		}
+
+		recs, err := r.GetRecommendationsForDate(ctx, today)
+		if err != nil {
+			l.Errorw("Failed to get today's recommendations", zap.Error(err))
+			writeError(w, req, "We couldn't load today's picks. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		if len(recs) == 0 {
+			writeError(w, req, "There are no picks to spin yet today. Please check back later.", http.StatusNotFound)
+			return
+		}
+		data := spinData{Date: today, Picks: recs, Weighted: weighted, CSRFToken: csrfToken(req)}
+		if id, err := strconv.ParseUint(req.URL.Query().Get("landed"), 10, 64); err == nil {
+			for i := range recs {
+				if uint64(recs[i].ID) == id {
+					data.Landed = &recs[i]
+				}
+			}
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "spin.html"}, data)
+	}
+}
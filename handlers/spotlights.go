@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// apiSpotlight is a spotlight as /admin/spotlights reports it.
+type apiSpotlight struct {
+	Date   string `json:"date"`
+	Person string `json:"person"`
+	Auto   bool   `json:"auto"` // chosen by the weekly rotation
+}
+
+func toAPISpotlight(s models.Spotlight) apiSpotlight {
+	return apiSpotlight{Date: s.Date.UTC().Format("2006-01-02"), Person: s.Person, Auto: s.Auto}
+}
+
+// spotlightRequest is the POST /admin/spotlights body.
+type spotlightRequest struct {
+	Date   string `json:"date"`
+	Person string `json:"person"`
+
+	date time.Time
+}
+
+func (s *spotlightRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if d, err := time.Parse("2006-01-02", s.Date); err != nil {
+		errs.Add("date", "must be YYYY-MM-DD")
+	} else if d.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		errs.Add("date", "must be today or later")
+	} else {
+		s.date = d
+	}
+	if s.Person == "" {
+		errs.Add("person", "is required")
+	} else if len(s.Person) > 255 {
+		errs.Add("person", "must be at most 255 bytes")
+	}
+	return errs
+}
+
+// HandleSpotlights lists today's and later spotlights on GET as
+// {"spotlights": [...]}, and on POST themes a day around a director or
+// actor with {"date": "2026-11-07", "person": "Denzel Washington"}.
+func HandleSpotlights(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		if req.Method == http.MethodPost {
+			var body spotlightRequest
+			if err := validation.DecodeJSON(w, req, 4096, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+			var before any
+			if prev, err := r.Spotlights(ctx, body.date); err == nil && len(prev) > 0 && prev[0].Date.Equal(body.date) {
+				before = toAPISpotlight(prev[0])
+			}
+			s, err := r.SetSpotlight(ctx, body.date, body.Person)
+			switch {
+			case errors.Is(err, recommend.ErrSpotlightUnknown):
+				writeJSONError(ctx, w, err.Error(), http.StatusNotFound)
+				return
+			case err != nil:
+				l.Errorw("Failed to set spotlight", zap.Error(err))
+				writeJSONError(ctx, w, "failed to set spotlight", http.StatusInternalServerError)
+				return
+			}
+			recordAudit(ctx, "spotlight.set", "spotlight "+body.Date, before, toAPISpotlight(s))
+			writeJSON(ctx, w, toAPISpotlight(s))
+			return
+		}
+
+		spotlights, err := r.Spotlights(ctx, time.Now())
+		if err != nil {
+			l.Errorw("Failed to list spotlights", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list spotlights", http.StatusInternalServerError)
+			return
+		}
+		out := make([]apiSpotlight, 0, len(spotlights))
+		for _, s := range spotlights {
+			out = append(out, toAPISpotlight(s))
+		}
+		writeJSON(ctx, w, map[string][]apiSpotlight{"spotlights": out})
+	}
+}
+
+// HandleDeleteSpotlight clears the spotlight for /admin/spotlights/{date}.
+func HandleDeleteSpotlight(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		date, err := time.Parse("2006-01-02", chi.URLParam(req, "date"))
+		if err != nil {
+			writeJSONError(ctx, w, "invalid date; expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		s, found, err := r.DeleteSpotlight(ctx, date)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to delete spotlight", "date", date, zap.Error(err))
+			writeJSONError(ctx, w, "failed to delete spotlight", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeJSONError(ctx, w, "no spotlight on that date", http.StatusNotFound)
+			return
+		}
+		recordAudit(ctx, "spotlight.delete", "spotlight "+date.Format("2006-01-02"), toAPISpotlight(s), nil)
+		writeJSON(ctx, w, map[string]string{"status": "deleted"})
+	}
+}
@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the Go time layout pages render full dates with (the "date"
+// template function). Set once at startup from DATE_FORMAT.
+var DateLayout = dateFormats["us"]
+
+// WeekStart is the first day of the week in calendars and week groupings:
+// Sunday for US weeks, Monday for ISO weeks. Set once at startup from
+// WEEK_START.
+var WeekStart = time.Sunday
+
+// dateFormats are the named DATE_FORMAT styles.
+var dateFormats = map[string]string{
+	"us":   "January 2, 2006",
+	"intl": "2 January 2006",
+	"iso":  "2006-01-02",
+}
+
+// ParseDateFormat returns the layout for a DATE_FORMAT value: one of the
+// named styles "us" (January 2, 2006), "intl" (2 January 2006), or "iso"
+// (2006-01-02), or a Go time layout containing the year 2006.
+func ParseDateFormat(s string) (string, error) {
+	if layout, ok := dateFormats[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return layout, nil
+	}
+	if strings.Contains(s, "2006") {
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid date format %q (want us, intl, iso, or a Go layout such as \"Jan 2, 2006\")", s)
+}
+
+// ParseWeekStart returns the weekday a WEEK_START value names: "sunday"
+// (US weeks) or "monday" (ISO weeks).
+func ParseWeekStart(s string) (time.Weekday, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "sunday", "sun", "us":
+		return time.Sunday, nil
+	case "monday", "mon", "iso":
+		return time.Monday, nil
+	}
+	return 0, fmt.Errorf("invalid week start %q (want sunday or monday)", s)
+}
+
+// StartOfWeek returns midnight on the first day of t's week per WeekStart.
+func StartOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	back := (int(day.Weekday()) - int(WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -back)
+}
+
+// formatDate renders t with DateLayout.
+func formatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
@@ -1,11 +1,88 @@
 package templates
 
-import "html/template"
+import (
+	"html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
 
-// ParseTemplates parses HTML templates from the embedded filesystem.
-// It takes a variadic list of template file paths and returns a parsed template
-// or an error if parsing fails.
+// FallbackPosterURL, when non-empty, is shown for titles without a poster in
+// place of the generated per-title placeholder. Set once at startup from
+// FALLBACK_POSTER_URL.
+var FallbackPosterURL string
+
+// SignPoster, when non-nil, turns a stored poster URL into one browsers can
+// load: Plex-hosted posters become signed links through the image proxy.
+// Set once at startup.
+var SignPoster func(poster string) string
+
+// Dir, when non-empty, is a directory templates are read from on every
+// render instead of the embedded FS, so edits show on refresh. Set once at
+// startup in DEV_MODE.
+var Dir string
+
+// parsed caches templates parsed from the embedded FS, keyed by file list.
+var parsed sync.Map
+
+// posterURL returns the URL a card should load for a title: its own poster
+// when known, else the configured fallback, else a generated placeholder.
+func posterURL(poster, title string, year int) string {
+	if poster != "" {
+		return PublicPosterURL(poster)
+	}
+	if FallbackPosterURL != "" {
+		return FallbackPosterURL
+	}
+	q := url.Values{"title": {title}}
+	if year > 0 {
+		q.Set("year", strconv.Itoa(year))
+	}
+	return "/placeholder.svg?" + q.Encode()
+}
+
+// formatRating renders a 10-point rating, or "Unrated" when it's unknown.
+func formatRating(r *float64) string {
+	if r == nil {
+		return "Unrated"
+	}
+	return strconv.FormatFloat(*r, 'f', 1, 64) + "/10"
+}
+
+// PublicPosterURL returns the URL to give browsers and API clients for a
+// stored poster URL, signing it through SignPoster when set.
+func PublicPosterURL(poster string) string {
+	if poster == "" || SignPoster == nil {
+		return poster
+	}
+	return SignPoster(poster)
+}
+
+// ParseTemplates parses HTML templates from the embedded filesystem, or from
+// Dir when set. It takes a variadic list of template file paths and returns a
+// parsed template or an error if parsing fails. Embedded templates never
+// change, so each file list is parsed once and shared.
 func ParseTemplates(files ...string) (*template.Template, error) {
+	if Dir != "" {
+		return parse(os.DirFS(Dir), files)
+	}
+	key := strings.Join(files, "\x00")
+	if t, ok := parsed.Load(key); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := parse(FS, files)
+	if err != nil {
+		return nil, err
+	}
+	parsed.Store(key, t)
+	return t, nil
+}
+
+// parse parses files from fsys with the templates' helper functions.
+func parse(fsys fs.FS, files []string) (*template.Template, error) {
 	funcMap := template.FuncMap{
 		"add": func(a, b int) int {
 			return a + b
@@ -13,7 +90,13 @@ func ParseTemplates(files ...string) (*template.Template, error) {
 		"subtract": func(a, b int) int {
 			return a - b
 		},
+		"multiply": func(a, b int) int {
+			return a * b
+		},
+		"rating": formatRating,
+		"poster": posterURL,
+		"date":   formatDate,
 	}
 
-	return template.New("").Funcs(funcMap).ParseFS(FS, files...)
+	return template.New("").Funcs(funcMap).ParseFS(fsys, files...)
 }
@@ -0,0 +1,80 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemplates_cachesEmbedded(t *testing.T) {
+	a, err := ParseTemplates("base.html", "error.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseTemplates("base.html", "error.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Error("embedded templates should be parsed once per file list")
+	}
+}
+
+func TestParseTemplates_dir(t *testing.T) {
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = "" })
+	path := filepath.Join(Dir, "page.html")
+	render := func() string {
+		tmpl, err := ParseTemplates("page.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		if err := tmpl.ExecuteTemplate(&sb, "page.html", nil); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+
+	for _, body := range []string{"before", "after"} {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := render(); got != body {
+			t.Errorf("render = %q, want %q from disk", got, body)
+		}
+	}
+}
+
+func TestDateStyle(t *testing.T) {
+	if l, err := ParseDateFormat("ISO"); err != nil || l != "2006-01-02" {
+		t.Errorf("ParseDateFormat(ISO) = %q, %v", l, err)
+	}
+	if l, err := ParseDateFormat("Mon Jan 2 2006"); err != nil || l != "Mon Jan 2 2006" {
+		t.Errorf("custom layout = %q, %v", l, err)
+	}
+	if _, err := ParseDateFormat("french"); err == nil {
+		t.Error("unknown style should be rejected")
+	}
+	if _, err := ParseWeekStart("wednesday"); err == nil {
+		t.Error("only sunday and monday weeks are supported")
+	}
+
+	WeekStart, _ = ParseWeekStart("monday")
+	t.Cleanup(func() { WeekStart = time.Sunday })
+	sun := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	if got := StartOfWeek(sun); !got.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ISO week of Sunday Oct 18 starts %v, want Monday Oct 12", got)
+	}
+}
+
+func TestFormatRating(t *testing.T) {
+	if got := formatRating(nil); got != "Unrated" {
+		t.Errorf("formatRating(nil) = %q, want Unrated", got)
+	}
+	if got := formatRating(new(7.94)); got != "7.9/10" {
+		t.Errorf("formatRating(7.94) = %q, want 7.9/10", got)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/auth"
+	"github.com/icco/recommender/lib/validation"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+// apiUser is a web UI user as /admin/users reports it.
+type apiUser struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Password     bool      `json:"password"` // can sign in with a password
+	PlexUsername string    `json:"plex_username,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func toAPIUser(u models.User) apiUser {
+	out := apiUser{ID: u.ID, Name: u.Name, Password: u.PasswordHash != "", CreatedAt: u.CreatedAt}
+	if u.PlexUsername != nil {
+		out.PlexUsername = *u.PlexUsername
+	}
+	return out
+}
+
+// userRequest is the POST /admin/users body.
+type userRequest struct {
+	Name         string  `json:"name"`
+	Password     string  `json:"password"`
+	PlexUsername *string `json:"plex_username"`
+}
+
+func (u *userRequest) Validate() validation.FieldErrors {
+	var errs validation.FieldErrors
+	if u.Name == "" {
+		errs.Add("name", "is required")
+	} else if len(u.Name) > 64 {
+		errs.Add("name", "must be at most 64 bytes")
+	}
+	if u.Password != "" && (len(u.Password) < auth.MinPasswordLen || len(u.Password) > auth.MaxPasswordLen) {
+		errs.Add("password", fmt.Sprintf("must be %d to %d bytes", auth.MinPasswordLen, auth.MaxPasswordLen))
+	}
+	if u.PlexUsername != nil && len(*u.PlexUsername) > 128 {
+		errs.Add("plex_username", "must be at most 128 bytes")
+	}
+	return errs
+}
+
+// HandleUsers lists web UI users on GET as {"users": [...]}, and on POST
+// adds or updates one with {"name": "Sam", "password": "...",
+// "plex_username": "sam"}. An omitted password or plex_username is left as
+// it was; plex_username "" unlinks the Plex account. Updating a user signs
+// them out everywhere.
+func HandleUsers(store *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		l := logging.FromContext(ctx)
+		if req.Method == http.MethodPost {
+			var body userRequest
+			if err := validation.DecodeJSON(w, req, 4096, &body); err != nil {
+				validation.WriteRequestError(ctx, w, err)
+				return
+			}
+			prev, err := store.UserByName(ctx, body.Name)
+			if err != nil {
+				l.Errorw("Failed to find user", zap.Error(err))
+				writeJSONError(ctx, w, "failed to save user", http.StatusInternalServerError)
+				return
+			}
+			u, err := store.SetUser(ctx, body.Name, body.Password, body.PlexUsername)
+			if err != nil {
+				l.Errorw("Failed to save user", zap.Error(err))
+				writeJSONError(ctx, w, "failed to save user", http.StatusInternalServerError)
+				return
+			}
+			var before any
+			if prev != nil {
+				before = toAPIUser(*prev)
+			}
+			recordAudit(ctx, "user.set", fmt.Sprintf("user %d", u.ID), before, toAPIUser(u))
+			writeJSON(ctx, w, toAPIUser(u))
+			return
+		}
+
+		users, err := store.Users(ctx)
+		if err != nil {
+			l.Errorw("Failed to list users", zap.Error(err))
+			writeJSONError(ctx, w, "failed to list users", http.StatusInternalServerError)
+			return
+		}
+		out := make([]apiUser, 0, len(users))
+		for _, u := range users {
+			out = append(out, toAPIUser(u))
+		}
+		writeJSON(ctx, w, map[string][]apiUser{"users": out})
+	}
+}
+
+// HandleDeleteUser removes the user /admin/users/{id} and signs them out.
+func HandleDeleteUser(store *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeJSONError(ctx, w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+		u, found, err := store.DeleteUser(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to delete user", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to delete user", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			writeJSONError(ctx, w, "user not found", http.StatusNotFound)
+			return
+		}
+		recordAudit(ctx, "user.delete", fmt.Sprintf("user %d", u.ID), toAPIUser(u), nil)
+		writeJSON(ctx, w, map[string]string{"status": "deleted"})
+	}
+}
+
+// HandleEndUserSessions signs the user /admin/users/{id} out everywhere,
+// answering {"ended": n}.
+func HandleEndUserSessions(store *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+		if err != nil {
+			writeJSONError(ctx, w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+		n, err := store.EndUserSessions(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to end sessions", "id", id, zap.Error(err))
+			writeJSONError(ctx, w, "failed to end sessions", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, "user.signout", fmt.Sprintf("user %d", id), nil, map[string]int64{"ended": n})
+		writeJSON(ctx, w, map[string]int64{"ended": n})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+	"github.com/icco/recommender/lib/recommend"
+	"go.uber.org/zap"
+)
+
+// The HTML pages answer with the v1 API's typed views instead when the
+// client asks for JSON (see wantsJSON). Each is built from the view model its
+// template renders, so both formats always agree.
+
+func (d homeData) api() v1.Day {
+	out := v1.Day{Date: v1.Date(d.Date), Theme: d.Theme, Mood: string(d.Mood), With: d.With, Sections: make([]v1.Section, 0, len(d.Sections))}
+	out.Note = d.Note
+	if !d.PausedUntil.IsZero() {
+		out.PausedUntil = v1.Date(d.PausedUntil)
+	}
+	for _, s := range d.Sections {
+		sec := v1.Section{Key: s.Key, Title: s.Title, Total: s.Total, Page: s.Page, TotalPages: s.TotalPages, Recommendations: make([]v1.Recommendation, 0, len(s.Recommendations))}
+		for _, rec := range s.Recommendations {
+			sec.Recommendations = append(sec.Recommendations, toAPIRecommendation(rec))
+		}
+		out.Sections = append(out.Sections, sec)
+	}
+	return out
+}
+
+func (d datesData) api() v1.Dates {
+	out := v1.Dates{Dates: make([]string, 0, len(d.Dates)), Page: d.Page, Size: d.PageSize, Total: d.Total, TotalPages: d.TotalPages}
+	for _, date := range d.Dates {
+		out.Dates = append(out.Dates, v1.Date(date))
+	}
+	out.Calendar = v1.Calendar{Month: d.Calendar.Month.Format("2006-01"), WeekStart: d.Calendar.WeekStart.String(), Days: []string{}}
+	out.Activity = []v1.Activity{}
+	for _, week := range d.Heatmap.Weeks {
+		for _, day := range week {
+			if day.Picks > 0 || day.Failed {
+				out.Activity = append(out.Activity, v1.Activity{Date: v1.Date(day.Date), Picks: day.Picks, Failed: day.Failed})
+			}
+		}
+	}
+	for _, week := range d.Calendar.Weeks {
+		for _, day := range week {
+			if day.InMonth && day.HasPicks {
+				out.Calendar.Days = append(out.Calendar.Days, v1.Date(day.Date))
+			}
+		}
+	}
+	return out
+}
+
+func (p statsPage) api() v1.Stats {
+	out := v1.Stats{
+		TotalRecommendations: p.TotalRecommendations,
+		TotalMovies:          p.TotalMovies,
+		TotalTVShows:         p.TotalTVShows,
+		Watched:              p.Watched,
+		Ignored:              p.Ignored,
+		FirstDate:            v1.Time(p.FirstDate),
+		LastDate:             v1.Time(p.LastDate),
+		AverageDaily:         p.AverageDailyRecommendations,
+		From:                 p.From,
+		To:                   p.To,
+		Genres:               apiGenres(p.GenreDistribution),
+		MovieGenres:          apiGenres(p.MovieGenres),
+		TVShowGenres:         apiGenres(p.TVShowGenres),
+		CachedMovies:         p.TotalCachedMovies,
+		CachedTVShows:        p.TotalCachedTVShows,
+		LastCacheUpdate:      v1.Time(p.LastCacheUpdate),
+		Plex: v1.Plex{
+			Reachable: p.Plex.Reachable,
+			Route:     p.Plex.Route,
+			CheckedAt: v1.Time(p.Plex.CheckedAt),
+			Since:     v1.Time(p.Plex.Since),
+			Error:     p.Plex.Err,
+		},
+	}
+	out.Abandoned = v1.NewAbandonedShows(p.Abandoned)
+	out.MissingDays = make([]string, 0, len(p.MissingDays))
+	for _, d := range p.MissingDays {
+		out.MissingDays = append(out.MissingDays, v1.Date(d))
+	}
+	return out
+}
+
+func apiGenres(in []recommend.GenreCount) []v1.GenreCount {
+	out := make([]v1.GenreCount, 0, len(in))
+	for _, g := range in {
+		out = append(out, v1.GenreCount{Genre: g.Genre, Count: g.Count})
+	}
+	return out
+}
+
+// negotiate reports whether req wants JSON rather than the HTML page, and
+// marks the response as varying by Accept so caches keep both.
+func negotiate(w http.ResponseWriter, req *http.Request) bool {
+	w.Header().Add("Vary", "Accept")
+	return wantsJSON(req)
+}
+
+// JSONOnly makes the pages behind it answer with their JSON views whatever
+// the client's Accept header says, as the /api/v1 routes do.
+func JSONOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", "application/json")
+		next.ServeHTTP(w, req)
+	})
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(ctx context.Context, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.FromContext(ctx).Errorw("Failed to encode JSON response", zap.Error(err))
+	}
+}
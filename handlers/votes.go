@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// voterCookie remembers who is voting on this device.
+const voterCookie = "voter"
+
+// votePollInterval is how often the vote stream checks for new votes. The
+// stream reads the database rather than an in-process feed so it sees votes
+// cast through any replica.
+var votePollInterval = 2 * time.Second
+
+// votePick is one of the day's picks with its vote count.
+type votePick struct {
+	models.Recommendation
+	Votes int
+}
+
+// voteData is the view model for vote.html.
+type voteData struct {
+	Date      time.Time
+	Picks     []votePick
+	Voter     string
+	Mine      uint // the pick Voter chose today; 0 = none yet
+	Total     int
+	CSRFToken string
+}
+
+// apiTally is a vote stream event: today's counts by pick ID.
+type apiTally struct {
+	Date  string         `json:"date"`
+	Total int            `json:"total"`
+	Votes map[string]int `json:"votes"`
+}
+
+func toAPITally(date time.Time, t recommend.Tally) apiTally {
+	out := apiTally{Date: date.Format("2006-01-02"), Total: t.Total, Votes: make(map[string]int, len(t.Votes))}
+	for id, n := range t.Votes {
+		out.Votes[strconv.FormatUint(uint64(id), 10)] = n
+	}
+	return out
+}
+
+// HandleVote serves the household voting page for today's picks (GET) and
+// records a member's favorite (POST, form fields voter and pick). The name
+// is remembered on the device, and each member's vote can be moved until
+// the day ends. Votes feed future generation as household signals.
+func HandleVote(r *recommend.Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+		l := logging.FromContext(ctx)
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+
+		if req.Method == http.MethodPost {
+			req.Body = http.MaxBytesReader(w, req.Body, 64<<10)
+			if err := req.ParseForm(); err != nil {
+				writeError(w, req, "We couldn't read your vote.", http.StatusBadRequest)
+				return
+			}
+			voter := strings.TrimSpace(req.PostForm.Get("voter"))
+			if voter == "" || len(voter) > recommend.MaxVoterLen {
+				writeError(w, req, "Enter your name (up to 64 characters) to vote.", http.StatusBadRequest)
+				return
+			}
+			pick, err := strconv.ParseUint(req.PostForm.Get("pick"), 10, 64)
+			if err != nil {
+				writeError(w, req, "Choose one of today's picks.", http.StatusBadRequest)
+				return
+			}
+			switch err := r.CastVote(ctx, today, voter, uint(pick)); {
+			case errors.Is(err, recommend.ErrVoteNotOnDay):
+				writeError(w, req, "That isn't one of today's picks; reload the page and try again.", http.StatusBadRequest)
+				return
+			case err != nil:
+				l.Errorw("Failed to record vote", zap.Error(err))
+				writeError(w, req, "We couldn't record your vote. Please try again later.", http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     voterCookie,
+				Value:    voter,
+				Path:     "/vote",
+				MaxAge:   365 * 24 * 60 * 60,
+				HttpOnly: true,
+				Secure:   req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https",
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, req, "/vote", http.StatusSeeOther)
+			return
+		}
+
+		recs, err := r.GetRecommendationsForDate(ctx, today)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, req, "There are no picks to vote on yet today. Please check back later.", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Errorw("Failed to get today's recommendations", zap.Error(err))
+			writeError(w, req, "We couldn't load today's picks. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		tally, err := r.VoteTally(ctx, today)
+		if err != nil {
+			l.Errorw("Failed to count votes", zap.Error(err))
+			writeError(w, req, "We couldn't load today's votes. Please try again later.", http.StatusInternalServerError)
+			return
+		}
+		data := voteData{Date: today, Total: tally.Total, CSRFToken: csrfToken(req)}
+		if c, err := req.Cookie(voterCookie); err == nil {
+			data.Voter = c.Value
+		} else if u := currentUser(ctx); u != nil {
+			data.Voter = u.Name
+		}
+		data.Mine = tally.By[data.Voter]
+		for _, rec := range recs {
+			data.Picks = append(data.Picks, votePick{Recommendation: rec, Votes: tally.Votes[rec.ID]})
+		}
+		renderTemplate(ctx, w, []string{baseTemplate, "vote.html"}, data)
+	}
+}
+
+// HandleVoteStream streams today's vote counts as server-sent "tally" events
+// ({"date", "total", "votes": {pick ID: count}}): one on connect, then one
+// whenever the counts change. Streams end when done is closed, so they don't
+// hold up a graceful shutdown.
+func HandleVoteStream(r *recommend.Recommender, done <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no") // let proxies pass events through
+
+		var last []byte
+		ticker := time.NewTicker(votePollInterval)
+		defer ticker.Stop()
+		for {
+			today := time.Now().UTC().Truncate(24 * time.Hour)
+			pollCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			tally, err := r.VoteTally(pollCtx, today)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Warnw("Failed to count votes for stream", zap.Error(err))
+			}
+			if err == nil {
+				b, _ := json.Marshal(toAPITally(today, tally)) // maps of ints always marshal
+				if !bytes.Equal(b, last) {
+					if _, err := fmt.Fprintf(w, "event: tally\ndata: %s\n\n", b); err != nil {
+						return
+					}
+					if err := rc.Flush(); err != nil {
+						return
+					}
+					last = b
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -1,5 +1,5 @@
 // Package anilist is a minimal AniList GraphQL client for a user's public anime
-// list and scores, used as a recommendation ranking signal.
+// list, scores, and completed titles, used as recommendation ranking signals.
 package anilist
 
 import (
@@ -25,22 +25,26 @@ func NewClient() *Client {
 	return &Client{URL: defaultURL, httpClient: &http.Client{Timeout: 30 * time.Second}}
 }
 
-// Entry is one rated anime from a user's list, score normalized to 0..10.
+// Entry is one rated or completed anime from a user's list, score
+// normalized to 0..10 (0 = unscored).
 type Entry struct {
-	Title string
-	Year  int
-	Score float64
+	Title     string
+	Year      int
+	Score     float64
+	Completed bool
 }
 
 const listQuery = `query($u:String){
   User(name:$u){ mediaListOptions { scoreFormat } }
   MediaListCollection(userName:$u, type:ANIME){ lists { entries {
     score
+    status
     media { seasonYear title { romaji english } }
   } } }
 }`
 
-// List returns the user's rated anime (score > 0) with scores normalized to 0..10.
+// List returns the user's rated (score > 0) and completed anime, scores
+// normalized to 0..10.
 func (c *Client) List(ctx context.Context, username string) ([]Entry, error) {
 	reqBody, err := json.Marshal(map[string]any{
 		"query":     listQuery,
@@ -77,8 +81,9 @@ func (c *Client) List(ctx context.Context, username string) ([]Entry, error) {
 			MediaListCollection struct {
 				Lists []struct {
 					Entries []struct {
-						Score float64 `json:"score"`
-						Media struct {
+						Score  float64 `json:"score"`
+						Status string  `json:"status"`
+						Media  struct {
 							SeasonYear int `json:"seasonYear"`
 							Title      struct {
 								Romaji  string `json:"romaji"`
@@ -98,7 +103,8 @@ func (c *Client) List(ctx context.Context, username string) ([]Entry, error) {
 	var entries []Entry
 	for _, l := range out.Data.MediaListCollection.Lists {
 		for _, e := range l.Entries {
-			if e.Score <= 0 {
+			completed := e.Status == "COMPLETED"
+			if e.Score <= 0 && !completed {
 				continue
 			}
 			title := e.Media.Title.English
@@ -109,9 +115,10 @@ func (c *Client) List(ctx context.Context, username string) ([]Entry, error) {
 				continue
 			}
 			entries = append(entries, Entry{
-				Title: title,
-				Year:  e.Media.SeasonYear,
-				Score: normalizeScore(e.Score, format),
+				Title:     title,
+				Year:      e.Media.SeasonYear,
+				Score:     normalizeScore(e.Score, format),
+				Completed: completed,
 			})
 		}
 	}
@@ -13,7 +13,8 @@ func TestList_normalizesScoresAndPicksTitle(t *testing.T) {
 		_, _ = w.Write([]byte(`{"data":{"User":{"mediaListOptions":{"scoreFormat":"POINT_100"}},
 			"MediaListCollection":{"lists":[{"entries":[
 				{"score":90,"media":{"seasonYear":2019,"title":{"romaji":"Kimetsu","english":"Demon Slayer"}}},
-				{"score":0,"media":{"seasonYear":2020,"title":{"romaji":"Unrated","english":null}}}
+				{"score":0,"media":{"seasonYear":2020,"title":{"romaji":"Unrated","english":null}}},
+				{"score":0,"status":"COMPLETED","media":{"seasonYear":2021,"title":{"romaji":"Finished"}}}
 			]}]}}}`))
 	}))
 	defer srv.Close()
@@ -24,8 +25,11 @@ func TestList_normalizesScoresAndPicksTitle(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(entries) != 1 {
-		t.Fatalf("expected 1 rated entry, got %d (%+v)", len(entries), entries)
+	if len(entries) != 2 {
+		t.Fatalf("expected the rated and the completed entry, got %d (%+v)", len(entries), entries)
+	}
+	if e := entries[1]; e.Title != "Finished" || !e.Completed || e.Score != 0 {
+		t.Errorf("bad completed entry: %+v", e)
 	}
 	if entries[0].Title != "Demon Slayer" || entries[0].Year != 2019 {
 		t.Errorf("bad title/year: %+v", entries[0])
@@ -0,0 +1,329 @@
+// Package v1 defines version 1 of the JSON API's response bodies. They are
+// built from the database models by the constructors here rather than
+// serialized from the models, so a schema change can't rename or drop a field
+// clients rely on, and internal row IDs stay out of responses. A version only
+// ever gains fields; anything incompatible belongs in a new package.
+package v1
+
+import (
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// DateLayout is how calendar days are written: ISO 8601, YYYY-MM-DD.
+const DateLayout = time.DateOnly
+
+// Date formats a calendar day.
+func Date(t time.Time) string {
+	return t.Format(DateLayout)
+}
+
+// Time is t in UTC for JSON (RFC 3339), or nil for the zero time so unknown
+// times are omitted.
+func Time(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// Score is how the scoring engine ranked a recommendation.
+type Score struct {
+	Rating        float64 `json:"rating"`
+	Recency       float64 `json:"recency"`
+	Affinity      float64 `json:"affinity"`
+	Novelty       float64 `json:"novelty"`
+	RuntimeFit    float64 `json:"runtime_fit"`
+	Watchlist     float64 `json:"watchlist"`
+	Total         float64 `json:"total"`
+	Rank          int     `json:"rank"` // among the day's eligible titles of its type
+	DiversitySwap bool    `json:"diversity_swap"`
+	Anniversary   float64 `json:"anniversary"`
+	Snoozed       float64 `json:"snoozed"`
+}
+
+// Recommendation is one pick.
+type Recommendation struct {
+	Title          string   `json:"title"`
+	Type           string   `json:"type"` // "movie" or "tvshow"
+	Slot           string   `json:"slot"`
+	Year           int      `json:"year"`
+	Rating         *float64 `json:"rating"` // null when unknown
+	Genre          string   `json:"genre"`
+	Runtime        int      `json:"runtime"`           // movies: minutes; 0 for TV
+	Seasons        int      `json:"seasons,omitempty"` // TV: number of seasons
+	PosterURL      string   `json:"poster_url"`
+	TMDbID         int      `json:"tmdb_id,omitempty"`
+	Explanation    string   `json:"explanation"`
+	Pinned         bool     `json:"pinned"`
+	Anniversary    int      `json:"anniversary,omitempty"`     // years since release, on a milestone anniversary
+	Episodes       int      `json:"episodes,omitempty"`        // TV: episodes that fit the nightly viewing window
+	EpisodeRuntime int      `json:"episode_runtime,omitempty"` // TV: typical episode length in minutes
+	SnoozedUntil   string   `json:"snoozed_until,omitempty"`   // the day a snoozed pick may come back
+	Score          *Score   `json:"score,omitempty"`           // absent on rows generated before scores were stored
+}
+
+// NewRecommendation is rec as clients see it. posterURL is the URL to give
+// them for its poster, which may differ from the stored one.
+func NewRecommendation(rec models.Recommendation, posterURL string) Recommendation {
+	out := Recommendation{
+		Title: rec.Title, Type: rec.Type, Slot: rec.Slot, Year: rec.Year,
+		Rating: rec.Rating, Genre: rec.Genre, Runtime: rec.Runtime, Seasons: rec.Seasons, PosterURL: posterURL,
+		TMDbID: rec.TMDbID, Explanation: rec.Explanation, Pinned: rec.Pinned, Anniversary: rec.Anniversary,
+		Episodes: rec.Episodes, EpisodeRuntime: rec.EpisodeRuntime,
+	}
+	if rec.SnoozedUntil != nil {
+		out.SnoozedUntil = Date(*rec.SnoozedUntil)
+	}
+	if s := rec.Score; s != nil {
+		out.Score = &Score{
+			Rating: s.Rating, Recency: s.Recency, Affinity: s.Affinity, Novelty: s.Novelty,
+			RuntimeFit: s.RuntimeFit, Watchlist: s.Watchlist, Anniversary: s.Anniversary, Snoozed: s.Snoozed,
+			Total: s.Total, Rank: s.Rank, DiversitySwap: s.DiversitySwap,
+		}
+	}
+	return out
+}
+
+// Recommendations is a day's picks, as /api/recommendations serves them.
+type Recommendations struct {
+	Date            string           `json:"date"`
+	Theme           string           `json:"theme,omitempty"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// Section is one section of a day page.
+type Section struct {
+	Key             string           `json:"key"`
+	Title           string           `json:"title"`
+	Total           int              `json:"total"`
+	Page            int              `json:"page"`
+	TotalPages      int              `json:"total_pages"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// Day is the JSON view of / and /date/{date}.
+type Day struct {
+	Date     string    `json:"date"`
+	Theme    string    `json:"theme,omitempty"`
+	Mood     string    `json:"mood,omitempty"`
+	With     []string  `json:"with,omitempty"` // the movie-night group the picks are ranked for
+	Sections []Section `json:"sections"`
+	// PausedUntil is the last day of the blackout covering this day, when
+	// generation was paused on purpose.
+	PausedUntil string `json:"paused_until,omitempty"`
+	Note        string `json:"note,omitempty"` // the day's journal note
+}
+
+// Calendar lists the days of a month that have recommendations.
+type Calendar struct {
+	Month     string   `json:"month"`      // YYYY-MM
+	WeekStart string   `json:"week_start"` // "Sunday" or "Monday"
+	Days      []string `json:"days"`
+}
+
+// Activity is one day of the /dates heatmap.
+type Activity struct {
+	Date   string `json:"date"`
+	Picks  int    `json:"picks"`
+	Failed bool   `json:"failed,omitempty"`
+}
+
+// Dates is the JSON view of /dates.
+type Dates struct {
+	Dates      []string `json:"dates"`
+	Page       int      `json:"page"`
+	Size       int      `json:"size"`
+	Total      int64    `json:"total"`
+	TotalPages int      `json:"total_pages"`
+	Calendar   Calendar `json:"calendar"`
+	// Activity lists the past year's days with picks or failing generation.
+	Activity []Activity `json:"activity"`
+}
+
+// GenreCount is how many recommendations had a genre.
+type GenreCount struct {
+	Genre string `json:"genre"`
+	Count int64  `json:"count"`
+}
+
+// Plex is whether the Plex server answered its last check.
+type Plex struct {
+	Reachable bool       `json:"reachable"`
+	Route     string     `json:"route,omitempty"`      // direct, remote, or relay
+	CheckedAt *time.Time `json:"checked_at,omitempty"` // absent before the first check
+	Since     *time.Time `json:"since,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// AbandonedShow is a show suggested for dropping. ID is the handle
+// /admin/shows/{id}/decision takes, the one row ID the API exposes.
+type AbandonedShow struct {
+	ID              uint       `json:"id"`
+	Title           string     `json:"title"`
+	Year            int        `json:"year"`
+	WatchedEpisodes int        `json:"watched_episodes"`
+	Episodes        int        `json:"episodes"`
+	LastViewedAt    *time.Time `json:"last_viewed_at,omitempty"`
+}
+
+// NewAbandonedShows is shows as clients see them.
+func NewAbandonedShows(shows []models.TVShow) []AbandonedShow {
+	out := make([]AbandonedShow, 0, len(shows))
+	for _, s := range shows {
+		a := AbandonedShow{
+			ID: s.ID, Title: s.Title, Year: s.Year,
+			WatchedEpisodes: s.WatchedEpisodes, Episodes: s.Episodes,
+		}
+		if s.LastViewedAt != nil {
+			a.LastViewedAt = Time(*s.LastViewedAt)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// Stats is the JSON view of /stats.
+type Stats struct {
+	TotalRecommendations int64           `json:"total_recommendations"`
+	TotalMovies          int64           `json:"total_movies"`
+	TotalTVShows         int64           `json:"total_tvshows"`
+	Watched              int64           `json:"watched"` // picks with feedback
+	Ignored              int64           `json:"ignored"`
+	FirstDate            *time.Time      `json:"first_date,omitempty"`
+	LastDate             *time.Time      `json:"last_date,omitempty"`
+	AverageDaily         float64         `json:"average_daily"`
+	From                 string          `json:"from,omitempty"` // the requested range
+	To                   string          `json:"to,omitempty"`
+	Genres               []GenreCount    `json:"genres"`
+	MovieGenres          []GenreCount    `json:"movie_genres"`
+	TVShowGenres         []GenreCount    `json:"tvshow_genres"`
+	CachedMovies         int64           `json:"cached_movies"`
+	CachedTVShows        int64           `json:"cached_tvshows"`
+	LastCacheUpdate      *time.Time      `json:"last_cache_update,omitempty"`
+	Plex                 Plex            `json:"plex"`
+	MissingDays          []string        `json:"missing_days"`
+	Abandoned            []AbandonedShow `json:"abandoned_shows"` // suggested for dropping
+}
+
+// Similar is a library title and others like it, generated on request and
+// not saved as picks.
+type Similar struct {
+	Like   Recommendation   `json:"like"`
+	Titles []Recommendation `json:"titles"`
+}
+
+// Preferences is what the household says it wants from its picks.
+type Preferences struct {
+	FavoriteGenres []string   `json:"favorite_genres"`
+	Moods          []string   `json:"moods"`
+	Lengths        []string   `json:"lengths"` // "short", "standard", "long" movies
+	Languages      []string   `json:"languages"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"` // absent until first saved
+}
+
+// NewPreferences is p as clients see it.
+func NewPreferences(p models.UserPreference) Preferences {
+	out := Preferences{
+		FavoriteGenres: p.FavoriteGenres, Moods: p.Moods, Lengths: p.Lengths, Languages: p.Languages,
+		UpdatedAt: Time(p.UpdatedAt),
+	}
+	for _, l := range []*[]string{&out.FavoriteGenres, &out.Moods, &out.Lengths, &out.Languages} {
+		if *l == nil {
+			*l = []string{}
+		}
+	}
+	return out
+}
+
+// Spin is where a spin of the day's picks landed.
+type Spin struct {
+	Date     string         `json:"date"`
+	Weighted bool           `json:"weighted"`
+	Pick     Recommendation `json:"pick"`
+}
+
+// Share is a signed, expiring link to a day's page.
+type Share struct {
+	Date    string    `json:"date"`
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// Job is background work queued or running.
+type Job struct {
+	Kind     string     `json:"kind"`  // e.g. "cache_update", "generate_recommendations"
+	Label    string     `json:"label"` // what it is doing, e.g. "Updating library"
+	Status   string     `json:"status"`
+	Progress int        `json:"progress"` // percent done while running
+	RunAt    *time.Time `json:"run_at"`   // when a queued job is due
+}
+
+// NewJob is j as clients see it, described by label.
+func NewJob(j models.Job, label string) Job {
+	return Job{Kind: j.Kind, Label: label, Status: j.Status, Progress: j.Progress, RunAt: Time(j.RunAt)}
+}
+
+// Jobs is the background work under way.
+type Jobs struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Run is one generation of a day's set and what produced it.
+type Run struct {
+	Date             string            `json:"date"`
+	Slot             string            `json:"slot"`   // "daily" or a time-of-day slot
+	Status           string            `json:"status"` // "running", "ok", or "error"
+	Attempts         int               `json:"attempts"`
+	Movies           int               `json:"movies"`
+	TVShows          int               `json:"tv_shows"`
+	Error            string            `json:"error,omitempty"`
+	Provider         string            `json:"provider"` // e.g. "gemini"; "none" without a model
+	Model            string            `json:"model"`    // "scoring-fallback" when the scorer picked
+	PromptVersion    string            `json:"prompt_version,omitempty"`
+	PromptCanary     string            `json:"prompt_canary,omitempty"` // canary rollout version when its prompts were used
+	ValidationFailed bool              `json:"validation_failed"`       // the model's reply was unparseable or named unknown titles
+	Strategy         string            `json:"strategy"`                // "model", "fallback", or "scored"
+	Build            string            `json:"build,omitempty"`
+	Config           *models.RunConfig `json:"config"` // null for runs recorded before snapshots
+	Context          string            `json:"context,omitempty"`
+	Theme            string            `json:"theme,omitempty"`
+	DurationMS       int64             `json:"duration_ms"`
+	UpdatedAt        *time.Time        `json:"updated_at"`
+}
+
+// NewRuns is runs as clients see them.
+func NewRuns(runs []models.GenerationRun) []Run {
+	out := make([]Run, len(runs))
+	for i, r := range runs {
+		out[i] = Run{
+			Date: Date(r.Date), Slot: r.Context, Status: r.Status, Attempts: r.Attempts,
+			Movies: r.MovieCount, TVShows: r.TVShowCount, Error: r.Error,
+			Provider: r.Provider, Model: r.Model, PromptVersion: r.PromptVersion, PromptCanary: r.PromptCanary,
+			ValidationFailed: r.ValidationFailed, Strategy: r.Strategy,
+			Build: r.Build, Config: r.Config, Context: r.PromptContext, Theme: r.Theme,
+			DurationMS: r.DurationMS, UpdatedAt: Time(r.UpdatedAt),
+		}
+	}
+	return out
+}
+
+// PromptRollout is a canary prompt set and whether it was rolled back.
+type PromptRollout struct {
+	Version   string     `json:"version"`
+	Status    string     `json:"status"`           // "canary" or "rolled_back"
+	Reason    string     `json:"reason,omitempty"` // why it was rolled back
+	CreatedAt *time.Time `json:"created_at"`       // first used
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// NewPromptRollouts is rollouts as clients see them.
+func NewPromptRollouts(rollouts []models.PromptRollout) []PromptRollout {
+	out := make([]PromptRollout, len(rollouts))
+	for i, p := range rollouts {
+		out[i] = PromptRollout{Version: p.Version, Status: p.Status, Reason: p.Reason, CreatedAt: Time(p.CreatedAt), UpdatedAt: Time(p.UpdatedAt)}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// keys returns the JSON object keys v marshals to.
+func keys(t *testing.T, v any) []string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	return slices.Sorted(maps.Keys(m))
+}
+
+// TestRecommendation_fieldNames pins the v1 field names: renaming a model
+// column mustn't rename them.
+func TestRecommendation_fieldNames(t *testing.T) {
+	rec := models.Recommendation{
+		ID: 42, Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Slot: "daily", Title: "Heat", Type: models.TypeMovie,
+		Year: 1995, Rating: new(8.3), Genre: "Crime", PosterURL: "/library/metadata/1/thumb", Explanation: "Taut.",
+		Runtime: 170, Seasons: 1, TMDbID: 949, Pinned: true, Anniversary: 30, Episodes: 2, EpisodeRuntime: 40,
+		SnoozedUntil: new(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)),
+		Score:        &models.ScoreBreakdown{Rating: 1.66, Total: 4, Rank: 1},
+	}
+	got := NewRecommendation(rec, "/plex/image/library/metadata/1/thumb?sig=x")
+	want := []string{
+		"anniversary", "episode_runtime", "episodes", "explanation", "genre", "pinned", "poster_url", "rating",
+		"runtime", "score", "seasons", "slot", "snoozed_until", "title", "tmdb_id", "type", "year",
+	}
+	if k := keys(t, got); !slices.Equal(k, want) {
+		t.Errorf("recommendation keys = %v, want %v", k, want)
+	}
+	want = []string{"anniversary", "affinity", "diversity_swap", "novelty", "rank", "rating", "recency", "runtime_fit", "snoozed", "total", "watchlist"}
+	slices.Sort(want)
+	if k := keys(t, got.Score); !slices.Equal(k, want) {
+		t.Errorf("score keys = %v, want %v", k, want)
+	}
+	if got.PosterURL != "/plex/image/library/metadata/1/thumb?sig=x" || *got.Rating != 8.3 || got.Score.Rank != 1 || got.SnoozedUntil != "2026-03-08" {
+		t.Errorf("NewRecommendation = %+v", got)
+	}
+}
+
+func TestDatesAndTimes(t *testing.T) {
+	day := time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("PST", -8*3600))
+	if got := Date(day); got != "2026-03-01" {
+		t.Errorf("Date = %q", got)
+	}
+	if Time(time.Time{}) != nil {
+		t.Error("zero Time should be nil")
+	}
+	if got := Time(day); got.Location() != time.UTC || !got.Equal(day) {
+		t.Errorf("Time = %v, want %v in UTC", got, day)
+	}
+	seen := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	shows := NewAbandonedShows([]models.TVShow{{ID: 7, Title: "Lost", Year: 2004, Episodes: 121, WatchedEpisodes: 12, LastViewedAt: &seen}})
+	if len(shows) != 1 || shows[0].ID != 7 || !shows[0].LastViewedAt.Equal(seen) {
+		t.Errorf("NewAbandonedShows = %+v", shows)
+	}
+}
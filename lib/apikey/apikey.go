@@ -0,0 +1,145 @@
+// Package apikey issues and checks scoped API keys. Keys are random tokens
+// shown once when created; the database keeps only their SHA-256 hashes, so
+// a leaked backup can't be replayed against the API.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scopes a key can hold. An admin key can do everything.
+const (
+	ScopeRead     = "read"     // the JSON read API (/api/*)
+	ScopeFeedback = "feedback" // reporting what the household watched or thought
+	ScopeCron     = "cron"     // triggering background jobs (/cron/*)
+	ScopeAdmin    = "admin"    // operator endpoints (/admin/*)
+)
+
+// Scopes lists every scope.
+var Scopes = []string{ScopeRead, ScopeFeedback, ScopeCron, ScopeAdmin}
+
+// tokenPrefix marks recommender keys so they're recognizable in config files
+// and secret scanners.
+const tokenPrefix = "rk_"
+
+// shownPrefix is how much of a key listings show.
+const shownPrefix = len(tokenPrefix) + 6
+
+// touchInterval limits how often a key's last use is written back.
+const touchInterval = time.Minute
+
+var (
+	// ErrUnknownScope is returned by Create for a scope not in Scopes.
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrInvalid is returned by Authenticate for a token that isn't a key.
+	ErrInvalid = errors.New("invalid API key")
+)
+
+// Store keeps API keys in the database.
+type Store struct {
+	db *gorm.DB
+}
+
+// New returns a Store backed by db.
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create issues a key named name with scopes and returns its token, which
+// can't be recovered later, alongside the stored row.
+func (s *Store) Create(ctx context.Context, name string, scopes []string) (string, models.APIKey, error) {
+	for _, sc := range scopes {
+		if !slices.Contains(Scopes, sc) {
+			return "", models.APIKey{}, fmt.Errorf("%w %q", ErrUnknownScope, sc)
+		}
+	}
+	b := make([]byte, 32)
+	_, _ = rand.Read(b) // never fails
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	key := models.APIKey{
+		Name:   name,
+		Prefix: token[:shownPrefix],
+		Hash:   hash(token),
+		Scopes: strings.Join(slices.Compact(slices.Sorted(slices.Values(scopes))), ","),
+	}
+	if err := s.db.WithContext(ctx).Create(&key).Error; err != nil {
+		return "", models.APIKey{}, fmt.Errorf("save API key: %w", err)
+	}
+	return token, key, nil
+}
+
+// Authenticate returns the key token belongs to, or ErrInvalid. It notes
+// when the key was last used, at most once a minute.
+func (s *Store) Authenticate(ctx context.Context, token string) (models.APIKey, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return models.APIKey{}, ErrInvalid
+	}
+	var key models.APIKey
+	err := s.db.WithContext(ctx).Where("hash = ?", hash(token)).Take(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.APIKey{}, ErrInvalid
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("find API key: %w", err)
+	}
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		if err := s.db.WithContext(ctx).Model(&key).Update("last_used_at", now).Error; err != nil {
+			logging.FromContext(ctx).Warnw("Failed to note API key use", "key", key.Prefix, zap.Error(err))
+		}
+	}
+	return key, nil
+}
+
+// List returns every key, oldest first.
+func (s *Store) List(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke deletes the key id, returning it and whether it existed.
+func (s *Store) Revoke(ctx context.Context, id uint) (models.APIKey, bool, error) {
+	var key models.APIKey
+	res := s.db.WithContext(ctx).Clauses(clause.Returning{}).Where("id = ?", id).Delete(&key)
+	if res.Error != nil {
+		return models.APIKey{}, false, fmt.Errorf("revoke API key: %w", res.Error)
+	}
+	return key, res.RowsAffected > 0, nil
+}
+
+// ScopesOf splits key's scopes.
+func ScopesOf(key models.APIKey) []string {
+	if key.Scopes == "" {
+		return nil
+	}
+	return strings.Split(key.Scopes, ",")
+}
+
+// Allows reports whether key may act in scope.
+func Allows(key models.APIKey, scope string) bool {
+	scopes := ScopesOf(key)
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
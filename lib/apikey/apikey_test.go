@@ -0,0 +1,73 @@
+package apikey
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestStore_lifecycle(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.APIKey{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	s := New(db)
+
+	if _, _, err := s.Create(ctx, "bad", []string{"write"}); !errors.Is(err, ErrUnknownScope) {
+		t.Errorf("unknown scope: err = %v, want ErrUnknownScope", err)
+	}
+	token, key, err := s.Create(ctx, "home assistant", []string{ScopeRead, ScopeCron, ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Scopes != "cron,read" || key.Prefix != token[:shownPrefix] || key.Hash == token {
+		t.Errorf("stored key = %+v", key)
+	}
+
+	got, err := s.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != key.ID {
+		t.Errorf("Authenticate = %+v, want key %d", got, key.ID)
+	}
+	if err := db.Take(&got, key.ID).Error; err != nil || got.LastUsedAt == nil {
+		t.Errorf("last use not noted: %+v, %v", got, err)
+	}
+	for _, bad := range []string{"", token + "x", "Bearer " + token} {
+		if _, err := s.Authenticate(ctx, bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Authenticate(%q) = %v, want ErrInvalid", bad, err)
+		}
+	}
+
+	if old, found, err := s.Revoke(ctx, key.ID); err != nil || !found || old.Name != "home assistant" {
+		t.Fatalf("Revoke = %+v, %v, %v", old, found, err)
+	}
+	if _, err := s.Authenticate(ctx, token); !errors.Is(err, ErrInvalid) {
+		t.Errorf("revoked key still works: %v", err)
+	}
+	if _, found, _ := s.Revoke(ctx, key.ID); found {
+		t.Error("revoking twice should report not found")
+	}
+}
+
+func TestAllows(t *testing.T) {
+	for _, tc := range []struct {
+		scopes, scope string
+		want          bool
+	}{
+		{"read", ScopeRead, true},
+		{"cron,read", ScopeCron, true},
+		{"read", ScopeCron, false},
+		{"feedback", ScopeAdmin, false},
+		{"admin", ScopeFeedback, true},
+		{"", ScopeRead, false},
+	} {
+		if got := Allows(models.APIKey{Scopes: tc.scopes}, tc.scope); got != tc.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tc.scopes, tc.scope, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package audit keeps the log of changes made through the operator
+// endpoints (pins, spotlights, show decisions, maintenance mode, reloads,
+// backfills, API keys), so a household with several admins can see who did
+// what.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+)
+
+// Log stores audit entries in the database.
+type Log struct {
+	db *gorm.DB
+}
+
+// New returns a Log backed by db.
+func New(db *gorm.DB) *Log {
+	return &Log{db: db}
+}
+
+// Record notes that actor performed action on target, which looked like
+// before and then after (either may be nil).
+func (l *Log) Record(ctx context.Context, actor, action, target string, before, after any) error {
+	e := models.AuditEntry{Actor: actor, Action: action, Target: target}
+	var err error
+	if e.Before, err = snapshot(before); err != nil {
+		return fmt.Errorf("audit %s: before: %w", action, err)
+	}
+	if e.After, err = snapshot(after); err != nil {
+		return fmt.Errorf("audit %s: after: %w", action, err)
+	}
+	if err := l.db.WithContext(ctx).Create(&e).Error; err != nil {
+		return fmt.Errorf("save audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query selects a page of entries, newest first. Action, when set, limits
+// them to one kind of change.
+type Query struct {
+	Action string
+	Page   int
+	Size   int
+}
+
+// List returns q's page of entries and how many match in all.
+func (l *Log) List(ctx context.Context, q Query) ([]models.AuditEntry, int64, error) {
+	db := l.db.WithContext(ctx).Model(&models.AuditEntry{})
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count audit entries: %w", err)
+	}
+	var out []models.AuditEntry
+	if err := db.Order("id DESC").Offset((q.Page - 1) * q.Size).Limit(q.Size).Find(&out).Error; err != nil {
+		return nil, 0, fmt.Errorf("list audit entries: %w", err)
+	}
+	return out, total, nil
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	return b, nil
+}
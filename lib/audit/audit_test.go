@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestLog_recordAndList(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.AuditEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	l := New(db)
+
+	type pin struct {
+		Title string `json:"title"`
+	}
+	var none *pin
+	for _, e := range []struct {
+		action        string
+		before, after any
+	}{
+		{"pin.add", nil, pin{"Heat"}},
+		{"maintenance.set", map[string]bool{"enabled": false}, map[string]bool{"enabled": true}},
+		{"pin.delete", pin{"Heat"}, none},
+	} {
+		if err := l.Record(ctx, "admin token", e.action, "pin 1", e.before, e.after); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, total, err := l.List(ctx, Query{Page: 1, Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(all) != 2 || all[0].Action != "pin.delete" {
+		t.Fatalf("List = %d entries of %d, first %+v; want newest first", len(all), total, all)
+	}
+	if string(all[0].Before) != `{"title":"Heat"}` || all[0].After != nil {
+		t.Errorf("pin.delete snapshots = %s / %s, want the pin then nothing", all[0].Before, all[0].After)
+	}
+
+	adds, total, err := l.List(ctx, Query{Action: "pin.add", Page: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(adds) != 1 || adds[0].Before != nil || adds[0].Actor != "admin token" {
+		t.Errorf("pin.add entries = %+v (total %d)", adds, total)
+	}
+}
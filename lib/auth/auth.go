@@ -0,0 +1,257 @@
+// Package auth signs household members in to the web UI: users with a local
+// password and/or a linked Plex account, and the browser sessions they
+// start. Session tokens, like API keys, are stored only as SHA-256 hashes.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Session lifetimes: a remembered session lasts a month, any other until
+// the browser closes or half a day passes.
+const (
+	RememberFor = 30 * 24 * time.Hour
+	SessionFor  = 12 * time.Hour
+)
+
+// Password length bounds for callers to enforce. bcrypt reads at most 72
+// bytes, so longer passwords would be silently truncated.
+const (
+	MinPasswordLen = 8
+	MaxPasswordLen = 72
+)
+
+// touchInterval limits how often a session's last use is written back.
+const touchInterval = time.Minute
+
+var (
+	// ErrBadCredentials is returned for an unknown user or a wrong password.
+	ErrBadCredentials = errors.New("wrong name or password")
+	// ErrNoSession is returned for a missing, expired, or ended session.
+	ErrNoSession = errors.New("not signed in")
+	// ErrNotLinked is returned by UserByPlex for a Plex account no user has.
+	ErrNotLinked = errors.New("that Plex account isn't linked to a user")
+)
+
+// dummyHash is compared against for unknown users, so a sign-in takes as
+// long whether or not the name exists.
+var dummyHash = sync.OnceValue(func() []byte {
+	h, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return h
+})
+
+// Store keeps users and sessions in the database.
+type Store struct {
+	db *gorm.DB
+}
+
+// New returns a Store backed by db.
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// SetUser creates the user name, or updates them if they exist. A non-empty
+// password replaces theirs; plexUsername, when non-nil, links that Plex
+// account ("" unlinks). Updating a user ends their sessions, so a changed
+// password or unlinked account takes effect at once.
+func (s *Store) SetUser(ctx context.Context, name, password string, plexUsername *string) (models.User, error) {
+	var u models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).Limit(1).Find(&u).Error; err != nil {
+			return fmt.Errorf("find user: %w", err)
+		}
+		existed := u.ID != 0
+		u.Name = name
+		if password != "" {
+			h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("hash password: %w", err)
+			}
+			u.PasswordHash = string(h)
+		}
+		if plexUsername != nil {
+			u.PlexUsername = nil
+			if p := strings.TrimSpace(*plexUsername); p != "" {
+				u.PlexUsername = &p
+			}
+		}
+		if err := tx.Save(&u).Error; err != nil {
+			return fmt.Errorf("save user: %w", err)
+		}
+		if existed {
+			if err := tx.Where("user_id = ?", u.ID).Delete(&models.Session{}).Error; err != nil {
+				return fmt.Errorf("end sessions: %w", err)
+			}
+		}
+		return nil
+	})
+	return u, err
+}
+
+// UserByName returns the user name, or nil.
+func (s *Store) UserByName(ctx context.Context, name string) (*models.User, error) {
+	var u models.User
+	res := s.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&u)
+	if res.Error != nil {
+		return nil, fmt.Errorf("find user: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+// Users returns every user, by name.
+func (s *Store) Users(ctx context.Context) ([]models.User, error) {
+	var out []models.User
+	if err := s.db.WithContext(ctx).Order("name").Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteUser removes the user id and their sessions, returning the user and
+// whether they existed.
+func (s *Store) DeleteUser(ctx context.Context, id uint) (models.User, bool, error) {
+	var u models.User
+	var found bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.Returning{}).Where("id = ?", id).Delete(&u)
+		if res.Error != nil {
+			return fmt.Errorf("delete user: %w", res.Error)
+		}
+		found = res.RowsAffected > 0
+		if err := tx.Where("user_id = ?", id).Delete(&models.Session{}).Error; err != nil {
+			return fmt.Errorf("end sessions: %w", err)
+		}
+		return nil
+	})
+	return u, found, err
+}
+
+// CheckPassword returns the user name if password is theirs, else
+// ErrBadCredentials.
+func (s *Store) CheckPassword(ctx context.Context, name, password string) (models.User, error) {
+	var u models.User
+	if err := s.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&u).Error; err != nil {
+		return models.User{}, fmt.Errorf("find user: %w", err)
+	}
+	if u.PasswordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return models.User{}, ErrBadCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return models.User{}, ErrBadCredentials
+	}
+	return u, nil
+}
+
+// UserByPlex returns the user linked to the Plex account username, else
+// ErrNotLinked.
+func (s *Store) UserByPlex(ctx context.Context, username string) (models.User, error) {
+	var u models.User
+	res := s.db.WithContext(ctx).Where("LOWER(plex_username) = LOWER(?)", username).Limit(1).Find(&u)
+	if res.Error != nil {
+		return models.User{}, fmt.Errorf("find user: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.User{}, ErrNotLinked
+	}
+	return u, nil
+}
+
+// StartSession signs user in and returns the session cookie's value and
+// when it expires. Expired sessions are cleared out on the way.
+func (s *Store) StartSession(ctx context.Context, user models.User, remember bool, userAgent string) (string, time.Time, error) {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b) // never fails
+	token := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now()
+	life := SessionFor
+	if remember {
+		life = RememberFor
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	sess := models.Session{
+		ID: hash(token), UserID: user.ID, ExpiresAt: now.Add(life), Remember: remember,
+		UserAgent: userAgent, LastSeenAt: now,
+	}
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.Session{}).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("clear expired sessions: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Create(&sess).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("save session: %w", err)
+	}
+	return token, sess.ExpiresAt, nil
+}
+
+// SessionUser returns who the session cookie token signs in, else
+// ErrNoSession.
+func (s *Store) SessionUser(ctx context.Context, token string) (models.User, error) {
+	if token == "" {
+		return models.User{}, ErrNoSession
+	}
+	var sess models.Session
+	res := s.db.WithContext(ctx).Where("id = ? AND expires_at > ?", hash(token), time.Now()).Limit(1).Find(&sess)
+	if res.Error != nil {
+		return models.User{}, fmt.Errorf("find session: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.User{}, ErrNoSession
+	}
+	var u models.User
+	res = s.db.WithContext(ctx).Where("id = ?", sess.UserID).Limit(1).Find(&u)
+	if res.Error != nil {
+		return models.User{}, fmt.Errorf("find user: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.User{}, ErrNoSession
+	}
+	if now := time.Now(); now.Sub(sess.LastSeenAt) >= touchInterval {
+		if err := s.db.WithContext(ctx).Model(&sess).Update("last_seen_at", now).Error; err != nil {
+			logging.FromContext(ctx).Warnw("Failed to note session use", "user", u.Name, zap.Error(err))
+		}
+	}
+	return u, nil
+}
+
+// EndSession signs the session cookie token out.
+func (s *Store) EndSession(ctx context.Context, token string) error {
+	if err := s.db.WithContext(ctx).Where("id = ?", hash(token)).Delete(&models.Session{}).Error; err != nil {
+		return fmt.Errorf("end session: %w", err)
+	}
+	return nil
+}
+
+// EndUserSessions signs the user id out everywhere, returning how many
+// sessions ended.
+func (s *Store) EndUserSessions(ctx context.Context, id uint) (int64, error) {
+	res := s.db.WithContext(ctx).Where("user_id = ?", id).Delete(&models.Session{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("end sessions: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
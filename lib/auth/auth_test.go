@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestStore_signInAndOut(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.User{}, &models.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	s := New(db)
+
+	plex := "SamPlex"
+	u, err := s.SetUser(ctx, "sam", "correct horse", &plex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.PasswordHash == "correct horse" {
+		t.Fatal("password stored in the clear")
+	}
+	if _, err := s.CheckPassword(ctx, "sam", "wrong horse"); !errors.Is(err, ErrBadCredentials) {
+		t.Errorf("wrong password: err = %v, want ErrBadCredentials", err)
+	}
+	if _, err := s.CheckPassword(ctx, "nobody", "correct horse"); !errors.Is(err, ErrBadCredentials) {
+		t.Errorf("unknown user: err = %v, want ErrBadCredentials", err)
+	}
+	if got, err := s.CheckPassword(ctx, "sam", "correct horse"); err != nil || got.ID != u.ID {
+		t.Fatalf("CheckPassword = %+v, %v", got, err)
+	}
+	if got, err := s.UserByPlex(ctx, "samplex"); err != nil || got.ID != u.ID {
+		t.Errorf("UserByPlex should ignore case: %+v, %v", got, err)
+	}
+
+	token, expires, err := s.StartSession(ctx, u, true, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expires.IsZero() {
+		t.Error("remembered session has no expiry")
+	}
+	if got, err := s.SessionUser(ctx, token); err != nil || got.ID != u.ID {
+		t.Fatalf("SessionUser = %+v, %v", got, err)
+	}
+	if _, err := s.SessionUser(ctx, token+"x"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("bad token: err = %v, want ErrNoSession", err)
+	}
+	if err := s.EndSession(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SessionUser(ctx, token); !errors.Is(err, ErrNoSession) {
+		t.Errorf("ended session still works: %v", err)
+	}
+
+	// Changing a user signs them out everywhere; unlinking Plex takes effect.
+	token, _, err = s.StartSession(ctx, u, false, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlink := ""
+	if _, err := s.SetUser(ctx, "sam", "", &unlink); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SessionUser(ctx, token); !errors.Is(err, ErrNoSession) {
+		t.Errorf("session survived a user change: %v", err)
+	}
+	if _, err := s.UserByPlex(ctx, "samplex"); !errors.Is(err, ErrNotLinked) {
+		t.Errorf("unlinked Plex account: err = %v, want ErrNotLinked", err)
+	}
+	if _, err := s.CheckPassword(ctx, "sam", "correct horse"); err != nil {
+		t.Errorf("an empty password should keep the old one: %v", err)
+	}
+
+	if _, found, err := s.DeleteUser(ctx, u.ID); err != nil || !found {
+		t.Fatalf("DeleteUser = %v, %v", found, err)
+	}
+	if _, err := s.CheckPassword(ctx, "sam", "correct horse"); !errors.Is(err, ErrBadCredentials) {
+		t.Errorf("deleted user can still sign in: %v", err)
+	}
+}
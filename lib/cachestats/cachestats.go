@@ -0,0 +1,140 @@
+// Package cachestats counts hits, misses, and evictions for the app's
+// caches and exports them to /metrics and the admin API, so cache sizes can
+// be tuned from real traffic.
+package cachestats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var meter = otel.Meter("github.com/icco/recommender/lib/cachestats")
+
+var (
+	mu       sync.Mutex
+	counters = map[string]*Counter{}
+	register sync.Once
+)
+
+// Counter tracks one named cache. It is safe for concurrent use; a nil
+// Counter ignores every call, so tests can build caches without one.
+type Counter struct {
+	name      string
+	size      func() int
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// Stats is a point-in-time copy of a Counter.
+type Stats struct {
+	Name      string  `json:"name"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	Entries   int     `json:"entries"`
+	HitRatio  float64 `json:"hit_ratio"` // hits / lookups, 0 before any lookup
+}
+
+// New returns the Counter for the cache called name, creating it on first
+// use so a cache rebuilt at runtime keeps its totals. size, when non-nil,
+// reports how many entries the cache holds.
+func New(name string, size func() int) *Counter {
+	register.Do(registerMetrics)
+	mu.Lock()
+	defer mu.Unlock()
+	c, ok := counters[name]
+	if !ok {
+		c = &Counter{name: name}
+		counters[name] = c
+	}
+	if size != nil {
+		c.size = size
+	}
+	return c
+}
+
+// Hit records a lookup the cache answered.
+func (c *Counter) Hit() {
+	if c != nil {
+		c.hits.Add(1)
+	}
+}
+
+// Miss records a lookup the cache couldn't answer.
+func (c *Counter) Miss() {
+	if c != nil {
+		c.misses.Add(1)
+	}
+}
+
+// Evict records n entries dropped to bound the cache.
+func (c *Counter) Evict(n int) {
+	if c != nil && n > 0 {
+		c.evictions.Add(int64(n))
+	}
+}
+
+// Stats returns c's current counts.
+func (c *Counter) Stats() Stats {
+	s := Stats{Name: c.name, Hits: c.hits.Load(), Misses: c.misses.Load(), Evictions: c.evictions.Load()}
+	if c.size != nil {
+		s.Entries = c.size()
+	}
+	if n := s.Hits + s.Misses; n > 0 {
+		s.HitRatio = float64(s.Hits) / float64(n)
+	}
+	return s
+}
+
+// All returns every cache's counts, by name.
+func All() []Stats {
+	mu.Lock()
+	cs := make([]*Counter, 0, len(counters))
+	for _, c := range counters {
+		cs = append(cs, c)
+	}
+	mu.Unlock()
+	out := make([]Stats, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.Stats())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// registerMetrics exports every Counter as cache.requests{cache,result},
+// cache.evictions{cache}, and cache.entries{cache}.
+func registerMetrics() {
+	requests, err := meter.Int64ObservableCounter("cache.requests",
+		metric.WithDescription("cache lookups, by cache and result (hit or miss)"))
+	if err != nil {
+		return
+	}
+	evictions, err := meter.Int64ObservableCounter("cache.evictions",
+		metric.WithDescription("entries dropped to bound a cache"))
+	if err != nil {
+		return
+	}
+	entries, err := meter.Int64ObservableGauge("cache.entries",
+		metric.WithDescription("entries a cache currently holds"))
+	if err != nil {
+		return
+	}
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, s := range All() {
+			name := attribute.String("cache", s.Name)
+			o.ObserveInt64(requests, s.Hits, metric.WithAttributes(name, attribute.String("result", "hit")))
+			o.ObserveInt64(requests, s.Misses, metric.WithAttributes(name, attribute.String("result", "miss")))
+			o.ObserveInt64(evictions, s.Evictions, metric.WithAttributes(name))
+			o.ObserveInt64(entries, int64(s.Entries), metric.WithAttributes(name))
+		}
+		return nil
+	}, requests, evictions, entries)
+}
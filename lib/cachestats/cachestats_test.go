@@ -0,0 +1,36 @@
+package cachestats
+
+import "testing"
+
+func TestCounter(t *testing.T) {
+	entries := 3
+	c := New("test_counter", func() int { return entries })
+	c.Hit()
+	c.Hit()
+	c.Hit()
+	c.Miss()
+	c.Evict(2)
+	c.Evict(0)
+
+	got := c.Stats()
+	want := Stats{Name: "test_counter", Hits: 3, Misses: 1, Evictions: 2, Entries: 3, HitRatio: 0.75}
+	if got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if New("test_counter", nil) != c {
+		t.Error("New should return the existing counter for a name")
+	}
+
+	var found bool
+	for _, s := range All() {
+		found = found || s.Name == "test_counter"
+	}
+	if !found {
+		t.Error("All() should include test_counter")
+	}
+
+	var none *Counter
+	none.Hit()
+	none.Miss()
+	none.Evict(1) // must not panic
+}
@@ -0,0 +1,177 @@
+// Package calendar provides regional holiday calendars used to theme a day's
+// recommendations (horror on Halloween, ghost stories during Obon, …).
+package calendar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Holiday is a themed day. Theme is guidance for the model, written to
+// complete "Lean toward …".
+type Holiday struct {
+	Name  string
+	Theme string
+}
+
+// Calendar reports the themed holiday, if any, falling on a date.
+type Calendar interface {
+	Holiday(date time.Time) (Holiday, bool)
+}
+
+var (
+	mu      sync.RWMutex
+	regions = map[string]Calendar{
+		"US": US,
+		"UK": UK,
+		"JP": JP,
+	}
+)
+
+// Register adds (or replaces) the calendar for region, so deployments can
+// plug in calendars beyond the built-in US, UK, and JP ones.
+func Register(region string, c Calendar) {
+	mu.Lock()
+	defer mu.Unlock()
+	regions[strings.ToUpper(region)] = c
+}
+
+// Lookup returns the calendar for region (case-insensitive; "GB" is accepted
+// for the UK).
+func Lookup(region string) (Calendar, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "GB" {
+		region = "UK"
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := regions[region]
+	if !ok {
+		names := make([]string, 0, len(regions))
+		for k := range regions {
+			names = append(names, k)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown calendar region %q (want one of %s)", region, strings.Join(names, ", "))
+	}
+	return c, nil
+}
+
+// rule is one holiday and the dates it falls on.
+type rule struct {
+	Holiday
+	on func(time.Time) bool
+}
+
+// Rules is a Calendar built from a list of holidays; the first match wins.
+type Rules []rule
+
+// Holiday implements Calendar.
+func (rs Rules) Holiday(date time.Time) (Holiday, bool) {
+	for _, r := range rs {
+		if r.on(date) {
+			return r.Holiday, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// fixed matches the same month and day every year.
+func fixed(name, theme string, m time.Month, d int) rule {
+	return rule{Holiday{name, theme}, func(t time.Time) bool {
+		return t.Month() == m && t.Day() == d
+	}}
+}
+
+// span matches days first through last (inclusive) of month m.
+func span(name, theme string, m time.Month, first, last int) rule {
+	return rule{Holiday{name, theme}, func(t time.Time) bool {
+		return t.Month() == m && t.Day() >= first && t.Day() <= last
+	}}
+}
+
+// nth matches the n-th weekday wd of month m.
+func nth(name, theme string, m time.Month, wd time.Weekday, n int) rule {
+	return rule{Holiday{name, theme}, func(t time.Time) bool {
+		return t.Month() == m && t.Weekday() == wd && (t.Day()-1)/7 == n-1
+	}}
+}
+
+// easter matches offset days from Western Easter Sunday.
+func easter(name, theme string, offset int) rule {
+	return rule{Holiday{name, theme}, func(t time.Time) bool {
+		e := EasterSunday(t.Year()).AddDate(0, 0, offset)
+		return t.Month() == e.Month() && t.Day() == e.Day()
+	}}
+}
+
+// EasterSunday returns Western (Gregorian) Easter Sunday for year, using the
+// anonymous Gregorian algorithm.
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// Themes shared by more than one region.
+const (
+	themeNewYear   = "hopeful fresh starts and feel-good favorites"
+	themeNYE       = "celebratory, countdown-to-midnight crowd-pleasers"
+	themeValentine = "romance and romantic comedies"
+	themeHalloween = "horror, spooky, and supernatural titles"
+	themeChristmas = "Christmas films and warm, family-friendly classics"
+)
+
+// US is the United States calendar.
+var US = Rules{
+	fixed("New Year's Day", themeNewYear, time.January, 1),
+	nth("Martin Luther King Jr. Day", "civil rights history and stories of social justice", time.January, time.Monday, 3),
+	fixed("Valentine's Day", themeValentine, time.February, 14),
+	fixed("Independence Day", "big American blockbusters and Americana", time.July, 4),
+	fixed("Halloween", themeHalloween, time.October, 31),
+	nth("Thanksgiving", "family gatherings, food, and comfort rewatches", time.November, time.Thursday, 4),
+	fixed("Christmas Eve", themeChristmas, time.December, 24),
+	fixed("Christmas Day", themeChristmas, time.December, 25),
+	fixed("New Year's Eve", themeNYE, time.December, 31),
+}
+
+// UK is the United Kingdom calendar.
+var UK = Rules{
+	fixed("New Year's Day", themeNewYear, time.January, 1),
+	fixed("Burns Night", "Scottish stories and settings", time.January, 25),
+	fixed("Valentine's Day", themeValentine, time.February, 14),
+	easter("Good Friday", "sweeping, long-weekend epics", -2),
+	easter("Easter Sunday", "gentle family films", 0),
+	fixed("Halloween", themeHalloween, time.October, 31),
+	fixed("Bonfire Night", "heists, conspiracies, and things that go bang", time.November, 5),
+	nth("Remembrance Sunday", "thoughtful war dramas and history", time.November, time.Sunday, 2),
+	fixed("Christmas Eve", themeChristmas, time.December, 24),
+	fixed("Christmas Day", themeChristmas, time.December, 25),
+	fixed("Boxing Day", "big comfortable blockbusters to doze in front of", time.December, 26),
+	fixed("New Year's Eve", themeNYE, time.December, 31),
+}
+
+// JP is the Japan calendar.
+var JP = Rules{
+	span("Shōgatsu", "unhurried New Year viewing and long series to binge", time.January, 1, 3),
+	nth("Coming of Age Day", "coming-of-age stories", time.January, time.Monday, 2),
+	fixed("Children's Day", "family films and animation", time.May, 5),
+	fixed("Tanabata", "star-crossed lovers and longing", time.July, 7),
+	span("Obon", "ghost stories and films about family and memory", time.August, 13, 16),
+	nth("Respect for the Aged Day", "stories about elders, aging, and generations", time.September, time.Monday, 3),
+	fixed("Halloween", themeHalloween, time.October, 31),
+	fixed("Christmas Eve", "date-night romance", time.December, 24),
+	fixed("Ōmisoka", "year-end music, celebration, and reflection", time.December, 31),
+}
@@ -0,0 +1,75 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEasterSunday(t *testing.T) {
+	for year, want := range map[int]time.Time{
+		2024: day(2024, time.March, 31),
+		2025: day(2025, time.April, 20),
+		2026: day(2026, time.April, 5),
+		2038: day(2038, time.April, 25),
+	} {
+		if got := EasterSunday(year); !got.Equal(want) {
+			t.Errorf("EasterSunday(%d) = %s, want %s", year, got.Format(time.DateOnly), want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestRegionalHolidays(t *testing.T) {
+	for _, tc := range []struct {
+		cal  Calendar
+		date time.Time
+		want string // "" for no holiday
+	}{
+		{US, day(2026, time.November, 26), "Thanksgiving"},
+		{US, day(2026, time.November, 19), ""},
+		{US, day(2026, time.January, 19), "Martin Luther King Jr. Day"},
+		{US, day(2026, time.October, 31), "Halloween"},
+		{UK, day(2026, time.April, 3), "Good Friday"},
+		{UK, day(2026, time.November, 8), "Remembrance Sunday"},
+		{UK, day(2026, time.December, 26), "Boxing Day"},
+		{UK, day(2026, time.July, 4), ""},
+		{JP, day(2026, time.January, 2), "Shōgatsu"},
+		{JP, day(2026, time.January, 12), "Coming of Age Day"},
+		{JP, day(2026, time.August, 15), "Obon"},
+		{JP, day(2026, time.November, 26), ""},
+	} {
+		h, ok := tc.cal.Holiday(tc.date)
+		if ok != (tc.want != "") || h.Name != tc.want {
+			t.Errorf("%s: got %q (%v), want %q", tc.date.Format(time.DateOnly), h.Name, ok, tc.want)
+		}
+		if ok && h.Theme == "" {
+			t.Errorf("%s: %s has no theme", tc.date.Format(time.DateOnly), h.Name)
+		}
+	}
+}
+
+type fixedCal struct{}
+
+func (fixedCal) Holiday(time.Time) (Holiday, bool) {
+	return Holiday{Name: "Film Day", Theme: "anything"}, true
+}
+
+func TestLookup(t *testing.T) {
+	if c, err := Lookup("gb"); err != nil || c == nil {
+		t.Fatalf("Lookup(gb) = %v, %v; want UK", c, err)
+	}
+	if _, err := Lookup("XX"); err == nil {
+		t.Error("Lookup(XX) should fail")
+	}
+	Register("xx", fixedCal{})
+	c, err := Lookup("XX")
+	if err != nil {
+		t.Fatalf("Lookup after Register: %v", err)
+	}
+	if h, _ := c.Holiday(time.Now()); h.Name != "Film Day" {
+		t.Errorf("registered calendar returned %q", h.Name)
+	}
+}
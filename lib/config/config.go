@@ -0,0 +1,350 @@
+// Package config reads the settings that can change without a restart. Values
+// come from the process environment, overlaid by an optional KEY=VALUE file
+// (CONFIG_FILE) that is re-read on SIGHUP or POST /admin/reload.
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/lib/calendar"
+	"github.com/icco/recommender/lib/plex"
+	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/weather"
+	"go.uber.org/zap/zapcore"
+)
+
+// Source looks up configuration values. A key set in the config file wins
+// over the environment, even when its value is empty.
+type Source struct {
+	file map[string]string
+}
+
+// Load reads the config file at path; "" means the environment only.
+func Load(path string) (*Source, error) {
+	s := &Source{file: map[string]string{}}
+	if path == "" {
+		return s, nil
+	}
+	b, err := os.ReadFile(path) //nolint:gosec // path is operator-set config, not user input
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("%s:%d: want KEY=VALUE", path, n)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		s.file[strings.TrimSpace(k)] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns key's value from the config file, else the environment.
+func (s *Source) Get(key string) string {
+	if v, ok := s.file[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// Keys are the reloadable variables.
+var Keys = []string{
+	"LOG_LEVEL", "LLM_DAILY_CAP", "WEATHER_LATITUDE", "WEATHER_LONGITUDE",
+	"COLLECTION_COOLDOWNS", "DIVERSITY_RULES", "HOLIDAY_CALENDAR",
+	"DAILY_MOVIES", "DAILY_TVSHOWS", "MISSING_DAYS_WINDOW",
+	"MOOD_CACHE_SIZE", "MOOD_CACHE_TTL", "BLACKOUT_DATES", "SPOTLIGHT_DAY",
+	"NIGHTLY_MINUTES", "PLEX_INCLUDE_OTHER_VIDEOS", "PLEX_LIBRARIES_INCLUDE",
+	"PLEX_LIBRARIES_EXCLUDE", "CANDIDATE_MIN_RATING", "CANDIDATE_MIN_YEAR",
+	"CANDIDATE_MIN_RUNTIME", "CANDIDATE_REQUIRE_METADATA",
+	"GENERATION_RETRY_INTERVAL", "GENERATION_RETRY_UNTIL",
+	"PROMPT_CANARY_DIR", "PROMPT_CANARY_PERCENT", "PROMPT_CANARY_MAX_REGRESSION",
+	"KIDS_LIBRARIES", "KIDS_RATINGS",
+}
+
+// Values returns the reloadable variables that are set, for recording what
+// a reload changed.
+func (s *Source) Values() map[string]string {
+	out := map[string]string{}
+	for _, k := range Keys {
+		if v := s.Get(k); v != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Reloadable is the configuration applied at startup and again on reload.
+type Reloadable struct {
+	Settings    recommend.Settings
+	Libraries   plex.LibraryFilter
+	LLMDailyCap int
+	LogLevel    zapcore.Level
+}
+
+// Reloadable parses and validates the reloadable keys. Any invalid value
+// fails the whole load, so a bad edit never half-applies.
+func (s *Source) Reloadable() (Reloadable, error) {
+	out := Reloadable{LLMDailyCap: recommend.DefaultLLMDailyCap, LogLevel: zapcore.DebugLevel}
+	out.Settings.MissingDaysWindow = recommend.DefaultMissingDaysWindow
+	out.Settings.MoodCacheSize = recommend.DefaultMoodCacheSize
+	out.Settings.MoodCacheTTL = recommend.DefaultMoodCacheTTL
+	var err error
+
+	if v := s.Get("LOG_LEVEL"); v != "" {
+		out.LogLevel, err = zapcore.ParseLevel(v)
+		if err != nil {
+			return out, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+
+	if v := s.Get("LLM_DAILY_CAP"); v != "" {
+		out.LLMDailyCap, err = strconv.Atoi(v)
+		if err != nil {
+			return out, fmt.Errorf("LLM_DAILY_CAP must be an integer (0 disables the cap): %w", err)
+		}
+	}
+
+	// WEATHER_LATITUDE/WEATHER_LONGITUDE enable weather-aware prompts via
+	// Open-Meteo (no API key).
+	if lat, lon := s.Get("WEATHER_LATITUDE"), s.Get("WEATHER_LONGITUDE"); lat != "" || lon != "" {
+		latF, latErr := strconv.ParseFloat(lat, 64)
+		lonF, lonErr := strconv.ParseFloat(lon, 64)
+		if latErr != nil || lonErr != nil {
+			return out, fmt.Errorf("WEATHER_LATITUDE and WEATHER_LONGITUDE must both be set to decimal degrees")
+		}
+		out.Settings.Weather = weather.NewClient(latF, lonF)
+	}
+
+	// COLLECTION_COOLDOWNS overrides how long a franchise is suppressed after
+	// one of its movies is recommended, by collection size.
+	if v := s.Get("COLLECTION_COOLDOWNS"); v != "" {
+		out.Settings.CollectionCooldowns, err = recommend.ParseCollectionCooldowns(v)
+		if err != nil {
+			return out, fmt.Errorf("COLLECTION_COOLDOWNS: %w", err)
+		}
+	}
+
+	// DIVERSITY_RULES picks which attributes a day's picks may not share.
+	if v := s.Get("DIVERSITY_RULES"); v != "" {
+		rules, err := recommend.ParseDiversityRules(v)
+		if err != nil {
+			return out, fmt.Errorf("DIVERSITY_RULES: %w", err)
+		}
+		out.Settings.Diversity = &rules
+	}
+
+	// HOLIDAY_CALENDAR (US, UK, JP) themes local holidays; unset disables.
+	if v := s.Get("HOLIDAY_CALENDAR"); v != "" {
+		out.Settings.Calendar, err = calendar.Lookup(v)
+		if err != nil {
+			return out, fmt.Errorf("HOLIDAY_CALENDAR: %w", err)
+		}
+	}
+
+	// DAILY_MOVIES/DAILY_TVSHOWS change the daily set's composition.
+	for _, c := range []struct {
+		key string
+		dst *int
+	}{{"DAILY_MOVIES", &out.Settings.DailyMovies}, {"DAILY_TVSHOWS", &out.Settings.DailyTVShows}} {
+		v := s.Get(c.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 10 {
+			return out, fmt.Errorf("%s must be an integer from 1 to 10", c.key)
+		}
+		*c.dst = n
+	}
+
+	// MISSING_DAYS_WINDOW is how many past days /stats checks for skipped
+	// generation; 0 turns the check off.
+	if v := s.Get("MISSING_DAYS_WINDOW"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 366 {
+			return out, fmt.Errorf("MISSING_DAYS_WINDOW must be an integer from 0 to 366")
+		}
+		out.Settings.MissingDaysWindow = n
+	}
+
+	// MOOD_CACHE_SIZE/MOOD_CACHE_TTL bound the LLM mood re-rank cache.
+	if v := s.Get("MOOD_CACHE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 10000 {
+			return out, fmt.Errorf("MOOD_CACHE_SIZE must be an integer from 1 to 10000")
+		}
+		out.Settings.MoodCacheSize = n
+	}
+	if v := s.Get("MOOD_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Minute || d > 7*24*time.Hour {
+			return out, fmt.Errorf("MOOD_CACHE_TTL must be a duration from 1m to 168h")
+		}
+		out.Settings.MoodCacheTTL = d
+	}
+
+	// BLACKOUT_DATES pauses generation on days and ranges, e.g. vacations.
+	if v := s.Get("BLACKOUT_DATES"); v != "" {
+		out.Settings.Blackouts, err = recommend.ParseBlackouts(v)
+		if err != nil {
+			return out, fmt.Errorf("BLACKOUT_DATES: %w", err)
+		}
+	}
+
+	// SPOTLIGHT_DAY (e.g. "saturday") themes that day each week around a
+	// favorite director or actor; unset disables the rotation.
+	if v := s.Get("SPOTLIGHT_DAY"); v != "" {
+		day, ok := parseWeekday(v)
+		if !ok {
+			return out, fmt.Errorf("SPOTLIGHT_DAY must be a day of the week, e.g. saturday")
+		}
+		out.Settings.SpotlightDay = &day
+	}
+
+	// NIGHTLY_MINUTES is the usual evening viewing window; TV picks suggest
+	// how many episodes fit in it.
+	if v := s.Get("NIGHTLY_MINUTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 15 || n > 600 {
+			return out, fmt.Errorf("NIGHTLY_MINUTES must be an integer from 15 to 600")
+		}
+		out.Settings.NightlyMinutes = n
+	}
+
+	// CANDIDATE_* set minimums titles must meet to be offered to the
+	// generator at all.
+	if v := s.Get("CANDIDATE_MIN_RATING"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 10 {
+			return out, fmt.Errorf("CANDIDATE_MIN_RATING must be a number from 0 to 10")
+		}
+		out.Settings.Quality.MinRating = f
+	}
+	if v := s.Get("CANDIDATE_MIN_YEAR"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 9999 {
+			return out, fmt.Errorf("CANDIDATE_MIN_YEAR must be a year")
+		}
+		out.Settings.Quality.MinYear = n
+	}
+	if v := s.Get("CANDIDATE_MIN_RUNTIME"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 600 {
+			return out, fmt.Errorf("CANDIDATE_MIN_RUNTIME must be an integer from 0 to 600")
+		}
+		out.Settings.Quality.MinRuntime = n
+	}
+	if v := s.Get("CANDIDATE_REQUIRE_METADATA"); v != "" {
+		out.Settings.Quality.RequireMetadata, err = strconv.ParseBool(v)
+		if err != nil {
+			return out, fmt.Errorf("CANDIDATE_REQUIRE_METADATA must be true or false")
+		}
+	}
+
+	// GENERATION_RETRY_INTERVAL/GENERATION_RETRY_UNTIL retry a daily run
+	// whose model call failed until an hour of the day (UTC) before falling
+	// back to scored picks; unset falls back at once.
+	if v := s.Get("GENERATION_RETRY_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Minute || d > 6*time.Hour {
+			return out, fmt.Errorf("GENERATION_RETRY_INTERVAL must be a duration from 1m to 6h")
+		}
+		out.Settings.ModelRetry = recommend.ModelRetry{Interval: d, Until: recommend.DefaultModelRetryUntil}
+	}
+	if v := s.Get("GENERATION_RETRY_UNTIL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 23 {
+			return out, fmt.Errorf("GENERATION_RETRY_UNTIL must be an hour from 0 to 23")
+		}
+		if out.Settings.ModelRetry.Interval == 0 {
+			return out, fmt.Errorf("GENERATION_RETRY_UNTIL needs GENERATION_RETRY_INTERVAL")
+		}
+		out.Settings.ModelRetry.Until = n
+	}
+
+	// PROMPT_CANARY_DIR holds candidate prompt templates tried on
+	// PROMPT_CANARY_PERCENT of runs and rolled back automatically if they
+	// regress by more than PROMPT_CANARY_MAX_REGRESSION.
+	if v := s.Get("PROMPT_CANARY_DIR"); v != "" {
+		if info, err := os.Stat(v); err != nil || !info.IsDir() {
+			return out, fmt.Errorf("PROMPT_CANARY_DIR must be a directory")
+		}
+		out.Settings.Canary.Prompts = os.DirFS(v)
+	}
+	if v := s.Get("PROMPT_CANARY_PERCENT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 100 {
+			return out, fmt.Errorf("PROMPT_CANARY_PERCENT must be a number from 0 to 100")
+		}
+		if out.Settings.Canary.Prompts == nil {
+			return out, fmt.Errorf("PROMPT_CANARY_PERCENT needs PROMPT_CANARY_DIR")
+		}
+		out.Settings.Canary.Percent = n
+	}
+	if v := s.Get("PROMPT_CANARY_MAX_REGRESSION"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return out, fmt.Errorf("PROMPT_CANARY_MAX_REGRESSION must be a fraction above 0, at most 1")
+		}
+		out.Settings.Canary.MaxRegression = f
+	}
+
+	// PLEX_INCLUDE_OTHER_VIDEOS keeps home-video libraries the cache sync
+	// otherwise skips; PLEX_LIBRARIES_INCLUDE/EXCLUDE name libraries to
+	// always or never read.
+	if v := s.Get("PLEX_INCLUDE_OTHER_VIDEOS"); v != "" {
+		out.Libraries.IncludeOther, err = strconv.ParseBool(v)
+		if err != nil {
+			return out, fmt.Errorf("PLEX_INCLUDE_OTHER_VIDEOS must be true or false")
+		}
+	}
+	out.Libraries.Include = splitList(s.Get("PLEX_LIBRARIES_INCLUDE"))
+	out.Libraries.Exclude = splitList(s.Get("PLEX_LIBRARIES_EXCLUDE"))
+
+	// KIDS_LIBRARIES and KIDS_RATINGS decide what the kids' picks may use:
+	// everything in those libraries, and titles with those ratings elsewhere.
+	out.Settings.Kids.Libraries = splitList(s.Get("KIDS_LIBRARIES"))
+	out.Settings.Kids.Ratings = splitList(s.Get("KIDS_RATINGS"))
+
+	return out, nil
+}
+
+// splitList splits a comma-separated list, trimming and dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseWeekday parses a weekday name, ignoring case: "Saturday" or "sat".
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.TrimSpace(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/recommend"
+	"go.uber.org/zap/zapcore"
+)
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recommender.env")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_fileOverridesEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("DIVERSITY_RULES", "genre")
+	t.Setenv("HOLIDAY_CALENDAR", "US")
+	src, err := Load(writeFile(t, `
+# comments and blank lines are skipped
+LOG_LEVEL = "info"
+HOLIDAY_CALENDAR=
+DAILY_MOVIES=6
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := src.Reloadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LogLevel != zapcore.InfoLevel {
+		t.Errorf("LogLevel = %v, want info from the file", got.LogLevel)
+	}
+	if got.Settings.Diversity == nil || got.Settings.Diversity.Decade {
+		t.Errorf("Diversity = %+v, want genre only from the environment", got.Settings.Diversity)
+	}
+	if got.Settings.Calendar != nil {
+		t.Error("an empty value in the file should unset HOLIDAY_CALENDAR")
+	}
+	if got.Settings.DailyMovies != 6 || got.Settings.DailyTVShows != 0 {
+		t.Errorf("composition = %d/%d, want 6/0", got.Settings.DailyMovies, got.Settings.DailyTVShows)
+	}
+}
+
+func TestReloadable_defaults(t *testing.T) {
+	for _, k := range []string{"LOG_LEVEL", "LLM_DAILY_CAP", "WEATHER_LATITUDE", "WEATHER_LONGITUDE", "COLLECTION_COOLDOWNS", "DIVERSITY_RULES", "HOLIDAY_CALENDAR", "DAILY_MOVIES", "DAILY_TVSHOWS", "MISSING_DAYS_WINDOW", "MOOD_CACHE_SIZE", "MOOD_CACHE_TTL", "BLACKOUT_DATES", "SPOTLIGHT_DAY", "NIGHTLY_MINUTES", "PLEX_INCLUDE_OTHER_VIDEOS", "PLEX_LIBRARIES_INCLUDE", "PLEX_LIBRARIES_EXCLUDE", "CANDIDATE_MIN_RATING", "CANDIDATE_MIN_YEAR", "CANDIDATE_MIN_RUNTIME", "CANDIDATE_REQUIRE_METADATA", "GENERATION_RETRY_INTERVAL", "GENERATION_RETRY_UNTIL", "PROMPT_CANARY_DIR", "PROMPT_CANARY_PERCENT", "PROMPT_CANARY_MAX_REGRESSION", "KIDS_LIBRARIES", "KIDS_RATINGS"} {
+		t.Setenv(k, "")
+	}
+	src, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := src.Reloadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LogLevel != zapcore.DebugLevel || got.LLMDailyCap != 20 || got.Settings.Weather != nil || got.Settings.Calendar != nil || got.Settings.MissingDaysWindow != 14 ||
+		got.Settings.MoodCacheSize != 64 || got.Settings.MoodCacheTTL != 24*time.Hour || got.Settings.Blackouts != nil || got.Settings.SpotlightDay != nil || got.Settings.NightlyMinutes != 0 ||
+		got.Libraries.IncludeOther || got.Libraries.Include != nil || got.Libraries.Exclude != nil || got.Settings.Quality != (recommend.QualityFilter{}) || got.Settings.ModelRetry != (recommend.ModelRetry{}) || got.Settings.Canary != (recommend.PromptCanary{}) ||
+		got.Settings.Kids.Libraries != nil || got.Settings.Kids.Ratings != nil {
+		t.Errorf("defaults = %+v", got)
+	}
+}
+
+func TestReloadable_invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed line": "LOG_LEVEL\n",
+		"log level":      "LOG_LEVEL=loud\n",
+		"cap":            "LLM_DAILY_CAP=lots\n",
+		"half weather":   "WEATHER_LATITUDE=51.5\nWEATHER_LONGITUDE=\n",
+		"cooldowns":      "COLLECTION_COOLDOWNS=2-14\n",
+		"diversity":      "DIVERSITY_RULES=mood\n",
+		"calendar":       "HOLIDAY_CALENDAR=Atlantis\n",
+		"composition":    "DAILY_TVSHOWS=0\n",
+		"missing days":   "MISSING_DAYS_WINDOW=-1\n",
+		"mood cache":     "MOOD_CACHE_SIZE=0\n",
+		"mood cache ttl": "MOOD_CACHE_TTL=forever\n",
+		"blackout":       "BLACKOUT_DATES=2026-12-31..2026-12-20\n",
+		"spotlight day":  "SPOTLIGHT_DAY=someday\n",
+		"nightly window": "NIGHTLY_MINUTES=5\n",
+		"other videos":   "PLEX_INCLUDE_OTHER_VIDEOS=sometimes\n",
+		"min rating":     "CANDIDATE_MIN_RATING=11\n",
+		"min year":       "CANDIDATE_MIN_YEAR=nineties\n",
+		"min runtime":    "CANDIDATE_MIN_RUNTIME=-40\n",
+		"metadata":       "CANDIDATE_REQUIRE_METADATA=mostly\n",
+		"retry interval": "GENERATION_RETRY_INTERVAL=10s\n",
+		"retry until":    "GENERATION_RETRY_INTERVAL=30m\nGENERATION_RETRY_UNTIL=24\n",
+		"retry no int":   "GENERATION_RETRY_UNTIL=9\n",
+		"canary dir":     "PROMPT_CANARY_DIR=/nonexistent/prompts\n",
+		"canary no dir":  "PROMPT_CANARY_PERCENT=10\n",
+		"canary share":   "PROMPT_CANARY_DIR=/\nPROMPT_CANARY_PERCENT=150\n",
+		"regression":     "PROMPT_CANARY_MAX_REGRESSION=0\n",
+	} {
+		src, err := Load(writeFile(t, body))
+		if err == nil {
+			_, err = src.Reloadable()
+		}
+		if err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestReloadable_calendar(t *testing.T) {
+	src, err := Load(writeFile(t, "HOLIDAY_CALENDAR=gb\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := src.Reloadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Settings.Calendar == nil {
+		t.Fatal("Calendar not set")
+	}
+	if h, ok := got.Settings.Calendar.Holiday(time.Date(2026, 12, 26, 0, 0, 0, 0, time.UTC)); !ok || h.Name != "Boxing Day" {
+		t.Errorf("Dec 26 = %+v, %v; want the UK calendar's Boxing Day", h, ok)
+	}
+}
+
+func TestReloadable_libraries(t *testing.T) {
+	src, err := Load(writeFile(t, "PLEX_INCLUDE_OTHER_VIDEOS=true\nPLEX_LIBRARIES_INCLUDE=Concerts\nPLEX_LIBRARIES_EXCLUDE= Kids Movies , ,Old TV\nKIDS_LIBRARIES=Cartoons\nKIDS_RATINGS=G, gb/U\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := src.Reloadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Libraries.IncludeOther || !slices.Equal(got.Libraries.Include, []string{"Concerts"}) || !slices.Equal(got.Libraries.Exclude, []string{"Kids Movies", "Old TV"}) {
+		t.Errorf("Libraries = %+v", got.Libraries)
+	}
+	if !slices.Equal(got.Settings.Kids.Libraries, []string{"Cartoons"}) || !slices.Equal(got.Settings.Kids.Ratings, []string{"G", "gb/U"}) {
+		t.Errorf("Kids = %+v", got.Settings.Kids)
+	}
+}
+
+func TestReloadable_quality(t *testing.T) {
+	src, err := Load(writeFile(t, "CANDIDATE_MIN_RATING=6.5\nCANDIDATE_MIN_YEAR=1970\nCANDIDATE_MIN_RUNTIME=40\nCANDIDATE_REQUIRE_METADATA=true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := src.Reloadable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := recommend.QualityFilter{MinRating: 6.5, MinYear: 1970, MinRuntime: 40, RequireMetadata: true}
+	if got.Settings.Quality != want {
+		t.Errorf("Quality = %+v, want %+v", got.Settings.Quality, want)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for in, want := range map[string]time.Weekday{"saturday": time.Saturday, "Sun": time.Sunday, " MONDAY ": time.Monday} {
+		if got, ok := parseWeekday(in); !ok || got != want {
+			t.Errorf("parseWeekday(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := parseWeekday("someday"); ok {
+		t.Error("parseWeekday(someday) should fail")
+	}
+}
+
+func TestValues(t *testing.T) {
+	for _, k := range Keys {
+		t.Setenv(k, "")
+	}
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("PLEX_TOKEN", "secret") // not reloadable, so never reported
+	src, err := Load(writeFile(t, "DAILY_MOVIES=6\nHOLIDAY_CALENDAR=\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := src.Values()
+	if len(got) != 2 || got["LOG_LEVEL"] != "error" || got["DAILY_MOVIES"] != "6" {
+		t.Errorf("Values = %v", got)
+	}
+
+	// Every key Reloadable reads is listed.
+	b, err := os.ReadFile("config.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range regexp.MustCompile(`"([A-Z][A-Z_]+)"`).FindAllStringSubmatch(string(b), -1) {
+		if !slices.Contains(Keys, m[1]) {
+			t.Errorf("%s is read but missing from Keys", m[1])
+		}
+	}
+}
@@ -4,27 +4,119 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/icco/gutil/logging"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// DefaultSlowQueryThreshold is the query duration above which GormLogger logs
+// a warning when no explicit threshold is configured.
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+const (
+	// MinExplainVersion is the first Postgres server_version_num with
+	// EXPLAIN (GENERIC_PLAN), which slow-query plans need (PostgreSQL 16).
+	MinExplainVersion = 160000
+	// explainTimeout bounds one background EXPLAIN.
+	explainTimeout = 2 * time.Second
+	// explainEvery is how often the same slow statement is explained again;
+	// its plan rarely changes from one occurrence to the next.
+	explainEvery = 10 * time.Minute
+	// maxExplaining bounds concurrent EXPLAINs, so a burst of slow queries
+	// can't tie up the pool; slow queries beyond it are logged without one.
+	maxExplaining = 2
+	// maxExplained bounds the statements remembered for explainEvery.
+	maxExplained = 1000
+)
+
+var meter = otel.Meter("github.com/icco/recommender/lib/db")
+
+// queryDuration and slowQueries aggregate query latency for /metrics, labeled
+// by statement kind (select/insert/update/delete/other) to keep cardinality low.
+var (
+	queryDuration, _ = meter.Float64Histogram("db.query.duration",
+		metric.WithDescription("Duration of SQL statements issued through GORM"),
+		metric.WithUnit("s"))
+	slowQueries, _ = meter.Int64Counter("db.query.slow",
+		metric.WithDescription("SQL statements slower than the slow-query threshold"))
+)
+
 // GormLogger implements gorm's logger.Interface and forwards records to zap.
 // When a request-scoped logger is attached to ctx via gutil/logging it is
 // preferred; otherwise we fall back to the package-level logger captured at
 // construction time.
 type GormLogger struct {
-	logger *zap.SugaredLogger
+	logger        *zap.SugaredLogger
+	slowThreshold time.Duration
+	explainDB     *sql.DB
+	explaining    chan struct{} // semaphore of maxExplaining slots
+
+	mu        sync.Mutex
+	explained map[string]time.Time // statement -> when it was last explained
 }
 
 var _ gorm.ParamsFilter = (*GormLogger)(nil)
 
 // NewGormLogger creates a new GORM logger that forwards to zap.
 func NewGormLogger(base *zap.Logger) *GormLogger {
-	return &GormLogger{logger: base.Sugar()}
+	return &GormLogger{
+		logger:        base.Sugar(),
+		slowThreshold: DefaultSlowQueryThreshold,
+		explaining:    make(chan struct{}, maxExplaining),
+		explained:     make(map[string]time.Time),
+	}
+}
+
+// SetSlowQuery configures slow-query logging. Statements slower than threshold
+// are logged at warn level. When explainDB is non-nil and the server is
+// PostgreSQL 16 or later (MinExplainVersion), slow SELECTs also get their
+// generic plan logged, from the background and at most once per explainEvery
+// per statement; on an older server, or if the version can't be read, a
+// warning is logged and plans are left out. A threshold <= 0 disables
+// slow-query logging. explainDB is the raw pool (not GORM) so EXPLAIN never
+// re-enters this logger. Call it once at startup, before queries run.
+func (l *GormLogger) SetSlowQuery(ctx context.Context, threshold time.Duration, explainDB *sql.DB) {
+	l.slowThreshold = threshold
+	l.explainDB = nil
+	if explainDB == nil || threshold <= 0 {
+		return
+	}
+	version, err := serverVersion(ctx, explainDB)
+	switch {
+	case err != nil:
+		l.loggerFor(ctx).Warnw("Could not read the Postgres version; slow queries are logged without plans", zap.Error(err))
+	case version < MinExplainVersion:
+		l.loggerFor(ctx).Warnw("Slow-query plans need PostgreSQL 16 or later; logging slow queries without them", "server_version_num", version)
+	default:
+		l.explainDB = explainDB
+	}
+}
+
+// serverVersion returns the server's server_version_num, e.g. 160002.
+func serverVersion(ctx context.Context, db *sql.DB) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+	defer cancel()
+	var s string
+	if err := db.QueryRowContext(ctx, "SHOW server_version_num").Scan(&s); err != nil {
+		return 0, fmt.Errorf("show server_version_num: %w", err)
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("parse server_version_num %q: %w", s, err)
+	}
+	return v, nil
 }
 
 // LogMode is part of gorm's logger.Interface; zap controls leveling so we
@@ -70,6 +162,9 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 	sql, rows := fc()
 	scoped := l.loggerFor(ctx)
 
+	kind := statementKind(sql)
+	queryDuration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attribute.String("statement", kind)))
+
 	if err != nil {
 		scoped.Errorw("GORM error",
 			zap.Error(err),
@@ -80,9 +175,122 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 		return
 	}
 
+	if l.slowThreshold > 0 && elapsed > l.slowThreshold {
+		slowQueries.Add(ctx, 1, metric.WithAttributes(attribute.String("statement", kind)))
+		fields := []any{
+			"sql", sql,
+			"rows", rows,
+			"elapsed", elapsed,
+			"threshold", l.slowThreshold,
+		}
+		scoped.Warnw("GORM slow query", fields...)
+		if kind == "select" {
+			l.explainLater(ctx, scoped, sql)
+		}
+		return
+	}
+
 	scoped.Debugw("GORM query",
 		"sql", sql,
 		"rows", rows,
 		"elapsed", elapsed,
 	)
 }
+
+// explainLater logs query's plan from the background, so the request that
+// ran it doesn't also wait on EXPLAIN. It does nothing without an explain
+// handle, when the statement was explained within explainEvery, or when
+// maxExplaining EXPLAINs are already running.
+func (l *GormLogger) explainLater(ctx context.Context, scoped *zap.SugaredLogger, query string) {
+	if l.explainDB == nil || !l.shouldExplain(query, time.Now()) {
+		return
+	}
+	select {
+	case l.explaining <- struct{}{}:
+	default:
+		return
+	}
+	go func() {
+		defer func() { <-l.explaining }()
+		if plan := l.explain(ctx, query); plan != "" {
+			scoped.Warnw("GORM slow query plan", "sql", query, "plan", plan)
+		}
+	}()
+}
+
+// shouldExplain reports whether query is due an EXPLAIN at now, and if so
+// records that it got one.
+func (l *GormLogger) shouldExplain(query string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if last, ok := l.explained[query]; ok && now.Sub(last) < explainEvery {
+		return false
+	}
+	if len(l.explained) >= maxExplained {
+		for q, last := range l.explained {
+			if now.Sub(last) >= explainEvery {
+				delete(l.explained, q)
+			}
+		}
+		if len(l.explained) >= maxExplained {
+			return false
+		}
+	}
+	l.explained[query] = now
+	return true
+}
+
+// explain returns the generic (parameter-free) Postgres plan for query, or ""
+// when EXPLAIN fails. GENERIC_PLAN plans
+// $n placeholders without values, matching the params ParamsFilter drops; it
+// is sent over the simple-query protocol because the extended protocol would
+// demand arguments for those placeholders.
+func (l *GormLogger) explain(ctx context.Context, query string) string {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), explainTimeout)
+	defer cancel()
+
+	conn, err := l.explainDB.Conn(ctx)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = conn.Close() }()
+
+	var lines []string
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver conn %T", driverConn)
+		}
+		//nolint:gosec // query is GORM-generated SQL with placeholders, not user input
+		results, err := c.Conn().PgConn().Exec(ctx, "EXPLAIN (GENERIC_PLAN) "+query).ReadAll()
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			for _, row := range res.Rows {
+				if len(row) > 0 {
+					lines = append(lines, string(row[0]))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		l.loggerFor(ctx).Debugw("EXPLAIN failed", zap.Error(err))
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statementKind classifies SQL by its leading keyword for metric labels.
+func statementKind(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch k := strings.ToLower(fields[0]); k {
+	case "select", "insert", "update", "delete":
+		return k
+	}
+	return "other"
+}
@@ -3,8 +3,11 @@ package db
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/icco/gutil/logging"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // TestParamsFilterDropsValues verifies bound parameters are stripped so GORM
@@ -21,3 +24,59 @@ func TestParamsFilterDropsValues(t *testing.T) {
 		t.Fatalf("expected params dropped, got %v", gotParams)
 	}
 }
+
+func TestTrace_slowQueryWarns(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	ctx := logging.NewContext(context.Background(), zap.New(core).Sugar())
+	l := NewGormLogger(zap.NewNop())
+	l.SetSlowQuery(ctx, 50*time.Millisecond, nil)
+	fc := func() (string, int64) { return `SELECT * FROM "movies"`, 3 }
+
+	l.Trace(ctx, time.Now(), fc, nil)
+	l.Trace(ctx, time.Now().Add(-time.Second), fc, nil)
+
+	if got := logs.FilterMessage("GORM query").Len(); got != 1 {
+		t.Errorf("fast queries logged = %d, want 1", got)
+	}
+	slow := logs.FilterMessage("GORM slow query").All()
+	if len(slow) != 1 || slow[0].Level != zap.WarnLevel {
+		t.Fatalf("slow query entries = %+v, want one warning", slow)
+	}
+
+	l.SetSlowQuery(ctx, 0, nil)
+	l.Trace(ctx, time.Now().Add(-time.Second), fc, nil)
+	if got := logs.FilterMessage("GORM slow query").Len(); got != 1 {
+		t.Errorf("threshold 0 should disable slow-query logging, got %d", got)
+	}
+}
+
+func TestShouldExplain(t *testing.T) {
+	l := NewGormLogger(zap.NewNop())
+	now := time.Now()
+	q := `SELECT * FROM "movies" WHERE id = $1`
+	if !l.shouldExplain(q, now) {
+		t.Fatal("first slow occurrence should be explained")
+	}
+	if l.shouldExplain(q, now.Add(time.Minute)) {
+		t.Error("a repeat within explainEvery should be skipped")
+	}
+	if !l.shouldExplain(`SELECT 1`, now.Add(time.Minute)) {
+		t.Error("another statement should still be explained")
+	}
+	if !l.shouldExplain(q, now.Add(explainEvery)) {
+		t.Error("the statement should be explained again after explainEvery")
+	}
+}
+
+func TestStatementKind(t *testing.T) {
+	for sql, want := range map[string]string{
+		`SELECT 1`:                  "select",
+		`  insert INTO x VALUES(1)`: "insert",
+		`WITH x AS (SELECT 1) ...`:  "other",
+		``:                          "other",
+	} {
+		if got := statementKind(sql); got != want {
+			t.Errorf("statementKind(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
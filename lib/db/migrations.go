@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/icco/gutil/logging"
 	"github.com/icco/recommender/models"
@@ -28,35 +32,184 @@ var (
 		"recommendation_anime",
 		"recommendation_movies",
 		"recommendation_tvshows",
-		"user_preferences",
 		"user_ratings",
 	}
 	indexesToDrop = []string{
 		"idx_animes_title",
+		"idx_generation_runs_date", // replaced by unique idx_generation_runs_date_context
+		"idx_jobs_kind_key",        // replaced by partial unique idx_jobs_active_key
 		"idx_movies_title",
 		"idx_movies_title_year", // was unique; conflicts with multiple Plex items same title+year
 		"idx_plex_animes_title",
 		"idx_plex_tv_shows_title",
 		"idx_recommendations_date",
+		"idx_recommendations_date_title", // replaced by unique idx_recommendations_date_slot_title
 		"idx_tv_shows_title",
 		"idx_tvshows_title_year", // same as movies
 	}
 )
 
-// RunMigrations runs all database migrations.
+// SchemaVersion is the schema this binary migrates to. Bump it with any
+// migration older binaries can't run against (a dropped or renamed column,
+// a changed constraint); they then refuse to start instead of misbehaving.
+const SchemaVersion = 2 // 2: TV season counts moved out of recommendations.runtime
+
+// ErrSchemaTooNew means the database was migrated by a newer binary.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+const (
+	// migrationLockKey is the Postgres advisory lock replicas hold while
+	// migrating, so only one runs DDL at a time.
+	migrationLockKey = 0x7265636f6d6d // "recomm"
+	// migrationLockWait bounds how long a replica waits for another's
+	// migrations to finish.
+	migrationLockWait = 5 * time.Minute
+)
+
+// RunMigrations runs all database migrations under a Postgres advisory lock,
+// so replicas starting together migrate one at a time. It fails with
+// ErrSchemaTooNew, before changing anything, if a newer binary has already
+// migrated the database.
 func RunMigrations(ctx context.Context, db *gorm.DB) error {
+	// The lock belongs to a session, so everything runs on one connection.
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		lockCtx, cancel := context.WithTimeout(ctx, migrationLockWait)
+		defer cancel()
+		if err := conn.WithContext(lockCtx).Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer func() {
+			//nolint:contextcheck // release the lock even if ctx ended mid-migration
+			if err := conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error; err != nil {
+				logging.FromContext(ctx).Warnw("Failed to release migration lock", zap.Error(err))
+			}
+		}()
+
+		current, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current > SchemaVersion {
+			return fmt.Errorf("%w: database is at version %d, this binary supports %d", ErrSchemaTooNew, current, SchemaVersion)
+		}
+		if err := migrate(ctx, conn); err != nil {
+			return err
+		}
+		if current < SchemaVersion {
+			if err := conn.WithContext(ctx).Exec(`
+				INSERT INTO schema_version (id, version, updated_at) VALUES (1, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, updated_at = EXCLUDED.updated_at`,
+				SchemaVersion, time.Now().UTC()).Error; err != nil {
+				return fmt.Errorf("record schema version: %w", err)
+			}
+			logging.FromContext(ctx).Infow("Migrated schema", "from", current, "to", SchemaVersion)
+		}
+		return nil
+	})
+}
+
+// schemaVersion returns the version the database was last migrated to; 0
+// for a database from before versions were recorded.
+func schemaVersion(ctx context.Context, db *gorm.DB) (int, error) {
+	if err := db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		id integer PRIMARY KEY CHECK (id = 1),
+		version integer NOT NULL,
+		updated_at timestamptz NOT NULL
+	)`).Error; err != nil {
+		return 0, fmt.Errorf("create schema_version: %w", err)
+	}
+	var versions []int
+	if err := db.WithContext(ctx).Raw("SELECT version FROM schema_version WHERE id = 1").Scan(&versions).Error; err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[0], nil
+}
+
+// migrate brings the schema up to date. Every step is idempotent.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	// Must precede AutoMigrate, which adds the unique (date, context) index.
+	if err := dedupeGenerationRuns(ctx, db); err != nil {
+		return fmt.Errorf("dedupe generation runs: %w", err)
+	}
+	// Likewise for the partial unique (kind, unique_key) index on active jobs.
+	if err := dedupeActiveJobs(ctx, db); err != nil {
+		return fmt.Errorf("dedupe active jobs: %w", err)
+	}
+
+	// Movies looked up before release dates were stored need another lookup.
+	m := db.WithContext(ctx).Migrator()
+	refetchReleaseDates := m.HasTable(&models.Movie{}) && !m.HasColumn(&models.Movie{}, "ReleaseDate")
+	// TV recommendations stored season counts in runtime before seasons had
+	// a column of their own.
+	splitSeasons := m.HasTable(&models.Recommendation{}) && !m.HasColumn(&models.Recommendation{}, "Seasons")
+
+	// TMDb IDs are unique only among titles still in Plex once removed
+	// titles are kept; drop the old indexes so AutoMigrate builds partial ones.
+	for _, t := range []struct {
+		model any
+		index string
+	}{{&models.Movie{}, "idx_movies_tmdb_id"}, {&models.TVShow{}, "idx_tvshows_tmdb_id"}} {
+		if m.HasTable(t.model) && !m.HasColumn(t.model, "DeletedAt") && m.HasIndex(t.model, t.index) {
+			if err := m.DropIndex(t.model, t.index); err != nil {
+				return fmt.Errorf("drop %s: %w", t.index, err)
+			}
+		}
+	}
+
+	// An older, unrelated user_preferences table is replaced by the settings
+	// page's; the current one has favorite_genres.
+	if m.HasTable(&models.UserPreference{}) && !m.HasColumn(&models.UserPreference{}, "FavoriteGenres") {
+		if err := dropTableIfExists(ctx, db, "user_preferences"); err != nil {
+			return fmt.Errorf("failed to drop legacy user_preferences: %w", err)
+		}
+	}
+
 	if err := db.WithContext(ctx).AutoMigrate(
 		&models.Movie{}, &models.TVShow{}, &models.Recommendation{},
 		&models.GenerationRun{}, &models.ExternalSignal{}, &models.OAuthToken{},
+		&models.LeaderLease{}, &models.Job{}, &models.LLMUsage{}, &models.TasteProfile{},
+		&models.MaintenanceState{}, &models.Pin{}, &models.DateNote{},
+		&models.Spotlight{}, &models.ShowDecision{}, &models.Vote{}, &models.APIKey{},
+		&models.AuditEntry{}, &models.User{}, &models.Session{}, &models.UserPreference{},
+		&models.ChatMessage{},
+		&models.TitleEmbedding{},
+		&models.BecauseRow{},
+		&models.PromptRollout{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if refetchReleaseDates {
+		if err := db.WithContext(ctx).Exec("UPDATE movies SET collection_checked_at = NULL").Error; err != nil {
+			return fmt.Errorf("queue release date lookups: %w", err)
+		}
+	}
+	if splitSeasons {
+		if err := db.WithContext(ctx).Exec("UPDATE recommendations SET seasons = runtime, runtime = 0 WHERE type = 'tvshow'").Error; err != nil {
+			return fmt.Errorf("move TV seasons out of runtime: %w", err)
+		}
+	}
+
 	// Plex cache upserts use unique plex_rating_key; backfill legacy rows before unique conflicts.
 	if err := backfillPlexRatingKeys(ctx, db); err != nil {
 		return fmt.Errorf("backfill plex_rating_key: %w", err)
 	}
 
+	if err := clearLegacyPlaceholderPosters(ctx, db); err != nil {
+		return fmt.Errorf("clear legacy placeholder posters: %w", err)
+	}
+
+	if err := stripPlexPosterURLs(ctx, db); err != nil {
+		return fmt.Errorf("strip plex poster urls: %w", err)
+	}
+
+	if err := clearUnknownRatings(ctx, db); err != nil {
+		return fmt.Errorf("clear unknown ratings: %w", err)
+	}
+
 	for _, table := range tablesToDrop {
 		if err := dropTableIfExists(ctx, db, table); err != nil {
 			return fmt.Errorf("failed to drop table %s: %w", table, err)
@@ -90,6 +243,95 @@ func backfillPlexRatingKeys(ctx context.Context, db *gorm.DB) error {
 	return nil
 }
 
+// legacyPlaceholderPrefix is the third-party placeholder host older builds
+// stored as PosterURL for titles without artwork.
+const legacyPlaceholderPrefix = "https://via.placeholder.com/"
+
+// clearLegacyPlaceholderPosters blanks stored third-party placeholder URLs so
+// the web layer renders its own placeholder for those titles instead.
+func clearLegacyPlaceholderPosters(ctx context.Context, db *gorm.DB) error {
+	l := logging.FromContext(ctx)
+	for _, table := range []string{"movies", "tv_shows", "recommendations"} {
+		res := db.WithContext(ctx).Exec("UPDATE "+table+" SET poster_url = '' WHERE poster_url LIKE ?", legacyPlaceholderPrefix+"%")
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			l.Infow("Cleared legacy placeholder posters", "table", table, "rows", res.RowsAffected)
+		}
+	}
+	return nil
+}
+
+// stripPlexPosterURLs rewrites poster URLs older builds stored as absolute
+// Plex server URLs, sometimes with an X-Plex-Token, to the server path the
+// Plex sync stores now. The address and token are added back when a poster
+// is fetched, so neither lives in the database.
+func stripPlexPosterURLs(ctx context.Context, db *gorm.DB) error {
+	l := logging.FromContext(ctx)
+	for _, table := range []string{"movies", "tv_shows", "recommendations"} {
+		var rows []struct {
+			ID        uint
+			PosterURL string
+		}
+		if err := db.WithContext(ctx).Table(table).Select("id, poster_url").
+			Where("poster_url LIKE ? OR poster_url LIKE ?", "http%/library/%", "%X-Plex-Token%").Find(&rows).Error; err != nil {
+			return err
+		}
+		n := 0
+		for _, row := range rows {
+			stripped := strippedPosterURL(row.PosterURL)
+			if stripped == row.PosterURL {
+				continue
+			}
+			if err := db.WithContext(ctx).Table(table).Where("id = ?", row.ID).Update("poster_url", stripped).Error; err != nil {
+				return err
+			}
+			n++
+		}
+		if n > 0 {
+			l.Infow("Stripped Plex addresses and tokens from poster URLs", "table", table, "rows", n)
+		}
+	}
+	return nil
+}
+
+// strippedPosterURL returns poster as the Plex sync now stores it: a
+// /library/ path on the Plex server loses its scheme, host, and query, and
+// any other URL loses its X-Plex-Token.
+func strippedPosterURL(poster string) string {
+	u, err := url.Parse(poster)
+	if err != nil {
+		return ""
+	}
+	if strings.HasPrefix(u.Path, "/library/") {
+		return u.Path
+	}
+	q := u.Query()
+	if !q.Has("X-Plex-Token") {
+		return poster
+	}
+	q.Del("X-Plex-Token")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// clearUnknownRatings sets the 0 ratings older builds stored for unrated
+// titles to NULL, so they read as unknown rather than as the worst score.
+func clearUnknownRatings(ctx context.Context, db *gorm.DB) error {
+	l := logging.FromContext(ctx)
+	for _, table := range []string{"movies", "tv_shows", "recommendations"} {
+		res := db.WithContext(ctx).Exec(`UPDATE ` + table + ` SET rating = NULL WHERE rating = 0`)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			l.Infow("Cleared unknown ratings stored as 0", "table", table, "rows", res.RowsAffected)
+		}
+	}
+	return nil
+}
+
 // dropIndexes drops the indexes if they exist.
 func dropIndexes(ctx context.Context, db *gorm.DB) error {
 	l := logging.FromContext(ctx)
@@ -102,6 +344,59 @@ func dropIndexes(ctx context.Context, db *gorm.DB) error {
 	return nil
 }
 
+// dedupeGenerationRuns collapses the legacy one-row-per-attempt history to one
+// row per day (preferring a successful run, then the latest) so the unique
+// (date, context) index can be built. It is a no-op once that index exists.
+func dedupeGenerationRuns(ctx context.Context, db *gorm.DB) error {
+	m := db.WithContext(ctx).Migrator()
+	if !m.HasTable(&models.GenerationRun{}) || m.HasIndex(&models.GenerationRun{}, "idx_generation_runs_date_context") {
+		return nil
+	}
+	res := db.WithContext(ctx).Exec(`
+		DELETE FROM generation_runs WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY "date" ORDER BY (status = 'ok') DESC, id DESC
+				) AS rn FROM generation_runs
+			) ranked WHERE rn > 1
+		)`)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		logging.FromContext(ctx).Infow("Removed duplicate generation runs", "count", res.RowsAffected)
+	}
+	return nil
+}
+
+// dedupeActiveJobs fails all but one pending or running job per (kind,
+// unique_key), preferring a running one, then the oldest, so the partial
+// unique idx_jobs_active_key can be built. Enqueue's old check-then-insert
+// could leave such duplicates. It is a no-op once that index exists.
+func dedupeActiveJobs(ctx context.Context, db *gorm.DB) error {
+	m := db.WithContext(ctx).Migrator()
+	if !m.HasTable(&models.Job{}) || m.HasIndex(&models.Job{}, "idx_jobs_active_key") {
+		return nil
+	}
+	res := db.WithContext(ctx).Exec(`
+		UPDATE jobs SET status = 'failed', last_error = 'duplicate of another active job', updated_at = ?
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY kind, unique_key ORDER BY (status = 'running') DESC, id
+				) AS rn FROM jobs
+				WHERE status IN ('pending', 'running') AND unique_key <> ''
+			) ranked WHERE rn > 1
+		)`, time.Now().UTC())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		logging.FromContext(ctx).Infow("Failed duplicate active jobs", "count", res.RowsAffected)
+	}
+	return nil
+}
+
 // dropTableIfExists drops a table if it exists.
 func dropTableIfExists(ctx context.Context, db *gorm.DB, tableName string) error {
 	l := logging.FromContext(ctx)
@@ -126,6 +421,8 @@ func createAdditionalIndexes(ctx context.Context, db *gorm.DB) {
 		"CREATE INDEX IF NOT EXISTS idx_recommendations_date_type ON recommendations(date, type)",
 		"CREATE INDEX IF NOT EXISTS idx_recommendations_rating_year ON recommendations(rating, year)",
 		"CREATE INDEX IF NOT EXISTS idx_recommendations_genre_type ON recommendations(genre, type)",
+		// Backs the archive's per-genre filter (see recommend.genreTags).
+		"CREATE INDEX IF NOT EXISTS idx_recommendations_genres ON recommendations USING GIN (string_to_array(lower(genre), ', '))",
 	}
 
 	for _, indexSQL := range additionalIndexes {
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -30,3 +31,38 @@ func TestRunMigrations_createsNewTables(t *testing.T) {
 		t.Fatal("expected assigned ID")
 	}
 }
+
+func TestRunMigrations_schemaVersion(t *testing.T) {
+	gdb := dbtest.New(t)
+	for range 2 { // a second run, as on another replica, is a no-op
+		if err := RunMigrations(t.Context(), gdb); err != nil {
+			t.Fatalf("RunMigrations: %v", err)
+		}
+	}
+	got, err := schemaVersion(t.Context(), gdb)
+	if err != nil || got != SchemaVersion {
+		t.Fatalf("schema version = %d, %v; want %d", got, err, SchemaVersion)
+	}
+
+	if err := gdb.Exec("UPDATE schema_version SET version = ?", SchemaVersion+1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := RunMigrations(t.Context(), gdb); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("RunMigrations on a newer schema = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestStrippedPosterURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://192.168.1.5:32400/library/metadata/1/thumb/2?X-Plex-Token=secret": "/library/metadata/1/thumb/2",
+		"https://plex.example.com/library/metadata/1/thumb/2":                     "/library/metadata/1/thumb/2",
+		"/library/metadata/1/thumb/2?X-Plex-Token=secret":                         "/library/metadata/1/thumb/2",
+		"https://cdn.example/p.jpg?X-Plex-Token=secret":                           "https://cdn.example/p.jpg",
+		"https://image.tmdb.org/t/p/w500/abc.jpg":                                 "https://image.tmdb.org/t/p/w500/abc.jpg",
+		"/posters/movie-1.jpg":                                                    "/posters/movie-1.jpg",
+	} {
+		if got := strippedPosterURL(in); got != want {
+			t.Errorf("strippedPosterURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
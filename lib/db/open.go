@@ -0,0 +1,43 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Dialector returns the GORM dialector for a DATABASE_URL: a postgres:// or
+// postgresql:// URL, or a libpq key=value string. Only Postgres is
+// supported, since the job queue (SKIP LOCKED), leader leases, advisory
+// locks, jsonb columns, and cache upserts are written in its SQL; a MySQL
+// or SQLite URL is refused up front instead of failing part way.
+func Dialector(url string) (gorm.Dialector, error) {
+	const needs = "the service needs Postgres for its job queue, locks, and jsonb columns"
+	lower := strings.ToLower(url)
+	switch {
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		return postgres.Open(url), nil
+	case strings.HasPrefix(lower, "mysql:"), strings.Contains(lower, "@tcp("):
+		return nil, fmt.Errorf("database URL: MySQL is not supported; %s", needs)
+	case strings.HasPrefix(lower, "sqlite:"), strings.HasPrefix(lower, "file:"), strings.HasSuffix(lower, ".db"):
+		return nil, fmt.Errorf("database URL: SQLite is not supported; %s", needs)
+	case !strings.Contains(lower, "://") && strings.Contains(url, "="):
+		return postgres.Open(url), nil
+	}
+	return nil, fmt.Errorf("database URL: want postgres://… or libpq key=value settings")
+}
+
+// Open connects to the Postgres database at url with cfg.
+func Open(url string, cfg *gorm.Config) (*gorm.DB, error) {
+	d, err := Dialector(url)
+	if err != nil {
+		return nil, err
+	}
+	gdb, err := gorm.Open(d, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return gdb, nil
+}
@@ -0,0 +1,28 @@
+package db
+
+import "testing"
+
+func TestDialector(t *testing.T) {
+	for _, tc := range []struct {
+		url string
+		ok  bool
+	}{
+		{"postgres://u:p@localhost:5432/recommender?sslmode=disable", true},
+		{"postgresql://localhost/recommender", true},
+		{"host=localhost user=u dbname=recommender", true},
+		{"mysql://u:p@localhost:3306/recommender", false},
+		{"u:p@tcp(localhost:3306)/recommender", false},
+		{"sqlite://recommender.db", false},
+		{"recommender.db", false},
+		{"file:recommender.db?cache=shared", false},
+		{"https://example.com", false},
+	} {
+		d, err := Dialector(tc.url)
+		if ok := err == nil; ok != tc.ok {
+			t.Errorf("Dialector(%q) error = %v, want ok %v", tc.url, err, tc.ok)
+		}
+		if tc.ok && d.Name() != "postgres" {
+			t.Errorf("Dialector(%q) = %s, want postgres", tc.url, d.Name())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PoolConfig sizes the sql.DB connection pool. Cache syncs hold connections in
+// long write transactions while the web UI reads, so the pool needs enough
+// headroom that page loads don't queue behind a sync.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolConfig matches the values the service has always run with.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: 10 * time.Minute,
+	}
+}
+
+// PoolConfigFromEnv overlays DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,
+// DB_CONN_MAX_LIFETIME, and DB_CONN_MAX_IDLE_TIME on DefaultPoolConfig.
+func PoolConfigFromEnv() (PoolConfig, error) {
+	cfg := DefaultPoolConfig()
+	for _, v := range []struct {
+		env string
+		dst *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns},
+	} {
+		s := os.Getenv(v.env)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("%s must be a non-negative integer, got %q", v.env, s)
+		}
+		*v.dst = n
+	}
+	for _, v := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime},
+	} {
+		s := os.Getenv(v.env)
+		if s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("%s must be a non-negative duration, got %q", v.env, s)
+		}
+		*v.dst = d
+	}
+	if cfg.MaxOpenConns > 0 && cfg.MaxIdleConns > cfg.MaxOpenConns {
+		cfg.MaxIdleConns = cfg.MaxOpenConns
+	}
+	return cfg, nil
+}
+
+// Apply configures sqlDB's pool. Zero values keep database/sql semantics
+// (unlimited open conns, no lifetime cap).
+func (c PoolConfig) Apply(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
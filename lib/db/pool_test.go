@@ -0,0 +1,30 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "4")
+	t.Setenv("DB_MAX_IDLE_CONNS", "8")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "30m")
+	cfg, err := PoolConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxOpenConns != 4 || cfg.MaxIdleConns != 4 {
+		t.Errorf("open/idle = %d/%d, want 4/4 (idle capped at open)", cfg.MaxOpenConns, cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime != 30*time.Minute {
+		t.Errorf("lifetime = %v", cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime != DefaultPoolConfig().ConnMaxIdleTime {
+		t.Errorf("idle time should keep default, got %v", cfg.ConnMaxIdleTime)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "lots")
+	if _, err := PoolConfigFromEnv(); err == nil {
+		t.Error("expected error for non-numeric DB_MAX_OPEN_CONNS")
+	}
+}
@@ -0,0 +1,306 @@
+// Package errreport sends panics and notable errors to a Sentry-compatible
+// service (Sentry or GlitchTip) when ERROR_REPORTING_DSN is set. Until
+// Configure is called every Capture is a no-op, so packages can report
+// unconditionally.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"go.uber.org/zap"
+)
+
+// Levels events are reported at.
+const (
+	LevelError = "error"
+	LevelFatal = "fatal" // panics
+)
+
+// maxInFlight bounds unsent events; past it new events are dropped rather
+// than piling up goroutines while the service is unreachable.
+const maxInFlight = 32
+
+// Options configure the reporter.
+type Options struct {
+	DSN         string // https://<key>@<host>/<project>
+	Environment string
+	Release     string
+	HTTPClient  *http.Client // nil uses a client with a 5s timeout
+}
+
+type reporter struct {
+	endpoint string
+	auth     string
+	opts     Options
+	client   *http.Client
+	sem      chan struct{}
+	wg       sync.WaitGroup
+}
+
+var current atomic.Pointer[reporter]
+
+// Configure parses opts.DSN and starts reporting to it. An empty DSN turns
+// reporting off.
+func Configure(opts Options) error {
+	if opts.DSN == "" {
+		current.Store(nil)
+		return nil
+	}
+	u, err := url.Parse(opts.DSN)
+	if err != nil {
+		return fmt.Errorf("parse DSN: %w", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.Scheme == "" || u.Host == "" || u.User == nil || u.User.Username() == "" || project == "" {
+		return fmt.Errorf("DSN must look like https://<key>@<host>/<project>")
+	}
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	r := &reporter{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		auth:     "Sentry sentry_version=7, sentry_client=recommender/1.0, sentry_key=" + u.User.Username(),
+		opts:     opts,
+		client:   opts.HTTPClient,
+		sem:      make(chan struct{}, maxInFlight),
+	}
+	if r.client == nil {
+		r.client = &http.Client{Timeout: 5 * time.Second}
+	}
+	current.Store(r)
+	return nil
+}
+
+// Enabled reports whether a DSN is configured.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Flush waits for events already captured to be sent, or for ctx to end.
+func Flush(ctx context.Context) {
+	r := current.Load()
+	if r == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+type tagsKey struct{}
+
+// WithTags returns ctx carrying tags (alternating keys and values) that
+// every event captured under it is labelled with, such as a generation
+// run's id and date.
+func WithTags(ctx context.Context, kv ...string) context.Context {
+	tags := maps.Clone(Tags(ctx))
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		tags[kv[i]] = kv[i+1]
+	}
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+// Tags returns the tags ctx carries.
+func Tags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// CaptureError reports err, labelled with ctx's tags plus kv. Errors from
+// canceled contexts, such as a client going away, are skipped.
+func CaptureError(ctx context.Context, err error, kv ...string) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	capture(WithTags(ctx, kv...), LevelError, errorType(err), err.Error(), 4)
+}
+
+// CapturePanic reports a recovered panic value with the panicking
+// goroutine's stack. Call it from the deferred function that recovered.
+func CapturePanic(ctx context.Context, v any, kv ...string) {
+	capture(WithTags(ctx, kv...), LevelFatal, "panic", fmt.Sprint(v), 4)
+}
+
+// event is the subset of Sentry's event payload this package sends.
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   struct {
+		Values []exception `json:"values"`
+	} `json:"exception"`
+}
+
+type exception struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []frame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+func capture(ctx context.Context, level, typ, msg string, skip int) {
+	r := current.Load()
+	if r == nil {
+		return
+	}
+	ev := event{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Environment: r.opts.Environment,
+		Release:     r.opts.Release,
+		Tags:        Tags(ctx),
+	}
+	ev.ServerName, _ = os.Hostname()
+	ex := exception{Type: typ, Value: msg}
+	ex.Stacktrace.Frames = stack(skip)
+	ev.Exception.Values = []exception{ex}
+
+	select {
+	case r.sem <- struct{}{}:
+	default:
+		logging.FromContext(ctx).Warnw("Error report dropped; too many in flight", "event_id", ev.EventID)
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.sem }()
+		//nolint:contextcheck // reports outlive the request that raised them
+		if err := r.send(context.Background(), ev); err != nil {
+			logging.FromContext(ctx).Warnw("Failed to send error report", "event_id", ev.EventID, zap.Error(err))
+		}
+	}()
+}
+
+// send posts ev as a one-item envelope.
+func (r *reporter) send(ctx context.Context, ev event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]string{"event_id": ev.EventID, "dsn": r.opts.DSN, "sent_at": ev.Timestamp})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post event: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("post event: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// stack returns the caller's frames, oldest first as Sentry expects,
+// skipping skip frames of reporting machinery.
+func stack(skip int) []frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var out []frame
+	for {
+		f, more := frames.Next()
+		module, fn := splitFunction(f.Function)
+		out = append(out, frame{
+			Function: fn,
+			Module:   module,
+			Filename: f.File[strings.LastIndex(f.File, "/")+1:],
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(module, "github.com/icco/recommender"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// splitFunction splits "github.com/x/y/pkg.(*T).M" into its package path
+// and "(*T).M".
+func splitFunction(name string) (module, fn string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// errorType names the innermost error err wraps, e.g. "*tmdb.APIError",
+// so events group by cause rather than by wrapping message.
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,87 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testError struct{}
+
+func (testError) Error() string { return "upstream said no" }
+
+func TestCaptureError(t *testing.T) {
+	events := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		events <- req
+		bodies <- b
+	}))
+	t.Cleanup(srv.Close)
+	dsn := strings.Replace(srv.URL, "http://", "http://pubkey@", 1) + "/sentry/42"
+	if err := Configure(Options{DSN: dsn, Environment: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Configure(Options{}) })
+
+	ctx := WithTags(context.Background(), "run_id", "7", "date", "2025-01-02")
+	CaptureError(ctx, fmt.Errorf("generate: %w", testError{}), "provider", "gemini")
+	CaptureError(ctx, context.Canceled) // skipped
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	Flush(flushCtx)
+
+	req := <-events
+	if req.URL.Path != "/sentry/api/42/envelope/" || !strings.Contains(req.Header.Get("X-Sentry-Auth"), "sentry_key=pubkey") {
+		t.Errorf("posted to %s with auth %q", req.URL.Path, req.Header.Get("X-Sentry-Auth"))
+	}
+	lines := bytes.Split(bytes.TrimSpace(<-bodies), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("envelope has %d lines, want header, item header, event", len(lines))
+	}
+	var ev event
+	if err := json.Unmarshal(lines[2], &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Level != LevelError || ev.Environment != "test" || len(ev.Exception.Values) != 1 {
+		t.Fatalf("event = %+v", ev)
+	}
+	ex := ev.Exception.Values[0]
+	if ex.Type != "errreport.testError" || ex.Value != "generate: upstream said no" {
+		t.Errorf("exception = %s: %s", ex.Type, ex.Value)
+	}
+	if ev.Tags["run_id"] != "7" || ev.Tags["date"] != "2025-01-02" || ev.Tags["provider"] != "gemini" {
+		t.Errorf("tags = %v", ev.Tags)
+	}
+	frames := ex.Stacktrace.Frames
+	if len(frames) == 0 || frames[len(frames)-1].Function != "TestCaptureError" || !frames[len(frames)-1].InApp {
+		t.Errorf("innermost frame should be the caller: %+v", frames)
+	}
+	select {
+	case <-events:
+		t.Error("canceled-context errors should not be reported")
+	default:
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(Options{}) })
+	for _, dsn := range []string{"not a url", "https://sentry.example.com/1", "https://key@sentry.example.com/"} {
+		if err := Configure(Options{DSN: dsn}); err == nil {
+			t.Errorf("Configure(%q) should fail", dsn)
+		}
+	}
+	if err := Configure(Options{}); err != nil || Enabled() {
+		t.Errorf("an empty DSN should disable reporting: %v", err)
+	}
+	CapturePanic(context.Background(), errors.New("boom")) // no-op while disabled
+}
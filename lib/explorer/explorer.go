@@ -0,0 +1,134 @@
+// Package explorer is a constrained, read-only view over the service's main
+// tables, so operators can inspect rows without a database shell. Only the
+// tables and columns listed here can be queried; callers never supply SQL.
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/icco/recommender/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Errors callers should report as bad requests rather than failures.
+var (
+	ErrUnknownTable = errors.New("unknown table")
+	ErrBadFilter    = errors.New("bad filter")
+)
+
+// Table describes a browsable table.
+type Table struct {
+	Name    string   `json:"name"`
+	Filters []string `json:"filters"`          // columns accepted as exact-match filters
+	Search  string   `json:"search,omitempty"` // column matched case-insensitively by ?q=
+
+	model any    // for checking Filters against the schema in tests
+	order string // newest rows first
+}
+
+// Tables are the browsable tables. OAuth tokens are deliberately absent:
+// the rows are credentials.
+var Tables = []Table{
+	{Name: "movies", Filters: []string{"id", "year", "plex_rating_key"}, Search: "title", model: &models.Movie{}, order: "id DESC"},
+	{Name: "tv_shows", Filters: []string{"id", "year", "plex_rating_key"}, Search: "title", model: &models.TVShow{}, order: "id DESC"},
+	{Name: "recommendations", Filters: []string{"id", "date", "slot", "type", "year"}, Search: "title", model: &models.Recommendation{}, order: `"date" DESC, id DESC`},
+	{Name: "generation_runs", Filters: []string{"id", "date", "context", "status", "model", "provider", "strategy", "prompt_version", "prompt_canary"}, model: &models.GenerationRun{}, order: `"date" DESC, id DESC`},
+	{Name: "jobs", Filters: []string{"id", "kind", "status", "unique_key"}, Search: "last_error", model: &models.Job{}, order: "id DESC"},
+	{Name: "external_signals", Filters: []string{"id", "source", "kind", "movie_id", "tv_show_id"}, Search: "external_ref", model: &models.ExternalSignal{}, order: "id DESC"},
+	{Name: "taste_profiles", Filters: []string{"key"}, model: &models.TasteProfile{}, order: "key"},
+	{Name: "llm_usages", Filters: []string{"date"}, model: &models.LLMUsage{}, order: `"date" DESC`},
+	{Name: "leader_leases", Filters: []string{"name", "holder"}, model: &models.LeaderLease{}, order: "name"},
+	{Name: "pins", Filters: []string{"id", "date", "type", "movie_id", "tv_show_id"}, Search: "title", model: &models.Pin{}, order: `"date" DESC, id DESC`},
+	{Name: "date_notes", Filters: []string{"date"}, Search: "body", model: &models.DateNote{}, order: `"date" DESC`},
+	{Name: "spotlights", Filters: []string{"date", "auto"}, Search: "person", model: &models.Spotlight{}, order: `"date" DESC`},
+	{Name: "show_decisions", Filters: []string{"tv_show_id", "decision"}, model: &models.ShowDecision{}, order: "updated_at DESC"},
+	{Name: "votes", Filters: []string{"id", "date", "voter", "recommendation_id"}, model: &models.Vote{}, order: `"date" DESC, id DESC`},
+	{Name: "user_preferences", model: &models.UserPreference{}, order: "id"},
+	{Name: "chat_messages", Filters: []string{"id", "owner", "role"}, Search: "content", model: &models.ChatMessage{}, order: "id DESC"},
+	{Name: "title_embeddings", Filters: []string{"id", "type", "title_id", "model"}, model: &models.TitleEmbedding{}, order: "id"},
+	{Name: "because_rows", Filters: []string{"id", "seed_type", "seed_id"}, Search: "seed_title", model: &models.BecauseRow{}, order: "position"},
+	{Name: "prompt_rollouts", Filters: []string{"id", "version", "status"}, model: &models.PromptRollout{}, order: "id DESC"},
+	{Name: "maintenance_states", model: &models.MaintenanceState{}, order: "id"},
+}
+
+// Lookup returns the browsable table named name.
+func Lookup(name string) (Table, bool) {
+	i := slices.IndexFunc(Tables, func(t Table) bool { return t.Name == name })
+	if i < 0 {
+		return Table{}, false
+	}
+	return Tables[i], true
+}
+
+// Query selects a page of rows. Filters maps column to an exact value, given
+// as text and parsed by Postgres as the column's type.
+type Query struct {
+	Filters map[string]string
+	Search  string
+	Page    int
+	Size    int
+}
+
+// Page is one page of rows, each a column-to-value map.
+type Page struct {
+	Table string           `json:"table"`
+	Page  int              `json:"page"`
+	Size  int              `json:"size"`
+	Total int64            `json:"total"`
+	Rows  []map[string]any `json:"rows"`
+}
+
+// Browse returns a page of table's rows matching q, newest first. It runs in
+// a read-only transaction.
+func Browse(ctx context.Context, db *gorm.DB, table string, q Query) (*Page, error) {
+	t, ok := Lookup(table)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTable, table)
+	}
+	for col := range q.Filters {
+		if !slices.Contains(t.Filters, col) {
+			return nil, fmt.Errorf("%w: %s cannot be filtered on %q", ErrBadFilter, t.Name, col)
+		}
+	}
+	if q.Search != "" && t.Search == "" {
+		return nil, fmt.Errorf("%w: %s has no search column", ErrBadFilter, t.Name)
+	}
+
+	out := &Page{Table: t.Name, Page: q.Page, Size: q.Size, Rows: []map[string]any{}}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET TRANSACTION READ ONLY").Error; err != nil {
+			return err
+		}
+		sel := tx.Table(t.Name)
+		for _, col := range t.Filters { // fixed order keeps the SQL stable
+			if v, ok := q.Filters[col]; ok {
+				sel = sel.Where(fmt.Sprintf("%q = ?", col), v)
+			}
+		}
+		if q.Search != "" {
+			sel = sel.Where(fmt.Sprintf("%q ILIKE ?", t.Search), "%"+escapeLike(q.Search)+"%")
+		}
+		if err := sel.Count(&out.Total).Error; err != nil {
+			return err
+		}
+		return sel.Order(t.order).Limit(q.Size).Offset((q.Page - 1) * q.Size).Find(&out.Rows).Error
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") { // data exception, e.g. "abc" for an integer
+			return nil, fmt.Errorf("%w: %s", ErrBadFilter, pgErr.Message)
+		}
+		return nil, fmt.Errorf("failed to browse %s: %w", t.Name, err)
+	}
+	return out, nil
+}
+
+// escapeLike escapes LIKE wildcards so a search matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
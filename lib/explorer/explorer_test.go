@@ -0,0 +1,85 @@
+package explorer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm/schema"
+)
+
+func TestTables_matchSchema(t *testing.T) {
+	cache := &sync.Map{}
+	for _, tbl := range Tables {
+		s, err := schema.Parse(tbl.model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Table != tbl.Name {
+			t.Errorf("%s: model table is %s", tbl.Name, s.Table)
+		}
+		cols := append([]string{}, tbl.Filters...)
+		if tbl.Search != "" {
+			cols = append(cols, tbl.Search)
+		}
+		for _, col := range cols {
+			if s.LookUpField(col) == nil {
+				t.Errorf("%s has no column %q", tbl.Name, col)
+			}
+		}
+	}
+	if _, ok := Lookup("oauth_tokens"); ok {
+		t.Error("oauth_tokens must not be browsable")
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	for _, j := range []models.Job{
+		{Kind: "cache", Status: models.JobStatusFailed, RunAt: time.Now(), LastError: "plex 50%_down"},
+		{Kind: "cache", Status: models.JobStatusDone, RunAt: time.Now()},
+		{Kind: "recommend", Status: models.JobStatusFailed, RunAt: time.Now(), LastError: "model down"},
+	} {
+		if err := db.Create(&j).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := Browse(ctx, db, "jobs", Query{Filters: map[string]string{"status": "failed"}, Page: 1, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Total != 2 || len(p.Rows) != 1 || p.Rows[0]["kind"] != "recommend" {
+		t.Errorf("failed jobs = %d total, rows %v; want newest of 2 (recommend)", p.Total, p.Rows)
+	}
+
+	p, err = Browse(ctx, db, "jobs", Query{Search: "50%_", Page: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Total != 1 {
+		t.Errorf("literal search matched %d rows, want 1", p.Total)
+	}
+
+	for _, tc := range []struct {
+		table string
+		q     Query
+		want  error
+	}{
+		{"oauth_tokens", Query{Page: 1, Size: 10}, ErrUnknownTable},
+		{"jobs", Query{Filters: map[string]string{"payload": "x"}, Page: 1, Size: 10}, ErrBadFilter},
+		{"jobs", Query{Filters: map[string]string{"id": "abc"}, Page: 1, Size: 10}, ErrBadFilter},
+		{"taste_profiles", Query{Search: "x", Page: 1, Size: 10}, ErrBadFilter},
+	} {
+		if _, err := Browse(ctx, db, tc.table, tc.q); !errors.Is(err, tc.want) {
+			t.Errorf("%s %+v: err = %v, want %v", tc.table, tc.q, err, tc.want)
+		}
+	}
+}
@@ -1,5 +1,6 @@
-// Package health exposes a /health HTTP handler that reports liveness and
-// database connectivity for the recommender service.
+// Package health exposes a /health HTTP handler that reports liveness,
+// database connectivity, how Plex was last reached, and the state of the
+// recommendation pipeline for the recommender service.
 package health
 
 import (
@@ -9,12 +10,18 @@ import (
 	"time"
 
 	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/leader"
+	"github.com/icco/recommender/lib/plex"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // Health represents the health check response structure.
-// It includes the overall status, timestamp, and database health information.
+// It includes the overall status, timestamp, database health, this
+// replica's leadership role, its latest Plex reachability check, and the
+// pipeline's state. Plex being unreachable or generation falling behind
+// doesn't fail the check: pages keep working from the cache, and monitoring
+// alerts on the pipeline fields instead.
 type Health struct {
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
@@ -22,12 +29,25 @@ type Health struct {
 		Status  string `json:"status"`
 		Message string `json:"message,omitempty"`
 	} `json:"db"`
+	Leader struct {
+		Enabled  bool   `json:"enabled"`
+		IsLeader bool   `json:"is_leader"`
+		ID       string `json:"id"`
+	} `json:"leader"`
+	Plex struct {
+		Status    string     `json:"status"`          // "ok", "unreachable", or "unknown" before the first check
+		Route     string     `json:"route,omitempty"` // "direct", "remote", or "relay"
+		CheckedAt *time.Time `json:"checked_at,omitempty"`
+		Message   string     `json:"message,omitempty"`
+	} `json:"plex"`
+	Pipeline *Pipeline `json:"pipeline,omitempty"` // nil when it couldn't be loaded
 }
 
 // Check returns an HTTP handler that performs health checks on the application.
 // It verifies the database connection and returns the health status.
-// The handler returns a JSON response with the health information.
-func Check(db *gorm.DB) http.HandlerFunc {
+// The handler returns a JSON response with the health information, including
+// the pipeline from pm when it isn't nil.
+func Check(db *gorm.DB, el *leader.Elector, p *plex.Client, pm *PipelineMonitor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
@@ -36,6 +56,19 @@ func Check(db *gorm.DB) http.HandlerFunc {
 			Status:    "ok",
 			Timestamp: time.Now(),
 		}
+		health.Leader.Enabled = el.Enabled()
+		health.Leader.IsLeader = el.IsLeader()
+		health.Leader.ID = el.ID()
+		health.Plex.Status = "unknown"
+		if a := p.Availability(); !a.CheckedAt.IsZero() {
+			health.Plex.CheckedAt = &a.CheckedAt
+			health.Plex.Route = a.Route
+			health.Plex.Status = "ok"
+			if !a.Reachable {
+				health.Plex.Status = "unreachable"
+				health.Plex.Message = a.Err
+			}
+		}
 
 		sqlDB, err := db.DB()
 		if err != nil {
@@ -55,6 +88,13 @@ func Check(db *gorm.DB) http.HandlerFunc {
 		}
 
 		health.DB.Status = "ok"
+		if pm != nil {
+			if pl, err := pm.Get(ctx); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to load pipeline health", zap.Error(err))
+			} else {
+				health.Pipeline = &pl
+			}
+		}
 		writeHealth(ctx, w, health, http.StatusOK)
 	}
 }
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var meter = otel.Meter("github.com/icco/recommender/lib/health")
+
+// pipelineTTL is how long a loaded Pipeline is reused, so frequent probes
+// and scrapes don't each rebuild the candidate pool.
+const pipelineTTL = time.Minute
+
+// Pipeline is the state of the recommendation pipeline: when generation
+// and the Plex cache sync last succeeded, and how many titles are left to
+// recommend. Times are nil when unknown.
+type Pipeline struct {
+	LastGeneratedDate string     `json:"last_generated_date,omitempty"` // YYYY-MM-DD of the newest day with a successful daily run
+	LastGeneratedAt   *time.Time `json:"last_generated_at,omitempty"`   // when a daily run last succeeded
+	LastCacheSync     *time.Time `json:"last_cache_sync,omitempty"`     // when a cache sync last finished
+	Candidates        struct {
+		Movies  int `json:"movies"`
+		TVShows int `json:"tv_shows"`
+	} `json:"candidates"`
+}
+
+// PipelineFunc loads the current Pipeline.
+type PipelineFunc func(ctx context.Context) (Pipeline, error)
+
+// PipelineMonitor serves the Pipeline to /health and /metrics, loading it
+// at most once per pipelineTTL.
+type PipelineMonitor struct {
+	load PipelineFunc
+
+	mu       sync.Mutex
+	last     Pipeline
+	loadedAt time.Time
+}
+
+// NewPipelineMonitor returns a monitor that loads the Pipeline with load.
+func NewPipelineMonitor(load PipelineFunc) *PipelineMonitor {
+	return &PipelineMonitor{load: load}
+}
+
+// Get returns the Pipeline, reloading it once the cached copy is older
+// than pipelineTTL.
+func (m *PipelineMonitor) Get(ctx context.Context) (Pipeline, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.loadedAt.IsZero() && time.Since(m.loadedAt) < pipelineTTL {
+		return m.last, nil
+	}
+	p, err := m.load(ctx)
+	if err != nil {
+		return Pipeline{}, err
+	}
+	m.last, m.loadedAt = p, time.Now()
+	return p, nil
+}
+
+// RegisterMetrics exports the Pipeline for /metrics as
+// pipeline.last_generation and pipeline.last_cache_sync (Unix seconds, 0
+// when unknown) and pipeline.candidates{type}, so alerting rules can fire
+// on, say, no successful generation for 36 hours.
+func (m *PipelineMonitor) RegisterMetrics() {
+	generated, err := meter.Int64ObservableGauge("pipeline.last_generation",
+		metric.WithDescription("Unix time a daily generation run last succeeded"), metric.WithUnit("s"))
+	if err != nil {
+		return
+	}
+	synced, err := meter.Int64ObservableGauge("pipeline.last_cache_sync",
+		metric.WithDescription("Unix time a Plex cache sync last finished"), metric.WithUnit("s"))
+	if err != nil {
+		return
+	}
+	candidates, err := meter.Int64ObservableGauge("pipeline.candidates",
+		metric.WithDescription("titles the next daily run could pick from, by type"))
+	if err != nil {
+		return
+	}
+	_, _ = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		p, err := m.Get(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to load pipeline health", zap.Error(err))
+			return nil
+		}
+		o.ObserveInt64(generated, unix(p.LastGeneratedAt))
+		o.ObserveInt64(synced, unix(p.LastCacheSync))
+		o.ObserveInt64(candidates, int64(p.Candidates.Movies), metric.WithAttributes(attribute.String("type", "movie")))
+		o.ObserveInt64(candidates, int64(p.Candidates.TVShows), metric.WithAttributes(attribute.String("type", "tvshow")))
+		return nil
+	}, generated, synced, candidates)
+}
+
+func unix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPipelineMonitor_caches(t *testing.T) {
+	calls := 0
+	fail := true
+	m := NewPipelineMonitor(func(context.Context) (Pipeline, error) {
+		calls++
+		if fail {
+			return Pipeline{}, errors.New("db down")
+		}
+		var p Pipeline
+		p.Candidates.Movies = calls
+		return p, nil
+	})
+
+	if _, err := m.Get(t.Context()); err == nil {
+		t.Fatal("Get = nil error, want the load failure")
+	}
+	fail = false
+	p, err := m.Get(t.Context())
+	if err != nil || p.Candidates.Movies != 2 {
+		t.Fatalf("Get = %+v, %v; want a reload after the failure", p, err)
+	}
+	if p, _ := m.Get(t.Context()); p.Candidates.Movies != 2 || calls != 2 {
+		t.Errorf("Get = %+v after %d loads; want the cached copy", p, calls)
+	}
+}
@@ -0,0 +1,362 @@
+// Package jobs is a small durable work queue backed by the jobs table. Work
+// that must outlive an HTTP request (cache syncs, generation, deliveries) is
+// enqueued here instead of being run in fire-and-forget goroutines, so it is
+// retried with backoff and survives restarts.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/errreport"
+	"github.com/icco/recommender/lib/sanitize"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultMaxAttempts bounds retries when Enqueue callers don't set one.
+	DefaultMaxAttempts = 5
+	pollInterval       = 5 * time.Second
+	baseBackoff        = 30 * time.Second
+	maxBackoff         = time.Hour
+	// staleAfter returns running jobs to the queue when their worker vanished
+	// (crash or restart) without finishing them.
+	staleAfter = 30 * time.Minute
+	// retainDone is how long finished jobs are kept for inspection.
+	retainDone = 7 * 24 * time.Hour
+	// maxLastError is the size of Job.LastError's varchar column.
+	maxLastError = 1000
+	// enqueueAttempts bounds Enqueue's insert-or-read-back loop.
+	enqueueAttempts = 3
+)
+
+// Handler runs one job. A returned error schedules a retry with exponential
+// backoff until the job's MaxAttempts is exhausted; return Defer to
+// reschedule without consuming an attempt.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// deferError asks the queue to retry after a delay without counting an attempt.
+type deferError struct {
+	after  time.Duration
+	reason string
+}
+
+func (e *deferError) Error() string { return "deferred: " + e.reason }
+
+// Defer returns an error that reschedules the job after d without consuming
+// an attempt — for transient conditions such as a held lock.
+func Defer(d time.Duration, reason string) error {
+	return &deferError{after: d, reason: reason}
+}
+
+// Options tune a single Enqueue call.
+type Options struct {
+	// Key de-duplicates work: while a pending or running job of the same kind
+	// and key exists, Enqueue returns it instead of adding another.
+	Key         string
+	RunAt       time.Time
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+// Queue dispatches jobs to registered handlers. Only one job runs at a time
+// per replica, which also serializes heavy work such as cache syncs and
+// generation.
+type Queue struct {
+	db       *gorm.DB
+	isLeader func() bool
+	workerID string
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+}
+
+type registration struct {
+	handler Handler
+	timeout time.Duration
+}
+
+// New returns a Queue. isLeader gates claiming so that in multi-replica
+// deployments only the leader works the queue; pass nil to always work.
+func New(db *gorm.DB, workerID string, isLeader func() bool) *Queue {
+	if isLeader == nil {
+		isLeader = func() bool { return true }
+	}
+	return &Queue{db: db, isLeader: isLeader, workerID: workerID, handlers: map[string]registration{}}
+}
+
+// Register binds kind to h. timeout bounds each run (0 = no extra bound).
+func (q *Queue) Register(kind string, timeout time.Duration, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = registration{handler: h, timeout: timeout}
+}
+
+// Enqueue persists a job and returns it. With opts.Key set, an existing
+// pending/running job of the same kind and key is returned instead (created
+// is false); the partial unique idx_jobs_active_key settles concurrent calls.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts Options) (job *models.Job, created bool, err error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal %s payload: %w", kind, err)
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RunAt.IsZero() {
+		opts.RunAt = time.Now().UTC()
+	}
+	db := q.db.WithContext(ctx)
+	// The active job that blocked the insert can finish before it is read
+	// back; then the insert is tried again.
+	for range enqueueAttempts {
+		job = &models.Job{
+			Kind: kind, UniqueKey: opts.Key, Payload: string(raw), Status: models.JobStatusPending,
+			MaxAttempts: opts.MaxAttempts, RunAt: opts.RunAt,
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+		if res.Error != nil {
+			return nil, false, fmt.Errorf("enqueue %s: %w", kind, res.Error)
+		}
+		if res.RowsAffected > 0 {
+			return job, true, nil
+		}
+		var existing []models.Job
+		if err := db.Where("kind = ? AND unique_key = ? AND status IN ?", kind, opts.Key,
+			[]string{models.JobStatusPending, models.JobStatusRunning}).
+			Limit(1).Find(&existing).Error; err != nil {
+			return nil, false, fmt.Errorf("enqueue %s: load active job: %w", kind, err)
+		}
+		if len(existing) > 0 {
+			return &existing[0], false, nil
+		}
+	}
+	return nil, false, fmt.Errorf("enqueue %s: active job %q kept changing", kind, opts.Key)
+}
+
+// Active returns pending and running jobs, oldest first.
+func (q *Queue) Active(ctx context.Context) ([]models.Job, error) {
+	var out []models.Job
+	if err := q.db.WithContext(ctx).
+		Where("status IN ?", []string{models.JobStatusPending, models.JobStatusRunning}).
+		Order("run_at, id").Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("list active jobs: %w", err)
+	}
+	return out, nil
+}
+
+// LastDone returns when the most recent job of kind finished successfully,
+// or the zero time when none has since finished jobs were last pruned.
+func (q *Queue) LastDone(ctx context.Context, kind string) (time.Time, error) {
+	var at sql.NullTime
+	if err := q.db.WithContext(ctx).Model(&models.Job{}).
+		Where("kind = ? AND status = ?", kind, models.JobStatusDone).
+		Select("MAX(updated_at)").Row().Scan(&at); err != nil {
+		return time.Time{}, fmt.Errorf("last done %s: %w", kind, err)
+	}
+	return at.Time, nil
+}
+
+// Run works the queue until ctx is canceled.
+func (q *Queue) Run(ctx context.Context) {
+	l := logging.FromContext(ctx)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastMaintenance := time.Time{}
+	for {
+		if q.isLeader() {
+			if time.Since(lastMaintenance) > time.Minute {
+				q.maintain(ctx)
+				lastMaintenance = time.Now()
+			}
+			// Drain everything that's due before sleeping again.
+			for ctx.Err() == nil {
+				ran, err := q.runNext(ctx)
+				if err != nil {
+					l.Warnw("Job queue poll failed", zap.Error(err))
+					break
+				}
+				if !ran {
+					break
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs one due job. It reports whether a job ran.
+func (q *Queue) runNext(ctx context.Context) (bool, error) {
+	job, err := q.claim(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+	l := logging.FromContext(ctx).With("job_id", job.ID, "job_kind", job.Kind, "attempt", job.Attempts)
+
+	q.mu.RLock()
+	reg, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		return true, q.finish(ctx, job, fmt.Errorf("no handler registered for %q", job.Kind), true)
+	}
+
+	runCtx := errreport.WithTags(logging.NewContext(ctx, l), "job_kind", job.Kind, "job_id", strconv.FormatUint(uint64(job.ID), 10))
+	runCtx = context.WithValue(runCtx, progressKey{}, &progress{db: q.db, id: job.ID})
+	cancel := func() {}
+	if reg.timeout > 0 {
+		runCtx, cancel = context.WithTimeout(runCtx, reg.timeout)
+	}
+	start := time.Now()
+	runErr := safeRun(runCtx, reg.handler, json.RawMessage(job.Payload))
+	cancel()
+
+	var d *deferError
+	if errors.As(runErr, &d) {
+		l.Infow("Job deferred", "reason", d.reason, "retry_in", d.after)
+		return true, q.db.WithContext(ctx).Model(job).Updates(map[string]any{
+			"status": models.JobStatusPending, "attempts": gorm.Expr("attempts - 1"),
+			"run_at": time.Now().UTC().Add(d.after), "locked_by": "", "locked_at": nil,
+		}).Error
+	}
+	if runErr != nil {
+		l.Warnw("Job failed", "duration", time.Since(start), zap.Error(runErr))
+	} else {
+		l.Infow("Job completed", "duration", time.Since(start))
+	}
+	return true, q.finish(ctx, job, runErr, false)
+}
+
+// progressKey carries the running job's *progress in its handler's context.
+type progressKey struct{}
+
+// progress records how far one running job has got.
+type progress struct {
+	db *gorm.DB
+	id uint
+
+	mu   sync.Mutex
+	last int
+}
+
+// Progress records that the job running with ctx is pct percent done, for
+// status displays such as the home page's banner. Only changes are written,
+// and outside a job it does nothing, so library code can report freely.
+func Progress(ctx context.Context, pct int) {
+	p, ok := ctx.Value(progressKey{}).(*progress)
+	if !ok {
+		return
+	}
+	pct = min(max(pct, 0), 100)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pct == p.last {
+		return
+	}
+	if err := p.db.WithContext(ctx).Model(&models.Job{}).Where("id = ?", p.id).Update("progress", pct).Error; err != nil {
+		logging.FromContext(ctx).Debugw("Failed to record job progress", zap.Error(err))
+		return
+	}
+	p.last = pct
+}
+
+// safeRun converts a handler panic into an error so one bad job can't kill
+// the worker loop.
+func safeRun(ctx context.Context, h Handler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errreport.CapturePanic(ctx, r)
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(ctx, payload)
+}
+
+// claim atomically moves the oldest due pending job to running. SKIP LOCKED
+// lets several workers poll the same table without double-claiming.
+func (q *Queue) claim(ctx context.Context) (*models.Job, error) {
+	now := time.Now().UTC()
+	var job models.Job
+	res := q.db.WithContext(ctx).Raw(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, progress = 0, locked_by = ?, locked_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs WHERE status = ? AND run_at <= ?
+			ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobStatusRunning, q.workerID, now, now, models.JobStatusPending, now).Scan(&job)
+	if res.Error != nil {
+		return nil, fmt.Errorf("claim job: %w", res.Error)
+	}
+	if job.ID == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// finish records a job's outcome: done, retry with backoff, or failed once
+// attempts are exhausted (or permanent is set).
+func (q *Queue) finish(ctx context.Context, job *models.Job, runErr error, permanent bool) error {
+	updates := map[string]any{"locked_by": "", "locked_at": nil, "updated_at": time.Now().UTC()}
+	switch {
+	case runErr == nil:
+		updates["status"] = models.JobStatusDone
+		updates["last_error"] = ""
+	case permanent || job.Attempts >= job.MaxAttempts:
+		updates["status"] = models.JobStatusFailed
+		updates["last_error"] = sanitize.Truncate(runErr.Error(), maxLastError)
+	default:
+		updates["status"] = models.JobStatusPending
+		updates["last_error"] = sanitize.Truncate(runErr.Error(), maxLastError)
+		updates["run_at"] = time.Now().UTC().Add(Backoff(job.Attempts))
+	}
+	if err := q.db.WithContext(ctx).Model(job).Updates(updates).Error; err != nil {
+		return fmt.Errorf("record job %d outcome: %w", job.ID, err)
+	}
+	return nil
+}
+
+// maintain requeues jobs orphaned by a dead worker and prunes old finished jobs.
+func (q *Queue) maintain(ctx context.Context) {
+	l := logging.FromContext(ctx)
+	now := time.Now().UTC()
+	res := q.db.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, now.Add(-staleAfter)).
+		Updates(map[string]any{"status": models.JobStatusPending, "locked_by": "", "locked_at": nil, "run_at": now})
+	if res.Error != nil {
+		l.Warnw("Failed to requeue stale jobs", zap.Error(res.Error))
+	} else if res.RowsAffected > 0 {
+		l.Warnw("Requeued stale running jobs", "count", res.RowsAffected)
+	}
+	if err := q.db.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []string{models.JobStatusDone, models.JobStatusFailed}, now.Add(-retainDone)).
+		Delete(&models.Job{}).Error; err != nil {
+		l.Warnw("Failed to prune finished jobs", zap.Error(err))
+	}
+}
+
+// Backoff is the delay before retry number attempt (1-based): 30s doubling,
+// capped at an hour.
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
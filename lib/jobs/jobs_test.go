@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, time.Hour},
+	} {
+		if got := Backoff(tc.attempt); got != tc.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestQueue_retryDeferAndDedupe(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	q := New(db, "test", nil)
+
+	calls := 0
+	q.Register("flaky", 0, func(context.Context, json.RawMessage) error {
+		calls++
+		switch calls {
+		case 1:
+			return Defer(0, "busy")
+		case 2:
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	job, created, err := q.Enqueue(ctx, "flaky", map[string]int{"n": 1}, Options{Key: "k"})
+	if err != nil || !created {
+		t.Fatalf("Enqueue = %v, %v", created, err)
+	}
+	if dup, created, err := q.Enqueue(ctx, "flaky", nil, Options{Key: "k"}); err != nil || created || dup.ID != job.ID {
+		t.Fatalf("duplicate Enqueue = %+v, %v, %v; want existing job", dup, created, err)
+	}
+
+	// Deferred: back to pending with the attempt refunded.
+	if ran, err := q.runNext(ctx); err != nil || !ran {
+		t.Fatalf("runNext = %v, %v", ran, err)
+	}
+	var got models.Job
+	db.First(&got, job.ID)
+	if got.Status != models.JobStatusPending || got.Attempts != 0 {
+		t.Fatalf("after defer: status=%s attempts=%d", got.Status, got.Attempts)
+	}
+
+	// Failed: retried later with backoff, so nothing is due right now.
+	if _, err := q.runNext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&got, job.ID)
+	if got.Status != models.JobStatusPending || got.Attempts != 1 || got.LastError != "boom" || !got.RunAt.After(time.Now()) {
+		t.Fatalf("after failure: %+v", got)
+	}
+	if ran, _ := q.runNext(ctx); ran {
+		t.Fatal("job ran before its backoff elapsed")
+	}
+
+	db.Model(&got).Update("run_at", time.Now().Add(-time.Second))
+	if _, err := q.runNext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&got, job.ID)
+	if got.Status != models.JobStatusDone {
+		t.Fatalf("after success: status=%s", got.Status)
+	}
+}
+
+func TestQueue_concurrentEnqueue(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	q := New(db, "test", nil)
+
+	const n = 8
+	ids := make(chan uint, n)
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			job, ok, err := q.Enqueue(t.Context(), "sync", nil, Options{Key: "full"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				created.Add(1)
+			}
+			ids <- job.ID
+		})
+	}
+	wg.Wait()
+	close(ids)
+	if created.Load() != 1 {
+		t.Errorf("%d concurrent Enqueues created %d jobs, want 1", n, created.Load())
+	}
+	var first uint
+	for id := range ids {
+		if first == 0 {
+			first = id
+		}
+		if id != first {
+			t.Errorf("Enqueue returned jobs %d and %d for one key", first, id)
+		}
+	}
+	var count int64
+	db.Model(&models.Job{}).Where("kind = ?", "sync").Count(&count)
+	if count != 1 {
+		t.Errorf("jobs stored = %d, want 1", count)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	Progress(t.Context(), 50) // outside a job: nothing to record, no panic
+
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	q := New(db, "test", nil)
+	var seen int
+	q.Register("sync", 0, func(ctx context.Context, _ json.RawMessage) error {
+		Progress(ctx, 40)
+		Progress(ctx, 140) // capped
+		var j models.Job
+		db.Where("kind = ?", "sync").First(&j)
+		seen = j.Progress
+		return nil
+	})
+	if _, _, err := q.Enqueue(ctx, "sync", nil, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.runNext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if seen != 100 {
+		t.Errorf("progress while running = %d, want 100", seen)
+	}
+}
+
+func TestQueue_LastDone(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	q := New(db, "test", nil)
+	if at, err := q.LastDone(ctx, "sync"); err != nil || !at.IsZero() {
+		t.Fatalf("LastDone with no jobs = %v, %v; want zero", at, err)
+	}
+	done := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, j := range []models.Job{
+		{Kind: "sync", Status: models.JobStatusDone, RunAt: done, UpdatedAt: done},
+		{Kind: "sync", Status: models.JobStatusFailed, RunAt: done, UpdatedAt: done.Add(time.Hour)},
+		{Kind: "other", Status: models.JobStatusDone, RunAt: done, UpdatedAt: done.Add(2 * time.Hour)},
+	} {
+		if err := db.Create(&j).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if at, err := q.LastDone(ctx, "sync"); err != nil || !at.Equal(done) {
+		t.Errorf("LastDone = %v, %v; want %v", at, err, done)
+	}
+}
@@ -0,0 +1,154 @@
+// Package leader implements lease-based leader election on the shared
+// database so that, in multi-replica deployments, exactly one replica runs
+// background jobs while every replica keeps serving reads.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DefaultTTL is how long a lease stays valid without renewal. Leaders renew
+// every TTL/3, so a crashed leader is replaced within one TTL.
+const DefaultTTL = 30 * time.Second
+
+var meter = otel.Meter("github.com/icco/recommender/lib/leader")
+
+// Elector competes for a named lease. A disabled Elector (single-replica
+// mode) always reports itself as leader.
+type Elector struct {
+	db      *gorm.DB
+	name    string
+	id      string
+	ttl     time.Duration
+	enabled bool
+	leader  atomic.Bool
+}
+
+// New returns an Elector for the lease called name. It is not leader until
+// Run acquires the lease.
+func New(db *gorm.DB, name string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	e := &Elector{
+		db:      db,
+		name:    name,
+		id:      fmt.Sprintf("%s-%d-%04x", host, os.Getpid(), rand.Intn(1<<16)), //nolint:gosec // uniqueness suffix, not security-sensitive
+		ttl:     ttl,
+		enabled: true,
+	}
+	e.registerMetrics()
+	return e
+}
+
+// Disabled returns an Elector that is always leader, for single-replica
+// deployments where no coordination is needed.
+func Disabled() *Elector {
+	e := &Elector{name: "disabled", id: "standalone"}
+	e.leader.Store(true)
+	e.registerMetrics()
+	return e
+}
+
+// IsLeader reports whether this replica currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Enabled reports whether election is active (false in single-replica mode).
+func (e *Elector) Enabled() bool {
+	return e.enabled
+}
+
+// ID is this replica's identity as recorded in the lease.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// Run acquires and renews the lease until ctx is canceled, then releases it
+// so another replica can take over without waiting for expiry. It is a no-op
+// for a disabled Elector.
+func (e *Elector) Run(ctx context.Context) {
+	if !e.enabled {
+		return
+	}
+	l := logging.FromContext(ctx)
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		ok, err := e.tryAcquire(ctx)
+		if err != nil {
+			l.Warnw("Leader lease renewal failed", "lease", e.name, zap.Error(err))
+			ok = false
+		}
+		if was := e.leader.Swap(ok); was != ok {
+			l.Infow("Leadership changed", "lease", e.name, "id", e.id, "leader", ok)
+		}
+		select {
+		case <-ctx.Done():
+			e.release(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquire claims or renews the lease in one statement: the upsert only
+// takes effect when this replica already holds the lease or it has expired.
+func (e *Elector) tryAcquire(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+	res := e.db.WithContext(ctx).Exec(`
+		INSERT INTO leader_leases (name, holder, expires_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.updated_at
+		WHERE leader_leases.holder = EXCLUDED.holder OR leader_leases.expires_at < ?`,
+		e.name, e.id, now.Add(e.ttl), now, now)
+	if res.Error != nil {
+		return false, fmt.Errorf("acquire lease %s: %w", e.name, res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// release expires the lease if this replica holds it.
+func (e *Elector) release(ctx context.Context) {
+	e.leader.Store(false)
+	if err := e.db.WithContext(ctx).Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ?", e.name, e.id).
+		Update("expires_at", time.Unix(0, 0).UTC()).Error; err != nil {
+		logging.FromContext(ctx).Warnw("Failed to release leader lease", "lease", e.name, zap.Error(err))
+	}
+}
+
+// registerMetrics exports leadership as a 0/1 gauge for /metrics.
+func (e *Elector) registerMetrics() {
+	gauge, err := meter.Int64ObservableGauge("leader.is_leader",
+		metric.WithDescription("1 when this replica holds the leader lease, else 0"))
+	if err != nil {
+		return
+	}
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		v := int64(0)
+		if e.IsLeader() {
+			v = 1
+		}
+		o.ObserveInt64(gauge, v, metric.WithAttributes(attribute.String("lease", e.name)))
+		return nil
+	}, gauge)
+}
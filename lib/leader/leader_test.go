@@ -0,0 +1,41 @@
+package leader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestTryAcquire_singleHolderUntilRelease(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.LeaderLease{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	a := New(db, "scheduler", time.Minute)
+	b := New(db, "scheduler", time.Minute)
+
+	if ok, err := a.tryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("a acquire = %v, %v; want true", ok, err)
+	}
+	if ok, err := b.tryAcquire(ctx); err != nil || ok {
+		t.Fatalf("b acquire while a holds = %v, %v; want false", ok, err)
+	}
+	if ok, err := a.tryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("a renew = %v, %v; want true", ok, err)
+	}
+
+	a.release(ctx)
+	if ok, err := b.tryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("b acquire after release = %v, %v; want true", ok, err)
+	}
+}
+
+func TestDisabled_alwaysLeader(t *testing.T) {
+	e := Disabled()
+	if !e.IsLeader() || e.Enabled() {
+		t.Errorf("disabled elector: leader=%v enabled=%v", e.IsLeader(), e.Enabled())
+	}
+}
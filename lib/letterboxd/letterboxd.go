@@ -0,0 +1,160 @@
+// Package letterboxd reads a Letterboxd account export (Settings → Import &
+// Export on letterboxd.com): the films a user logged as watched and their
+// star ratings. Letterboxd's API is invitation-only, so the export is the
+// only way in.
+package letterboxd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Film is one watched film, with its rating in stars (0.5–5; 0 = unrated).
+type Film struct {
+	Name   string
+	Year   int
+	Rating float64
+}
+
+// MaxFileBytes bounds each CSV Parse decompresses from an export zip. A
+// heavy user's diary is a few megabytes.
+const MaxFileBytes = 16 << 20
+
+// ErrTooLarge is returned by Parse for an export zip holding a CSV larger
+// than MaxFileBytes once decompressed.
+var ErrTooLarge = fmt.Errorf("export CSV is larger than %d bytes uncompressed", MaxFileBytes)
+
+// exportFiles are the CSVs Parse reads from an export zip. The watchlist
+// and lists in the same zip are films not yet watched, so they're skipped.
+var exportFiles = []string{"watched.csv", "ratings.csv", "diary.csv"}
+
+// Parse reads an export zip, or a single watched.csv, ratings.csv, or
+// diary.csv from one. Every film in it counts as watched. A film listed more
+// than once (a diary rewatch, or the same film in two files) appears once,
+// with its last non-zero rating.
+func Parse(b []byte) ([]Film, error) {
+	if !bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		return merge(nil, bytes.NewReader(b))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("read export zip: %w", err)
+	}
+	var films []Film
+	found := false
+	for _, name := range exportFiles {
+		f, err := zr.Open(name)
+		if err != nil {
+			continue
+		}
+		found = true
+		// A small zip can inflate to gigabytes; read no more than the cap.
+		csvBytes, err := io.ReadAll(io.LimitReader(f, MaxFileBytes+1))
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(csvBytes) > MaxFileBytes {
+			return nil, fmt.Errorf("%s: %w", name, ErrTooLarge)
+		}
+		if films, err = merge(films, bytes.NewReader(csvBytes)); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if !found {
+		return nil, errors.New("export zip has no watched.csv, ratings.csv, or diary.csv")
+	}
+	return films, nil
+}
+
+// merge adds the films of one CSV to films, deduplicated by name and year.
+func merge(films []Film, r io.Reader) ([]Film, error) {
+	rows, err := parseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(films))
+	for i, f := range films {
+		index[key(f)] = i
+	}
+	for _, f := range rows {
+		i, ok := index[key(f)]
+		if !ok {
+			index[key(f)] = len(films)
+			films = append(films, f)
+			continue
+		}
+		if f.Rating > 0 {
+			films[i].Rating = f.Rating
+		}
+	}
+	return films, nil
+}
+
+func key(f Film) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(f.Name), f.Year)
+}
+
+// parseCSV reads one export CSV, finding its columns by header.
+func parseCSV(r io.Reader) ([]Film, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")] = i
+	}
+	name, ok := cols["Name"]
+	if !ok {
+		return nil, errors.New(`CSV has no "Name" column; is it a Letterboxd export?`)
+	}
+	year, ok := cols["Year"]
+	if !ok {
+		return nil, errors.New(`CSV has no "Year" column; is it a Letterboxd export?`)
+	}
+	rating, hasRating := cols["Rating"]
+	field := func(rec []string, i int) string {
+		if i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var films []Film
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return films, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+		f := Film{Name: field(rec, name)}
+		if f.Name == "" {
+			continue
+		}
+		if s := field(rec, year); s != "" {
+			if f.Year, err = strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("line %d: year %q is not a number", line, s)
+			}
+		}
+		if s := field(rec, rating); hasRating && s != "" {
+			if f.Rating, err = strconv.ParseFloat(s, 64); err != nil || f.Rating < 0 || f.Rating > 5 {
+				return nil, fmt.Errorf("line %d: rating %q is not 0.5–5 stars", line, s)
+			}
+		}
+		films = append(films, f)
+	}
+}
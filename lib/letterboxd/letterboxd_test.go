@@ -0,0 +1,110 @@
+package letterboxd
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse_csv(t *testing.T) {
+	films, err := Parse([]byte("\ufeffDate,Name,Year,Letterboxd URI,Rating\n" +
+		"2024-01-02,Heat,1995,https://boxd.it/a,4.5\n" +
+		"2024-01-03,\"Crouching Tiger, Hidden Dragon\",2000,https://boxd.it/b,\n" +
+		"2024-02-01,heat,1995,https://boxd.it/c,5\n" +
+		"2024-02-02,,2001,https://boxd.it/d,3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 2 {
+		t.Fatalf("Parse = %+v, want two films", films)
+	}
+	if f := films[0]; f.Name != "Heat" || f.Year != 1995 || f.Rating != 5 {
+		t.Errorf("first film = %+v, want Heat (1995) with its later rating of 5", f)
+	}
+	if f := films[1]; f.Name != "Crouching Tiger, Hidden Dragon" || f.Year != 2000 || f.Rating != 0 {
+		t.Errorf("second film = %+v, want an unrated Crouching Tiger, Hidden Dragon (2000)", f)
+	}
+}
+
+func TestParse_zip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"watched.csv":   "Date,Name,Year,Letterboxd URI\n2024-01-02,Heat,1995,https://boxd.it/a\n2024-01-03,Ran,1985,https://boxd.it/b\n",
+		"ratings.csv":   "Date,Name,Year,Letterboxd URI,Rating\n2024-01-02,Heat,1995,https://boxd.it/a,4\n",
+		"watchlist.csv": "Date,Name,Year,Letterboxd URI\n2024-01-04,Alien,1979,https://boxd.it/c\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	films, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 2 || films[0].Name != "Heat" || films[0].Rating != 4 || films[1].Name != "Ran" || films[1].Rating != 0 {
+		t.Errorf("Parse = %+v, want Heat rated 4 and an unrated Ran, without the watchlist", films)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for name, tt := range map[string]struct {
+		body string
+		want string
+	}{
+		"empty":      {"", "empty"},
+		"not export": {"title,year\nHeat,1995\n", `no "Name" column`},
+		"bad year":   {"Name,Year\nHeat,soon\n", "line 2: year"},
+		"bad rating": {"Name,Year,Rating\nHeat,1995,9\n", "line 2: rating"},
+		"empty zip":  {"PK\x03\x04", "zip"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_zipBomb(t *testing.T) {
+	_, err := Parse(oversizedZip(t))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Parse error = %v, want ErrTooLarge", err)
+	}
+}
+
+// oversizedZip returns a small export zip whose watched.csv decompresses to
+// more than MaxFileBytes.
+func oversizedZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("watched.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []byte("2024-01-02,Heat,1995,https://boxd.it/a\n")
+	if _, err := w.Write([]byte("Date,Name,Year,Letterboxd URI\n")); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n <= MaxFileBytes; n += len(row) {
+		if _, err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
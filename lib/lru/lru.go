@@ -0,0 +1,127 @@
+// Package lru is a small, concurrency-safe least-recently-used cache with an
+// optional per-entry TTL, so in-memory caches stay bounded without a cleanup
+// goroutine: expired entries are dropped when looked up or pushed out.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/icco/recommender/lib/cachestats"
+)
+
+// Cache holds up to a maximum number of entries, dropping the least recently
+// used to make room. The zero value is not usable; call New.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	max     int           // <= 0: no entry limit
+	ttl     time.Duration // <= 0: entries never expire
+	order   *list.List    // front is most recently used
+	entries map[K]*list.Element
+	stats   *cachestats.Counter
+	now     func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	val     V
+	expires time.Time
+}
+
+// New returns a cache of at most max entries that each live for ttl. Its
+// hits, misses, evictions, and size are reported as cache name in
+// cachestats; "" leaves it uncounted.
+func New[K comparable, V any](name string, max int, ttl time.Duration) *Cache[K, V] {
+	c := &Cache[K, V]{
+		max:     max,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: map[K]*list.Element{},
+		now:     time.Now,
+	}
+	if name != "" {
+		c.stats = cachestats.New(name, c.Len)
+	}
+	return c
+}
+
+// Get returns key's value and marks it recently used. An expired entry is
+// dropped and reported as missing.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if ok && c.expired(el) {
+		c.remove(el)
+		c.stats.Evict(1)
+		ok = false
+	}
+	if !ok {
+		c.stats.Miss()
+		var zero V
+		return zero, false
+	}
+	c.stats.Hit()
+	c.order.MoveToFront(el)
+	return el.Value.(*entry[K, V]).val, true
+}
+
+// Add stores val under key, replacing any existing value, and evicts the
+// least recently used entries beyond the limit.
+func (c *Cache[K, V]) Add(key K, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.val, e.expires = val, expires
+		c.order.MoveToFront(el)
+	} else {
+		c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, val: val, expires: expires})
+	}
+	c.trim()
+}
+
+// Len returns the number of entries held, including expired ones not yet
+// dropped.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Resize changes the entry limit and TTL, evicting entries beyond the new
+// limit. A new TTL applies to entries added from then on.
+func (c *Cache[K, V]) Resize(max int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max, c.ttl = max, ttl
+	c.trim()
+}
+
+// trim drops expired entries from the cold end, then least recently used
+// entries until the cache fits its limit. c.mu must be held.
+func (c *Cache[K, V]) trim() {
+	for el := c.order.Back(); el != nil && c.expired(el); el = c.order.Back() {
+		c.remove(el)
+		c.stats.Evict(1)
+	}
+	for c.max > 0 && c.order.Len() > c.max {
+		c.remove(c.order.Back())
+		c.stats.Evict(1)
+	}
+}
+
+func (c *Cache[K, V]) expired(el *list.Element) bool {
+	e := el.Value.(*entry[K, V])
+	return !e.expires.IsZero() && !c.now().Before(e.expires)
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,64 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/cachestats"
+)
+
+func TestCache_evictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string, int]("test_lru", 2, 0)
+	stats := cachestats.New("test_lru", nil)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v", v, ok)
+	}
+	c.Add("c", 3) // b is now the least recently used
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("a should have survived")
+	}
+	c.Add("a", 10)
+	if v, _ := c.Get("a"); v != 10 || c.Len() != 2 {
+		t.Errorf("Add should replace in place: a = %d, len %d", v, c.Len())
+	}
+
+	c.Resize(1, 0)
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d after shrinking to 1", c.Len())
+	}
+	if s := stats.Stats(); s.Hits != 3 || s.Misses != 1 || s.Evictions != 2 {
+		t.Errorf("stats = %+v, want 3 hits, 1 miss, 2 evictions", s)
+	}
+}
+
+func TestCache_ttl(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New[string, int]("", 0, time.Hour)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", 1)
+	now = now.Add(30 * time.Minute)
+	c.Add("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a should live for an hour")
+	}
+
+	now = now.Add(45 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("a should have expired")
+	}
+	c.Add("c", 3)
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want b and c", c.Len())
+	}
+	now = now.Add(time.Hour)
+	c.Add("d", 4)
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, expired entries should be dropped on Add", c.Len())
+	}
+}
@@ -0,0 +1,122 @@
+// Package maintenance is the operator switch for maintenance mode (e.g. while
+// the Plex server is migrated): background jobs pause and writes are refused
+// while every read endpoint stays up. The state lives in the database, so all
+// replicas follow it and it survives restarts.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refreshInterval is how quickly replicas pick up a change made elsewhere.
+const refreshInterval = 10 * time.Second
+
+// stateID is the primary key of the single maintenance_states row.
+const stateID = 1
+
+// State is the current maintenance mode.
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+}
+
+// Switch caches the shared maintenance state for cheap per-request checks.
+type Switch struct {
+	db *gorm.DB
+
+	mu    sync.RWMutex
+	state State
+}
+
+// New returns a Switch that reports maintenance off until Refresh or Run
+// loads the stored state.
+func New(db *gorm.DB) *Switch {
+	return &Switch{db: db}
+}
+
+// State returns the cached state.
+func (s *Switch) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Enabled reports whether maintenance mode is on.
+func (s *Switch) Enabled() bool {
+	return s.State().Enabled
+}
+
+// Set turns maintenance mode on or off for every replica. reason is shown on
+// the maintenance page.
+func (s *Switch) Set(ctx context.Context, enabled bool, reason string) (State, error) {
+	if !enabled {
+		reason = ""
+	}
+	row := models.MaintenanceState{ID: stateID, Enabled: enabled, Reason: reason}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "reason", "updated_at"}),
+	}).Create(&row).Error; err != nil {
+		return State{}, fmt.Errorf("save maintenance state: %w", err)
+	}
+	logging.FromContext(ctx).Infow("Maintenance mode changed", "enabled", enabled, "reason", reason)
+	return s.store(row), nil
+}
+
+// Refresh reloads the state from the database.
+func (s *Switch) Refresh(ctx context.Context) error {
+	var row models.MaintenanceState
+	err := s.db.WithContext(ctx).Take(&row, stateID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.store(models.MaintenanceState{})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load maintenance state: %w", err)
+	}
+	s.store(row)
+	return nil
+}
+
+// Run refreshes the state until ctx is canceled. On a failed refresh the last
+// known state is kept.
+func (s *Switch) Run(ctx context.Context) {
+	l := logging.FromContext(ctx)
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		was := s.Enabled()
+		if err := s.Refresh(ctx); err != nil {
+			l.Warnw("Maintenance state refresh failed", zap.Error(err))
+		} else if now := s.Enabled(); now != was {
+			l.Infow("Maintenance mode changed elsewhere", "enabled", now)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Switch) store(row models.MaintenanceState) State {
+	st := State{Enabled: row.Enabled, Reason: row.Reason}
+	if row.Enabled {
+		st.Since = row.UpdatedAt
+	}
+	s.mu.Lock()
+	s.state = st
+	s.mu.Unlock()
+	return st
+}
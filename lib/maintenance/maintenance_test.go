@@ -0,0 +1,44 @@
+package maintenance
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/models"
+)
+
+func TestSwitch_sharedAcrossReplicas(t *testing.T) {
+	db := dbtest.New(t)
+	if err := db.AutoMigrate(&models.MaintenanceState{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	a, b := New(db), New(db)
+
+	if err := b.Refresh(ctx); err != nil || b.Enabled() {
+		t.Fatalf("no row: enabled=%v, err=%v; want off", b.Enabled(), err)
+	}
+	st, err := a.Set(ctx, true, "moving Plex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Enabled || st.Since.IsZero() || !a.Enabled() {
+		t.Fatalf("Set on = %+v", st)
+	}
+	if err := b.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.State(); !got.Enabled || got.Reason != "moving Plex" {
+		t.Errorf("other replica sees %+v", got)
+	}
+
+	if _, err := a.Set(ctx, false, "ignored"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.State(); got.Enabled || got.Reason != "" {
+		t.Errorf("after off, other replica sees %+v", got)
+	}
+}
@@ -0,0 +1,126 @@
+// Package mal is a minimal MyAnimeList API v2 client for a user's public
+// anime list, scores, and completed titles, used as recommendation ranking
+// signals. It needs only an API client ID, not a user login.
+package mal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultURL = "https://api.myanimelist.net/v2"
+
+// maxPages bounds paging through a very long list.
+const maxPages = 20
+
+// Client queries the MyAnimeList API. URL is overridable for tests.
+type Client struct {
+	URL        string
+	clientID   string
+	httpClient *http.Client
+}
+
+// NewClient returns a MyAnimeList client for the API client ID.
+func NewClient(clientID string) *Client {
+	return &Client{URL: defaultURL, clientID: clientID, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Entry is one rated or completed anime from a user's list, score 0..10
+// (0 = unscored).
+type Entry struct {
+	Title     string
+	Year      int
+	Score     float64
+	Completed bool
+}
+
+type listPage struct {
+	Data []struct {
+		Node struct {
+			Title             string `json:"title"`
+			AlternativeTitles struct {
+				En string `json:"en"`
+			} `json:"alternative_titles"`
+			StartSeason struct {
+				Year int `json:"year"`
+			} `json:"start_season"`
+		} `json:"node"`
+		ListStatus struct {
+			Status string  `json:"status"`
+			Score  float64 `json:"score"`
+		} `json:"list_status"`
+	} `json:"data"`
+	Paging struct {
+		Next string `json:"next"`
+	} `json:"paging"`
+}
+
+// List returns the user's rated (score > 0) and completed anime. Titles
+// prefer the English name, as AniList's do.
+func (c *Client) List(ctx context.Context, username string) ([]Entry, error) {
+	q := url.Values{}
+	q.Set("fields", "list_status,alternative_titles,start_season")
+	q.Set("limit", "1000")
+	q.Set("nsfw", "true")
+	next := c.URL + "/users/" + url.PathEscape(username) + "/animelist?" + q.Encode()
+
+	var entries []Entry
+	for range maxPages {
+		page, err := c.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range page.Data {
+			completed := d.ListStatus.Status == "completed"
+			if d.ListStatus.Score <= 0 && !completed {
+				continue
+			}
+			title := d.Node.AlternativeTitles.En
+			if title == "" {
+				title = d.Node.Title
+			}
+			if title == "" {
+				continue
+			}
+			entries = append(entries, Entry{
+				Title: title, Year: d.Node.StartSeason.Year,
+				Score: d.ListStatus.Score, Completed: completed,
+			})
+		}
+		if page.Paging.Next == "" {
+			break
+		}
+		next = page.Paging.Next
+	}
+	return entries, nil
+}
+
+func (c *Client) get(ctx context.Context, u string) (*listPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-MAL-CLIENT-ID", c.clientID)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("mal: HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	var page listPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("decode mal: %w", err)
+	}
+	return &page, nil
+}
@@ -0,0 +1,47 @@
+package mal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestList_pagesAndPicksTitle(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MAL-CLIENT-ID") != "cid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("offset") == "" {
+			_, _ = w.Write([]byte(`{"data":[
+				{"node":{"title":"Kimetsu no Yaiba","alternative_titles":{"en":"Demon Slayer"},"start_season":{"year":2019}},"list_status":{"status":"completed","score":9}},
+				{"node":{"title":"Plan","start_season":{"year":2020}},"list_status":{"status":"plan_to_watch","score":0}}
+			],"paging":{"next":"` + srvURL + `/users/nat/animelist?offset=2"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"node":{"title":"Mushishi","start_season":{"year":2005}},"list_status":{"status":"completed","score":0}}
+		],"paging":{}}`))
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	c := NewClient("cid")
+	c.URL = srv.URL
+	entries, err := c.List(context.Background(), "nat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the completed entries from both pages, got %+v", entries)
+	}
+	if e := entries[0]; e.Title != "Demon Slayer" || e.Year != 2019 || e.Score != 9 || !e.Completed {
+		t.Errorf("bad first entry: %+v", e)
+	}
+	if e := entries[1]; e.Title != "Mushishi" || e.Score != 0 || !e.Completed {
+		t.Errorf("bad second entry: %+v", e)
+	}
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+// maxExplanation bounds how many characters of a pick's explanation a chat
+// digest shows, well inside Discord's and Slack's per-field limits.
+const maxExplanation = 1000
+
+// ParsePublicURL checks NOTIFY_PUBLIC_URL, the address this server is
+// reached at, which chat digests need to link to the day and show posters
+// served from here. Empty means unset and returns nil.
+func ParsePublicURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if err := checkURL(raw); err != nil {
+		return nil, fmt.Errorf("NOTIFY_PUBLIC_URL: %w", err)
+	}
+	u, _ := url.Parse(strings.TrimSuffix(raw, "/"))
+	return u, nil
+}
+
+// heading titles a day's digest.
+func heading(day v1.Recommendations) string {
+	h := "Picks for " + day.Date
+	if day.Theme != "" {
+		h += ": " + day.Theme
+	}
+	return h
+}
+
+// label names a pick with its year.
+func label(rec v1.Recommendation) string {
+	if rec.Year > 0 {
+		return fmt.Sprintf("%s (%d)", rec.Title, rec.Year)
+	}
+	return rec.Title
+}
+
+// details is a pick's genre and length on one line.
+func details(rec v1.Recommendation) string {
+	var parts []string
+	if rec.Genre != "" {
+		parts = append(parts, rec.Genre)
+	}
+	switch {
+	case rec.Runtime > 0:
+		parts = append(parts, fmt.Sprintf("%d min", rec.Runtime))
+	case rec.Seasons == 1:
+		parts = append(parts, "1 season")
+	case rec.Seasons > 1:
+		parts = append(parts, fmt.Sprintf("%d seasons", rec.Seasons))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// dayURL links to the page for date, or is empty without a public URL.
+func dayURL(public *url.URL, date string) string {
+	if public == nil {
+		return ""
+	}
+	return public.JoinPath("date", date).String()
+}
+
+// absURL resolves ref, such as a poster URL, against the public URL. An
+// already absolute ref is kept; a relative one is dropped without a public
+// URL, since chat services can't fetch it.
+func absURL(public *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || ref == "" {
+		return ""
+	}
+	if u.IsAbs() {
+		return ref
+	}
+	if public == nil {
+		return ""
+	}
+	return public.ResolveReference(u).String()
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+// maxDiscordEmbeds is how many embeds Discord accepts in one message.
+const maxDiscordEmbeds = 10
+
+// Discord posts each day's picks to a Discord channel webhook as a digest:
+// the day's heading, then an embed per pick with its poster, details, and
+// explanation.
+type Discord struct {
+	URL string
+	// PublicURL makes page and poster links absolute; without it the
+	// embeds aren't linked and posters served from here are left out.
+	PublicURL  *url.URL
+	Retry      Retry
+	httpClient *http.Client
+}
+
+// NewDiscord returns a Discord sink for the webhook URL rawURL.
+func NewDiscord(rawURL string, public *url.URL) (*Discord, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	return &Discord{URL: rawURL, PublicURL: public, Retry: DefaultRetry, httpClient: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Name identifies the sink in logs by host; the webhook's token is in its
+// path.
+func (d *Discord) Name() string {
+	u, _ := url.Parse(d.URL)
+	return "discord:" + u.Host
+}
+
+// Send posts day's digest.
+func (d *Discord) Send(ctx context.Context, day v1.Recommendations) error {
+	body, err := json.Marshal(discordMessage(day, d.PublicURL))
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	return post(ctx, d.httpClient, d.URL, body, d.Retry)
+}
+
+type discordPayload struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string        `json:"title"`
+	URL         string        `json:"url,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Thumbnail   *discordImage `json:"thumbnail,omitempty"`
+	Footer      *discordText  `json:"footer,omitempty"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+type discordText struct {
+	Text string `json:"text"`
+}
+
+// discordMessage is the webhook body for day. Discord caps a message at
+// maxDiscordEmbeds embeds, more than a day's picks.
+func discordMessage(day v1.Recommendations, public *url.URL) discordPayload {
+	link := dayURL(public, day.Date)
+	msg := discordPayload{Content: "**" + heading(day) + "**"}
+	if link != "" {
+		// Angle brackets stop Discord adding its own preview of the page.
+		msg.Content += "\n<" + link + ">"
+	}
+	for _, rec := range day.Recommendations {
+		if len(msg.Embeds) == maxDiscordEmbeds {
+			break
+		}
+		e := discordEmbed{Title: label(rec), URL: link, Description: truncate(rec.Explanation, maxExplanation)}
+		if poster := absURL(public, rec.PosterURL); poster != "" {
+			e.Thumbnail = &discordImage{URL: poster}
+		}
+		if d := details(rec); d != "" {
+			e.Footer = &discordText{Text: d}
+		}
+		msg.Embeds = append(msg.Embeds, e)
+	}
+	return msg
+}
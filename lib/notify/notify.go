@@ -0,0 +1,108 @@
+// Package notify announces a day's recommendations to other services once
+// they are generated. Each destination is a Sink that retries its own failed
+// deliveries, so one that is down doesn't hold up or duplicate the others.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+// Sink delivers a day's picks somewhere.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, day v1.Recommendations) error
+}
+
+// Notifier sends each day's picks to every configured sink.
+type Notifier struct {
+	sinks []Sink
+}
+
+// New returns a Notifier for sinks; with none it does nothing.
+func New(sinks ...Sink) *Notifier {
+	return &Notifier{sinks: sinks}
+}
+
+// Enabled reports whether any sink is configured. A nil Notifier has none.
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.sinks) > 0
+}
+
+// Notify sends day to every sink. Every sink is tried; the joined error
+// names the ones that failed after their retries.
+func (n *Notifier) Notify(ctx context.Context, day v1.Recommendations) error {
+	if !n.Enabled() {
+		return nil
+	}
+	var errs []error
+	for _, s := range n.sinks {
+		if err := s.Send(ctx, day); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+			continue
+		}
+		logging.FromContext(ctx).Infow("Sent recommendations notification", "sink", s.Name(), "date", day.Date, "picks", len(day.Recommendations))
+	}
+	return errors.Join(errs...)
+}
+
+// FromEnv builds the Notifier from the environment. Each kind of sink reads
+// a comma-separated list of URLs from NOTIFY_<KIND>_URLS (WEBHOOK, DISCORD,
+// or SLACK) and an enable flag, NOTIFY_<KIND>_ENABLED, which is on unless
+// set to false, so a kind can be paused without unsetting its URLs. The chat
+// digests link back here through NOTIFY_PUBLIC_URL. getenv is os.Getenv
+// outside tests.
+func FromEnv(getenv func(string) string) (*Notifier, error) {
+	public, err := ParsePublicURL(getenv("NOTIFY_PUBLIC_URL"))
+	if err != nil {
+		return nil, err
+	}
+	kinds := []struct {
+		name string
+		sink func(rawURL string) (Sink, error)
+	}{
+		{"WEBHOOK", func(u string) (Sink, error) { return NewWebhook(u) }},
+		{"DISCORD", func(u string) (Sink, error) { return NewDiscord(u, public) }},
+		{"SLACK", func(u string) (Sink, error) { return NewSlack(u, public) }},
+	}
+	var sinks []Sink
+	for _, k := range kinds {
+		on, err := enabled(getenv, "NOTIFY_"+k.name+"_ENABLED")
+		if err != nil {
+			return nil, err
+		}
+		if !on {
+			continue
+		}
+		for raw := range strings.SplitSeq(getenv("NOTIFY_"+k.name+"_URLS"), ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			s, err := k.sink(raw)
+			if err != nil {
+				return nil, fmt.Errorf("NOTIFY_%s_URLS: %w", k.name, err)
+			}
+			sinks = append(sinks, s)
+		}
+	}
+	return New(sinks...), nil
+}
+
+// enabled reads the enable flag key: true when unset.
+func enabled(getenv func(string) string, key string) (bool, error) {
+	v := getenv(key)
+	if v == "" {
+		return true, nil
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return on, nil
+}
@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+var testDay = v1.Recommendations{
+	Date:            "2026-05-01",
+	Theme:           "Heists",
+	Recommendations: []v1.Recommendation{{Title: "Heat", Type: "movie"}},
+}
+
+// testWebhook is a webhook to srv that retries without waiting.
+func testWebhook(t *testing.T, srv *httptest.Server) *Webhook {
+	t.Helper()
+	w, err := NewWebhook(srv.URL + "/hook?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Retry.Backoff = time.Millisecond
+	return w
+}
+
+func TestWebhook_retriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	var got v1.Recommendations
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	if err := testWebhook(t, srv).Send(t.Context(), testDay); err != nil {
+		t.Fatalf("Send = %v, want success on the third try", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server saw %d requests, want 3", calls.Load())
+	}
+	if got.Date != testDay.Date || len(got.Recommendations) != 1 || got.Recommendations[0].Title != "Heat" {
+		t.Errorf("posted %+v, want the day's picks", got)
+	}
+}
+
+func TestWebhook_givesUp(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		calls  int32
+	}{
+		{http.StatusBadRequest, 1},          // not worth retrying
+		{http.StatusInternalServerError, 3}, // retried until out of attempts
+	} {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			http.Error(w, "nope", tt.status)
+		}))
+		err := testWebhook(t, srv).Send(t.Context(), testDay)
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), "nope") {
+			t.Errorf("status %d: Send = %v, want an error with the response body", tt.status, err)
+		}
+		if calls.Load() != tt.calls {
+			t.Errorf("status %d: server saw %d requests, want %d", tt.status, calls.Load(), tt.calls)
+		}
+	}
+}
+
+func TestWebhook_errorHidesURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	w := testWebhook(t, srv)
+	srv.Close()
+	err := w.Send(t.Context(), testDay)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Send = %v, want an error without the URL's token", err)
+	}
+	if name := w.Name(); strings.Contains(name, "secret") || !strings.HasPrefix(name, "webhook:127.0.0.1") {
+		t.Errorf("Name = %q, want the host alone", name)
+	}
+}
+
+type fakeSink struct {
+	name string
+	err  error
+	sent int
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) Send(context.Context, v1.Recommendations) error {
+	f.sent++
+	return f.err
+}
+
+func TestNotifier_triesEverySink(t *testing.T) {
+	down := &fakeSink{name: "down", err: errors.New("timeout")}
+	up := &fakeSink{name: "up"}
+	err := New(down, up).Notify(t.Context(), testDay)
+	if err == nil || !strings.Contains(err.Error(), "down: timeout") {
+		t.Errorf("Notify = %v, want the failed sink named", err)
+	}
+	if down.sent != 1 || up.sent != 1 {
+		t.Errorf("sent down %d, up %d times; want each once", down.sent, up.sent)
+	}
+
+	var off *Notifier
+	if off.Enabled() || off.Notify(t.Context(), testDay) != nil {
+		t.Error("nil Notifier should be disabled and do nothing")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+	n, err := FromEnv(env(map[string]string{"NOTIFY_WEBHOOK_URLS": "https://a.example/hook, ,http://b.example"}))
+	if err != nil || len(n.sinks) != 2 {
+		t.Fatalf("FromEnv = %+v, %v; want two webhooks", n, err)
+	}
+	n, err = FromEnv(env(map[string]string{
+		"NOTIFY_WEBHOOK_URLS": "https://a.example/hook",
+		"NOTIFY_DISCORD_URLS": "https://discord.com/api/webhooks/1/tok",
+		"NOTIFY_SLACK_URLS":   "https://hooks.slack.com/services/T/B/x",
+		"NOTIFY_PUBLIC_URL":   "https://recs.example/",
+	}))
+	if err != nil || len(n.sinks) != 3 {
+		t.Fatalf("FromEnv = %+v, %v; want a sink of each kind", n, err)
+	}
+	if d, ok := n.sinks[1].(*Discord); !ok || d.PublicURL.String() != "https://recs.example" {
+		t.Errorf("sinks[1] = %+v, want Discord linking to https://recs.example", n.sinks[1])
+	}
+	n, err = FromEnv(env(map[string]string{"NOTIFY_WEBHOOK_URLS": "https://a.example/hook", "NOTIFY_WEBHOOK_ENABLED": "false"}))
+	if err != nil || n.Enabled() {
+		t.Errorf("FromEnv = %+v, %v; want webhooks switched off", n, err)
+	}
+	if n, err := FromEnv(env(nil)); err != nil || n.Enabled() {
+		t.Errorf("FromEnv with nothing set = %+v, %v; want no sinks", n, err)
+	}
+	for _, bad := range []map[string]string{
+		{"NOTIFY_WEBHOOK_URLS": "ftp://a.example"},
+		{"NOTIFY_WEBHOOK_URLS": "https://a.example", "NOTIFY_WEBHOOK_ENABLED": "yes please"},
+		{"NOTIFY_SLACK_URLS": "hooks.slack.com/services/T/B/x"},
+		{"NOTIFY_PUBLIC_URL": "recs.example"},
+	} {
+		if _, err := FromEnv(env(bad)); err == nil {
+			t.Errorf("FromEnv(%v) = nil error, want it rejected", bad)
+		}
+	}
+}
+
+var chatDay = v1.Recommendations{
+	Date:  "2026-05-01",
+	Theme: "Heists",
+	Recommendations: []v1.Recommendation{
+		{Title: "Heat", Year: 1995, Genre: "Crime", Runtime: 170, PosterURL: "/plex/image/heat?exp=1", Explanation: "De Niro & Pacino <finally> share a scene."},
+		{Title: "Leverage", Seasons: 5, PosterURL: "https://image.tmdb.org/t/p/w500/l.jpg"},
+	},
+}
+
+func TestDiscordMessage(t *testing.T) {
+	public, _ := ParsePublicURL("https://recs.example")
+	msg := discordMessage(chatDay, public)
+	if want := "**Picks for 2026-05-01: Heists**\n<https://recs.example/date/2026-05-01>"; msg.Content != want {
+		t.Errorf("Content = %q, want %q", msg.Content, want)
+	}
+	if len(msg.Embeds) != 2 {
+		t.Fatalf("got %d embeds, want one per pick", len(msg.Embeds))
+	}
+	heat := msg.Embeds[0]
+	if heat.Title != "Heat (1995)" || heat.URL != "https://recs.example/date/2026-05-01" ||
+		heat.Thumbnail == nil || heat.Thumbnail.URL != "https://recs.example/plex/image/heat?exp=1" ||
+		heat.Footer == nil || heat.Footer.Text != "Crime · 170 min" || !strings.HasPrefix(heat.Description, "De Niro") {
+		t.Errorf("Heat embed = %+v", heat)
+	}
+
+	// Without a public URL, nothing links here and only absolute posters stay.
+	msg = discordMessage(chatDay, nil)
+	if strings.Contains(msg.Content, "http") || msg.Embeds[0].URL != "" || msg.Embeds[0].Thumbnail != nil {
+		t.Errorf("message = %+v, want no links to this server", msg)
+	}
+	if th := msg.Embeds[1].Thumbnail; th == nil || th.URL != chatDay.Recommendations[1].PosterURL {
+		t.Errorf("Leverage thumbnail = %+v, want the TMDb poster", th)
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	public, _ := ParsePublicURL("https://recs.example")
+	msg := slackMessage(chatDay, public)
+	if msg.Text != "Picks for 2026-05-01: Heists — Heat, Leverage" {
+		t.Errorf("Text = %q", msg.Text)
+	}
+	if len(msg.Blocks) != 3 || msg.Blocks[0].Type != "header" {
+		t.Fatalf("Blocks = %+v, want a header and a section per pick", msg.Blocks)
+	}
+	heat := msg.Blocks[1]
+	want := "*<https://recs.example/date/2026-05-01|Heat (1995)>*\nCrime · 170 min\nDe Niro &amp; Pacino &lt;finally&gt; share a scene."
+	if heat.Text.Text != want {
+		t.Errorf("Heat text = %q, want %q", heat.Text.Text, want)
+	}
+	if heat.Accessory == nil || heat.Accessory.ImageURL != "https://recs.example/plex/image/heat?exp=1" {
+		t.Errorf("Heat accessory = %+v, want the poster", heat.Accessory)
+	}
+	if got := slackMessage(chatDay, nil).Blocks[2].Text.Text; got != "*Leverage*\n5 seasons" {
+		t.Errorf("Leverage text = %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("héllo world", 6); got != "héllo…" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate("short", 6); got != "short" {
+		t.Errorf("truncate = %q", got)
+	}
+}
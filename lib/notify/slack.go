@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+// maxSlackHeader is how long Slack lets a header block's text be.
+const maxSlackHeader = 150
+
+// Slack posts each day's picks to a Slack incoming webhook as a digest: a
+// header, then a section per pick with its details and explanation beside
+// the poster.
+type Slack struct {
+	URL string
+	// PublicURL makes page and poster links absolute; without it titles
+	// aren't linked and posters served from here are left out.
+	PublicURL  *url.URL
+	Retry      Retry
+	httpClient *http.Client
+}
+
+// NewSlack returns a Slack sink for the incoming webhook URL rawURL.
+func NewSlack(rawURL string, public *url.URL) (*Slack, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	return &Slack{URL: rawURL, PublicURL: public, Retry: DefaultRetry, httpClient: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Name identifies the sink in logs by host; the webhook's secret is in its
+// path.
+func (s *Slack) Name() string {
+	u, _ := url.Parse(s.URL)
+	return "slack:" + u.Host
+}
+
+// Send posts day's digest.
+func (s *Slack) Send(ctx context.Context, day v1.Recommendations) error {
+	body, err := json.Marshal(slackMessage(day, s.PublicURL))
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	return post(ctx, s.httpClient, s.URL, body, s.Retry)
+}
+
+type slackPayload struct {
+	Text   string       `json:"text"` // for notifications and clients without blocks
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type      string      `json:"type"`
+	Text      *slackText  `json:"text,omitempty"`
+	Accessory *slackImage `json:"accessory,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"` // "plain_text" or "mrkdwn"
+	Text string `json:"text"`
+}
+
+type slackImage struct {
+	Type     string `json:"type"` // "image"
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// slackMessage is the webhook body for day.
+func slackMessage(day v1.Recommendations, public *url.URL) slackPayload {
+	link := dayURL(public, day.Date)
+	titles := make([]string, 0, len(day.Recommendations))
+	msg := slackPayload{Blocks: []slackBlock{{
+		Type: "header",
+		Text: &slackText{Type: "plain_text", Text: truncate(heading(day), maxSlackHeader)},
+	}}}
+	for _, rec := range day.Recommendations {
+		titles = append(titles, rec.Title)
+		title := "*" + slackEscape(label(rec)) + "*"
+		if link != "" {
+			title = "*<" + link + "|" + slackEscape(label(rec)) + ">*"
+		}
+		lines := []string{title}
+		if d := details(rec); d != "" {
+			lines = append(lines, slackEscape(d))
+		}
+		if rec.Explanation != "" {
+			lines = append(lines, slackEscape(truncate(rec.Explanation, maxExplanation)))
+		}
+		b := slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: strings.Join(lines, "\n")}}
+		if poster := absURL(public, rec.PosterURL); poster != "" {
+			b.Accessory = &slackImage{Type: "image", ImageURL: poster, AltText: rec.Title + " poster"}
+		}
+		msg.Blocks = append(msg.Blocks, b)
+	}
+	msg.Text = heading(day)
+	if len(titles) > 0 {
+		msg.Text += " — " + strings.Join(titles, ", ")
+	}
+	return msg
+}
+
+// slackEscape escapes the characters Slack's mrkdwn treats as markup.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/lib/api/v1"
+)
+
+// Retry is how a sink retries a failed delivery: up to Attempts tries in
+// all, waiting Backoff before the second and twice as long before each one
+// after. Network errors, 429s, and 5xx responses are retried; other
+// responses fail at once.
+type Retry struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// DefaultRetry is the Retry sinks use unless told otherwise.
+var DefaultRetry = Retry{Attempts: 3, Backoff: 2 * time.Second}
+
+// Webhook POSTs each day's picks as JSON, in the v1.Recommendations shape
+// /api/recommendations serves, to a URL.
+type Webhook struct {
+	URL        string
+	Retry      Retry
+	httpClient *http.Client
+}
+
+// NewWebhook returns a webhook sink for the http or https URL rawURL.
+func NewWebhook(rawURL string) (*Webhook, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	return &Webhook{URL: rawURL, Retry: DefaultRetry, httpClient: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Name identifies the sink in logs by host, keeping any token in the URL's
+// path or query out of them.
+func (w *Webhook) Name() string {
+	u, _ := url.Parse(w.URL)
+	return "webhook:" + u.Host
+}
+
+// Send POSTs day.
+func (w *Webhook) Send(ctx context.Context, day v1.Recommendations) error {
+	body, err := json.Marshal(day)
+	if err != nil {
+		return fmt.Errorf("encode picks: %w", err)
+	}
+	return post(ctx, w.httpClient, w.URL, body, w.Retry)
+}
+
+// checkURL requires an absolute http or https URL.
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", rawURL)
+	}
+	return nil
+}
+
+// post sends body as JSON to target, retrying as r says.
+func post(ctx context.Context, client *http.Client, target string, body []byte, r Retry) error {
+	wait := r.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = postOnce(ctx, client, target, body)
+		if err == nil || !retry || attempt >= r.Attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up retrying: %w)", err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// postOnce makes one delivery attempt and says whether a failure is worth
+// retrying.
+func postOnce(ctx context.Context, client *http.Client, target string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "recommender")
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the *url.Error wrapper, which would put the URL, and any
+		// token in it, into logs.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return true, fmt.Errorf("post: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	err = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
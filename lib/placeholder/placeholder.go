@@ -0,0 +1,93 @@
+// Package placeholder renders text-on-color SVG posters for titles that have
+// no usable artwork, so cards never fall back to a third-party image host.
+package placeholder
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"strings"
+)
+
+// palette holds the background colors placeholders rotate through. Each is
+// dark enough for white text to stay readable.
+var palette = []string{
+	"#4f46e5", // indigo (matches the favicon)
+	"#0f766e",
+	"#b45309",
+	"#be123c",
+	"#7c3aed",
+	"#1d4ed8",
+	"#15803d",
+	"#9d174d",
+}
+
+const (
+	width        = 500
+	height       = 750
+	maxLineChars = 18
+	maxLines     = 4
+)
+
+// Color returns the palette color for title. The same title always maps to
+// the same color so a card doesn't flicker between page loads.
+func Color(title string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(strings.TrimSpace(title))))
+	return palette[h.Sum32()%uint32(len(palette))] //nolint:gosec // palette length is a small constant
+}
+
+// SVG renders a poster-shaped placeholder with the title (and year, when
+// non-zero) centered on a title-derived background color.
+func SVG(title string, year int) []byte {
+	lines := wrap(strings.TrimSpace(title), maxLineChars, maxLines)
+	if len(lines) == 0 {
+		lines = []string{"No Poster"}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`, width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, width, height, Color(title))
+
+	const lineHeight = 56
+	y := height/2 - (len(lines)-1)*lineHeight/2
+	for _, line := range lines {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" fill="#fff" font-family="system-ui,sans-serif" font-size="44" font-weight="600">%s</text>`,
+			width/2, y, html.EscapeString(line))
+		y += lineHeight
+	}
+	if year > 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" fill="#fff" fill-opacity="0.75" font-family="system-ui,sans-serif" font-size="32">%d</text>`,
+			width/2, y+lineHeight/2, year)
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+// wrap splits s on word boundaries into at most maxLines lines of roughly
+// lineChars characters, ellipsizing whatever doesn't fit.
+func wrap(s string, lineChars, maxLines int) []string {
+	words := strings.Fields(s)
+	var lines []string
+	var cur string
+	for i, w := range words {
+		switch {
+		case cur == "":
+			cur = w
+		case len(cur)+1+len(w) <= lineChars:
+			cur += " " + w
+		default:
+			lines = append(lines, cur)
+			cur = w
+		}
+		if len(lines) == maxLines {
+			lines[maxLines-1] += "…"
+			return lines
+		}
+		if i == len(words)-1 {
+			lines = append(lines, cur)
+		}
+	}
+	return lines
+}
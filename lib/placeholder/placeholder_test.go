@@ -0,0 +1,41 @@
+package placeholder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColor_stablePerTitle(t *testing.T) {
+	if Color("Arrival") != Color(" arrival ") {
+		t.Error("color should ignore case and surrounding space")
+	}
+	seen := map[string]bool{}
+	for _, title := range []string{"Arrival", "Heat", "Alien", "Up", "Jaws", "Ran", "Her", "Big"} {
+		seen[Color(title)] = true
+	}
+	if len(seen) < 2 {
+		t.Error("different titles should spread across the palette")
+	}
+}
+
+func TestSVG_escapesAndWraps(t *testing.T) {
+	out := string(SVG(`Tom & Jerry <The Movie>`, 2021))
+	if strings.Contains(out, "<The") || !strings.Contains(out, "&amp;") {
+		t.Errorf("title not escaped: %s", out)
+	}
+	if !strings.Contains(out, ">2021<") {
+		t.Error("year missing")
+	}
+
+	long := string(SVG(strings.Repeat("word ", 40), 0))
+	if n := strings.Count(long, "<text"); n != maxLines {
+		t.Errorf("got %d lines, want %d", n, maxLines)
+	}
+	if !strings.Contains(long, "…") {
+		t.Error("overflow should be ellipsized")
+	}
+
+	if !strings.Contains(string(SVG("", 0)), "No Poster") {
+		t.Error("empty title should render generic label")
+	}
+}
@@ -0,0 +1,209 @@
+package plex
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/plextv"
+	"github.com/icco/recommender/lib/wol"
+	"go.uber.org/zap"
+)
+
+// pingTimeout bounds the reachability check; a sleeping server never answers.
+const pingTimeout = 5 * time.Second
+
+// DefaultWakeWait is how long EnsureAwake waits for a woken server to answer.
+const DefaultWakeWait = 2 * time.Minute
+
+// wakePollInterval is how often EnsureAwake re-pings a waking server.
+var wakePollInterval = 5 * time.Second
+
+// Routes to the server, as Availability reports them.
+const (
+	RouteDirect = "direct" // PLEX_URL
+	RouteRemote = "remote" // a public address plex.tv lists for the server
+	RouteRelay  = "relay"  // Plex's relay service, bandwidth-limited
+)
+
+// Availability is the outcome of this replica's latest reachability check.
+type Availability struct {
+	Reachable bool
+	Route     string    // how the server was reached; empty when it wasn't
+	CheckedAt time.Time // zero until the first Ping
+	Since     time.Time // when the current reachable/unreachable state began
+	Err       string    // why the last check failed
+}
+
+// SetRemote enables remote access: when PLEX_URL doesn't answer, Ping asks
+// plex.tv where the server can be reached and uses the first public address,
+// or else the relay, that does. serverID picks one of the account's servers
+// by machine identifier; "" picks the one it owns. The next Ping that
+// reaches PLEX_URL switches back.
+func (c *Client) SetRemote(tv *plextv.Client, serverID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remote = tv
+	c.serverID = serverID
+}
+
+// Ping checks that the server answers its /identity endpoint, falling back
+// to remote access when it's configured, and records the outcome for
+// Availability.
+func (c *Client) Ping(ctx context.Context) error {
+	route, base, token := RouteDirect, "", ""
+	err := c.ping(ctx, strings.TrimRight(c.plexURL, "/"), c.plexToken)
+	c.mu.Lock()
+	remote := c.remote
+	c.mu.Unlock()
+	if err != nil && remote != nil {
+		var rerr error
+		if route, base, token, rerr = c.findRemote(ctx, remote); rerr == nil {
+			err = nil
+		} else {
+			err = fmt.Errorf("%w; remote access: %w", err, rerr)
+		}
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		if route != c.avail.Route && !c.avail.CheckedAt.IsZero() {
+			logging.FromContext(ctx).Infow("Plex route changed", "from", c.avail.Route, "to", route)
+		}
+		c.base, c.token = base, token
+	}
+	if c.avail.CheckedAt.IsZero() || c.avail.Reachable != (err == nil) {
+		c.avail.Since = now
+	}
+	c.avail.Reachable = err == nil
+	c.avail.Route = ""
+	if err == nil {
+		c.avail.Route = route
+	}
+	c.avail.CheckedAt = now
+	c.avail.Err = ""
+	if err != nil {
+		c.avail.Err = err.Error()
+	}
+	return err
+}
+
+// findRemote returns the first of the server's remote addresses that
+// answers: public ones before the relay. Local addresses are skipped, since
+// PLEX_URL already didn't answer.
+func (c *Client) findRemote(ctx context.Context, tv *plextv.Client) (route, base, token string, err error) {
+	c.mu.Lock()
+	serverID := c.serverID
+	c.mu.Unlock()
+	resources, err := tv.Resources(ctx, c.plexToken)
+	if err != nil {
+		return "", "", "", err
+	}
+	i := slices.IndexFunc(resources, func(r plextv.Resource) bool {
+		return r.IsServer() && (r.ClientIdentifier == serverID || serverID == "" && r.Owned)
+	})
+	if i < 0 {
+		return "", "", "", errors.New("plex.tv lists no such server for this account")
+	}
+	server := resources[i]
+	token = cmp.Or(server.AccessToken, c.plexToken)
+	conns := slices.DeleteFunc(slices.Clone(server.Connections), func(cn plextv.Connection) bool { return cn.Local })
+	slices.SortStableFunc(conns, func(a, b plextv.Connection) int {
+		switch {
+		case a.Relay == b.Relay:
+			return 0
+		case b.Relay:
+			return -1
+		}
+		return 1
+	})
+	err = errors.New("the server has no remote addresses")
+	for _, cn := range conns {
+		base = strings.TrimRight(cn.URI, "/")
+		if err = c.ping(ctx, base, token); err == nil {
+			if cn.Relay {
+				return RouteRelay, base, token, nil
+			}
+			return RouteRemote, base, token, nil
+		}
+	}
+	return "", "", "", err
+}
+
+func (c *Client) ping(ctx context.Context, base, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/identity", nil)
+	if err != nil {
+		return fmt.Errorf("build plex ping: %w", err)
+	}
+	req.Header.Set("X-Plex-Token", token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("plex unreachable: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("plex ping: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SetWake enables Wake-on-LAN: EnsureAwake sends target a magic packet when
+// the server doesn't answer, then waits up to wait for it to come up.
+func (c *Client) SetWake(target wol.Target, wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wake = &target
+	c.wakeWait = wait
+}
+
+// EnsureAwake pings the server and, if it doesn't answer and Wake-on-LAN is
+// set, wakes it and waits for it to respond. It returns the last ping error.
+func (c *Client) EnsureAwake(ctx context.Context) error {
+	err := c.Ping(ctx)
+	c.mu.Lock()
+	target, wait := c.wake, c.wakeWait
+	c.mu.Unlock()
+	if err == nil || target == nil {
+		return err
+	}
+
+	l := logging.FromContext(ctx)
+	if err := target.Send(ctx); err != nil {
+		l.Warnw("Failed to send Wake-on-LAN packet", "mac", target.MAC.String(), zap.Error(err))
+		return err
+	}
+	l.Infow("Sent Wake-on-LAN packet; waiting for Plex", "mac", target.MAC.String(), "wait", wait)
+	deadline := time.Now().Add(wait)
+	ticker := time.NewTicker(wakePollInterval)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err = c.Ping(ctx); err == nil {
+			l.Infow("Plex is awake")
+			return nil
+		}
+	}
+	return err
+}
+
+// Availability returns the latest Ping outcome.
+func (c *Client) Availability() Availability {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.avail
+}
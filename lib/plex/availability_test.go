@@ -0,0 +1,154 @@
+package plex
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/plextv"
+	"github.com/icco/recommender/lib/wol"
+)
+
+func TestPing_tracksAvailability(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identity" || r.Header.Get("X-Plex-Token") != "tok" {
+			t.Errorf("unexpected request %s (token %q)", r.URL.Path, r.Header.Get("X-Plex-Token"))
+		}
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	c := &Client{plexURL: srv.URL + "/", plexToken: "tok"}
+
+	if got := c.Availability(); !got.CheckedAt.IsZero() {
+		t.Fatalf("before any ping: %+v", got)
+	}
+	if err := c.Ping(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	first := c.Availability()
+	if !first.Reachable || first.Since.IsZero() {
+		t.Fatalf("after ok ping: %+v", first)
+	}
+	if err := c.Ping(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Availability(); !got.Since.Equal(first.Since) {
+		t.Error("Since should not move while the state holds")
+	}
+
+	down.Store(true)
+	if err := c.Ping(t.Context()); err == nil {
+		t.Fatal("want an error from a 503")
+	}
+	got := c.Availability()
+	if got.Reachable || got.Err == "" || !got.Since.After(first.Since) {
+		t.Errorf("after failed ping: %+v", got)
+	}
+}
+
+func TestEnsureAwake_wakesAndWaits(t *testing.T) {
+	wakePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { wakePollInterval = 5 * time.Second })
+
+	var awake atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !awake.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = pc.Close() }()
+	go func() {
+		buf := make([]byte, 200)
+		if _, _, err := pc.ReadFrom(buf); err == nil {
+			awake.Store(true)
+		}
+	}()
+
+	c := &Client{plexURL: srv.URL}
+	if err := c.EnsureAwake(t.Context()); err == nil {
+		t.Fatal("without Wake-on-LAN a sleeping server should stay down")
+	}
+	target, err := wol.ParseTarget("00:11:22:33:44:55", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetWake(target, 5*time.Second)
+	if err := c.EnsureAwake(t.Context()); err != nil {
+		t.Fatalf("EnsureAwake: %v", err)
+	}
+	if !c.Availability().Reachable {
+		t.Error("availability should show the server up")
+	}
+}
+
+func TestPing_fallsBackToRemoteAccess(t *testing.T) {
+	var homeDown atomic.Bool
+	home := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if homeDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer home.Close()
+	away := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "server-tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer away.Close()
+	tv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/resources" || r.Header.Get("X-Plex-Token") != "tok" {
+			t.Errorf("unexpected plex.tv request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`[
+			{"name":"Phone","provides":"client","owned":true,"connections":[]},
+			{"name":"Home","provides":"server","owned":true,"clientIdentifier":"abc","accessToken":"server-tok","connections":[
+				{"uri":"` + home.URL + `","local":true},
+				{"uri":"http://127.0.0.1:1","relay":true},
+				{"uri":"` + away.URL + `","local":false}
+			]}]`))
+	}))
+	defer tv.Close()
+
+	c := &Client{plexURL: home.URL, plexToken: "tok"}
+	remote := plextv.NewClient("test")
+	remote.URL = tv.URL
+	c.SetRemote(remote, "")
+
+	if err := c.Ping(t.Context()); err != nil || c.Availability().Route != RouteDirect {
+		t.Fatalf("home: %v, %+v", err, c.Availability())
+	}
+	homeDown.Store(true)
+	if err := c.Ping(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Availability(); !got.Reachable || got.Route != RouteRemote {
+		t.Errorf("away: %+v", got)
+	}
+	if base, token := c.conn(); base != away.URL || token != "server-tok" {
+		t.Errorf("away connection = %s, %s", base, token)
+	}
+	homeDown.Store(false)
+	if err := c.Ping(t.Context()); err != nil || c.Availability().Route != RouteDirect {
+		t.Fatalf("back home: %v, %+v", err, c.Availability())
+	}
+	if base, _ := c.conn(); base != home.URL {
+		t.Errorf("back home connection = %s", base)
+	}
+
+	c.SetRemote(remote, "other")
+	homeDown.Store(true)
+	if err := c.Ping(t.Context()); err == nil || c.Availability().Route != "" {
+		t.Errorf("unknown server should stay unreachable: %v, %+v", err, c.Availability())
+	}
+}
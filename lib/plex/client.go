@@ -5,19 +5,27 @@ package plex
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/LukeHagar/plexgo"
 	"github.com/LukeHagar/plexgo/models/components"
 	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/errreport"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/plextv"
 	"github.com/icco/recommender/lib/tmdb"
+	"github.com/icco/recommender/lib/wol"
 	"github.com/icco/recommender/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -32,31 +40,48 @@ type Client struct {
 	db        *gorm.DB
 	plexToken string
 	tmdb      *tmdb.Client
+
+	mu        sync.Mutex
+	avail     Availability
+	wake      *wol.Target // nil unless Wake-on-LAN is configured
+	wakeWait  time.Duration
+	libraries LibraryFilter
+	remote    *plextv.Client // nil unless remote access is configured
+	serverID  string         // which of the account's servers to reach remotely; "" = the owned one
+	// base and token are the remote connection Ping fell back to; empty
+	// while plexURL answers.
+	base  string
+	token string
 }
 
-const (
-	fallbackPosterURL = "https://via.placeholder.com/500x750?text=No+Poster+Available"
-	// titleKey is the shared spelling of the "title" identifier used both as a
-	// structured-log field name and as the GORM-mapped column name for the
-	// title columns on the Movie/TVShow tables.
-	titleKey = "title"
-)
+// titleKey is the shared spelling of the "title" identifier used both as a
+// structured-log field name and as the GORM-mapped column name for the title
+// columns on the Movie/TVShow tables.
+const titleKey = "title"
 
 // maxPosterBytes caps a single poster download so a misbehaving host can't fill
 // the disk.
 const maxPosterBytes = 25 << 20 // 25 MiB
 
-// DownloadImage fetches an image URL and writes it to dest. The X-Plex-Token is
-// attached only when imageURL is on the configured Plex host: thumb metadata can
-// carry absolute off-host URLs, and sending the token there would leak it and
+// DownloadImage fetches an image URL, or a path on the Plex server as stored
+// by posterPath, and writes it to dest. The X-Plex-Token is attached only
+// when the image is on the configured Plex host: thumb metadata can carry
+// absolute off-host URLs, and sending the token there would leak it and
 // allow SSRF with the service's credentials.
 func (c *Client) DownloadImage(ctx context.Context, imageURL, dest string) error {
+	base, token := c.conn()
+	if c.onServer(imageURL) {
+		imageURL = c.PlexPath(imageURL)
+	}
+	if strings.HasPrefix(imageURL, "/") {
+		imageURL = base + imageURL
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
 	if err != nil {
 		return err
 	}
-	if sameHost(imageURL, c.plexURL) {
-		req.Header.Set("X-Plex-Token", c.plexToken)
+	if sameHost(imageURL, base) {
+		req.Header.Set("X-Plex-Token", token)
 	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -80,6 +105,60 @@ func (c *Client) DownloadImage(ctx context.Context, imageURL, dest string) error
 	return nil
 }
 
+// ErrNotImage is returned by Image when Plex answers with something other
+// than an image.
+var ErrNotImage = errors.New("not an image")
+
+// Image fetches the image at path on the Plex server (e.g. a thumb's
+// /library/metadata/…), with the token, for proxying to browsers. The body
+// is capped like a poster download; the caller closes it.
+func (c *Client) Image(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	if !strings.HasPrefix(path, "/library/") || pathpkg.Clean(path) != path {
+		return nil, "", fmt.Errorf("image path %q: %w", path, ErrNotImage)
+	}
+	base, token := c.conn()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("X-Plex-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch image: %w", err)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, "", fmt.Errorf("fetch image %s: HTTP %d", path, resp.StatusCode)
+	}
+	if !strings.HasPrefix(ct, "image/") {
+		_ = resp.Body.Close()
+		return nil, "", fmt.Errorf("fetch image %s (%s): %w", path, ct, ErrNotImage)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxPosterBytes), resp.Body}, ct, nil
+}
+
+// PlexPath returns the path of a stored poster on the Plex server, so it can
+// be fetched through Image, else "". The query, which may carry a token, is
+// dropped.
+func (c *Client) PlexPath(imageURL string) string {
+	if strings.HasPrefix(imageURL, "/library/") {
+		path, _, _ := strings.Cut(imageURL, "?")
+		return path
+	}
+	if !c.onServer(imageURL) {
+		return ""
+	}
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
 // sameHost reports whether two URLs target the same host:port. A parse failure
 // on either side returns false, so the caller fails closed (no token attached).
 func sameHost(a, b string) bool {
@@ -112,6 +191,23 @@ func NewClient(plexURL, plexToken string, db *gorm.DB, tmdbClient *tmdb.Client)
 	}
 }
 
+// conn returns the base URL and token of the connection in use.
+func (c *Client) conn() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.base != "" {
+		return c.base, c.token
+	}
+	return strings.TrimRight(c.plexURL, "/"), c.plexToken
+}
+
+// onServer reports whether u is on the Plex server, by its configured or
+// current address.
+func (c *Client) onServer(u string) bool {
+	base, _ := c.conn()
+	return sameHost(u, c.plexURL) || sameHost(u, base)
+}
+
 // GetAPI returns the underlying Plex API instance for direct access to Plex API methods.
 func (c *Client) GetAPI() *plexgo.PlexAPI {
 	return c.api
@@ -122,19 +218,29 @@ func (c *Client) GetURL() string {
 	return c.plexURL
 }
 
-// resolvePosterURL returns an absolute URL for HTML img src. Plex often returns relative thumb paths.
-func (c *Client) resolvePosterURL(thumb string) string {
+// posterPath returns what to store as a title's PosterURL for a Plex thumb:
+// the path of artwork on the Plex server ("/library/metadata/…/thumb/…"), or
+// the URL of artwork hosted elsewhere, never carrying an X-Plex-Token. The
+// server address and token are added only when the poster is fetched
+// (DownloadImage, Image). An empty thumb stays empty so the web layer can
+// substitute its placeholder.
+func (c *Client) posterPath(thumb string) string {
 	if thumb == "" {
-		return fallbackPosterURL
+		return ""
 	}
-	if strings.HasPrefix(thumb, "http://") || strings.HasPrefix(thumb, "https://") {
-		return thumb
+	u, err := url.Parse(thumb)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "" || c.onServer(thumb) {
+		return "/" + strings.TrimLeft(u.Path, "/")
 	}
-	base := strings.TrimRight(c.plexURL, "/")
-	if strings.HasPrefix(thumb, "/") {
-		return base + thumb
+	q := u.Query()
+	if q.Has("X-Plex-Token") {
+		q.Del("X-Plex-Token")
+		u.RawQuery = q.Encode()
 	}
-	return base + "/" + thumb
+	return u.String()
 }
 
 // GetLibrary returns the Library API instance for accessing Plex library operations.
@@ -155,12 +261,22 @@ type LibrarySectionInfo struct {
 	UUID     string
 }
 
+// knownRating returns a copy of Plex's rating, or nil when Plex has none;
+// Plex reports an unrated title as 0, which isn't a score.
+func knownRating(r *float64) *float64 {
+	if r == nil || *r <= 0 {
+		return nil
+	}
+	v := *r
+	return &v
+}
+
 // GetAllLibraries fetches library sections (GET /library/sections/all) with a minimal decoder.
 func (c *Client) GetAllLibraries(ctx context.Context) ([]LibrarySectionInfo, error) {
 	l := logging.FromContext(ctx)
-	l.Debugw("Fetching libraries from Plex", "url", c.plexURL)
+	base, token := c.conn()
+	l.Debugw("Fetching libraries from Plex", "url", base)
 
-	base := strings.TrimRight(c.plexURL, "/")
 	reqURL, err := url.JoinPath(base, "library", "sections", "all")
 	if err != nil {
 		return nil, fmt.Errorf("failed to build library sections URL: %w", err)
@@ -171,7 +287,7 @@ func (c *Client) GetAllLibraries(ctx context.Context) ([]LibrarySectionInfo, err
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Plex-Token", c.plexToken)
+	req.Header.Set("X-Plex-Token", token)
 	req.Header.Set("User-Agent", "recommender")
 
 	httpResp, err := http.DefaultClient.Do(req)
@@ -245,23 +361,30 @@ func (c *Client) GetAllLibraries(ctx context.Context) ([]LibrarySectionInfo, err
 
 // Item represents a media item from Plex.
 type Item struct {
-	RatingKey  string
-	Key        string
-	Title      string
-	Type       string
-	Year       *int
-	Rating     *float64
-	Summary    string
-	Thumb      *string
-	Art        *string
-	Duration   *int
-	AddedAt    int64
-	UpdatedAt  *int64
-	ViewCount  *int
-	Genre      []components.Tag
-	Guids      []string
-	LeafCount  *int
-	ChildCount *int
+	RatingKey       string
+	Key             string
+	Title           string
+	Type            string
+	Year            *int
+	Rating          *float64
+	UserRating      *float64 // the account's star rating, 0–10; nil = unrated
+	Summary         string
+	Thumb           *string
+	Art             *string
+	Duration        *int
+	AddedAt         int64
+	UpdatedAt       *int64
+	ViewCount       *int
+	ContentRating   string // age rating, e.g. "PG" or "TV-Y7"; "" = unrated
+	Library         string // title of the library section it was listed in
+	Genre           []components.Tag
+	Director        []components.Tag
+	Role            []components.Tag
+	Guids           []string
+	LeafCount       *int
+	ChildCount      *int
+	ViewedLeafCount *int
+	LastViewedAt    *int64 // Unix seconds
 }
 
 // GetPlexItems lists a section via plexgo Content.ListContent (GET …/library/sections/{id}/all)
@@ -317,10 +440,7 @@ func (c *Client) GetUnwatchedMovies(ctx context.Context, libraries []components.
 				year = *item.Year
 			}
 
-			rating := 0.0
-			if item.Rating != nil {
-				rating = *item.Rating
-			}
+			rating := knownRating(item.Rating)
 
 			genre := ""
 			if len(item.Genre) > 0 {
@@ -336,7 +456,7 @@ func (c *Client) GetUnwatchedMovies(ctx context.Context, libraries []components.
 			if item.Thumb != nil {
 				thumb = *item.Thumb
 			}
-			posterURL := c.resolvePosterURL(thumb)
+			posterURL := c.posterPath(thumb)
 
 			movies = append(movies, models.Recommendation{
 				Title:     item.Title,
@@ -377,10 +497,7 @@ func (c *Client) GetUnwatchedTVShows(ctx context.Context, libraries []components
 				year = *item.Year
 			}
 
-			rating := 0.0
-			if item.Rating != nil {
-				rating = *item.Rating
-			}
+			rating := knownRating(item.Rating)
 
 			genre := ""
 			if len(item.Genre) > 0 {
@@ -396,7 +513,7 @@ func (c *Client) GetUnwatchedTVShows(ctx context.Context, libraries []components
 			if item.Thumb != nil {
 				thumb = *item.Thumb
 			}
-			posterURL := c.resolvePosterURL(thumb)
+			posterURL := c.posterPath(thumb)
 
 			shows = append(shows, models.Recommendation{
 				Title:     item.Title,
@@ -405,7 +522,7 @@ func (c *Client) GetUnwatchedTVShows(ctx context.Context, libraries []components
 				Rating:    rating,
 				Genre:     genre,
 				PosterURL: posterURL,
-				Runtime:   seasons,
+				Seasons:   seasons,
 			})
 		}
 	}
@@ -427,10 +544,12 @@ func chunkUints(ids []uint, size int) [][]uint {
 	return out
 }
 
-// removeMoviesNotInSnapshot deletes cache movies whose Plex ratingKey is not in present (and clears recommendation FKs).
-func (c *Client) removeMoviesNotInSnapshot(ctx context.Context, present map[string]struct{}) error {
+// removeMoviesNotInSnapshot soft-deletes cache movies whose Plex ratingKey is not in present, returning how many.
+// Recommendations keep their movie_id, and the row comes back if Plex lists the item again.
+func (c *Client) removeMoviesNotInSnapshot(ctx context.Context, present map[string]struct{}) (int, error) {
 	const chunk = 400
-	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	removed := 0
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var rows []models.Movie
 		if err := tx.Select("id", "plex_rating_key").Find(&rows).Error; err != nil {
 			return err
@@ -449,21 +568,21 @@ func (c *Client) removeMoviesNotInSnapshot(ctx context.Context, present map[stri
 			if len(part) == 0 {
 				continue
 			}
-			if err := tx.Exec("UPDATE recommendations SET movie_id = NULL WHERE movie_id IN ?", part).Error; err != nil {
-				return fmt.Errorf("clear recommendation movie_id refs: %w", err)
-			}
 			if err := tx.Where("id IN ?", part).Delete(&models.Movie{}).Error; err != nil {
 				return fmt.Errorf("delete stale movies: %w", err)
 			}
+			removed += len(part)
 		}
 		return nil
 	})
+	return removed, err
 }
 
-// removeTVShowsNotInSnapshot deletes cache TV rows whose Plex ratingKey is not in present (and clears recommendation FKs).
-func (c *Client) removeTVShowsNotInSnapshot(ctx context.Context, present map[string]struct{}) error {
+// removeTVShowsNotInSnapshot soft-deletes cache TV rows whose Plex ratingKey is not in present, like removeMoviesNotInSnapshot.
+func (c *Client) removeTVShowsNotInSnapshot(ctx context.Context, present map[string]struct{}) (int, error) {
 	const chunk = 400
-	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	removed := 0
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var rows []models.TVShow
 		if err := tx.Select("id", "plex_rating_key").Find(&rows).Error; err != nil {
 			return err
@@ -482,19 +601,19 @@ func (c *Client) removeTVShowsNotInSnapshot(ctx context.Context, present map[str
 			if len(part) == 0 {
 				continue
 			}
-			if err := tx.Exec("UPDATE recommendations SET tv_show_id = NULL WHERE tv_show_id IN ?", part).Error; err != nil {
-				return fmt.Errorf("clear recommendation tv_show_id refs: %w", err)
-			}
 			if err := tx.Where("id IN ?", part).Delete(&models.TVShow{}).Error; err != nil {
 				return fmt.Errorf("delete stale TV shows: %w", err)
 			}
+			removed += len(part)
 		}
 		return nil
 	})
+	return removed, err
 }
 
 // UpdateCache updates the Plex cache by fetching all libraries and their items.
-// Rows are upserted by Plex ratingKey; items no longer returned by Plex are removed.
+// Rows are upserted by Plex ratingKey, writing only new and changed items;
+// items no longer returned by Plex are soft-deleted.
 func (c *Client) UpdateCache(ctx context.Context) error {
 	l := logging.FromContext(ctx)
 	l.Infow("Starting cache update")
@@ -506,6 +625,7 @@ func (c *Client) UpdateCache(ctx context.Context) error {
 	libraries, err := c.GetAllLibraries(ctx)
 	if err != nil {
 		l.Errorw("Failed to get libraries", zap.Error(err))
+		errreport.CaptureError(ctx, err, "provider", "plex", "op", "get libraries")
 		return fmt.Errorf("failed to get libraries: %w", err)
 	}
 	l.Infow("Successfully fetched libraries", "count", len(libraries))
@@ -514,28 +634,36 @@ func (c *Client) UpdateCache(ctx context.Context) error {
 	var allTVShows []Item
 	var fetchErrCount int
 
+	// Reading the libraries is the first half of the progress reported to
+	// the job queue, writing the rows the second.
 	libs := libraries
-	for _, lib := range libs {
-		key := ""
-		if lib.Key != nil {
-			key = *lib.Key
+	filter := c.libraryFilter()
+	for i, lib := range libs {
+		jobs.Progress(ctx, 50*i/len(libs))
+		key := deref(lib.Key)
+		title := deref(lib.Title)
+		if reason := filter.skip(lib); reason != "" {
+			l.Infow("Skipping library", "library", title, "reason", reason)
+			continue
 		}
 
 		items, err := c.GetPlexItems(ctx, key, false)
 		if err != nil {
 			fetchErrCount++
-			title := ""
-			if lib.Title != nil {
-				title = *lib.Title
-			}
 			l.Errorw("Failed to get items from library",
 				"library", title,
 				zap.Error(err),
 			)
+			errreport.CaptureError(ctx, err, "provider", "plex", "op", "get library items", "library", title)
+			continue
+		}
+		if reason := filter.skipItems(lib, items); reason != "" {
+			l.Infow("Skipping library", "library", title, "reason", reason)
 			continue
 		}
 
 		for _, item := range items {
+			item.Library = title
 			if item.RatingKey == "" {
 				l.Warnw("Skipping Plex item without ratingKey",
 					titleKey, item.Title,
@@ -581,51 +709,128 @@ func (c *Client) UpdateCache(ctx context.Context) error {
 	}
 
 	const batchSize = 50
+	var moviesWritten, showsWritten int
+	total := len(allMovies) + len(allTVShows)
 	for i := 0; i < len(allMovies); i += batchSize {
+		jobs.Progress(ctx, 50+45*i/total)
 		end := i + batchSize
 		if end > len(allMovies) {
 			end = len(allMovies)
 		}
-		if err := c.upsertMovieBatch(ctx, allMovies[i:end]); err != nil {
+		n, err := c.upsertMovieBatch(ctx, allMovies[i:end])
+		if err != nil {
 			return fmt.Errorf("failed to upsert movie batch %d-%d: %w", i, end, err)
 		}
+		moviesWritten += n
 	}
 
 	for i := 0; i < len(allTVShows); i += batchSize {
+		jobs.Progress(ctx, 50+45*(len(allMovies)+i)/total)
 		end := i + batchSize
 		if end > len(allTVShows) {
 			end = len(allTVShows)
 		}
-		if err := c.upsertTVShowBatch(ctx, allTVShows[i:end]); err != nil {
+		n, err := c.upsertTVShowBatch(ctx, allTVShows[i:end])
+		if err != nil {
 			return fmt.Errorf("failed to upsert TV show batch %d-%d: %w", i, end, err)
 		}
+		showsWritten += n
 	}
 
-	if err := c.removeMoviesNotInSnapshot(ctx, movieKeys); err != nil {
+	jobs.Progress(ctx, 95)
+	moviesRemoved, err := c.removeMoviesNotInSnapshot(ctx, movieKeys)
+	if err != nil {
 		return fmt.Errorf("failed to prune stale movies: %w", err)
 	}
-	if err := c.removeTVShowsNotInSnapshot(ctx, tvKeys); err != nil {
+	showsRemoved, err := c.removeTVShowsNotInSnapshot(ctx, tvKeys)
+	if err != nil {
 		return fmt.Errorf("failed to prune stale TV shows: %w", err)
 	}
 
-	l.Infow("Successfully updated cache")
+	l.Infow("Successfully updated cache",
+		"movies_written", moviesWritten, "movies_unchanged", len(allMovies)-moviesWritten, "movies_removed", moviesRemoved,
+		"tvshows_written", showsWritten, "tvshows_unchanged", len(allTVShows)-showsWritten, "tvshows_removed", showsRemoved,
+	)
 	return nil
 }
 
-// GORM maps the TMDbID field to the tm_db_id column (see schema).
+// movieUpsertColumns are the Plex fields a sync overwrites on a changed
+// movie. External IDs are handled by upsertSet.
 var movieUpsertColumns = []string{
-	titleKey, "year", "rating", "genre", "poster_url", "runtime",
-	"tm_db_id", "im_db_id", "tv_db_id", "enriched_at", "view_count", "updated_at",
+	titleKey, "year", "rating", "genre", "director", "actors", "poster_url", "runtime", "view_count",
+	"last_viewed_at", "content_rating", "library",
 }
 
 var tvUpsertColumns = []string{
-	titleKey, "year", "rating", "genre", "poster_url", "seasons",
-	"tm_db_id", "im_db_id", "tv_db_id", "enriched_at", "view_count", "updated_at",
+	titleKey, "year", "rating", "genre", "actors", "poster_url", "seasons", "episode_runtime",
+	"episodes", "watched_episodes", "last_viewed_at", "view_count", "content_rating", "library",
+}
+
+// GORM maps the TMDbID field to the tm_db_id column (see schema).
+var (
+	guidIDColumns   = []string{"tm_db_id"}
+	guidTextColumns = []string{"im_db_id", "tv_db_id"}
+)
+
+// upsertSet is the DO UPDATE SET of a cache upsert into table: the Plex
+// columns, updated_at, and deleted_at (cleared, so a title Plex lists again
+// is restored), while external IDs Plex doesn't send keep their stored
+// value, so IDs filled in after an earlier sync aren't lost.
+func upsertSet(table string, columns []string) clause.Set {
+	set := clause.AssignmentColumns(append(slices.Clone(columns), "updated_at", "deleted_at"))
+	keep := func(col, incoming string) clause.Assignment {
+		return clause.Assignment{Column: clause.Column{Name: col}, Value: gorm.Expr(fmt.Sprintf("COALESCE(%s, %s.%s)", incoming, table, col))}
+	}
+	for _, col := range append(slices.Clone(guidIDColumns), "enriched_at") {
+		set = append(set, keep(col, "EXCLUDED."+col))
+	}
+	for _, col := range guidTextColumns {
+		set = append(set, keep(col, "NULLIF(EXCLUDED."+col+", '')"))
+	}
+	return set
 }
 
-// upsertMovieBatch upserts movies by plex_rating_key in a single transaction.
-func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) error {
-	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// upsertChanged is the DO UPDATE WHERE of a cache upsert into table: only
+// rows that were removed, or whose Plex columns or supplied external IDs
+// differ, are written, so an unchanged title keeps its updated_at.
+func upsertChanged(table string, columns []string) clause.Where {
+	stored := make([]string, len(columns))
+	incoming := make([]string, len(columns))
+	for i, col := range columns {
+		stored[i] = table + "." + col
+		incoming[i] = "EXCLUDED." + col
+	}
+	conds := []string{
+		table + ".deleted_at IS NOT NULL",
+		fmt.Sprintf("(%s) IS DISTINCT FROM (%s)", strings.Join(stored, ", "), strings.Join(incoming, ", ")),
+	}
+	for _, col := range guidIDColumns {
+		conds = append(conds, fmt.Sprintf("(EXCLUDED.%s IS NOT NULL AND EXCLUDED.%[1]s IS DISTINCT FROM %s.%[1]s)", col, table))
+	}
+	for _, col := range guidTextColumns {
+		conds = append(conds, fmt.Sprintf("(EXCLUDED.%s <> '' AND EXCLUDED.%[1]s IS DISTINCT FROM %s.%[1]s)", col, table))
+	}
+	return clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: strings.Join(conds, " OR ")}}}
+}
+
+// upsertedID returns the ID of the row a cache upsert into model's table
+// left untouched, which Postgres doesn't return.
+func upsertedID(tx *gorm.DB, model any, ratingKey string) (uint, error) {
+	var ids []uint
+	if err := tx.Unscoped().Model(model).Where("plex_rating_key = ?", ratingKey).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("no row for ratingKey %q", ratingKey)
+	}
+	return ids[0], nil
+}
+
+// upsertMovieBatch upserts movies by plex_rating_key in a single transaction,
+// returning how many were inserted or changed.
+func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) (int, error) {
+	written := 0
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		now := time.Now()
 		for _, item := range movies {
 			year := 0
@@ -633,12 +838,9 @@ func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) error {
 				year = *item.Year
 			}
 
-			rating := 0.0
-			if item.Rating != nil {
-				rating = *item.Rating
-			}
+			rating := knownRating(item.Rating)
 
-			genre := joinGenres(item.Genre)
+			genre := joinTags(item.Genre)
 
 			runtime := 0
 			if item.Duration != nil {
@@ -649,12 +851,17 @@ func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) error {
 			if item.ViewCount != nil {
 				viewCount = *item.ViewCount
 			}
+			var lastViewedAt *time.Time
+			if item.LastViewedAt != nil && *item.LastViewedAt > 0 {
+				t := time.Unix(*item.LastViewedAt, 0).UTC()
+				lastViewedAt = &t
+			}
 
 			thumb := ""
 			if item.Thumb != nil {
 				thumb = *item.Thumb
 			}
-			posterURL := c.resolvePosterURL(thumb)
+			posterURL := c.posterPath(thumb)
 
 			imdb, tmdbID, tvdb := parseGUIDs(item.Guids)
 			var enrichedAt *time.Time
@@ -668,6 +875,8 @@ func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) error {
 				Year:          year,
 				Rating:        rating,
 				Genre:         genre,
+				Director:      joinTags(item.Director),
+				Actors:        capTags(joinTags(item.Role), 1000),
 				PosterURL:     posterURL,
 				Runtime:       runtime,
 				TMDbID:        tmdbID,
@@ -675,23 +884,42 @@ func (c *Client) upsertMovieBatch(ctx context.Context, movies []Item) error {
 				TVDbID:        tvdb,
 				EnrichedAt:    enrichedAt,
 				ViewCount:     viewCount,
+				LastViewedAt:  lastViewedAt,
+				ContentRating: item.ContentRating,
+				Library:       item.Library,
 				UpdatedAt:     now,
 			}
 
-			if err := tx.Clauses(clause.OnConflict{
+			res := tx.Clauses(clause.OnConflict{
 				Columns:   []clause.Column{{Name: "plex_rating_key"}},
-				DoUpdates: clause.AssignmentColumns(movieUpsertColumns),
-			}).Create(&movie).Error; err != nil {
-				return fmt.Errorf("failed to upsert movie %q: %w", item.Title, err)
+				DoUpdates: upsertSet("movies", movieUpsertColumns),
+				Where:     upsertChanged("movies", movieUpsertColumns),
+			}).Create(&movie)
+			if res.Error != nil {
+				return fmt.Errorf("failed to upsert movie %q: %w", item.Title, res.Error)
+			}
+			if res.RowsAffected > 0 {
+				written++
+			} else {
+				id, err := upsertedID(tx, &models.Movie{}, item.RatingKey)
+				if err != nil {
+					return fmt.Errorf("failed to find movie %q: %w", item.Title, err)
+				}
+				movie.ID = id
+			}
+			if err := syncUserRating(tx, item, &movie.ID, nil); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
+	return written, err
 }
 
-// upsertTVShowBatch upserts TV shows by plex_rating_key in a single transaction.
-func (c *Client) upsertTVShowBatch(ctx context.Context, shows []Item) error {
-	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// upsertTVShowBatch upserts TV shows by plex_rating_key like upsertMovieBatch.
+func (c *Client) upsertTVShowBatch(ctx context.Context, shows []Item) (int, error) {
+	written := 0
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		now := time.Now()
 		for _, item := range shows {
 			year := 0
@@ -699,28 +927,44 @@ func (c *Client) upsertTVShowBatch(ctx context.Context, shows []Item) error {
 				year = *item.Year
 			}
 
-			rating := 0.0
-			if item.Rating != nil {
-				rating = *item.Rating
-			}
+			rating := knownRating(item.Rating)
 
-			genre := joinGenres(item.Genre)
+			genre := joinTags(item.Genre)
 
 			seasons := 0
 			if item.ChildCount != nil {
 				seasons = *item.ChildCount
 			}
 
+			// A show's duration is Plex's typical episode length.
+			episodeRuntime := 0
+			if item.Duration != nil {
+				episodeRuntime = *item.Duration / 60000
+			}
+
 			viewCount := 0
 			if item.ViewCount != nil {
 				viewCount = *item.ViewCount
 			}
 
+			episodes, watchedEpisodes := 0, 0
+			if item.LeafCount != nil {
+				episodes = *item.LeafCount
+			}
+			if item.ViewedLeafCount != nil {
+				watchedEpisodes = *item.ViewedLeafCount
+			}
+			var lastViewedAt *time.Time
+			if item.LastViewedAt != nil && *item.LastViewedAt > 0 {
+				t := time.Unix(*item.LastViewedAt, 0).UTC()
+				lastViewedAt = &t
+			}
+
 			thumb := ""
 			if item.Thumb != nil {
 				thumb = *item.Thumb
 			}
-			posterURL := c.resolvePosterURL(thumb)
+			posterURL := c.posterPath(thumb)
 
 			imdb, tmdbID, tvdb := parseGUIDs(item.Guids)
 			var enrichedAt *time.Time
@@ -729,28 +973,50 @@ func (c *Client) upsertTVShowBatch(ctx context.Context, shows []Item) error {
 			}
 
 			tvShow := models.TVShow{
-				PlexRatingKey: item.RatingKey,
-				Title:         item.Title,
-				Year:          year,
-				Rating:        rating,
-				Genre:         genre,
-				PosterURL:     posterURL,
-				Seasons:       seasons,
-				TMDbID:        tmdbID,
-				IMDbID:        imdb,
-				TVDbID:        tvdb,
-				EnrichedAt:    enrichedAt,
-				ViewCount:     viewCount,
-				UpdatedAt:     now,
-			}
-
-			if err := tx.Clauses(clause.OnConflict{
+				PlexRatingKey:   item.RatingKey,
+				Title:           item.Title,
+				Year:            year,
+				Rating:          rating,
+				Genre:           genre,
+				Actors:          capTags(joinTags(item.Role), 1000),
+				PosterURL:       posterURL,
+				Seasons:         seasons,
+				EpisodeRuntime:  episodeRuntime,
+				Episodes:        episodes,
+				WatchedEpisodes: watchedEpisodes,
+				LastViewedAt:    lastViewedAt,
+				TMDbID:          tmdbID,
+				IMDbID:          imdb,
+				TVDbID:          tvdb,
+				EnrichedAt:      enrichedAt,
+				ViewCount:       viewCount,
+				ContentRating:   item.ContentRating,
+				Library:         item.Library,
+				UpdatedAt:       now,
+			}
+
+			res := tx.Clauses(clause.OnConflict{
 				Columns:   []clause.Column{{Name: "plex_rating_key"}},
-				DoUpdates: clause.AssignmentColumns(tvUpsertColumns),
-			}).Create(&tvShow).Error; err != nil {
-				return fmt.Errorf("failed to upsert TV show %q: %w", item.Title, err)
+				DoUpdates: upsertSet("tv_shows", tvUpsertColumns),
+				Where:     upsertChanged("tv_shows", tvUpsertColumns),
+			}).Create(&tvShow)
+			if res.Error != nil {
+				return fmt.Errorf("failed to upsert TV show %q: %w", item.Title, res.Error)
+			}
+			if res.RowsAffected > 0 {
+				written++
+			} else {
+				id, err := upsertedID(tx, &models.TVShow{}, item.RatingKey)
+				if err != nil {
+					return fmt.Errorf("failed to find TV show %q: %w", item.Title, err)
+				}
+				tvShow.ID = id
+			}
+			if err := syncUserRating(tx, item, nil, &tvShow.ID); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
+	return written, err
 }
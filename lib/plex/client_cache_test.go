@@ -12,7 +12,7 @@ import (
 func testPlexDB(t *testing.T) *gorm.DB {
 	t.Helper()
 	db := dbtest.New(t)
-	if err := db.AutoMigrate(&models.Movie{}, &models.TVShow{}, &models.Recommendation{}); err != nil {
+	if err := db.AutoMigrate(&models.Movie{}, &models.TVShow{}, &models.Recommendation{}, &models.ExternalSignal{}); err != nil {
 		t.Fatal(err)
 	}
 	db.Exec(`UPDATE movies SET plex_rating_key = 'legacy-' || CAST(id AS TEXT) WHERE plex_rating_key IS NULL OR TRIM(plex_rating_key) = ''`)
@@ -29,7 +29,7 @@ func TestUpsertMovieBatch_updatesSameRow(t *testing.T) {
 	ctx := t.Context()
 
 	v1 := []Item{{RatingKey: "501", Key: "/m/501", Title: "Alpha", Type: models.TypeMovie, AddedAt: 1}}
-	if err := c.upsertMovieBatch(ctx, v1); err != nil {
+	if _, err := c.upsertMovieBatch(ctx, v1); err != nil {
 		t.Fatal(err)
 	}
 	var id1 uint
@@ -38,7 +38,7 @@ func TestUpsertMovieBatch_updatesSameRow(t *testing.T) {
 	}
 
 	v2 := []Item{{RatingKey: "501", Key: "/m/501", Title: "Beta", Type: models.TypeMovie, AddedAt: 2}}
-	if err := c.upsertMovieBatch(ctx, v2); err != nil {
+	if _, err := c.upsertMovieBatch(ctx, v2); err != nil {
 		t.Fatal(err)
 	}
 	var n int64
@@ -60,7 +60,68 @@ func TestUpsertMovieBatch_updatesSameRow(t *testing.T) {
 	}
 }
 
-func TestRemoveMoviesNotInSnapshot_clearsRecFK(t *testing.T) {
+func TestUpsertMovieBatch_syncsUserRating(t *testing.T) {
+	db := testPlexDB(t)
+	c := &Client{plexURL: "http://localhost:32400", db: db}
+	ctx := t.Context()
+	stars := 9.0
+	rated := []Item{{RatingKey: "601", Key: "/m/601", Title: "Gamma", Type: models.TypeMovie, AddedAt: 1, UserRating: &stars}}
+	if _, err := c.upsertMovieBatch(ctx, rated); err != nil {
+		t.Fatal(err)
+	}
+	var sig models.ExternalSignal
+	if err := db.Where("source = ? AND kind = ?", models.SourcePlex, models.SignalKindRated).Take(&sig).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sig.Value != 9 || sig.MovieID == nil || sig.ExternalRef != "rated:601" {
+		t.Fatalf("signal = %+v, want a 9 rating on the movie", sig)
+	}
+
+	rated[0].UserRating = nil // cleared in Plex
+	if _, err := c.upsertMovieBatch(ctx, rated); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err := db.Model(&models.ExternalSignal{}).Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("signals = %d, %v; want the rating removed", n, err)
+	}
+}
+
+func TestUpsertMovieBatch_keepsIDsAndSkipsUnchanged(t *testing.T) {
+	db := testPlexDB(t)
+	c := &Client{plexURL: "http://localhost:32400", db: db}
+	ctx := t.Context()
+
+	item := []Item{{RatingKey: "701", Key: "/m/701", Title: "Delta", Type: models.TypeMovie, AddedAt: 1}}
+	if n, err := c.upsertMovieBatch(ctx, item); err != nil || n != 1 {
+		t.Fatalf("insert: %d written, %v", n, err)
+	}
+	// A TMDb ID filled in after the sync, which Plex doesn't send.
+	if err := db.Model(&models.Movie{}).Where("plex_rating_key = ?", "701").Update("tm_db_id", 949).Error; err != nil {
+		t.Fatal(err)
+	}
+	var before models.Movie
+	if err := db.Where("plex_rating_key = ?", "701").Take(&before).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := c.upsertMovieBatch(ctx, item); err != nil || n != 0 {
+		t.Fatalf("unchanged: %d written, %v; want 0", n, err)
+	}
+	item[0].Title = "Delta (Director's Cut)"
+	if n, err := c.upsertMovieBatch(ctx, item); err != nil || n != 1 {
+		t.Fatalf("changed: %d written, %v; want 1", n, err)
+	}
+	var after models.Movie
+	if err := db.Where("plex_rating_key = ?", "701").Take(&after).Error; err != nil {
+		t.Fatal(err)
+	}
+	if after.ID != before.ID || after.TMDbID == nil || *after.TMDbID != 949 || after.Title != "Delta (Director's Cut)" {
+		t.Fatalf("after re-sync: %+v, want the same row with its TMDb ID kept", after)
+	}
+}
+
+func TestRemoveMoviesNotInSnapshot_softDeletes(t *testing.T) {
 	db := testPlexDB(t)
 	c := &Client{
 		plexURL: "http://localhost:32400",
@@ -68,10 +129,11 @@ func TestRemoveMoviesNotInSnapshot_clearsRecFK(t *testing.T) {
 	}
 	ctx := t.Context()
 
-	if err := c.upsertMovieBatch(ctx, []Item{
+	items := []Item{
 		{RatingKey: "10", Key: "/m/10", Title: "Keep", Type: models.TypeMovie, AddedAt: 1},
 		{RatingKey: "11", Key: "/m/11", Title: "Drop", Type: models.TypeMovie, AddedAt: 1},
-	}); err != nil {
+	}
+	if _, err := c.upsertMovieBatch(ctx, items); err != nil {
 		t.Fatal(err)
 	}
 	var dropID uint
@@ -81,28 +143,40 @@ func TestRemoveMoviesNotInSnapshot_clearsRecFK(t *testing.T) {
 	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	if err := db.Create(&models.Recommendation{
 		Date:  day,
-		Title: "Rec", Type: models.TypeMovie, Year: 2020, Rating: 8, Genre: "x", TMDbID: 1,
+		Title: "Rec", Type: models.TypeMovie, Year: 2020, Rating: new(8.0), Genre: "x", TMDbID: 1,
 		MovieID: &dropID,
 	}).Error; err != nil {
 		t.Fatal(err)
 	}
 
 	present := map[string]struct{}{"10": {}}
-	if err := c.removeMoviesNotInSnapshot(ctx, present); err != nil {
-		t.Fatal(err)
+	if n, err := c.removeMoviesNotInSnapshot(ctx, present); err != nil || n != 1 {
+		t.Fatalf("removed %d, %v; want 1", n, err)
 	}
-	var cnt int64
+	var cnt, all int64
 	if err := db.Model(&models.Movie{}).Count(&cnt).Error; err != nil {
 		t.Fatal(err)
 	}
-	if cnt != 1 {
-		t.Fatalf("movies left = %d want 1", cnt)
+	if err := db.Unscoped().Model(&models.Movie{}).Count(&all).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 1 || all != 2 {
+		t.Fatalf("movies left = %d (%d with removed) want 1 (2)", cnt, all)
 	}
 	var rec models.Recommendation
 	if err := db.Where("title = ?", "Rec").First(&rec).Error; err != nil {
 		t.Fatal(err)
 	}
-	if rec.MovieID != nil {
-		t.Fatalf("movie_id = %v want nil", rec.MovieID)
+	if rec.MovieID == nil || *rec.MovieID != dropID {
+		t.Fatalf("movie_id = %v want %d kept", rec.MovieID, dropID)
+	}
+
+	// Plex lists it again: the same row is restored.
+	if n, err := c.upsertMovieBatch(ctx, items[1:]); err != nil || n != 1 {
+		t.Fatalf("restore: %d written, %v", n, err)
+	}
+	var back models.Movie
+	if err := db.Where("plex_rating_key = ?", "11").Take(&back).Error; err != nil || back.ID != dropID {
+		t.Fatalf("restored movie = %+v, %v; want id %d", back, err, dropID)
 	}
 }
@@ -14,20 +14,21 @@ func testPlexClient(t *testing.T, srvURL string) *Client {
 	return NewClient(srvURL, "tok", nil, nil)
 }
 
-func TestClient_resolvePosterURL(t *testing.T) {
+func TestClient_posterPath(t *testing.T) {
 	c := &Client{plexURL: "https://plex.example.com:32400"}
 
-	if got := c.resolvePosterURL(""); got != fallbackPosterURL {
-		t.Fatalf("empty: %q", got)
-	}
-	if got := c.resolvePosterURL("https://cdn.example/p.jpg"); got != "https://cdn.example/p.jpg" {
-		t.Fatalf("absolute: %q", got)
-	}
-	if got := c.resolvePosterURL("/library/metadata/1/thumb/abc"); got != "https://plex.example.com:32400/library/metadata/1/thumb/abc" {
-		t.Fatalf("relative slash: %q", got)
-	}
-	if got := c.resolvePosterURL("library/foo"); got != "https://plex.example.com:32400/library/foo" {
-		t.Fatalf("relative no slash: %q", got)
+	for thumb, want := range map[string]string{
+		"":                              "",
+		"https://cdn.example/p.jpg":     "https://cdn.example/p.jpg",
+		"/library/metadata/1/thumb/abc": "/library/metadata/1/thumb/abc",
+		"library/foo":                   "/library/foo",
+		"/library/metadata/1/thumb/abc?X-Plex-Token=secret":                               "/library/metadata/1/thumb/abc",
+		"https://plex.example.com:32400/library/metadata/1/thumb/abc?X-Plex-Token=secret": "/library/metadata/1/thumb/abc",
+		"https://cdn.example/p.jpg?X-Plex-Token=secret&w=300":                             "https://cdn.example/p.jpg?w=300",
+	} {
+		if got := c.posterPath(thumb); got != want {
+			t.Errorf("posterPath(%q) = %q, want %q", thumb, got, want)
+		}
 	}
 }
 
@@ -84,7 +85,7 @@ func TestGetAllLibraries_ignoresNumericBoolsOnSection(t *testing.T) {
 
 func TestGetPlexItems_viaPlexgoListContent(t *testing.T) {
 	t.Parallel()
-	const payload = `{"MediaContainer":{"size":1,"totalSize":1,"Metadata":[{"ratingKey":"42","key":"/library/metadata/42","title":"Test Film","type":"movie","addedAt":1,"year":2020}]}}`
+	const payload = `{"MediaContainer":{"size":1,"totalSize":1,"Metadata":[{"ratingKey":"42","key":"/library/metadata/42","title":"Test Film","type":"movie","addedAt":1,"year":2020,"contentRating":"PG"}]}}`
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("X-Plex-Token") != "tok" {
 			t.Error("expected X-Plex-Token header")
@@ -117,12 +118,15 @@ func TestGetPlexItems_viaPlexgoListContent(t *testing.T) {
 	if items[0].RatingKey != "42" {
 		t.Fatalf("RatingKey=%q", items[0].RatingKey)
 	}
+	if items[0].ContentRating != "PG" {
+		t.Errorf("ContentRating=%q want PG", items[0].ContentRating)
+	}
 }
 
 func TestGetPlexItems_toleratesNumericBoolsAndNumericRatingKey(t *testing.T) {
 	t.Parallel()
 	// Newer PMS can send 0/1 for Metadata fields modeled as *bool in plexgo.
-	const payload = `{"MediaContainer":{"totalSize":1,"Metadata":[{"ratingKey":99,"key":"/library/metadata/99","title":"Numeric Key","type":"movie","addedAt":1,"search":1,"secondary":0,"year":2021,"Genre":[{"tag":"Comedy"}]}]}}`
+	const payload = `{"MediaContainer":{"totalSize":1,"Metadata":[{"ratingKey":99,"key":"/library/metadata/99","title":"Numeric Key","type":"movie","addedAt":1,"search":1,"secondary":0,"year":2021,"Genre":[{"tag":"Comedy"}],"Director":[{"tag":"Jane Doe"}],"Role":[{"tag":"Sam Roe"},{"tag":"Ada Poe"}],"userRating":8.0}]}}`
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(payload))
@@ -140,4 +144,16 @@ func TestGetPlexItems_toleratesNumericBoolsAndNumericRatingKey(t *testing.T) {
 	if len(items[0].Genre) != 1 || items[0].Genre[0].Tag != "Comedy" {
 		t.Fatalf("genre %+v", items[0].Genre)
 	}
+	if len(items[0].Director) != 1 || items[0].Director[0].Tag != "Jane Doe" {
+		t.Fatalf("director %+v", items[0].Director)
+	}
+	if got := joinTags(items[0].Role); got != "Sam Roe, Ada Poe" {
+		t.Fatalf("cast %q", got)
+	}
+	if items[0].UserRating == nil || *items[0].UserRating != 8 {
+		t.Fatalf("user rating %v, want 8", items[0].UserRating)
+	}
+	if got := capTags("Sam Roe, Ada Poe", 12); got != "Sam Roe" {
+		t.Fatalf("capTags = %q, want whole names only", got)
+	}
 }
@@ -1,6 +1,12 @@
 package plex
 
-import "testing"
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
 
 func TestSameHost(t *testing.T) {
 	cases := []struct {
@@ -25,3 +31,38 @@ func TestSameHost(t *testing.T) {
 		})
 	}
 }
+
+func TestImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/library/metadata/1/art/2" {
+			w.Header().Set("Content-Type", "text/html")
+		} else {
+			w.Header().Set("Content-Type", "image/jpeg")
+		}
+		_, _ = w.Write([]byte("jpeg"))
+	}))
+	defer srv.Close()
+	c := &Client{plexURL: srv.URL, plexToken: "tok"}
+
+	body, ct, err := c.Image(context.Background(), c.PlexPath(srv.URL+"/library/metadata/1/thumb/2?X-Plex-Token=tok"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(body)
+	_ = body.Close()
+	if ct != "image/jpeg" || string(b) != "jpeg" {
+		t.Errorf("Image = %q, %q", ct, b)
+	}
+	for _, p := range []string{"/library/metadata/1/art/2", "/accounts", "/library/../accounts", ""} {
+		if _, _, err := c.Image(context.Background(), p); err == nil {
+			t.Errorf("Image(%q) should fail", p)
+		}
+	}
+	if p := c.PlexPath("https://image.tmdb.org/t/p/w500/x.jpg"); p != "" {
+		t.Errorf("PlexPath of an off-host URL = %q, want empty", p)
+	}
+}
@@ -25,9 +25,9 @@ func parseGUIDs(guids []string) (imdb string, tmdb *int, tvdb string) {
 	return imdb, tmdb, tvdb
 }
 
-// joinGenres returns a comma-separated, order-preserving, de-duplicated list of
-// genre tags. Empty when there are none.
-func joinGenres(tags []components.Tag) string {
+// joinTags returns a comma-separated, order-preserving, de-duplicated list of
+// tags (genres, directors). Empty when there are none.
+func joinTags(tags []components.Tag) string {
 	seen := make(map[string]struct{}, len(tags))
 	out := make([]string, 0, len(tags))
 	for _, t := range tags {
@@ -42,3 +42,15 @@ func joinGenres(tags []components.Tag) string {
 	}
 	return strings.Join(out, ", ")
 }
+
+// capTags cuts a joinTags list to at most n bytes, dropping whole tags from
+// the end so it fits its column.
+func capTags(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if i := strings.LastIndex(s[:n], ", "); i >= 0 {
+		return s[:i]
+	}
+	return ""
+}
@@ -53,9 +53,9 @@ func TestParseGUIDs_empty(t *testing.T) {
 	}
 }
 
-func TestJoinGenres(t *testing.T) {
-	got := joinGenres([]components.Tag{{Tag: "Comedy"}, {Tag: "Drama"}, {Tag: "Comedy"}})
+func TestJoinTags(t *testing.T) {
+	got := joinTags([]components.Tag{{Tag: "Comedy"}, {Tag: "Drama"}, {Tag: "Comedy"}})
 	if got != "Comedy, Drama" {
-		t.Errorf("joinGenres = %q, want %q", got, "Comedy, Drama")
+		t.Errorf("joinTags = %q, want %q", got, "Comedy, Drama")
 	}
 }
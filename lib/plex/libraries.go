@@ -0,0 +1,90 @@
+package plex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// clipMinutes is the median length below which a movie library is taken to
+// hold home videos or clips rather than films.
+const clipMinutes = 20
+
+// minClipSample is how many timed items a library needs before its median
+// length is trusted.
+const minClipSample = 5
+
+// otherVideoAgents are the metadata agents of Plex's "Other Videos" library
+// type (Personal Media), used for home videos and clips. Such libraries still
+// report type "movie".
+var otherVideoAgents = []string{"com.plexapp.agents.none", "tv.plex.agents.none"}
+
+// LibraryFilter decides which library sections the cache sync reads. The
+// zero value reads every library except detected home-video ones.
+type LibraryFilter struct {
+	IncludeOther bool     // read "Other Videos"/home-video libraries too
+	Include      []string // library titles always read, overriding detection
+	Exclude      []string // library titles never read
+}
+
+// SetLibraryFilter replaces the filter later cache syncs use.
+func (c *Client) SetLibraryFilter(f LibraryFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.libraries = f
+}
+
+func (c *Client) libraryFilter() LibraryFilter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.libraries
+}
+
+// skip returns why lib shouldn't be read, or "" to read it.
+func (f LibraryFilter) skip(lib LibrarySectionInfo) string {
+	title := deref(lib.Title)
+	switch {
+	case containsFold(f.Exclude, title):
+		return "excluded by PLEX_LIBRARIES_EXCLUDE"
+	case containsFold(f.Include, title) || f.IncludeOther:
+		return ""
+	case lib.Agent != nil && slices.Contains(otherVideoAgents, *lib.Agent):
+		return "other videos library (" + *lib.Agent + ")"
+	case lib.Scanner != nil && strings.Contains(*lib.Scanner, "Video Files"):
+		return "other videos library (" + *lib.Scanner + ")"
+	}
+	return ""
+}
+
+// skipItems returns why a movie library whose items are mostly short clips
+// shouldn't be cached, or "" to cache it.
+func (f LibraryFilter) skipItems(lib LibrarySectionInfo, items []Item) string {
+	if lib.Type != "movie" || f.IncludeOther || containsFold(f.Include, deref(lib.Title)) {
+		return ""
+	}
+	var minutes []int
+	for _, it := range items {
+		if it.Duration != nil && *it.Duration > 0 {
+			minutes = append(minutes, *it.Duration/60000)
+		}
+	}
+	if len(minutes) < minClipSample {
+		return ""
+	}
+	slices.Sort(minutes)
+	if median := minutes[len(minutes)/2]; median < clipMinutes {
+		return fmt.Sprintf("mostly clips (median %d min)", median)
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, s) })
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,60 @@
+package plex
+
+import "testing"
+
+func TestLibraryFilter_skip(t *testing.T) {
+	str := func(s string) *string { return &s }
+	movies := LibrarySectionInfo{Title: str("Movies"), Type: "movie", Agent: str("tv.plex.agents.movie"), Scanner: str("Plex Movie")}
+	home := LibrarySectionInfo{Title: str("Home Videos"), Type: "movie", Agent: str("tv.plex.agents.none"), Scanner: str("Plex Video Files Scanner")}
+	legacy := LibrarySectionInfo{Title: str("Clips"), Type: "movie", Scanner: str("Plex Video Files")}
+
+	for _, tt := range []struct {
+		name   string
+		filter LibraryFilter
+		lib    LibrarySectionInfo
+		skip   bool
+	}{
+		{"movies", LibraryFilter{}, movies, false},
+		{"home videos", LibraryFilter{}, home, true},
+		{"legacy scanner", LibraryFilter{}, legacy, true},
+		{"include other", LibraryFilter{IncludeOther: true}, home, false},
+		{"include by title", LibraryFilter{Include: []string{"home videos"}}, home, false},
+		{"exclude by title", LibraryFilter{Exclude: []string{"Movies"}}, movies, true},
+		{"exclude beats include", LibraryFilter{IncludeOther: true, Include: []string{"Home Videos"}, Exclude: []string{"Home Videos"}}, home, true},
+	} {
+		if got := tt.filter.skip(tt.lib) != ""; got != tt.skip {
+			t.Errorf("%s: skip = %v, want %v", tt.name, got, tt.skip)
+		}
+	}
+}
+
+func TestLibraryFilter_skipItems(t *testing.T) {
+	title := "Movies"
+	lib := LibrarySectionInfo{Title: &title, Type: "movie"}
+	items := func(minutes ...int) []Item {
+		out := make([]Item, len(minutes))
+		for i, m := range minutes {
+			ms := m * 60000
+			out[i].Duration = &ms
+		}
+		return out
+	}
+	clips := items(2, 3, 5, 8, 12, 95)
+
+	if got := (LibraryFilter{}).skipItems(lib, clips); got == "" {
+		t.Error("mostly clips: want skipped")
+	}
+	if got := (LibraryFilter{}).skipItems(lib, items(88, 95, 102, 110, 131)); got != "" {
+		t.Errorf("feature films: skipped (%s)", got)
+	}
+	if got := (LibraryFilter{}).skipItems(lib, items(2, 3, 5)); got != "" {
+		t.Errorf("too few to judge: skipped (%s)", got)
+	}
+	if got := (LibraryFilter{Include: []string{"Movies"}}).skipItems(lib, clips); got != "" {
+		t.Errorf("included by title: skipped (%s)", got)
+	}
+	shows := LibrarySectionInfo{Title: &title, Type: "show"}
+	if got := (LibraryFilter{}).skipItems(shows, clips); got != "" {
+		t.Errorf("show library: skipped (%s)", got)
+	}
+}
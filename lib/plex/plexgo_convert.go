@@ -56,19 +56,33 @@ type sectionListMetadata struct {
 	Type      string        `json:"type"`
 	Year      *int          `json:"year,omitempty"`
 	Rating    *float32      `json:"rating,omitempty"`
-	Summary   *string       `json:"summary,omitempty"`
-	Thumb     *string       `json:"thumb,omitempty"`
-	Art       *string       `json:"art,omitempty"`
-	Duration  *int          `json:"duration,omitempty"`
-	AddedAt   int64         `json:"addedAt"`
-	UpdatedAt *int64        `json:"updatedAt,omitempty"`
-	ViewCount *int          `json:"viewCount,omitempty"`
-	Genre     []struct {
+	// UserRating is the account's own star rating, 0–10.
+	UserRating *float32 `json:"userRating,omitempty"`
+	Summary    *string  `json:"summary,omitempty"`
+	Thumb      *string  `json:"thumb,omitempty"`
+	Art        *string  `json:"art,omitempty"`
+	Duration   *int     `json:"duration,omitempty"`
+	AddedAt    int64    `json:"addedAt"`
+	UpdatedAt  *int64   `json:"updatedAt,omitempty"`
+	ViewCount  *int     `json:"viewCount,omitempty"`
+	// ContentRating is the age rating, e.g. "PG-13", "TV-Y7", or "gb/12".
+	ContentRating *string `json:"contentRating,omitempty"`
+	Genre         []struct {
 		Tag string `json:"tag"`
 	} `json:"Genre,omitempty"`
+	Director []struct {
+		Tag string `json:"tag"`
+	} `json:"Director,omitempty"`
+	Role []struct {
+		Tag string `json:"tag"`
+	} `json:"Role,omitempty"` // top-billed cast
 	GUID       plexGUIDs `json:"Guid,omitempty"`
 	LeafCount  *int      `json:"leafCount,omitempty"`
 	ChildCount *int      `json:"childCount,omitempty"`
+	// Shows only: episodes watched. LastViewedAt is the latest view of a
+	// movie, or of any of a show's episodes.
+	ViewedLeafCount *int   `json:"viewedLeafCount,omitempty"`
+	LastViewedAt    *int64 `json:"lastViewedAt,omitempty"`
 }
 
 // plexGUIDs decodes Plex's GUID field, which varies: an array of {id} objects
@@ -116,35 +130,58 @@ func sectionMetadataToPlexItem(md sectionListMetadata) Item {
 	for _, g := range md.Genre {
 		genres = append(genres, components.Tag{Tag: g.Tag})
 	}
+	var directors []components.Tag
+	for _, d := range md.Director {
+		directors = append(directors, components.Tag{Tag: d.Tag})
+	}
+	var roles []components.Tag
+	for _, r := range md.Role {
+		roles = append(roles, components.Tag{Tag: r.Tag})
+	}
 	rk := string(md.RatingKey)
 	var rating *float64
 	if md.Rating != nil {
 		x := float64(*md.Rating)
 		rating = &x
 	}
+	var userRating *float64
+	if md.UserRating != nil {
+		x := float64(*md.UserRating)
+		userRating = &x
+	}
 	summary := ""
 	if md.Summary != nil {
 		summary = *md.Summary
 	}
 	guids := []string(md.GUID)
+	contentRating := ""
+	if md.ContentRating != nil {
+		contentRating = *md.ContentRating
+	}
 	return Item{
-		RatingKey:  rk,
-		Key:        md.Key,
-		Title:      md.Title,
-		Type:       md.Type,
-		Year:       md.Year,
-		Rating:     rating,
-		Summary:    summary,
-		Thumb:      md.Thumb,
-		Art:        md.Art,
-		Duration:   md.Duration,
-		AddedAt:    md.AddedAt,
-		UpdatedAt:  md.UpdatedAt,
-		ViewCount:  md.ViewCount,
-		Genre:      genres,
-		Guids:      guids,
-		LeafCount:  md.LeafCount,
-		ChildCount: md.ChildCount,
+		RatingKey:       rk,
+		Key:             md.Key,
+		Title:           md.Title,
+		Type:            md.Type,
+		Year:            md.Year,
+		Rating:          rating,
+		UserRating:      userRating,
+		Summary:         summary,
+		Thumb:           md.Thumb,
+		Art:             md.Art,
+		Duration:        md.Duration,
+		AddedAt:         md.AddedAt,
+		UpdatedAt:       md.UpdatedAt,
+		ViewCount:       md.ViewCount,
+		ContentRating:   contentRating,
+		Genre:           genres,
+		Director:        directors,
+		Role:            roles,
+		Guids:           guids,
+		LeafCount:       md.LeafCount,
+		ChildCount:      md.ChildCount,
+		ViewedLeafCount: md.ViewedLeafCount,
+		LastViewedAt:    md.LastViewedAt,
 	}
 }
 
@@ -155,7 +192,7 @@ func (c *Client) listSectionContentAll(ctx context.Context, sectionID string) ([
 	const pageSize = 200
 	start := 0
 	var all []Item
-	base := strings.TrimRight(c.plexURL, "/")
+	base, token := c.conn()
 
 	for range 500 {
 		u, err := url.JoinPath(base, "library", "sections", sectionID, "all")
@@ -173,7 +210,7 @@ func (c *Client) listSectionContentAll(ctx context.Context, sectionID string) ([
 			return nil, err
 		}
 		req.Header.Set("Accept", "application/json")
-		req.Header.Set("X-Plex-Token", c.plexToken)
+		req.Header.Set("X-Plex-Token", token)
 		req.Header.Set("User-Agent", "recommender")
 
 		httpResp, err := http.DefaultClient.Do(req)
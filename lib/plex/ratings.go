@@ -0,0 +1,35 @@
+package plex
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// syncUserRating stores the item's Plex star rating (userRating, 0–10 with
+// one point per half star) as a rated signal for the cached title, or
+// removes the signal once the rating is cleared in Plex.
+func syncUserRating(tx *gorm.DB, item Item, movieID, tvShowID *uint) error {
+	ref := "rated:" + item.RatingKey
+	if item.UserRating == nil || *item.UserRating <= 0 {
+		if err := tx.Where("source = ? AND kind = ? AND external_ref = ?", models.SourcePlex, models.SignalKindRated, ref).
+			Delete(&models.ExternalSignal{}).Error; err != nil {
+			return fmt.Errorf("clear rating for %q: %w", item.Title, err)
+		}
+		return nil
+	}
+	sig := models.ExternalSignal{
+		Source: models.SourcePlex, ExternalRef: ref, Kind: models.SignalKindRated,
+		MovieID: movieID, TVShowID: tvShowID, Value: *item.UserRating, UpdatedAt: time.Now(),
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "external_ref"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "movie_id", "tv_show_id", "updated_at"}),
+	}).Create(&sig).Error; err != nil {
+		return fmt.Errorf("save rating for %q: %w", item.Title, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package plextv is a minimal client for the plex.tv account API. It signs
+// household members in with their Plex accounts through the PIN flow (create
+// a PIN, send the browser to app.plex.tv to approve it, then trade the
+// approved PIN for the account's token and name), and lists the addresses an
+// account's servers can be reached at, for remote access away from home.
+package plextv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultURL     = "https://plex.tv/api/v2"
+	defaultAuthURL = "https://app.plex.tv/auth"
+	product        = "Recommender"
+)
+
+// Client talks to plex.tv on behalf of this app. URL and AuthURL are
+// overridable for tests.
+type Client struct {
+	URL        string
+	AuthURL    string
+	clientID   string
+	httpClient *http.Client
+}
+
+// NewClient returns a plex.tv client that identifies this app as clientID,
+// which should stay the same across restarts.
+func NewClient(clientID string) *Client {
+	return &Client{URL: defaultURL, AuthURL: defaultAuthURL, clientID: clientID, httpClient: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Pin is a sign-in request awaiting the user's approval.
+type Pin struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+}
+
+// CreatePin starts a sign-in.
+func (c *Client) CreatePin(ctx context.Context) (Pin, error) {
+	var pin Pin
+	if err := c.do(ctx, http.MethodPost, c.URL+"/pins?strong=true", "", &pin); err != nil {
+		return Pin{}, fmt.Errorf("create plex.tv pin: %w", err)
+	}
+	return pin, nil
+}
+
+// SignInURL is where to send the browser to approve pin; plex.tv sends it
+// on to forward afterwards.
+func (c *Client) SignInURL(pin Pin, forward string) string {
+	q := url.Values{}
+	q.Set("clientID", c.clientID)
+	q.Set("code", pin.Code)
+	q.Set("forwardUrl", forward)
+	q.Set("context[device][product]", product)
+	return c.AuthURL + "#?" + q.Encode()
+}
+
+// PinToken returns the account token for an approved pin, or "" if it
+// hasn't been approved (yet).
+func (c *Client) PinToken(ctx context.Context, id int) (string, error) {
+	var out struct {
+		AuthToken string `json:"authToken"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.URL+"/pins/"+strconv.Itoa(id), "", &out); err != nil {
+		return "", fmt.Errorf("check plex.tv pin: %w", err)
+	}
+	return out.AuthToken, nil
+}
+
+// Username returns the name of the account token belongs to.
+func (c *Client) Username(ctx context.Context, token string) (string, error) {
+	var out struct {
+		Username string `json:"username"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.URL+"/user", token, &out); err != nil {
+		return "", fmt.Errorf("get plex.tv user: %w", err)
+	}
+	if out.Username == "" {
+		return "", fmt.Errorf("get plex.tv user: no username in response")
+	}
+	return out.Username, nil
+}
+
+// Resource is a device on a plex.tv account, such as a media server.
+type Resource struct {
+	Name             string       `json:"name"`
+	ClientIdentifier string       `json:"clientIdentifier"` // a server's machine identifier
+	Provides         string       `json:"provides"`         // comma-separated, e.g. "server"
+	Owned            bool         `json:"owned"`
+	AccessToken      string       `json:"accessToken"` // for connecting to this device
+	Connections      []Connection `json:"connections"`
+}
+
+// Connection is one address a Resource can be reached at.
+type Connection struct {
+	URI   string `json:"uri"`
+	Local bool   `json:"local"` // on the server's own network
+	Relay bool   `json:"relay"` // through Plex's relay service
+}
+
+// IsServer reports whether r is a media server.
+func (r Resource) IsServer() bool {
+	return slices.Contains(strings.Split(r.Provides, ","), "server")
+}
+
+// Resources lists the devices the account token belongs to can reach,
+// including relay and HTTPS connections.
+func (c *Client) Resources(ctx context.Context, token string) ([]Resource, error) {
+	var out []Resource
+	if err := c.do(ctx, http.MethodGet, c.URL+"/resources?includeHttps=1&includeRelay=1", token, &out); err != nil {
+		return nil, fmt.Errorf("list plex.tv resources: %w", err)
+	}
+	return out, nil
+}
+
+func (c *Client) do(ctx context.Context, method, u, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Plex-Client-Identifier", c.clientID)
+	req.Header.Set("X-Plex-Product", product)
+	if token != "" {
+		req.Header.Set("X-Plex-Token", token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, b)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
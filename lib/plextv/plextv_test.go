@@ -0,0 +1,58 @@
+package plextv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_pinFlow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Client-Identifier") != "test-app" {
+			t.Errorf("%s: missing client identifier", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/pins":
+			_, _ = w.Write([]byte(`{"id":42,"code":"abcd"}`))
+		case r.URL.Path == "/pins/42":
+			_, _ = w.Write([]byte(`{"id":42,"authToken":"tok"}`))
+		case r.URL.Path == "/pins/43":
+			_, _ = w.Write([]byte(`{"id":43,"authToken":null}`))
+		case r.URL.Path == "/user" && r.Header.Get("X-Plex-Token") == "tok":
+			_, _ = w.Write([]byte(`{"username":"sam"}`))
+		default:
+			http.Error(w, "nope", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("test-app")
+	c.URL = srv.URL
+	ctx := context.Background()
+	pin, err := c.CreatePin(ctx)
+	if err != nil || pin.ID != 42 || pin.Code != "abcd" {
+		t.Fatalf("CreatePin = %+v, %v", pin, err)
+	}
+	u := c.SignInURL(pin, "https://example.com/login/plex/callback")
+	for _, want := range []string{"#?", "code=abcd", "clientID=test-app", "forwardUrl=https%3A%2F%2Fexample.com"} {
+		if !strings.Contains(u, want) {
+			t.Errorf("SignInURL = %s, missing %s", u, want)
+		}
+	}
+	if tok, err := c.PinToken(ctx, 43); err != nil || tok != "" {
+		t.Errorf("unapproved PinToken = %q, %v", tok, err)
+	}
+	tok, err := c.PinToken(ctx, 42)
+	if err != nil || tok != "tok" {
+		t.Fatalf("PinToken = %q, %v", tok, err)
+	}
+	if name, err := c.Username(ctx, tok); err != nil || name != "sam" {
+		t.Errorf("Username = %q, %v", name, err)
+	}
+	if _, err := c.Username(ctx, "bad"); err == nil {
+		t.Error("Username with a bad token should fail")
+	}
+}
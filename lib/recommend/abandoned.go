@@ -0,0 +1,89 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// abandonAfterDays is how long a started show must sit untouched before
+	// it is suggested for dropping, and how long a "keep" quiets it.
+	abandonAfterDays = 90
+	// maxDropSuggestions bounds AbandonedShows.
+	maxDropSuggestions = 20
+)
+
+// ErrShowNotFound is returned by DecideShow for an unknown show ID.
+var ErrShowNotFound = errors.New("tv show not found")
+
+// AbandonedShows returns shows started but not finished whose last episode
+// view is more than abandonAfterDays before now, longest-idle first. Dropped
+// shows are left out, as are shows kept within abandonAfterDays.
+func (r *Recommender) AbandonedShows(ctx context.Context, now time.Time) ([]models.TVShow, error) {
+	cutoff := now.AddDate(0, 0, -abandonAfterDays)
+	var shows []models.TVShow
+	if err := r.db.WithContext(ctx).
+		Joins("LEFT JOIN show_decisions d ON d.tv_show_id = tv_shows.id").
+		Where("tv_shows.watched_episodes > 0 AND tv_shows.watched_episodes < tv_shows.episodes").
+		Where("tv_shows.last_viewed_at < ?", cutoff).
+		Where("d.tv_show_id IS NULL OR (d.decision = ? AND d.updated_at < ?)", models.ShowKept, cutoff).
+		Order("tv_shows.last_viewed_at, tv_shows.id").
+		Limit(maxDropSuggestions).
+		Find(&shows).Error; err != nil {
+		return nil, fmt.Errorf("find abandoned shows: %w", err)
+	}
+	return shows, nil
+}
+
+// DecideShow records decision (models.ShowDropped or models.ShowKept) for
+// the show, replacing any earlier one; keeping a dropped show undoes the
+// drop.
+func (r *Recommender) DecideShow(ctx context.Context, id uint, decision string) (models.ShowDecision, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&models.TVShow{}).Where("id = ?", id).Count(&n).Error; err != nil {
+		return models.ShowDecision{}, fmt.Errorf("find tv show: %w", err)
+	}
+	if n == 0 {
+		return models.ShowDecision{}, ErrShowNotFound
+	}
+	d := models.ShowDecision{TVShowID: id, Decision: decision}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tv_show_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"decision", "updated_at"}),
+	}).Create(&d).Error; err != nil {
+		return models.ShowDecision{}, fmt.Errorf("save show decision: %w", err)
+	}
+	return d, nil
+}
+
+// ShowDecisionFor returns the decision recorded for the show, or nil.
+func (r *Recommender) ShowDecisionFor(ctx context.Context, id uint) (*models.ShowDecision, error) {
+	var d models.ShowDecision
+	res := r.db.WithContext(ctx).Where("tv_show_id = ?", id).Limit(1).Find(&d)
+	if res.Error != nil {
+		return nil, fmt.Errorf("load show decision: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// droppedShowIDs returns the IDs of shows that were dropped.
+func (r *Recommender) droppedShowIDs(ctx context.Context) (map[uint]struct{}, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&models.ShowDecision{}).
+		Where("decision = ?", models.ShowDropped).Pluck("tv_show_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("load dropped shows: %w", err)
+	}
+	out := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package recommend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestAbandonedShows(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	shows := []models.TVShow{
+		{Title: "Stalled", PlexRatingKey: "s1", Episodes: 10, WatchedEpisodes: 3, ViewCount: 3, LastViewedAt: ago(200)},
+		{Title: "Finished", PlexRatingKey: "s2", Episodes: 10, WatchedEpisodes: 10, ViewCount: 10, LastViewedAt: ago(200)},
+		{Title: "In progress", PlexRatingKey: "s3", Episodes: 10, WatchedEpisodes: 3, ViewCount: 3, LastViewedAt: ago(10)},
+		{Title: "Unstarted", PlexRatingKey: "s4", Episodes: 10},
+		{Title: "Also stalled", PlexRatingKey: "s5", Episodes: 8, WatchedEpisodes: 1, ViewCount: 1, LastViewedAt: ago(120)},
+	}
+	if err := db.Create(&shows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.AbandonedShows(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Title != "Stalled" || got[1].Title != "Also stalled" {
+		t.Fatalf("AbandonedShows = %+v, want Stalled then Also stalled", got)
+	}
+
+	if _, err := r.DecideShow(ctx, shows[0].ID, models.ShowDropped); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DecideShow(ctx, shows[4].ID, models.ShowKept); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.AbandonedShows(ctx, now); err != nil || len(got) != 0 {
+		t.Errorf("after deciding: AbandonedShows = %+v, %v; want none", got, err)
+	}
+	if _, err := r.DecideShow(ctx, 9999, models.ShowDropped); !errors.Is(err, ErrShowNotFound) {
+		t.Errorf("unknown show: err = %v, want ErrShowNotFound", err)
+	}
+}
+
+func TestLoadCandidates_skipsDroppedShows(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	shows := []models.TVShow{{Title: "Dropped", PlexRatingKey: "d1"}, {Title: "Fresh", PlexRatingKey: "f1"}}
+	if err := db.Create(&shows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DecideShow(ctx, shows[0].ID, models.ShowDropped); err != nil {
+		t.Fatal(err)
+	}
+	_, tv, err := r.loadCandidates(ctx, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tv) != 1 || tv[0].Title != "Fresh" {
+		t.Errorf("tv candidates = %+v, want only Fresh", tv)
+	}
+}
@@ -0,0 +1,56 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// DayActivity is what happened on one day: how many picks it got, and
+// whether its generation is failing.
+type DayActivity struct {
+	Date   time.Time
+	Picks  int
+	Failed bool // the latest run for one of the day's slots ended in error
+}
+
+// DailyActivity aggregates household recommendations and failed generation
+// runs per day in [from, to), oldest first. Days with neither are left out, as are
+// days that haven't begun.
+func (r *Recommender) DailyActivity(ctx context.Context, from, to time.Time) ([]DayActivity, error) {
+	_, before := recommendationUTCDayRange(time.Now())
+	var rows []struct {
+		D      string
+		Picks  int
+		Failed bool
+	}
+	if err := r.reads().WithContext(ctx).Raw(`
+		SELECT d, SUM(picks)::int AS picks, bool_or(failed) AS failed FROM (
+			SELECT to_char("date", 'YYYY-MM-DD') AS d, COUNT(*) AS picks, false AS failed
+			FROM (?) AS recommendations
+			WHERE "date" >= ? AND "date" < ? AND "date" < ?
+			GROUP BY 1
+			UNION ALL
+			SELECT to_char("date", 'YYYY-MM-DD'), 0, true
+			FROM generation_runs
+			WHERE "date" >= ? AND "date" < ? AND "date" < ? AND status = ?
+		) AS days
+		GROUP BY d
+		ORDER BY d`,
+		householdRecs(r.reads().WithContext(ctx)), from.UTC(), to.UTC(), before,
+		from.UTC(), to.UTC(), before, models.RunStatusError,
+	).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate daily activity: %w", err)
+	}
+	out := make([]DayActivity, 0, len(rows))
+	for _, row := range rows {
+		t, err := time.Parse("2006-01-02", row.D)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", row.D, err)
+		}
+		out = append(out, DayActivity{Date: t, Picks: row.Picks, Failed: row.Failed})
+	}
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestDailyActivity(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday, lastWeek := today.AddDate(0, 0, -1), today.AddDate(0, 0, -7)
+
+	for i, title := range []string{"A", "B", "C"} {
+		if err := db.Create(&models.Recommendation{
+			Date: yesterday, Title: title, Type: models.TypeMovie, Year: 2020,
+			Rating: new(8.0), Genre: testGenreComedy, TMDbID: i + 1,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.Recommendation{
+		Date: today.AddDate(0, 0, 1), Title: "Tomorrow", Type: models.TypeMovie, Year: 2020, TMDbID: 9,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []models.GenerationRun{
+		{Date: yesterday, Context: "late", Status: models.RunStatusError},
+		{Date: lastWeek, Context: models.RunContextDaily, Status: models.RunStatusError},
+		{Date: today, Context: models.RunContextDaily, Status: models.RunStatusOK},
+	} {
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := r.DailyActivity(t.Context(), today.AddDate(0, 0, -30), today.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DayActivity{
+		{Date: lastWeek, Failed: true},
+		{Date: yesterday, Picks: 3, Failed: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("activity = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || got[i].Picks != want[i].Picks || got[i].Failed != want[i].Failed {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package recommend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// anniversaryYears returns how many years before date release was, when
+// date is the release's anniversary and the count is a milestone: every
+// tenth year and every quarter century (10, 20, 25, 30, … 75, …). A Feb 29
+// release celebrates on Feb 28 in other years. It returns 0 otherwise.
+func anniversaryYears(release *time.Time, date time.Time) int {
+	if release == nil {
+		return 0
+	}
+	rel, day := release.UTC(), date.UTC()
+	month, dom := rel.Month(), rel.Day()
+	if month == time.February && dom == 29 && !isLeap(day.Year()) {
+		dom = 28
+	}
+	if day.Month() != month || day.Day() != dom {
+		return 0
+	}
+	years := day.Year() - rel.Year()
+	if years <= 0 || (years%10 != 0 && years%25 != 0) {
+		return 0
+	}
+	return years
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// anniversaryNote is how a milestone reads in the prompt and explanations.
+func anniversaryNote(years int) string {
+	return fmt.Sprintf("released %d years ago today", years)
+}
+
+// noteAnniversaries adds the milestone to the explanation of each
+// anniversary pick that doesn't already mention it.
+func noteAnniversaries(recs []models.Recommendation) {
+	for i := range recs {
+		years := recs[i].Anniversary
+		if years == 0 || strings.Contains(recs[i].Explanation, fmt.Sprintf("%d years", years)) {
+			continue
+		}
+		note := anniversaryNote(years)
+		note = strings.ToUpper(note[:1]) + note[1:] + "."
+		if recs[i].Explanation == "" {
+			recs[i].Explanation = note
+		} else {
+			recs[i].Explanation = strings.TrimSpace(recs[i].Explanation) + " " + note
+		}
+	}
+}
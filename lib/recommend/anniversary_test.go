@@ -0,0 +1,56 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestAnniversaryYears(t *testing.T) {
+	day := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	for _, tc := range []struct {
+		release *time.Time
+		date    time.Time
+		want    int
+	}{
+		{day(2001, 7, 15), *day(2026, 7, 15), 25},
+		{day(1976, 7, 15), *day(2026, 7, 15), 50},
+		{day(2016, 7, 15), *day(2026, 7, 15), 10},
+		{day(2003, 7, 15), *day(2026, 7, 15), 0},  // 23 years isn't a milestone
+		{day(2001, 7, 16), *day(2026, 7, 15), 0},  // a day off
+		{day(1996, 2, 29), *day(2026, 2, 28), 30}, // leap-day release, non-leap year
+		{day(1996, 2, 29), *day(2024, 2, 28), 0},  // leap year: celebrated on the 29th
+		{nil, *day(2026, 7, 15), 0},
+	} {
+		if got := anniversaryYears(tc.release, tc.date); got != tc.want {
+			t.Errorf("anniversaryYears(%v, %s) = %d, want %d", tc.release, tc.date.Format(time.DateOnly), got, tc.want)
+		}
+	}
+}
+
+func TestNoteAnniversaries(t *testing.T) {
+	recs := []models.Recommendation{
+		{Title: "A", Anniversary: 25, Explanation: "A heist classic."},
+		{Title: "B", Anniversary: 30, Explanation: "Out 30 years today!"},
+		{Title: "C", Anniversary: 10},
+		{Title: "D", Explanation: "Just good."},
+	}
+	noteAnniversaries(recs)
+	for i, want := range []string{
+		"A heist classic. Released 25 years ago today.",
+		"Out 30 years today!",
+		"Released 10 years ago today.",
+		"Just good.",
+	} {
+		if recs[i].Explanation != want {
+			t.Errorf("%s: explanation = %q, want %q", recs[i].Title, recs[i].Explanation, want)
+		}
+	}
+	if b := scoreBreakdown(candidate{Anniversary: 25}); b.Anniversary != scoreWeights.Anniversary {
+		t.Errorf("anniversary boost = %v, want %v", b.Anniversary, scoreWeights.Anniversary)
+	}
+}
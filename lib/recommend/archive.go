@@ -0,0 +1,88 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icco/recommender/models"
+)
+
+// genreTags is the SQL expression splitting a recommendation's comma-joined
+// genres into a lowercased array. It must match idx_recommendations_genres
+// exactly for Postgres to use that index.
+const genreTags = `string_to_array(lower(genre), ', ')`
+
+// ArchiveFilter narrows the archive of past recommendations. Zero values
+// match everything.
+type ArchiveFilter struct {
+	Genre  string // any of a pick's genres, case-insensitive
+	Decade int    // first year of the release decade, e.g. 1980
+}
+
+// ParseDecade parses a decade as "1980s" or "1980". An empty string is 0 (any
+// decade).
+func ParseDecade(s string) (int, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "s")
+	if s == "" {
+		return 0, nil
+	}
+	d, err := strconv.Atoi(s)
+	if err != nil || d < 1000 || d > 9990 || d%10 != 0 {
+		return 0, fmt.Errorf("invalid decade %q (want e.g. 1980s)", s)
+	}
+	return d, nil
+}
+
+// GetArchive returns a page of past household recommendations matching f,
+// newest day first, and the total number of matches.
+func (r *Recommender) GetArchive(ctx context.Context, f ArchiveFilter, page, pageSize int) ([]models.Recommendation, int64, error) {
+	q := published(householdRecs(r.reads().WithContext(ctx)))
+	if g := strings.TrimSpace(f.Genre); g != "" {
+		q = q.Where(genreTags+` @> ARRAY[lower(?)]`, g)
+	}
+	if f.Decade > 0 {
+		q = q.Where("year >= ? AND year < ?", f.Decade, f.Decade+10)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count archive: %w", err)
+	}
+	var recs []models.Recommendation
+	if err := q.Order(`"date" DESC, slot, type, id`).
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&recs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get archive: %w", err)
+	}
+	return recs, total, nil
+}
+
+// ArchiveGenres lists the distinct genres across all past household
+// recommendations, alphabetically, for the archive's filter menu.
+func (r *Recommender) ArchiveGenres(ctx context.Context) ([]string, error) {
+	var genres []string
+	if err := r.reads().WithContext(ctx).Raw(`
+		SELECT DISTINCT trim(g) AS g
+		FROM (?) AS recommendations, unnest(string_to_array(genre, ',')) AS g
+		WHERE trim(g) <> ''
+		ORDER BY g`, householdRecs(r.reads().WithContext(ctx))).Scan(&genres).Error; err != nil {
+		return nil, fmt.Errorf("failed to get archive genres: %w", err)
+	}
+	return genres, nil
+}
+
+// ArchiveDecades lists the release decades across all past household
+// recommendations, newest first.
+func (r *Recommender) ArchiveDecades(ctx context.Context) ([]int, error) {
+	var decades []int
+	if err := r.reads().WithContext(ctx).Raw(`
+		SELECT DISTINCT year / 10 * 10 AS d
+		FROM (?) AS recommendations
+		WHERE year > 0
+		ORDER BY d DESC`, householdRecs(r.reads().WithContext(ctx))).Scan(&decades).Error; err != nil {
+		return nil, fmt.Errorf("failed to get archive decades: %w", err)
+	}
+	return decades, nil
+}
@@ -0,0 +1,88 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestParseDecade(t *testing.T) {
+	for in, want := range map[string]int{"1980s": 1980, "1990": 1990, " 2020s ": 2020, "": 0} {
+		if got, err := ParseDecade(in); err != nil || got != want {
+			t.Errorf("ParseDecade(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"80s", "1985", "eighties"} {
+		if _, err := ParseDecade(in); err == nil {
+			t.Errorf("ParseDecade(%q) should fail", in)
+		}
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+
+	day := func(d int) time.Time { return time.Date(2025, 10, d, 0, 0, 0, 0, time.UTC) }
+	for _, rec := range []models.Recommendation{
+		{Date: day(1), Title: "Halloween", Type: models.TypeMovie, Year: 1978, Genre: "Horror"},
+		{Date: day(2), Title: "The Thing", Type: models.TypeMovie, Year: 1982, Genre: "Horror, Science Fiction"},
+		{Date: day(3), Title: "Airplane!", Type: models.TypeMovie, Year: 1980, Genre: testGenreComedy},
+		{Date: day(4), Title: "Hereditary", Type: models.TypeMovie, Year: 2018, Genre: "Drama, Horror"},
+	} {
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	titles := func(recs []models.Recommendation) []string {
+		out := make([]string, len(recs))
+		for i, rec := range recs {
+			out[i] = rec.Title
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		filter ArchiveFilter
+		want   []string
+	}{
+		{ArchiveFilter{Genre: "horror"}, []string{"Hereditary", "The Thing", "Halloween"}},
+		{ArchiveFilter{Genre: "Horror", Decade: 1980}, []string{"The Thing"}},
+		{ArchiveFilter{Decade: 1980}, []string{"Airplane!", "The Thing"}},
+		{ArchiveFilter{Genre: "Science"}, nil},
+	} {
+		recs, total, err := r.GetArchive(ctx, tc.filter, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := titles(recs)
+		if int(total) != len(tc.want) || len(got) != len(tc.want) {
+			t.Errorf("%+v: got %v (total %d), want %v", tc.filter, got, total, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%+v: got %v, want %v", tc.filter, got, tc.want)
+				break
+			}
+		}
+	}
+
+	recs, total, err := r.GetArchive(ctx, ArchiveFilter{Genre: "Horror"}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(recs) != 1 || recs[0].Title != "Halloween" {
+		t.Errorf("page 2 = %v (total %d), want [Halloween] of 3", titles(recs), total)
+	}
+
+	genres, err := r.ArchiveGenres(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{testGenreComedy, "Drama", "Horror", "Science Fiction"}; len(genres) != len(want) {
+		t.Errorf("genres = %v, want %v", genres, want)
+	}
+}
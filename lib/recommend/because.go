@@ -0,0 +1,332 @@
+package recommend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/vectors"
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// BecauseRows is how many "Because you watched …" rows are kept.
+	BecauseRows = 3
+	// BecauseRowSize is how many titles one row holds at most.
+	BecauseRowSize = 6
+	// becauseSeeds is how many of the latest watched titles are tried, so a
+	// seed with nothing close left to watch can be passed over.
+	becauseSeeds = 10
+	// embedBatch is how many descriptions go to the embedder at once.
+	embedBatch = 50
+)
+
+// WatchedRow is a "Because you watched …" row for the home page.
+type WatchedRow struct {
+	Seed            string // the watched title
+	Recommendations []models.Recommendation
+}
+
+// libraryTitle is a live library title, with when it was last watched.
+type libraryTitle struct {
+	candidate
+	watched    bool
+	lastViewed *time.Time
+}
+
+// libraryTitles loads every movie and show in the library, or only those
+// added since addedSince when it isn't zero.
+func (r *Recommender) libraryTitles(ctx context.Context, now, addedSince time.Time) ([]libraryTitle, error) {
+	q := func() *gorm.DB {
+		if addedSince.IsZero() {
+			return r.db.WithContext(ctx)
+		}
+		return r.db.WithContext(ctx).Where("created_at >= ?", addedSince)
+	}
+	var movies []models.Movie
+	if err := q().Find(&movies).Error; err != nil {
+		return nil, fmt.Errorf("load movies: %w", err)
+	}
+	var shows []models.TVShow
+	if err := q().Find(&shows).Error; err != nil {
+		return nil, fmt.Errorf("load tv shows: %w", err)
+	}
+	out := make([]libraryTitle, 0, len(movies)+len(shows))
+	for _, m := range movies {
+		out = append(out, libraryTitle{candidate: movieCandidate(m, now), watched: m.ViewCount > 0, lastViewed: m.LastViewedAt})
+	}
+	for _, s := range shows {
+		// A show already started is one to continue, not to discover.
+		out = append(out, libraryTitle{candidate: tvCandidate(s, now), watched: s.ViewCount > 0 || s.WatchedEpisodes > 0, lastViewed: s.LastViewedAt})
+	}
+	return out, nil
+}
+
+// embedText describes c for the embedder.
+func embedText(c candidate) string {
+	kind := "movie"
+	if c.Type == models.TypeTVShow {
+		kind = "TV show"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d), a %s.", c.Title, c.Year, kind)
+	if len(c.Genres) > 0 {
+		fmt.Fprintf(&b, " Genres: %s.", strings.Join(c.Genres, ", "))
+	}
+	if len(c.Directors) > 0 {
+		fmt.Fprintf(&b, " Directed by %s.", strings.Join(c.Directors, ", "))
+	}
+	if len(c.Actors) > 0 {
+		fmt.Fprintf(&b, " Starring %s.", strings.Join(c.Actors[:min(5, len(c.Actors))], ", "))
+	}
+	return b.String()
+}
+
+// RefreshBecauseRows embeds titles whose description changed since they were
+// last embedded, brings the vector index up to date, then replaces the
+// "Because you watched …" rows: for each of the most recently watched
+// titles, the unwatched titles closest to it. Titles already in an earlier
+// row aren't repeated.
+func (r *Recommender) RefreshBecauseRows(ctx context.Context, now time.Time) error {
+	titles, err := r.libraryTitles(ctx, now, time.Time{})
+	if err != nil {
+		return err
+	}
+	vecs, changed, err := r.refreshEmbeddings(ctx, titles)
+	if err != nil {
+		return err
+	}
+	model, store := r.embeddings().Model(), r.vectors
+	n, err := store.Count(ctx, model)
+	if err != nil {
+		return fmt.Errorf("count %s vectors: %w", store.Name(), err)
+	}
+	// An index missing vectors (first run, a new model, another backend) is
+	// rebuilt whole; otherwise only the changed vectors are written.
+	if n < len(vecs) {
+		err = r.RebuildVectors(ctx)
+	} else {
+		err = store.Put(ctx, model, changed)
+	}
+	if err != nil {
+		return fmt.Errorf("update %s vectors: %w", store.Name(), err)
+	}
+
+	rows, err := becauseRows(ctx, titles, vecs, store, model)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id > 0").Delete(&models.BecauseRow{}).Error; err != nil {
+			return fmt.Errorf("clear because rows: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("save because rows: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Infow("Refreshed because-you-watched rows",
+		"model", model, "store", store.Name(), "embedded", len(changed), "rows", len(rows))
+	return nil
+}
+
+// RebuildVectors replaces the vector index with every stored embedding of
+// the current model, e.g. after switching VECTOR_STORE or embedding model.
+func (r *Recommender) RebuildVectors(ctx context.Context) error {
+	model, store := r.embeddings().Model(), r.vectors
+	var stored []models.TitleEmbedding
+	if err := r.db.WithContext(ctx).Where("model = ?", model).Find(&stored).Error; err != nil {
+		return fmt.Errorf("load embeddings: %w", err)
+	}
+	items := make([]vectors.Item, len(stored))
+	for i, s := range stored {
+		items[i] = vectors.Item{Key: vectors.Key{Type: s.Type, ID: s.TitleID}, Vector: s.Vector}
+	}
+	if err := store.Rebuild(ctx, model, items); err != nil {
+		return fmt.Errorf("rebuild %s index: %w", store.Name(), err)
+	}
+	logging.FromContext(ctx).Infow("Rebuilt vector index", "store", store.Name(), "model", model, "vectors", len(items))
+	return nil
+}
+
+// refreshEmbeddings returns the vectors of titles, embedding those not yet
+// embedded with the current model or whose description changed, and the
+// newly embedded ones. Batches are saved as they finish, so a failure part
+// way keeps the work done.
+func (r *Recommender) refreshEmbeddings(ctx context.Context, titles []libraryTitle) (map[vectors.Key][]float32, []vectors.Item, error) {
+	e := r.embeddings()
+	var stored []models.TitleEmbedding
+	if err := r.db.WithContext(ctx).Where("model = ?", e.Model()).Find(&stored).Error; err != nil {
+		return nil, nil, fmt.Errorf("load embeddings: %w", err)
+	}
+	live := make(map[vectors.Key]bool, len(titles))
+	for _, t := range titles {
+		live[vectors.Key{Type: t.Type, ID: t.ID}] = true
+	}
+	vecs := make(map[vectors.Key][]float32, len(titles))
+	hashes := make(map[vectors.Key]string, len(stored))
+	for _, s := range stored {
+		k := vectors.Key{Type: s.Type, ID: s.TitleID}
+		if live[k] {
+			vecs[k] = s.Vector
+			hashes[k] = s.TextHash
+		}
+	}
+
+	var stale []models.TitleEmbedding
+	var texts []string
+	for _, t := range titles {
+		text := embedText(t.candidate)
+		sum := sha256.Sum256([]byte(text))
+		h := hex.EncodeToString(sum[:])
+		if hashes[vectors.Key{Type: t.Type, ID: t.ID}] == h {
+			continue
+		}
+		stale = append(stale, models.TitleEmbedding{Type: t.Type, TitleID: t.ID, Model: e.Model(), TextHash: h})
+		texts = append(texts, text)
+	}
+
+	var changed []vectors.Item
+	for start := 0; start < len(stale); start += embedBatch {
+		end := min(start+embedBatch, len(stale))
+		vs, err := e.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, nil, fmt.Errorf("embed titles: %w", err)
+		}
+		batch := stale[start:end]
+		for i := range batch {
+			batch[i].Vector = vs[i]
+		}
+		if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "type"}, {Name: "title_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"model", "text_hash", "vector", "updated_at"}),
+		}).Create(&batch).Error; err != nil {
+			return nil, nil, fmt.Errorf("save embeddings: %w", err)
+		}
+		for _, s := range batch {
+			k := vectors.Key{Type: s.Type, ID: s.TitleID}
+			vecs[k] = s.Vector
+			changed = append(changed, vectors.Item{Key: k, Vector: s.Vector})
+		}
+	}
+	return vecs, changed, nil
+}
+
+// becauseRows builds up to BecauseRows rows: the latest watched titles with
+// a vector are the seeds, each followed by the unwatched titles store finds
+// nearest to it. Seeds with nothing left to suggest are skipped.
+func becauseRows(ctx context.Context, titles []libraryTitle, vecs map[vectors.Key][]float32, store vectors.Store, model string) ([]models.BecauseRow, error) {
+	var seeds []libraryTitle
+	unwatched := make(map[vectors.Key]bool)
+	for _, t := range titles {
+		k := vectors.Key{Type: t.Type, ID: t.ID}
+		if _, ok := vecs[k]; !ok {
+			continue
+		}
+		if t.lastViewed != nil {
+			seeds = append(seeds, t)
+		}
+		if !t.watched {
+			unwatched[k] = true
+		}
+	}
+	slices.SortStableFunc(seeds, func(a, b libraryTitle) int {
+		return b.lastViewed.Compare(*a.lastViewed)
+	})
+	seeds = seeds[:min(becauseSeeds, len(seeds))]
+
+	used := make(map[vectors.Key]bool)
+	var rows []models.BecauseRow
+	for _, seed := range seeds {
+		if len(rows) == BecauseRows {
+			break
+		}
+		sk := vectors.Key{Type: seed.Type, ID: seed.ID}
+		matches, err := store.Nearest(ctx, model, vecs[sk], BecauseRowSize, func(k vectors.Key) bool {
+			return k == sk || used[k] || !unwatched[k]
+		})
+		if err != nil {
+			return nil, fmt.Errorf("find titles like %q: %w", seed.Title, err)
+		}
+		var refs []models.TitleRef
+		for _, m := range matches {
+			if m.Similarity > 0 {
+				used[m.Key] = true
+				refs = append(refs, models.TitleRef{Type: m.Type, ID: m.ID, Similarity: m.Similarity})
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		rows = append(rows, models.BecauseRow{
+			Position: len(rows), SeedType: seed.Type, SeedID: seed.ID, SeedTitle: seed.Title, Titles: refs,
+		})
+	}
+	return rows, nil
+}
+
+// BecauseYouWatched returns the stored "Because you watched …" rows. Titles
+// that have since left the library or been watched are left out, as are
+// rows left empty.
+func (r *Recommender) BecauseYouWatched(ctx context.Context, now time.Time) ([]WatchedRow, error) {
+	var rows []models.BecauseRow
+	if err := r.db.WithContext(ctx).Order("position").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("load because rows: %w", err)
+	}
+	var movieIDs, showIDs []uint
+	for _, row := range rows {
+		for _, t := range row.Titles {
+			if t.Type == models.TypeMovie {
+				movieIDs = append(movieIDs, t.ID)
+			} else {
+				showIDs = append(showIDs, t.ID)
+			}
+		}
+	}
+
+	titles := make(map[string]candidate)
+	if len(movieIDs) > 0 {
+		var movies []models.Movie
+		if err := r.db.WithContext(ctx).Where("id IN ? AND view_count = 0", movieIDs).Find(&movies).Error; err != nil {
+			return nil, fmt.Errorf("load movies: %w", err)
+		}
+		for _, m := range movies {
+			titles[candKey(models.TypeMovie, m.ID)] = movieCandidate(m, now)
+		}
+	}
+	if len(showIDs) > 0 {
+		var shows []models.TVShow
+		if err := r.db.WithContext(ctx).Where("id IN ? AND view_count = 0 AND watched_episodes = 0", showIDs).Find(&shows).Error; err != nil {
+			return nil, fmt.Errorf("load tv shows: %w", err)
+		}
+		for _, s := range shows {
+			titles[candKey(models.TypeTVShow, s.ID)] = tvCandidate(s, now)
+		}
+	}
+
+	var out []WatchedRow
+	for _, row := range rows {
+		w := WatchedRow{Seed: row.SeedTitle}
+		for _, t := range row.Titles {
+			if c, ok := titles[candKey(t.Type, t.ID)]; ok {
+				w.Recommendations = append(w.Recommendations, toRec(c, "", time.Time{}))
+			}
+		}
+		if len(w.Recommendations) > 0 {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package recommend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/vectors"
+	"github.com/icco/recommender/models"
+)
+
+func TestFeatureEmbedder_similarTitlesAreCloser(t *testing.T) {
+	texts := []string{
+		embedText(candidate{Type: models.TypeMovie, Title: "Arrival", Year: 2016, Genres: []string{"Science Fiction", "Drama"}, Directors: []string{"Denis Villeneuve"}}),
+		embedText(candidate{Type: models.TypeMovie, Title: "Dune", Year: 2021, Genres: []string{"Science Fiction", "Adventure"}, Directors: []string{"Denis Villeneuve"}}),
+		embedText(candidate{Type: models.TypeMovie, Title: "Paddington", Year: 2014, Genres: []string{"Family", "Comedy"}, Directors: []string{"Paul King"}}),
+	}
+	vs, err := featureEmbedder{}.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if near, far := vectors.Cosine(vs[0], vs[1]), vectors.Cosine(vs[0], vs[2]); near <= far {
+		t.Errorf("Arrival~Dune = %.2f, Arrival~Paddington = %.2f; want Dune closer", near, far)
+	}
+	if got := vectors.Cosine(vs[0], vs[0]); got < 0.999 {
+		t.Errorf("self-similarity = %.3f, want 1", got)
+	}
+}
+
+func TestBecauseRows(t *testing.T) {
+	day := func(d int) *time.Time {
+		v := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &v
+	}
+	title := func(id uint, name string, watched bool, seen *time.Time) libraryTitle {
+		return libraryTitle{candidate: candidate{ID: id, Type: models.TypeMovie, Title: name}, watched: watched, lastViewed: seen}
+	}
+	titles := []libraryTitle{
+		title(1, "Arrival", true, day(10)),
+		title(2, "Paddington", true, day(12)),
+		title(3, "Dune", false, nil),
+		title(4, "Sicario", false, nil),
+		title(5, "Paddington 2", false, nil),
+		title(6, "Unembedded", false, nil),
+	}
+	vecs := map[vectors.Key][]float32{
+		{Type: models.TypeMovie, ID: 1}: {1, 0},
+		{Type: models.TypeMovie, ID: 2}: {0, 1},
+		{Type: models.TypeMovie, ID: 3}: {0.9, 0},
+		{Type: models.TypeMovie, ID: 4}: {0.7, 0},
+		{Type: models.TypeMovie, ID: 5}: {0.1, 0.9},
+	}
+	store := vectors.NewMemory()
+	var items []vectors.Item
+	for k, v := range vecs {
+		items = append(items, vectors.Item{Key: k, Vector: v})
+	}
+	if err := store.Rebuild(t.Context(), "test", items); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := becauseRows(t.Context(), titles, vecs, store, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %+v", len(rows), rows)
+	}
+	// Paddington was watched last, so its row comes first and claims the
+	// titles closest to it; Arrival's row doesn't repeat them.
+	if rows[0].SeedTitle != "Paddington" || rows[0].Position != 0 {
+		t.Errorf("row 0 = %+v, want Paddington first", rows[0])
+	}
+	if got := rows[0].Titles[0].ID; got != 5 {
+		t.Errorf("Paddington's closest = %d, want Paddington 2", got)
+	}
+	seen := map[uint]bool{}
+	for _, row := range rows {
+		for _, ref := range row.Titles {
+			if seen[ref.ID] || ref.ID == 1 || ref.ID == 2 || ref.ID == 6 {
+				t.Errorf("row %q has %d: watched, unembedded, or repeated", row.SeedTitle, ref.ID)
+			}
+			seen[ref.ID] = true
+		}
+	}
+	if rows[1].SeedTitle != "Arrival" || len(rows[1].Titles) == 0 {
+		t.Errorf("row 1 = %+v, want Arrival with titles", rows[1])
+	}
+}
+
+func TestRefreshBecauseRows(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	seen := now.AddDate(0, 0, -1)
+	for _, m := range []models.Movie{
+		{Title: "Arrival", Year: 2016, PlexRatingKey: "m1", Genre: "Science Fiction, Drama", Director: "Denis Villeneuve", ViewCount: 1, LastViewedAt: &seen},
+		{Title: "Dune", Year: 2021, PlexRatingKey: "m2", Genre: "Science Fiction, Adventure", Director: "Denis Villeneuve"},
+		{Title: "Paddington", Year: 2014, PlexRatingKey: "m3", Genre: "Family, Comedy", Director: "Paul King"},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 { // the second run re-embeds nothing and replaces the rows
+		if err := r.RefreshBecauseRows(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n int64
+	if err := db.Model(&models.TitleEmbedding{}).Where("model = ?", FeatureEmbeddingModel).Count(&n).Error; err != nil || n != 3 {
+		t.Errorf("embeddings = %d, %v; want 3", n, err)
+	}
+	if n, err := r.vectors.Count(ctx, FeatureEmbeddingModel); err != nil || n != 3 {
+		t.Errorf("indexed vectors = %d, %v; want 3", n, err)
+	}
+
+	rows, err := r.BecauseYouWatched(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Seed != "Arrival" || len(rows[0].Recommendations) == 0 {
+		t.Fatalf("rows = %+v, want one Arrival row", rows)
+	}
+	if got := rows[0].Recommendations[0].Title; got != "Dune" {
+		t.Errorf("closest to Arrival = %q, want Dune", got)
+	}
+
+	// Watching Dune drops it from the row without a refresh.
+	if err := db.Model(&models.Movie{}).Where("title = ?", "Dune").Update("view_count", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	rows, err = r.BecauseYouWatched(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		for _, rec := range row.Recommendations {
+			if rec.Title == "Dune" {
+				t.Errorf("watched Dune still shown in %q", row.Seed)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package recommend
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Blackout is an inclusive span of UTC days with no generation, such as a
+// vacation.
+type Blackout struct {
+	From, To time.Time // UTC midnights; To is the last paused day
+}
+
+// Contains reports whether date's UTC day falls within b.
+func (b Blackout) Contains(date time.Time) bool {
+	day := date.UTC().Truncate(24 * time.Hour)
+	return !day.Before(b.From) && !day.After(b.To)
+}
+
+// Blackouts are the configured paused days, sorted by start.
+type Blackouts []Blackout
+
+// ParseBlackouts parses BLACKOUT_DATES-style days and ranges such as
+// "2026-07-04,2026-12-20..2027-01-02".
+func ParseBlackouts(s string) (Blackouts, error) {
+	var out Blackouts
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fromStr, toStr, isRange := strings.Cut(part, "..")
+		if !isRange {
+			toStr = fromStr
+		}
+		from, fromErr := time.Parse(time.DateOnly, strings.TrimSpace(fromStr))
+		to, toErr := time.Parse(time.DateOnly, strings.TrimSpace(toStr))
+		if fromErr != nil || toErr != nil || to.Before(from) {
+			return nil, fmt.Errorf("invalid blackout %q (want YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD)", part)
+		}
+		out = append(out, Blackout{From: from, To: to})
+	}
+	slices.SortFunc(out, func(a, b Blackout) int { return a.From.Compare(b.From) })
+	return out, nil
+}
+
+// Find returns the blackout containing date, if any. Overlapping ranges
+// are merged, so To is the last day of the whole pause.
+func (bs Blackouts) Find(date time.Time) (Blackout, bool) {
+	var found Blackout
+	ok := false
+	for _, b := range bs {
+		switch {
+		case !ok && b.Contains(date):
+			found, ok = b, true
+		case ok && !b.From.After(found.To.AddDate(0, 0, 1)):
+			found.To = maxTime(found.To, b.To)
+		}
+	}
+	return found, ok
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Paused returns the blackout covering date, during which generation is
+// skipped and the day shows as paused instead of missing.
+func (r *Recommender) Paused(date time.Time) (Blackout, bool) {
+	return r.currentSettings().Blackouts.Find(date)
+}
@@ -0,0 +1,42 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBlackouts(t *testing.T) {
+	bs, err := ParseBlackouts(" 2026-12-20..2026-12-31, 2026-07-04 ,2027-01-01..2027-01-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 15, 0, 0, 0, time.UTC) }
+	if b, ok := bs.Find(day(7, 4)); !ok || !b.To.Equal(time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Jul 4 = %+v, %v; want a one-day blackout", b, ok)
+	}
+	if _, ok := bs.Find(day(7, 5)); ok {
+		t.Error("Jul 5 should not be paused")
+	}
+	// Back-to-back ranges read as one pause.
+	if b, ok := bs.Find(day(12, 24)); !ok || !b.To.Equal(time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Dec 24 = %+v, %v; want paused through Jan 2", b, ok)
+	}
+
+	for _, bad := range []string{"2026-12-31..2026-12-20", "next week", "2026-12-20..", "2026-13-01"} {
+		if _, err := ParseBlackouts(bad); err == nil {
+			t.Errorf("ParseBlackouts(%q): want an error", bad)
+		}
+	}
+}
+
+func TestGenerateSlot_skipsBlackout(t *testing.T) {
+	r := &Recommender{} // no database: a paused day must not touch it
+	bs, err := ParseBlackouts("2026-08-01..2026-08-14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ApplySettings(Settings{Blackouts: bs})
+	if err := r.GenerateRecommendations(t.Context(), time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("generate on a blackout day = %v, want a no-op", err)
+	}
+}
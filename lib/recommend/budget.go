@@ -0,0 +1,101 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+)
+
+// DefaultLLMDailyCap bounds LLM calls per UTC day when LLM_DAILY_CAP is unset.
+// A normal day needs one call; the headroom covers retries and on-demand runs.
+const DefaultLLMDailyCap = 20
+
+// ErrLLMDailyCap is returned instead of calling the model once the day's cap
+// is spent.
+var ErrLLMDailyCap = errors.New("daily LLM call cap reached")
+
+type capOverrideKey struct{}
+
+// WithLLMCapOverride marks ctx so calls made under it skip the daily cap (they
+// are still counted).
+func WithLLMCapOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, capOverrideKey{}, true)
+}
+
+func capOverridden(ctx context.Context) bool {
+	v, _ := ctx.Value(capOverrideKey{}).(bool)
+	return v
+}
+
+// CappedChatter wraps a Chatter with a soft per-day call limit shared by all
+// replicas through the llm_usages table, so a misconfigured external cron
+// can't run up the model bill.
+type CappedChatter struct {
+	next Chatter
+	db   *gorm.DB
+	cap  atomic.Int64
+}
+
+// NewCappedChatter limits next to limit calls per UTC day. limit <= 0 disables
+// the cap (calls are still counted).
+func NewCappedChatter(db *gorm.DB, next Chatter, limit int) *CappedChatter {
+	c := &CappedChatter{next: next, db: db}
+	c.SetLimit(limit)
+	return c
+}
+
+// SetLimit changes the daily limit; calls already counted today still count.
+func (c *CappedChatter) SetLimit(limit int) {
+	c.cap.Store(int64(limit))
+}
+
+// Complete reserves one call from today's budget, then delegates.
+func (c *CappedChatter) Complete(ctx context.Context, system, user string, schema *genai.Schema) (string, error) {
+	if err := c.reserve(ctx); err != nil {
+		return "", err
+	}
+	return c.next.Complete(ctx, system, user, schema)
+}
+
+// Stream reserves one call from today's budget, then streams from the
+// wrapped Chatter (or completes, if it can't stream).
+func (c *CappedChatter) Stream(ctx context.Context, system, user string, schema *genai.Schema, fn func(chunk string)) (string, error) {
+	if err := c.reserve(ctx); err != nil {
+		return "", err
+	}
+	return streamOrComplete(ctx, c.next, system, user, schema, fn)
+}
+
+// reserve atomically increments today's counter unless that would exceed the
+// cap. The conditional upsert makes the check race-free across replicas.
+func (c *CappedChatter) reserve(ctx context.Context) error {
+	now := time.Now().UTC()
+	capLimit := int(c.cap.Load())
+	limit := capLimit
+	if limit <= 0 || capOverridden(ctx) {
+		limit = math.MaxInt32
+	}
+	var calls []int
+	if err := c.db.WithContext(ctx).Raw(`
+		INSERT INTO llm_usages ("date", calls, updated_at) VALUES (?, 1, ?)
+		ON CONFLICT ("date") DO UPDATE SET calls = llm_usages.calls + 1, updated_at = EXCLUDED.updated_at
+		WHERE llm_usages.calls < ?
+		RETURNING calls`, now.Truncate(24*time.Hour), now, limit).Scan(&calls).Error; err != nil {
+		return fmt.Errorf("reserve llm call: %w", err)
+	}
+	if len(calls) == 0 {
+		logging.FromContext(ctx).Warnw("LLM daily cap reached; skipping call", "cap", capLimit)
+		return fmt.Errorf("%w (%d calls)", ErrLLMDailyCap, capLimit)
+	}
+	if capOverridden(ctx) && capLimit > 0 && calls[0] > capLimit {
+		logging.FromContext(ctx).Infow("LLM call over daily cap allowed by override", "calls", calls[0], "cap", capLimit)
+	}
+	return nil
+}
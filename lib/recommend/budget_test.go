@@ -0,0 +1,37 @@
+package recommend
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestCappedChatter_enforcesDailyCap(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&models.LLMUsage{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	c := NewCappedChatter(db, fakeChatter{reply: "{}"}, 2)
+
+	for i := range 2 {
+		if _, err := c.Complete(ctx, "", "", nil); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	if _, err := c.Complete(ctx, "", "", nil); !errors.Is(err, ErrLLMDailyCap) {
+		t.Fatalf("call over cap: err = %v, want ErrLLMDailyCap", err)
+	}
+	if _, err := c.Complete(WithLLMCapOverride(ctx), "", "", nil); err != nil {
+		t.Fatalf("override call: %v", err)
+	}
+
+	var usage models.LLMUsage
+	if err := db.First(&usage).Error; err != nil {
+		t.Fatal(err)
+	}
+	if usage.Calls != 3 {
+		t.Errorf("calls = %d, want 3 (rejected call not counted)", usage.Calls)
+	}
+}
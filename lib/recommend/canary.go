@@ -0,0 +1,254 @@
+package recommend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/errreport"
+	"github.com/icco/recommender/lib/recommend/prompts"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultCanaryMaxRegression is how far a canary prompt may fall behind
+	// the stable one on either measure before it is rolled back.
+	DefaultCanaryMaxRegression = 0.2
+	// canaryMinRuns is how many canary runs are needed before their
+	// validation failure rate is judged.
+	canaryMinRuns = 5
+	// canaryMinFeedback is how many picks with feedback each side needs
+	// before their watched shares are compared.
+	canaryMinFeedback = 10
+	// canaryBaseline is how far back stable runs are measured.
+	canaryBaseline = 60 * 24 * time.Hour
+)
+
+// ErrInvalidPicks is returned by the model call when its reply can't be
+// parsed.
+var ErrInvalidPicks = errors.New("model reply failed validation")
+
+// PromptCanary rolls out a candidate set of prompt templates to Percent of
+// model runs. Files in Prompts replace the built-in ones of the same name;
+// missing ones fall back. The set is rolled back for good, with an alert,
+// once its runs do worse than the built-in prompts' by more than
+// MaxRegression on validation failures or on the share of picks watched.
+type PromptCanary struct {
+	Prompts       fs.FS   // nil disables the canary
+	Percent       int     // share of runs, 0–100
+	MaxRegression float64 // 0 uses DefaultCanaryMaxRegression
+}
+
+func (c PromptCanary) enabled() bool {
+	return c.Prompts != nil && c.Percent > 0
+}
+
+func (c PromptCanary) maxRegression() float64 {
+	if c.MaxRegression > 0 {
+		return c.MaxRegression
+	}
+	return DefaultCanaryMaxRegression
+}
+
+// overlayFS reads from top, falling back to base for files top lacks.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
+
+// canaryVersion is a short hash of every prompt file in fsys.
+func canaryVersion(fsys fs.FS) (string, error) {
+	names, err := fs.Glob(fsys, "*.txt")
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no *.txt prompt files")
+	}
+	h := sha256.New()
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", name, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(b))
+		_, _ = h.Write(b) // never fails
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// inCanary reports whether the run of slot on date falls in the canary's
+// share. It depends only on the run, so every attempt uses the same prompts.
+func inCanary(date time.Time, slot string, percent int) bool {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%s/%s", date.Format(time.DateOnly), slot) // never fails
+	return int(h.Sum32()%100) < percent
+}
+
+// choosePrompts returns the prompt templates for the run of slot on date,
+// and the canary version when they are the canary's. A canary that can't be
+// read or was rolled back leaves the run on the built-in prompts.
+func (r *Recommender) choosePrompts(ctx context.Context, date time.Time, slot Slot, cfg *Settings) (fs.FS, string) {
+	if !r.LLMEnabled() || !cfg.Canary.enabled() || !inCanary(date, slot.Name, cfg.Canary.Percent) {
+		return prompts.FS, ""
+	}
+	l := logging.FromContext(ctx)
+	v, err := canaryVersion(cfg.Canary.Prompts)
+	if err != nil {
+		l.Warnw("Canary prompts unreadable; using the built-in prompts", zap.Error(err))
+		return prompts.FS, ""
+	}
+	rollout := models.PromptRollout{Version: v, Status: models.RolloutCanary}
+	if err := r.db.WithContext(ctx).Where(models.PromptRollout{Version: v}).FirstOrCreate(&rollout).Error; err != nil {
+		l.Warnw("Failed to load prompt rollout; using the built-in prompts", zap.Error(err))
+		return prompts.FS, ""
+	}
+	if rollout.Status == models.RolloutRolledBack {
+		return prompts.FS, ""
+	}
+	fsys := overlayFS{top: cfg.Canary.Prompts, base: prompts.FS}
+	if _, err := promptVersion(fsys, slot); err != nil {
+		l.Warnw("Canary prompts incomplete; using the built-in prompts", "version", v, zap.Error(err))
+		return prompts.FS, ""
+	}
+	return fsys, v
+}
+
+// invalidPicks counts picks naming a title that isn't on its shortlist.
+func invalidPicks(pr pickResponse, movies, tvshows []candidate) int {
+	n := 0
+	for _, set := range []struct {
+		picks     []pick
+		shortlist []candidate
+	}{{pr.Movies, movies}, {pr.TVShows, tvshows}} {
+		byID := candByID(set.shortlist)
+		for _, p := range set.picks {
+			if _, ok := byID[p.ID]; !ok {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// failValidation marks the run's model reply as having failed validation.
+func (r *Recommender) failValidation(ctx context.Context, runID uint) {
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{ID: runID}).
+		Update("validation_failed", true).Error; err != nil {
+		logging.FromContext(ctx).Warnw("Failed to record validation failure", zap.Error(err))
+	}
+}
+
+// promptStats measures runs made with one set of prompts.
+type promptStats struct {
+	Runs    int // finished runs that asked the model
+	Failed  int // of those, runs whose reply failed validation
+	Watched int // picks from model runs marked watched
+	Ignored int // and marked ignored
+}
+
+func (s promptStats) failureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(s.Runs)
+}
+
+func (s promptStats) watchedShare() float64 {
+	if s.Watched+s.Ignored == 0 {
+		return 0
+	}
+	return float64(s.Watched) / float64(s.Watched+s.Ignored)
+}
+
+// regression says how canary fell behind stable by more than allowed, or
+// "" while it hasn't or there is too little to tell.
+func regression(canary, stable promptStats, allowed float64) string {
+	if canary.Runs >= canaryMinRuns && canary.failureRate()-stable.failureRate() > allowed {
+		return fmt.Sprintf("validation failures in %.0f%% of runs, against %.0f%% with the stable prompts",
+			100*canary.failureRate(), 100*stable.failureRate())
+	}
+	if canary.Watched+canary.Ignored >= canaryMinFeedback && stable.Watched+stable.Ignored >= canaryMinFeedback &&
+		stable.watchedShare()-canary.watchedShare() > allowed {
+		return fmt.Sprintf("%.0f%% of rated picks watched, against %.0f%% with the stable prompts",
+			100*canary.watchedShare(), 100*stable.watchedShare())
+	}
+	return ""
+}
+
+// promptStats measures the runs since since made with canary version
+// canary, or with the built-in prompts when it is empty.
+func (r *Recommender) promptStats(ctx context.Context, canary string, since time.Time) (promptStats, error) {
+	var runs, feedback promptStats
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{}).
+		Select("COUNT(*) AS runs, COALESCE(SUM(CASE WHEN validation_failed THEN 1 ELSE 0 END), 0) AS failed").
+		Where("prompt_canary = ? AND prompt_version <> '' AND status <> ? AND updated_at >= ?", canary, models.RunStatusRunning, since).
+		Scan(&runs).Error; err != nil {
+		return promptStats{}, fmt.Errorf("count %q runs: %w", canary, err)
+	}
+	if err := r.db.WithContext(ctx).Table("recommendations AS rec").
+		Select("COALESCE(SUM(CASE WHEN rec.feedback = ? THEN 1 ELSE 0 END), 0) AS watched, COALESCE(SUM(CASE WHEN rec.feedback = ? THEN 1 ELSE 0 END), 0) AS ignored",
+			models.FeedbackWatched, models.FeedbackIgnored).
+		Joins(`JOIN generation_runs AS run ON run."date" = rec."date" AND run.context = rec.slot`).
+		Where("run.prompt_canary = ? AND run.strategy = ? AND run.updated_at >= ?", canary, models.StrategyModel, since).
+		Scan(&feedback).Error; err != nil {
+		return promptStats{}, fmt.Errorf("count %q feedback: %w", canary, err)
+	}
+	runs.Watched, runs.Ignored = feedback.Watched, feedback.Ignored
+	return runs, nil
+}
+
+// checkCanary compares canary version v with the built-in prompts and
+// rolls it back, reporting why, once it has regressed.
+func (r *Recommender) checkCanary(ctx context.Context, v string, cfg *Settings) {
+	l := logging.FromContext(ctx).With("version", v)
+	canary, err := r.promptStats(ctx, v, time.Time{})
+	if err != nil {
+		l.Warnw("Failed to measure canary prompts", zap.Error(err))
+		return
+	}
+	stable, err := r.promptStats(ctx, "", time.Now().Add(-canaryBaseline))
+	if err != nil {
+		l.Warnw("Failed to measure stable prompts", zap.Error(err))
+		return
+	}
+	reason := regression(canary, stable, cfg.Canary.maxRegression())
+	if reason == "" {
+		return
+	}
+	res := r.db.WithContext(ctx).Model(&models.PromptRollout{}).
+		Where("version = ? AND status = ?", v, models.RolloutCanary).
+		Updates(map[string]any{"status": models.RolloutRolledBack, "reason": truncateRunError(reason)})
+	if res.Error != nil {
+		l.Warnw("Failed to roll back canary prompts", zap.Error(res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		return // already rolled back
+	}
+	l.Errorw("Rolled back canary prompts", "reason", reason)
+	errreport.CaptureError(ctx, fmt.Errorf("canary prompts %s rolled back: %s", v, reason), "prompt_canary", v)
+}
+
+// PromptRollouts returns every canary prompt set seen, newest first.
+func (r *Recommender) PromptRollouts(ctx context.Context) ([]models.PromptRollout, error) {
+	var out []models.PromptRollout
+	if err := r.db.WithContext(ctx).Order("id DESC").Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("load prompt rollouts: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package recommend
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/icco/recommender/lib/recommend/prompts"
+	"github.com/icco/recommender/models"
+)
+
+func TestRegression(t *testing.T) {
+	stable := promptStats{Runs: 20, Failed: 1, Watched: 12, Ignored: 8}
+	for _, tt := range []struct {
+		name   string
+		canary promptStats
+		want   bool
+	}{
+		{"too few runs", promptStats{Runs: canaryMinRuns - 1, Failed: canaryMinRuns - 1}, false},
+		{"failing", promptStats{Runs: 5, Failed: 2}, true},
+		{"failing a little", promptStats{Runs: 10, Failed: 2}, false},
+		{"watched less", promptStats{Runs: 10, Watched: 3, Ignored: 7}, true},
+		{"too little feedback", promptStats{Runs: 10, Watched: 0, Ignored: canaryMinFeedback - 1}, false},
+		{"as good", promptStats{Runs: 10, Watched: 6, Ignored: 4}, false},
+	} {
+		if got := regression(tt.canary, stable, DefaultCanaryMaxRegression); (got != "") != tt.want {
+			t.Errorf("%s: regression = %q, want regressed %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInCanary(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := 0
+	for i := range 1000 {
+		if inCanary(day.AddDate(0, 0, i), models.RunContextDaily, 25) {
+			n++
+		}
+	}
+	if n < 180 || n > 320 {
+		t.Errorf("%d of 1000 runs in a 25%% canary", n)
+	}
+	if inCanary(day, models.RunContextDaily, 0) || !inCanary(day, models.RunContextDaily, 100) {
+		t.Error("0% should never and 100% should always pick the canary")
+	}
+}
+
+func TestCanaryPrompts(t *testing.T) {
+	canary := fstest.MapFS{"system.txt": {Data: []byte("Be brief.")}}
+	v, err := canaryVersion(canary)
+	if err != nil || len(v) != 12 {
+		t.Fatalf("canaryVersion = %q, %v", v, err)
+	}
+	fsys := overlayFS{top: canary, base: prompts.FS}
+	stable, _ := promptVersion(prompts.FS, DailySlot)
+	if got, err := promptVersion(fsys, DailySlot); err != nil || got == stable {
+		t.Errorf("canary promptVersion = %q, %v; want one differing from %q", got, err, stable)
+	}
+	if _, err := canaryVersion(fstest.MapFS{}); err == nil {
+		t.Error("an empty canary directory should be an error")
+	}
+}
+
+func TestInvalidPicks(t *testing.T) {
+	movies := []candidate{{ID: 1, Type: models.TypeMovie}}
+	tv := []candidate{{ID: 2, Type: models.TypeTVShow}}
+	pr := pickResponse{Movies: []pick{{ID: 1}, {ID: 2}}, TVShows: []pick{{ID: 2}, {ID: 9}}}
+	if got := invalidPicks(pr, movies, tv); got != 2 {
+		t.Errorf("invalidPicks = %d, want 2", got)
+	}
+}
+
+func TestGenerateSlot_canaryRollback(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	for i, genre := range []string{"Comedy", "Action", "Drama", "Horror"} {
+		m := models.Movie{Title: genre + " film", Year: 2000 + i, Rating: new(8.0), Genre: genre, PlexRatingKey: fmt.Sprintf("m%d", i)}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Recommender{db: db, chat: fakeChatter{reply: "not json"}, model: "test"}
+	r.ApplySettings(Settings{Canary: PromptCanary{Prompts: fstest.MapFS{"system.txt": {Data: []byte("Be brief.")}}, Percent: 100}})
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := range canaryMinRuns + 1 {
+		if err := r.GenerateRecommendations(ctx, day.AddDate(0, 0, i)); err != nil {
+			t.Fatalf("day %d: %v", i, err)
+		}
+	}
+	rollouts, err := r.PromptRollouts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollouts) != 1 || rollouts[0].Status != models.RolloutRolledBack || rollouts[0].Reason == "" {
+		t.Fatalf("rollouts = %+v, want one rolled back", rollouts)
+	}
+	runs, err := r.Runs(ctx, day.AddDate(0, 0, canaryMinRuns), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].PromptCanary != "" || !runs[0].ValidationFailed {
+		t.Errorf("run after rollback = %+v, want the stable prompts", runs)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"sort"
 	"strings"
 	"time"
 
@@ -13,18 +12,28 @@ import (
 
 // candidate is a Plex-owned title eligible for recommendation, with a computed score.
 type candidate struct {
-	ID          uint
-	Type        string
-	Title       string
-	Year        int
-	Rating      float64
-	Genres      []string
-	PosterURL   string
-	Runtime     int // minutes (movie) or seasons (tv)
-	ViewCount   int
-	TMDbID      *int
-	Affinity    float64 // taste-profile boost (Phase 2); 0 otherwise
-	Watchlisted bool    // present on an external watchlist (Trakt)
+	ID             uint
+	Type           string
+	Title          string
+	Year           int
+	Rating         *float64 // nil = unknown, not low; see ratingFeature
+	Genres         []string
+	Directors      []string // movies only
+	Actors         []string // top-billed cast
+	PosterURL      string
+	Runtime        int // minutes (movies); 0 for TV
+	Seasons        int // tv only
+	ViewCount      int
+	TMDbID         *int
+	Affinity       float64 // taste-profile boost (Phase 2); 0 otherwise
+	Watchlisted    bool    // present on an external watchlist (Trakt)
+	Recency        float64 // 0–1, newer releases higher; see recencyFeature
+	RuntimeFit     float64 // 0–1, closeness to the typical watched runtime (movies)
+	Anniversary    int     // milestone years since release on the day (movies); 0 otherwise
+	EpisodeRuntime int     // typical episode minutes (tv); 0 = unknown
+	Resurfacing    bool    // back from a snooze that ended recently
+	ContentRating  string  // Plex age rating; "" = unrated
+	Library        string  // Plex library section
 }
 
 // dateSeed derives a stable per-UTC-day seed so shortlists are reproducible.
@@ -33,40 +42,24 @@ func dateSeed(date time.Time) int64 {
 	return int64(y)*10000 + int64(m)*100 + int64(d)
 }
 
-// watchlistBoost lifts titles the user has explicitly watchlisted externally.
-const watchlistBoost = 1.5
-
-// scoreCandidate ranks a title: rating drives it, unwatched gets a novelty
-// boost, taste affinity and watchlist membership add on top.
-func scoreCandidate(c candidate) float64 {
-	s := c.Rating / 10.0 * 2.0
-	if c.ViewCount == 0 {
-		s += 1.0
-	}
-	s += c.Affinity
-	if c.Watchlisted {
-		s += watchlistBoost
-	}
-	return s
-}
-
 // buildShortlist takes the top poolSize by score, then a date-seeded shuffle to
-// shortlistSize — quality plus deterministic daily variety.
+// shortlistSize — quality plus deterministic daily variety. Titles back from
+// a snooze skip the shuffle and lead the shortlist.
 func buildShortlist(cands []candidate, date time.Time, poolSize, shortlistSize int) []candidate {
-	sorted := make([]candidate, len(cands))
-	copy(sorted, cands)
-	sort.SliceStable(sorted, func(i, j int) bool {
-		si, sj := scoreCandidate(sorted[i]), scoreCandidate(sorted[j])
-		if si == sj {
-			return sorted[i].ID < sorted[j].ID // stable tie-break
-		}
-		return si > sj
-	})
-	if poolSize < len(sorted) {
-		sorted = sorted[:poolSize]
+	var back, sorted []candidate
+	for _, c := range rankCandidates(cands) {
+		if c.Resurfacing && len(back) < shortlistSize {
+			back = append(back, c)
+		} else {
+			sorted = append(sorted, c)
+		}
+	}
+	if n := max(poolSize-len(back), 0); n < len(sorted) {
+		sorted = sorted[:n]
 	}
 	rng := rand.New(rand.NewSource(dateSeed(date))) //nolint:gosec // deterministic daily shuffle, not security-sensitive
 	rng.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+	sorted = append(back, sorted...)
 	if shortlistSize < len(sorted) {
 		sorted = sorted[:shortlistSize]
 	}
@@ -82,19 +75,50 @@ func formatShortlist(cands []candidate) string {
 		if c.ViewCount > 0 {
 			watched = "watched"
 		}
-		fmt.Fprintf(&b, "[id=%d] %s (%d) — Rating: %.1f — Genres: %s — %s\n",
-			c.ID, c.Title, c.Year, c.Rating, strings.Join(c.Genres, ", "), watched)
+		rating := "unrated"
+		if c.Rating != nil {
+			rating = fmt.Sprintf("%.1f", *c.Rating)
+		}
+		fmt.Fprintf(&b, "[id=%d] %s (%d) — Rating: %s — Genres: %s — %s",
+			c.ID, c.Title, c.Year, rating, strings.Join(c.Genres, ", "), watched)
+		switch {
+		case c.Type == models.TypeMovie && c.Runtime > 0:
+			fmt.Fprintf(&b, " — %d min", c.Runtime)
+		case c.Type == models.TypeTVShow && c.Seasons > 0:
+			unit := "seasons"
+			if c.Seasons == 1 {
+				unit = "season"
+			}
+			fmt.Fprintf(&b, " — %d %s", c.Seasons, unit)
+			if c.EpisodeRuntime > 0 {
+				fmt.Fprintf(&b, " of ~%d-min episodes", c.EpisodeRuntime)
+			}
+		}
+		if c.Anniversary > 0 {
+			fmt.Fprintf(&b, " — %s", anniversaryNote(c.Anniversary))
+		}
+		if c.Resurfacing {
+			b.WriteString(" — snoozed earlier; the household asked to see it again")
+		}
+		b.WriteByte('\n')
 	}
 	return b.String()
 }
 
 // loadCandidates loads eligible movies and TV shows, excluding titles recommended
-// in the last 30 days. TV is restricted to unwatched shows.
+// in the last 30 days, titles still snoozed, and movies whose collection is
+// cooling down after a sibling was recommended. Titles whose snooze recently
+// ended are marked Resurfacing. TV is restricted to unwatched shows that
+// weren't dropped.
 func (r *Recommender) loadCandidates(ctx context.Context, date time.Time) (movies, tvshows []candidate, err error) {
 	excludeMovies, excludeTV, err := r.recentlyRecommendedIDs(ctx, date, 30)
 	if err != nil {
 		return nil, nil, err
 	}
+	snoozed, resurfacing, err := r.snoozedKeys(ctx, date)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	aff, err := r.genreAffinity(ctx)
 	if err != nil {
@@ -123,24 +147,45 @@ func (r *Recommender) loadCandidates(ctx context.Context, date time.Time) (movie
 	if err := r.db.WithContext(ctx).Find(&dbMovies).Error; err != nil {
 		return nil, nil, fmt.Errorf("load movies: %w", err)
 	}
+	coolingDown, err := r.suppressedCollections(ctx, date, dbMovies)
+	if err != nil {
+		return nil, nil, err
+	}
+	typical := typicalRuntime(dbMovies)
 	for _, m := range dbMovies {
 		if _, skip := excludeMovies[m.ID]; skip {
 			continue
 		}
+		if _, skip := snoozed[candKey(models.TypeMovie, m.ID)]; skip {
+			continue
+		}
+		if m.CollectionID != nil {
+			if _, skip := coolingDown[*m.CollectionID]; skip {
+				continue
+			}
+		}
 		genres := splitGenres(m.Genre)
 		vc := m.ViewCount
 		if _, w := watchedMovies[m.ID]; w && vc == 0 {
 			vc = 1 // treat Trakt-watched as watched
 		}
 		_, wl := watchlistMovies[m.ID]
+		_, back := resurfacing[candKey(models.TypeMovie, m.ID)]
 		movies = append(movies, candidate{
 			ID: m.ID, Type: models.TypeMovie, Title: m.Title, Year: m.Year,
-			Rating: m.Rating, Genres: genres, PosterURL: m.PosterURL,
+			Rating: m.Rating, Genres: genres, Directors: splitGenres(m.Director), Actors: splitGenres(m.Actors), PosterURL: m.PosterURL,
 			Runtime: m.Runtime, ViewCount: vc, TMDbID: m.TMDbID,
 			Affinity: affinityFor(genres), Watchlisted: wl,
+			Recency: recencyFeature(m.Year, date), RuntimeFit: runtimeFitFeature(m.Runtime, typical),
+			Anniversary: anniversaryYears(m.ReleaseDate, date), Resurfacing: back,
+			ContentRating: m.ContentRating, Library: m.Library,
 		})
 	}
 
+	dropped, err := r.droppedShowIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	var dbShows []models.TVShow
 	if err := r.db.WithContext(ctx).Where("view_count = 0").Find(&dbShows).Error; err != nil {
 		return nil, nil, fmt.Errorf("load tv shows: %w", err)
@@ -149,27 +194,37 @@ func (r *Recommender) loadCandidates(ctx context.Context, date time.Time) (movie
 		if _, skip := excludeTV[s.ID]; skip {
 			continue
 		}
+		if _, skip := dropped[s.ID]; skip {
+			continue
+		}
+		if _, skip := snoozed[candKey(models.TypeTVShow, s.ID)]; skip {
+			continue
+		}
 		if _, watched := watchedTV[s.ID]; watched {
 			continue // watched elsewhere; not a fresh TV pick
 		}
 		genres := splitGenres(s.Genre)
 		_, wl := watchlistTV[s.ID]
+		_, back := resurfacing[candKey(models.TypeTVShow, s.ID)]
 		tvshows = append(tvshows, candidate{
 			ID: s.ID, Type: models.TypeTVShow, Title: s.Title, Year: s.Year,
-			Rating: s.Rating, Genres: genres, PosterURL: s.PosterURL,
-			Runtime: s.Seasons, ViewCount: s.ViewCount, TMDbID: s.TMDbID,
+			Rating: s.Rating, Genres: genres, Actors: splitGenres(s.Actors), PosterURL: s.PosterURL,
+			Seasons: s.Seasons, EpisodeRuntime: s.EpisodeRuntime, ViewCount: s.ViewCount, TMDbID: s.TMDbID,
 			Affinity: affinityFor(genres), Watchlisted: wl,
+			Recency: recencyFeature(s.Year, date), Resurfacing: back,
+			ContentRating: s.ContentRating, Library: s.Library,
 		})
 	}
 	return movies, tvshows, nil
 }
 
-// recentlyRecommendedIDs returns Movie/TVShow IDs recommended within the last `days` days.
+// recentlyRecommendedIDs returns Movie/TVShow IDs recommended within the last
+// `days` days. Snoozed picks don't count; snoozedKeys handles those.
 func (r *Recommender) recentlyRecommendedIDs(ctx context.Context, date time.Time, days int) (map[uint]struct{}, map[uint]struct{}, error) {
 	cutoff := date.AddDate(0, 0, -days)
 	var recs []models.Recommendation
 	if err := r.db.WithContext(ctx).
-		Where(`"date" >= ? AND "date" <= ?`, cutoff, date).
+		Where(`"date" >= ? AND "date" <= ? AND snoozed_until IS NULL`, cutoff, date).
 		Find(&recs).Error; err != nil {
 		return nil, nil, fmt.Errorf("load recent recommendations: %w", err)
 	}
@@ -205,7 +260,7 @@ func (r *Recommender) signalIDSet(ctx context.Context, kind string) (map[uint]st
 	return m, tv, nil
 }
 
-// splitGenres parses the comma-joined genre column into a slice.
+// splitGenres parses a comma-joined tag column (genre, director) into a slice.
 func splitGenres(s string) []string {
 	if strings.TrimSpace(s) == "" {
 		return nil
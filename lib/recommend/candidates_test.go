@@ -1,6 +1,7 @@
 package recommend
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -8,7 +9,7 @@ import (
 )
 
 func mkCand(id uint, rating float64, view int) candidate {
-	return candidate{ID: id, Type: "movie", Title: "T", Rating: rating, ViewCount: view}
+	return candidate{ID: id, Type: "movie", Title: "T", Rating: &rating, ViewCount: view}
 }
 
 func TestScoreCandidate_ratingAndNovelty(t *testing.T) {
@@ -73,8 +74,8 @@ func TestLoadCandidates_excludesRecentAndWatchedTV(t *testing.T) {
 	ctx := t.Context()
 	today := time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC)
 
-	m1 := models.Movie{Title: "Keep", Year: 2000, Rating: 8, PlexRatingKey: "k1"}
-	m2 := models.Movie{Title: "RecentlyRecd", Year: 2001, Rating: 8, PlexRatingKey: "k2"}
+	m1 := models.Movie{Title: "Keep", Year: 2000, Rating: new(8.0), PlexRatingKey: "k1"}
+	m2 := models.Movie{Title: "RecentlyRecd", Year: 2001, Rating: new(8.0), PlexRatingKey: "k2"}
 	if err := db.Create(&m1).Error; err != nil {
 		t.Fatal(err)
 	}
@@ -122,8 +123,8 @@ func TestLoadCandidates_externalWatched(t *testing.T) {
 	ctx := t.Context()
 	today := time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC)
 
-	movie := models.Movie{Title: "M", Year: 2000, Rating: 8, ViewCount: 0, PlexRatingKey: "m1"}
-	show := models.TVShow{Title: "S", Year: 2001, Rating: 8, ViewCount: 0, PlexRatingKey: "s1"}
+	movie := models.Movie{Title: "M", Year: 2000, Rating: new(8.0), ViewCount: 0, PlexRatingKey: "m1"}
+	show := models.TVShow{Title: "S", Year: 2001, Rating: new(8.0), ViewCount: 0, PlexRatingKey: "s1"}
 	db.Create(&movie)
 	db.Create(&show)
 	db.Create(&models.ExternalSignal{Source: models.SourceTrakt, ExternalRef: "watched:m", Kind: models.SignalKindWatched, MovieID: &movie.ID, Value: 1})
@@ -140,3 +141,35 @@ func TestLoadCandidates_externalWatched(t *testing.T) {
 		t.Errorf("externally-watched movie should be treated as watched: %+v", movies)
 	}
 }
+
+func TestFormatShortlist_runtimeAndSeasons(t *testing.T) {
+	got := formatShortlist([]candidate{
+		{ID: 1, Type: models.TypeMovie, Title: "Heat", Year: 1995, Runtime: 170},
+		{ID: 2, Type: models.TypeTVShow, Title: "Severance", Year: 2022, Seasons: 2, EpisodeRuntime: 50},
+		{ID: 3, Type: models.TypeTVShow, Title: "Chernobyl", Year: 2019, Seasons: 1},
+	})
+	for _, want := range []string{"Heat (1995)", "— 170 min", "— 2 seasons of ~50-min episodes", "Chernobyl (2019) — Rating: unrated — Genres:  — unwatched — 1 season\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("shortlist missing %q:\n%s", want, got)
+		}
+	}
+	if rec := toRec(candidate{Type: models.TypeTVShow, Title: "Severance", Seasons: 2}, "", time.Time{}); rec.Seasons != 2 || rec.Runtime != 0 {
+		t.Errorf("toRec = seasons %d, runtime %d; want 2, 0", rec.Seasons, rec.Runtime)
+	}
+}
+
+func TestBuildShortlist_resurfacingLeads(t *testing.T) {
+	var cands []candidate
+	for i := uint(1); i <= 200; i++ {
+		cands = append(cands, mkCand(i, 5.0+float64(i%5), 0))
+	}
+	cands[199].Rating = new(1.0) // far outside the top pool on score alone
+	cands[199].Resurfacing = true
+	got := buildShortlist(cands, time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC), 120, 40)
+	if len(got) != 40 || got[0].ID != 200 {
+		t.Errorf("shortlist starts with %d (len %d), want the snoozed title 200 first", got[0].ID, len(got))
+	}
+	if !strings.Contains(formatShortlist(got[:1]), "snoozed earlier") {
+		t.Errorf("formatShortlist = %q, want the snooze noted", formatShortlist(got[:1]))
+	}
+}
@@ -0,0 +1,280 @@
+package recommend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+const (
+	// MaxChatMessageLen bounds one chat message, in bytes.
+	MaxChatMessageLen = 1000
+	// ChatMaxTitles is how many titles one reply suggests at most.
+	ChatMaxTitles = 5
+	// chatHistoryTurns is how many earlier messages go to the model, so a
+	// follow-up ("something shorter") refines the last answer.
+	chatHistoryTurns = 20
+	// chatPageSize is how many messages the chat page shows.
+	chatPageSize = 100
+	// chatMovies and chatTVShows size the library lists the model chooses
+	// from for each message.
+	chatMovies  = 40
+	chatTVShows = 20
+)
+
+// chatResponse is the model's chat reply: prose first, so it can be shown
+// while the picks are still being written.
+type chatResponse struct {
+	Reply string `json:"reply"`
+	pickResponse
+}
+
+// chatSchema is the Gemini response schema for chat: a reply and, like
+// pickSchema, two arrays of {id, explanation}.
+func chatSchema() *genai.Schema {
+	s := pickSchema()
+	s.Properties["reply"] = &genai.Schema{Type: genai.TypeString}
+	s.Required = append(s.Required, "reply")
+	s.PropertyOrdering = []string{"reply", "movies", "tvshows"}
+	return s
+}
+
+// ChatHistory returns owner's latest chat messages, oldest first.
+func (r *Recommender) ChatHistory(ctx context.Context, owner string) ([]models.ChatMessage, error) {
+	return r.chatHistory(ctx, owner, chatPageSize)
+}
+
+func (r *Recommender) chatHistory(ctx context.Context, owner string, limit int) ([]models.ChatMessage, error) {
+	var msgs []models.ChatMessage
+	if err := r.db.WithContext(ctx).Where("owner = ?", owner).Order("id DESC").Limit(limit).Find(&msgs).Error; err != nil {
+		return nil, fmt.Errorf("load chat history: %w", err)
+	}
+	slices.Reverse(msgs)
+	return msgs, nil
+}
+
+// ClearChat deletes owner's conversation, so the next message starts afresh.
+func (r *Recommender) ClearChat(ctx context.Context, owner string) error {
+	if err := r.db.WithContext(ctx).Where("owner = ?", owner).Delete(&models.ChatMessage{}).Error; err != nil {
+		return fmt.Errorf("clear chat: %w", err)
+	}
+	return nil
+}
+
+// Ask answers message in owner's conversation with titles from the library,
+// saving both turns. Earlier turns go to the model too, so a follow-up
+// refines the last answer. onText receives the reply as it is written;
+// without a model, or if the call fails, the best-scored titles matching the
+// message are suggested instead, with a note saying so.
+func (r *Recommender) Ask(ctx context.Context, owner, message string, now time.Time, onText func(string)) (models.ChatMessage, error) {
+	history, err := r.chatHistory(ctx, owner, chatHistoryTurns)
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	asked := models.ChatMessage{Owner: owner, Role: models.ChatRoleUser, Content: message}
+	if err := r.db.WithContext(ctx).Create(&asked).Error; err != nil {
+		return models.ChatMessage{}, fmt.Errorf("save chat message: %w", err)
+	}
+
+	movies, tvshows, err := r.loadCandidates(ctx, now)
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	movies = chatPool(movies, message, chatMovies)
+	tvshows = chatPool(tvshows, message, chatTVShows)
+
+	reply, err := r.modelChat(ctx, history, message, movies, tvshows, onText)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Chat model call failed; suggesting the closest titles", zap.Error(err))
+	}
+	if reply == nil {
+		reply = chatFallback(movies, tvshows)
+		onText(reply.Reply)
+	}
+
+	answer := models.ChatMessage{Owner: owner, Role: models.ChatRoleAssistant, Content: reply.Reply, Titles: chatTitles(reply, movies, tvshows)}
+	if err := r.db.WithContext(ctx).Create(&answer).Error; err != nil {
+		return models.ChatMessage{}, fmt.Errorf("save chat reply: %w", err)
+	}
+	return answer, nil
+}
+
+// modelChat asks the model, streaming the reply's prose to onText. It
+// returns nil without a model.
+func (r *Recommender) modelChat(ctx context.Context, history []models.ChatMessage, message string, movies, tvshows []candidate, onText func(string)) (*chatResponse, error) {
+	if !r.LLMEnabled() {
+		return nil, nil
+	}
+	var b strings.Builder
+	if profile, err := r.tasteProfile(ctx); err == nil && profile != "" {
+		fmt.Fprintf(&b, "Taste profile:\n%s\n\n", profile)
+	}
+	if prefs, err := r.Preferences(ctx); err == nil {
+		if p := preferencesPrompt(prefs); p != "" {
+			fmt.Fprintf(&b, "Household preferences:\n%s\n\n", p)
+		}
+	}
+	fmt.Fprintf(&b, "Library movies:\n%s\nLibrary TV shows:\n%s\n", formatShortlist(movies), formatShortlist(tvshows))
+	if len(history) > 0 {
+		b.WriteString("Conversation so far:\n")
+		for _, m := range history {
+			b.WriteString(chatLine(m))
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "Viewer: %s\n", message)
+	system := fmt.Sprintf("You help a household choose something to watch from their own library. Suggest at most %d titles, "+
+		"only by ids from the lists, each with a short, specific reason. Keep the reply to a few friendly sentences and "+
+		"don't list the titles in it; they are shown beside it. If the viewer asks to change an earlier suggestion, "+
+		"adjust it rather than starting over.", ChatMaxTitles)
+
+	var buf, sent string
+	raw, err := streamOrComplete(ctx, r.chat, system, b.String(), chatSchema(), func(chunk string) {
+		buf += chunk
+		if so := replySoFar(buf); strings.HasPrefix(so, sent) && len(so) > len(sent) {
+			onText(so[len(sent):])
+			sent = so
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("llm: %w", err)
+	}
+	var resp chatResponse
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &resp); err != nil {
+		return nil, fmt.Errorf("parse chat response: %w", err)
+	}
+	if rest, ok := strings.CutPrefix(resp.Reply, sent); ok && rest != "" {
+		onText(rest)
+	}
+	return &resp, nil
+}
+
+// chatLine renders one earlier message for the prompt.
+func chatLine(m models.ChatMessage) string {
+	if m.Role == models.ChatRoleUser {
+		return "Viewer: " + m.Content + "\n"
+	}
+	line := "You: " + m.Content
+	if len(m.Titles) > 0 {
+		var names []string
+		for _, t := range m.Titles {
+			names = append(names, fmt.Sprintf("%s (%d)", t.Title, t.Year))
+		}
+		line += " [suggested: " + strings.Join(names, "; ") + "]"
+	}
+	return line + "\n"
+}
+
+// replySoFar decodes as much of the "reply" string as raw, the start of a
+// chatResponse, holds. It stops short of a split escape or character so
+// the text only ever grows.
+func replySoFar(raw string) string {
+	i := strings.Index(raw, `"reply"`)
+	if i < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(raw[i+len(`"reply"`):], " \t\r\n")
+	rest, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return ""
+	}
+	rest, ok = strings.CutPrefix(strings.TrimLeft(rest, " \t\r\n"), `"`)
+	if !ok {
+		return ""
+	}
+	end := 0
+scan:
+	for end < len(rest) {
+		switch rest[end] {
+		case '"':
+			break scan
+		case '\\':
+			n := 2
+			if end+1 < len(rest) && rest[end+1] == 'u' {
+				n = 6
+			}
+			if end+n > len(rest) {
+				break scan
+			}
+			end += n
+		default:
+			end++
+		}
+	}
+	for end > 0 && !utf8.ValidString(rest[:end]) {
+		end--
+	}
+	var s string
+	if err := json.Unmarshal([]byte(`"`+rest[:end]+`"`), &s); err != nil {
+		return ""
+	}
+	return s
+}
+
+// chatPool ranks cands for message: titles whose name or genres share a
+// word with it first, then by score, keeping the top limit.
+func chatPool(cands []candidate, message string, limit int) []candidate {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r > 127)
+	}) {
+		if len(w) >= 3 {
+			words = append(words, w)
+		}
+	}
+	hits := func(c candidate) int {
+		text := strings.ToLower(c.Title + " " + strings.Join(c.Genres, " "))
+		n := 0
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				n++
+			}
+		}
+		return n
+	}
+	ranked := rankCandidates(cands)
+	sort.SliceStable(ranked, func(i, j int) bool { return hits(ranked[i]) > hits(ranked[j]) })
+	return ranked[:min(limit, len(ranked))]
+}
+
+// chatFallback suggests the top of the pools when the model can't answer.
+func chatFallback(movies, tvshows []candidate) *chatResponse {
+	resp := &chatResponse{Reply: "I can't reach the model right now, so here are the closest matches in your library by score."}
+	for _, c := range movies[:min(3, len(movies))] {
+		resp.Movies = append(resp.Movies, pick{ID: c.ID, Explanation: "One of your best-scored movies for this."})
+	}
+	for _, c := range tvshows[:min(2, len(tvshows))] {
+		resp.TVShows = append(resp.TVShows, pick{ID: c.ID, Explanation: "One of your best-scored shows for this."})
+	}
+	return resp
+}
+
+// chatTitles resolves the reply's picks against the pools, dropping unknown
+// IDs and repeats and keeping at most ChatMaxTitles.
+func chatTitles(resp *chatResponse, movies, tvshows []candidate) []models.ChatTitle {
+	var out []models.ChatTitle
+	add := func(picks []pick, pool []candidate) {
+		byID := candByID(pool)
+		for _, p := range picks {
+			c, ok := byID[p.ID]
+			if !ok || len(out) == ChatMaxTitles {
+				continue
+			}
+			delete(byID, p.ID)
+			out = append(out, models.ChatTitle{Type: c.Type, ID: c.ID, Title: c.Title, Year: c.Year, Reason: p.Explanation})
+		}
+	}
+	add(resp.Movies, movies)
+	add(resp.TVShows, tvshows)
+	return out
+}
@@ -0,0 +1,145 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"google.golang.org/genai"
+)
+
+// fakeStreamer hands its reply over a few bytes at a time.
+type fakeStreamer struct{ reply string }
+
+func (f fakeStreamer) Complete(_ context.Context, _, _ string, _ *genai.Schema) (string, error) {
+	return f.reply, nil
+}
+
+func (f fakeStreamer) Stream(_ context.Context, _, _ string, _ *genai.Schema, fn func(string)) (string, error) {
+	for s := f.reply; s != ""; {
+		n := min(3, len(s))
+		fn(s[:n])
+		s = s[n:]
+	}
+	return f.reply, nil
+}
+
+func TestReplySoFar(t *testing.T) {
+	for _, tc := range []struct{ raw, want string }{
+		{`{"rep`, ""},
+		{`{"reply": "Two tense`, "Two tense"},
+		{`{"reply":"a \"quoted\" b`, `a "quoted" b`},
+		{`{"reply":"split \`, "split "},
+		{`{"reply":"caf\u00e`, "caf"},
+		{`{"reply":"café ok", "movies": [`, "café ok"},
+		{"{\"reply\":\"caf\xc3", "caf"},
+	} {
+		if got := replySoFar(tc.raw); got != tc.want {
+			t.Errorf("replySoFar(%q) = %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestChatPool(t *testing.T) {
+	cands := []candidate{
+		{ID: 1, Title: "Heat", Genres: []string{"Crime"}, Rating: new(9.0)},
+		{ID: 2, Title: "Paddington", Genres: []string{"Family", "Comedy"}, Rating: new(7.0)},
+		{ID: 3, Title: "Alien", Genres: []string{"Horror"}, Rating: new(8.0)},
+	}
+	got := chatPool(cands, "a cozy comedy for the family", 2)
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 1 {
+		t.Errorf("chatPool = %+v, want Paddington, then the best-rated", got)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.Movie{
+		{Title: "Heat", Year: 1995, Genre: "Crime", PlexRatingKey: "m1"},
+		{Title: "Paddington", Year: 2014, Genre: "Family", PlexRatingKey: "m2"},
+	}
+	if err := db.Create(&movies).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reply := fmt.Sprintf(`{"reply":"Try the \"bear\" one.","movies":[{"id":%d,"explanation":"Gentle."},{"id":9999,"explanation":"?"}],"tvshows":[]}`, movies[1].ID)
+	r := &Recommender{db: db, chat: fakeStreamer{reply: reply}}
+	var streamed strings.Builder
+	msg, err := r.Ask(ctx, "user:1", "something cozy", now, func(s string) { streamed.WriteString(s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.String() != `Try the "bear" one.` || msg.Content != streamed.String() {
+		t.Errorf("streamed %q, saved %q; want the reply", streamed.String(), msg.Content)
+	}
+	if len(msg.Titles) != 1 || msg.Titles[0].Title != "Paddington" || msg.Titles[0].Reason != "Gentle." {
+		t.Errorf("titles = %+v, want Paddington only", msg.Titles)
+	}
+
+	// Without a model the reply says so and still suggests titles.
+	r = testRecommender(db)
+	if msg, err = r.Ask(ctx, "user:1", "shorter?", now, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Content, "can't reach the model") || len(msg.Titles) == 0 {
+		t.Errorf("fallback reply = %+v", msg)
+	}
+
+	history, err := r.ChatHistory(ctx, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 || history[0].Content != "something cozy" || history[3].Role != models.ChatRoleAssistant {
+		t.Errorf("history = %+v, want four turns oldest first", history)
+	}
+	if other, _ := r.ChatHistory(ctx, "user:2"); len(other) != 0 {
+		t.Errorf("another user's history = %+v, want none", other)
+	}
+	if err := r.ClearChat(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ = r.ChatHistory(ctx, "user:1"); len(history) != 0 {
+		t.Errorf("after clearing: %+v", history)
+	}
+}
+
+func TestChatActions(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	tomorrow := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	heat := models.Movie{Title: "Heat", Year: 1995, PlexRatingKey: "m1"}
+	if err := db.Create(&heat).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if title, err := r.AddToWatchlist(ctx, models.TypeMovie, heat.ID); err != nil || title != "Heat" {
+			t.Fatalf("AddToWatchlist = %q, %v", title, err)
+		}
+	}
+	movies, _, err := r.signalIDSet(ctx, models.SignalKindWatchlist)
+	if _, ok := movies[heat.ID]; err != nil || !ok || len(movies) != 1 {
+		t.Errorf("watchlist = %v, %v; want Heat", movies, err)
+	}
+
+	pin, err := r.PinTitle(ctx, tomorrow, models.TypeMovie, heat.ID, "Suggested in chat.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin.Title != "Heat" || pin.MovieID == nil || *pin.MovieID != heat.ID || !pin.Date.Equal(tomorrow) {
+		t.Errorf("pin = %+v", pin)
+	}
+	if _, err := r.PinTitle(ctx, tomorrow, models.TypeMovie, heat.ID, ""); !errors.Is(err, ErrPinExists) {
+		t.Errorf("pinning again: err = %v, want ErrPinExists", err)
+	}
+	if _, err := r.AddToWatchlist(ctx, models.TypeTVShow, 9999); !errors.Is(err, ErrTitleNotFound) {
+		t.Errorf("unknown show: err = %v, want ErrTitleNotFound", err)
+	}
+}
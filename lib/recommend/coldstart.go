@@ -0,0 +1,53 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+)
+
+// ColdStart prepares titles Plex added since since, so they are ready for
+// the next generation instead of waiting their turn: they are embedded and
+// indexed for the "Because you watched …" rows, and new movies get their
+// TMDb collection and release date looked up (for collection cooldowns and
+// recency scoring). It returns how many titles were added.
+func (r *Recommender) ColdStart(ctx context.Context, since time.Time) (int, error) {
+	titles, err := r.libraryTitles(ctx, time.Now(), since)
+	if err != nil {
+		return 0, err
+	}
+	if len(titles) == 0 {
+		return 0, nil
+	}
+
+	_, changed, err := r.refreshEmbeddings(ctx, titles)
+	if err != nil {
+		return 0, err
+	}
+	model := r.embeddings().Model()
+	if err := r.vectors.Put(ctx, model, changed); err != nil {
+		return 0, fmt.Errorf("index %s vectors: %w", r.vectors.Name(), err)
+	}
+
+	checked := 0
+	if r.TMDbEnabled() {
+		var movies []models.Movie
+		if err := r.db.WithContext(ctx).
+			Where("created_at >= ? AND tm_db_id IS NOT NULL AND collection_checked_at IS NULL", since).
+			Order("id").
+			Limit(collectionLookupBatch).
+			Find(&movies).Error; err != nil {
+			return 0, fmt.Errorf("load new movies: %w", err)
+		}
+		if checked, err = r.checkCollections(ctx, movies); err != nil {
+			return 0, err
+		}
+	}
+
+	logging.FromContext(ctx).Infow("Prepared newly added titles",
+		"since", since, "titles", len(titles), "embedded", len(changed), "collections_checked", checked)
+	return len(titles), nil
+}
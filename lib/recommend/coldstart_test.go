@@ -0,0 +1,54 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/vectors"
+	"github.com/icco/recommender/models"
+)
+
+func TestColdStart_preparesOnlyNewTitles(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	since := time.Now().Add(-time.Hour)
+	for _, m := range []models.Movie{
+		{Title: "Arrival", Year: 2016, PlexRatingKey: "m1", Genre: "Science Fiction", CreatedAt: since.Add(-24 * time.Hour)},
+		{Title: "Dune", Year: 2021, PlexRatingKey: "m2", Genre: "Science Fiction"},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.TVShow{Title: "Severance", Year: 2022, PlexRatingKey: "s1", Genre: "Drama"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := r.ColdStart(ctx, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("ColdStart = %d titles, want 2", n)
+	}
+	var embedded []models.TitleEmbedding
+	if err := db.Order("type").Find(&embedded).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) != 2 {
+		t.Fatalf("embeddings = %+v, want Dune and Severance", embedded)
+	}
+	matches, err := r.vectors.Nearest(ctx, FeatureEmbeddingModel, embedded[0].Vector, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Key != (vectors.Key{Type: embedded[0].Type, ID: embedded[0].TitleID}) {
+		t.Errorf("indexed = %+v, want both new titles", matches)
+	}
+
+	// Nothing added since: nothing to do.
+	if n, err := r.ColdStart(ctx, time.Now().Add(time.Hour)); err != nil || n != 0 {
+		t.Errorf("ColdStart later = %d, %v; want 0", n, err)
+	}
+}
@@ -0,0 +1,186 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/tmdb"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// collectionLookupBatch bounds TMDb calls per EnrichCollections run
+	// (about a minute at TMDb's rate limit); the rest wait for the next sync.
+	collectionLookupBatch = 200
+	// collectionRecheckAfter is how long a lookup is trusted before a movie is
+	// checked again, in case TMDb has since added it to a collection.
+	collectionRecheckAfter = 90 * 24 * time.Hour
+)
+
+// CollectionCooldown suppresses the rest of a collection for Days after one
+// of its entries is recommended, for collections with at least MinSize
+// entries in the library.
+type CollectionCooldown struct {
+	MinSize int
+	Days    int
+}
+
+// CollectionCooldowns are tiers by collection size; the tier with the
+// largest MinSize not above a collection's size applies.
+type CollectionCooldowns []CollectionCooldown
+
+// DefaultCollectionCooldowns gives bigger franchises longer cooldowns, since
+// they have more siblings waiting to crowd the picks.
+var DefaultCollectionCooldowns = CollectionCooldowns{
+	{MinSize: 2, Days: 14},
+	{MinSize: 4, Days: 30},
+	{MinSize: 8, Days: 60},
+}
+
+// ParseCollectionCooldowns parses COLLECTION_COOLDOWNS-style tiers such as
+// "2:14,4:30,8:60" (min library entries:cooldown days). A tier of 0 days
+// turns suppression off for collections of that size.
+func ParseCollectionCooldowns(s string) (CollectionCooldowns, error) {
+	var out CollectionCooldowns
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		sizeStr, daysStr, ok := strings.Cut(part, ":")
+		size, sizeErr := strconv.Atoi(strings.TrimSpace(sizeStr))
+		days, daysErr := strconv.Atoi(strings.TrimSpace(daysStr))
+		if !ok || sizeErr != nil || daysErr != nil || size < 1 || days < 0 {
+			return nil, fmt.Errorf("invalid collection cooldown %q (want size:days)", part)
+		}
+		out = append(out, CollectionCooldown{MinSize: size, Days: days})
+	}
+	slices.SortFunc(out, func(a, b CollectionCooldown) int { return a.MinSize - b.MinSize })
+	return out, nil
+}
+
+// For returns the cooldown in days for a collection with size library entries.
+func (cs CollectionCooldowns) For(size int) int {
+	days := 0
+	for _, c := range cs {
+		if size >= c.MinSize {
+			days = c.Days
+		}
+	}
+	return days
+}
+
+// collectionCooldowns returns the configured tiers, or the defaults.
+func (s *Settings) collectionCooldowns() CollectionCooldowns {
+	if s.CollectionCooldowns == nil {
+		return DefaultCollectionCooldowns
+	}
+	return s.CollectionCooldowns
+}
+
+// suppressedCollections returns the TMDb collection IDs still cooling down on
+// date because a sibling was recommended recently. movies is the library,
+// used to size each collection.
+func (r *Recommender) suppressedCollections(ctx context.Context, date time.Time, movies []models.Movie) (map[int]struct{}, error) {
+	cooldowns := r.currentSettings().collectionCooldowns()
+	maxDays := 0
+	for _, c := range cooldowns {
+		maxDays = max(maxDays, c.Days)
+	}
+	if maxDays == 0 {
+		return nil, nil
+	}
+
+	sizes := make(map[int]int)
+	for _, m := range movies {
+		if m.CollectionID != nil {
+			sizes[*m.CollectionID]++
+		}
+	}
+	if len(sizes) == 0 {
+		return nil, nil
+	}
+
+	var recent []struct {
+		CollectionID int
+		Last         time.Time
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT m.collection_id, MAX(r."date") AS last
+		FROM (?) AS r JOIN movies m ON m.id = r.movie_id
+		WHERE m.collection_id IS NOT NULL AND r."date" >= ? AND r."date" <= ?
+		GROUP BY m.collection_id`, householdRecs(r.db.WithContext(ctx)), date.AddDate(0, 0, -maxDays), date).
+		Scan(&recent).Error; err != nil {
+		return nil, fmt.Errorf("load recent collections: %w", err)
+	}
+
+	out := make(map[int]struct{})
+	for _, rc := range recent {
+		days := cooldowns.For(sizes[rc.CollectionID])
+		if days > 0 && rc.Last.After(date.AddDate(0, 0, -days)) {
+			out[rc.CollectionID] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+// EnrichCollections looks up the TMDb collection and release date of movies
+// not checked recently, up to collectionLookupBatch per call, and returns how
+// many were checked. It stops early, without error, when TMDb's circuit is
+// open, and does nothing when TMDb is disabled.
+func (r *Recommender) EnrichCollections(ctx context.Context) (int, error) {
+	if !r.TMDbEnabled() {
+		return 0, nil
+	}
+	var movies []models.Movie
+	if err := r.db.WithContext(ctx).
+		Where("tm_db_id IS NOT NULL AND (collection_checked_at IS NULL OR collection_checked_at < ?)", time.Now().Add(-collectionRecheckAfter)).
+		Order("collection_checked_at NULLS FIRST, id").
+		Limit(collectionLookupBatch).
+		Find(&movies).Error; err != nil {
+		return 0, fmt.Errorf("load movies to check: %w", err)
+	}
+	return r.checkCollections(ctx, movies)
+}
+
+// checkCollections looks up and saves the TMDb collection and release date
+// of each of movies, returning how many were checked. A failed lookup is
+// logged and left for a later run; TMDb's circuit opening stops the loop.
+func (r *Recommender) checkCollections(ctx context.Context, movies []models.Movie) (int, error) {
+	checked := 0
+	for _, m := range movies {
+		details, err := r.tmdb.GetMovie(ctx, *m.TMDbID)
+		var apiErr *tmdb.APIError
+		switch {
+		case errors.Is(err, tmdb.ErrCircuitOpen), ctx.Err() != nil:
+			return checked, nil
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			details = &tmdb.MovieDetails{} // stale TMDb ID; record it as checked
+		case err != nil:
+			logging.FromContext(ctx).Warnw("TMDb collection lookup failed", "movie_id", m.ID, zap.Error(err))
+			continue
+		}
+
+		updates := map[string]any{"collection_id": nil, "collection_name": "", "collection_checked_at": time.Now()}
+		if c := details.BelongsToCollection; c != nil && c.ID > 0 {
+			updates["collection_id"] = c.ID
+			updates["collection_name"] = c.Name
+		}
+		if d, err := time.Parse(time.DateOnly, details.ReleaseDate); err == nil {
+			updates["release_date"] = d
+		}
+		if err := r.db.WithContext(ctx).Model(&models.Movie{ID: m.ID}).Updates(updates).Error; err != nil {
+			return checked, fmt.Errorf("save collection for movie %d: %w", m.ID, err)
+		}
+		checked++
+	}
+	return checked, nil
+}
@@ -0,0 +1,94 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestParseCollectionCooldowns(t *testing.T) {
+	got, err := ParseCollectionCooldowns(" 5:45, 2:7 ,3:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CollectionCooldowns{{MinSize: 2, Days: 7}, {MinSize: 3, Days: 0}, {MinSize: 5, Days: 45}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for size, days := range map[int]int{1: 0, 2: 7, 3: 0, 4: 0, 5: 45, 12: 45} {
+		if d := got.For(size); d != days {
+			t.Errorf("For(%d) = %d, want %d", size, d, days)
+		}
+	}
+	for _, bad := range []string{"2", "x:14", "0:14", "2:-1"} {
+		if _, err := ParseCollectionCooldowns(bad); err == nil {
+			t.Errorf("ParseCollectionCooldowns(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadCandidates_suppressesCoolingDownCollections(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	trilogy, duology := 10, 20
+	movies := []models.Movie{
+		{PlexRatingKey: "t1", Title: "Trilogy I", Year: 2001, CollectionID: &trilogy},
+		{PlexRatingKey: "t2", Title: "Trilogy II", Year: 2002, CollectionID: &trilogy},
+		{PlexRatingKey: "t3", Title: "Trilogy III", Year: 2003, CollectionID: &trilogy},
+		{PlexRatingKey: "d1", Title: "Duology I", Year: 2010, CollectionID: &duology},
+		{PlexRatingKey: "d2", Title: "Duology II", Year: 2012, CollectionID: &duology},
+		{PlexRatingKey: "s1", Title: "Standalone", Year: 2015},
+	}
+	if err := db.Create(&movies).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Trilogy I was picked 10 days ago, Duology I 20 days ago.
+	for _, rec := range []models.Recommendation{
+		{Date: day.AddDate(0, 0, -10), Title: "Trilogy I", Type: models.TypeMovie, Year: 2001, MovieID: &movies[0].ID},
+		{Date: day.AddDate(0, 0, -20), Title: "Duology I", Type: models.TypeMovie, Year: 2010, MovieID: &movies[3].ID},
+	} {
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	titles := func() map[string]bool {
+		t.Helper()
+		got, _, err := r.loadCandidates(ctx, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]bool)
+		for _, c := range got {
+			out[c.Title] = true
+		}
+		return out
+	}
+
+	// Defaults: both collections are size 2-3, so 14 days. The trilogy is
+	// still cooling down; the duology's cooldown has passed.
+	got := titles()
+	if got["Trilogy II"] || got["Trilogy III"] {
+		t.Errorf("trilogy siblings should be suppressed: %v", got)
+	}
+	if !got["Duology II"] || !got["Standalone"] {
+		t.Errorf("duology sibling and standalone should be eligible: %v", got)
+	}
+
+	// Longer cooldowns for 3+ entry collections don't touch the duology.
+	r.ApplySettings(Settings{CollectionCooldowns: CollectionCooldowns{{MinSize: 2, Days: 5}, {MinSize: 3, Days: 30}}})
+	got = titles()
+	if got["Trilogy II"] || !got["Duology II"] {
+		t.Errorf("tiered cooldowns not applied by size: %v", got)
+	}
+
+	r.ApplySettings(Settings{CollectionCooldowns: CollectionCooldowns{{MinSize: 2, Days: 0}}})
+	if got := titles(); !got["Trilogy II"] {
+		t.Errorf("0-day tier should disable suppression: %v", got)
+	}
+}
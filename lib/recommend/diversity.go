@@ -0,0 +1,144 @@
+package recommend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icco/recommender/models"
+)
+
+// DiversityRules selects which attributes no two picks of the same type may
+// share within a day's set.
+type DiversityRules struct {
+	Genre    bool // primary (first-listed) genre
+	Decade   bool // release decade
+	Director bool // any shared director (movies only; Plex lists none for shows)
+}
+
+// DefaultDiversityRules enforces all three.
+var DefaultDiversityRules = DiversityRules{Genre: true, Decade: true, Director: true}
+
+// ParseDiversityRules parses DIVERSITY_RULES-style input: a comma-separated
+// subset of "genre", "decade", and "director", or "none".
+func ParseDiversityRules(s string) (DiversityRules, error) {
+	var out DiversityRules
+	for part := range strings.SplitSeq(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "genre":
+			out.Genre = true
+		case "decade":
+			out.Decade = true
+		case "director":
+			out.Director = true
+		case "none", "":
+		default:
+			return out, fmt.Errorf("unknown diversity rule %q (want genre, decade, director, or none)", part)
+		}
+	}
+	return out, nil
+}
+
+// diversityRules returns the configured rules, or the defaults.
+func (s *Settings) diversityRules() DiversityRules {
+	if s.Diversity == nil {
+		return DefaultDiversityRules
+	}
+	return *s.Diversity
+}
+
+// conflicts reports whether a and b share an attribute the rules forbid.
+func (rules DiversityRules) conflicts(a, b candidate) bool {
+	if rules.Genre && len(a.Genres) > 0 && len(b.Genres) > 0 && strings.EqualFold(a.Genres[0], b.Genres[0]) {
+		return true
+	}
+	if rules.Decade && a.Year > 0 && b.Year > 0 && a.Year/10 == b.Year/10 {
+		return true
+	}
+	if rules.Director {
+		for _, d := range a.Directors {
+			for _, e := range b.Directors {
+				if strings.EqualFold(d, e) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// diversify enforces rules on recs after the model's picks are slotted.
+// Within each media type, a pick that conflicts with an earlier one is
+// replaced by the best-scoring pool candidate that conflicts with none; if
+// the pool has no such candidate, the original pick is kept so the day
+// never comes up short. Picks are never added beyond len(recs).
+func diversify(recs []models.Recommendation, pool []candidate, rules DiversityRules) []models.Recommendation {
+	if rules == (DiversityRules{}) {
+		return recs
+	}
+	byID := make(map[string]candidate, len(pool))
+	for _, c := range pool {
+		byID[candKey(c.Type, c.ID)] = c
+	}
+	ranked := rankCandidates(pool)
+
+	used := make(map[string]bool, len(recs))
+	for _, rec := range recs {
+		used[recKey(rec)] = true
+	}
+	kept := make(map[string][]candidate) // by type
+	fits := func(c candidate) bool {
+		for _, k := range kept[c.Type] {
+			if rules.conflicts(c, k) {
+				return false
+			}
+		}
+		return true
+	}
+
+	out := make([]models.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		c, ok := byID[recKey(rec)]
+		if !ok || fits(c) {
+			if ok {
+				kept[c.Type] = append(kept[c.Type], c)
+			}
+			out = append(out, rec)
+			continue
+		}
+		replaced := false
+		for _, alt := range ranked {
+			k := candKey(alt.Type, alt.ID)
+			if alt.Type != c.Type || used[k] || !fits(alt) {
+				continue
+			}
+			used[k] = true
+			kept[alt.Type] = append(kept[alt.Type], alt)
+			swap := toRec(alt, "", rec.Date)
+			swap.Score = &models.ScoreBreakdown{DiversitySwap: true}
+			out = append(out, swap)
+			replaced = true
+			break
+		}
+		if !replaced {
+			kept[c.Type] = append(kept[c.Type], c)
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// candKey identifies a candidate across types (movie and show IDs overlap).
+func candKey(typ string, id uint) string {
+	return fmt.Sprintf("%s:%d", typ, id)
+}
+
+// recKey is candKey for a selected recommendation.
+func recKey(rec models.Recommendation) string {
+	switch {
+	case rec.MovieID != nil:
+		return candKey(models.TypeMovie, *rec.MovieID)
+	case rec.TVShowID != nil:
+		return candKey(models.TypeTVShow, *rec.TVShowID)
+	}
+	return ""
+}
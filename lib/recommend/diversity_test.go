@@ -0,0 +1,73 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestParseDiversityRules(t *testing.T) {
+	got, err := ParseDiversityRules("Genre, director")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (DiversityRules{Genre: true, Director: true}) {
+		t.Errorf("got %+v", got)
+	}
+	if got, err := ParseDiversityRules("none"); err != nil || got != (DiversityRules{}) {
+		t.Errorf("none = %+v, %v", got, err)
+	}
+	if _, err := ParseDiversityRules("genre,mood"); err == nil {
+		t.Error("unknown rule should fail")
+	}
+}
+
+func TestDiversify(t *testing.T) {
+	mov := func(id uint, year int, genre string, directors ...string) candidate {
+		return candidate{ID: id, Type: models.TypeMovie, Title: genre, Year: year, Rating: new(5.0), Genres: []string{genre}, Directors: directors}
+	}
+	show := func(id uint, year int, genre string) candidate {
+		return candidate{ID: id, Type: models.TypeTVShow, Year: year, Rating: new(5.0), Genres: []string{genre}}
+	}
+	pool := []candidate{
+		mov(1, 1994, "Drama", "A"),
+		mov(2, 2004, "Drama", "B"),  // same primary genre as 1
+		mov(3, 1995, "Comedy", "C"), // same decade as 1
+		mov(4, 2012, "Horror", "A"), // same director as 1
+		mov(5, 2021, "Western", "D"),
+		mov(6, 1982, "Comedy", "E"),
+		show(1, 1994, "Drama"), // shares ID, genre, and decade with movie 1; other type
+	}
+	pool[5].Rating = new(9.0) // best-scoring backfill
+	picks := []candidate{pool[0], pool[1], pool[2], pool[3], pool[6]}
+	recs := make([]models.Recommendation, len(picks))
+	for i, c := range picks {
+		recs[i] = toRec(c, "model", time.Time{})
+	}
+	ids := func(recs []models.Recommendation) []string {
+		out := make([]string, len(recs))
+		for i, r := range recs {
+			out[i] = recKey(r)
+		}
+		return out
+	}
+
+	got := ids(diversify(recs, pool, DefaultDiversityRules))
+	// Movie 2 (genre) is replaced by 6, the best-scoring fit; 3 (decade) by
+	// 5; 4 (director) has nothing left to swap for, so it stays. The show is
+	// only compared against shows.
+	want := []string{"movie:1", "movie:6", "movie:5", "movie:4", "tvshow:1"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if got := ids(diversify(recs, pool, DiversityRules{})); got[1] != "movie:2" {
+		t.Errorf("no rules should leave picks alone: %v", got)
+	}
+}
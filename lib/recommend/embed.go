@@ -0,0 +1,125 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"github.com/icco/recommender/lib/errreport"
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultEmbeddingModel is the Gemini embedding model used when
+	// EMBEDDING_MODEL is unset.
+	DefaultEmbeddingModel = "gemini-embedding-001"
+	// FeatureEmbeddingModel names the built-in embedder used without Gemini.
+	FeatureEmbeddingModel = "features-v1"
+	// featureDims is the length of a FeatureEmbeddingModel vector.
+	featureDims = 512
+)
+
+// Embedder turns title descriptions into vectors whose cosine similarity
+// says how alike the titles are. Implemented by GeminiEmbedder and, without
+// a model, by a word-feature embedder.
+type Embedder interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the embedding model; vectors from different models are
+	// never compared.
+	Model() string
+}
+
+// GeminiEmbedder embeds text with a Gemini embedding model on Vertex AI.
+type GeminiEmbedder struct {
+	client *genai.Client
+	model  string
+}
+
+// NewGeminiEmbedder builds a Vertex AI-backed embedder from ADC, configured
+// like NewGeminiChatter.
+func NewGeminiEmbedder(ctx context.Context, model string) (*GeminiEmbedder, error) {
+	g, err := NewGeminiChatter(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	return &GeminiEmbedder{client: g.client, model: model}, nil
+}
+
+// Embed sends texts in one request.
+func (g *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, t := range texts {
+		contents[i] = genai.NewContentFromText(t, genai.RoleUser)
+	}
+	resp, err := g.client.Models.EmbedContent(ctx, g.model, contents, &genai.EmbedContentConfig{TaskType: "SEMANTIC_SIMILARITY"})
+	if err != nil {
+		err = fmt.Errorf("gemini embed: %w", err)
+		errreport.CaptureError(ctx, err, "provider", "gemini", "model", g.model)
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini embed: got %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	out := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		out[i] = e.Values
+	}
+	return out, nil
+}
+
+// Model returns the Gemini model name.
+func (g *GeminiEmbedder) Model() string {
+	return g.model
+}
+
+// featureEmbedder hashes the words of a description into a fixed-length
+// vector, so titles sharing genres, people, and title words come out close.
+// It needs no model, and keeps the rows working with LLM_PROVIDER=none.
+type featureEmbedder struct{}
+
+// featureStopWords are the labels embedText writes into every description.
+var featureStopWords = map[string]bool{
+	"genres": true, "directed": true, "starring": true, "movie": true, "show": true, "and": true, "the": true,
+}
+
+func (featureEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, featureDims)
+		for _, w := range strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+			return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r > 127)
+		}) {
+			if len(w) < 3 || featureStopWords[w] {
+				continue
+			}
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(w)) // never fails
+			v[h.Sum32()%featureDims]++
+		}
+		out[i] = normalize(v)
+	}
+	return out, nil
+}
+
+func (featureEmbedder) Model() string {
+	return FeatureEmbeddingModel
+}
+
+// normalize scales v to unit length in place; a zero vector is returned as is.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+	return v
+}
@@ -0,0 +1,34 @@
+package recommend
+
+import "github.com/icco/recommender/models"
+
+// DefaultNightlyMinutes is the evening viewing window TV picks are sized to
+// when NIGHTLY_MINUTES is unset.
+const DefaultNightlyMinutes = 90
+
+func (s *Settings) nightlyMinutes() int {
+	if s.NightlyMinutes > 0 {
+		return s.NightlyMinutes
+	}
+	return DefaultNightlyMinutes
+}
+
+// episodesFor returns how many episodes of runtime minutes fit in window,
+// at least one so a long episode still gets a suggestion. It returns 0 when
+// the runtime is unknown.
+func episodesFor(runtime, window int) int {
+	if runtime <= 0 {
+		return 0
+	}
+	return max(1, window/runtime)
+}
+
+// suggestEpisodes sets how many episodes of each TV pick fit the nightly
+// window, for shows whose episode length Plex reported.
+func suggestEpisodes(recs []models.Recommendation, window int) {
+	for i := range recs {
+		if recs[i].Type == models.TypeTVShow {
+			recs[i].Episodes = episodesFor(recs[i].EpisodeRuntime, window)
+		}
+	}
+}
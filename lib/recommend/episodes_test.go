@@ -0,0 +1,35 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestEpisodesFor(t *testing.T) {
+	for _, tc := range []struct{ runtime, window, want int }{
+		{42, 90, 2},
+		{22, 90, 4},
+		{60, 90, 1},
+		{120, 90, 1}, // longer than the window: still one
+		{0, 90, 0},   // unknown runtime
+	} {
+		if got := episodesFor(tc.runtime, tc.window); got != tc.want {
+			t.Errorf("episodesFor(%d, %d) = %d, want %d", tc.runtime, tc.window, got, tc.want)
+		}
+	}
+}
+
+func TestSuggestEpisodes(t *testing.T) {
+	recs := []models.Recommendation{
+		{Title: "Show", Type: models.TypeTVShow, EpisodeRuntime: 42},
+		{Title: "Unknown", Type: models.TypeTVShow},
+		{Title: "Movie", Type: models.TypeMovie, Runtime: 120},
+	}
+	suggestEpisodes(recs, (&Settings{}).nightlyMinutes())
+	for i, want := range []int{2, 0, 0} {
+		if recs[i].Episodes != want {
+			t.Errorf("%s: Episodes = %d, want %d", recs[i].Title, recs[i].Episodes, want)
+		}
+	}
+}
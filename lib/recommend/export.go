@@ -0,0 +1,102 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+)
+
+// ListExporter pushes a day's movie picks to a list on an external service.
+type ListExporter interface {
+	Name() string
+	Export(ctx context.Context, movies []models.Recommendation) error
+}
+
+// traktListExporter adds picks to a list on the connected Trakt account.
+type traktListExporter struct {
+	source *traktSource // for the OAuth token
+	list   string
+}
+
+func (e *traktListExporter) Name() string { return "trakt:" + e.list }
+
+// Export adds movies with a TMDb ID to the list; Trakt matches by ID only, so
+// the rest are skipped.
+func (e *traktListExporter) Export(ctx context.Context, movies []models.Recommendation) error {
+	var ids []int
+	for _, m := range movies {
+		if m.TMDbID > 0 {
+			ids = append(ids, m.TMDbID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	token, err := e.source.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := e.source.client.AddToList(ctx, token, e.list, ids)
+	if err != nil {
+		return fmt.Errorf("add to trakt list %s: %w", e.list, err)
+	}
+	logging.FromContext(ctx).Infow("Exported picks to Trakt list", "list", e.list,
+		"added", res.Added, "existing", res.Existing, "not_found", res.NotFound, "skipped_no_tmdb", len(movies)-len(ids))
+	return nil
+}
+
+// listExporters returns the configured export destinations.
+func (r *Recommender) listExporters() []ListExporter {
+	var out []ListExporter
+	if c := r.traktClient(); c != nil && r.sigCfg.TraktExportList != "" {
+		out = append(out, &traktListExporter{source: &traktSource{db: r.db, client: c}, list: r.sigCfg.TraktExportList})
+	}
+	return out
+}
+
+// ExportEnabled reports whether any list export destination is configured.
+func (r *Recommender) ExportEnabled() bool {
+	return len(r.listExporters()) > 0
+}
+
+// ExportDay pushes date's daily movie picks to every configured destination.
+// Every destination is tried; the joined error names the ones that failed.
+// Destinations ignore picks they already have, so a retry is harmless.
+func (r *Recommender) ExportDay(ctx context.Context, date time.Time) error {
+	exporters := r.listExporters()
+	if len(exporters) == 0 {
+		return nil
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		return err
+	}
+	movies := dailyMovies(recs)
+	if len(movies) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, e := range exporters {
+		if err := e.Export(ctx, movies); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dailyMovies is the daily slot's movie picks; time-of-day slots and shows
+// aren't exported.
+func dailyMovies(recs []models.Recommendation) []models.Recommendation {
+	daily, _ := SplitSlots(recs)
+	var out []models.Recommendation
+	for _, rec := range daily {
+		if rec.Type == models.TypeMovie {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
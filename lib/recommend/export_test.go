@@ -0,0 +1,51 @@
+package recommend
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/trakt"
+	"github.com/icco/recommender/models"
+)
+
+func TestDailyMovies(t *testing.T) {
+	got := dailyMovies([]models.Recommendation{
+		{Title: "A", Type: models.TypeMovie, Slot: models.RunContextDaily},
+		{Title: "B", Type: models.TypeTVShow, Slot: models.RunContextDaily},
+		{Title: "C", Type: models.TypeMovie, Slot: "late"},
+		{Title: "D", Type: models.TypeMovie},
+	})
+	if len(got) != 2 || got[0].Title != "A" || got[1].Title != "D" {
+		t.Errorf("got %+v, want daily movies A and D", got)
+	}
+}
+
+func TestTraktListExporter(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	if err := db.Create(&models.OAuthToken{
+		Source: models.SourceTrakt, AccessToken: "tok", RefreshToken: "r", ExpiresAt: time.Now().Add(time.Hour),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = r.URL.Path + " " + string(b)
+		_, _ = w.Write([]byte(`{"added":{"movies":1}}`))
+	}))
+	defer srv.Close()
+	client := trakt.NewClient("cid", "secret")
+	client.BaseURL = srv.URL
+
+	e := &traktListExporter{source: &traktSource{db: db, client: client}, list: "picks"}
+	if err := e.Export(ctx, []models.Recommendation{{Title: "Heat", TMDbID: 949}, {Title: "Unmatched"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `/users/me/lists/picks/items {"movies":[{"ids":{"tmdb":949}}]}`; body != want {
+		t.Errorf("request = %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,26 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// MarkFeedbackBefore records feedback (models.FeedbackWatched or
+// models.FeedbackIgnored) on every pick dated before before that has none
+// yet, returning how many it marked. Picks already marked keep their
+// feedback, so running it again over an overlapping range is harmless.
+func (r *Recommender) MarkFeedbackBefore(ctx context.Context, before time.Time, feedback string) (int64, error) {
+	if feedback != models.FeedbackWatched && feedback != models.FeedbackIgnored {
+		return 0, fmt.Errorf("unknown feedback %q", feedback)
+	}
+	res := r.db.WithContext(ctx).Model(&models.Recommendation{}).
+		Where(`"date" < ? AND feedback = ''`, before.UTC()).
+		Updates(map[string]any{"feedback": feedback, "feedback_at": time.Now()})
+	if res.Error != nil {
+		return 0, fmt.Errorf("mark feedback: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
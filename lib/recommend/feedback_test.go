@@ -0,0 +1,49 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestMarkFeedbackBefore(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	recs := []models.Recommendation{
+		{Date: day(1), Title: "Heat", Type: models.TypeMovie, Year: 1995},
+		{Date: day(2), Title: "Alien", Type: models.TypeMovie, Year: 1979, Feedback: models.FeedbackIgnored},
+		{Date: day(3), Title: "Severance", Type: models.TypeTVShow, Year: 2022},
+	}
+	if err := db.Create(&recs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := r.MarkFeedbackBefore(ctx, day(3), models.FeedbackWatched)
+	if err != nil || n != 1 {
+		t.Fatalf("MarkFeedbackBefore = %d, %v; want 1 marked", n, err)
+	}
+	want := map[string]string{"Heat": models.FeedbackWatched, "Alien": models.FeedbackIgnored, "Severance": ""}
+	var got []models.Recommendation
+	if err := db.Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range got {
+		if rec.Feedback != want[rec.Title] {
+			t.Errorf("%s: feedback = %q, want %q", rec.Title, rec.Feedback, want[rec.Title])
+		}
+	}
+	if _, err := r.MarkFeedbackBefore(ctx, day(3), "loved"); err == nil {
+		t.Error("unknown feedback: want an error")
+	}
+
+	stats, err := r.GetStats(ctx, StatsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Watched != 1 || stats.Ignored != 1 {
+		t.Errorf("stats watched/ignored = %d/%d, want 1/1", stats.Watched, stats.Ignored)
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/icco/gutil/logging"
-	"github.com/icco/recommender/lib/recommend/prompts"
+	"github.com/icco/recommender/lib/errreport"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/sanitize"
 	"github.com/icco/recommender/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -21,70 +27,260 @@ const (
 	shortlistSize = 80
 	targetMovies  = 4
 	targetTVShows = 3
+	// staleRunAfter lets a new attempt reclaim a run left "running" by a
+	// process that died mid-generation.
+	staleRunAfter = 15 * time.Minute
+	// posterMaxAge is how long a cached poster is reused before it's
+	// downloaded again.
+	posterMaxAge = 7 * 24 * time.Hour
 )
 
+// FallbackModel is GenerationRun.Model when the model was unavailable and the
+// day's picks came from the scorer alone.
+const FallbackModel = "scoring-fallback"
+
 type promptData struct {
 	TargetMovies  int
 	TargetTVShows int
+	SlotGuidance  string
+	Context       string // situational context, e.g. holiday theme and weather
 	Profile       string
+	Preferences   string // stated on the settings page
 	Loved         string
 	Movies        string
 	TVShows       string
 }
 
-// GenerateRecommendations builds the day's recommendations from the cached Plex
-// library using Gemini to pick from a scored shortlist. It records a
-// GenerationRun and is a no-op if a successful run already exists for the day.
+// GenerateRecommendations builds the day's main (daily slot) recommendations
+// from the cached Plex library using Gemini to pick from a scored shortlist.
+// It records a GenerationRun and is a no-op if a successful run already exists
+// for the day or another generator currently holds the day's run.
 func (r *Recommender) GenerateRecommendations(ctx context.Context, date time.Time) error {
-	l := logging.FromContext(ctx)
+	return r.GenerateSlot(ctx, date, DailySlot)
+}
+
+// GenerateSlot is GenerateRecommendations for one slot: the slot's prompt
+// guidance, composition, and runtime cap apply, and its run and rows are kept
+// separate from the day's other slots. Under WithRegenerate it replaces a
+// slot that already generated.
+func (r *Recommender) GenerateSlot(ctx context.Context, date time.Time, slot Slot) (err error) {
+	l := logging.FromContext(ctx).With("slot", slot.Name)
 	start := time.Now()
+	cfg := r.currentSettings()
+	slot = cfg.compose(slot)
+	date = date.UTC().Truncate(24 * time.Hour)
+	if b, ok := cfg.Blackouts.Find(date); ok {
+		l.Infow("Generation paused for date", "date", date, "until", b.To)
+		return nil
+	}
 
-	done, err := r.DidRunToday(ctx, date)
+	// A regeneration replaces picks from a successful run, which it must
+	// keep if this attempt fails.
+	var replacing bool
+	if regenerating(ctx) {
+		// The deferred restore reads the named result, so don't shadow err.
+		prev, lerr := r.lastRun(ctx, date, slot.Name)
+		if lerr != nil {
+			return lerr
+		}
+		if replacing = prev.Status == models.RunStatusOK; replacing {
+			defer func() {
+				if err == nil {
+					return
+				}
+				if rerr := r.restoreRun(ctx, prev); rerr != nil {
+					l.Errorw("Failed to restore run after regeneration failed", zap.Error(rerr))
+				}
+			}()
+		}
+	} else {
+		// didRun is only a cheap early exit; claimRun is what guarantees a
+		// single generator per day and slot.
+		done, err := r.didRun(ctx, date, slot.Name)
+		if err != nil {
+			return err
+		}
+		if done {
+			l.Infow("Recommendations already generated for date", "date", date)
+			return nil
+		}
+	}
+	runID, claimed, err := r.claimRun(ctx, date, slot.Name, replacing)
 	if err != nil {
 		return err
 	}
-	if done {
-		l.Infow("Recommendations already generated for date", "date", date)
+	if !claimed {
+		l.Infow("Generation for date already done or in progress elsewhere", "date", date)
 		return nil
 	}
+	ctx = errreport.WithTags(ctx, "run_id", strconv.FormatUint(uint64(runID), 10),
+		"date", date.Format(time.DateOnly), "slot", slot.Name, "model", r.model)
+	fsys, canary := r.choosePrompts(ctx, date, slot, cfg)
+	if canary != "" {
+		l = l.With("prompt_canary", canary)
+		ctx = errreport.WithTags(ctx, "prompt_canary", canary)
+		// Judged once this attempt's outcome is recorded.
+		defer r.checkCanary(ctx, canary, cfg)
+	}
+	if err := r.recordProvenance(ctx, runID, slot, cfg, fsys, canary); err != nil {
+		l.Warnw("Failed to record run provenance", zap.Error(err))
+	}
 
+	jobs.Progress(ctx, 10)
 	movies, tvshows, err := r.loadCandidates(ctx, date)
 	if err != nil {
-		return r.recordRun(ctx, date, 0, 0, err)
+		return r.recordRun(ctx, runID, start, 0, 0, err)
 	}
 	if len(movies) == 0 && len(tvshows) == 0 {
 		err := fmt.Errorf("no eligible candidates; run /cron/cache first")
-		return r.recordRun(ctx, date, 0, 0, err)
+		return r.recordRun(ctx, runID, start, 0, 0, err)
+	}
+	if cfg.Quality.enabled() {
+		dropped := map[string]int{}
+		movies, tvshows = cfg.Quality.filter(movies, dropped), cfg.Quality.filter(tvshows, dropped)
+		l.Infow("Filtered candidates below quality minimums",
+			"dropped", dropped, "movies", len(movies), "tvshows", len(tvshows))
+		if len(movies) == 0 && len(tvshows) == 0 {
+			err := fmt.Errorf("no candidates meet the quality minimums; loosen the CANDIDATE_* settings")
+			return r.recordRun(ctx, runID, start, 0, 0, err)
+		}
+	}
+
+	if slot.Kids {
+		movies, tvshows = cfg.Kids.filter(movies), cfg.Kids.filter(tvshows)
+		l.Infow("Filtered candidates to kid-appropriate titles", "movies", len(movies), "tvshows", len(tvshows))
+		if len(movies) == 0 && len(tvshows) == 0 {
+			err := fmt.Errorf("no kid-appropriate candidates; set KIDS_LIBRARIES or KIDS_RATINGS")
+			return r.recordRun(ctx, runID, start, 0, 0, err)
+		}
+	}
+
+	// Pinned titles fill the daily slot first; the generator picks the rest.
+	var pinned []models.Recommendation
+	if slot.Name == models.RunContextDaily {
+		if pinned, err = r.pinnedRecs(ctx, date); err != nil {
+			return r.recordRun(ctx, runID, start, 0, 0, err)
+		}
+		movies, tvshows = withoutPinned(movies, pinned), withoutPinned(tvshows, pinned)
+		for _, rec := range pinned {
+			if rec.Type == models.TypeMovie {
+				slot.Movies = max(slot.Movies-1, 0)
+			} else {
+				slot.TVShows = max(slot.TVShows-1, 0)
+			}
+		}
+	}
+
+	if slot.MaxRuntime > 0 {
+		movies = slices.DeleteFunc(movies, func(c candidate) bool {
+			return c.Runtime <= 0 || c.Runtime > slot.MaxRuntime
+		})
+	}
+
+	// A spotlight narrows the day to titles crediting one person, when
+	// enough of them are eligible; shows only when some match.
+	var spotlight string
+	if slot.Name == models.RunContextDaily {
+		person, err := r.spotlightFor(ctx, date, cfg, movies)
+		if err != nil {
+			l.Warnw("Spotlight lookup failed; generating without", zap.Error(err))
+		}
+		if m := featuring(movies, person); person != "" && len(m) >= spotlightMinTitles {
+			spotlight, movies = person, m
+			if tv := featuring(tvshows, person); len(tv) > 0 {
+				tvshows = tv
+			}
+		} else if person != "" {
+			l.Infow("Too few eligible titles for spotlight; generating as usual", "person", person, "titles", len(m))
+		}
 	}
 
 	movieShortlist := buildShortlist(movies, date, poolSize, shortlistSize)
 	tvShortlist := buildShortlist(tvshows, date, poolSize, shortlistSize)
 
-	system, user, err := r.renderPrompts(ctx, movieShortlist, tvShortlist)
-	if err != nil {
-		return r.recordRun(ctx, date, 0, 0, err)
+	promptContext := cfg.weatherContext(ctx, date)
+	var theme string
+	if h, ok := cfg.holiday(date); ok {
+		theme = h.Name
+		promptContext = strings.TrimSpace(holidayPrompt(h) + "\n" + promptContext)
 	}
-
-	raw, err := r.chat.Complete(ctx, system, user, pickSchema())
-	if err != nil {
-		return r.recordRun(ctx, date, 0, 0, fmt.Errorf("gemini: %w", err))
+	if spotlight != "" {
+		theme = spotlight + " spotlight"
+		promptContext = strings.TrimSpace(spotlightPrompt(spotlight) + "\n" + promptContext)
+	}
+	if notes := r.notesContext(ctx, date); notes != "" {
+		promptContext = strings.TrimSpace(promptContext + "\n" + notes)
+	}
+	if promptContext != "" {
+		if err := r.db.WithContext(ctx).Model(&models.GenerationRun{ID: runID}).
+			Updates(map[string]any{"prompt_context": truncateRunError(promptContext), "theme": theme}).Error; err != nil {
+			l.Warnw("Failed to record prompt context", zap.Error(err))
+		}
 	}
 
-	pr, err := parsePickResponse(raw)
-	if err != nil {
-		return r.recordRun(ctx, date, 0, 0, err)
+	// The model call is most of the wait.
+	jobs.Progress(ctx, 30)
+	var pr pickResponse
+	if !r.LLMEnabled() {
+		// Heuristic-only mode (LLM_PROVIDER=none): the scorer picks, and the
+		// run was claimed under FallbackModel.
+		pr = fallbackPicks(movieShortlist, tvShortlist)
+	} else if pr, err = r.modelPicks(ctx, fsys, slot, promptContext, movieShortlist, tvShortlist); err != nil {
+		if errors.Is(err, ErrInvalidPicks) {
+			r.failValidation(ctx, runID)
+		}
+		if replacing {
+			return r.recordRun(ctx, runID, start, 0, 0, fmt.Errorf("model picks failed; keeping the existing picks: %w", err))
+		}
+		// The daily set waits for the model while a retry is still due
+		// before the cutoff; the run is recorded as failed meanwhile.
+		if at, ok := cfg.ModelRetry.next(date, time.Now(), err); ok && slot.Name == DailySlot.Name {
+			l.Warnw("Model picks failed; retrying later", "at", at, zap.Error(err))
+			return r.recordRun(ctx, runID, start, 0, 0, &RetryLaterError{At: at, Err: err})
+		}
+		// Rank the shortlist ourselves rather than leave the day empty; the
+		// run's model and strategy record that the picks are the scorer's.
+		l.Warnw("Model picks failed; falling back to scored picks", zap.Error(err))
+		pr = fallbackPicks(movieShortlist, tvShortlist)
+		if err := r.db.WithContext(ctx).Model(&models.GenerationRun{ID: runID}).
+			Updates(map[string]any{"model": FallbackModel, "strategy": models.StrategyFallback}).Error; err != nil {
+			l.Warnw("Failed to record fallback model", zap.Error(err))
+		}
+	} else if n := invalidPicks(pr, movieShortlist, tvShortlist); n > 0 {
+		l.Warnw("Model picked titles not on the shortlist", "invalid", n)
+		r.failValidation(ctx, runID)
 	}
 
+	jobs.Progress(ctx, 85)
 	combined := append([]candidate{}, movieShortlist...)
 	combined = append(combined, tvShortlist...)
-	recs := selectMovies(pr.Movies, combined, targetMovies)
-	recs = append(recs, selectTVShows(pr.TVShows, combined, targetTVShows)...)
+	var recs []models.Recommendation
+	if slot.Prompt == "" {
+		recs = selectMovies(pr.Movies, combined, slot.Movies)
+	} else {
+		// Time-of-day slots take the model's picks as-is rather than filling
+		// the daily comedy/action/rewatch roles.
+		recs = selectInOrder(pr.Movies, combined, slot.Movies, models.TypeMovie)
+	}
+	recs = append(recs, selectTVShows(pr.TVShows, combined, slot.TVShows)...)
+	// Backfill from every eligible title, not just the shortlist, so a
+	// homogeneous shortlist can't leave conflicts in place.
+	pool := slices.Concat(movies, tvshows)
+	rules := cfg.diversityRules()
+	if spotlight != "" {
+		rules.Director = false // the spotlight's films may share a director by design
+	}
+	recs = append(pinned, diversify(recs, pool, rules)...)
+	annotateScores(recs, pool)
+	noteAnniversaries(recs)
+	suggestEpisodes(recs, cfg.nightlyMinutes())
 	if len(recs) == 0 {
-		return r.recordRun(ctx, date, 0, 0, fmt.Errorf("no recommendations selected"))
+		return r.recordRun(ctx, runID, start, 0, 0, fmt.Errorf("no recommendations selected"))
 	}
 
 	for i := range recs {
 		recs[i].Date = date
+		recs[i].Slot = slot.Name
 		r.cachePoster(ctx, &recs[i])
 	}
 
@@ -97,23 +293,41 @@ func (r *Recommender) GenerateRecommendations(ctx context.Context, date time.Tim
 		}
 	}
 
-	if err := r.saveRecommendations(ctx, date, recs); err != nil {
-		return r.recordRun(ctx, date, movieCount, tvCount, err)
+	if err := r.saveRecommendations(ctx, date, slot.Name, recs); err != nil {
+		return r.recordRun(ctx, runID, start, movieCount, tvCount, err)
 	}
 
-	if err := r.recordRun(ctx, date, movieCount, tvCount, nil); err != nil {
+	if err := r.recordRun(ctx, runID, start, movieCount, tvCount, nil); err != nil {
 		return err
 	}
 	l.Infow("Generated recommendations", "movies", movieCount, "tvshows", tvCount, "duration", time.Since(start))
 	return nil
 }
 
-func (r *Recommender) renderPrompts(ctx context.Context, movies, tvshows []candidate) (system, user string, err error) {
-	sysTmpl, err := prompts.FS.ReadFile("system.txt")
+// modelPicks asks the model to pick from the shortlists, with the prompt
+// templates in fsys. A reply that can't be parsed is an ErrInvalidPicks.
+func (r *Recommender) modelPicks(ctx context.Context, fsys fs.FS, slot Slot, promptContext string, movies, tvshows []candidate) (pickResponse, error) {
+	system, user, err := r.renderPrompts(ctx, fsys, slot, promptContext, movies, tvshows)
+	if err != nil {
+		return pickResponse{}, err
+	}
+	raw, err := r.chat.Complete(ctx, system, user, pickSchema())
+	if err != nil {
+		return pickResponse{}, fmt.Errorf("llm: %w", err)
+	}
+	pr, err := parsePickResponse(raw)
+	if err != nil {
+		return pr, fmt.Errorf("%w: %w", ErrInvalidPicks, err)
+	}
+	return pr, nil
+}
+
+func (r *Recommender) renderPrompts(ctx context.Context, fsys fs.FS, slot Slot, promptContext string, movies, tvshows []candidate) (system, user string, err error) {
+	sysTmpl, err := fs.ReadFile(fsys, "system.txt")
 	if err != nil {
 		return "", "", fmt.Errorf("read system prompt: %w", err)
 	}
-	userTmplBytes, err := prompts.FS.ReadFile("recommendation.txt")
+	userTmplBytes, err := fs.ReadFile(fsys, "recommendation.txt")
 	if err != nil {
 		return "", "", fmt.Errorf("read user prompt: %w", err)
 	}
@@ -121,6 +335,14 @@ func (r *Recommender) renderPrompts(ctx context.Context, movies, tvshows []candi
 	if err != nil {
 		return "", "", fmt.Errorf("parse user prompt: %w", err)
 	}
+	var guidance string
+	if slot.Prompt != "" {
+		b, err := fs.ReadFile(fsys, slot.Prompt)
+		if err != nil {
+			return "", "", fmt.Errorf("read %s slot prompt: %w", slot.Name, err)
+		}
+		guidance = strings.TrimSpace(string(b))
+	}
 	profile, err := r.tasteProfile(ctx)
 	if err != nil {
 		logging.FromContext(ctx).Warnw("taste profile failed; continuing without", zap.Error(err))
@@ -131,9 +353,14 @@ func (r *Recommender) renderPrompts(ctx context.Context, movies, tvshows []candi
 		logging.FromContext(ctx).Warnw("loved titles failed; continuing without", zap.Error(err))
 		loved = ""
 	}
+	prefs, err := r.Preferences(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("preferences failed; continuing without", zap.Error(err))
+	}
 	var b strings.Builder
 	if err := userTmpl.Execute(&b, promptData{
-		TargetMovies: targetMovies, TargetTVShows: targetTVShows, Profile: profile, Loved: loved,
+		TargetMovies: slot.Movies, TargetTVShows: slot.TVShows, SlotGuidance: guidance, Context: promptContext, Profile: profile,
+		Preferences: preferencesPrompt(prefs), Loved: loved,
 		Movies: formatShortlist(movies), TVShows: formatShortlist(tvshows),
 	}); err != nil {
 		return "", "", fmt.Errorf("execute user prompt: %w", err)
@@ -142,15 +369,23 @@ func (r *Recommender) renderPrompts(ctx context.Context, movies, tvshows []candi
 }
 
 // cachePoster downloads the finalist's Plex poster into the local poster dir and
-// rewrites PosterURL to a public /posters/ path the web page can load. Plex thumb
-// URLs point at a private, token-gated host browsers can't reach. Bounded to the
-// finalist set, so at most a handful of downloads per run.
+// rewrites PosterURL to a public /posters/ path the web page can load. Plex
+// posters are stored as paths on a private, token-gated host browsers can't
+// reach; uncached ones go through the signed image proxy instead. Bounded to the
+// finalist set, so at most a handful of downloads per run. A copy younger than
+// posterMaxAge is reused, so replaced artwork still shows within a week.
 func (r *Recommender) cachePoster(ctx context.Context, rec *models.Recommendation) {
 	if r.posterDir == "" || rec.PosterURL == "" || r.plex == nil {
 		return
 	}
 	name := fmt.Sprintf("%s-%d.jpg", rec.Type, posterID(rec))
 	dest := filepath.Join(r.posterDir, name)
+	if info, err := os.Stat(dest); err == nil && time.Since(info.ModTime()) < posterMaxAge {
+		r.posters.Hit()
+		rec.PosterURL = "/posters/" + name
+		return
+	}
+	r.posters.Miss()
 	if err := r.plex.DownloadImage(ctx, rec.PosterURL, dest); err != nil {
 		logging.FromContext(ctx).Warnw("cache poster failed", "title", rec.Title, zap.Error(err))
 		return
@@ -158,6 +393,18 @@ func (r *Recommender) cachePoster(ctx context.Context, rec *models.Recommendatio
 	rec.PosterURL = "/posters/" + name
 }
 
+// posterCount is how many posters are cached on disk.
+func (r *Recommender) posterCount() int {
+	if r.posterDir == "" {
+		return 0
+	}
+	entries, err := os.ReadDir(r.posterDir)
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
 // posterID returns the Plex-backed ID used to name the cached poster file.
 func posterID(rec *models.Recommendation) uint {
 	switch {
@@ -169,14 +416,36 @@ func posterID(rec *models.Recommendation) uint {
 	return 0
 }
 
-func (r *Recommender) saveRecommendations(ctx context.Context, date time.Time, recs []models.Recommendation) error {
+// saveRecommendations swaps the slot's picks for recs in one transaction,
+// moving the day's votes onto the new picks.
+func (r *Recommender) saveRecommendations(ctx context.Context, date time.Time, slot string, recs []models.Recommendation) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where(`"date" = ?`, date).Delete(&models.Recommendation{}).Error; err != nil {
-			return fmt.Errorf("clear existing recs: %w", err)
+		var old []models.Recommendation
+		if err := tx.Where(`"date" = ? AND slot = ?`, date, slot).Find(&old).Error; err != nil {
+			return fmt.Errorf("load existing recs: %w", err)
 		}
-		// The (date, title) unique index rejects two Plex items with the same title
+		// The (date, slot, title) unique index rejects two Plex items with the same title
 		// on one day; skip in-batch title collisions rather than fail the run.
 		seen := make(map[string]bool, len(recs))
+		// A regenerated day's new pick for a title keeps the household's feedback
+		// and snooze on the old one. An old pick they acted on that isn't picked
+		// again stays: snoozedKeys reads snoozes from these rows, and snoozed
+		// titles are never candidates, so they would be lost otherwise.
+		var oldIDs []uint
+		for _, o := range old {
+			if i := sameTitle(o.MovieID, o.TVShowID, recs); i >= 0 {
+				carryFeedback(&recs[i], o)
+			} else if o.Feedback != "" || o.SnoozedUntil != nil {
+				seen[o.Title] = true
+				continue
+			}
+			oldIDs = append(oldIDs, o.ID)
+		}
+		if len(oldIDs) > 0 {
+			if err := tx.Where("id IN ?", oldIDs).Delete(&models.Recommendation{}).Error; err != nil {
+				return fmt.Errorf("clear existing recs: %w", err)
+			}
+		}
 		for i := range recs {
 			if seen[recs[i].Title] {
 				continue
@@ -186,21 +455,67 @@ func (r *Recommender) saveRecommendations(ctx context.Context, date time.Time, r
 				return fmt.Errorf("create rec %q: %w", recs[i].Title, err)
 			}
 		}
-		return nil
+		return moveVotes(ctx, tx, oldIDs, recs)
 	})
 }
 
-func (r *Recommender) recordRun(ctx context.Context, date time.Time, movieCount, tvCount int, genErr error) error {
-	run := models.GenerationRun{
-		Date: date, Status: models.RunStatusOK, MovieCount: movieCount,
-		TVShowCount: tvCount, Model: r.model,
+// claimRun takes the (date, runContext) GenerationRun for this generator. A new
+// row is inserted as "running"; an existing row is taken over only if its last
+// attempt failed or went stale, or, when replacing, succeeded. It reports
+// false when the day is already done or another generator holds it.
+func (r *Recommender) claimRun(ctx context.Context, date time.Time, runContext string, replacing bool) (uint, bool, error) {
+	now := time.Now().UTC()
+	var ids []uint
+	if err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO generation_runs ("date", context, status, attempts, model, created_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?, ?)
+		ON CONFLICT ("date", context) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = generation_runs.attempts + 1,
+			model = EXCLUDED.model,
+			error = '',
+			updated_at = EXCLUDED.updated_at
+		WHERE generation_runs.status = ?
+			OR (generation_runs.status = ? AND generation_runs.updated_at < ?)
+			OR (? AND generation_runs.status = ?)
+		RETURNING id`,
+		date, runContext, models.RunStatusRunning, r.model, now, now,
+		models.RunStatusError, models.RunStatusRunning, now.Add(-staleRunAfter),
+		replacing, models.RunStatusOK).
+		Scan(&ids).Error; err != nil {
+		return 0, false, fmt.Errorf("claim run: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, false, nil
+	}
+	return ids[0], true, nil
+}
+
+// recordRun stores the outcome of the claimed run and returns genErr.
+func (r *Recommender) recordRun(ctx context.Context, runID uint, start time.Time, movieCount, tvCount int, genErr error) error {
+	updates := map[string]any{
+		"status": models.RunStatusOK, "movie_count": movieCount, "tv_show_count": tvCount,
+		"duration_ms": time.Since(start).Milliseconds(), "error": "",
 	}
 	if genErr != nil {
-		run.Status = models.RunStatusError
-		run.Error = genErr.Error()
+		updates["status"] = models.RunStatusError
+		updates["error"] = truncateRunError(genErr.Error())
+		// A run that will be retried isn't final, so it isn't reported.
+		var later *RetryLaterError
+		if !errors.As(genErr, &later) {
+			errreport.CaptureError(ctx, genErr)
+		}
 	}
-	if err := r.db.WithContext(ctx).Create(&run).Error; err != nil {
+	// Record the outcome even if ctx timed out mid-generation, so the run
+	// doesn't sit in "running" until it goes stale.
+	//nolint:contextcheck // intentional detach: outcome must be stored after ctx expiry
+	if err := r.db.WithContext(context.WithoutCancel(ctx)).Model(&models.GenerationRun{ID: runID}).Updates(updates).Error; err != nil {
 		return fmt.Errorf("record run: %w", errors.Join(err, genErr))
 	}
 	return genErr
 }
+
+// truncateRunError fits err into GenerationRun's varchar(1000) text columns.
+func truncateRunError(err string) string {
+	return sanitize.Truncate(err, 1000)
+}
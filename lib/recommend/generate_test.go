@@ -2,6 +2,7 @@ package recommend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -21,9 +22,9 @@ func TestGenerateRecommendations_endToEnd(t *testing.T) {
 	ctx := context.Background()
 	date := time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC)
 
-	comedy := models.Movie{Title: "Funny", Year: 2000, Rating: 8, Genre: "Comedy", PosterURL: "p1", PlexRatingKey: "m1"}
-	action := models.Movie{Title: "Boom", Year: 2001, Rating: 8, Genre: "Action", PosterURL: "p2", PlexRatingKey: "m2"}
-	show := models.TVShow{Title: "Series", Year: 2010, Rating: 8, Genre: "Drama", PosterURL: "p3", ViewCount: 0, PlexRatingKey: "s1"}
+	comedy := models.Movie{Title: "Funny", Year: 2000, Rating: new(8.0), Genre: "Comedy", PosterURL: "p1", PlexRatingKey: "m1"}
+	action := models.Movie{Title: "Boom", Year: 2001, Rating: new(8.0), Genre: "Action", PosterURL: "p2", PlexRatingKey: "m2"}
+	show := models.TVShow{Title: "Series", Year: 2010, Rating: new(8.0), Genre: "Drama", PosterURL: "p3", ViewCount: 0, PlexRatingKey: "s1"}
 	for _, m := range []*models.Movie{&comedy, &action} {
 		if err := db.Create(m).Error; err != nil {
 			t.Fatal(err)
@@ -35,7 +36,7 @@ func TestGenerateRecommendations_endToEnd(t *testing.T) {
 
 	reply := fmt.Sprintf(`{"movies":[{"id":%d,"explanation":"lol"},{"id":%d,"explanation":"bang"}],"tvshows":[{"id":%d,"explanation":"gripping"}]}`,
 		comedy.ID, action.ID, show.ID)
-	r := &Recommender{db: db, chat: fakeChatter{reply: reply}, model: "test"}
+	r := &Recommender{db: db, chat: fakeChatter{reply: reply}, model: "test", provider: "gemini"}
 
 	if err := r.GenerateRecommendations(ctx, date); err != nil {
 		t.Fatalf("generate: %v", err)
@@ -65,6 +66,14 @@ func TestGenerateRecommendations_endToEnd(t *testing.T) {
 	if !done {
 		t.Error("expected a successful GenerationRun")
 	}
+	runs, err := r.Runs(ctx, date, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Provider != "gemini" || runs[0].Strategy != models.StrategyModel ||
+		len(runs[0].PromptVersion) != 12 || runs[0].Config == nil || runs[0].Config.Movies != DailySlot.Movies {
+		t.Errorf("runs = %+v, want one model run with its provenance", runs)
+	}
 
 	// Second call is a no-op (already ran).
 	if err := r.GenerateRecommendations(ctx, date); err != nil {
@@ -75,3 +84,122 @@ func TestGenerateRecommendations_endToEnd(t *testing.T) {
 		t.Fatalf("rerun changed rec count to %d", len(recs2))
 	}
 }
+
+func TestGenerateRecommendations_withoutLLM(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	date := time.Date(2026, 7, 7, 0, 0, 0, 0, time.UTC)
+	for i, genre := range []string{"Comedy", "Action", "Drama"} {
+		m := models.Movie{Title: genre + " film", Year: 2000 + i, Rating: new(8.0), Genre: genre, PlexRatingKey: fmt.Sprintf("m%d", i)}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Recommender{db: db, model: FallbackModel}
+	if r.LLMEnabled() {
+		t.Fatal("a Recommender without a Chatter should be heuristic-only")
+	}
+	if err := r.GenerateRecommendations(ctx, date); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) == 0 {
+		t.Fatal("the scorer should have picked without a model")
+	}
+	var run models.GenerationRun
+	if err := db.Where(`"date" = ?`, date).Take(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.Model != FallbackModel || run.Status != models.RunStatusOK {
+		t.Errorf("run = %s/%s, want %s/ok", run.Model, run.Status, FallbackModel)
+	}
+	if run.Provider != "none" || run.Strategy != models.StrategyScored || run.PromptVersion != "" {
+		t.Errorf("provenance = %s/%s/%q, want none/scored without a prompt version", run.Provider, run.Strategy, run.PromptVersion)
+	}
+
+	recs = RankForMood(recs, MoodCozy)
+	if got := r.RerankForMood(ctx, recs, MoodCozy, true); got[0].ID != recs[0].ID {
+		t.Error("mood re-rank should fall back to the heuristic without a model")
+	}
+}
+
+func TestClaimRun_singleGeneratorPerDay(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	id, ok, err := r.claimRun(ctx, day, models.RunContextDaily, false)
+	if err != nil || !ok {
+		t.Fatalf("first claim = %v, %v; want claimed", ok, err)
+	}
+	if _, ok, err := r.claimRun(ctx, day, models.RunContextDaily, false); err != nil || ok {
+		t.Fatalf("claim while running = %v, %v; want not claimed", ok, err)
+	}
+	if _, ok, err := r.claimRun(ctx, day, "other", false); err != nil || !ok {
+		t.Fatalf("claim for another context = %v, %v; want claimed", ok, err)
+	}
+
+	// A failed run can be retried; the same row is reused.
+	if err := r.recordRun(ctx, id, time.Now(), 0, 0, errors.New("boom")); err == nil {
+		t.Fatal("recordRun should return the generation error")
+	}
+	id2, ok, err := r.claimRun(ctx, day, models.RunContextDaily, false)
+	if err != nil || !ok || id2 != id {
+		t.Fatalf("reclaim after error = %d, %v, %v; want %d, true", id2, ok, err, id)
+	}
+	var run models.GenerationRun
+	db.First(&run, id)
+	if run.Attempts != 2 || run.Status != models.RunStatusRunning {
+		t.Fatalf("run = %+v; want attempts 2, running", run)
+	}
+
+	// A successful run is only reclaimed to replace it.
+	if err := r.recordRun(ctx, id, time.Now(), 4, 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := r.claimRun(ctx, day, models.RunContextDaily, false); err != nil || ok {
+		t.Fatalf("claim after success = %v, %v; want not claimed", ok, err)
+	}
+	if id3, ok, err := r.claimRun(ctx, day, models.RunContextDaily, true); err != nil || !ok || id3 != id {
+		t.Fatalf("replacing claim after success = %d, %v, %v; want %d, true", id3, ok, err, id)
+	}
+}
+
+func TestGenerateSlot_runtimeCapAndSeparateRows(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	date := time.Date(2026, 7, 7, 0, 0, 0, 0, time.UTC)
+
+	short := models.Movie{Title: "Short", Year: 2000, Rating: new(7.0), Genre: "Comedy", Runtime: 85, PlexRatingKey: "m1"}
+	long := models.Movie{Title: "Long", Year: 2001, Rating: new(9.0), Genre: "Drama", Runtime: 180, PlexRatingKey: "m2"}
+	for _, m := range []*models.Movie{&short, &long} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The model asks for the long movie; the late slot's runtime cap must win.
+	reply := fmt.Sprintf(`{"movies":[{"id":%d,"explanation":"epic"}],"tvshows":[]}`, long.ID)
+	r := &Recommender{db: db, chat: fakeChatter{reply: reply}, model: "test"}
+
+	late, _ := LookupSlot("late")
+	if err := r.GenerateSlot(ctx, date, late); err != nil {
+		t.Fatalf("generate late: %v", err)
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Title != "Short" || recs[0].Slot != "late" {
+		t.Fatalf("late recs = %+v; want only Short in the late slot", recs)
+	}
+	if done, _ := r.DidRunToday(ctx, date); done {
+		t.Error("a time-of-day slot must not mark the daily slot done")
+	}
+	if done, _ := r.DidRun(ctx, date, late); !done {
+		t.Error("expected the late slot to be done")
+	}
+}
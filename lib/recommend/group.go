@@ -0,0 +1,145 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/icco/recommender/models"
+)
+
+// MaxGroupSize bounds how many people a movie night can be planned for.
+const MaxGroupSize = 8
+
+// Group is the people watching together and their merged taste.
+type Group struct {
+	Members []string // as named in ?with=
+	Unknown []string // members without any votes, whose taste isn't known
+	Taste   map[string]float64
+}
+
+// ParseGroup splits a comma-separated list of names, as in
+// ?with=alice,bob, dropping blanks, repeats (ignoring case), and names too
+// long to be voters. It keeps at most MaxGroupSize names.
+func ParseGroup(s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || len(name) > MaxVoterLen || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, name)
+		if len(out) == MaxGroupSize {
+			break
+		}
+	}
+	return out
+}
+
+// GroupTaste learns each member's taste from the genres of the titles they
+// voted for on the vote page (names match voters ignoring case) and merges
+// them with mergeTastes. Members who never voted are listed in Unknown and
+// left out of the merge.
+func (r *Recommender) GroupTaste(ctx context.Context, members []string) (Group, error) {
+	g := Group{Members: members}
+	lower := make([]string, len(members))
+	for i, m := range members {
+		lower[i] = strings.ToLower(m)
+	}
+	var rows []struct {
+		Voter string
+		Genre string
+	}
+	if err := r.db.WithContext(ctx).Table("votes").
+		Select("LOWER(votes.voter) AS voter, COALESCE(movies.genre, tv_shows.genre, '') AS genre").
+		Joins("LEFT JOIN movies ON movies.id = votes.movie_id").
+		Joins("LEFT JOIN tv_shows ON tv_shows.id = votes.tv_show_id").
+		Where("LOWER(votes.voter) IN ?", lower).
+		Scan(&rows).Error; err != nil {
+		return g, fmt.Errorf("load group votes: %w", err)
+	}
+	genres := map[string][]string{}
+	for _, row := range rows {
+		genres[row.Voter] = append(genres[row.Voter], row.Genre)
+	}
+	var tastes []map[string]float64
+	for i, m := range members {
+		voted, ok := genres[lower[i]]
+		if !ok {
+			g.Unknown = append(g.Unknown, m)
+			continue
+		}
+		tastes = append(tastes, tasteOf(voted))
+	}
+	g.Taste = mergeTastes(tastes)
+	return g, nil
+}
+
+// tasteOf weights genres by how many of a person's voted-for picks, given
+// by their comma-joined genres, carry each one, scaled so the most frequent
+// genre weighs 1.
+func tasteOf(voted []string) map[string]float64 {
+	taste := map[string]float64{}
+	peak := 0.0
+	for _, genres := range voted {
+		for _, g := range splitGenres(genres) {
+			taste[g]++
+			peak = max(peak, taste[g])
+		}
+	}
+	for g := range taste {
+		taste[g] /= peak
+	}
+	return taste
+}
+
+// mergeTastes combines the members' tastes so that genres everyone shares
+// win: a genre weighs its average weight across all members (0 for those
+// without it) times the share of members who have it at all. A genre all
+// three people like equally keeps its weight; one only one of them likes
+// keeps a ninth of it.
+func mergeTastes(tastes []map[string]float64) map[string]float64 {
+	merged := map[string]float64{}
+	if len(tastes) == 0 {
+		return merged
+	}
+	sums, fans := map[string]float64{}, map[string]int{}
+	for _, taste := range tastes {
+		for g, w := range taste {
+			if w > 0 {
+				sums[g] += w
+				fans[g]++
+			}
+		}
+	}
+	n := float64(len(tastes))
+	for g, sum := range sums {
+		merged[g] = sum / n * float64(fans[g]) / n
+	}
+	return merged
+}
+
+// groupScore rates how well rec suits a group's merged taste.
+func groupScore(rec models.Recommendation, taste map[string]float64) float64 {
+	score := 0.0
+	for _, g := range splitGenres(rec.Genre) {
+		score += taste[g]
+	}
+	return score
+}
+
+// RankForGroup returns recs reordered so the picks the whole group is most
+// likely to enjoy come first. The sort is stable, so ties keep the day's
+// order, and a group with no known taste leaves it unchanged.
+func RankForGroup(recs []models.Recommendation, taste map[string]float64) []models.Recommendation {
+	out := slices.Clone(recs)
+	sort.SliceStable(out, func(i, j int) bool {
+		return groupScore(out[i], taste) > groupScore(out[j], taste)
+	})
+	return out
+}
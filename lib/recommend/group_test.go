@@ -0,0 +1,119 @@
+package recommend
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestParseGroup(t *testing.T) {
+	if got := ParseGroup(" alice, Bob,,ALICE ,bob"); !slices.Equal(got, []string{"alice", "Bob"}) {
+		t.Errorf("ParseGroup = %q, want alice and Bob once each", got)
+	}
+	if got := ParseGroup("a,b,c,d,e,f,g,h,i,j"); len(got) != MaxGroupSize {
+		t.Errorf("ParseGroup kept %d names, want %d", len(got), MaxGroupSize)
+	}
+	if got := ParseGroup(""); got != nil {
+		t.Errorf("ParseGroup(\"\") = %q, want none", got)
+	}
+}
+
+func TestTasteOf(t *testing.T) {
+	got := tasteOf([]string{"Comedy, Drama", "Comedy", "Horror"})
+	want := map[string]float64{"Comedy": 1, "Drama": 0.5, "Horror": 0.5}
+	if len(got) != len(want) {
+		t.Fatalf("tasteOf = %v, want %v", got, want)
+	}
+	for g, w := range want {
+		if got[g] != w {
+			t.Errorf("tasteOf[%s] = %v, want %v", g, got[g], w)
+		}
+	}
+}
+
+func TestMergeTastes(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	merged := mergeTastes([]map[string]float64{
+		{"Comedy": 1, "Horror": 1, "Drama": 0.5},
+		{"Comedy": 1, "Romance": 1, "Drama": 0.5},
+		{"Comedy": 0.5, "Drama": 1},
+	})
+	for g, want := range map[string]float64{
+		"Comedy":  2.5 / 3,     // everyone likes it
+		"Drama":   2.0 / 3,     // everyone, less keenly
+		"Horror":  1.0 / 3 / 3, // one of three
+		"Romance": 1.0 / 3 / 3, // one of three
+		"Western": 0,           // nobody
+	} {
+		if !near(merged[g], want) {
+			t.Errorf("merged[%s] = %v, want %v", g, merged[g], want)
+		}
+	}
+	// A genre one person loves loses to one everyone likes a little.
+	shared := mergeTastes([]map[string]float64{{"Horror": 1, "Drama": 0.4}, {"Drama": 0.4}})
+	if shared["Drama"] <= shared["Horror"] {
+		t.Errorf("merged = %v, want the shared Drama above Horror", shared)
+	}
+	if got := mergeTastes(nil); len(got) != 0 {
+		t.Errorf("mergeTastes(nil) = %v, want empty", got)
+	}
+}
+
+func TestRankForGroup(t *testing.T) {
+	recs := []models.Recommendation{
+		{Title: "Scream", Genre: "Horror"},
+		{Title: "Airplane!", Genre: "Comedy"},
+		{Title: "Unknown"},
+		{Title: "Groundhog Day", Genre: "Comedy, Romance"},
+	}
+	taste := map[string]float64{"Comedy": 0.8, "Romance": 0.1, "Horror": 0.1}
+	var got []string
+	for _, rec := range RankForGroup(recs, taste) {
+		got = append(got, rec.Title)
+	}
+	if want := []string{"Groundhog Day", "Airplane!", "Scream", "Unknown"}; !slices.Equal(got, want) {
+		t.Errorf("RankForGroup = %q, want %q", got, want)
+	}
+	if out := RankForGroup(recs, nil); out[0].Title != "Scream" {
+		t.Errorf("RankForGroup with no taste reordered the day: %+v", out)
+	}
+}
+
+func TestGroupTaste(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.Movie{
+		{Title: "Airplane!", Year: 1980, PlexRatingKey: "m1", Genre: "Comedy"},
+		{Title: "Scream", Year: 1996, PlexRatingKey: "m2", Genre: "Horror, Comedy"},
+	}
+	for i := range movies {
+		if err := db.Create(&movies[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Votes keep their titles after the day's picks are regenerated.
+	for _, v := range []models.Vote{
+		{Date: day, Voter: "Alice", RecommendationID: 1, MovieID: &movies[0].ID},
+		{Date: day, Voter: "bob", RecommendationID: 2, MovieID: &movies[1].ID},
+		{Date: day, Voter: "carol", RecommendationID: 2, MovieID: &movies[1].ID},
+	} {
+		if err := db.Create(&v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := r.GroupTaste(t.Context(), []string{"alice", "Bob", "dave"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(g.Unknown, []string{"dave"}) {
+		t.Errorf("Unknown = %q, want dave", g.Unknown)
+	}
+	if g.Taste["Comedy"] != 1 || g.Taste["Horror"] != 0.25 {
+		t.Errorf("Taste = %v, want Comedy shared by both and Horror by Bob alone", g.Taste)
+	}
+}
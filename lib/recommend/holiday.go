@@ -0,0 +1,43 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/lib/calendar"
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+)
+
+// holiday returns the themed holiday on date, if a calendar is set.
+func (s *Settings) holiday(date time.Time) (calendar.Holiday, bool) {
+	if s.Calendar == nil {
+		return calendar.Holiday{}, false
+	}
+	return s.Calendar.Holiday(date)
+}
+
+// holidayPrompt is the prompt line for a themed day.
+func holidayPrompt(h calendar.Holiday) string {
+	return fmt.Sprintf("Today is %s: lean toward %s where the shortlist allows, without forcing it.", h.Name, h.Theme)
+}
+
+// ThemeForDate returns the holiday theme applied to the day's recommendations,
+// or "" when the day wasn't themed.
+func (r *Recommender) ThemeForDate(ctx context.Context, date time.Time) (string, error) {
+	start, end := recommendationUTCDayRange(date)
+	var run models.GenerationRun
+	err := r.db.WithContext(ctx).
+		Where(`"date" >= ? AND "date" < ? AND status = ? AND theme <> ''`, start, end, models.RunStatusOK).
+		Order("context").
+		Take(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get theme: %w", err)
+	}
+	return run.Theme, nil
+}
@@ -0,0 +1,51 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/calendar"
+	"github.com/icco/recommender/models"
+)
+
+func TestHoliday_calendarOptional(t *testing.T) {
+	thanksgiving := time.Date(2026, 11, 26, 0, 0, 0, 0, time.UTC)
+	if _, ok := (&Settings{}).holiday(thanksgiving); ok {
+		t.Error("no calendar set should mean no themed days")
+	}
+	h, ok := (&Settings{Calendar: calendar.US}).holiday(thanksgiving)
+	if !ok || h.Name != "Thanksgiving" {
+		t.Fatalf("holiday = %+v, %v; want Thanksgiving", h, ok)
+	}
+	if p := holidayPrompt(h); !strings.Contains(p, "Thanksgiving") || !strings.Contains(p, h.Theme) {
+		t.Errorf("holidayPrompt = %q", p)
+	}
+}
+
+func TestThemeForDate(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 12, 26, 0, 0, 0, 0, time.UTC)
+
+	for _, run := range []models.GenerationRun{
+		{Date: day, Context: DailySlot.Name, Status: models.RunStatusOK, Theme: "Boxing Day"},
+		{Date: day.AddDate(0, 0, 1), Context: DailySlot.Name, Status: models.RunStatusOK},
+		{Date: day.AddDate(0, 0, 2), Context: DailySlot.Name, Status: models.RunStatusError, Theme: "New Year's Eve"},
+	} {
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for offset, want := range []string{"Boxing Day", "", ""} {
+		got, err := r.ThemeForDate(ctx, day.AddDate(0, 0, offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("ThemeForDate(+%d) = %q, want %q", offset, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package recommend
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultKidsRatings are the content ratings the kids' picks allow when
+// KidsFilter.Ratings is unset: the US film and TV ratings for all ages.
+var DefaultKidsRatings = []string{"G", "PG", "TV-Y", "TV-Y7", "TV-Y7-FV", "TV-G", "TV-PG"}
+
+// KidsFilter decides which titles KidsSlot may pick. A title qualifies if it
+// is in one of Libraries, whatever its rating, or carries one of Ratings.
+// Unrated titles outside those libraries never qualify.
+type KidsFilter struct {
+	Libraries []string // Plex library titles, e.g. "Kids Movies"; matched ignoring case
+	Ratings   []string // content ratings as Plex reports them, e.g. "PG" or "gb/U"; nil uses DefaultKidsRatings
+}
+
+func (f KidsFilter) allows(c candidate) bool {
+	if c.Library != "" && slices.ContainsFunc(f.Libraries, func(l string) bool { return strings.EqualFold(l, c.Library) }) {
+		return true
+	}
+	ratings := f.Ratings
+	if ratings == nil {
+		ratings = DefaultKidsRatings
+	}
+	return c.ContentRating != "" && slices.ContainsFunc(ratings, func(r string) bool { return strings.EqualFold(r, c.ContentRating) })
+}
+
+// filter returns the candidates f allows in a new slice, leaving cands as
+// it was for the other slots and the fallback.
+func (f KidsFilter) filter(cands []candidate) []candidate {
+	out := make([]candidate, 0, len(cands))
+	for _, c := range cands {
+		if f.allows(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package recommend
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestKidsFilter(t *testing.T) {
+	cands := []candidate{
+		{Title: "Toy Story", ContentRating: "G", Library: "Movies"},
+		{Title: "Bluey", ContentRating: "tv-y", Library: "TV Shows"},
+		{Title: "Heat", ContentRating: "R", Library: "Movies"},
+		{Title: "Home Movie", Library: "Movies"},
+		{Title: "Cartoon Short", Library: "Kids Movies"},
+		{Title: "Paddington", ContentRating: "gb/PG", Library: "Movies"},
+	}
+	titles := func(cs []candidate) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.Title)
+		}
+		return out
+	}
+
+	all := titles(cands)
+	got := titles(KidsFilter{}.filter(cands))
+	if want := []string{"Toy Story", "Bluey"}; !slices.Equal(got, want) {
+		t.Errorf("default filter = %q, want %q", got, want)
+	}
+	got = titles(KidsFilter{Libraries: []string{"kids movies"}, Ratings: []string{"gb/PG"}}.filter(cands))
+	if want := []string{"Cartoon Short", "Paddington"}; !slices.Equal(got, want) {
+		t.Errorf("configured filter = %q, want %q", got, want)
+	}
+	// The other slots and the fallback share the candidates.
+	if got := titles(cands); !slices.Equal(got, all) {
+		t.Errorf("candidates after filtering = %q, want them untouched: %q", got, all)
+	}
+}
+
+func TestGetSlotRecommendations(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, rec := range []models.Recommendation{
+		{Date: day, Slot: "daily", Title: "Heat", Type: models.TypeMovie, Year: 1995},
+		{Date: day, Slot: "kids", Title: "Bluey", Type: models.TypeTVShow, Year: 2018},
+		{Date: day, Slot: "kids", Title: "Toy Story", Type: models.TypeMovie, Year: 1995},
+	} {
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kids, err := r.GetSlotRecommendations(t.Context(), day, KidsSlot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kids) != 2 || kids[0].Title != "Toy Story" {
+		t.Errorf("kids' picks = %+v, want Toy Story then Bluey", kids)
+	}
+	day1, err := r.GetRecommendationsForDate(t.Context(), day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day1) != 1 || day1[0].Title != "Heat" {
+		t.Errorf("day's picks = %+v, want Heat without the kids' picks", day1)
+	}
+}
+
+func TestKidsPicksUnlisted(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	household := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	kidsOnly := household.AddDate(0, 0, 1)
+	for _, rec := range []models.Recommendation{
+		{Date: household, Slot: "daily", Title: "Heat", Type: models.TypeMovie, Genre: "Crime", Year: 1995},
+		{Date: kidsOnly, Slot: "kids", Title: "Toy Story", Type: models.TypeMovie, Genre: "Animation", Year: 1995},
+	} {
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive, total, err := r.GetArchive(ctx, ArchiveFilter{}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(archive) != 1 || archive[0].Title != "Heat" {
+		t.Errorf("archive = %+v (total %d), want only Heat", archive, total)
+	}
+	genres, err := r.ArchiveGenres(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(genres, []string{"Crime"}) {
+		t.Errorf("archive genres = %q, want only Crime", genres)
+	}
+	dates, total, err := r.GetRecommendationDates(ctx, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(dates) != 1 || !dates[0].Equal(household) {
+		t.Errorf("dates = %v (total %d), want only %s", dates, total, household.Format("2006-01-02"))
+	}
+	days, err := r.RecommendationDaysBetween(ctx, household, kidsOnly.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || !days[0].Equal(household) {
+		t.Errorf("calendar days = %v, want only %s", days, household.Format("2006-01-02"))
+	}
+	stats, err := r.GetStats(ctx, StatsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalRecommendations != 1 {
+		t.Errorf("stats count %d picks, want 1", stats.TotalRecommendations)
+	}
+	activity, err := r.DailyActivity(ctx, household, kidsOnly.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || !activity[0].Date.Equal(household) {
+		t.Errorf("activity = %+v, want only %s", activity, household.Format("2006-01-02"))
+	}
+}
+
+func TestGenerateSlot_kidsOnlyAllowedTitles(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	date := time.Date(2026, 7, 8, 0, 0, 0, 0, time.UTC)
+
+	cartoon := models.Movie{Title: "Cartoon", Year: 2010, Genre: "Animation", Runtime: 80, PlexRatingKey: "m1", ContentRating: "G"}
+	thriller := models.Movie{Title: "Thriller", Year: 2011, Genre: "Thriller", Runtime: 110, PlexRatingKey: "m2", ContentRating: "R"}
+	for _, m := range []*models.Movie{&cartoon, &thriller} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The model asks for the thriller; it must never reach the kids' page.
+	reply := fmt.Sprintf(`{"movies":[{"id":%d,"explanation":"tense"}],"tvshows":[]}`, thriller.ID)
+	r := &Recommender{db: db, chat: fakeChatter{reply: reply}, model: "test"}
+
+	if err := r.GenerateSlot(ctx, date, KidsSlot); err != nil {
+		t.Fatalf("generate kids: %v", err)
+	}
+	recs, err := r.GetSlotRecommendations(ctx, date, KidsSlot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Title != "Cartoon" {
+		t.Fatalf("kids' picks = %+v; want only Cartoon", recs)
+	}
+}
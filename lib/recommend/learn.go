@@ -0,0 +1,169 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minWatchedForProfile is how much history LearnTasteProfile needs before its
+// summary says more than the genre line tasteProfile falls back to.
+const minWatchedForProfile = 5
+
+// historyStats is what LearnTasteProfile extracts from the library.
+type historyStats struct {
+	moviesOwned, moviesWatched int
+	showsOwned, showsWatched   int
+	rewatched                  int // movies with more than one play
+	decades                    map[int]int
+	runtimes                   []int // watched movie runtimes, minutes
+}
+
+// LearnTasteProfile analyzes the watch history (genres, decades, runtimes, and
+// how much of what's owned gets watched or rewatched) and stores a short
+// natural-language summary that generation uses as the prompt's taste profile.
+func (r *Recommender) LearnTasteProfile(ctx context.Context) error {
+	stats := historyStats{decades: map[int]int{}}
+
+	var movies []models.Movie
+	if err := r.db.WithContext(ctx).Select("year", "runtime", "view_count").Find(&movies).Error; err != nil {
+		return fmt.Errorf("profile movies: %w", err)
+	}
+	stats.moviesOwned = len(movies)
+	for _, m := range movies {
+		if m.ViewCount == 0 {
+			continue
+		}
+		stats.moviesWatched++
+		if m.ViewCount > 1 {
+			stats.rewatched++
+		}
+		if m.Year > 0 {
+			stats.decades[m.Year/10*10]++
+		}
+		if m.Runtime > 0 {
+			stats.runtimes = append(stats.runtimes, m.Runtime)
+		}
+	}
+
+	var shows []models.TVShow
+	if err := r.db.WithContext(ctx).Select("year", "view_count").Find(&shows).Error; err != nil {
+		return fmt.Errorf("profile shows: %w", err)
+	}
+	stats.showsOwned = len(shows)
+	for _, s := range shows {
+		if s.ViewCount == 0 {
+			continue
+		}
+		stats.showsWatched++
+		if s.Year > 0 {
+			stats.decades[s.Year/10*10]++
+		}
+	}
+
+	watched := stats.moviesWatched + stats.showsWatched
+	if watched < minWatchedForProfile {
+		logging.FromContext(ctx).Infow("Not enough watch history to learn a taste profile", "watched", watched)
+		return nil
+	}
+
+	genres, err := r.genreProfile(ctx)
+	if err != nil {
+		return err
+	}
+	summary := summarizeHistory(genres, stats)
+
+	profile := models.TasteProfile{Key: models.TasteProfileDefault, Summary: summary, WatchedCount: watched}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"summary", "watched_count", "updated_at"}),
+	}).Create(&profile).Error; err != nil {
+		return fmt.Errorf("save taste profile: %w", err)
+	}
+	logging.FromContext(ctx).Infow("Learned taste profile", "watched", watched, "summary", summary)
+	return nil
+}
+
+// summarizeHistory renders stats as a few plain sentences for the prompt.
+func summarizeHistory(genreLine string, s historyStats) string {
+	var parts []string
+	if genreLine != "" {
+		parts = append(parts, genreLine)
+	}
+
+	if len(s.decades) > 0 {
+		type dc struct{ decade, n int }
+		var dcs []dc
+		for d, n := range s.decades {
+			dcs = append(dcs, dc{d, n})
+		}
+		sort.Slice(dcs, func(i, j int) bool {
+			if dcs[i].n == dcs[j].n {
+				return dcs[i].decade > dcs[j].decade
+			}
+			return dcs[i].n > dcs[j].n
+		})
+		if len(dcs) > 2 {
+			dcs = dcs[:2]
+		}
+		names := make([]string, len(dcs))
+		for i, d := range dcs {
+			names[i] = fmt.Sprintf("%ds", d.decade)
+		}
+		parts = append(parts, "Watches mostly titles from the "+strings.Join(names, " and ")+".")
+	}
+
+	if len(s.runtimes) > 0 {
+		sorted := append([]int(nil), s.runtimes...)
+		sort.Ints(sorted)
+		median := sorted[len(sorted)/2]
+		switch {
+		case median < 95:
+			parts = append(parts, fmt.Sprintf("Prefers shorter movies (typically about %d minutes).", median))
+		case median > 130:
+			parts = append(parts, fmt.Sprintf("Happy to sit through long movies (typically about %d minutes).", median))
+		default:
+			parts = append(parts, fmt.Sprintf("Typically watches movies of about %d minutes.", median))
+		}
+	}
+
+	if s.moviesOwned > 0 {
+		parts = append(parts, fmt.Sprintf("Has watched %d of %d owned movies", s.moviesWatched, s.moviesOwned)+
+			rewatchClause(s.rewatched, s.moviesWatched)+".")
+	}
+	if s.showsOwned > 0 {
+		parts = append(parts, fmt.Sprintf("Has started %d of %d owned shows.", s.showsWatched, s.showsOwned))
+	}
+	return strings.Join(parts, " ")
+}
+
+func rewatchClause(rewatched, watched int) string {
+	if watched == 0 || rewatched == 0 {
+		return ""
+	}
+	pct := rewatched * 100 / watched
+	if pct >= 20 {
+		return fmt.Sprintf(" and rewatches often (%d%% seen more than once)", pct)
+	}
+	return fmt.Sprintf(" and occasionally rewatches (%d%% seen more than once)", pct)
+}
+
+// storedTasteProfile returns the learned summary, or "" when none exists yet.
+func (r *Recommender) storedTasteProfile(ctx context.Context) (string, error) {
+	var p models.TasteProfile
+	err := r.db.WithContext(ctx).First(&p, "key = ?", models.TasteProfileDefault).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("load taste profile: %w", err)
+	}
+	return p.Summary, nil
+}
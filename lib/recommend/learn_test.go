@@ -0,0 +1,57 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestSummarizeHistory(t *testing.T) {
+	got := summarizeHistory("Favorite genres, most to least: Comedy.", historyStats{
+		moviesOwned: 10, moviesWatched: 4, rewatched: 1,
+		showsOwned: 5, showsWatched: 2,
+		decades:  map[int]int{1990: 3, 2010: 2, 1980: 1},
+		runtimes: []int{85, 88, 90, 140},
+	})
+	for _, want := range []string{
+		"Comedy",
+		"1990s and 2010s",
+		"shorter movies (typically about 90 minutes)",
+		"watched 4 of 10 owned movies and rewatches often (25% seen more than once)",
+		"started 2 of 5 owned shows",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary %q missing %q", got, want)
+		}
+	}
+}
+
+func TestLearnTasteProfile_storedSummaryFeedsPrompt(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+
+	for i, title := range []string{"A", "B", "C", "D", "E", "F"} {
+		db.Create(&models.Movie{Title: title, Year: 1995, Genre: "Comedy", Runtime: 100, ViewCount: i % 3, PlexRatingKey: title})
+	}
+	// Four watched titles is below the threshold: nothing stored yet.
+	if err := r.LearnTasteProfile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := r.storedTasteProfile(ctx); s != "" {
+		t.Fatalf("stored profile with too little history: %q", s)
+	}
+
+	db.Create(&models.TVShow{Title: "S", Year: 2020, Genre: "Drama", ViewCount: 1, PlexRatingKey: "s"})
+	if err := r.LearnTasteProfile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p, err := r.tasteProfile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(p, "1990s") || !strings.Contains(p, "Comedy") {
+		t.Errorf("profile = %q; want learned summary", p)
+	}
+}
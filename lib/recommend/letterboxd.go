@@ -0,0 +1,90 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/letterboxd"
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+)
+
+// maxUnmatched bounds how many unmatched films an import lists by name.
+const maxUnmatched = 50
+
+// LetterboxdImport is what ImportLetterboxd recorded.
+type LetterboxdImport struct {
+	Films     int      `json:"films"`               // films in the export
+	Matched   int      `json:"matched"`             // of those, owned in Plex and marked watched
+	Rated     int      `json:"rated"`               // of those, with a rating recorded
+	Unmatched []string `json:"unmatched,omitempty"` // the first films not found in Plex
+}
+
+// ImportLetterboxd records the films of a Letterboxd export that are owned
+// in Plex as watched, so they aren't picked again, and their ratings
+// (stars doubled onto the 0–10 scale) as rated signals, which feed genre
+// affinity and the loved titles in the prompt. Films are matched to Plex
+// movies by title and year, allowing a year either way since the two often
+// disagree on release years. Importing the same export again is harmless.
+func (r *Recommender) ImportLetterboxd(ctx context.Context, films []letterboxd.Film) (LetterboxdImport, error) {
+	out := LetterboxdImport{Films: len(films)}
+	var movies []models.Movie
+	if err := r.db.WithContext(ctx).Select("id", "title", "year").Find(&movies).Error; err != nil {
+		return out, fmt.Errorf("load movies: %w", err)
+	}
+	owned := make(map[string]uint, len(movies))
+	for _, m := range movies {
+		owned[titleYearKey(m.Title, m.Year)] = m.ID
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, f := range films {
+			id, ok := matchFilm(owned, f)
+			if !ok {
+				if len(out.Unmatched) < maxUnmatched {
+					out.Unmatched = append(out.Unmatched, fmt.Sprintf("%s (%d)", f.Name, f.Year))
+				}
+				continue
+			}
+			key := titleYearKey(f.Name, f.Year)
+			sigs := []models.ExternalSignal{{Kind: models.SignalKindWatched, ExternalRef: "watched:" + key, Value: 1}}
+			if f.Rating > 0 {
+				sigs = append(sigs, models.ExternalSignal{Kind: models.SignalKindRated, ExternalRef: "rated:" + key, Value: f.Rating * 2})
+			}
+			for _, sig := range sigs {
+				sig.Source, sig.MovieID = models.SourceLetterboxd, &id
+				if err := upsertSignal(ctx, tx, sig); err != nil {
+					return fmt.Errorf("save letterboxd signal %s: %w", sig.ExternalRef, err)
+				}
+			}
+			out.Matched++
+			if f.Rating > 0 {
+				out.Rated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return LetterboxdImport{Films: len(films)}, err
+	}
+	logging.FromContext(ctx).Infow("Imported Letterboxd films",
+		"films", out.Films, "matched", out.Matched, "rated", out.Rated)
+	return out, nil
+}
+
+// matchFilm finds f among owned movies keyed by titleYearKey: its own year
+// first, then a year either side.
+func matchFilm(owned map[string]uint, f letterboxd.Film) (uint, bool) {
+	for _, year := range []int{f.Year, f.Year - 1, f.Year + 1} {
+		if id, ok := owned[titleYearKey(f.Name, year)]; ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func titleYearKey(title string, year int) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(strings.TrimSpace(title)), year)
+}
@@ -0,0 +1,62 @@
+package recommend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/letterboxd"
+	"github.com/icco/recommender/models"
+)
+
+func TestImportLetterboxd_marksWatchedAndRated(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	heat := models.Movie{Title: "Heat", Year: 1995, PlexRatingKey: "m1", Genre: "Crime"}
+	ran := models.Movie{Title: "Ran", Year: 1985, PlexRatingKey: "m2", Genre: "Drama"}
+	for _, m := range []*models.Movie{&heat, &ran} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := testRecommender(db)
+	films := []letterboxd.Film{
+		{Name: "heat", Year: 1995, Rating: 4.5},
+		{Name: "Ran", Year: 1986}, // Letterboxd and Plex disagree by a year
+		{Name: "Alien", Year: 1979, Rating: 5},
+	}
+	res, err := r.ImportLetterboxd(ctx, films)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Films != 3 || res.Matched != 2 || res.Rated != 1 || len(res.Unmatched) != 1 || res.Unmatched[0] != "Alien (1979)" {
+		t.Errorf("ImportLetterboxd = %+v, want 2 of 3 matched, 1 rated, Alien unmatched", res)
+	}
+	// A second import of the same export adds nothing.
+	if _, err := r.ImportLetterboxd(ctx, films); err != nil {
+		t.Fatal(err)
+	}
+
+	var sigs []models.ExternalSignal
+	if err := db.Where("source = ?", models.SourceLetterboxd).Order("kind, external_ref").Find(&sigs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 3 {
+		t.Fatalf("signals = %+v, want a rating and two watched", sigs)
+	}
+	if s := sigs[0]; s.Kind != models.SignalKindRated || *s.MovieID != heat.ID || s.Value != 9 {
+		t.Errorf("rated signal = %+v, want Heat at 9/10", s)
+	}
+
+	movies, _, err := r.loadCandidates(ctx, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movies) != 0 {
+		t.Errorf("films watched on Letterboxd shouldn't be fresh picks: %+v", movies)
+	}
+	if loved, err := r.lovedTitles(ctx); err != nil || loved == "" {
+		t.Errorf("lovedTitles = %q, %v; want Heat named", loved, err)
+	}
+}
@@ -4,17 +4,40 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/icco/recommender/lib/errreport"
 	"google.golang.org/genai"
 )
 
 // Chatter is the minimal LLM surface the recommender needs: given a system and
 // user prompt plus a JSON response schema, return the model's JSON text.
-// Implemented by GeminiChatter; faked in tests.
+// It is the provider abstraction: implemented by GeminiChatter, and by
+// OpenAIChatter, AnthropicChatter, and OllamaChatter in providers.go, which
+// convert the schema with jsonSchema; faked in tests. LLM_PROVIDER picks one.
 type Chatter interface {
 	Complete(ctx context.Context, system, user string, schema *genai.Schema) (string, error)
 }
 
+// Streamer is a Chatter that can also hand over the JSON text as the model
+// writes it, calling fn with each chunk, and returns the whole text.
+type Streamer interface {
+	Stream(ctx context.Context, system, user string, schema *genai.Schema, fn func(chunk string)) (string, error)
+}
+
+// streamOrComplete streams from c when it is a Streamer; otherwise it calls
+// Complete and hands fn the whole text at once.
+func streamOrComplete(ctx context.Context, c Chatter, system, user string, schema *genai.Schema, fn func(string)) (string, error) {
+	if s, ok := c.(Streamer); ok {
+		return s.Stream(ctx, system, user, schema, fn)
+	}
+	raw, err := c.Complete(ctx, system, user, schema)
+	if err == nil {
+		fn(raw)
+	}
+	return raw, err
+}
+
 // GeminiChatter calls Gemini on Vertex AI via the unified google.golang.org/genai SDK.
 type GeminiChatter struct {
 	client *genai.Client
@@ -44,7 +67,30 @@ func (g *GeminiChatter) Complete(ctx context.Context, system, user string, schem
 	}
 	resp, err := g.client.Models.GenerateContent(ctx, g.model, genai.Text(user), cfg)
 	if err != nil {
-		return "", fmt.Errorf("gemini generate: %w", err)
+		err = fmt.Errorf("gemini generate: %w", err)
+		errreport.CaptureError(ctx, err, "provider", "gemini", "model", g.model)
+		return "", err
 	}
 	return resp.Text(), nil
 }
+
+// Stream is Complete, calling fn with each chunk of text as it arrives.
+func (g *GeminiChatter) Stream(ctx context.Context, system, user string, schema *genai.Schema, fn func(chunk string)) (string, error) {
+	cfg := &genai.GenerateContentConfig{
+		ResponseMIMEType:  "application/json",
+		ResponseSchema:    schema,
+		SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
+	}
+	var b strings.Builder
+	for resp, err := range g.client.Models.GenerateContentStream(ctx, g.model, genai.Text(user), cfg) {
+		if err != nil {
+			err = fmt.Errorf("gemini stream: %w", err)
+			errreport.CaptureError(ctx, err, "provider", "gemini", "model", g.model)
+			return "", err
+		}
+		chunk := resp.Text()
+		b.WriteString(chunk)
+		fn(chunk)
+	}
+	return b.String(), nil
+}
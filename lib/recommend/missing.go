@@ -0,0 +1,86 @@
+package recommend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var meter = otel.Meter("github.com/icco/recommender/lib/recommend")
+
+// DefaultMissingDaysWindow is how many days back MissingDays looks when
+// MISSING_DAYS_WINDOW is unset.
+const DefaultMissingDaysWindow = 14
+
+// MissingDays returns the days before today, within the configured window,
+// that have no successful daily generation run, oldest first. Days before
+// the first run ever recorded don't count, so a new install isn't flagged,
+// and neither do blacked-out days.
+// It returns nil when the check is disabled.
+func (r *Recommender) MissingDays(ctx context.Context) ([]time.Time, error) {
+	cfg := r.currentSettings()
+	window := cfg.MissingDaysWindow
+	if window <= 0 {
+		return nil, nil
+	}
+	today, _ := recommendationUTCDayRange(time.Now())
+	from := today.AddDate(0, 0, -window)
+
+	var first sql.NullTime
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{}).
+		Select(`MIN("date")`).Row().Scan(&first); err != nil {
+		return nil, fmt.Errorf("failed to get first run: %w", err)
+	}
+	if !first.Valid {
+		return nil, nil
+	}
+	if start, _ := recommendationUTCDayRange(first.Time); start.After(from) {
+		from = start
+	}
+
+	var ran []time.Time
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{}).
+		Where(`"date" >= ? AND "date" < ? AND context = ? AND status = ?`, from, today, models.RunContextDaily, models.RunStatusOK).
+		Pluck("date", &ran).Error; err != nil {
+		return nil, fmt.Errorf("failed to get runs: %w", err)
+	}
+	done := make(map[string]bool, len(ran))
+	for _, d := range ran {
+		done[d.UTC().Format("2006-01-02")] = true
+	}
+	var missing []time.Time
+	for d := from; d.Before(today); d = d.AddDate(0, 0, 1) {
+		if _, paused := cfg.Blackouts.Find(d); !paused && !done[d.Format("2006-01-02")] {
+			missing = append(missing, d)
+		}
+	}
+	return missing, nil
+}
+
+// RegisterMissingDaysMetric exports len(MissingDays) as a gauge for
+// /metrics, so alerting rules can fire on days generation skipped.
+func (r *Recommender) RegisterMissingDaysMetric() {
+	gauge, err := meter.Int64ObservableGauge("recommend.missing_days",
+		metric.WithDescription("days in the missing-days window without a successful daily generation run"))
+	if err != nil {
+		return
+	}
+	_, _ = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		missing, err := r.MissingDays(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to count missing days", zap.Error(err))
+			return nil
+		}
+		o.ObserveInt64(gauge, int64(len(missing)))
+		return nil
+	}, gauge)
+}
@@ -0,0 +1,43 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestMissingDays(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := func(n int) time.Time { return today.AddDate(0, 0, n) }
+
+	if got, err := r.MissingDays(t.Context()); err != nil || got != nil {
+		t.Fatalf("disabled check = %v, %v", got, err)
+	}
+	r.ApplySettings(Settings{MissingDaysWindow: 7})
+	if got, err := r.MissingDays(t.Context()); err != nil || len(got) != 0 {
+		t.Fatalf("no runs yet = %v, %v; want none flagged", got, err)
+	}
+
+	// Runs began 5 days ago; 3 days ago failed, 2 days ago only ran a slot.
+	for _, run := range []models.GenerationRun{
+		{Date: day(-5), Context: models.RunContextDaily, Status: models.RunStatusOK},
+		{Date: day(-4), Context: models.RunContextDaily, Status: models.RunStatusOK},
+		{Date: day(-3), Context: models.RunContextDaily, Status: models.RunStatusError},
+		{Date: day(-2), Context: "late", Status: models.RunStatusOK},
+		{Date: day(-1), Context: models.RunContextDaily, Status: models.RunStatusOK},
+	} {
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := r.MissingDays(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Equal(day(-3)) || !got[1].Equal(day(-2)) {
+		t.Errorf("missing = %v, want the last 3 and 2 days", got)
+	}
+}
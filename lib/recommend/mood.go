@@ -0,0 +1,165 @@
+package recommend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+// Mood is a viewing mood the home page can re-rank a day's picks for.
+type Mood string
+
+// Moods offered by the home page picker.
+const (
+	MoodCozy       Mood = "cozy"
+	MoodIntense    Mood = "intense"
+	MoodFunny      Mood = "funny"
+	MoodBackground Mood = "background"
+)
+
+// Moods lists the picker's moods in display order.
+var Moods = []Mood{MoodCozy, MoodIntense, MoodFunny, MoodBackground}
+
+// ParseMood returns the Mood named s, if it is one of Moods.
+func ParseMood(s string) (Mood, bool) {
+	m := Mood(strings.ToLower(strings.TrimSpace(s)))
+	return m, slices.Contains(Moods, m)
+}
+
+// moodGenres weights genres per mood; unlisted genres score 0.
+var moodGenres = map[Mood]map[string]float64{
+	MoodCozy: {
+		"Comedy": 1, "Romance": 1, "Family": 1, "Animation": 1, "Music": 0.5, "Fantasy": 0.5,
+		"Horror": -1, "Thriller": -0.5, "War": -1, "Crime": -0.5,
+	},
+	MoodIntense: {
+		"Thriller": 1, "Action": 1, "Horror": 1, "Crime": 0.5, "War": 0.5, "Mystery": 0.5, "Science Fiction": 0.5,
+		"Family": -1, "Romance": -0.5, "Animation": -0.5,
+	},
+	MoodFunny: {
+		"Comedy": 2, "Animation": 0.5, "Family": 0.5,
+		"Horror": -0.5, "War": -1, "Drama": -0.5,
+	},
+	MoodBackground: {
+		"Comedy": 1, "Documentary": 1, "Reality": 1, "Animation": 0.5, "Game Show": 1,
+		"Mystery": -1, "Thriller": -1, "Drama": -0.5,
+	},
+}
+
+// moodScore rates how well rec fits mood from its genres and length.
+func moodScore(rec models.Recommendation, mood Mood) float64 {
+	weights := moodGenres[mood]
+	score := 0.0
+	for _, g := range splitGenres(rec.Genre) {
+		score += weights[g]
+	}
+	switch mood {
+	case MoodBackground:
+		// Episodic TV is the classic background watch.
+		if rec.Type == models.TypeTVShow {
+			score++
+		}
+	case MoodCozy:
+		if rec.Type == models.TypeMovie && rec.Runtime > 0 && rec.Runtime <= 100 {
+			score += 0.5
+		}
+	}
+	return score
+}
+
+// RankForMood returns recs reordered best-fit first for mood using genre and
+// runtime metadata. The sort is stable, so ties keep the day's order.
+func RankForMood(recs []models.Recommendation, mood Mood) []models.Recommendation {
+	out := slices.Clone(recs)
+	sort.SliceStable(out, func(i, j int) bool {
+		return moodScore(out[i], mood) > moodScore(out[j], mood)
+	})
+	return out
+}
+
+// Defaults for the LLM mood re-rank cache, which remembers orders per day and
+// mood so reloading the page doesn't spend another model call.
+const (
+	DefaultMoodCacheSize = 64
+	DefaultMoodCacheTTL  = 24 * time.Hour
+)
+
+// RerankForMood orders recs for mood. With useLLM it asks the model for the
+// order (once per day and mood; the result is cached), falling back to
+// RankForMood if the call fails or the daily LLM cap is spent.
+func (r *Recommender) RerankForMood(ctx context.Context, recs []models.Recommendation, mood Mood, useLLM bool) []models.Recommendation {
+	ranked := RankForMood(recs, mood)
+	if !useLLM || !r.LLMEnabled() || len(recs) < 2 {
+		return ranked
+	}
+	day := recs[0].Date.UTC().Format(time.DateOnly)
+	key := day + "/" + string(mood)
+	order, ok := r.moods.Get(key)
+	if !ok {
+		var err error
+		order, err = r.llmMoodOrder(ctx, ranked, mood)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("LLM mood re-rank failed; using heuristic order", "mood", mood, zap.Error(err))
+			return ranked
+		}
+		r.moods.Add(key, order)
+	}
+	return applyOrder(ranked, order)
+}
+
+// llmMoodOrder asks the model to order recs for mood and returns their IDs.
+func (r *Recommender) llmMoodOrder(ctx context.Context, recs []models.Recommendation, mood Mood) ([]uint, error) {
+	var b strings.Builder
+	for _, rec := range recs {
+		fmt.Fprintf(&b, "%d | %s (%d) | %s | %s\n", rec.ID, rec.Title, rec.Year, rec.Type, rec.Genre)
+	}
+	system := "You re-order a short list of already-chosen titles for the viewer's current mood. Return every id exactly once, best fit first."
+	user := fmt.Sprintf("Mood: %s\n\nTitles (id | title | type | genres):\n%s", mood, b.String())
+	schema := &genai.Schema{
+		Type:       genai.TypeObject,
+		Properties: map[string]*genai.Schema{"ids": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeInteger}}},
+		Required:   []string{"ids"},
+	}
+	raw, err := r.chat.Complete(ctx, system, user, schema)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &resp); err != nil {
+		return nil, fmt.Errorf("parse mood order: %w", err)
+	}
+	return resp.IDs, nil
+}
+
+// applyOrder puts recs listed in order first (in that order); the rest keep
+// their relative position after them. Unknown and repeated IDs are ignored.
+func applyOrder(recs []models.Recommendation, order []uint) []models.Recommendation {
+	byID := make(map[uint]models.Recommendation, len(recs))
+	for _, rec := range recs {
+		byID[rec.ID] = rec
+	}
+	out := make([]models.Recommendation, 0, len(recs))
+	for _, id := range order {
+		if rec, ok := byID[id]; ok {
+			out = append(out, rec)
+			delete(byID, id)
+		}
+	}
+	for _, rec := range recs {
+		if _, ok := byID[rec.ID]; ok {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
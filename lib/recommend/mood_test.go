@@ -0,0 +1,51 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestRankForMood(t *testing.T) {
+	recs := []models.Recommendation{
+		{ID: 1, Title: "Slasher", Type: models.TypeMovie, Genre: "Horror, Thriller"},
+		{ID: 2, Title: "Sitcom", Type: models.TypeTVShow, Genre: "Comedy"},
+		{ID: 3, Title: "Romcom", Type: models.TypeMovie, Genre: "Comedy, Romance", Runtime: 95},
+	}
+	for _, tc := range []struct {
+		mood  Mood
+		first uint
+	}{
+		{MoodCozy, 3},
+		{MoodIntense, 1},
+		{MoodFunny, 2}, // ties with the romcom; stable sort keeps day order
+		{MoodBackground, 2},
+	} {
+		if got := RankForMood(recs, tc.mood)[0].ID; got != tc.first {
+			t.Errorf("%s: first = %d, want %d", tc.mood, got, tc.first)
+		}
+	}
+	if recs[0].ID != 1 {
+		t.Error("RankForMood must not reorder its input")
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	recs := []models.Recommendation{{ID: 1}, {ID: 2}, {ID: 3}}
+	got := applyOrder(recs, []uint{3, 99, 3, 1})
+	want := []uint{3, 1, 2}
+	for i, rec := range got {
+		if rec.ID != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseMood(t *testing.T) {
+	if m, ok := ParseMood(" Cozy "); !ok || m != MoodCozy {
+		t.Errorf("ParseMood(Cozy) = %q, %v", m, ok)
+	}
+	if _, ok := ParseMood("sleepy"); ok {
+		t.Error("unknown mood accepted")
+	}
+}
@@ -0,0 +1,88 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxDateNoteLen bounds a DateNote's body, in bytes.
+	MaxDateNoteLen = 2000
+	// noteContextDays is how far back notes are fed to the prompt, and
+	// noteContextMax how many of them; each is cut to noteContextLen bytes
+	// so notes can't crowd out the rest of the context.
+	noteContextDays = 14
+	noteContextMax  = 5
+	noteContextLen  = 200
+)
+
+// DateNote returns the note attached to date's UTC day, or "" if none.
+func (r *Recommender) DateNote(ctx context.Context, date time.Time) (string, error) {
+	day, _ := recommendationUTCDayRange(date)
+	var n models.DateNote
+	err := r.db.WithContext(ctx).Where(`"date" = ?`, day).Take(&n).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get note: %w", err)
+	}
+	return n.Body, nil
+}
+
+// SetDateNote attaches body to date's UTC day, replacing any earlier note.
+// An empty body removes the note.
+func (r *Recommender) SetDateNote(ctx context.Context, date time.Time, body string) error {
+	day, _ := recommendationUTCDayRange(date)
+	body = strings.TrimSpace(body)
+	if body == "" {
+		if err := r.db.WithContext(ctx).Where(`"date" = ?`, day).Delete(&models.DateNote{}).Error; err != nil {
+			return fmt.Errorf("delete note: %w", err)
+		}
+		return nil
+	}
+	if len(body) > MaxDateNoteLen {
+		return fmt.Errorf("note is longer than %d bytes", MaxDateNoteLen)
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"body", "updated_at"}),
+	}).Create(&models.DateNote{Date: day, Body: body}).Error; err != nil {
+		return fmt.Errorf("save note: %w", err)
+	}
+	return nil
+}
+
+// notesContext describes the notes from the days before date for the
+// prompt, newest first, so the model hears how recent picks landed.
+func (r *Recommender) notesContext(ctx context.Context, date time.Time) string {
+	var notes []models.DateNote
+	if err := r.db.WithContext(ctx).
+		Where(`"date" >= ? AND "date" < ?`, date.AddDate(0, 0, -noteContextDays), date).
+		Order(`"date" DESC`).Limit(noteContextMax).Find(&notes).Error; err != nil {
+		logging.FromContext(ctx).Warnw("notes lookup failed; continuing without", zap.Error(err))
+		return ""
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Recent viewing notes from the household:")
+	for _, n := range notes {
+		body := strings.Join(strings.Fields(n.Body), " ")
+		if len(body) > noteContextLen {
+			body = strings.ToValidUTF8(body[:noteContextLen], "") + "…"
+		}
+		fmt.Fprintf(&b, "\n- %s: %s", n.Date.UTC().Format("Jan 2"), body)
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetDateNote(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 10, 14, 21, 30, 0, 0, time.UTC)
+
+	if err := r.SetDateNote(ctx, day, "  watched the thriller  "); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetDateNote(ctx, day, "watched the thriller, loved it"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.DateNote(ctx, day.Truncate(24*time.Hour)); err != nil || got != "watched the thriller, loved it" {
+		t.Errorf("DateNote = %q, %v; want the replacement", got, err)
+	}
+	if err := r.SetDateNote(ctx, day, strings.Repeat("x", MaxDateNoteLen+1)); err == nil {
+		t.Error("an overlong note should be refused")
+	}
+
+	got := r.notesContext(ctx, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	if !strings.Contains(got, "Oct 14: watched the thriller, loved it") {
+		t.Errorf("notesContext = %q, want the Oct 14 note", got)
+	}
+	if got := r.notesContext(ctx, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)); got != "" {
+		t.Errorf("a day's own note shouldn't feed its prompt: %q", got)
+	}
+
+	if err := r.SetDateNote(ctx, day, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.DateNote(ctx, day); err != nil || got != "" {
+		t.Errorf("after clearing, DateNote = %q, %v", got, err)
+	}
+}
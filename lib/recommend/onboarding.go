@@ -0,0 +1,140 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OnboardingMoods are the moods offered by the cold-start quiz.
+var OnboardingMoods = []string{
+	"Light and funny",
+	"Edge-of-your-seat",
+	"Thought-provoking",
+	"Heartwarming",
+	"Dark and gritty",
+	"Epic and sweeping",
+	"Cozy comfort",
+	"Weird and offbeat",
+}
+
+// maxOnboardingFavorites bounds how many picked films are stored and prompted.
+const maxOnboardingFavorites = 10
+
+// OnboardingFilm is one tile in the quiz's favorite-film grid.
+type OnboardingFilm struct {
+	Title     string
+	Year      int
+	PosterURL string
+}
+
+// Label is the film as submitted back by the quiz form, e.g. "Heat (1995)".
+func (f OnboardingFilm) Label() string {
+	if f.Year > 0 {
+		return f.Title + " (" + strconv.Itoa(f.Year) + ")"
+	}
+	return f.Title
+}
+
+// OnboardingFilms returns currently popular films from TMDb for the quiz grid,
+// or none when TMDb is disabled.
+func (r *Recommender) OnboardingFilms(ctx context.Context) ([]OnboardingFilm, error) {
+	if !r.TMDbEnabled() {
+		return nil, nil
+	}
+	res, err := r.tmdb.PopularMovies(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("tmdb popular: %w", err)
+	}
+	films := make([]OnboardingFilm, 0, len(res.Results))
+	for _, m := range res.Results {
+		year := 0
+		if len(m.ReleaseDate) >= 4 {
+			year, _ = strconv.Atoi(m.ReleaseDate[:4])
+		}
+		films = append(films, OnboardingFilm{Title: m.Title, Year: year, PosterURL: r.tmdb.GetPosterURL(m.PosterPath)})
+	}
+	return films, nil
+}
+
+// NeedsOnboarding reports whether there is too little history for a learned
+// profile and the quiz hasn't been answered, so the UI should offer it.
+func (r *Recommender) NeedsOnboarding(ctx context.Context) (bool, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&models.TasteProfile{}).
+		Where("key IN ?", []string{models.TasteProfileDefault, models.TasteProfileOnboarding}).
+		Count(&n).Error; err != nil {
+		return false, fmt.Errorf("check taste profiles: %w", err)
+	}
+	return n == 0, nil
+}
+
+// SaveOnboarding stores the quiz answers as the onboarding taste profile,
+// replacing earlier answers. Favorites are free text from the form and are
+// normalized and capped; moods outside OnboardingMoods are dropped.
+func (r *Recommender) SaveOnboarding(ctx context.Context, favorites, moods []string) error {
+	summary := onboardingSummary(favorites, moods)
+	if summary == "" {
+		return errors.New("pick at least one film or mood")
+	}
+	profile := models.TasteProfile{Key: models.TasteProfileOnboarding, Summary: summary}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"summary", "updated_at"}),
+	}).Create(&profile).Error; err != nil {
+		return fmt.Errorf("save onboarding: %w", err)
+	}
+	return nil
+}
+
+// onboardingSummary renders quiz answers as prompt sentences.
+func onboardingSummary(favorites, moods []string) string {
+	var favs []string
+	for _, f := range favorites {
+		// Collapse whitespace (including newlines) so a submitted value can't
+		// break out of its line in the prompt.
+		f = strings.Join(strings.Fields(f), " ")
+		if f == "" || len(f) > 200 || slices.Contains(favs, f) {
+			continue
+		}
+		favs = append(favs, f)
+		if len(favs) == maxOnboardingFavorites {
+			break
+		}
+	}
+	var picked []string
+	for _, m := range moods {
+		if slices.Contains(OnboardingMoods, m) && !slices.Contains(picked, m) {
+			picked = append(picked, m)
+		}
+	}
+
+	var parts []string
+	if len(favs) > 0 {
+		parts = append(parts, "Says they love: "+strings.Join(favs, ", ")+".")
+	}
+	if len(picked) > 0 {
+		parts = append(parts, "Enjoys moods: "+strings.ToLower(strings.Join(picked, ", "))+".")
+	}
+	return strings.Join(parts, " ")
+}
+
+// onboardingProfile returns the stored quiz summary, or "" when unanswered.
+func (r *Recommender) onboardingProfile(ctx context.Context) (string, error) {
+	var p models.TasteProfile
+	err := r.db.WithContext(ctx).First(&p, "key = ?", models.TasteProfileOnboarding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("load onboarding profile: %w", err)
+	}
+	return p.Summary, nil
+}
@@ -0,0 +1,175 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm/clause"
+)
+
+// Errors AddPin returns for a title it can't pin.
+var (
+	ErrPinNotFound  = errors.New("no library title matches")
+	ErrPinAmbiguous = errors.New("several library titles match; add the year")
+	ErrPinExists    = errors.New("title is already pinned to that day")
+)
+
+// AddPin pins the library title of type typ (models.TypeMovie or
+// models.TypeTVShow) matching title, case-insensitively, to date. year, when
+// non-zero, picks between same-titled items. note becomes the pick's
+// explanation. A day whose picks are already generated only shows the pin
+// once it is regenerated.
+func (r *Recommender) AddPin(ctx context.Context, date time.Time, typ, title string, year int, note string) (models.Pin, error) {
+	pin := models.Pin{Date: date.UTC().Truncate(24 * time.Hour), Type: typ, Note: note}
+	q := r.db.WithContext(ctx).Where("LOWER(title) = LOWER(?)", strings.TrimSpace(title))
+	if year != 0 {
+		q = q.Where("year = ?", year)
+	}
+	switch typ {
+	case models.TypeMovie:
+		var ms []models.Movie
+		if err := q.Limit(2).Find(&ms).Error; err != nil {
+			return models.Pin{}, fmt.Errorf("find movie: %w", err)
+		}
+		if err := pinMatch(len(ms)); err != nil {
+			return models.Pin{}, err
+		}
+		pin.Title, pin.Year, pin.MovieID = ms[0].Title, ms[0].Year, &ms[0].ID
+	case models.TypeTVShow:
+		var ss []models.TVShow
+		if err := q.Limit(2).Find(&ss).Error; err != nil {
+			return models.Pin{}, fmt.Errorf("find tv show: %w", err)
+		}
+		if err := pinMatch(len(ss)); err != nil {
+			return models.Pin{}, err
+		}
+		pin.Title, pin.Year, pin.TVShowID = ss[0].Title, ss[0].Year, &ss[0].ID
+	default:
+		return models.Pin{}, fmt.Errorf("unknown type %q", typ)
+	}
+	return r.createPin(ctx, pin)
+}
+
+// PinTitle pins the library movie or TV show id to date, like AddPin but
+// for a title already chosen, such as a chat suggestion. It returns
+// ErrTitleNotFound for an unknown title.
+func (r *Recommender) PinTitle(ctx context.Context, date time.Time, typ string, id uint, note string) (models.Pin, error) {
+	seed, err := r.similarSeed(ctx, typ, id, date)
+	if err != nil {
+		return models.Pin{}, err
+	}
+	pin := models.Pin{Date: date.UTC().Truncate(24 * time.Hour), Type: typ, Title: seed.Title, Year: seed.Year, Note: note}
+	if typ == models.TypeMovie {
+		pin.MovieID = &seed.ID
+	} else {
+		pin.TVShowID = &seed.ID
+	}
+	return r.createPin(ctx, pin)
+}
+
+func (r *Recommender) createPin(ctx context.Context, pin models.Pin) (models.Pin, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&pin)
+	if res.Error != nil {
+		return models.Pin{}, fmt.Errorf("create pin: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.Pin{}, ErrPinExists
+	}
+	return pin, nil
+}
+
+func pinMatch(n int) error {
+	switch n {
+	case 0:
+		return ErrPinNotFound
+	case 1:
+		return nil
+	}
+	return ErrPinAmbiguous
+}
+
+// Pins returns the pins for from and later days, soonest first.
+func (r *Recommender) Pins(ctx context.Context, from time.Time) ([]models.Pin, error) {
+	var pins []models.Pin
+	if err := r.db.WithContext(ctx).
+		Where(`"date" >= ?`, from.UTC().Truncate(24*time.Hour)).
+		Order(`"date", id`).Find(&pins).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pins: %w", err)
+	}
+	return pins, nil
+}
+
+// DeletePin removes the pin with id, returning it and whether it existed.
+func (r *Recommender) DeletePin(ctx context.Context, id uint) (models.Pin, bool, error) {
+	var pin models.Pin
+	res := r.db.WithContext(ctx).Clauses(clause.Returning{}).Where("id = ?", id).Delete(&pin)
+	if res.Error != nil {
+		return models.Pin{}, false, fmt.Errorf("delete pin: %w", res.Error)
+	}
+	return pin, res.RowsAffected > 0, nil
+}
+
+// pinnedRecs returns date's pins as Pinned recommendations, built from the
+// library rows so they bypass the candidate filters (recently recommended,
+// already watched) that would otherwise hide them. Pins whose title has
+// left the library are skipped.
+func (r *Recommender) pinnedRecs(ctx context.Context, date time.Time) ([]models.Recommendation, error) {
+	var pins []models.Pin
+	if err := r.db.WithContext(ctx).Where(`"date" = ?`, date).Order("id").Find(&pins).Error; err != nil {
+		return nil, fmt.Errorf("load pins: %w", err)
+	}
+	var recs []models.Recommendation
+	for _, p := range pins {
+		var c candidate
+		switch {
+		case p.MovieID != nil:
+			var m models.Movie
+			if err := r.db.WithContext(ctx).Limit(1).Find(&m, *p.MovieID).Error; err != nil {
+				return nil, fmt.Errorf("load pinned movie: %w", err)
+			}
+			if m.ID == 0 {
+				continue
+			}
+			c = candidate{
+				ID: m.ID, Type: models.TypeMovie, Title: m.Title, Year: m.Year, Rating: m.Rating,
+				Genres: splitGenres(m.Genre), PosterURL: m.PosterURL, Runtime: m.Runtime, TMDbID: m.TMDbID,
+			}
+		case p.TVShowID != nil:
+			var s models.TVShow
+			if err := r.db.WithContext(ctx).Limit(1).Find(&s, *p.TVShowID).Error; err != nil {
+				return nil, fmt.Errorf("load pinned tv show: %w", err)
+			}
+			if s.ID == 0 {
+				continue
+			}
+			c = candidate{
+				ID: s.ID, Type: models.TypeTVShow, Title: s.Title, Year: s.Year, Rating: s.Rating,
+				Genres: splitGenres(s.Genre), PosterURL: s.PosterURL, Seasons: s.Seasons, EpisodeRuntime: s.EpisodeRuntime,
+				TMDbID: s.TMDbID,
+			}
+		default:
+			continue
+		}
+		rec := toRec(c, p.Note, date)
+		rec.Pinned = true
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// withoutPinned drops pinned titles from cands so they aren't picked twice.
+func withoutPinned(cands []candidate, pinned []models.Recommendation) []candidate {
+	if len(pinned) == 0 {
+		return cands
+	}
+	keys := make(map[string]bool, len(pinned))
+	for _, rec := range pinned {
+		keys[recKey(rec)] = true
+	}
+	return slices.DeleteFunc(cands, func(c candidate) bool { return keys[candKey(c.Type, c.ID)] })
+}
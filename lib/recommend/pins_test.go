@@ -0,0 +1,96 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestAddPin_resolvesLibraryTitle(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)
+	for i, year := range []int{1988, 2013} {
+		m := models.Movie{Title: "Die Hard", Year: year, PlexRatingKey: fmt.Sprintf("dh%d", i)}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := r.AddPin(ctx, day, models.TypeMovie, "die hard", 0, ""); !errors.Is(err, ErrPinAmbiguous) {
+		t.Errorf("two matches: err = %v, want ErrPinAmbiguous", err)
+	}
+	if _, err := r.AddPin(ctx, day, models.TypeMovie, "Die Harder", 0, ""); !errors.Is(err, ErrPinNotFound) {
+		t.Errorf("no match: err = %v, want ErrPinNotFound", err)
+	}
+	pin, err := r.AddPin(ctx, day, models.TypeMovie, "die hard", 1988, "It's a Christmas movie.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin.Title != "Die Hard" || pin.Year != 1988 || pin.MovieID == nil {
+		t.Errorf("pin = %+v, want the 1988 library movie", pin)
+	}
+	if _, err := r.AddPin(ctx, day, models.TypeMovie, "Die Hard", 1988, ""); !errors.Is(err, ErrPinExists) {
+		t.Errorf("repeat: err = %v, want ErrPinExists", err)
+	}
+
+	pins, err := r.Pins(ctx, day)
+	if err != nil || len(pins) != 1 {
+		t.Fatalf("Pins = %v, %v; want the one pin", pins, err)
+	}
+	if old, found, err := r.DeletePin(ctx, pin.ID); err != nil || !found || old.Title != pin.Title {
+		t.Fatalf("DeletePin = %+v, %v, %v", old, found, err)
+	}
+	if _, found, _ := r.DeletePin(ctx, pin.ID); found {
+		t.Error("deleting twice should report not found")
+	}
+}
+
+func TestGenerateRecommendations_includesPins(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	date := time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)
+	for i, genre := range []string{"Comedy", "Action", "Drama", "Horror", "Western"} {
+		m := models.Movie{Title: genre + " film", Year: 2000 + i, Rating: new(8.0), Genre: genre, PlexRatingKey: fmt.Sprintf("m%d", i)}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	pinned := models.Movie{Title: "Die Hard", Year: 1988, Rating: new(8.2), Genre: "Action", PlexRatingKey: "dh"}
+	if err := db.Create(&pinned).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := &Recommender{db: db, model: FallbackModel}
+	if _, err := r.AddPin(ctx, date, models.TypeMovie, "Die Hard", 0, "It's a Christmas movie."); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.GenerateRecommendations(ctx, date); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	movies, pins := 0, 0
+	for _, rec := range recs {
+		if rec.Type == models.TypeMovie {
+			movies++
+		}
+		if rec.Pinned {
+			pins++
+			if rec.Title != "Die Hard" || rec.Explanation != "It's a Christmas movie." {
+				t.Errorf("pinned rec = %q (%q), want Die Hard with the pin's note", rec.Title, rec.Explanation)
+			}
+		}
+	}
+	if pins != 1 {
+		t.Errorf("got %d pinned recs, want 1", pins)
+	}
+	if movies != targetMovies {
+		t.Errorf("got %d movies, want the pin to count against the %d movie slots", movies, targetMovies)
+	}
+}
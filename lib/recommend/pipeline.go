@@ -0,0 +1,47 @@
+package recommend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// PipelineStatus is the state of daily generation, for health monitoring.
+type PipelineStatus struct {
+	LastGeneratedDate time.Time // newest day with a successful daily run; zero if none
+	LastGeneratedAt   time.Time // when a daily run last succeeded; zero if never
+	CandidateMovies   int       // titles the next daily run could pick from
+	CandidateTVShows  int
+}
+
+// PipelineStatus reports when daily generation last succeeded and how many
+// titles pass the candidate rules and quality minimums for today's set.
+func (r *Recommender) PipelineStatus(ctx context.Context) (PipelineStatus, error) {
+	var out PipelineStatus
+	var last struct {
+		Date sql.NullTime
+		At   sql.NullTime
+	}
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{}).
+		Select(`MAX("date") AS date, MAX(updated_at) AS at`).
+		Where("context = ? AND status = ?", models.RunContextDaily, models.RunStatusOK).
+		Scan(&last).Error; err != nil {
+		return out, fmt.Errorf("last generation: %w", err)
+	}
+	out.LastGeneratedDate, out.LastGeneratedAt = last.Date.Time, last.At.Time
+
+	today, _ := recommendationUTCDayRange(time.Now())
+	movies, tvshows, err := r.loadCandidates(ctx, today)
+	if err != nil {
+		return out, err
+	}
+	if q := r.currentSettings().Quality; q.enabled() {
+		dropped := map[string]int{}
+		movies, tvshows = q.filter(movies, dropped), q.filter(tvshows, dropped)
+	}
+	out.CandidateMovies, out.CandidateTVShows = len(movies), len(tvshows)
+	return out, nil
+}
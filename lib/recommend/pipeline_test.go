@@ -0,0 +1,50 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestPipelineStatus(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	if st, err := r.PipelineStatus(ctx); err != nil || !st.LastGeneratedAt.IsZero() || st.CandidateMovies != 0 {
+		t.Fatalf("empty PipelineStatus = %+v, %v; want nothing yet", st, err)
+	}
+
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	ok := day.Add(6 * time.Hour)
+	for _, run := range []models.GenerationRun{
+		{Date: day, Context: models.RunContextDaily, Status: models.RunStatusOK, UpdatedAt: ok},
+		{Date: day.AddDate(0, 0, 1), Context: models.RunContextDaily, Status: models.RunStatusError, UpdatedAt: ok.Add(24 * time.Hour)},
+		{Date: day.AddDate(0, 0, 1), Context: "late", Status: models.RunStatusOK, UpdatedAt: ok.Add(24 * time.Hour)},
+	} {
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	high, low := 8.0, 5.0
+	for _, m := range []models.Movie{
+		{Title: "Heat", Year: 1995, PlexRatingKey: "m1", Rating: &high},
+		{Title: "Ran", Year: 1985, PlexRatingKey: "m2", Rating: &low},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.ApplySettings(Settings{Quality: QualityFilter{MinRating: 7}})
+
+	st, err := r.PipelineStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.LastGeneratedDate.Equal(day) || !st.LastGeneratedAt.Equal(ok) {
+		t.Errorf("last generation = %v at %v, want %v at %v", st.LastGeneratedDate, st.LastGeneratedAt, day, ok)
+	}
+	if st.CandidateMovies != 1 || st.CandidateTVShows != 0 {
+		t.Errorf("candidates = %d movies, %d shows; want the one movie above the rating minimum", st.CandidateMovies, st.CandidateTVShows)
+	}
+}
@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm/clause"
+)
+
+// PreferenceLengths are the movie lengths a household can ask for, with how
+// the prompt describes each.
+var PreferenceLengths = []string{"short", "standard", "long"}
+
+var lengthPrompts = map[string]string{
+	"short":    "short movies (under 100 minutes)",
+	"standard": "standard-length movies (100–150 minutes)",
+	"long":     "long movies (over 150 minutes)",
+}
+
+// Bounds on the free-text preference lists.
+const (
+	MaxPreferenceGenres    = 10
+	MaxPreferenceLanguages = 5
+	MaxPreferenceLen       = 64 // bytes per genre or language
+)
+
+// preferenceID is the single UserPreference row's ID.
+const preferenceID = 1
+
+// Preferences returns the household's stated preferences; the zero value
+// when none have been saved.
+func (r *Recommender) Preferences(ctx context.Context) (models.UserPreference, error) {
+	var p models.UserPreference
+	if err := r.db.WithContext(ctx).Where("id = ?", preferenceID).Limit(1).Find(&p).Error; err != nil {
+		return models.UserPreference{}, fmt.Errorf("load preferences: %w", err)
+	}
+	return p, nil
+}
+
+// SavePreferences replaces the household's preferences with p, tidied:
+// entries are trimmed and de-duplicated, free text is capped, and moods and
+// lengths outside OnboardingMoods and PreferenceLengths are dropped. It
+// returns what was stored.
+func (r *Recommender) SavePreferences(ctx context.Context, p models.UserPreference) (models.UserPreference, error) {
+	p = models.UserPreference{
+		ID:             preferenceID,
+		FavoriteGenres: tidyList(p.FavoriteGenres, MaxPreferenceGenres, nil),
+		Moods:          tidyList(p.Moods, len(OnboardingMoods), OnboardingMoods),
+		Lengths:        tidyList(p.Lengths, len(PreferenceLengths), PreferenceLengths),
+		Languages:      tidyList(p.Languages, MaxPreferenceLanguages, nil),
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"favorite_genres", "moods", "lengths", "languages", "updated_at"}),
+	}).Create(&p).Error; err != nil {
+		return models.UserPreference{}, fmt.Errorf("save preferences: %w", err)
+	}
+	return p, nil
+}
+
+// tidyList collapses whitespace in each entry and drops empty, over-long,
+// and repeated ones, and those not in allowed when it is non-nil, keeping at
+// most limit. The result is never nil, so it stores as [] rather than null.
+func tidyList(list []string, limit int, allowed []string) []string {
+	out := []string{}
+	for _, s := range list {
+		s = strings.Join(strings.Fields(s), " ")
+		if s == "" || len(s) > MaxPreferenceLen || containsFold(out, s) {
+			continue
+		}
+		if allowed != nil && !slices.Contains(allowed, s) {
+			continue
+		}
+		out = append(out, s)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// preferencesPrompt renders p as prompt sentences, or "" when it is empty.
+func preferencesPrompt(p models.UserPreference) string {
+	var parts []string
+	if len(p.FavoriteGenres) > 0 {
+		parts = append(parts, "Favorite genres: "+strings.Join(p.FavoriteGenres, ", ")+".")
+	}
+	if len(p.Moods) > 0 {
+		parts = append(parts, "In the mood for: "+strings.ToLower(strings.Join(p.Moods, ", "))+".")
+	}
+	if len(p.Lengths) > 0 {
+		var lengths []string
+		for _, l := range p.Lengths {
+			lengths = append(lengths, lengthPrompts[l])
+		}
+		parts = append(parts, "Prefers "+strings.Join(lengths, " or ")+".")
+	}
+	if len(p.Languages) > 0 {
+		parts = append(parts, "Prefers titles in: "+strings.Join(p.Languages, ", ")+".")
+	}
+	return strings.Join(parts, " ")
+}
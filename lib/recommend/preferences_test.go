@@ -0,0 +1,65 @@
+package recommend
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestTidyList(t *testing.T) {
+	got := tidyList([]string{"  Crime ", "crime", "", "Science   Fiction", strings.Repeat("x", 65), "Drama"}, 2, nil)
+	if want := []string{"Crime", "Science Fiction"}; !slices.Equal(got, want) {
+		t.Errorf("tidyList = %q, want %q", got, want)
+	}
+	if got := tidyList([]string{"short", "epic"}, 3, PreferenceLengths); !slices.Equal(got, []string{"short"}) {
+		t.Errorf("tidyList with allowed = %q, want [short]", got)
+	}
+	if got := tidyList(nil, 3, nil); got == nil {
+		t.Error("tidyList(nil) = nil, want empty")
+	}
+}
+
+func TestPreferencesPrompt(t *testing.T) {
+	if got := preferencesPrompt(models.UserPreference{}); got != "" {
+		t.Errorf("empty preferences: got %q", got)
+	}
+	got := preferencesPrompt(models.UserPreference{
+		FavoriteGenres: []string{"Crime"}, Moods: []string{"Light and funny"},
+		Lengths: []string{"short", "long"}, Languages: []string{"Japanese"},
+	})
+	for _, want := range []string{"Favorite genres: Crime.", "In the mood for: light and funny.", "short movies (under 100 minutes) or long movies", "titles in: Japanese."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt %q missing %q", got, want)
+		}
+	}
+}
+
+func TestSavePreferences(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+
+	if p, err := r.Preferences(ctx); err != nil || len(p.Moods) != 0 {
+		t.Fatalf("before saving: %+v, %v; want zero", p, err)
+	}
+	if _, err := r.SavePreferences(ctx, models.UserPreference{FavoriteGenres: []string{"Crime"}, Lengths: []string{"epic"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SavePreferences(ctx, models.UserPreference{FavoriteGenres: []string{"Drama", "drama"}, Moods: []string{"Light and funny"}}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := r.Preferences(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.FavoriteGenres, []string{"Drama"}) || !slices.Equal(p.Moods, []string{"Light and funny"}) || len(p.Lengths) != 0 {
+		t.Errorf("saved preferences = %+v, want the second save only", p)
+	}
+	var n int64
+	db.Model(&models.UserPreference{}).Count(&n)
+	if n != 1 {
+		t.Errorf("%d preference rows, want 1", n)
+	}
+}
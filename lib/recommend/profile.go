@@ -10,15 +10,19 @@ import (
 )
 
 // genreAffinity computes a normalized (0..1) taste weight per genre from watched
-// and highly-rated Plex titles. Watched titles and higher ratings weigh more.
+// and highly-rated Plex titles. Watched titles and higher ratings weigh more;
+// dropped shows don't count as watched.
 func (r *Recommender) genreAffinity(ctx context.Context) (map[string]float64, error) {
 	raw := make(map[string]float64)
 	movieGenres := make(map[uint][]string)
 	tvGenres := make(map[uint][]string)
 
-	accumulate := func(genres []string, rating float64, viewCount int) {
+	accumulate := func(genres []string, rating *float64, viewCount int) {
 		for _, g := range genres {
-			w := rating / 10.0
+			w := 0.0 // an unrated title says nothing about its genres
+			if rating != nil {
+				w = *rating / 10.0
+			}
 			if viewCount > 0 {
 				w += 1.0
 			}
@@ -39,16 +43,28 @@ func (r *Recommender) genreAffinity(ctx context.Context) (map[string]float64, er
 	if err := r.db.WithContext(ctx).Find(&shows).Error; err != nil {
 		return nil, fmt.Errorf("affinity shows: %w", err)
 	}
+	dropped, err := r.droppedShowIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for _, s := range shows {
 		g := splitGenres(s.Genre)
 		tvGenres[s.ID] = g
-		accumulate(g, s.Rating, s.ViewCount)
+		vc := s.ViewCount
+		if _, ok := dropped[s.ID]; ok {
+			vc = 0
+		}
+		accumulate(g, s.Rating, vc)
 	}
 
-	// Fold in external rated/score signals: a high signal lifts its title's genres.
+	// Fold in rated/score signals (Plex stars, Trakt, AniList; all 0–10): a
+	// rating above the midpoint lifts its title's genres and one below it
+	// pulls them down, so a watched-but-disliked title counts for little.
+	// Household votes lift a title's genres by half a point each, up to four;
+	// spins that landed on it lift them a full point each, up to three.
 	var sigs []models.ExternalSignal
 	if err := r.db.WithContext(ctx).
-		Where("kind IN ?", []string{models.SignalKindRated, models.SignalKindScore}).
+		Where("kind IN ?", []string{models.SignalKindRated, models.SignalKindScore, models.SignalKindVote, models.SignalKindSpin}).
 		Find(&sigs).Error; err != nil {
 		return nil, fmt.Errorf("affinity signals: %w", err)
 	}
@@ -60,8 +76,15 @@ func (r *Recommender) genreAffinity(ctx context.Context) (map[string]float64, er
 		case sig.TVShowID != nil:
 			genres = tvGenres[*sig.TVShowID]
 		}
+		w := (sig.Value - 5) / 5
+		switch sig.Kind {
+		case models.SignalKindVote:
+			w = min(sig.Value, 4) / 2
+		case models.SignalKindSpin:
+			w = min(sig.Value, 3)
+		}
 		for _, g := range genres {
-			raw[g] += sig.Value / 10.0
+			raw[g] += w
 		}
 	}
 
@@ -76,13 +99,33 @@ func (r *Recommender) genreAffinity(ctx context.Context) (map[string]float64, er
 	}
 	out := make(map[string]float64, len(raw))
 	for g, v := range raw {
-		out[g] = v / peak
+		out[g] = max(v, 0) / peak
 	}
 	return out, nil
 }
 
-// tasteProfile renders the top genres as a short prompt fragment.
+// tasteProfile returns the prompt's taste profile: the summary learned by
+// LearnTasteProfile when one exists, else the top genres, followed by any
+// onboarding quiz answers.
 func (r *Recommender) tasteProfile(ctx context.Context) (string, error) {
+	profile, err := r.storedTasteProfile(ctx)
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		if profile, err = r.genreProfile(ctx); err != nil {
+			return "", err
+		}
+	}
+	seed, err := r.onboardingProfile(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(profile + " " + seed), nil
+}
+
+// genreProfile renders the top genres as a short prompt fragment.
+func (r *Recommender) genreProfile(ctx context.Context) (string, error) {
 	aff, err := r.genreAffinity(ctx)
 	if err != nil {
 		return "", err
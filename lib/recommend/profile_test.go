@@ -14,9 +14,9 @@ func TestGenreAffinity_favorsWatchedAndRated(t *testing.T) {
 	ctx := context.Background()
 
 	// Two watched high-rated comedies, one unwatched horror.
-	db.Create(&models.Movie{Title: "C1", Genre: "Comedy", Rating: 9, ViewCount: 3, PlexRatingKey: "a"})
-	db.Create(&models.Movie{Title: "C2", Genre: "Comedy", Rating: 8, ViewCount: 2, PlexRatingKey: "b"})
-	db.Create(&models.Movie{Title: "H1", Genre: "Horror", Rating: 8, ViewCount: 0, PlexRatingKey: "c"})
+	db.Create(&models.Movie{Title: "C1", Genre: "Comedy", Rating: new(9.0), ViewCount: 3, PlexRatingKey: "a"})
+	db.Create(&models.Movie{Title: "C2", Genre: "Comedy", Rating: new(8.0), ViewCount: 2, PlexRatingKey: "b"})
+	db.Create(&models.Movie{Title: "H1", Genre: "Horror", Rating: new(8.0), ViewCount: 0, PlexRatingKey: "c"})
 
 	aff, err := r.genreAffinity(ctx)
 	if err != nil {
@@ -34,7 +34,7 @@ func TestTasteProfile_nonEmptyWhenSignalsExist(t *testing.T) {
 	db := testDB(t)
 	r := testRecommender(db)
 	ctx := context.Background()
-	db.Create(&models.Movie{Title: "C1", Genre: "Comedy", Rating: 9, ViewCount: 3, PlexRatingKey: "a"})
+	db.Create(&models.Movie{Title: "C1", Genre: "Comedy", Rating: new(9.0), ViewCount: 3, PlexRatingKey: "a"})
 	p, err := r.tasteProfile(ctx)
 	if err != nil {
 		t.Fatal(err)
@@ -49,8 +49,8 @@ func TestGenreAffinity_blendsRatedSignals(t *testing.T) {
 	r := testRecommender(db)
 	ctx := context.Background()
 
-	comedy := models.Movie{Title: "C", Genre: "Comedy", Rating: 0, ViewCount: 0, PlexRatingKey: "a"}
-	horror := models.Movie{Title: "H", Genre: "Horror", Rating: 0, ViewCount: 0, PlexRatingKey: "b"}
+	comedy := models.Movie{Title: "C", Genre: "Comedy", ViewCount: 0, PlexRatingKey: "a"}
+	horror := models.Movie{Title: "H", Genre: "Horror", ViewCount: 0, PlexRatingKey: "b"}
 	db.Create(&comedy)
 	db.Create(&horror)
 	db.Create(&models.ExternalSignal{Source: models.SourceTrakt, ExternalRef: "rated:1", Kind: models.SignalKindRated, MovieID: &comedy.ID, Value: 10})
@@ -64,6 +64,26 @@ func TestGenreAffinity_blendsRatedSignals(t *testing.T) {
 	}
 }
 
+func TestGenreAffinity_lowRatingsPullDown(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := context.Background()
+
+	western := models.Movie{Title: "W", Genre: "Western", ViewCount: 1, PlexRatingKey: "a"}
+	drama := models.Movie{Title: "D", Genre: "Drama", ViewCount: 1, PlexRatingKey: "b"}
+	db.Create(&western)
+	db.Create(&drama)
+	db.Create(&models.ExternalSignal{Source: models.SourcePlex, ExternalRef: "rated:a", Kind: models.SignalKindRated, MovieID: &western.ID, Value: 2})
+
+	aff, err := r.genreAffinity(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aff["Western"] >= aff["Drama"] {
+		t.Errorf("a 1-star rating should pull Western (%.2f) below Drama (%.2f)", aff["Western"], aff["Drama"])
+	}
+}
+
 func TestLovedTitles_listsHighlyRated(t *testing.T) {
 	db := testDB(t)
 	r := testRecommender(db)
@@ -0,0 +1,116 @@
+package recommend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// build identifies the running binary: its VCS revision when built from a
+// checkout, else its module version.
+var build = func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return info.Main.Version
+}()
+
+// UseProvider records name (LLM_PROVIDER, e.g. "gemini") as the provider of
+// each run. Call it before serving.
+func (r *Recommender) UseProvider(name string) {
+	r.provider = name
+}
+
+// promptVersion is a short hash of the prompt templates in fsys slot is
+// generated with, so any edit to them shows up as a new version.
+func promptVersion(fsys fs.FS, slot Slot) (string, error) {
+	h := sha256.New()
+	for _, name := range []string{"system.txt", "recommendation.txt", slot.Prompt} {
+		if name == "" {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", name, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(b))
+		_, _ = h.Write(b) // never fails
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// runConfig snapshots the settings a run of slot reads.
+func (s *Settings) runConfig(slot Slot) *models.RunConfig {
+	c := &models.RunConfig{
+		Movies: slot.Movies, TVShows: slot.TVShows, MaxRuntime: slot.MaxRuntime, Shortlist: shortlistSize,
+		MinRating: s.Quality.MinRating, MinYear: s.Quality.MinYear, MinRuntime: s.Quality.MinRuntime,
+		RequireMetadata: s.Quality.RequireMetadata, NightlyMinutes: s.nightlyMinutes(),
+		Weather: s.Weather != nil, Holidays: s.Calendar != nil,
+		Diversity: []string{},
+	}
+	rules := s.diversityRules()
+	for _, rule := range []struct {
+		name string
+		on   bool
+	}{{"genre", rules.Genre}, {"decade", rules.Decade}, {"director", rules.Director}} {
+		if rule.on {
+			c.Diversity = append(c.Diversity, rule.name)
+		}
+	}
+	if s.ModelRetry.Interval > 0 {
+		c.RetryInterval, c.RetryUntil = s.ModelRetry.Interval.String(), s.ModelRetry.Until
+	}
+	return c
+}
+
+// recordProvenance stores what the claimed run is about to generate with:
+// the prompt templates in fsys, from canary rollout version canary if not
+// empty. The strategy starts as the intended one; a fallback overwrites it.
+func (r *Recommender) recordProvenance(ctx context.Context, runID uint, slot Slot, cfg *Settings, fsys fs.FS, canary string) error {
+	updates := map[string]any{
+		"provider": r.provider, "build": build, "prompt_version": "", "prompt_canary": canary,
+		"validation_failed": false, "strategy": models.StrategyScored, "config": cfg.runConfig(slot),
+	}
+	if !r.LLMEnabled() {
+		updates["provider"] = "none"
+	} else {
+		v, err := promptVersion(fsys, slot)
+		if err != nil {
+			return err
+		}
+		updates["prompt_version"], updates["strategy"] = v, models.StrategyModel
+	}
+	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{ID: runID}).Updates(updates).Error; err != nil {
+		return fmt.Errorf("record provenance: %w", err)
+	}
+	return nil
+}
+
+// Runs returns the generation runs of date's UTC day, daily set first, or
+// the latest limit runs when date is zero.
+func (r *Recommender) Runs(ctx context.Context, date time.Time, limit int) ([]models.GenerationRun, error) {
+	q := r.reads().WithContext(ctx)
+	if date.IsZero() {
+		q = q.Order(`"date" DESC, id DESC`).Limit(limit)
+	} else {
+		q = q.Where(`"date" = ?`, date.UTC().Truncate(24*time.Hour)).
+			Order("context <> 'daily', context")
+	}
+	var runs []models.GenerationRun
+	if err := q.Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("load runs: %w", err)
+	}
+	return runs, nil
+}
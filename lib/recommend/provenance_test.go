@@ -0,0 +1,39 @@
+package recommend
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/recommend/prompts"
+)
+
+func TestPromptVersion(t *testing.T) {
+	daily, err := promptVersion(prompts.FS, DailySlot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := promptVersion(prompts.FS, DailySlot); again != daily || len(daily) != 12 {
+		t.Errorf("promptVersion = %q then %q, want the same 12 characters", daily, again)
+	}
+	tonight, err := promptVersion(prompts.FS, TimeSlots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tonight == daily {
+		t.Error("a slot with its own prompt should have its own version")
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	s := &Settings{
+		Diversity:  &DiversityRules{Genre: true, Director: true},
+		Quality:    QualityFilter{MinYear: 1980},
+		ModelRetry: ModelRetry{Interval: 30 * time.Minute, Until: 9},
+	}
+	c := s.runConfig(s.compose(DailySlot))
+	if c.Movies != DailySlot.Movies || c.Shortlist != shortlistSize || c.MinYear != 1980 || c.NightlyMinutes != DefaultNightlyMinutes ||
+		!slices.Equal(c.Diversity, []string{"genre", "director"}) || c.RetryInterval != "30m0s" || c.RetryUntil != 9 || c.Weather {
+		t.Errorf("runConfig = %+v", c)
+	}
+}
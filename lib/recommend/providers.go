@@ -0,0 +1,234 @@
+package recommend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/lib/errreport"
+	"google.golang.org/genai"
+)
+
+const (
+	defaultOpenAIURL    = "https://api.openai.com/v1"
+	defaultAnthropicURL = "https://api.anthropic.com/v1"
+	defaultOllamaURL    = "http://localhost:11434"
+	// anthropicVersion is the Messages API version requested.
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens bounds a reply; picks and chat replies are short.
+	anthropicMaxTokens = 4096
+	// providerTimeout bounds one HTTP model call; generation jobs have five
+	// minutes in all.
+	providerTimeout = 2 * time.Minute
+)
+
+// OpenAIChatter calls the OpenAI Chat Completions API, or any server that
+// speaks it (vLLM, LM Studio, llama.cpp), with structured JSON output. URL
+// is the API base, overridable for tests.
+type OpenAIChatter struct {
+	URL        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIChatter returns a client for model. An empty baseURL uses
+// OpenAI's; apiKey may be empty for local servers that don't check it.
+func NewOpenAIChatter(apiKey, model, baseURL string) *OpenAIChatter {
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+	return &OpenAIChatter{URL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, model: model, httpClient: &http.Client{Timeout: providerTimeout}}
+}
+
+// Complete sends the prompts with schema as the response format and returns
+// the reply's JSON text.
+func (o *OpenAIChatter) Complete(ctx context.Context, system, user string, schema *genai.Schema) (string, error) {
+	body := map[string]any{
+		"model": o.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+		"response_format": map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": "response", "schema": jsonSchema(schema)},
+		},
+	}
+	headers := map[string]string{}
+	if o.apiKey != "" {
+		headers["Authorization"] = "Bearer " + o.apiKey
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, o.httpClient, o.URL+"/chat/completions", headers, body, &resp); err != nil {
+		err = fmt.Errorf("openai complete: %w", err)
+		errreport.CaptureError(ctx, err, "provider", "openai", "model", o.model)
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("openai complete: no choices in response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// AnthropicChatter calls the Anthropic Messages API. The schema is sent as
+// the input schema of a single tool the model must call, so the tool input
+// is the JSON reply. URL is the API base, overridable for tests.
+type AnthropicChatter struct {
+	URL        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAnthropicChatter returns a client for model.
+func NewAnthropicChatter(apiKey, model string) *AnthropicChatter {
+	return &AnthropicChatter{URL: defaultAnthropicURL, apiKey: apiKey, model: model, httpClient: &http.Client{Timeout: providerTimeout}}
+}
+
+// Complete sends the prompts and returns the forced tool call's input as JSON text.
+func (a *AnthropicChatter) Complete(ctx context.Context, system, user string, schema *genai.Schema) (string, error) {
+	body := map[string]any{
+		"model":      a.model,
+		"max_tokens": anthropicMaxTokens,
+		"system":     system,
+		"messages":   []map[string]string{{"role": "user", "content": user}},
+		"tools": []map[string]any{{
+			"name":         "respond",
+			"description":  "Give the answer.",
+			"input_schema": jsonSchema(schema),
+		}},
+		"tool_choice": map[string]string{"type": "tool", "name": "respond"},
+	}
+	headers := map[string]string{"x-api-key": a.apiKey, "anthropic-version": anthropicVersion}
+	var resp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := postJSON(ctx, a.httpClient, a.URL+"/messages", headers, body, &resp); err != nil {
+		err = fmt.Errorf("anthropic complete: %w", err)
+		errreport.CaptureError(ctx, err, "provider", "anthropic", "model", a.model)
+		return "", err
+	}
+	for _, c := range resp.Content {
+		if c.Type == "tool_use" {
+			return string(c.Input), nil
+		}
+	}
+	return "", fmt.Errorf("anthropic complete: no tool call in response")
+}
+
+// OllamaChatter calls a local Ollama server's chat API with the schema as
+// its output format. URL is the server, overridable for tests.
+type OllamaChatter struct {
+	URL        string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllamaChatter returns a client for model on the server at baseURL
+// (empty for http://localhost:11434).
+func NewOllamaChatter(baseURL, model string) *OllamaChatter {
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+	return &OllamaChatter{URL: strings.TrimSuffix(baseURL, "/"), model: model, httpClient: &http.Client{Timeout: providerTimeout}}
+}
+
+// Complete sends the prompts and returns the reply's JSON text.
+func (o *OllamaChatter) Complete(ctx context.Context, system, user string, schema *genai.Schema) (string, error) {
+	body := map[string]any{
+		"model": o.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+		"format": jsonSchema(schema),
+		"stream": false,
+	}
+	var resp struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := postJSON(ctx, o.httpClient, o.URL+"/api/chat", nil, body, &resp); err != nil {
+		err = fmt.Errorf("ollama chat: %w", err)
+		errreport.CaptureError(ctx, err, "provider", "ollama", "model", o.model)
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// postJSON posts body as JSON to url with headers and decodes the JSON reply
+// into out. A non-2xx status is an error carrying the start of the reply.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// jsonSchema converts a Gemini response schema to standard JSON Schema for
+// the other providers. Only the parts the recommender's schemas use are
+// carried over.
+func jsonSchema(s *genai.Schema) map[string]any {
+	if s == nil {
+		return nil
+	}
+	out := map[string]any{"type": strings.ToLower(string(s.Type))}
+	if s.Description != "" {
+		out["description"] = s.Description
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+	}
+	if s.Items != nil {
+		out["items"] = jsonSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		props := make(map[string]any, len(s.Properties))
+		for name, p := range s.Properties {
+			props[name] = jsonSchema(p)
+		}
+		out["properties"] = props
+		out["additionalProperties"] = false
+	}
+	if len(s.Required) > 0 {
+		out["required"] = s.Required
+	}
+	return out
+}
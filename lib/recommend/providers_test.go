@@ -0,0 +1,113 @@
+package recommend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// providerServer answers every request with reply after checking the body
+// carries the converted schema, recording the request.
+func providerServer(t *testing.T, reply string, got **http.Request, body *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*got = req.Clone(req.Context())
+		if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIChatter_Complete(t *testing.T) {
+	var req *http.Request
+	var body map[string]any
+	srv := providerServer(t, `{"choices":[{"message":{"content":"{\"movies\":[],\"tvshows\":[]}"}}]}`, &req, &body)
+	c := NewOpenAIChatter("sk-test", "gpt-test", srv.URL+"/")
+
+	raw, err := c.Complete(t.Context(), "sys", "user", pickSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw != `{"movies":[],"tvshows":[]}` {
+		t.Errorf("raw = %q", raw)
+	}
+	if req.URL.Path != "/chat/completions" || req.Header.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("request = %s %v", req.URL.Path, req.Header)
+	}
+	format, _ := body["response_format"].(map[string]any)
+	if body["model"] != "gpt-test" || format["type"] != "json_schema" {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestAnthropicChatter_Complete(t *testing.T) {
+	var req *http.Request
+	var body map[string]any
+	srv := providerServer(t, `{"content":[{"type":"text","text":"Sure."},{"type":"tool_use","name":"respond","input":{"movies":[{"id":1,"explanation":"x"}],"tvshows":[]}}]}`, &req, &body)
+	c := NewAnthropicChatter("key", "claude-test")
+	c.URL = srv.URL
+
+	raw, err := c.Complete(t.Context(), "sys", "user", pickSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := parsePickResponse(raw)
+	if err != nil || len(resp.Movies) != 1 || resp.Movies[0].ID != 1 {
+		t.Errorf("parsed %q = %+v, %v", raw, resp, err)
+	}
+	if req.URL.Path != "/messages" || req.Header.Get("x-api-key") != "key" || req.Header.Get("anthropic-version") == "" {
+		t.Errorf("request = %s %v", req.URL.Path, req.Header)
+	}
+	if body["system"] != "sys" {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestOllamaChatter_Complete(t *testing.T) {
+	var req *http.Request
+	var body map[string]any
+	srv := providerServer(t, `{"message":{"role":"assistant","content":"{\"movies\":[],\"tvshows\":[]}"}}`, &req, &body)
+	c := NewOllamaChatter(srv.URL, "llama-test")
+
+	if _, err := c.Complete(t.Context(), "sys", "user", pickSchema()); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.Path != "/api/chat" || body["stream"] != false {
+		t.Errorf("request = %s %v", req.URL.Path, body)
+	}
+	format, _ := body["format"].(map[string]any)
+	if format["type"] != "object" {
+		t.Errorf("format = %v, want the converted schema", body["format"])
+	}
+}
+
+func TestProviderErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := NewOllamaChatter(srv.URL, "m").Complete(t.Context(), "s", "u", pickSchema())
+	if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("err = %v, want the status and body", err)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	s := jsonSchema(chatSchema())
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"type":"object"`, `"reply":{"type":"string"}`, `"items":{"additionalProperties":false`, `"id":{"type":"integer"}`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("schema %s lacks %s", b, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package recommend
+
+import "github.com/icco/recommender/models"
+
+// Reasons a candidate fails the quality minimums, as logged per run.
+const (
+	qualityNoMetadata = "no_metadata"
+	qualityRating     = "rating"
+	qualityYear       = "year"
+	qualityRuntime    = "runtime"
+)
+
+// QualityFilter holds minimums a title must meet to be offered to the
+// generator at all. The zero value lets everything through.
+type QualityFilter struct {
+	MinRating       float64 // drop titles rated below this (0–10); unrated ones pass; 0 disables
+	MinYear         int     // drop titles released before this year; 0 disables
+	MinRuntime      int     // drop movies shorter than this many minutes; 0 disables
+	RequireMetadata bool    // drop titles with no rating, year, genres, credits, or TMDb match
+}
+
+func (q QualityFilter) enabled() bool {
+	return q != QualityFilter{}
+}
+
+// reject returns why c falls short of q, or "" if it passes. Titles with no
+// metadata at all are reported as such rather than by their zero year. An
+// unknown rating passes the rating minimum, and an unknown movie runtime the
+// runtime minimum.
+func (q QualityFilter) reject(c candidate) string {
+	switch {
+	case q.RequireMetadata && c.Rating == nil && c.Year == 0 && len(c.Genres) == 0 &&
+		len(c.Directors) == 0 && len(c.Actors) == 0 && c.TMDbID == nil:
+		return qualityNoMetadata
+	case q.MinRating > 0 && c.Rating != nil && *c.Rating < q.MinRating:
+		return qualityRating
+	case q.MinYear > 0 && c.Year < q.MinYear:
+		return qualityYear
+	case q.MinRuntime > 0 && c.Type == models.TypeMovie && c.Runtime > 0 && c.Runtime < q.MinRuntime:
+		return qualityRuntime
+	}
+	return ""
+}
+
+// filter returns the candidates that pass q, adding how many failed, by
+// reason, to dropped.
+func (q QualityFilter) filter(cands []candidate, dropped map[string]int) []candidate {
+	out := cands[:0]
+	for _, c := range cands {
+		if reason := q.reject(c); reason != "" {
+			dropped[reason]++
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
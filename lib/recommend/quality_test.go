@@ -0,0 +1,56 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestQualityFilter_reject(t *testing.T) {
+	tmdb := 603
+	good := candidate{Type: models.TypeMovie, Title: "The Matrix", Year: 1999, Rating: new(8.7), Genres: []string{"Action"}, Runtime: 136, TMDbID: &tmdb}
+	bare := candidate{Type: models.TypeMovie, Title: "VID_0042"}
+	short := good
+	short.Runtime = 12
+	unknownRuntime := good
+	unknownRuntime.Runtime = 0
+	show := candidate{Type: models.TypeTVShow, Title: "Short Episodes", Year: 2010, Rating: new(8.0), Seasons: 3, EpisodeRuntime: 22}
+	strict := QualityFilter{MinRating: 6, MinYear: 1970, MinRuntime: 40, RequireMetadata: true}
+
+	for _, tc := range []struct {
+		name   string
+		filter QualityFilter
+		c      candidate
+		want   string
+	}{
+		{"zero filter", QualityFilter{}, bare, ""},
+		{"passes", strict, good, ""},
+		{"no metadata", strict, bare, qualityNoMetadata},
+		{"low rating", QualityFilter{MinRating: 9}, good, qualityRating},
+		{"unrated passes min rating", QualityFilter{MinRating: 9}, candidate{Title: "Unrated", Year: 2020}, ""},
+		{"too old", QualityFilter{MinYear: 2000}, good, qualityYear},
+		{"short movie", strict, short, qualityRuntime},
+		{"unknown runtime", strict, unknownRuntime, ""},
+		{"short episodes", strict, show, ""},
+	} {
+		if got := tc.filter.reject(tc.c); got != tc.want {
+			t.Errorf("%s: reject = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestQualityFilter_filter(t *testing.T) {
+	cands := []candidate{
+		{ID: 1, Year: 1955, Rating: new(7.0)},
+		{ID: 2, Year: 2001, Rating: new(5.0)},
+		{ID: 3, Year: 2012, Rating: new(8.0)},
+	}
+	dropped := map[string]int{}
+	got := QualityFilter{MinRating: 6, MinYear: 1970}.filter(cands, dropped)
+	if len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("filter kept %+v, want only id 3", got)
+	}
+	if dropped[qualityYear] != 1 || dropped[qualityRating] != 1 {
+		t.Errorf("dropped = %v, want one each for year and rating", dropped)
+	}
+}
@@ -7,29 +7,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/icco/recommender/lib/cachestats"
+	"github.com/icco/recommender/lib/lru"
 	"github.com/icco/recommender/lib/plex"
 	"github.com/icco/recommender/lib/tmdb"
+	"github.com/icco/recommender/lib/vectors"
 	"github.com/icco/recommender/models"
 	"gorm.io/gorm"
 )
 
-// StatsData represents statistics about the recommendations database.
+// GenreCount is how many recommendations had a genre.
+type GenreCount struct {
+	Genre string
+	Count int64
+}
+
+// StatsData represents statistics about the recommendations database. It is
+// the only stats type: the stats template renders it (via handlers.statsPage)
+// and the JSON view, v1.Stats, is built from it by statsPage.api, so the two
+// can't drift apart.
 type StatsData struct {
 	TotalRecommendations        int64
 	TotalMovies                 int64
 	TotalTVShows                int64
+	Watched                     int64 // picks marked models.FeedbackWatched
+	Ignored                     int64 // picks marked models.FeedbackIgnored
 	FirstDate                   time.Time
 	LastDate                    time.Time
 	AverageDailyRecommendations float64
-	GenreDistribution           []struct {
-		Genre string
-		Count int64
-	}
-	TotalCachedMovies  int64
-	TotalCachedTVShows int64
-	LastCacheUpdate    time.Time
+	GenreDistribution           []GenreCount // both types
+	MovieGenres                 []GenreCount
+	TVShowGenres                []GenreCount
+	TotalCachedMovies           int64
+	TotalCachedTVShows          int64
+	LastCacheUpdate             time.Time
 }
 
 // Recommender produces and serves daily Plex/TMDb recommendations using
@@ -40,15 +54,24 @@ type Recommender struct {
 	tmdb      *tmdb.Client
 	chat      Chatter
 	model     string
+	provider  string // LLM_PROVIDER, recorded with each run
 	sigCfg    SignalConfig
 	posterDir string
+	replica   *gorm.DB                          // heavy read-only queries; nil uses db
+	embedder  Embedder                          // nil uses featureEmbedder
+	vectors   vectors.Store                     // indexes embeddings for BecauseYouWatched
+	moods     *lru.Cache[string, []uint]        // LLM mood orders by "day/mood"
+	similar   *lru.Cache[string, []similarPick] // LLM more-like-this picks by candKey
+	posters   *cachestats.Counter
+	settings  atomic.Pointer[Settings]
 }
 
 // New creates a new Recommender instance with the provided dependencies.
-// posterDir is where finalist posters are cached for public serving.
+// posterDir is where finalist posters are cached for public serving. A nil
+// chat runs heuristic-only; model should then be FallbackModel.
 // Loggers are sourced from per-call ctx via gutil/logging.
 func New(db *gorm.DB, plexClient *plex.Client, tmdbClient *tmdb.Client, chat Chatter, model string, sigCfg SignalConfig, posterDir string) (*Recommender, error) {
-	return &Recommender{
+	r := &Recommender{
 		db:        db,
 		plex:      plexClient,
 		tmdb:      tmdbClient,
@@ -56,7 +79,62 @@ func New(db *gorm.DB, plexClient *plex.Client, tmdbClient *tmdb.Client, chat Cha
 		model:     model,
 		sigCfg:    sigCfg,
 		posterDir: posterDir,
-	}, nil
+		vectors:   vectors.NewMemory(),
+	}
+	r.moods = lru.New[string, []uint]("mood_order", DefaultMoodCacheSize, DefaultMoodCacheTTL)
+	r.similar = lru.New[string, []similarPick]("more_like_this", similarCacheSize, similarCacheTTL)
+	r.posters = cachestats.New("posters", r.posterCount)
+	return r, nil
+}
+
+// UseReadReplica sends the heavy read-only queries behind the stats,
+// archive, and dates pages to replica, keeping them off the primary while a
+// cache sync writes to it. Everything else, including every write, stays on
+// the primary. Call it before serving.
+func (r *Recommender) UseReadReplica(replica *gorm.DB) {
+	r.replica = replica
+}
+
+// UseEmbedder embeds titles for the "Because you watched …" rows with e
+// instead of the built-in word-feature embedder. Call it before serving.
+func (r *Recommender) UseEmbedder(e Embedder) {
+	r.embedder = e
+}
+
+// embeddings returns the embedder titles are compared with.
+func (r *Recommender) embeddings() Embedder {
+	if r.embedder != nil {
+		return r.embedder
+	}
+	return featureEmbedder{}
+}
+
+// UseVectorStore indexes title embeddings in s instead of in memory. Call
+// it before serving.
+func (r *Recommender) UseVectorStore(s vectors.Store) {
+	r.vectors = s
+}
+
+// reads returns the database heavy read-only queries should use.
+func (r *Recommender) reads() *gorm.DB {
+	if r.replica != nil {
+		return r.replica
+	}
+	return r.db
+}
+
+// LLMEnabled reports whether a model is configured. Without one
+// (LLM_PROVIDER=none) the scorer picks every day and mood re-ranks use the
+// genre heuristic.
+func (r *Recommender) LLMEnabled() bool {
+	return r.chat != nil
+}
+
+// TMDbEnabled reports whether a TMDb client is configured. Without one,
+// candidates keep Plex's metadata and posters, and collection lookups and
+// the onboarding film grid are skipped.
+func (r *Recommender) TMDbEnabled() bool {
+	return r.tmdb != nil
 }
 
 // recommendationUTCDayRange returns [start, end) for the calendar day of t in UTC.
@@ -68,40 +146,105 @@ func recommendationUTCDayRange(t time.Time) (start, end time.Time) {
 	return start, end
 }
 
+// published limits q to days that have begun, so picks generated ahead of
+// time stay out of listings until their day.
+func published(q *gorm.DB) *gorm.DB {
+	_, end := recommendationUTCDayRange(time.Now())
+	return q.Where(`"date" < ?`, end)
+}
+
+// household limits a recommendations query to the household's picks. The
+// kids' picks are stored alongside them but are shown and counted only on
+// /kids, so every other listing, count, and cooldown goes through this.
+func household(q *gorm.DB) *gorm.DB {
+	return q.Where("slot <> ?", KidsSlot.Name)
+}
+
+// householdRecs is the recommendations table narrowed by household, for
+// raw SQL to select from as a subquery: FROM (?) AS recommendations.
+func householdRecs(db *gorm.DB) *gorm.DB {
+	return household(db.Model(&models.Recommendation{}))
+}
+
 // GetRecommendationsForDate retrieves all recommendations for a specific date
+// except the kids' picks, which GetSlotRecommendations serves on their own.
 func (r *Recommender) GetRecommendationsForDate(ctx context.Context, date time.Time) ([]models.Recommendation, error) {
 	var recommendations []models.Recommendation
 	start, end := recommendationUTCDayRange(date)
 	// Half-open range matches how GORM persists time.Time and avoids date-function
 	// quirks on a column named `date`.
+	if err := householdRecs(r.db.WithContext(ctx)).
+		Where(`"date" >= ? AND "date" < ?`, start, end).
+		Find(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	return recommendations, nil
+}
+
+// GetSlotRecommendations retrieves one slot's recommendations for a date,
+// movies first.
+func (r *Recommender) GetSlotRecommendations(ctx context.Context, date time.Time, slot Slot) ([]models.Recommendation, error) {
+	var recommendations []models.Recommendation
+	start, end := recommendationUTCDayRange(date)
 	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).
+		Where(`"date" >= ? AND "date" < ? AND slot = ?`, start, end, slot.Name).
+		Order("type, id").
+		Find(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get %s recommendations: %w", slot.Name, err)
+	}
+	return recommendations, nil
+}
+
+// GetRecommendationsInRange retrieves recommendations for the UTC days from
+// through to (inclusive), ordered by day, slot, and type (movies first). Like
+// GetRecommendationsForDate it leaves out the kids' picks.
+func (r *Recommender) GetRecommendationsInRange(ctx context.Context, from, to time.Time) ([]models.Recommendation, error) {
+	start, _ := recommendationUTCDayRange(from)
+	_, end := recommendationUTCDayRange(to)
+	var recommendations []models.Recommendation
+	if err := householdRecs(r.db.WithContext(ctx)).
 		Where(`"date" >= ? AND "date" < ?`, start, end).
+		Order(`"date", slot, type, id`).
 		Find(&recommendations).Error; err != nil {
 		return nil, fmt.Errorf("failed to get recommendations: %w", err)
 	}
 	return recommendations, nil
 }
 
-// DidRunToday reports whether a successful generation run exists for the day.
+// DidRunToday reports whether a successful daily-slot generation run exists
+// for the day.
 func (r *Recommender) DidRunToday(ctx context.Context, date time.Time) (bool, error) {
+	return r.didRun(ctx, date, DailySlot.Name)
+}
+
+// DidRun reports whether a successful run exists for the day and slot.
+func (r *Recommender) DidRun(ctx context.Context, date time.Time, slot Slot) (bool, error) {
+	return r.didRun(ctx, date, slot.Name)
+}
+
+// didRun reports whether a successful run exists for the day and slot name.
+func (r *Recommender) didRun(ctx context.Context, date time.Time, slot string) (bool, error) {
 	start, end := recommendationUTCDayRange(date)
 	var count int64
 	if err := r.db.WithContext(ctx).Model(&models.GenerationRun{}).
-		Where(`"date" >= ? AND "date" < ? AND status = ?`, start, end, models.RunStatusOK).
+		Where(`"date" >= ? AND "date" < ? AND context = ? AND status = ?`, start, end, slot, models.RunStatusOK).
 		Count(&count).Error; err != nil {
 		return false, fmt.Errorf("check run: %w", err)
 	}
 	return count > 0, nil
 }
 
-// GetRecommendationDates retrieves a paginated list of distinct calendar dates that have recommendations.
+// GetRecommendationDates retrieves a paginated list of distinct calendar dates
+// that have household recommendations, leaving out days that haven't begun.
 func (r *Recommender) GetRecommendationDates(ctx context.Context, page, pageSize int) ([]time.Time, int64, error) {
+	_, before := recommendationUTCDayRange(time.Now())
 	var total int64
-	if err := r.db.WithContext(ctx).Raw(`
+	if err := r.reads().WithContext(ctx).Raw(`
 		SELECT COUNT(*) FROM (
-			SELECT 1 FROM recommendations
+			SELECT 1 FROM (?) AS recommendations
+			WHERE "date" < ?
 			GROUP BY to_char("date", 'YYYY-MM-DD')
-		) AS sub`).Scan(&total).Error; err != nil {
+		) AS sub`, householdRecs(r.reads().WithContext(ctx)), before).Scan(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to get total distinct dates: %w", err)
 	}
 
@@ -109,11 +252,12 @@ func (r *Recommender) GetRecommendationDates(ctx context.Context, page, pageSize
 	var dateRows []struct {
 		D string `gorm:"column:d"`
 	}
-	if err := r.db.WithContext(ctx).Raw(`
-		SELECT to_char("date", 'YYYY-MM-DD') AS d FROM recommendations
+	if err := r.reads().WithContext(ctx).Raw(`
+		SELECT to_char("date", 'YYYY-MM-DD') AS d FROM (?) AS recommendations
+		WHERE "date" < ?
 		GROUP BY to_char("date", 'YYYY-MM-DD')
 		ORDER BY d DESC
-		LIMIT ? OFFSET ?`, pageSize, offset).Scan(&dateRows).Error; err != nil {
+		LIMIT ? OFFSET ?`, householdRecs(r.reads().WithContext(ctx)), before, pageSize, offset).Scan(&dateRows).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to get dates: %w", err)
 	}
 
@@ -134,32 +278,85 @@ func (r *Recommender) GetRecommendationDates(ctx context.Context, page, pageSize
 	return dates, total, nil
 }
 
+// RecommendationDaysBetween returns the distinct days in [from, to) that
+// have household recommendations, oldest first, leaving out days that haven't begun.
+func (r *Recommender) RecommendationDaysBetween(ctx context.Context, from, to time.Time) ([]time.Time, error) {
+	_, before := recommendationUTCDayRange(time.Now())
+	var rows []string
+	if err := r.reads().WithContext(ctx).Raw(`
+		SELECT DISTINCT to_char("date", 'YYYY-MM-DD') AS d FROM (?) AS recommendations
+		WHERE "date" >= ? AND "date" < ? AND "date" < ?
+		ORDER BY d`, householdRecs(r.reads().WithContext(ctx)), from.UTC(), to.UTC(), before).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get days between %s and %s: %w", from.Format("2006-01-02"), to.Format("2006-01-02"), err)
+	}
+	days := make([]time.Time, 0, len(rows))
+	for _, s := range rows {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", s, err)
+		}
+		days = append(days, t)
+	}
+	return days, nil
+}
+
+// StatsFilter narrows GetStats' recommendation figures to picks dated in
+// [From, To). Zero values leave that end open.
+type StatsFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// apply limits q to the filter's dates.
+func (f StatsFilter) apply(q *gorm.DB) *gorm.DB {
+	if !f.From.IsZero() {
+		q = q.Where(`"date" >= ?`, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		q = q.Where(`"date" < ?`, f.To.UTC())
+	}
+	return q
+}
+
 // GetStats retrieves statistics about the recommendations database.
-// It returns counts of recommendations by type, date range, and genre distribution.
-func (r *Recommender) GetStats(ctx context.Context) (*StatsData, error) {
+// It returns counts of recommendations by type, date range, and genre
+// distribution (overall and per type) for the household picks f selects,
+// plus cache counts, which f doesn't affect.
+func (r *Recommender) GetStats(ctx context.Context, f StatsFilter) (*StatsData, error) {
 	var stats StatsData
+	recs := func() *gorm.DB {
+		return f.apply(householdRecs(r.reads().WithContext(ctx)))
+	}
 
 	// Get total recommendations
-	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).Count(&stats.TotalRecommendations).Error; err != nil {
+	if err := recs().Count(&stats.TotalRecommendations).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total recommendations: %w", err)
 	}
 
 	// Get counts by type
-	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).Where("type = ?", models.TypeMovie).Count(&stats.TotalMovies).Error; err != nil {
+	if err := recs().Where("type = ?", models.TypeMovie).Count(&stats.TotalMovies).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total movies: %w", err)
 	}
-	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).Where("type = ?", models.TypeTVShow).Count(&stats.TotalTVShows).Error; err != nil {
+	if err := recs().Where("type = ?", models.TypeTVShow).Count(&stats.TotalTVShows).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total TV shows: %w", err)
 	}
 
+	// Get feedback counts
+	if err := recs().Where("feedback = ?", models.FeedbackWatched).Count(&stats.Watched).Error; err != nil {
+		return nil, fmt.Errorf("failed to get watched count: %w", err)
+	}
+	if err := recs().Where("feedback = ?", models.FeedbackIgnored).Count(&stats.Ignored).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ignored count: %w", err)
+	}
+
 	// Get date range
 	var firstDate, lastDate time.Time
-	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).Order("date ASC").Limit(1).Pluck("date", &firstDate).Error; err != nil {
+	if err := recs().Order("date ASC").Limit(1).Pluck("date", &firstDate).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to get first date: %w", err)
 		}
 	}
-	if err := r.db.WithContext(ctx).Model(&models.Recommendation{}).Order("date DESC").Limit(1).Pluck("date", &lastDate).Error; err != nil {
+	if err := recs().Order("date DESC").Limit(1).Pluck("date", &lastDate).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to get last date: %w", err)
 		}
@@ -175,51 +372,39 @@ func (r *Recommender) GetStats(ctx context.Context) (*StatsData, error) {
 		}
 	}
 
-	// Get genre distribution
-	type genreCount struct {
-		Genre string
-		Count int64
-	}
-	var genreCounts []genreCount
-	if err := r.db.WithContext(ctx).
-		Model(&models.Recommendation{}).
-		Select("genre, count(*) as count").
-		Group("genre").
-		Order("count DESC").
-		Find(&genreCounts).Error; err != nil {
-		return nil, fmt.Errorf("failed to get genre distribution: %w", err)
-	}
-
-	stats.GenreDistribution = make([]struct {
-		Genre string
-		Count int64
-	}, len(genreCounts))
-	for i, gc := range genreCounts {
-		stats.GenreDistribution[i] = struct {
-			Genre string
-			Count int64
-		}{
-			Genre: gc.Genre,
-			Count: gc.Count,
+	// Get genre distribution, overall and per type
+	for _, g := range []struct {
+		typ string
+		dst *[]GenreCount
+	}{{"", &stats.GenreDistribution}, {models.TypeMovie, &stats.MovieGenres}, {models.TypeTVShow, &stats.TVShowGenres}} {
+		q := recs()
+		if g.typ != "" {
+			q = q.Where("type = ?", g.typ)
+		}
+		if err := q.Select("genre, count(*) as count").
+			Group("genre").
+			Order("count DESC, genre").
+			Find(g.dst).Error; err != nil {
+			return nil, fmt.Errorf("failed to get genre distribution: %w", err)
 		}
 	}
 
 	// Get cache database statistics
-	if err := r.db.WithContext(ctx).Model(&models.Movie{}).Count(&stats.TotalCachedMovies).Error; err != nil {
+	if err := r.reads().WithContext(ctx).Model(&models.Movie{}).Count(&stats.TotalCachedMovies).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total cached movies: %w", err)
 	}
-	if err := r.db.WithContext(ctx).Model(&models.TVShow{}).Count(&stats.TotalCachedTVShows).Error; err != nil {
+	if err := r.reads().WithContext(ctx).Model(&models.TVShow{}).Count(&stats.TotalCachedTVShows).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total cached TV shows: %w", err)
 	}
 
 	// Get last cache update time from the most recent movie or TV show update
 	var lastMovieUpdate, lastTVShowUpdate time.Time
-	if err := r.db.WithContext(ctx).Model(&models.Movie{}).Order("updated_at DESC").Limit(1).Pluck("updated_at", &lastMovieUpdate).Error; err != nil {
+	if err := r.reads().WithContext(ctx).Model(&models.Movie{}).Order("updated_at DESC").Limit(1).Pluck("updated_at", &lastMovieUpdate).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to get last movie update: %w", err)
 		}
 	}
-	if err := r.db.WithContext(ctx).Model(&models.TVShow{}).Order("updated_at DESC").Limit(1).Pluck("updated_at", &lastTVShowUpdate).Error; err != nil {
+	if err := r.reads().WithContext(ctx).Model(&models.TVShow{}).Order("updated_at DESC").Limit(1).Pluck("updated_at", &lastTVShowUpdate).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to get last TV show update: %w", err)
 		}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/icco/recommender/lib/dbtest"
+	"github.com/icco/recommender/lib/vectors"
 	"github.com/icco/recommender/models"
 	"gorm.io/gorm"
 )
@@ -20,6 +21,10 @@ func testDB(t *testing.T) *gorm.DB {
 	if err := db.AutoMigrate(
 		&models.Recommendation{}, &models.Movie{}, &models.TVShow{},
 		&models.GenerationRun{}, &models.ExternalSignal{}, &models.OAuthToken{},
+		&models.TasteProfile{}, &models.Pin{}, &models.DateNote{},
+		&models.Spotlight{}, &models.ShowDecision{}, &models.Vote{}, &models.UserPreference{},
+		&models.ChatMessage{}, &models.TitleEmbedding{}, &models.BecauseRow{},
+		&models.PromptRollout{},
 	); err != nil {
 		t.Fatal(err)
 	}
@@ -27,7 +32,7 @@ func testDB(t *testing.T) *gorm.DB {
 }
 
 func testRecommender(db *gorm.DB) *Recommender {
-	return &Recommender{db: db}
+	return &Recommender{db: db, vectors: vectors.NewMemory()}
 }
 
 func TestGetRecommendationDates_distinctDaysAndPagination(t *testing.T) {
@@ -41,14 +46,14 @@ func TestGetRecommendationDates_distinctDaysAndPagination(t *testing.T) {
 	for _, title := range []string{"M1", "M2"} {
 		if err := db.Create(&models.Recommendation{
 			Date: day1, Title: title, Type: models.TypeMovie, Year: 2020,
-			Rating: 8, Genre: testGenreComedy, TMDbID: 1,
+			Rating: new(8.0), Genre: testGenreComedy, TMDbID: 1,
 		}).Error; err != nil {
 			t.Fatal(err)
 		}
 	}
 	if err := db.Create(&models.Recommendation{
 		Date: day2, Title: "M3", Type: models.TypeMovie, Year: 2021,
-		Rating: 7, Genre: "Drama", TMDbID: 2,
+		Rating: new(7.0), Genre: "Drama", TMDbID: 2,
 	}).Error; err != nil {
 		t.Fatal(err)
 	}
@@ -88,6 +93,30 @@ func TestGetRecommendationDates_distinctDaysAndPagination(t *testing.T) {
 	}
 }
 
+func TestRecommendationDaysBetween(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	// Two picks yesterday, one today, one pre-generated for tomorrow.
+	for i, d := range []time.Time{today.AddDate(0, 0, -1), today.AddDate(0, 0, -1).Add(3 * time.Hour), today, today.AddDate(0, 0, 1)} {
+		if err := db.Create(&models.Recommendation{
+			Date: d, Title: "M", Type: models.TypeMovie, Year: 2020,
+			Rating: new(8.0), Genre: testGenreComedy, TMDbID: i + 1,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	days, err := r.RecommendationDaysBetween(t.Context(), today.AddDate(0, 0, -7), today.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || !days[0].Equal(today.AddDate(0, 0, -1)) || !days[1].Equal(today) {
+		t.Fatalf("days = %v, want yesterday and today (tomorrow is unpublished)", days)
+	}
+}
+
 func TestGetRecommendationsForDate_sameUTCCalendarDay(t *testing.T) {
 	db := testDB(t)
 	r := testRecommender(db)
@@ -96,7 +125,7 @@ func TestGetRecommendationsForDate_sameUTCCalendarDay(t *testing.T) {
 	stored := time.Date(2026, 3, 27, 0, 0, 0, 0, time.UTC)
 	if err := db.Create(&models.Recommendation{
 		Date: stored, Title: "Abbott Elementary", Type: models.TypeTVShow, Year: 2021,
-		Rating: 0, Genre: testGenreComedy, TMDbID: 1,
+		Genre: testGenreComedy, TMDbID: 1,
 	}).Error; err != nil {
 		t.Fatal(err)
 	}
@@ -150,7 +179,8 @@ func TestDidRunToday(t *testing.T) {
 	}
 
 	// A successful run counts.
-	if err := db.Create(&models.GenerationRun{Date: day, Status: models.RunStatusOK, MovieCount: 4}).Error; err != nil {
+	if err := db.Model(&models.GenerationRun{}).Where(`"date" = ?`, day).
+		Updates(map[string]any{"status": models.RunStatusOK, "movie_count": 4}).Error; err != nil {
 		t.Fatal(err)
 	}
 	done, err = r.DidRunToday(ctx, day)
@@ -161,3 +191,61 @@ func TestDidRunToday(t *testing.T) {
 		t.Fatal("expected done after a successful run")
 	}
 }
+
+func TestGetStats_genresByTypeAndRange(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	march, april := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	for i, rec := range []models.Recommendation{
+		{Date: march, Title: "M1", Type: models.TypeMovie, Genre: testGenreComedy},
+		{Date: april, Title: "M2", Type: models.TypeMovie, Genre: testGenreComedy},
+		{Date: april, Title: "M3", Type: models.TypeMovie, Genre: "Drama"},
+		{Date: april, Title: "S1", Type: models.TypeTVShow, Genre: "Drama"},
+	} {
+		rec.Year, rec.TMDbID = 2020, i+1
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := r.GetStats(t.Context(), StatsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.TotalRecommendations != 4 || len(all.GenreDistribution) != 2 || len(all.MovieGenres) != 2 || len(all.TVShowGenres) != 1 {
+		t.Fatalf("all time = %+v", all)
+	}
+	if g := all.MovieGenres[0]; g.Genre != testGenreComedy || g.Count != 2 {
+		t.Errorf("top movie genre = %+v, want Comedy x2", g)
+	}
+
+	inMarch, err := r.GetStats(t.Context(), StatsFilter{From: march, To: march.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inMarch.TotalRecommendations != 1 || len(inMarch.MovieGenres) != 1 || len(inMarch.TVShowGenres) != 0 {
+		t.Errorf("March = %+v, want just M1", inMarch)
+	}
+}
+
+func TestUseReadReplica(t *testing.T) {
+	primary, replica := testDB(t), testDB(t)
+	r := testRecommender(primary)
+	r.UseReadReplica(replica)
+	ctx := t.Context()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	if err := primary.Create(&models.Recommendation{Date: day, Title: "Heat", Type: models.TypeMovie, Year: 1995}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// The "replica" here never receives the write, standing in for lag.
+	if recs, err := r.GetRecommendationsForDate(ctx, day); err != nil || len(recs) != 1 {
+		t.Fatalf("day page should read the primary: %d recs, %v", len(recs), err)
+	}
+	if _, total, err := r.GetArchive(ctx, ArchiveFilter{}, 1, 10); err != nil || total != 0 {
+		t.Errorf("archive should read the replica: total %d, %v", total, err)
+	}
+	if stats, err := r.GetStats(ctx, StatsFilter{}); err != nil || stats.TotalRecommendations != 0 {
+		t.Errorf("stats should read the replica: %+v, %v", stats, err)
+	}
+}
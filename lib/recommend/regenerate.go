@@ -0,0 +1,44 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+type regenerateKey struct{}
+
+// WithRegenerate marks ctx so GenerateSlot under it generates a day and slot
+// again even though a successful run exists. The existing picks stay until
+// the new set replaces them in one transaction; if generation fails, or the
+// model does and only the scorer's picks are left, they are kept and the
+// run is restored as it was.
+func WithRegenerate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, regenerateKey{}, true)
+}
+
+func regenerating(ctx context.Context) bool {
+	v, _ := ctx.Value(regenerateKey{}).(bool)
+	return v
+}
+
+// lastRun returns the day and slot's GenerationRun, or a zero one if there
+// is none.
+func (r *Recommender) lastRun(ctx context.Context, date time.Time, runContext string) (models.GenerationRun, error) {
+	var run models.GenerationRun
+	if err := r.db.WithContext(ctx).Where(`"date" = ? AND context = ?`, date, runContext).Limit(1).Find(&run).Error; err != nil {
+		return models.GenerationRun{}, fmt.Errorf("load run: %w", err)
+	}
+	return run, nil
+}
+
+// restoreRun puts back run, as it was before a failed regeneration claimed
+// it, so the day still counts as generated.
+func (r *Recommender) restoreRun(ctx context.Context, run models.GenerationRun) error {
+	if err := r.db.WithContext(context.WithoutCancel(ctx)).Save(&run).Error; err != nil {
+		return fmt.Errorf("restore run: %w", err)
+	}
+	return nil
+}
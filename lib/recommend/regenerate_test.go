@@ -0,0 +1,122 @@
+package recommend
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestGenerateSlot_regenerate(t *testing.T) {
+	db := testDB(t)
+	date := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	kept := models.Movie{Title: "Heat", Year: 1995, Genre: "Crime", PlexRatingKey: "m1"}
+	dropped := models.Movie{Title: "Ronin", Year: 1998, Genre: "Action", PlexRatingKey: "m2"}
+	fresh := models.Movie{Title: "Thief", Year: 1981, Genre: "Comedy", PlexRatingKey: "m3"}
+	snoozed := models.Movie{Title: "Ran", Year: 1985, Genre: "Drama", PlexRatingKey: "m4"}
+	for _, m := range []*models.Movie{&kept, &dropped, &fresh, &snoozed} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Heat is pinned, so it is picked again; Ronin isn't, and Ran, snoozed,
+	// can't be.
+	if err := db.Create(&models.Pin{Date: date, Type: models.TypeMovie, Title: kept.Title, Year: kept.Year, MovieID: &kept.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	watchedAt := date.Add(22 * time.Hour)
+	until := date.AddDate(0, 0, 7)
+	old := []models.Recommendation{
+		{Date: date, Slot: "daily", Title: kept.Title, Type: models.TypeMovie, MovieID: &kept.ID, Pinned: true,
+			Feedback: models.FeedbackWatched, FeedbackAt: &watchedAt},
+		{Date: date, Slot: "daily", Title: dropped.Title, Type: models.TypeMovie, MovieID: &dropped.ID},
+		{Date: date, Slot: "daily", Title: snoozed.Title, Type: models.TypeMovie, MovieID: &snoozed.ID, SnoozedUntil: &until},
+	}
+	for i := range old {
+		if err := db.Create(&old[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := models.GenerationRun{Date: date, Context: "daily", Status: models.RunStatusOK, Attempts: 1, MovieCount: 3}
+	if err := db.Create(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := &Recommender{db: db, model: "test", provider: "gemini"}
+	for voter, rec := range map[string]models.Recommendation{"alice": old[0], "bob": old[1]} {
+		if err := r.CastVote(t.Context(), date, voter, rec.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := WithRegenerate(t.Context())
+
+	// A failed regeneration keeps the day as it was.
+	r.chat = fakeChatter{reply: "not json"}
+	if err := r.GenerateSlot(ctx, date, DailySlot); err == nil {
+		t.Fatal("regenerate with a broken model = nil error, want it to fail")
+	}
+	recs, err := r.GetRecommendationsForDate(t.Context(), date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uint
+	for _, rec := range recs {
+		got = append(got, rec.ID)
+	}
+	if len(got) != 3 || !slices.Contains(got, old[0].ID) || !slices.Contains(got, old[1].ID) || !slices.Contains(got, old[2].ID) {
+		t.Errorf("picks after failed regeneration = %+v, want the original three", recs)
+	}
+	var after models.GenerationRun
+	db.First(&after, run.ID)
+	if after.Status != models.RunStatusOK || after.Attempts != 1 || after.MovieCount != 3 {
+		t.Errorf("run after failed regeneration = %+v, want it restored", after)
+	}
+	tally, err := r.VoteTally(t.Context(), date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tally.By["alice"] != old[0].ID || tally.By["bob"] != old[1].ID {
+		t.Errorf("votes after failed regeneration = %v, want them untouched", tally.By)
+	}
+
+	// A successful one swaps the picks and moves or drops the votes, keeping
+	// the household's feedback and snoozes.
+	r.chat = fakeChatter{reply: fmt.Sprintf(`{"movies":[{"id":%d,"explanation":"new"}],"tvshows":[]}`, fresh.ID)}
+	if err := r.GenerateSlot(ctx, date, DailySlot); err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	recs, err = r.GetRecommendationsForDate(t.Context(), date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]uint{}
+	byTitle := map[string]models.Recommendation{}
+	for _, rec := range recs {
+		ids[rec.Title] = rec.ID
+		byTitle[rec.Title] = rec
+	}
+	if len(recs) != 3 || ids["Heat"] == 0 || ids["Thief"] == 0 || ids["Ran"] != old[2].ID {
+		t.Fatalf("regenerated picks = %+v, want Heat, Thief, and the snoozed Ran", recs)
+	}
+	if heat := byTitle["Heat"]; heat.Feedback != models.FeedbackWatched || heat.FeedbackAt == nil || !heat.FeedbackAt.Equal(watchedAt) {
+		t.Errorf("new Heat feedback = %q at %v, want the old pick's", heat.Feedback, heat.FeedbackAt)
+	}
+	active, _, err := r.snoozedKeys(t.Context(), date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := active[candKey(models.TypeMovie, snoozed.ID)]; !ok {
+		t.Errorf("snoozed keys after regeneration = %v, want Ran still snoozed", active)
+	}
+	tally, err = r.VoteTally(t.Context(), date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tally.Total != 1 || tally.By["alice"] != ids["Heat"] {
+		t.Errorf("votes = %v, want alice's moved to the new Heat and bob's for Ronin gone", tally.By)
+	}
+	if done, _ := r.DidRun(t.Context(), date, DailySlot); !done {
+		t.Error("regenerated day isn't marked done")
+	}
+}
@@ -0,0 +1,48 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultModelRetryUntil is the UTC hour retries stop when only
+// GENERATION_RETRY_INTERVAL is set.
+const DefaultModelRetryUntil = 12
+
+// ModelRetry has a daily run whose model call failed (e.g. a provider
+// outage) try again every Interval until the Until hour (UTC) of its day,
+// and only then settle for scored picks. The zero value settles at once.
+type ModelRetry struct {
+	Interval time.Duration
+	Until    int // hour of the day, 0–23
+}
+
+// next returns when to try again after err failed a run for date at now, or
+// false to fall back now: retries are off, the cutoff would pass first, or
+// err is the daily call cap, which a retry today can't get past.
+func (m ModelRetry) next(date, now time.Time, err error) (time.Time, bool) {
+	if m.Interval <= 0 || errors.Is(err, ErrLLMDailyCap) {
+		return time.Time{}, false
+	}
+	at := now.Add(m.Interval)
+	if at.After(date.UTC().Truncate(24 * time.Hour).Add(time.Duration(m.Until) * time.Hour)) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// RetryLaterError is returned by GenerateSlot when the model failed and the
+// run should be tried again at At rather than fall back to scored picks.
+type RetryLaterError struct {
+	At  time.Time
+	Err error
+}
+
+func (e *RetryLaterError) Error() string {
+	return fmt.Sprintf("model picks failed, retrying at %s: %v", e.At.UTC().Format(time.Kitchen), e.Err)
+}
+
+func (e *RetryLaterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,42 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestModelRetry_next(t *testing.T) {
+	date := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	failed := errors.New("gemini: 503")
+	r := ModelRetry{Interval: 30 * time.Minute, Until: 9}
+	for _, tt := range []struct {
+		name   string
+		retry  ModelRetry
+		now    time.Time
+		err    error
+		want   time.Time
+		wantOK bool
+	}{
+		{"disabled", ModelRetry{}, date.Add(2 * time.Hour), failed, time.Time{}, false},
+		{"before cutoff", r, date.Add(2 * time.Hour), failed, date.Add(150 * time.Minute), true},
+		{"at cutoff", r, date.Add(8*time.Hour + 30*time.Minute), failed, date.Add(9 * time.Hour), true},
+		{"past cutoff", r, date.Add(8*time.Hour + 45*time.Minute), failed, time.Time{}, false},
+		{"daily cap", r, date.Add(2 * time.Hour), fmt.Errorf("chat: %w", ErrLLMDailyCap), time.Time{}, false},
+	} {
+		got, ok := tt.retry.next(date, tt.now, tt.err)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: next = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRetryLaterError(t *testing.T) {
+	failed := errors.New("gemini: 503")
+	err := fmt.Errorf("generate: %w", &RetryLaterError{At: time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), Err: failed})
+	var later *RetryLaterError
+	if !errors.As(err, &later) || !errors.Is(err, failed) {
+		t.Errorf("%v: want a RetryLaterError wrapping the model error", err)
+	}
+}
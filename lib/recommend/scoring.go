@@ -0,0 +1,169 @@
+package recommend
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// scoreWeights scale each scoring feature. Rating (0–1 from the 10-point
+// rating) and novelty keep the weights the shortlist has always used.
+var scoreWeights = struct {
+	Rating, Recency, Affinity, Novelty, RuntimeFit, Watchlist, Anniversary, Snoozed float64
+}{
+	Rating:     2.0,
+	Recency:    0.5,
+	Affinity:   1.0,
+	Novelty:    1.0,
+	RuntimeFit: 0.5,
+	Watchlist:  1.5,
+	// A release anniversary on the day is a nudge, not a trump card.
+	Anniversary: 1.0,
+	// A title back from a snooze was wanted, just not that night.
+	Snoozed: 1.5,
+}
+
+const (
+	// recencyYears is how far back the recency feature reaches: a title from
+	// this year scores 1, one recencyYears old or more scores 0.
+	recencyYears = 30
+	// runtimeTolerance is the fraction off the typical runtime at which the
+	// runtime-fit feature reaches 0.
+	runtimeTolerance = 0.5
+	// unknownRating stands in for a title with no rating: a typical score,
+	// so a missing rating neither buries a title nor lifts it over rated ones.
+	unknownRating = 6.0
+)
+
+// scoreBreakdown scores c from its features. It needs no LLM, so it also
+// ranks the fallback picks when the model is unavailable. Rank is left 0.
+func scoreBreakdown(c candidate) models.ScoreBreakdown {
+	b := models.ScoreBreakdown{
+		Rating:     ratingFeature(c.Rating) * scoreWeights.Rating,
+		Recency:    c.Recency * scoreWeights.Recency,
+		Affinity:   c.Affinity * scoreWeights.Affinity,
+		RuntimeFit: c.RuntimeFit * scoreWeights.RuntimeFit,
+	}
+	if c.ViewCount == 0 {
+		b.Novelty = scoreWeights.Novelty
+	}
+	if c.Watchlisted {
+		b.Watchlist = scoreWeights.Watchlist
+	}
+	if c.Anniversary > 0 {
+		b.Anniversary = scoreWeights.Anniversary
+	}
+	if c.Resurfacing {
+		b.Snoozed = scoreWeights.Snoozed
+	}
+	b.Total = b.Rating + b.Recency + b.Affinity + b.Novelty + b.RuntimeFit + b.Watchlist + b.Anniversary + b.Snoozed
+	return b
+}
+
+// ratingFeature is a 10-point rating scaled to 0–1, with unknownRating
+// standing in for a missing one.
+func ratingFeature(rating *float64) float64 {
+	if rating == nil {
+		return unknownRating / 10
+	}
+	return *rating / 10
+}
+
+// scoreCandidate is scoreBreakdown's total.
+func scoreCandidate(c candidate) float64 {
+	return scoreBreakdown(c).Total
+}
+
+// annotateScores attaches each rec's score breakdown, ranked among the pool
+// (the day's eligible titles) by type. Recs not in the pool are left alone.
+func annotateScores(recs []models.Recommendation, pool []candidate) {
+	byKey := make(map[string]candidate, len(pool))
+	ranks := make(map[string]int, len(pool))
+	perType := make(map[string]int)
+	for _, c := range rankCandidates(pool) {
+		k := candKey(c.Type, c.ID)
+		byKey[k] = c
+		perType[c.Type]++
+		ranks[k] = perType[c.Type]
+	}
+	for i := range recs {
+		k := recKey(recs[i])
+		c, ok := byKey[k]
+		if !ok {
+			continue
+		}
+		b := scoreBreakdown(c)
+		b.Rank = ranks[k]
+		if recs[i].Score != nil {
+			b.DiversitySwap = recs[i].Score.DiversitySwap
+		}
+		recs[i].Score = &b
+	}
+}
+
+// rankCandidates returns cands best-first by score, ties broken by ID.
+func rankCandidates(cands []candidate) []candidate {
+	sorted := slices.Clone(cands)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := scoreCandidate(sorted[i]), scoreCandidate(sorted[j])
+		if si == sj {
+			return sorted[i].ID < sorted[j].ID // stable tie-break
+		}
+		return si > sj
+	})
+	return sorted
+}
+
+// recencyFeature is 1 for a title released in date's year, falling linearly
+// to 0 at recencyYears old. Unknown years score 0.
+func recencyFeature(year int, date time.Time) float64 {
+	if year <= 0 {
+		return 0
+	}
+	age := float64(date.Year() - year)
+	return min(1, max(0, 1-age/recencyYears))
+}
+
+// runtimeFitFeature is 1 at the typical runtime, falling linearly to 0 at
+// runtimeTolerance away from it. It is 0 when either runtime is unknown.
+func runtimeFitFeature(runtime, typical int) float64 {
+	if runtime <= 0 || typical <= 0 {
+		return 0
+	}
+	off := float64(runtime-typical) / float64(typical)
+	if off < 0 {
+		off = -off
+	}
+	return max(0, 1-off/runtimeTolerance)
+}
+
+// typicalRuntime is the median runtime of watched movies, or 0 when none
+// have been watched.
+func typicalRuntime(movies []models.Movie) int {
+	var rts []int
+	for _, m := range movies {
+		if m.ViewCount > 0 && m.Runtime > 0 {
+			rts = append(rts, m.Runtime)
+		}
+	}
+	if len(rts) == 0 {
+		return 0
+	}
+	slices.Sort(rts)
+	return rts[len(rts)/2]
+}
+
+// fallbackPicks stands in for the model's response: the top-scored movies
+// and shows in rank order, so slotting still fills its roles.
+func fallbackPicks(movies, tvshows []candidate) pickResponse {
+	var pr pickResponse
+	for _, c := range rankCandidates(movies) {
+		pr.Movies = append(pr.Movies, pick{ID: c.ID})
+	}
+	for _, c := range rankCandidates(tvshows) {
+		pr.TVShows = append(pr.TVShows, pick{ID: c.ID})
+	}
+	return pr
+}
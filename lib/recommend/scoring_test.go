@@ -0,0 +1,152 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"google.golang.org/genai"
+)
+
+func TestScoreBreakdown_features(t *testing.T) {
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := candidate{ID: 1, Rating: new(7.0), ViewCount: 1}
+	for _, tc := range []struct {
+		name   string
+		better candidate
+	}{
+		{"recency", func() candidate { c := base; c.Recency = recencyFeature(2025, date); return c }()},
+		{"affinity", func() candidate { c := base; c.Affinity = 0.8; return c }()},
+		{"novelty", func() candidate { c := base; c.ViewCount = 0; return c }()},
+		{"runtime fit", func() candidate { c := base; c.RuntimeFit = runtimeFitFeature(110, 100); return c }()},
+		{"watchlist", func() candidate { c := base; c.Watchlisted = true; return c }()},
+	} {
+		if scoreCandidate(tc.better) <= scoreCandidate(base) {
+			t.Errorf("%s should raise the score", tc.name)
+		}
+	}
+
+	b := scoreBreakdown(candidate{Rating: new(10.0), ViewCount: 0, Watchlisted: true, Recency: 1, Affinity: 1, RuntimeFit: 1})
+	want := scoreWeights.Rating + scoreWeights.Recency + scoreWeights.Affinity + scoreWeights.Novelty + scoreWeights.RuntimeFit + scoreWeights.Watchlist
+	if b.Total != want {
+		t.Errorf("Total = %v, want %v", b.Total, want)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	date := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	for year, want := range map[int]float64{2026: 1, 2011: 0.5, 1990: 0, 0: 0} {
+		if got := recencyFeature(year, date); got != want {
+			t.Errorf("recencyFeature(%d) = %v, want %v", year, got, want)
+		}
+	}
+	for _, tc := range []struct {
+		runtime, typical int
+		want             float64
+	}{
+		{100, 100, 1}, {125, 100, 0.5}, {75, 100, 0.5}, {200, 100, 0}, {100, 0, 0}, {0, 100, 0},
+	} {
+		if got := runtimeFitFeature(tc.runtime, tc.typical); got != tc.want {
+			t.Errorf("runtimeFitFeature(%d, %d) = %v, want %v", tc.runtime, tc.typical, got, tc.want)
+		}
+	}
+	movies := []models.Movie{{Runtime: 90, ViewCount: 1}, {Runtime: 200}, {Runtime: 120, ViewCount: 2}, {Runtime: 100, ViewCount: 1}}
+	if got := typicalRuntime(movies); got != 100 {
+		t.Errorf("typicalRuntime = %d, want 100 (median of watched)", got)
+	}
+}
+
+func TestRatingFeature_unknownIsNotLow(t *testing.T) {
+	unrated := scoreCandidate(candidate{ID: 1})
+	low := scoreCandidate(candidate{ID: 2, Rating: new(3.0)})
+	high := scoreCandidate(candidate{ID: 3, Rating: new(8.5)})
+	if unrated <= low || unrated >= high {
+		t.Errorf("unrated scored %.2f; want between low (%.2f) and high (%.2f)", unrated, low, high)
+	}
+	shortlist := formatShortlist([]candidate{{ID: 1, Title: "Unknown", Year: 2001}, {ID: 2, Title: "Known", Year: 2002, Rating: new(7.3)}})
+	for _, want := range []string{"Unknown (2001) — Rating: unrated", "Known (2002) — Rating: 7.3"} {
+		if !strings.Contains(shortlist, want) {
+			t.Errorf("shortlist missing %q:\n%s", want, shortlist)
+		}
+	}
+}
+
+func TestFallbackPicks_rankOrder(t *testing.T) {
+	movies := []candidate{mkCand(1, 5, 0), mkCand(2, 9, 0), mkCand(3, 7, 0)}
+	pr := fallbackPicks(movies, nil)
+	var got []uint
+	for _, p := range pr.Movies {
+		got = append(got, p.ID)
+	}
+	if fmt.Sprint(got) != "[2 3 1]" {
+		t.Errorf("fallback order = %v, want [2 3 1]", got)
+	}
+}
+
+type failingChatter struct{}
+
+func (failingChatter) Complete(context.Context, string, string, *genai.Schema) (string, error) {
+	return "", errors.New("model down")
+}
+
+func TestGenerateSlot_fallsBackToScorer(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	date := time.Date(2026, 7, 8, 0, 0, 0, 0, time.UTC)
+	for _, m := range []models.Movie{
+		{Title: "Good", Year: 2001, Rating: new(9.0), Genre: "Drama", PlexRatingKey: "m1"},
+		{Title: "Fine", Year: 2015, Rating: new(6.0), Genre: "Comedy", PlexRatingKey: "m2"},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Recommender{db: db, chat: failingChatter{}, model: "test"}
+	if err := r.GenerateRecommendations(ctx, date); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d recs, want 2 scorer picks", len(recs))
+	}
+	var run models.GenerationRun
+	if err := db.Where(`"date" = ?`, date).Take(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != models.RunStatusOK || run.Model != FallbackModel {
+		t.Errorf("run = %s/%s, want ok/%s", run.Status, run.Model, FallbackModel)
+	}
+}
+
+func TestAnnotateScores_rankByType(t *testing.T) {
+	pool := []candidate{
+		mkCand(1, 9, 0), mkCand(2, 5, 0), mkCand(3, 7, 0),
+		{ID: 1, Type: models.TypeTVShow, Rating: new(4.0)},
+	}
+	recs := []models.Recommendation{
+		toRec(pool[2], "", time.Time{}),
+		toRec(pool[1], "", time.Time{}),
+		toRec(pool[3], "", time.Time{}),
+	}
+	recs[1].Score = &models.ScoreBreakdown{DiversitySwap: true}
+	annotateScores(recs, pool)
+
+	for i, want := range []int{2, 3, 1} {
+		if recs[i].Score == nil || recs[i].Score.Rank != want {
+			t.Fatalf("rec %d score = %+v, want rank %d", i, recs[i].Score, want)
+		}
+	}
+	if got := recs[0].Score.Total; got != scoreCandidate(pool[2]) {
+		t.Errorf("total = %v, want %v", got, scoreCandidate(pool[2]))
+	}
+	if !recs[1].Score.DiversitySwap || recs[0].Score.DiversitySwap {
+		t.Error("diversity swap flag should carry over only where set")
+	}
+}
@@ -0,0 +1,70 @@
+package recommend
+
+import (
+	"time"
+
+	"github.com/icco/recommender/lib/calendar"
+)
+
+// Settings are the generation knobs that can change while the server runs.
+// ApplySettings swaps the whole set at once, and each run reads it once, so
+// a run sees either the old settings or the new ones, never a mix.
+type Settings struct {
+	Weather             WeatherSource       // nil disables weather context
+	Calendar            calendar.Calendar   // nil disables holiday themes
+	CollectionCooldowns CollectionCooldowns // nil uses DefaultCollectionCooldowns
+	Diversity           *DiversityRules     // nil uses DefaultDiversityRules
+	DailyMovies         int                 // daily slot composition; 0 keeps DailySlot's
+	DailyTVShows        int
+	MissingDaysWindow   int           // days MissingDays checks; 0 disables the check
+	MoodCacheSize       int           // LLM mood orders kept; 0 uses DefaultMoodCacheSize
+	MoodCacheTTL        time.Duration // how long one is reused; 0 uses DefaultMoodCacheTTL
+	Blackouts           Blackouts     // days generation is paused, e.g. vacations
+	SpotlightDay        *time.Weekday // weekly day a person is spotlighted automatically; nil disables
+	NightlyMinutes      int           // typical evening viewing window; 0 uses DefaultNightlyMinutes
+	Quality             QualityFilter // minimums candidates must meet before the prompt is built
+	ModelRetry          ModelRetry    // retrying a failed daily model call before falling back; zero falls back at once
+	Canary              PromptCanary  // canary prompt rollout; zero uses the built-in prompts only
+	Kids                KidsFilter    // which titles the kids' picks may use
+}
+
+// ApplySettings replaces the current settings. Runs already in progress
+// finish with the settings they started with.
+func (r *Recommender) ApplySettings(s Settings) {
+	r.settings.Store(&s)
+	if r.moods == nil {
+		return
+	}
+	size, ttl := DefaultMoodCacheSize, DefaultMoodCacheTTL
+	if s.MoodCacheSize > 0 {
+		size = s.MoodCacheSize
+	}
+	if s.MoodCacheTTL > 0 {
+		ttl = s.MoodCacheTTL
+	}
+	r.moods.Resize(size, ttl)
+}
+
+// currentSettings returns the settings in effect; the zero Settings (all
+// defaults, optional context off) until ApplySettings is called.
+func (r *Recommender) currentSettings() *Settings {
+	if s := r.settings.Load(); s != nil {
+		return s
+	}
+	return &Settings{}
+}
+
+// compose applies the configured daily composition to slot. Time-of-day
+// slots keep their own.
+func (s *Settings) compose(slot Slot) Slot {
+	if slot.Name != DailySlot.Name {
+		return slot
+	}
+	if s.DailyMovies > 0 {
+		slot.Movies = s.DailyMovies
+	}
+	if s.DailyTVShows > 0 {
+		slot.TVShows = s.DailyTVShows
+	}
+	return slot
+}
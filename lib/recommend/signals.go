@@ -10,6 +10,7 @@ import (
 
 	"github.com/icco/gutil/logging"
 	"github.com/icco/recommender/lib/anilist"
+	"github.com/icco/recommender/lib/mal"
 	"github.com/icco/recommender/lib/trakt"
 	"github.com/icco/recommender/models"
 	"go.uber.org/zap"
@@ -189,8 +190,48 @@ func itoaOrEmpty(n int) string {
 	return strconv.Itoa(n)
 }
 
-// anilistSource syncs a user's AniList anime scores, matched to owned Plex titles
-// by title + year.
+// animeEntry is a rated or completed title from an anime list site.
+type animeEntry struct {
+	Title     string
+	Year      int
+	Score     float64 // 0..10; 0 = unscored
+	Completed bool
+}
+
+// syncAnimeList upserts a score signal for each scored entry and a watched
+// signal for each completed one, for titles owned in Plex. Completed titles
+// then count as watched elsewhere, so they aren't offered as fresh TV picks.
+func syncAnimeList(ctx context.Context, db *gorm.DB, source string, entries []animeEntry) int {
+	l := logging.FromContext(ctx)
+	count := 0
+	for _, e := range entries {
+		movieID, tvID := matchByTitleYear(ctx, db, e.Title, e.Year)
+		if movieID == nil && tvID == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", strings.ToLower(e.Title), e.Year)
+		var sigs []models.ExternalSignal
+		if e.Score > 0 {
+			sigs = append(sigs, models.ExternalSignal{Kind: models.SignalKindScore, ExternalRef: "score:" + key, Value: e.Score})
+		}
+		if e.Completed {
+			sigs = append(sigs, models.ExternalSignal{Kind: models.SignalKindWatched, ExternalRef: "watched:" + key, Value: 1})
+		}
+		for _, sig := range sigs {
+			sig.Source, sig.MovieID, sig.TVShowID = source, movieID, tvID
+			if err := upsertSignal(ctx, db, sig); err != nil {
+				l.Warnw("upsert anime signal failed", "source", source, "ref", sig.ExternalRef, zap.Error(err))
+				continue
+			}
+			count++
+		}
+	}
+	l.Infow("anime list sync", "source", source, "entries", len(entries), "signals", count)
+	return count
+}
+
+// anilistSource syncs a user's AniList anime scores and completed titles,
+// matched to owned Plex titles by title + year.
 type anilistSource struct {
 	db       *gorm.DB
 	client   *anilist.Client
@@ -199,31 +240,40 @@ type anilistSource struct {
 
 func (s *anilistSource) Name() string { return models.SourceAniList }
 
-// Sync fetches the AniList list and upserts score signals for titles owned in Plex.
+// Sync fetches the AniList list and upserts signals for titles owned in Plex.
 func (s *anilistSource) Sync(ctx context.Context) (int, error) {
-	l := logging.FromContext(ctx)
 	entries, err := s.client.List(ctx, s.username)
 	if err != nil {
 		return 0, fmt.Errorf("anilist list: %w", err)
 	}
-	count := 0
+	out := make([]animeEntry, 0, len(entries))
 	for _, e := range entries {
-		movieID, tvID := matchByTitleYear(ctx, s.db, e.Title, e.Year)
-		if movieID == nil && tvID == nil {
-			continue
-		}
-		ref := fmt.Sprintf("score:%s:%d", strings.ToLower(e.Title), e.Year)
-		if err := upsertSignal(ctx, s.db, models.ExternalSignal{
-			Source: models.SourceAniList, ExternalRef: ref, Kind: models.SignalKindScore,
-			MovieID: movieID, TVShowID: tvID, Value: e.Score,
-		}); err != nil {
-			l.Warnw("upsert anilist signal failed", "ref", ref, zap.Error(err))
-			continue
-		}
-		count++
+		out = append(out, animeEntry(e))
 	}
-	l.Infow("anilist sync", "entries", len(entries), "matched", count)
-	return count, nil
+	return syncAnimeList(ctx, s.db, models.SourceAniList, out), nil
+}
+
+// malSource syncs a user's MyAnimeList scores and completed titles, matched
+// to owned Plex titles by title + year.
+type malSource struct {
+	db       *gorm.DB
+	client   *mal.Client
+	username string
+}
+
+func (s *malSource) Name() string { return models.SourceMAL }
+
+// Sync fetches the MyAnimeList list and upserts signals for titles owned in Plex.
+func (s *malSource) Sync(ctx context.Context) (int, error) {
+	entries, err := s.client.List(ctx, s.username)
+	if err != nil {
+		return 0, fmt.Errorf("mal list: %w", err)
+	}
+	out := make([]animeEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, animeEntry(e))
+	}
+	return syncAnimeList(ctx, s.db, models.SourceMAL, out), nil
 }
 
 // matchByTitleYear finds an owned Plex title by case-insensitive title + year,
@@ -246,6 +296,12 @@ type SignalConfig struct {
 	TraktClientID     string
 	TraktClientSecret string
 	AniListUsername   string
+	// MALClientID and MALUsername enable MyAnimeList; the list must be public.
+	MALClientID string
+	MALUsername string
+	// TraktExportList is the slug of a list on the connected Trakt account
+	// that receives each day's movie picks; empty disables the export.
+	TraktExportList string
 }
 
 // traktClient returns a Trakt client if credentials are configured, else nil.
@@ -265,6 +321,9 @@ func (r *Recommender) configuredSources() []SignalSource {
 	if r.sigCfg.AniListUsername != "" {
 		out = append(out, &anilistSource{db: r.db, client: anilist.NewClient(), username: r.sigCfg.AniListUsername})
 	}
+	if r.sigCfg.MALClientID != "" && r.sigCfg.MALUsername != "" {
+		out = append(out, &malSource{db: r.db, client: mal.NewClient(r.sigCfg.MALClientID), username: r.sigCfg.MALUsername})
+	}
 	return out
 }
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/icco/recommender/lib/anilist"
+	"github.com/icco/recommender/lib/mal"
 	"github.com/icco/recommender/lib/trakt"
 	"github.com/icco/recommender/models"
 )
@@ -89,6 +90,37 @@ func TestAniListSource_Sync_matchesByTitleYear(t *testing.T) {
 	}
 }
 
+func TestMALSource_Sync_completedCountsAsWatched(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	show := models.TVShow{Title: "Mushishi", Year: 2005, PlexRatingKey: "s1"}
+	if err := db.Create(&show).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[
+			{"node":{"title":"Mushishi","start_season":{"year":2005}},"list_status":{"status":"completed","score":10}}
+		],"paging":{}}`))
+	}))
+	defer srv.Close()
+
+	c := mal.NewClient("cid")
+	c.URL = srv.URL
+	s := &malSource{db: db, client: c, username: "nat"}
+	if n, err := s.Sync(ctx); err != nil || n != 2 {
+		t.Fatalf("Sync = %d, %v; want a score and a watched signal", n, err)
+	}
+	r := testRecommender(db)
+	_, tv, err := r.loadCandidates(ctx, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tv) != 0 {
+		t.Errorf("a show completed on MyAnimeList shouldn't be a fresh pick: %+v", tv)
+	}
+}
+
 func TestStoreTraktToken_upserts(t *testing.T) {
 	db := testDB(t)
 	r := &Recommender{db: db, sigCfg: SignalConfig{TraktClientID: "a", TraktClientSecret: "b"}}
@@ -0,0 +1,265 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// MoreLikeThisCount is how many titles MoreLikeThis returns.
+	MoreLikeThisCount = 5
+	// similarShortlist is how many of the closest titles the model chooses
+	// among.
+	similarShortlist = 20
+	// similarCacheSize and similarCacheTTL bound the model's answers kept,
+	// so reopening the same title doesn't spend another call.
+	similarCacheSize = 256
+	similarCacheTTL  = 24 * time.Hour
+)
+
+// ErrTitleNotFound is returned by MoreLikeThis for an unknown library title.
+var ErrTitleNotFound = errors.New("title not found in the library")
+
+// similarPick is one of the model's more-like-this choices.
+type similarPick struct {
+	ID     uint
+	Reason string
+}
+
+// MoreLikeThis returns up to MoreLikeThisCount library titles of the same
+// type as the movie or show id, each with a one-line reason, and the title
+// itself. Titles are ranked by shared genres and people, with a boost for
+// those TMDb lists as similar; the model then chooses among the closest
+// when one is configured. Nothing is saved: the results have no ID or date.
+func (r *Recommender) MoreLikeThis(ctx context.Context, typ string, id uint, now time.Time) (models.Recommendation, []models.Recommendation, error) {
+	seed, err := r.similarSeed(ctx, typ, id, now)
+	if err != nil {
+		return models.Recommendation{}, nil, err
+	}
+	movies, tvshows, err := r.loadCandidates(ctx, now)
+	if err != nil {
+		return models.Recommendation{}, nil, err
+	}
+	pool := movies
+	if typ == models.TypeTVShow {
+		pool = tvshows
+	}
+	pool = slices.DeleteFunc(pool, func(c candidate) bool { return c.ID == id })
+
+	tmdbSimilar := r.tmdbSimilar(ctx, seed)
+	ranked := rankSimilar(seed, pool, tmdbSimilar)
+	if len(ranked) > similarShortlist {
+		ranked = ranked[:similarShortlist]
+	}
+
+	picks, err := r.modelSimilar(ctx, seed, ranked)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("More-like-this model call failed; using the closest titles", "title", seed.Title, zap.Error(err))
+	}
+	if len(picks) == 0 {
+		for _, c := range ranked[:min(MoreLikeThisCount, len(ranked))] {
+			picks = append(picks, similarPick{ID: c.ID, Reason: similarReason(seed, c, tmdbSimilar)})
+		}
+	}
+
+	byID := candByID(ranked)
+	var out []models.Recommendation
+	for _, p := range picks {
+		c, ok := byID[p.ID]
+		if !ok {
+			continue
+		}
+		delete(byID, p.ID)
+		out = append(out, toRec(c, p.Reason, time.Time{}))
+		if len(out) == MoreLikeThisCount {
+			break
+		}
+	}
+	return toRec(seed, "", time.Time{}), out, nil
+}
+
+// similarSeed loads the title more-like-this starts from. It needn't be
+// eligible for recommendation itself.
+func (r *Recommender) similarSeed(ctx context.Context, typ string, id uint, now time.Time) (candidate, error) {
+	switch typ {
+	case models.TypeMovie:
+		var m models.Movie
+		res := r.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&m)
+		if res.Error != nil {
+			return candidate{}, fmt.Errorf("find movie: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return candidate{}, ErrTitleNotFound
+		}
+		return movieCandidate(m, now), nil
+	case models.TypeTVShow:
+		var s models.TVShow
+		res := r.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&s)
+		if res.Error != nil {
+			return candidate{}, fmt.Errorf("find tv show: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return candidate{}, ErrTitleNotFound
+		}
+		return tvCandidate(s, now), nil
+	}
+	return candidate{}, ErrTitleNotFound
+}
+
+// movieCandidate is m with only its stored fields, as a candidate.
+func movieCandidate(m models.Movie, now time.Time) candidate {
+	return candidate{
+		ID: m.ID, Type: models.TypeMovie, Title: m.Title, Year: m.Year, Rating: m.Rating, Genres: splitGenres(m.Genre),
+		Directors: splitGenres(m.Director), Actors: splitGenres(m.Actors), PosterURL: m.PosterURL,
+		Runtime: m.Runtime, ViewCount: m.ViewCount, TMDbID: m.TMDbID, Recency: recencyFeature(m.Year, now),
+	}
+}
+
+// tvCandidate is s with only its stored fields, as a candidate.
+func tvCandidate(s models.TVShow, now time.Time) candidate {
+	return candidate{
+		ID: s.ID, Type: models.TypeTVShow, Title: s.Title, Year: s.Year, Rating: s.Rating, Genres: splitGenres(s.Genre),
+		Actors: splitGenres(s.Actors), PosterURL: s.PosterURL, Seasons: s.Seasons, ViewCount: s.ViewCount,
+		TMDbID: s.TMDbID, EpisodeRuntime: s.EpisodeRuntime, Recency: recencyFeature(s.Year, now),
+	}
+}
+
+// tmdbSimilar returns the TMDb IDs TMDb lists as similar to seed, or nil
+// without TMDb or a TMDb ID. Failures are logged, not returned.
+func (r *Recommender) tmdbSimilar(ctx context.Context, seed candidate) map[int]struct{} {
+	if !r.TMDbEnabled() || seed.TMDbID == nil {
+		return nil
+	}
+	out := make(map[int]struct{})
+	var err error
+	if seed.Type == models.TypeMovie {
+		res, e := r.tmdb.SimilarMovies(ctx, *seed.TMDbID)
+		if err = e; res != nil {
+			for _, m := range res.Results {
+				out[m.ID] = struct{}{}
+			}
+		}
+	} else {
+		res, e := r.tmdb.SimilarTVShows(ctx, *seed.TMDbID)
+		if err = e; res != nil {
+			for _, s := range res.Results {
+				out[s.ID] = struct{}{}
+			}
+		}
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warnw("TMDb similar lookup failed; ranking without", "title", seed.Title, zap.Error(err))
+	}
+	return out
+}
+
+// similarity scores how close c is to seed: the share of genres they have
+// in common, shared directors and cast, and TMDb's similar list. The
+// candidate's usual score breaks ties.
+func similarity(seed, c candidate, tmdbSimilar map[int]struct{}) float64 {
+	s := 2 * jaccard(seed.Genres, c.Genres)
+	s += 0.5 * float64(min(shared(seed.Directors, c.Directors)+shared(seed.Actors, c.Actors), 3))
+	if c.TMDbID != nil {
+		if _, ok := tmdbSimilar[*c.TMDbID]; ok {
+			s += 2
+		}
+	}
+	return s + 0.1*scoreCandidate(c)
+}
+
+// rankSimilar returns pool closest to seed first, ties broken by ID.
+func rankSimilar(seed candidate, pool []candidate, tmdbSimilar map[int]struct{}) []candidate {
+	sorted := slices.Clone(pool)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := similarity(seed, sorted[i], tmdbSimilar), similarity(seed, sorted[j], tmdbSimilar)
+		if si == sj {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return si > sj
+	})
+	return sorted
+}
+
+// similarReason explains a pick chosen without the model.
+func similarReason(seed, c candidate, tmdbSimilar map[int]struct{}) string {
+	if c.TMDbID != nil {
+		if _, ok := tmdbSimilar[*c.TMDbID]; ok {
+			return "TMDb lists it as similar to " + seed.Title + "."
+		}
+	}
+	var common []string
+	for _, g := range c.Genres {
+		if containsFold(seed.Genres, g) {
+			common = append(common, g)
+		}
+	}
+	if len(common) > 0 {
+		return "Also " + strings.ToLower(strings.Join(common, ", ")) + ", like " + seed.Title + "."
+	}
+	return "A close match for " + seed.Title + " in your library."
+}
+
+// modelSimilar asks the model to choose from shortlist, caching its answer
+// per title for similarCacheTTL. It returns nil without a model.
+func (r *Recommender) modelSimilar(ctx context.Context, seed candidate, shortlist []candidate) ([]similarPick, error) {
+	if !r.LLMEnabled() || len(shortlist) == 0 {
+		return nil, nil
+	}
+	key := candKey(seed.Type, seed.ID)
+	if r.similar != nil {
+		if picks, ok := r.similar.Get(key); ok {
+			return picks, nil
+		}
+	}
+	kind := "movies"
+	if seed.Type == models.TypeTVShow {
+		kind = "TV shows"
+	}
+	system := "You suggest titles from the viewer's own library that are like one they chose. Use only ids from the list, and give a short, specific reason each is like it."
+	user := fmt.Sprintf("Choose up to %d titles most like this one:\n%s\nLibrary %s:\n%s",
+		MoreLikeThisCount, formatShortlist([]candidate{seed}), kind, formatShortlist(shortlist))
+	raw, err := r.chat.Complete(ctx, system, user, pickSchema())
+	if err != nil {
+		return nil, fmt.Errorf("llm: %w", err)
+	}
+	resp, err := parsePickResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+	var picks []similarPick
+	for _, p := range slices.Concat(resp.Movies, resp.TVShows) {
+		picks = append(picks, similarPick{ID: p.ID, Reason: p.Explanation})
+	}
+	if r.similar != nil && len(picks) > 0 {
+		r.similar.Add(key, picks)
+	}
+	return picks, nil
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	n := shared(a, b)
+	return float64(n) / float64(len(a)+len(b)-n)
+}
+
+// shared counts the entries of b also in a, ignoring case.
+func shared(a, b []string) int {
+	n := 0
+	for _, s := range b {
+		if containsFold(a, s) {
+			n++
+		}
+	}
+	return n
+}
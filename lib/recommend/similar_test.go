@@ -0,0 +1,73 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestRankSimilar(t *testing.T) {
+	tmdbID := 949
+	seed := candidate{ID: 1, Genres: []string{"Crime", "Thriller"}, Directors: []string{"Michael Mann"}}
+	pool := []candidate{
+		{ID: 2, Genres: []string{"Comedy"}},
+		{ID: 3, Genres: []string{"Crime"}},
+		{ID: 4, Genres: []string{"Crime", "Thriller"}, Directors: []string{"Michael Mann"}},
+		{ID: 5, Genres: []string{"Drama"}, TMDbID: &tmdbID},
+	}
+	got := rankSimilar(seed, pool, map[int]struct{}{949: {}})
+	var ids []uint
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	if fmt.Sprint(ids) != "[4 5 3 2]" {
+		t.Errorf("rankSimilar order = %v, want [4 5 3 2]", ids)
+	}
+	if r := similarReason(seed, pool[1], nil); r != "Also crime, like "+seed.Title+"." {
+		t.Errorf("similarReason = %q", r)
+	}
+}
+
+func TestMoreLikeThis(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.Movie{
+		{Title: "Heat", Year: 1995, Genre: "Crime, Thriller", PlexRatingKey: "m1"},
+		{Title: "Collateral", Year: 2004, Genre: "Crime, Thriller", PlexRatingKey: "m2"},
+		{Title: "Paddington", Year: 2014, Genre: "Family", PlexRatingKey: "m3"},
+	}
+	if err := db.Create(&movies).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := testRecommender(db)
+	like, titles, err := r.MoreLikeThis(ctx, models.TypeMovie, movies[0].ID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if like.Title != "Heat" || len(titles) != 2 || titles[0].Title != "Collateral" || titles[0].Explanation == "" {
+		t.Errorf("without a model: MoreLikeThis = %s, %+v; want Collateral first, with a reason", like.Title, titles)
+	}
+
+	reply := fmt.Sprintf(`{"movies":[{"id":%d,"explanation":"Bear-shaped heist."},{"id":%d,"explanation":"self"}],"tvshows":[]}`, movies[2].ID, movies[0].ID)
+	r = &Recommender{db: db, chat: fakeChatter{reply: reply}}
+	if _, titles, err = r.MoreLikeThis(ctx, models.TypeMovie, movies[0].ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 1 || titles[0].Title != "Paddington" || titles[0].Explanation != "Bear-shaped heist." {
+		t.Errorf("with a model: titles = %+v, want only Paddington (the seed is never returned)", titles)
+	}
+	var n int64
+	db.Model(&models.Recommendation{}).Count(&n)
+	if n != 0 {
+		t.Errorf("%d recommendations saved, want none", n)
+	}
+
+	if _, _, err := r.MoreLikeThis(ctx, models.TypeTVShow, 9999, now); !errors.Is(err, ErrTitleNotFound) {
+		t.Errorf("unknown show: err = %v, want ErrTitleNotFound", err)
+	}
+}
@@ -0,0 +1,74 @@
+package recommend
+
+import (
+	"slices"
+
+	"github.com/icco/recommender/models"
+)
+
+// Slot is one set of picks generated per day. The daily slot is the main
+// set; time-of-day slots add smaller sets with their own prompt guidance and
+// composition, rendered as separate sections.
+type Slot struct {
+	Name       string // stored as Recommendation.Slot and GenerationRun.Context
+	Title      string // section heading on the home page
+	Prompt     string // prompts/ file with slot guidance; "" for the daily slot
+	Movies     int
+	TVShows    int
+	MaxRuntime int  // movie runtime cap in minutes; 0 = any
+	Kids       bool // only titles Settings.Kids allows
+}
+
+// DailySlot is the main per-day set filled by the nightly run.
+var DailySlot = Slot{Name: models.RunContextDaily, Movies: targetMovies, TVShows: targetTVShows}
+
+// TimeSlots are the optional time-of-day slots, in display order. Each is
+// generated on demand (e.g. /cron/recommend?slot=late from an evening cron).
+var TimeSlots = []Slot{
+	{Name: "tonight", Title: "Tonight's plan", Prompt: "slot_tonight.txt", Movies: 1, TVShows: 1},
+	{Name: "late", Title: "Something short before bed", Prompt: "slot_late.txt", Movies: 2, TVShows: 1, MaxRuntime: 100},
+}
+
+// KidsSlot is the kids' daily set. It has its own schedule and page (/kids)
+// and isn't shown with the household's picks.
+var KidsSlot = Slot{Name: "kids", Title: "Kids' picks", Prompt: "slot_kids.txt", Movies: 3, TVShows: 2, Kids: true}
+
+// LookupSlot returns the slot named name ("" or "daily" for DailySlot).
+func LookupSlot(name string) (Slot, bool) {
+	if name == "" || name == DailySlot.Name {
+		return DailySlot, true
+	}
+	if name == KidsSlot.Name {
+		return KidsSlot, true
+	}
+	i := slices.IndexFunc(TimeSlots, func(s Slot) bool { return s.Name == name })
+	if i < 0 {
+		return Slot{}, false
+	}
+	return TimeSlots[i], true
+}
+
+// SlotSection is one time-of-day slot's picks for a day.
+type SlotSection struct {
+	Slot            Slot
+	Recommendations []models.Recommendation
+}
+
+// SplitSlots separates a day's recommendations into the daily set and one
+// section per time-of-day slot that has picks, in TimeSlots order.
+func SplitSlots(recs []models.Recommendation) (daily []models.Recommendation, sections []SlotSection) {
+	bySlot := map[string][]models.Recommendation{}
+	for _, rec := range recs {
+		if rec.Slot == "" || rec.Slot == DailySlot.Name {
+			daily = append(daily, rec)
+			continue
+		}
+		bySlot[rec.Slot] = append(bySlot[rec.Slot], rec)
+	}
+	for _, s := range TimeSlots {
+		if picks := bySlot[s.Name]; len(picks) > 0 {
+			sections = append(sections, SlotSection{Slot: s, Recommendations: picks})
+		}
+	}
+	return daily, sections
+}
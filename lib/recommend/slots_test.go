@@ -0,0 +1,52 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestLookupSlot(t *testing.T) {
+	for _, name := range []string{"", "daily"} {
+		if s, ok := LookupSlot(name); !ok || s.Name != DailySlot.Name {
+			t.Errorf("LookupSlot(%q) = %+v, %v; want daily", name, s, ok)
+		}
+	}
+	if s, ok := LookupSlot("late"); !ok || s.MaxRuntime == 0 {
+		t.Errorf("LookupSlot(late) = %+v, %v", s, ok)
+	}
+	if s, ok := LookupSlot("kids"); !ok || !s.Kids {
+		t.Errorf("LookupSlot(kids) = %+v, %v", s, ok)
+	}
+	if _, ok := LookupSlot("brunch"); ok {
+		t.Error("unknown slot accepted")
+	}
+}
+
+func TestSplitSlots(t *testing.T) {
+	daily, sections := SplitSlots([]models.Recommendation{
+		{Title: "A", Slot: "daily"},
+		{Title: "B", Slot: "late"},
+		{Title: "C", Slot: "tonight"},
+		{Title: "D"},
+		{Title: "E", Slot: "kids"},
+	})
+	if len(daily) != 2 {
+		t.Errorf("daily = %d recs, want 2", len(daily))
+	}
+	if len(sections) != 2 || sections[0].Slot.Name != "tonight" || sections[1].Slot.Name != "late" {
+		t.Errorf("sections = %+v; want tonight then late", sections)
+	}
+}
+
+func TestSettingsCompose(t *testing.T) {
+	cfg := &Settings{DailyMovies: 6}
+	daily := cfg.compose(DailySlot)
+	if daily.Movies != 6 || daily.TVShows != DailySlot.TVShows {
+		t.Errorf("daily = %d/%d, want 6/%d", daily.Movies, daily.TVShows, DailySlot.TVShows)
+	}
+	late, _ := LookupSlot("late")
+	if got := cfg.compose(late); got.Movies != late.Movies {
+		t.Errorf("time-of-day slot composition changed: %+v", got)
+	}
+}
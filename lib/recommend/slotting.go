@@ -60,8 +60,8 @@ func candByID(shortlist []candidate) map[uint]candidate {
 func toRec(c candidate, explanation string, date time.Time) models.Recommendation {
 	rec := models.Recommendation{
 		Title: c.Title, Type: c.Type, Year: c.Year, Rating: c.Rating,
-		Genre: strings.Join(c.Genres, ", "), PosterURL: c.PosterURL, Runtime: c.Runtime,
-		Explanation: explanation, Date: date,
+		Genre: strings.Join(c.Genres, ", "), PosterURL: c.PosterURL, Runtime: c.Runtime, Seasons: c.Seasons,
+		Explanation: explanation, Date: date, Anniversary: c.Anniversary, EpisodeRuntime: c.EpisodeRuntime,
 	}
 	if c.TMDbID != nil {
 		rec.TMDbID = *c.TMDbID
@@ -157,6 +157,12 @@ func selectMovies(picks []pick, shortlist []candidate, target int) []models.Reco
 // selectTVShows fills up to `target` TV slots from valid picks, padding from the
 // shortlist. All candidates here are already unwatched (loadCandidates filters).
 func selectTVShows(picks []pick, shortlist []candidate, target int) []models.Recommendation {
+	return selectInOrder(picks, shortlist, target, models.TypeTVShow)
+}
+
+// selectInOrder takes up to `target` valid picks of type typ in the model's
+// order, padding from the ranked shortlist.
+func selectInOrder(picks []pick, shortlist []candidate, target int, typ string) []models.Recommendation {
 	byID := candByID(shortlist)
 	used := make(map[uint]bool)
 	var out []models.Recommendation
@@ -165,7 +171,7 @@ func selectTVShows(picks []pick, shortlist []candidate, target int) []models.Rec
 			break
 		}
 		c, ok := byID[p.ID]
-		if !ok || c.Type != models.TypeTVShow || used[c.ID] {
+		if !ok || c.Type != typ || used[c.ID] {
 			continue
 		}
 		used[c.ID] = true
@@ -175,7 +181,7 @@ func selectTVShows(picks []pick, shortlist []candidate, target int) []models.Rec
 		if len(out) >= target {
 			break
 		}
-		if c.Type != models.TypeTVShow || used[c.ID] {
+		if c.Type != typ || used[c.ID] {
 			continue
 		}
 		used[c.ID] = true
@@ -7,7 +7,7 @@ import (
 )
 
 func cand(id uint, view int, genres ...string) candidate {
-	return candidate{ID: id, Type: models.TypeMovie, Title: "t", Genres: genres, ViewCount: view, Rating: 7}
+	return candidate{ID: id, Type: models.TypeMovie, Title: "t", Genres: genres, ViewCount: view, Rating: new(7.0)}
 }
 
 func TestParsePickResponse_ok(t *testing.T) {
@@ -0,0 +1,106 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+)
+
+// SmokeModel is the model name runs made with SmokeChatter record.
+const SmokeModel = "smoke-stub"
+
+var (
+	// DefaultSmokeDate is the sentinel day `recommender smoke` generates.
+	DefaultSmokeDate = time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+	// SmokeCutoff bounds smoke days: only days before it are used, so a
+	// smoke test can never replace or delete a real day's set.
+	SmokeCutoff = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// SmokeChatter is a Chatter for smoke tests that calls no model: it picks
+// nothing, so every slot is filled from the top of the scored shortlist.
+type SmokeChatter struct{}
+
+// Complete returns an empty, valid pick response.
+func (SmokeChatter) Complete(context.Context, string, string, *genai.Schema) (string, error) {
+	return `{"movies":[],"tvshows":[]}`, nil
+}
+
+// SmokeResult is what a smoke test generated before it was cleaned up.
+type SmokeResult struct {
+	Run     models.GenerationRun
+	Movies  int
+	TVShows int
+}
+
+// Smoke runs the daily pipeline for date, a sentinel day before SmokeCutoff,
+// and checks it produced a successful run with picks from the model rather
+// than the scorer's fallback. Whatever the outcome, the day's rows are
+// removed again, including leftovers of an earlier interrupted smoke test.
+func (r *Recommender) Smoke(ctx context.Context, date time.Time) (SmokeResult, error) {
+	date = date.UTC().Truncate(24 * time.Hour)
+	if !date.Before(SmokeCutoff) {
+		return SmokeResult{}, fmt.Errorf("smoke date %s must be before %s", date.Format(time.DateOnly), SmokeCutoff.Format(time.DateOnly))
+	}
+	if err := r.clearDay(ctx, date); err != nil {
+		return SmokeResult{}, err
+	}
+	res, err := r.smoke(ctx, date)
+	//nolint:contextcheck // intentional detach: the sentinel day must be cleared even after ctx expiry
+	if cerr := r.clearDay(context.WithoutCancel(ctx), date); cerr != nil {
+		err = errors.Join(err, cerr)
+	}
+	return res, err
+}
+
+func (r *Recommender) smoke(ctx context.Context, date time.Time) (SmokeResult, error) {
+	var res SmokeResult
+	if err := r.GenerateSlot(ctx, date, DailySlot); err != nil {
+		return res, fmt.Errorf("generate: %w", err)
+	}
+	runs, err := r.Runs(ctx, date, 0)
+	if err != nil {
+		return res, err
+	}
+	if len(runs) == 0 {
+		return res, fmt.Errorf("no run was recorded; is %s in BLACKOUT_DATES?", date.Format(time.DateOnly))
+	}
+	res.Run = runs[0]
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		return res, err
+	}
+	for _, rec := range recs {
+		if rec.Type == models.TypeMovie {
+			res.Movies++
+		} else {
+			res.TVShows++
+		}
+	}
+	switch {
+	case res.Run.Status != models.RunStatusOK:
+		return res, fmt.Errorf("run finished %q: %s", res.Run.Status, res.Run.Error)
+	case len(recs) == 0:
+		return res, fmt.Errorf("no recommendations were saved")
+	case r.LLMEnabled() && res.Run.Strategy != models.StrategyModel:
+		return res, fmt.Errorf("the model failed; picks came from the %s strategy", res.Run.Strategy)
+	}
+	return res, nil
+}
+
+// clearDay deletes date's recommendations, runs, and spotlight.
+func (r *Recommender) clearDay(ctx context.Context, date time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range []any{&models.Recommendation{}, &models.GenerationRun{}, &models.Spotlight{}} {
+			if err := tx.Where(`"date" = ?`, date).Delete(m).Error; err != nil {
+				return fmt.Errorf("clear smoke day: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package recommend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestSmoke_refusesRealDays(t *testing.T) {
+	r := &Recommender{}
+	if _, err := r.Smoke(t.Context(), time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("a smoke test on a real day should be refused")
+	}
+}
+
+func TestSmoke(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	for i, genre := range []string{"Comedy", "Action", "Drama"} {
+		m := models.Movie{Title: genre + " film", Year: 2000 + i, Rating: new(8.0), Genre: genre, PlexRatingKey: fmt.Sprintf("m%d", i)}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Recommender{db: db, chat: SmokeChatter{}, model: SmokeModel, provider: "smoke"}
+	// Leftovers of an interrupted smoke test don't make it a no-op.
+	if err := db.Create(&models.GenerationRun{Date: DefaultSmokeDate, Context: models.RunContextDaily, Status: models.RunStatusOK}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := r.Smoke(ctx, DefaultSmokeDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Movies != 3 || res.Run.Strategy != models.StrategyModel || res.Run.Provider != "smoke" {
+		t.Errorf("Smoke = %+v, want 3 movies picked through the model", res)
+	}
+	var left int64
+	db.Model(&models.Recommendation{}).Count(&left)
+	var runs int64
+	db.Model(&models.GenerationRun{}).Count(&runs)
+	if left != 0 || runs != 0 {
+		t.Errorf("%d recommendations and %d runs left behind", left, runs)
+	}
+}
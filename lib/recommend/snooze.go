@@ -0,0 +1,74 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+const (
+	// DefaultSnoozeDays is how long "not tonight" sets a pick aside when no
+	// length is given; MaxSnoozeDays bounds the length.
+	DefaultSnoozeDays = 7
+	MaxSnoozeDays     = 90
+	// resurfaceDays is how long after a snooze ends its title keeps the
+	// resurfacing boost, if it hasn't been picked again by then.
+	resurfaceDays = 30
+)
+
+// ErrPickNotFound is returned by SnoozePick for an unknown recommendation.
+var ErrPickNotFound = errors.New("recommendation not found")
+
+// SnoozePick sets the pick id aside for days days from now: its title isn't
+// picked again until the snooze ends, then is favored over the usual
+// cooldown. Snoozing again replaces the end date.
+func (r *Recommender) SnoozePick(ctx context.Context, id uint, days int, now time.Time) (models.Recommendation, error) {
+	if days < 1 || days > MaxSnoozeDays {
+		return models.Recommendation{}, fmt.Errorf("snooze length %d outside 1–%d days", days, MaxSnoozeDays)
+	}
+	var rec models.Recommendation
+	res := r.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&rec)
+	if res.Error != nil {
+		return models.Recommendation{}, fmt.Errorf("find recommendation: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.Recommendation{}, ErrPickNotFound
+	}
+	until := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, days)
+	if err := r.db.WithContext(ctx).Model(&rec).Update("snoozed_until", until).Error; err != nil {
+		return models.Recommendation{}, fmt.Errorf("snooze recommendation: %w", err)
+	}
+	rec.SnoozedUntil = &until
+	return rec, nil
+}
+
+// snoozedKeys returns the candKeys of titles still snoozed on date, and of
+// titles whose snooze ended within resurfaceDays before it.
+func (r *Recommender) snoozedKeys(ctx context.Context, date time.Time) (active, back map[string]struct{}, err error) {
+	var recs []models.Recommendation
+	if err := r.db.WithContext(ctx).
+		Where("snoozed_until > ?", date.AddDate(0, 0, -resurfaceDays)).
+		Find(&recs).Error; err != nil {
+		return nil, nil, fmt.Errorf("load snoozed recommendations: %w", err)
+	}
+	active = make(map[string]struct{})
+	back = make(map[string]struct{})
+	for _, rec := range recs {
+		k := recKey(rec)
+		if k == "" {
+			continue
+		}
+		if rec.SnoozedUntil.After(date) {
+			active[k] = struct{}{}
+		} else {
+			back[k] = struct{}{}
+		}
+	}
+	for k := range active {
+		delete(back, k)
+	}
+	return active, back, nil
+}
@@ -0,0 +1,57 @@
+package recommend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestSnoozePick(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.Movie{
+		{Title: "Heat", Year: 1995, PlexRatingKey: "m1"},
+		{Title: "Alien", Year: 1979, PlexRatingKey: "m2"},
+	}
+	if err := db.Create(&movies).Error; err != nil {
+		t.Fatal(err)
+	}
+	recs := []models.Recommendation{
+		{Date: day, Title: "Heat", Type: models.TypeMovie, Year: 1995, MovieID: &movies[0].ID},
+		{Date: day, Title: "Alien", Type: models.TypeMovie, Year: 1979, MovieID: &movies[1].ID},
+	}
+	if err := db.Create(&recs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := r.SnoozePick(ctx, recs[0].ID, 3, day.Add(20*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := day.AddDate(0, 0, 3); rec.SnoozedUntil == nil || !rec.SnoozedUntil.Equal(want) {
+		t.Fatalf("SnoozedUntil = %v, want %v", rec.SnoozedUntil, want)
+	}
+	if _, err := r.SnoozePick(ctx, 9999, 3, day); !errors.Is(err, ErrPickNotFound) {
+		t.Errorf("unknown pick: err = %v, want ErrPickNotFound", err)
+	}
+	if _, err := r.SnoozePick(ctx, recs[0].ID, 0, day); err == nil {
+		t.Error("zero days: want an error")
+	}
+
+	// While snoozed, Heat sits out; Alien is on its usual cooldown.
+	if m, _, err := r.loadCandidates(ctx, day.AddDate(0, 0, 1)); err != nil || len(m) != 0 {
+		t.Errorf("during snooze: candidates = %+v, %v; want none", m, err)
+	}
+	// Once the snooze ends, Heat is back and favored despite the cooldown.
+	m, _, err := r.loadCandidates(ctx, day.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m[0].Title != "Heat" || !m[0].Resurfacing {
+		t.Errorf("after snooze: candidates = %+v, want Heat resurfacing", m)
+	}
+}
@@ -0,0 +1,88 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minSpinWeight keeps unscored and low-scoring picks on the wheel when a spin
+// is weighted by score.
+const minSpinWeight = 0.1
+
+// Spin picks one of date's recommendations at random, in proportion to their
+// score totals when weighted, and records where the wheel landed as a
+// household spin signal. It returns gorm.ErrRecordNotFound when the day has
+// no picks.
+func (r *Recommender) Spin(ctx context.Context, date time.Time, weighted bool) (models.Recommendation, error) {
+	recs, err := r.GetRecommendationsForDate(ctx, date)
+	if err != nil {
+		return models.Recommendation{}, err
+	}
+	if len(recs) == 0 {
+		return models.Recommendation{}, gorm.ErrRecordNotFound
+	}
+	rec := recs[spinIndex(recs, weighted, rand.Float64())] //nolint:gosec // a party trick, not security-sensitive
+	if err := recordSpin(ctx, r.db, rec); err != nil {
+		return models.Recommendation{}, err
+	}
+	return rec, nil
+}
+
+// spinIndex maps roll, in [0, 1), to a slot on the wheel. Slots are equal
+// unless weighted, when each is sized by its pick's score total.
+func spinIndex(recs []models.Recommendation, weighted bool, roll float64) int {
+	weights := make([]float64, len(recs))
+	sum := 0.0
+	for i, rec := range recs {
+		weights[i] = 1
+		if weighted {
+			weights[i] = minSpinWeight
+			if rec.Score != nil {
+				weights[i] = max(rec.Score.Total, minSpinWeight)
+			}
+		}
+		sum += weights[i]
+	}
+	at := roll * sum
+	for i, w := range weights {
+		if at < w {
+			return i
+		}
+		at -= w
+	}
+	return len(recs) - 1
+}
+
+// recordSpin counts a spin that landed on rec's title. The household watches
+// what the wheel picks, so each landing is a strong taste signal.
+func recordSpin(ctx context.Context, db *gorm.DB, rec models.Recommendation) error {
+	var ref string
+	switch {
+	case rec.MovieID != nil:
+		ref = fmt.Sprintf("spin:movie:%d", *rec.MovieID)
+	case rec.TVShowID != nil:
+		ref = fmt.Sprintf("spin:tvshow:%d", *rec.TVShowID)
+	default:
+		return nil
+	}
+	sig := models.ExternalSignal{
+		Source: models.SourceHousehold, ExternalRef: ref, Kind: models.SignalKindSpin,
+		MovieID: rec.MovieID, TVShowID: rec.TVShowID, Value: 1, UpdatedAt: time.Now(),
+	}
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "source"}, {Name: "external_ref"}, {Name: "kind"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"value":      gorm.Expr("external_signals.value + 1"),
+			"updated_at": sig.UpdatedAt,
+		}),
+	}).Create(&sig).Error; err != nil {
+		return fmt.Errorf("save spin signal: %w", err)
+	}
+	return nil
+}
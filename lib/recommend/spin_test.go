@@ -0,0 +1,79 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestSpinIndex(t *testing.T) {
+	recs := []models.Recommendation{
+		{Score: &models.ScoreBreakdown{Total: 3}},
+		{Score: &models.ScoreBreakdown{Total: -1}}, // floored
+		{}, // unscored, floored
+		{Score: &models.ScoreBreakdown{Total: 0.8}},
+	}
+	for _, tc := range []struct {
+		weighted bool
+		roll     float64
+		want     int
+	}{
+		{false, 0, 0},
+		{false, 0.3, 1},
+		{false, 0.6, 2},
+		{false, 0.99, 3},
+		{true, 0, 0},
+		{true, 0.7, 0}, // 3 of 4 by weight
+		{true, 0.76, 1},
+		{true, 0.78, 2},
+		{true, 0.99, 3},
+	} {
+		if got := spinIndex(recs, tc.weighted, tc.roll); got != tc.want {
+			t.Errorf("spinIndex(weighted=%v, %v) = %d, want %d", tc.weighted, tc.roll, got, tc.want)
+		}
+	}
+}
+
+func TestSpin_recordsSignal(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	if _, err := r.Spin(ctx, day, false); err == nil {
+		t.Error("spin with no picks: want an error")
+	}
+	movie := models.Movie{Title: "Heat", Year: 1995, Genre: "Crime", PlexRatingKey: "a"}
+	if err := db.Create(&movie).Error; err != nil {
+		t.Fatal(err)
+	}
+	rec := models.Recommendation{Date: day, Title: "Heat", Type: models.TypeMovie, Year: 1995, MovieID: &movie.ID}
+	if err := db.Create(&rec).Error; err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		got, err := r.Spin(ctx, day, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != rec.ID {
+			t.Errorf("spin landed on %d, want %d", got.ID, rec.ID)
+		}
+	}
+
+	var sig models.ExternalSignal
+	if err := db.Where("source = ? AND kind = ?", models.SourceHousehold, models.SignalKindSpin).Take(&sig).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sig.Value != 2 || sig.MovieID == nil || *sig.MovieID != movie.ID {
+		t.Errorf("spin signal = %+v, want two landings on Heat", sig)
+	}
+	aff, err := r.genreAffinity(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aff["Crime"] != 1 {
+		t.Errorf("affinity = %v, want Crime lifted by spins", aff)
+	}
+}
@@ -0,0 +1,207 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// spotlightMinTitles is how many eligible movies must credit a person
+	// for a spotlight to go ahead; fewer and the day is generated as usual.
+	spotlightMinTitles = 2
+	// spotlightMinWatched is how many watched movies must credit a person
+	// before the rotation considers them a favorite.
+	spotlightMinWatched = 2
+	// spotlightRepeatDays keeps the rotation from spotlighting the same
+	// person again too soon.
+	spotlightRepeatDays = 90
+)
+
+// ErrSpotlightUnknown is returned by SetSpotlight for a person no library
+// title credits.
+var ErrSpotlightUnknown = errors.New("no library title credits that person")
+
+// SetSpotlight themes date's daily picks around person, replacing any
+// spotlight already set for the day. The name must match a director or
+// cast credit Plex lists for some library title, ignoring case.
+func (r *Recommender) SetSpotlight(ctx context.Context, date time.Time, person string) (models.Spotlight, error) {
+	person = strings.TrimSpace(person)
+	credited, err := r.creditedName(ctx, person)
+	if err != nil {
+		return models.Spotlight{}, err
+	}
+	if credited == "" {
+		return models.Spotlight{}, ErrSpotlightUnknown
+	}
+	day, _ := recommendationUTCDayRange(date)
+	s := models.Spotlight{Date: day, Person: credited}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"person", "auto"}),
+	}).Create(&s).Error; err != nil {
+		return models.Spotlight{}, fmt.Errorf("save spotlight: %w", err)
+	}
+	return s, nil
+}
+
+// creditedName returns person as a library title credits them, or "" if
+// none does.
+func (r *Recommender) creditedName(ctx context.Context, person string) (string, error) {
+	like := "%" + strings.ToLower(person) + "%"
+	var movies []models.Movie
+	if err := r.db.WithContext(ctx).Select("director", "actors").
+		Where("LOWER(director) LIKE ? OR LOWER(actors) LIKE ?", like, like).
+		Limit(20).Find(&movies).Error; err != nil {
+		return "", fmt.Errorf("find credits: %w", err)
+	}
+	for _, m := range movies {
+		for _, name := range append(splitGenres(m.Director), splitGenres(m.Actors)...) {
+			if strings.EqualFold(name, person) {
+				return name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Spotlights returns the spotlights for from and later days, soonest first.
+func (r *Recommender) Spotlights(ctx context.Context, from time.Time) ([]models.Spotlight, error) {
+	day, _ := recommendationUTCDayRange(from)
+	var out []models.Spotlight
+	if err := r.db.WithContext(ctx).Where(`"date" >= ?`, day).Order(`"date"`).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spotlights: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteSpotlight clears date's spotlight, returning it and whether there
+// was one.
+func (r *Recommender) DeleteSpotlight(ctx context.Context, date time.Time) (models.Spotlight, bool, error) {
+	day, _ := recommendationUTCDayRange(date)
+	var s models.Spotlight
+	res := r.db.WithContext(ctx).Clauses(clause.Returning{}).Where(`"date" = ?`, day).Delete(&s)
+	if res.Error != nil {
+		return models.Spotlight{}, false, fmt.Errorf("delete spotlight: %w", res.Error)
+	}
+	return s, res.RowsAffected > 0, nil
+}
+
+// spotlightFor returns the person date's daily picks feature, or "": the
+// day's requested spotlight, else on the configured rotation day the
+// favorite person (see topPerson) with enough eligible movies, which is
+// recorded so the rotation moves on. movies are the day's eligible movies.
+func (r *Recommender) spotlightFor(ctx context.Context, date time.Time, cfg *Settings, movies []candidate) (string, error) {
+	var s models.Spotlight
+	err := r.db.WithContext(ctx).Where(`"date" = ?`, date).Take(&s).Error
+	if err == nil {
+		return s.Person, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("get spotlight: %w", err)
+	}
+	if cfg.SpotlightDay == nil || date.Weekday() != *cfg.SpotlightDay {
+		return "", nil
+	}
+	person, err := r.topPerson(ctx, date, movies)
+	if err != nil || person == "" {
+		return "", err
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Spotlight{Date: date, Person: person, Auto: true}).Error; err != nil {
+		return "", fmt.Errorf("record spotlight: %w", err)
+	}
+	return person, nil
+}
+
+// topPerson picks the director or actor most credited across watched
+// movies who has at least spotlightMinTitles eligible movies and wasn't
+// spotlighted in the last spotlightRepeatDays.
+func (r *Recommender) topPerson(ctx context.Context, date time.Time, movies []candidate) (string, error) {
+	var watched []models.Movie
+	if err := r.db.WithContext(ctx).Select("director", "actors").
+		Where("view_count > 0").Find(&watched).Error; err != nil {
+		return "", fmt.Errorf("load watched credits: %w", err)
+	}
+	var recent []string
+	if err := r.db.WithContext(ctx).Model(&models.Spotlight{}).
+		Where(`"date" >= ? AND "date" < ?`, date.AddDate(0, 0, -spotlightRepeatDays), date).
+		Pluck("person", &recent).Error; err != nil {
+		return "", fmt.Errorf("load recent spotlights: %w", err)
+	}
+	skip := make(map[string]bool, len(recent))
+	for _, p := range recent {
+		skip[strings.ToLower(p)] = true
+	}
+
+	counts := map[string]int{}
+	names := map[string]string{}
+	for _, m := range watched {
+		for _, name := range credits(m.Director, m.Actors) {
+			k := strings.ToLower(name)
+			counts[k]++
+			names[k] = name
+		}
+	}
+	people := make([]string, 0, len(counts))
+	for k, n := range counts {
+		if n >= spotlightMinWatched && !skip[k] {
+			people = append(people, k)
+		}
+	}
+	sort.Slice(people, func(i, j int) bool {
+		if counts[people[i]] != counts[people[j]] {
+			return counts[people[i]] > counts[people[j]]
+		}
+		return people[i] < people[j]
+	})
+	for _, k := range people {
+		if len(featuring(movies, names[k])) >= spotlightMinTitles {
+			return names[k], nil
+		}
+	}
+	return "", nil
+}
+
+// credits returns a title's distinct director and cast names.
+func credits(director, actors string) []string {
+	out := splitGenres(director)
+	for _, a := range splitGenres(actors) {
+		if !containsFold(out, a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// featuring returns the candidates crediting person as director or cast.
+func featuring(cands []candidate, person string) []candidate {
+	var out []candidate
+	for _, c := range cands {
+		if containsFold(c.Directors, person) || containsFold(c.Actors, person) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func containsFold(names []string, want string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// spotlightPrompt tells the model the day is themed around person.
+func spotlightPrompt(person string) string {
+	return fmt.Sprintf("Today is a %s spotlight: every shortlisted movie credits them as director or cast. Say in each explanation what the pick shows of their work.", person)
+}
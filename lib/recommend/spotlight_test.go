@@ -0,0 +1,97 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestFeaturing(t *testing.T) {
+	cands := []candidate{
+		{ID: 1, Directors: []string{"Spike Lee"}, Actors: []string{"Denzel Washington"}},
+		{ID: 2, Actors: []string{"Denzel Washington", "Ethan Hawke"}},
+		{ID: 3, Actors: []string{"Denzel Whitaker"}},
+	}
+	if got := featuring(cands, "denzel washington"); len(got) != 2 || got[0].ID != 1 || got[1].ID != 2 {
+		t.Errorf("featuring = %+v, want titles 1 and 2", got)
+	}
+	if got := credits("Spike Lee", "Denzel Washington, Spike Lee"); len(got) != 2 {
+		t.Errorf("credits = %v, want each name once", got)
+	}
+}
+
+func TestGenerateRecommendations_spotlight(t *testing.T) {
+	db := testDB(t)
+	ctx := t.Context()
+	saturday := time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC)
+	add := func(title, director, actors string, views int) {
+		t.Helper()
+		m := models.Movie{Title: title, Year: 2000, Rating: new(7.0), Genre: "Drama", Director: director, Actors: actors, ViewCount: views, PlexRatingKey: title}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Denzel is the most-watched credit, with two more movies to spotlight.
+	add("Glory", "Edward Zwick", "Denzel Washington, Matthew Broderick", 1)
+	add("Malcolm X", "Spike Lee", "Denzel Washington", 2)
+	add("Training Day", "Antoine Fuqua", "Denzel Washington, Ethan Hawke", 0)
+	add("Fences", "Denzel Washington", "Denzel Washington, Viola Davis", 0)
+	for i := range 6 {
+		add(fmt.Sprintf("Other %d", i), "Someone Else", "Nobody", 0)
+	}
+
+	r := &Recommender{db: db, model: FallbackModel}
+	day := saturday.Weekday()
+	r.ApplySettings(Settings{SpotlightDay: &day})
+	if err := r.GenerateRecommendations(ctx, saturday); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	recs, err := r.GetRecommendationsForDate(ctx, saturday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range recs {
+		if rec.Type == models.TypeMovie && rec.Title != "Training Day" && rec.Title != "Fences" && rec.Title != "Glory" && rec.Title != "Malcolm X" {
+			t.Errorf("%q doesn't feature the spotlighted person", rec.Title)
+		}
+	}
+	if theme, err := r.ThemeForDate(ctx, saturday); err != nil || theme != "Denzel Washington spotlight" {
+		t.Errorf("theme = %q, %v; want the spotlight", theme, err)
+	}
+
+	// The rotation moves on: Denzel was just spotlighted.
+	next := saturday.AddDate(0, 0, 7)
+	cands, _, err := r.loadCandidates(ctx, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person, err := r.spotlightFor(ctx, next, r.currentSettings(), cands); err != nil || person == "Denzel Washington" {
+		t.Errorf("next week's spotlight = %q, %v; want someone else", person, err)
+	}
+}
+
+func TestSetSpotlight(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	if err := db.Create(&models.Movie{Title: "Fences", Year: 2016, Director: "Denzel Washington", PlexRatingKey: "f"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC)
+	if _, err := r.SetSpotlight(ctx, day, "Denzel"); !errors.Is(err, ErrSpotlightUnknown) {
+		t.Errorf("partial name: err = %v, want ErrSpotlightUnknown", err)
+	}
+	s, err := r.SetSpotlight(ctx, day, "denzel washington")
+	if err != nil || s.Person != "Denzel Washington" {
+		t.Fatalf("SetSpotlight = %+v, %v; want the credited spelling", s, err)
+	}
+	if got, err := r.Spotlights(ctx, day); err != nil || len(got) != 1 {
+		t.Errorf("Spotlights = %v, %v", got, err)
+	}
+	if old, found, err := r.DeleteSpotlight(ctx, day); err != nil || !found || old.Person != "Denzel Washington" {
+		t.Errorf("DeleteSpotlight = %+v, %v, %v", old, found, err)
+	}
+}
@@ -0,0 +1,162 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/icco/recommender/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxVoterLen bounds a household member's name.
+const MaxVoterLen = 64
+
+// ErrVoteNotOnDay is returned by CastVote for a pick that isn't one of the
+// day's.
+var ErrVoteNotOnDay = errors.New("that pick isn't one of the day's recommendations")
+
+// Tally is a day's household votes.
+type Tally struct {
+	Votes map[uint]int    // recommendation ID -> votes
+	By    map[string]uint // voter -> the pick they voted for
+	Total int
+}
+
+// CastVote records voter's favorite among date's picks, moving any vote they
+// already cast that day, and refreshes the vote signals of the titles
+// involved so future generation leans toward what the household picks.
+func (r *Recommender) CastVote(ctx context.Context, date time.Time, voter string, recID uint) error {
+	day, _ := recommendationUTCDayRange(date)
+	voter = strings.TrimSpace(voter)
+	var rec models.Recommendation
+	err := r.db.WithContext(ctx).Where(`id = ? AND "date" = ?`, recID, day).Take(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrVoteNotOnDay
+	}
+	if err != nil {
+		return fmt.Errorf("find pick: %w", err)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var prev models.Vote
+		hadVote := tx.Where(`"date" = ? AND voter = ?`, day, voter).Limit(1).Find(&prev).RowsAffected > 0
+		v := models.Vote{Date: day, Voter: voter, RecommendationID: rec.ID, MovieID: rec.MovieID, TVShowID: rec.TVShowID}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}, {Name: "voter"}},
+			DoUpdates: clause.AssignmentColumns([]string{"recommendation_id", "movie_id", "tv_show_id", "updated_at"}),
+		}).Create(&v).Error; err != nil {
+			return fmt.Errorf("save vote: %w", err)
+		}
+		if hadVote {
+			if err := refreshVoteSignal(ctx, tx, prev.MovieID, prev.TVShowID); err != nil {
+				return err
+			}
+		}
+		return refreshVoteSignal(ctx, tx, rec.MovieID, rec.TVShowID)
+	})
+}
+
+// moveVotes points the votes cast for the replaced picks oldIDs at the same
+// title among recs, the saved replacements, and deletes the rest, since
+// Vote.RecommendationID has no foreign key to clear them.
+func moveVotes(ctx context.Context, tx *gorm.DB, oldIDs []uint, recs []models.Recommendation) error {
+	if len(oldIDs) == 0 {
+		return nil
+	}
+	var votes []models.Vote
+	if err := tx.WithContext(ctx).Where("recommendation_id IN ?", oldIDs).Find(&votes).Error; err != nil {
+		return fmt.Errorf("load votes: %w", err)
+	}
+	for _, v := range votes {
+		if i := sameTitle(v.MovieID, v.TVShowID, recs); i >= 0 && recs[i].ID != 0 {
+			if err := tx.WithContext(ctx).Model(&v).Update("recommendation_id", recs[i].ID).Error; err != nil {
+				return fmt.Errorf("move vote: %w", err)
+			}
+			continue
+		}
+		if err := tx.WithContext(ctx).Delete(&v).Error; err != nil {
+			return fmt.Errorf("delete vote: %w", err)
+		}
+		if err := refreshVoteSignal(ctx, tx, v.MovieID, v.TVShowID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameTitle returns the index of the pick in recs for the library title
+// movieID or tvShowID, or -1.
+func sameTitle(movieID, tvShowID *uint, recs []models.Recommendation) int {
+	for i, rec := range recs {
+		if movieID != nil && rec.MovieID != nil && *movieID == *rec.MovieID ||
+			tvShowID != nil && rec.TVShowID != nil && *tvShowID == *rec.TVShowID {
+			return i
+		}
+	}
+	return -1
+}
+
+// carryFeedback copies the household's feedback and snooze on old, a replaced
+// pick, onto rec, its replacement for the same title.
+func carryFeedback(rec *models.Recommendation, old models.Recommendation) {
+	if rec.Feedback == "" {
+		rec.Feedback, rec.FeedbackAt = old.Feedback, old.FeedbackAt
+	}
+	if rec.SnoozedUntil == nil {
+		rec.SnoozedUntil = old.SnoozedUntil
+	}
+}
+
+// refreshVoteSignal sets the title's household vote signal to its vote count
+// across all days, removing it when no votes are left.
+func refreshVoteSignal(ctx context.Context, tx *gorm.DB, movieID, tvShowID *uint) error {
+	var column, ref string
+	var id uint
+	switch {
+	case movieID != nil:
+		column, id = "movie_id", *movieID
+		ref = fmt.Sprintf("vote:movie:%d", id)
+	case tvShowID != nil:
+		column, id = "tv_show_id", *tvShowID
+		ref = fmt.Sprintf("vote:tvshow:%d", id)
+	default:
+		return nil
+	}
+	var n int64
+	if err := tx.WithContext(ctx).Model(&models.Vote{}).Where(column+" = ?", id).Count(&n).Error; err != nil {
+		return fmt.Errorf("count votes: %w", err)
+	}
+	if n == 0 {
+		if err := tx.WithContext(ctx).Where("source = ? AND kind = ? AND external_ref = ?", models.SourceHousehold, models.SignalKindVote, ref).
+			Delete(&models.ExternalSignal{}).Error; err != nil {
+			return fmt.Errorf("clear vote signal: %w", err)
+		}
+		return nil
+	}
+	if err := upsertSignal(ctx, tx, models.ExternalSignal{
+		Source: models.SourceHousehold, ExternalRef: ref, Kind: models.SignalKindVote,
+		MovieID: movieID, TVShowID: tvShowID, Value: float64(n),
+	}); err != nil {
+		return fmt.Errorf("save vote signal: %w", err)
+	}
+	return nil
+}
+
+// VoteTally counts date's votes.
+func (r *Recommender) VoteTally(ctx context.Context, date time.Time) (Tally, error) {
+	day, _ := recommendationUTCDayRange(date)
+	var votes []models.Vote
+	if err := r.db.WithContext(ctx).Where(`"date" = ?`, day).Find(&votes).Error; err != nil {
+		return Tally{}, fmt.Errorf("load votes: %w", err)
+	}
+	t := Tally{Votes: map[uint]int{}, By: map[string]uint{}, Total: len(votes)}
+	for _, v := range votes {
+		t.Votes[v.RecommendationID]++
+		t.By[v.Voter] = v.RecommendationID
+	}
+	return t, nil
+}
@@ -0,0 +1,57 @@
+package recommend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+func TestCastVote_movesVoteAndUpdatesSignals(t *testing.T) {
+	db := testDB(t)
+	r := testRecommender(db)
+	ctx := t.Context()
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	movies := []models.Movie{{Title: "Heat", Year: 1995, PlexRatingKey: "a"}, {Title: "Ronin", Year: 1998, PlexRatingKey: "b"}}
+	if err := db.Create(&movies).Error; err != nil {
+		t.Fatal(err)
+	}
+	recs := []models.Recommendation{
+		{Date: day, Title: "Heat", Type: models.TypeMovie, Year: 1995, MovieID: &movies[0].ID},
+		{Date: day, Title: "Ronin", Type: models.TypeMovie, Year: 1998, MovieID: &movies[1].ID},
+		{Date: day.AddDate(0, 0, -1), Title: "Heat", Type: models.TypeMovie, Year: 1995, MovieID: &movies[0].ID},
+	}
+	if err := db.Create(&recs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.CastVote(ctx, day, "Sam", recs[2].ID); !errors.Is(err, ErrVoteNotOnDay) {
+		t.Errorf("yesterday's pick: err = %v, want ErrVoteNotOnDay", err)
+	}
+	for _, v := range []struct {
+		voter string
+		rec   uint
+	}{{"Sam", recs[0].ID}, {"Alex", recs[0].ID}, {" Sam ", recs[1].ID}} {
+		if err := r.CastVote(ctx, day, v.voter, v.rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tally, err := r.VoteTally(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tally.Total != 2 || tally.Votes[recs[0].ID] != 1 || tally.Votes[recs[1].ID] != 1 || tally.By["Sam"] != recs[1].ID {
+		t.Errorf("tally = %+v, want Sam's vote moved to Ronin", tally)
+	}
+
+	var sigs []models.ExternalSignal
+	if err := db.Where("source = ? AND kind = ?", models.SourceHousehold, models.SignalKindVote).Order("external_ref").Find(&sigs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 2 || sigs[0].Value != 1 || sigs[1].Value != 1 {
+		t.Errorf("vote signals = %+v, want one vote on each title", sigs)
+	}
+}
@@ -0,0 +1,33 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/recommender/models"
+)
+
+// AddToWatchlist puts the library movie or TV show id on the household
+// watchlist, which scores like a Trakt watchlist entry, and returns its
+// title. Adding it again is harmless. It returns ErrTitleNotFound for an
+// unknown title.
+func (r *Recommender) AddToWatchlist(ctx context.Context, typ string, id uint) (string, error) {
+	seed, err := r.similarSeed(ctx, typ, id, time.Now())
+	if err != nil {
+		return "", err
+	}
+	sig := models.ExternalSignal{
+		Source: models.SourceHousehold, ExternalRef: fmt.Sprintf("watchlist:%s:%d", typ, id),
+		Kind: models.SignalKindWatchlist, Value: 1,
+	}
+	if typ == models.TypeMovie {
+		sig.MovieID = &seed.ID
+	} else {
+		sig.TVShowID = &seed.ID
+	}
+	if err := upsertSignal(ctx, r.db, sig); err != nil {
+		return "", fmt.Errorf("save watchlist signal: %w", err)
+	}
+	return seed.Title, nil
+}
@@ -0,0 +1,47 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/weather"
+	"go.uber.org/zap"
+)
+
+// WeatherSource is the forecast lookup generation uses; *weather.Client in
+// production. Set it via Settings.Weather to enable weather-aware prompts.
+type WeatherSource interface {
+	Day(ctx context.Context, date time.Time) (weather.Forecast, error)
+}
+
+// weatherContext returns the prompt line for date's weather, or "" when
+// weather is disabled or the lookup fails (it is never worth failing a run).
+func (s *Settings) weatherContext(ctx context.Context, date time.Time) string {
+	if s.Weather == nil {
+		return ""
+	}
+	f, err := s.Weather.Day(ctx, date)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("weather lookup failed; continuing without", zap.Error(err))
+		return ""
+	}
+	return weatherPrompt(f)
+}
+
+// weatherPrompt describes f and how it should bias the picks: rainy weekends
+// toward long epics, sunny days toward shorter fare.
+func weatherPrompt(f weather.Forecast) string {
+	weekend := f.Date.Weekday() == time.Saturday || f.Date.Weekday() == time.Sunday
+	s := fmt.Sprintf("Weather: %s, high of %.0f°C on a %s.", f.Describe(), f.MaxTempC, f.Date.Weekday())
+	switch {
+	case f.Rainy() && weekend:
+		s += " A rainy weekend: lean toward long, immersive epics worth settling in for."
+	case f.Rainy():
+		s += " A wet evening: absorbing, cozy picks suit it."
+	case f.Sunny() && f.MaxTempC >= 20:
+		s += " A sunny, warm day: favor shorter, lighter fare for the evening."
+	}
+	return s
+}
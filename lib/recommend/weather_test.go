@@ -0,0 +1,52 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icco/recommender/lib/weather"
+)
+
+type fakeWeather struct {
+	f   weather.Forecast
+	err error
+}
+
+func (w fakeWeather) Day(_ context.Context, date time.Time) (weather.Forecast, error) {
+	w.f.Date = date
+	return w.f, w.err
+}
+
+func TestWeatherPrompt(t *testing.T) {
+	sat := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	wed := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		f    weather.Forecast
+		want string
+	}{
+		{"rainy weekend", weather.Forecast{Date: sat, Code: 63, MaxTempC: 11, PrecipMM: 9}, "long, immersive epics"},
+		{"rainy weekday", weather.Forecast{Date: wed, Code: 61, MaxTempC: 11, PrecipMM: 3}, "cozy picks"},
+		{"sunny evening", weather.Forecast{Date: wed, Code: 0, MaxTempC: 26}, "shorter, lighter fare"},
+	} {
+		if got := weatherPrompt(tc.f); !strings.Contains(got, tc.want) {
+			t.Errorf("%s: %q missing %q", tc.name, got, tc.want)
+		}
+	}
+	if got := weatherPrompt(weather.Forecast{Date: wed, Code: 3, MaxTempC: 15}); strings.Contains(got, "lean") || strings.Contains(got, "favor") {
+		t.Errorf("neutral weather should not bias picks: %q", got)
+	}
+}
+
+func TestWeatherContext_failureIsSilent(t *testing.T) {
+	cfg := &Settings{Weather: fakeWeather{err: errors.New("down")}}
+	if got := cfg.weatherContext(t.Context(), time.Now()); got != "" {
+		t.Errorf("weatherContext on failure = %q, want empty", got)
+	}
+	if got := (&Settings{}).weatherContext(t.Context(), time.Now()); got != "" {
+		t.Errorf("weatherContext when disabled = %q, want empty", got)
+	}
+}
@@ -1,9 +1,13 @@
 // Package sanitize provides helpers for scrubbing user-controlled values
-// before they are written to structured logs, plus thin wrappers that emit
-// recommendation/cache cron-job lifecycle log lines with consistent fields.
+// before they are written to structured logs or bounded database columns,
+// plus thin wrappers that emit recommendation/cache cron-job lifecycle log
+// lines with consistent fields.
 package sanitize
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 // ForLog strips control characters that could fake extra log fields or lines.
 func ForLog(s string) string {
@@ -14,3 +18,15 @@ func ForLog(s string) string {
 		return r
 	}, s)
 }
+
+// Truncate shortens s to at most n bytes without splitting a UTF-8 rune, so
+// the result still fits a varchar(n) column Postgres will accept.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,25 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	// "é" is two bytes, and here byte 1000 falls inside one.
+	msg := "plex:" + strings.Repeat("Amélie ", 200)
+	if utf8.ValidString(msg[:1000]) {
+		t.Fatal("test message should split a rune at byte 1000")
+	}
+	got := Truncate(msg, 1000)
+	if len(got) > 1000 || !utf8.ValidString(got) {
+		t.Fatalf("Truncate = %d bytes, valid UTF-8 %v; want at most 1000 valid bytes", len(got), utf8.ValidString(got))
+	}
+	if !strings.HasPrefix(msg, got) || len(got) < 999 {
+		t.Errorf("Truncate cut %d bytes, want only the split rune dropped", len(msg)-len(got))
+	}
+	if got := Truncate("short", 1000); got != "short" {
+		t.Errorf("Truncate(short) = %q", got)
+	}
+}
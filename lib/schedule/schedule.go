@@ -0,0 +1,242 @@
+// Package schedule runs work on cron expressions inside the process, so
+// cache syncs and generation happen without an external cron hitting
+// /cron/*. Expressions are evaluated in UTC, like the app's days.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icco/gutil/logging"
+	"go.uber.org/zap"
+)
+
+// MaxJitter bounds the random delay added to each run.
+const MaxJitter = time.Hour
+
+// Spec is a parsed cron expression: minute, hour, day of month, month, and
+// day of week, each a bit set of allowed values.
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+	// Standard cron: when both day fields are restricted, a day matching
+	// either runs.
+	domAny, dowAny bool
+}
+
+var shorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Parse reads a five-field cron expression ("30 6 * * *") or one of
+// @hourly, @daily, @midnight, @weekly, and @monthly. Fields take *, numbers,
+// ranges (1-5), lists (1,15), and steps (*/15, 0-12/2); day of week is 0–6
+// from Sunday, with 7 also Sunday.
+func Parse(expr string) (Spec, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := shorthands[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("cron expression %q: want 5 fields, got %d", expr, len(fields))
+	}
+	var s Spec
+	var err error
+	for i, f := range []struct {
+		dst      *uint64
+		lo, hi   int
+		name     string
+		wildcard *bool
+	}{
+		{&s.minute, 0, 59, "minute", nil},
+		{&s.hour, 0, 23, "hour", nil},
+		{&s.dom, 1, 31, "day of month", &s.domAny},
+		{&s.month, 1, 12, "month", nil},
+		{&s.dow, 0, 7, "day of week", &s.dowAny},
+	} {
+		if *f.dst, err = parseField(fields[i], f.lo, f.hi); err != nil {
+			return Spec{}, fmt.Errorf("cron expression %q: %s: %w", expr, f.name, err)
+		}
+		if f.wildcard != nil {
+			*f.wildcard = strings.HasPrefix(fields[i], "*")
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday too
+	}
+	return s, nil
+}
+
+// parseField reads one field's comma-separated parts into a bit set.
+func parseField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", stepStr)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("bad value %q", a)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("bad value %q", b)
+				}
+			} else if hasStep {
+				to = hi // "5/15" is 5, 20, 35, 50
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%q outside %d–%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+// dayMatches reports whether t's day is allowed.
+func (s Spec) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first minute after t that s allows, in UTC, or the zero
+// time if there is none within five years (e.g. "0 0 31 2 *").
+func (s Spec) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// entry is one scheduled piece of work.
+type entry struct {
+	name string
+	spec Spec
+	fn   func(context.Context) error
+}
+
+// Scheduler runs added work at its scheduled times, each run delayed by a
+// random jitter so replicas and restarts don't pile onto the same second.
+// Runs are skipped while active reports false (not the leader, or in
+// maintenance). Work should only enqueue jobs: the job queue's keys fold
+// repeats, and its handlers take the serial lock.
+type Scheduler struct {
+	entries []entry
+	jitter  time.Duration
+	active  func() bool
+}
+
+// New returns a Scheduler. jitter above MaxJitter is capped; a nil active
+// always runs.
+func New(jitter time.Duration, active func() bool) *Scheduler {
+	if active == nil {
+		active = func() bool { return true }
+	}
+	return &Scheduler{jitter: min(max(jitter, 0), MaxJitter), active: active}
+}
+
+// Add schedules fn, named name in logs, on the cron expression expr.
+func (s *Scheduler) Add(name, expr string, fn func(context.Context) error) error {
+	spec, err := Parse(expr)
+	if err != nil {
+		return err
+	}
+	s.entries = append(s.entries, entry{name: name, spec: spec, fn: fn})
+	return nil
+}
+
+// Len is how many entries are scheduled.
+func (s *Scheduler) Len() int {
+	return len(s.entries)
+}
+
+// Run runs the entries until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, e := range s.entries {
+		go s.loop(ctx, e)
+	}
+	<-ctx.Done()
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	l := logging.FromContext(ctx)
+	for {
+		next := e.spec.Next(time.Now())
+		if next.IsZero() {
+			l.Warnw("Scheduled work never runs; check its expression", "name", e.name)
+			return
+		}
+		wait := time.Until(next) + s.delay()
+		l.Debugw("Next scheduled run", "name", e.name, "at", time.Now().Add(wait))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !s.active() {
+			l.Debugw("Skipping scheduled run on inactive replica", "name", e.name)
+			continue
+		}
+		if err := e.fn(ctx); err != nil {
+			l.Errorw("Scheduled run failed", "name", e.name, zap.Error(err))
+			continue
+		}
+		l.Infow("Ran scheduled work", "name", e.name)
+	}
+}
+
+// delay is a random jitter in [0, s.jitter).
+func (s *Scheduler) delay() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.jitter))) //nolint:gosec // spreading load, not security-sensitive
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_errors(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestSpec_Next(t *testing.T) {
+	// A Friday.
+	from := time.Date(2026, 10, 16, 14, 7, 30, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 16, 14, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 14, 15, 0, 0, time.UTC)},
+		{"30 6 * * *", time.Date(2026, 10, 17, 6, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)}, // skips the weekend
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},   // 7 is Sunday
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 25 12 *", time.Date(2026, 12, 25, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th, or Sunday).
+		{"0 0 20 * 0", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"5/20 14 * * *", time.Date(2026, 10, 16, 14, 25, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	} {
+		spec, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := spec.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: Next = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestScheduler_jitter(t *testing.T) {
+	if d := New(0, nil).delay(); d != 0 {
+		t.Errorf("no jitter: delay = %v", d)
+	}
+	s := New(3*time.Hour, nil)
+	if s.jitter != MaxJitter {
+		t.Errorf("jitter = %v, want capped at %v", s.jitter, MaxJitter)
+	}
+	for range 100 {
+		if d := s.delay(); d < 0 || d >= MaxJitter {
+			t.Fatalf("delay = %v outside [0, %v)", d, MaxJitter)
+		}
+	}
+	if err := s.Add("bad", "not cron", nil); err == nil || s.Len() != 0 {
+		t.Errorf("Add(bad) = %v, Len = %d", err, s.Len())
+	}
+}
@@ -0,0 +1,51 @@
+package signedurl
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	s := New([]byte("key"))
+	now := time.Unix(1_800_000_000, 0)
+	link := s.Sign("/share/2026-10-16", now.Add(time.Hour))
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/share/2026-10-16" {
+		t.Fatalf("Sign changed the path: %s", link)
+	}
+
+	q := u.Query()
+	if err := s.Verify(u.Path, q, now); err != nil {
+		t.Errorf("fresh link: %v", err)
+	}
+	q.Set("page", "2") // added by the page itself
+	if err := s.Verify(u.Path, q, now); err != nil {
+		t.Errorf("extra parameters should not matter: %v", err)
+	}
+	if err := s.Verify(u.Path, q, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired link: err = %v, want ErrExpired", err)
+	}
+	if err := s.Verify("/share/2026-10-17", q, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("other path: err = %v, want ErrInvalid", err)
+	}
+	if err := New([]byte("other")).Verify(u.Path, q, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("other key: err = %v, want ErrInvalid", err)
+	}
+
+	longer := url.Values{ExpiresParam: {"1900000000"}, SignatureParam: {q.Get(SignatureParam)}}
+	if err := s.Verify(u.Path, longer, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("extended expiry: err = %v, want ErrInvalid", err)
+	}
+	if err := s.Verify(u.Path, url.Values{}, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unsigned: err = %v, want ErrInvalid", err)
+	}
+	if strings.Contains(link, "key") {
+		t.Error("link leaks the key")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/icco/gutil/logging"
+	"github.com/icco/recommender/lib/errreport"
 	"go.uber.org/zap"
 )
 
@@ -96,6 +97,17 @@ type TVSearchResult struct {
 	} `json:"results"`
 }
 
+// MovieDetails is the subset of a TMDb movie record the recommender uses.
+type MovieDetails struct {
+	ID                  int    `json:"id"`
+	Title               string `json:"title"`
+	ReleaseDate         string `json:"release_date"` // YYYY-MM-DD; "" when unknown
+	BelongsToCollection *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"belongs_to_collection"`
+}
+
 // NewClient returns a configured TMDb client. Loggers are taken from the
 // per-call ctx via gutil/logging.
 func NewClient(apiKey string) *Client {
@@ -227,106 +239,55 @@ func (c *Client) do(ctx context.Context, safeURL string) (*http.Response, error)
 // SearchMovie searches TMDb for movies by title and year. Includes rate
 // limiting, retry, and circuit breaker behavior.
 func (c *Client) SearchMovie(ctx context.Context, title string, year int) (*SearchResult, error) {
-	l := logging.FromContext(ctx)
 	// safeURL never includes the api key so it is safe to embed in errors and logs.
 	safeURL := fmt.Sprintf("%s/search/movie?query=%s&year=%d",
 		c.baseURL, url.QueryEscape(title), year)
-
-	retryFunc := func() (*SearchResult, error) {
-		if !c.circuitBreaker.canExecute() {
-			return nil, ErrCircuitOpen
-		}
-
-		if err := c.rateLimiter.wait(ctx); err != nil {
-			return nil, fmt.Errorf("rate limit wait cancelled: %w", err)
-		}
-
-		resp, err := c.do(ctx, safeURL)
-		if err != nil {
-			c.circuitBreaker.recordFailure()
-			return nil, &APIError{
-				StatusCode: 0,
-				Message:    "transport error",
-				URL:        safeURL,
-				Method:     http.MethodGet,
-			}
-		}
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				l.Errorw("failed to close response body", zap.Error(err))
-			}
-		}()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			apiErr := &APIError{
-				StatusCode: resp.StatusCode,
-				Message:    string(body),
-				URL:        safeURL,
-				Method:     http.MethodGet,
-			}
-
-			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
-				if duration, err := time.ParseDuration(retryAfter + "s"); err == nil {
-					apiErr.RetryAfter = duration
-				}
-			}
-
-			if resp.StatusCode >= 500 {
-				c.circuitBreaker.recordFailure()
-			}
-
-			return nil, apiErr
-		}
-
-		var result SearchResult
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			c.circuitBreaker.recordFailure()
-			return nil, fmt.Errorf("failed to decode response: %w", err)
-		}
-
-		c.circuitBreaker.recordSuccess()
-		return &result, nil
-	}
-
-	for attempt := range 3 {
-		result, err := retryFunc()
-		if err == nil {
-			return result, nil
-		}
-
-		// When the breaker is open every retry will fail the same way, so
-		// fail fast instead of logging warn+sleep+retry 3 times per call.
-		if errors.Is(err, ErrCircuitOpen) {
-			return nil, err
-		}
-
-		l.Warnw("Retrying TMDb search movie",
-			"attempt", attempt+1,
-			zap.Error(err),
-		)
-
-		if attempt < 2 {
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-		}
-	}
-
-	result, err := retryFunc()
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
+	return getJSON[SearchResult](ctx, c, safeURL, "search movie")
 }
 
 // SearchTVShow searches TMDb for TV shows by title and year. Includes rate
 // limiting, retry, and circuit breaker behavior.
 func (c *Client) SearchTVShow(ctx context.Context, title string, year int) (*TVSearchResult, error) {
-	l := logging.FromContext(ctx)
 	// safeURL never includes the api key so it is safe to embed in errors and logs.
 	safeURL := fmt.Sprintf("%s/search/tv?query=%s&first_air_date_year=%d",
 		c.baseURL, url.QueryEscape(title), year)
+	return getJSON[TVSearchResult](ctx, c, safeURL, "search TV show")
+}
 
-	retryFunc := func() (*TVSearchResult, error) {
+// PopularMovies returns one page (1-based) of TMDb's currently popular movies,
+// in the same shape as SearchMovie.
+func (c *Client) PopularMovies(ctx context.Context, page int) (*SearchResult, error) {
+	safeURL := fmt.Sprintf("%s/movie/popular?page=%d", c.baseURL, page)
+	return getJSON[SearchResult](ctx, c, safeURL, "popular movies")
+}
+
+// SimilarMovies returns the first page of movies TMDb considers similar to
+// the movie id, in the same shape as SearchMovie.
+func (c *Client) SimilarMovies(ctx context.Context, id int) (*SearchResult, error) {
+	safeURL := fmt.Sprintf("%s/movie/%d/similar", c.baseURL, id)
+	return getJSON[SearchResult](ctx, c, safeURL, "similar movies")
+}
+
+// SimilarTVShows returns the first page of shows TMDb considers similar to
+// the show id, in the same shape as SearchTVShow.
+func (c *Client) SimilarTVShows(ctx context.Context, id int) (*TVSearchResult, error) {
+	safeURL := fmt.Sprintf("%s/tv/%d/similar", c.baseURL, id)
+	return getJSON[TVSearchResult](ctx, c, safeURL, "similar TV shows")
+}
+
+// GetMovie fetches a movie's details by TMDb ID, including the collection
+// (franchise) it belongs to, if any.
+func (c *Client) GetMovie(ctx context.Context, id int) (*MovieDetails, error) {
+	safeURL := fmt.Sprintf("%s/movie/%d", c.baseURL, id)
+	return getJSON[MovieDetails](ctx, c, safeURL, "get movie")
+}
+
+// getJSON GETs safeURL and decodes the body into T, with rate limiting, up to
+// four attempts, and circuit-breaker accounting. op names the call in logs.
+func getJSON[T any](ctx context.Context, c *Client, safeURL, op string) (*T, error) {
+	l := logging.FromContext(ctx)
+
+	retryFunc := func() (*T, error) {
 		if !c.circuitBreaker.canExecute() {
 			return nil, ErrCircuitOpen
 		}
@@ -373,7 +334,7 @@ func (c *Client) SearchTVShow(ctx context.Context, title string, year int) (*TVS
 			return nil, apiErr
 		}
 
-		var result TVSearchResult
+		var result T
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			c.circuitBreaker.recordFailure()
 			return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -389,11 +350,13 @@ func (c *Client) SearchTVShow(ctx context.Context, title string, year int) (*TVS
 			return result, nil
 		}
 
+		// When the breaker is open every retry will fail the same way, so
+		// fail fast instead of logging warn+sleep+retry 3 times per call.
 		if errors.Is(err, ErrCircuitOpen) {
 			return nil, err
 		}
 
-		l.Warnw("Retrying TMDb search TV show",
+		l.Warnw("Retrying TMDb "+op,
 			"attempt", attempt+1,
 			zap.Error(err),
 		)
@@ -405,6 +368,10 @@ func (c *Client) SearchTVShow(ctx context.Context, title string, year int) (*TVS
 
 	result, err := retryFunc()
 	if err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			errreport.CaptureError(ctx, err, "provider", "tmdb", "op", op)
+		}
 		return nil, err
 	}
 	return result, nil
@@ -1,5 +1,6 @@
 // Package trakt is a minimal Trakt API client: OAuth device flow, token
-// refresh, and the sync endpoints the recommender uses as ranking signals.
+// refresh, the sync endpoints the recommender uses as ranking signals, and
+// adding picks to a user's list.
 package trakt
 
 import (
@@ -9,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -80,7 +82,9 @@ func (t Token) ExpiresAt() time.Time {
 	return time.Unix(t.CreatedAt, 0).Add(time.Duration(t.ExpiresIn) * time.Second)
 }
 
-func (c *Client) postJSON(ctx context.Context, path string, body, out any) (int, error) {
+// postJSON POSTs body to path and decodes the response into out. accessToken
+// is sent, with the API key headers, when set.
+func (c *Client) postJSON(ctx context.Context, path, accessToken string, body, out any) (int, error) {
 	buf, err := json.Marshal(body)
 	if err != nil {
 		return 0, fmt.Errorf("marshal body: %w", err)
@@ -90,6 +94,11 @@ func (c *Client) postJSON(ctx context.Context, path string, body, out any) (int,
 		return 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("trakt-api-version", apiVersion)
+		req.Header.Set("trakt-api-key", c.clientID)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return 0, err
@@ -113,7 +122,7 @@ func (c *Client) postJSON(ctx context.Context, path string, body, out any) (int,
 // RequestDeviceCode starts the OAuth device flow.
 func (c *Client) RequestDeviceCode(ctx context.Context) (*DeviceCode, error) {
 	var dc DeviceCode
-	if _, err := c.postJSON(ctx, "oauth/device/code", map[string]string{"client_id": c.clientID}, &dc); err != nil {
+	if _, err := c.postJSON(ctx, "oauth/device/code", "", map[string]string{"client_id": c.clientID}, &dc); err != nil {
 		return nil, err
 	}
 	return &dc, nil
@@ -123,7 +132,7 @@ func (c *Client) RequestDeviceCode(ctx context.Context) (*DeviceCode, error) {
 // Returns (nil, nil) when still pending so the caller can wait and retry.
 func (c *Client) PollForToken(ctx context.Context, deviceCode string) (*Token, error) {
 	var tok Token
-	status, err := c.postJSON(ctx, "oauth/device/token", map[string]string{
+	status, err := c.postJSON(ctx, "oauth/device/token", "", map[string]string{
 		"code": deviceCode, "client_id": c.clientID, "client_secret": c.clientSecret,
 	}, &tok)
 	if status == http.StatusBadRequest {
@@ -138,7 +147,7 @@ func (c *Client) PollForToken(ctx context.Context, deviceCode string) (*Token, e
 // RefreshToken exchanges a refresh token for a new token set.
 func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*Token, error) {
 	var tok Token
-	if _, err := c.postJSON(ctx, "oauth/token", map[string]string{
+	if _, err := c.postJSON(ctx, "oauth/token", "", map[string]string{
 		"refresh_token": refreshToken,
 		"client_id":     c.clientID,
 		"client_secret": c.clientSecret,
@@ -176,3 +185,39 @@ func (c *Client) Sync(ctx context.Context, accessToken, path string) ([]SyncRow,
 	}
 	return rows, nil
 }
+
+// ListResult counts the movies an AddToList call added, found already on the
+// list, or couldn't match.
+type ListResult struct {
+	Added, Existing, NotFound int
+}
+
+// AddToList adds movies, by TMDb ID, to the authenticated user's list (its
+// slug or Trakt ID). Movies already on the list are left alone, so repeating
+// a call is harmless.
+func (c *Client) AddToList(ctx context.Context, accessToken, list string, tmdbIDs []int) (ListResult, error) {
+	type movie struct {
+		IDs struct {
+			TMDb int `json:"tmdb"`
+		} `json:"ids"`
+	}
+	body := struct {
+		Movies []movie `json:"movies"`
+	}{}
+	for _, id := range tmdbIDs {
+		var m movie
+		m.IDs.TMDb = id
+		body.Movies = append(body.Movies, m)
+	}
+	var resp struct {
+		Added    struct{ Movies int } `json:"added"`
+		Existing struct{ Movies int } `json:"existing"`
+		NotFound struct {
+			Movies []json.RawMessage `json:"movies"`
+		} `json:"not_found"`
+	}
+	if _, err := c.postJSON(ctx, "users/me/lists/"+url.PathEscape(list)+"/items", accessToken, body, &resp); err != nil {
+		return ListResult{}, err
+	}
+	return ListResult{Added: resp.Added.Movies, Existing: resp.Existing.Movies, NotFound: len(resp.NotFound.Movies)}, nil
+}
@@ -2,6 +2,7 @@ package trakt
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -43,3 +44,30 @@ func TestRequestDeviceCode(t *testing.T) {
 		t.Fatalf("bad device code: %+v", dc)
 	}
 }
+
+func TestAddToList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/users/me/lists/daily-picks/items" {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get("trakt-api-key") != "cid" {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"movies":[{"ids":{"tmdb":603}},{"ids":{"tmdb":604}}]}` {
+			t.Errorf("body = %s", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"added":{"movies":1},"existing":{"movies":1},"not_found":{"movies":[]}}`))
+	}))
+	defer srv.Close()
+	c := NewClient("cid", "secret")
+	c.BaseURL = srv.URL
+	got, err := c.AddToList(context.Background(), "tok", "daily-picks", []int{603, 604})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (ListResult{Added: 1, Existing: 1}) {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"go.uber.org/zap"
+)
+
+// MaxJSONBody is the usual cap on a write API's request body.
+const MaxJSONBody = 64 << 10
+
+// ErrMalformed means a request body isn't a single JSON object.
+var ErrMalformed = errors.New("malformed request body")
+
+// Validator is a write API payload that checks its own fields.
+type Validator interface {
+	Validate() FieldErrors
+}
+
+// FieldError is one invalid field of a request body. Nested fields are
+// dotted, e.g. "filters.genre".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors lists the invalid fields of a request body.
+type FieldErrors []FieldError
+
+// Add records that field is invalid.
+func (e *FieldErrors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, f := range e {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DecodeJSON reads a JSON object of at most limit bytes from req's body into
+// v and validates it. The error is an *http.MaxBytesError for an oversized
+// body, wraps ErrMalformed for one that isn't a single JSON object, and is
+// FieldErrors for unknown fields, wrongly typed fields, or fields v.Validate
+// rejects. WriteRequestError answers with the matching status.
+func DecodeJSON(w http.ResponseWriter, req *http.Request, limit int64, v Validator) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, limit))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var maxErr *http.MaxBytesError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &maxErr):
+			return maxErr
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return FieldErrors{{Field: typeErr.Field, Message: "must be " + jsonKind(typeErr.Type.String())}}
+		case errors.Is(err, io.EOF):
+			return fmt.Errorf("%w: body is empty", ErrMalformed)
+		}
+		if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if name, uerr := strconv.Unquote(name); uerr == nil {
+				return FieldErrors{{Field: name, Message: "is not a known field"}}
+			}
+		}
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: trailing data after the object", ErrMalformed)
+	}
+	if errs := v.Validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// jsonKind names the JSON value a Go type decodes from.
+func jsonKind(goType string) string {
+	switch {
+	case goType == "bool" || goType == "*bool":
+		return "true or false"
+	case goType == "string" || goType == "*string":
+		return "a string"
+	case strings.Contains(goType, "int") || strings.Contains(goType, "float"):
+		return "a number"
+	case strings.HasPrefix(goType, "[]"):
+		return "an array"
+	}
+	return "an object"
+}
+
+// WriteRequestError answers a DecodeJSON error: 413 for an oversized body,
+// 400 for a malformed one, and 422 listing the invalid fields as
+// {"error": "...", "fields": [{"field": "...", "message": "..."}]}.
+func WriteRequestError(ctx context.Context, w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	var fields FieldErrors
+	switch {
+	case errors.As(err, &maxErr):
+		WriteError(ctx, w, fmt.Errorf("request body is larger than %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+	case errors.As(err, &fields):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if encErr := json.NewEncoder(w).Encode(map[string]any{
+			"error":  "invalid request body",
+			"fields": fields,
+		}); encErr != nil {
+			logging.FromContext(ctx).Errorw("Failed to encode error response", zap.Error(encErr))
+		}
+	default:
+		WriteError(ctx, w, err, http.StatusBadRequest)
+	}
+}
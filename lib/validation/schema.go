@@ -1,6 +1,7 @@
 // Package validation provides JSON schema validation helpers used to
 // sanitize external API responses (e.g. the LLM) and basic input validation
-// (date / pagination parameters) for the recommender service.
+// (date / pagination parameters and write API request bodies) for the
+// recommender service.
 package validation
 
 import (
@@ -3,6 +3,7 @@ package validation
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -15,9 +16,32 @@ import (
 // dateRegex is a regular expression that matches dates in YYYY-MM-DD format.
 var dateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 
+// MaxFutureDays is how far ahead generation endpoints may prepare picks.
+const MaxFutureDays = 7
+
+// ErrFutureDate is returned for a well-formed date that is too far ahead.
+var ErrFutureDate = errors.New("date is in the future")
+
 // ValidateDate checks if a date string is in the correct format (YYYY-MM-DD)
 // and ensures it's not in the future. Returns an error if the date is invalid.
 func ValidateDate(date string) error {
+	return validateDate(date, time.Now())
+}
+
+// ValidateGenerationDate is ValidateDate for endpoints that generate picks,
+// which may also prepare them up to MaxFutureDays ahead (e.g. tomorrow's
+// picks the night before).
+func ValidateGenerationDate(date string) error {
+	err := validateDate(date, time.Now().AddDate(0, 0, MaxFutureDays))
+	if errors.Is(err, ErrFutureDate) {
+		return fmt.Errorf("%w (at most %d days ahead)", err, MaxFutureDays)
+	}
+	return err
+}
+
+// validateDate checks date's format and that the day starts no later than
+// latest.
+func validateDate(date string, latest time.Time) error {
 	if !dateRegex.MatchString(date) {
 		return fmt.Errorf("invalid date format: %s, expected YYYY-MM-DD", date)
 	}
@@ -27,9 +51,8 @@ func ValidateDate(date string) error {
 		return fmt.Errorf("invalid date: %w", err)
 	}
 
-	// Check if date is in the future
-	if parsed.After(time.Now()) {
-		return fmt.Errorf("date cannot be in the future")
+	if parsed.After(latest) {
+		return fmt.Errorf("%w: %s", ErrFutureDate, date)
 	}
 
 	return nil
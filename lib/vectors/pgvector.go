@@ -0,0 +1,175 @@
+package vectors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icco/gutil/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	// pgTable holds the vectors; its embedding column is sized to the model,
+	// so Rebuild recreates it.
+	pgTable = "title_vectors"
+	// maxHNSWDims is the most dimensions pgvector's HNSW index takes for
+	// the vector type. Larger vectors are searched without an index.
+	maxHNSWDims = 2000
+	// pgBatch is how many rows one insert writes.
+	pgBatch = 200
+)
+
+// PGVector is a Store in Postgres using the pgvector extension, with an
+// HNSW cosine index. The extension must be available to the database user
+// (CREATE EXTENSION vector); Rebuild creates it if it can.
+type PGVector struct {
+	db *gorm.DB
+}
+
+// NewPGVector returns a PGVector on db. Nothing is created until Rebuild.
+func NewPGVector(db *gorm.DB) *PGVector {
+	return &PGVector{db: db}
+}
+
+// Name returns "pgvector".
+func (p *PGVector) Name() string {
+	return "pgvector"
+}
+
+// Count is the number of indexed vectors of model, or 0 before the first
+// Rebuild.
+func (p *PGVector) Count(ctx context.Context, model string) (int, error) {
+	var exists bool
+	if err := p.db.WithContext(ctx).Raw("SELECT to_regclass(?) IS NOT NULL", pgTable).Scan(&exists).Error; err != nil {
+		return 0, fmt.Errorf("check %s: %w", pgTable, err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	var n int64
+	if err := p.db.WithContext(ctx).Table(pgTable).Where("model = ?", model).Count(&n).Error; err != nil {
+		return 0, fmt.Errorf("count vectors: %w", err)
+	}
+	return int(n), nil
+}
+
+// Put upserts items in batches.
+func (p *PGVector) Put(ctx context.Context, model string, items []Item) error {
+	return p.insert(p.db.WithContext(ctx), model, items)
+}
+
+func (p *PGVector) insert(tx *gorm.DB, model string, items []Item) error {
+	for start := 0; start < len(items); start += pgBatch {
+		batch := items[start:min(start+pgBatch, len(items))]
+		var b strings.Builder
+		args := make([]any, 0, 4*len(batch))
+		fmt.Fprintf(&b, "INSERT INTO %s (type, title_id, model, embedding) VALUES ", pgTable)
+		for i, it := range batch {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("(?, ?, ?, ?::vector)")
+			args = append(args, it.Type, it.ID, model, literal(it.Vector))
+		}
+		b.WriteString(" ON CONFLICT (type, title_id) DO UPDATE SET model = EXCLUDED.model, embedding = EXCLUDED.embedding")
+		if err := tx.Exec(b.String(), args...).Error; err != nil {
+			return fmt.Errorf("upsert vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// Nearest orders by cosine distance in the database. Skipped keys are
+// filtered here, so it fetches more rows until k remain or none are left.
+func (p *PGVector) Nearest(ctx context.Context, model string, query []float32, k int, skip func(Key) bool) ([]Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	q := literal(query)
+	for limit := 4 * k; ; limit *= 2 {
+		var rows []struct {
+			Type       string
+			TitleID    uint
+			Similarity float64
+		}
+		if err := p.db.WithContext(ctx).Raw(
+			fmt.Sprintf("SELECT type, title_id, 1 - (embedding <=> ?::vector) AS similarity FROM %s WHERE model = ? ORDER BY embedding <=> ?::vector LIMIT ?", pgTable),
+			q, model, q, limit,
+		).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("nearest vectors: %w", err)
+		}
+		var out []Match
+		for _, r := range rows {
+			key := Key{Type: r.Type, ID: r.TitleID}
+			if skip != nil && skip(key) {
+				continue
+			}
+			if out = append(out, Match{Key: key, Similarity: r.Similarity}); len(out) == k {
+				return out, nil
+			}
+		}
+		if len(rows) < limit {
+			return out, nil
+		}
+	}
+}
+
+// Rebuild drops and recreates the table sized to the items' vectors, fills
+// it, and builds the index, in one transaction.
+func (p *PGVector) Rebuild(ctx context.Context, model string, items []Item) error {
+	dims := 0
+	if len(items) > 0 {
+		dims = len(items[0].Vector)
+	}
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+			return fmt.Errorf("create pgvector extension: %w", err)
+		}
+		if err := tx.Exec("DROP TABLE IF EXISTS " + pgTable).Error; err != nil {
+			return fmt.Errorf("drop %s: %w", pgTable, err)
+		}
+		column := "vector"
+		if dims > 0 {
+			column = fmt.Sprintf("vector(%d)", dims)
+		}
+		if err := tx.Exec(fmt.Sprintf(`CREATE TABLE %s (
+			type varchar(16) NOT NULL,
+			title_id bigint NOT NULL,
+			model varchar(64) NOT NULL,
+			embedding %s NOT NULL,
+			PRIMARY KEY (type, title_id)
+		)`, pgTable, column)).Error; err != nil {
+			return fmt.Errorf("create %s: %w", pgTable, err)
+		}
+		if err := p.insert(tx, model, items); err != nil {
+			return err
+		}
+		if dims == 0 {
+			return nil
+		}
+		if dims > maxHNSWDims {
+			logging.FromContext(ctx).Warnw("Vectors too wide for an HNSW index; searches scan the table", "dims", dims, "max", maxHNSWDims)
+			return nil
+		}
+		if err := tx.Exec(fmt.Sprintf("CREATE INDEX idx_%s_embedding ON %s USING hnsw (embedding vector_cosine_ops)", pgTable, pgTable)).Error; err != nil {
+			return fmt.Errorf("create vector index: %w", err)
+		}
+		return nil
+	})
+}
+
+// literal formats v as a pgvector text value, "[1,0.5,…]".
+func literal(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
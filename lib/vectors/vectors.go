@@ -0,0 +1,142 @@
+// Package vectors indexes title embedding vectors for nearest-neighbour
+// lookups. Store is the backend interface: Memory searches in process, and
+// PGVector keeps an HNSW index in Postgres with the pgvector extension. The
+// title_embeddings table stays the source of truth; a Store can always be
+// rebuilt from it.
+package vectors
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+)
+
+// Key names a library title.
+type Key struct {
+	Type string // models.TypeMovie or models.TypeTVShow
+	ID   uint   // Movie or TVShow ID
+}
+
+// Item is a title's vector.
+type Item struct {
+	Key
+	Vector []float32
+}
+
+// Match is a title found by Nearest.
+type Match struct {
+	Key
+	Similarity float64 // cosine similarity, 1 for the same direction
+}
+
+// Store indexes vectors per embedding model.
+type Store interface {
+	// Name identifies the backend in logs and the admin API.
+	Name() string
+	// Count is how many vectors of model are indexed.
+	Count(ctx context.Context, model string) (int, error)
+	// Put adds or replaces vectors of model.
+	Put(ctx context.Context, model string, items []Item) error
+	// Nearest returns up to k vectors of model closest to query by cosine
+	// similarity, closest first, leaving out keys skip reports true for.
+	Nearest(ctx context.Context, model string, query []float32, k int, skip func(Key) bool) ([]Match, error)
+	// Rebuild replaces the whole index with items of model, for a new
+	// model, new dimensions, or a switched backend.
+	Rebuild(ctx context.Context, model string, items []Item) error
+}
+
+// Memory is an in-process Store that compares query against every vector.
+// It is empty after a restart until rebuilt. Safe for concurrent use.
+type Memory struct {
+	mu    sync.RWMutex
+	model string
+	vecs  map[Key][]float32
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{vecs: make(map[Key][]float32)}
+}
+
+// Name returns "memory".
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// Count is the number of vectors held, if they are of model.
+func (m *Memory) Count(_ context.Context, model string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.model != model {
+		return 0, nil
+	}
+	return len(m.vecs), nil
+}
+
+// Put adds items, first dropping vectors of any other model.
+func (m *Memory) Put(_ context.Context, model string, items []Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.model != model {
+		m.model, m.vecs = model, make(map[Key][]float32, len(items))
+	}
+	for _, it := range items {
+		m.vecs[it.Key] = it.Vector
+	}
+	return nil
+}
+
+// Nearest scores every held vector of model against query.
+func (m *Memory) Nearest(_ context.Context, model string, query []float32, k int, skip func(Key) bool) ([]Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.model != model || k <= 0 {
+		return nil, nil
+	}
+	var out []Match
+	for key, v := range m.vecs {
+		if skip != nil && skip(key) {
+			continue
+		}
+		out = append(out, Match{Key: key, Similarity: Cosine(query, v)})
+	}
+	slices.SortFunc(out, func(a, b Match) int {
+		if c := cmp.Compare(b.Similarity, a.Similarity); c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.ID, b.ID))
+	})
+	return out[:min(k, len(out))], nil
+}
+
+// Rebuild replaces the held vectors with items.
+func (m *Memory) Rebuild(_ context.Context, model string, items []Item) error {
+	vecs := make(map[Key][]float32, len(items))
+	for _, it := range items {
+		vecs[it.Key] = it.Vector
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.model, m.vecs = model, vecs
+	return nil
+}
+
+// Cosine is the cosine similarity of a and b, or 0 when their lengths
+// differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
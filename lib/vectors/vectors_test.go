@@ -0,0 +1,100 @@
+package vectors
+
+import (
+	"testing"
+
+	"github.com/icco/recommender/lib/dbtest"
+)
+
+func TestMemory_nearest(t *testing.T) {
+	ctx := t.Context()
+	m := NewMemory()
+	if err := m.Rebuild(ctx, "a", []Item{
+		{Key{"movie", 1}, []float32{1, 0}},
+		{Key{"movie", 2}, []float32{0.8, 0.2}},
+		{Key{"tv", 2}, []float32{0, 1}},
+		{Key{"movie", 3}, []float32{0.9, 0.1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.Nearest(ctx, "a", []float32{1, 0}, 2, func(k Key) bool { return k == Key{"movie", 1} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Key != (Key{"movie", 3}) || got[1].Key != (Key{"movie", 2}) {
+		t.Errorf("Nearest = %+v, want movie 3 then movie 2", got)
+	}
+	if got, _ := m.Nearest(ctx, "b", []float32{1, 0}, 2, nil); got != nil {
+		t.Errorf("Nearest with another model = %+v, want none", got)
+	}
+
+	// Putting vectors of a new model drops the old ones.
+	if err := m.Put(ctx, "b", []Item{{Key{"movie", 9}, []float32{1, 0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.Count(ctx, "b"); n != 1 {
+		t.Errorf("Count(b) = %d, want 1", n)
+	}
+	if n, _ := m.Count(ctx, "a"); n != 0 {
+		t.Errorf("Count(a) = %d, want 0", n)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	for _, tt := range []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 0}, []float32{-1, 0}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	} {
+		if got := Cosine(tt.a, tt.b); got != tt.want {
+			t.Errorf("Cosine(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	if got := literal([]float32{1, 0.5, -0.25}); got != "[1,0.5,-0.25]" {
+		t.Errorf("literal = %q", got)
+	}
+}
+
+func TestPGVector(t *testing.T) {
+	db := dbtest.New(t)
+	var available bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector')").Scan(&available).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		t.Skip("pgvector extension not installed")
+	}
+	ctx := t.Context()
+	p := NewPGVector(db)
+	if n, err := p.Count(ctx, "a"); err != nil || n != 0 {
+		t.Fatalf("Count before Rebuild = %d, %v; want 0", n, err)
+	}
+	if err := p.Rebuild(ctx, "a", []Item{
+		{Key{"movie", 1}, []float32{1, 0}},
+		{Key{"movie", 2}, []float32{0.8, 0.2}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Put(ctx, "a", []Item{{Key{"tv", 1}, []float32{0.9, 0.1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := p.Count(ctx, "a"); err != nil || n != 3 {
+		t.Fatalf("Count = %d, %v; want 3", n, err)
+	}
+	got, err := p.Nearest(ctx, "a", []float32{1, 0}, 1, func(k Key) bool { return k.Type == "movie" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Key != (Key{"tv", 1}) {
+		t.Errorf("Nearest = %+v, want tv 1", got)
+	}
+}
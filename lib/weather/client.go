@@ -0,0 +1,121 @@
+// Package weather is a minimal Open-Meteo client for the day's forecast at a
+// fixed location, used to give the recommendation prompt a little context
+// (rainy weekends suit epics; sunny evenings suit shorter fare).
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultURL = "https://api.open-meteo.com/v1/forecast"
+
+// Client fetches daily forecasts from Open-Meteo (no API key needed). URL is
+// overridable for tests.
+type Client struct {
+	URL        string
+	lat, lon   float64
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the location at lat/lon.
+func NewClient(lat, lon float64) *Client {
+	return &Client{URL: defaultURL, lat: lat, lon: lon, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Forecast is one day's weather at the client's location.
+type Forecast struct {
+	Date          time.Time
+	Code          int     // WMO weather interpretation code
+	MaxTempC      float64 // daily high, °C
+	PrecipMM      float64 // total precipitation, mm
+	PrecipPercent int     // max precipitation probability, %
+}
+
+// Rainy reports wet weather: measurable precipitation, or a drizzle, rain,
+// snow, or thunderstorm code.
+func (f Forecast) Rainy() bool {
+	return f.PrecipMM >= 2 || f.Code >= 51
+}
+
+// Sunny reports clear or mostly clear skies.
+func (f Forecast) Sunny() bool {
+	return f.Code <= 1 && f.PrecipMM < 1
+}
+
+// Describe is a short human phrase for the conditions, e.g. "rain".
+func (f Forecast) Describe() string {
+	switch c := f.Code; {
+	case c <= 1:
+		return "clear skies"
+	case c <= 3:
+		return "cloudy"
+	case c == 45 || c == 48:
+		return "fog"
+	case c >= 51 && c <= 67, c >= 80 && c <= 82:
+		return "rain"
+	case c >= 71 && c <= 77, c == 85 || c == 86:
+		return "snow"
+	case c >= 95:
+		return "thunderstorms"
+	default:
+		return "mixed weather"
+	}
+}
+
+// Day returns the forecast for date's calendar day in the location's timezone.
+func (c *Client) Day(ctx context.Context, date time.Time) (Forecast, error) {
+	day := date.Format(time.DateOnly)
+	q := url.Values{
+		"latitude":   {strconv.FormatFloat(c.lat, 'f', 4, 64)},
+		"longitude":  {strconv.FormatFloat(c.lon, 'f', 4, 64)},
+		"daily":      {"weather_code,temperature_2m_max,precipitation_sum,precipitation_probability_max"},
+		"timezone":   {"auto"},
+		"start_date": {day},
+		"end_date":   {day},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"?"+q.Encode(), nil)
+	if err != nil {
+		return Forecast{}, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Forecast{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Forecast{}, err
+	}
+	if resp.StatusCode >= 400 {
+		return Forecast{}, fmt.Errorf("open-meteo: HTTP %d: %s", resp.StatusCode, string(data))
+	}
+
+	var out struct {
+		Daily struct {
+			Time          []string  `json:"time"`
+			WeatherCode   []int     `json:"weather_code"`
+			MaxTemp       []float64 `json:"temperature_2m_max"`
+			Precip        []float64 `json:"precipitation_sum"`
+			PrecipPercent []int     `json:"precipitation_probability_max"`
+		} `json:"daily"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Forecast{}, fmt.Errorf("decode open-meteo: %w", err)
+	}
+	d := out.Daily
+	if len(d.Time) == 0 || len(d.WeatherCode) == 0 || len(d.MaxTemp) == 0 || len(d.Precip) == 0 {
+		return Forecast{}, fmt.Errorf("open-meteo: no forecast for %s", day)
+	}
+	f := Forecast{Date: date, Code: d.WeatherCode[0], MaxTempC: d.MaxTemp[0], PrecipMM: d.Precip[0]}
+	if len(d.PrecipPercent) > 0 {
+		f.PrecipPercent = d.PrecipPercent[0]
+	}
+	return f, nil
+}
@@ -0,0 +1,40 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("start_date"); got != "2026-10-17" {
+			t.Errorf("start_date = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"daily":{"time":["2026-10-17"],"weather_code":[63],"temperature_2m_max":[12.5],"precipitation_sum":[8.2],"precipitation_probability_max":[90]}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(51.5, -0.12)
+	c.URL = srv.URL
+	f, err := c.Day(t.Context(), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Rainy() || f.Sunny() || f.Describe() != "rain" || f.MaxTempC != 12.5 || f.PrecipPercent != 90 {
+		t.Errorf("forecast = %+v", f)
+	}
+}
+
+func TestDay_httpError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	c := NewClient(0, 0)
+	c.URL = srv.URL
+	if _, err := c.Day(t.Context(), time.Now()); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,63 @@
+// Package wol sends Wake-on-LAN magic packets, used to wake a Plex host that
+// sleeps overnight before the cache sync needs it.
+package wol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+)
+
+// DefaultAddr is the limited broadcast address on the conventional WoL port.
+const DefaultAddr = "255.255.255.255:9"
+
+// Target is a host to wake: its NIC's MAC and the UDP address (usually a
+// broadcast address) the packet is sent to.
+type Target struct {
+	MAC  net.HardwareAddr
+	Addr string
+}
+
+// ParseTarget parses a MAC such as "00:11:22:33:44:55"; addr "" means
+// DefaultAddr.
+func ParseTarget(mac, addr string) (Target, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return Target{}, fmt.Errorf("parse MAC: %w", err)
+	}
+	if len(hw) != 6 {
+		return Target{}, fmt.Errorf("MAC %q: want 6 bytes, got %d", mac, len(hw))
+	}
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return Target{}, fmt.Errorf("wake address %q: %w", addr, err)
+	}
+	return Target{MAC: hw, Addr: addr}, nil
+}
+
+// MagicPacket is six 0xFF bytes followed by the MAC sixteen times.
+func (t Target) MagicPacket() []byte {
+	p := bytes.Repeat([]byte{0xFF}, 6)
+	for range 16 {
+		p = append(p, t.MAC...)
+	}
+	return p
+}
+
+// Send transmits the magic packet once. Delivery is not confirmed; callers
+// check that the host came up.
+func (t Target) Send(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", t.Addr)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", t.Addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write(t.MagicPacket()); err != nil {
+		return fmt.Errorf("send magic packet: %w", err)
+	}
+	return nil
+}
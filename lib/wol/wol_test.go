@@ -0,0 +1,50 @@
+package wol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseTarget(t *testing.T) {
+	got, err := ParseTarget("00-11-22-AA-bb-cc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MAC.String() != "00:11:22:aa:bb:cc" || got.Addr != DefaultAddr {
+		t.Errorf("got %v %q", got.MAC, got.Addr)
+	}
+	for _, tc := range [][2]string{{"not-a-mac", ""}, {"00:00:5e:10:00:00:00:01", ""}, {"00:11:22:33:44:55", "no-port"}} {
+		if _, err := ParseTarget(tc[0], tc[1]); err == nil {
+			t.Errorf("ParseTarget(%q, %q) should fail", tc[0], tc[1])
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = pc.Close() }()
+	target, err := ParseTarget("00:11:22:33:44:55", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Send(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 200)
+	if err := pc.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 102 || !bytes.Equal(buf[:6], bytes.Repeat([]byte{0xFF}, 6)) || !bytes.Equal(buf[96:102], target.MAC) {
+		t.Errorf("packet = % x", buf[:n])
+	}
+}
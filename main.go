@@ -3,43 +3,68 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime/debug"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/icco/gutil/logging"
 	"github.com/icco/recommender/handlers"
+	"github.com/icco/recommender/handlers/templates"
+	"github.com/icco/recommender/lib/apikey"
+	"github.com/icco/recommender/lib/audit"
+	"github.com/icco/recommender/lib/auth"
+	"github.com/icco/recommender/lib/config"
 	"github.com/icco/recommender/lib/db"
+	"github.com/icco/recommender/lib/errreport"
 	"github.com/icco/recommender/lib/health"
+	"github.com/icco/recommender/lib/jobs"
+	"github.com/icco/recommender/lib/leader"
 	"github.com/icco/recommender/lib/lock"
+	"github.com/icco/recommender/lib/maintenance"
+	"github.com/icco/recommender/lib/notify"
 	"github.com/icco/recommender/lib/plex"
+	"github.com/icco/recommender/lib/plextv"
 	"github.com/icco/recommender/lib/recommend"
+	"github.com/icco/recommender/lib/schedule"
+	"github.com/icco/recommender/lib/signedurl"
 	"github.com/icco/recommender/lib/tmdb"
+	"github.com/icco/recommender/lib/vectors"
+	"github.com/icco/recommender/lib/wol"
 	"github.com/icco/recommender/static"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/unrolled/secure"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 const service = "recommender"
 
-var log = logging.Must(logging.NewLogger(service))
+// logLevel filters every log line; LOG_LEVEL sets it and a config reload can
+// change it. gutil's logger logs at debug, so any level can be layered on top.
+var logLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+var log = logging.Must(logging.NewLogger(service)).Desugar().
+	WithOptions(zap.IncreaseLevel(logLevel)).Sugar()
 
 // routeTag stamps the chi route pattern onto otelhttp metric labels so HTTP
 // metrics carry low-cardinality http.route values.
@@ -56,6 +81,21 @@ func routeTag(next http.Handler) http.Handler {
 	})
 }
 
+// buildRevision is the VCS revision the binary was built from, or "" when
+// the build didn't record one.
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return ""
+}
+
 // main wires dependencies and blocks until SIGINT/SIGTERM.
 func main() {
 	ctx, stop := signal.NotifyContext(
@@ -64,6 +104,18 @@ func main() {
 	)
 	defer stop()
 
+	// `recommender smoke` generates a sentinel day through the whole
+	// pipeline, checks it, cleans it up, and exits instead of serving; for
+	// post-deploy verification. --fake stubs the model, Plex, and TMDb.
+	var smoke *smokeOptions
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		opts, err := parseSmokeFlags(os.Args[2:])
+		if err != nil {
+			log.Fatalw("Invalid smoke test flags", zap.Error(err))
+		}
+		smoke = &opts
+	}
+
 	registry := prometheus.NewRegistry()
 	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
 	if err != nil {
@@ -79,6 +131,21 @@ func main() {
 		}
 	}()
 
+	// ERROR_REPORTING_DSN sends panics, failed generation runs, and upstream
+	// API errors to Sentry or GlitchTip.
+	if err := errreport.Configure(errreport.Options{
+		DSN:         os.Getenv("ERROR_REPORTING_DSN"),
+		Environment: os.Getenv("ERROR_REPORTING_ENVIRONMENT"),
+		Release:     buildRevision(),
+	}); err != nil {
+		log.Fatalw("Invalid ERROR_REPORTING_DSN", zap.Error(err))
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		errreport.Flush(flushCtx)
+	}()
+
 	plexURL := os.Getenv("PLEX_URL")
 	if plexURL == "" {
 		log.Fatalw("PLEX_URL environment variable is required")
@@ -89,16 +156,32 @@ func main() {
 		log.Fatalw("PLEX_TOKEN environment variable is required")
 	}
 
+	// TMDB_API_KEY is optional; without it collection lookups and the
+	// onboarding film grid are skipped and Plex metadata is used as-is.
 	tmdbAPIKey := os.Getenv("TMDB_API_KEY")
-	if tmdbAPIKey == "" {
-		log.Fatalw("TMDB_API_KEY environment variable is required")
-	}
 
-	if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" {
-		log.Fatalw("GOOGLE_CLOUD_PROJECT environment variable is required")
-	}
-	if os.Getenv("GOOGLE_CLOUD_LOCATION") == "" {
-		log.Fatalw("GOOGLE_CLOUD_LOCATION environment variable is required")
+	llmProvider := strings.ToLower(os.Getenv("LLM_PROVIDER"))
+	switch llmProvider {
+	case "", "gemini":
+		llmProvider = "gemini"
+		if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" {
+			log.Fatalw("GOOGLE_CLOUD_PROJECT environment variable is required (or set LLM_PROVIDER=none)")
+		}
+		if os.Getenv("GOOGLE_CLOUD_LOCATION") == "" {
+			log.Fatalw("GOOGLE_CLOUD_LOCATION environment variable is required (or set LLM_PROVIDER=none)")
+		}
+	case "openai":
+		// A self-hosted OpenAI-compatible server may not need a key.
+		if os.Getenv("OPENAI_API_KEY") == "" && os.Getenv("OPENAI_BASE_URL") == "" {
+			log.Fatalw("OPENAI_API_KEY environment variable is required with LLM_PROVIDER=openai")
+		}
+	case "anthropic":
+		if os.Getenv("ANTHROPIC_API_KEY") == "" {
+			log.Fatalw("ANTHROPIC_API_KEY environment variable is required with LLM_PROVIDER=anthropic")
+		}
+	case "ollama", "none":
+	default:
+		log.Fatalw("LLM_PROVIDER must be gemini, openai, anthropic, ollama, or none", "value", llmProvider)
 	}
 
 	databaseURL := os.Getenv("DATABASE_URL")
@@ -106,8 +189,9 @@ func main() {
 		log.Fatalw("DATABASE_URL environment variable is required")
 	}
 
-	gormDB, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{
-		Logger: db.NewGormLogger(log.Desugar()),
+	gormLogger := db.NewGormLogger(log.Desugar())
+	gormDB, err := db.Open(databaseURL, &gorm.Config{
+		Logger: gormLogger,
 	})
 	if err != nil {
 		log.Fatalw("Failed to connect to database", zap.Error(err))
@@ -117,33 +201,140 @@ func main() {
 	if err != nil {
 		log.Fatalw("Failed to get database handle", zap.Error(err))
 	}
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	poolCfg, err := db.PoolConfigFromEnv()
+	if err != nil {
+		log.Fatalw("Invalid database pool configuration", zap.Error(err))
+	}
+	poolCfg.Apply(sqlDB)
+
+	slowQuery := db.DefaultSlowQueryThreshold
+	if v := os.Getenv("DB_SLOW_QUERY_THRESHOLD"); v != "" {
+		slowQuery, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalw("DB_SLOW_QUERY_THRESHOLD must be a duration (e.g. 250ms)", zap.Error(err))
+		}
+	}
+	gormLogger.SetSlowQuery(ctx, slowQuery, sqlDB)
 
 	if err := db.RunMigrations(ctx, gormDB); err != nil {
 		log.Fatalw("Failed to run migrations", zap.Error(err))
 	}
 
+	// DATABASE_REPLICA_URL points heavy read-only pages (stats, archive,
+	// dates, the data explorer) at a streaming replica.
+	readDB := gormDB
+	if replicaURL := os.Getenv("DATABASE_REPLICA_URL"); replicaURL != "" {
+		readDB, err = db.Open(replicaURL, &gorm.Config{Logger: gormLogger})
+		if err != nil {
+			log.Fatalw("Failed to connect to read replica", zap.Error(err))
+		}
+		replicaSQL, err := readDB.DB()
+		if err != nil {
+			log.Fatalw("Failed to get read replica handle", zap.Error(err))
+		}
+		poolCfg.Apply(replicaSQL)
+		log.Infow("Routing heavy reads to the replica")
+	}
+
 	fileLock := lock.NewFileLock(ctx)
 
-	tmdbClient := tmdb.NewClient(tmdbAPIKey)
+	// LEADER_ELECTION=true is for multi-replica deployments sharing one
+	// database: every replica serves reads, only the lease holder runs jobs.
+	elector := leader.Disabled()
+	if os.Getenv("LEADER_ELECTION") == "true" && smoke == nil {
+		elector = leader.New(gormDB, "scheduler", leader.DefaultTTL)
+		go elector.Run(ctx)
+	}
+
+	var tmdbClient *tmdb.Client
+	if tmdbAPIKey != "" {
+		tmdbClient = tmdb.NewClient(tmdbAPIKey)
+	} else {
+		log.Infow("TMDB_API_KEY not set; TMDb features disabled")
+	}
 
 	plexClient := plex.NewClient(plexURL, plexToken, gormDB, tmdbClient)
 
-	geminiModel := os.Getenv("GEMINI_MODEL")
-	if geminiModel == "" {
-		geminiModel = "gemini-2.5-flash"
+	// PLEX_WOL_MAC wakes a sleeping Plex host before cache syncs.
+	if mac := os.Getenv("PLEX_WOL_MAC"); mac != "" {
+		target, err := wol.ParseTarget(mac, os.Getenv("PLEX_WOL_ADDR"))
+		if err != nil {
+			log.Fatalw("Invalid PLEX_WOL_MAC or PLEX_WOL_ADDR", zap.Error(err))
+		}
+		wait := plex.DefaultWakeWait
+		if v := os.Getenv("PLEX_WOL_WAIT"); v != "" {
+			wait, err = time.ParseDuration(v)
+			if err != nil || wait <= 0 || wait > 4*time.Minute {
+				log.Fatalw("PLEX_WOL_WAIT must be a duration up to 4m (e.g. 90s)", "value", v)
+			}
+		}
+		plexClient.SetWake(target, wait)
 	}
-	chat, err := recommend.NewGeminiChatter(ctx, geminiModel)
-	if err != nil {
-		log.Fatalw("Failed to create Gemini client", zap.Error(err))
+
+	// PLEX_REMOTE falls back to the addresses plex.tv lists for the server,
+	// including Plex's relay, when PLEX_URL doesn't answer (e.g. away from
+	// home). PLEX_SERVER_ID picks the server when the account has several.
+	if os.Getenv("PLEX_REMOTE") == "true" {
+		plexClient.SetRemote(plextv.NewClient(cmp.Or(os.Getenv("PLEX_LOGIN_CLIENT_ID"), service)), os.Getenv("PLEX_SERVER_ID"))
+	}
+
+	// LLM_PROVIDER chooses the model vendor; every provider shares the
+	// daily cap. LLM_PROVIDER=none runs heuristic-only: the scorer picks each
+	// day and no credentials are needed.
+	var (
+		chat     recommend.Chatter
+		capped   *recommend.CappedChatter
+		embedder recommend.Embedder
+		model    = recommend.FallbackModel
+	)
+	switch llmProvider {
+	case "gemini":
+		model = os.Getenv("GEMINI_MODEL")
+		if model == "" {
+			model = "gemini-2.5-flash"
+		}
+		gemini, err := recommend.NewGeminiChatter(ctx, model)
+		if err != nil {
+			log.Fatalw("Failed to create Gemini client", zap.Error(err))
+		}
+		capped = recommend.NewCappedChatter(gormDB, gemini, recommend.DefaultLLMDailyCap)
+		chat = capped
+		// EMBEDDING_MODEL embeds titles for the "Because you watched …"
+		// rows; embedding calls don't count against LLM_DAILY_CAP. Other
+		// providers use the built-in word-feature embedder.
+		embedder, err = recommend.NewGeminiEmbedder(ctx, cmp.Or(os.Getenv("EMBEDDING_MODEL"), recommend.DefaultEmbeddingModel))
+		if err != nil {
+			log.Fatalw("Failed to create Gemini embedder", zap.Error(err))
+		}
+	case "openai":
+		model = cmp.Or(os.Getenv("OPENAI_MODEL"), "gpt-4o-mini")
+		capped = recommend.NewCappedChatter(gormDB, recommend.NewOpenAIChatter(os.Getenv("OPENAI_API_KEY"), model, os.Getenv("OPENAI_BASE_URL")), recommend.DefaultLLMDailyCap)
+		chat = capped
+	case "anthropic":
+		model = cmp.Or(os.Getenv("ANTHROPIC_MODEL"), "claude-sonnet-4-5")
+		capped = recommend.NewCappedChatter(gormDB, recommend.NewAnthropicChatter(os.Getenv("ANTHROPIC_API_KEY"), model), recommend.DefaultLLMDailyCap)
+		chat = capped
+	case "ollama":
+		model = cmp.Or(os.Getenv("OLLAMA_MODEL"), "llama3.1")
+		capped = recommend.NewCappedChatter(gormDB, recommend.NewOllamaChatter(os.Getenv("OLLAMA_URL"), model), recommend.DefaultLLMDailyCap)
+		chat = capped
+	case "none":
+		log.Infow("LLM_PROVIDER=none; daily picks come from the scorer alone")
+	}
+	// A fake smoke test reaches no outside service.
+	recPlex, recTMDb := plexClient, tmdbClient
+	if smoke != nil && smoke.fake {
+		chat, capped, embedder, model, llmProvider = recommend.SmokeChatter{}, nil, nil, recommend.SmokeModel, "smoke"
+		recPlex, recTMDb = nil, nil
 	}
 
 	sigCfg := recommend.SignalConfig{
 		TraktClientID:     os.Getenv("TRAKT_CLIENT_ID"),
 		TraktClientSecret: os.Getenv("TRAKT_CLIENT_SECRET"),
 		AniListUsername:   os.Getenv("ANILIST_USERNAME"),
+		MALClientID:       os.Getenv("MAL_CLIENT_ID"),
+		MALUsername:       os.Getenv("MAL_USERNAME"),
+		TraktExportList:   os.Getenv("TRAKT_EXPORT_LIST"),
 	}
 
 	// posterDir holds locally cached Plex posters; POSTER_DIR is operator config.
@@ -155,43 +346,416 @@ func main() {
 		log.Fatalw("Failed to create poster dir", zap.Error(err))
 	}
 
-	recommender, err := recommend.New(gormDB, plexClient, tmdbClient, chat, geminiModel, sigCfg, posterDir)
+	// FALLBACK_POSTER_URL replaces the generated per-title placeholder for
+	// titles without a poster (e.g. a house-style image on a CDN).
+	templates.FallbackPosterURL = os.Getenv("FALLBACK_POSTER_URL")
+
+	// URL_SIGNING_KEY signs the time-limited links to proxied Plex posters
+	// (/plex/image/…) and share pages (/share/…). Without it a random key is
+	// used, so links break on restart and between replicas.
+	signingKey := []byte(os.Getenv("URL_SIGNING_KEY"))
+	switch {
+	case len(signingKey) == 0:
+		signingKey = make([]byte, 32)
+		_, _ = rand.Read(signingKey) // never fails
+		log.Warnw("URL_SIGNING_KEY is unset; share and poster links will stop working on restart")
+	case len(signingKey) < 32:
+		log.Fatalw("URL_SIGNING_KEY must be at least 32 bytes")
+	}
+	signer := signedurl.New(signingKey)
+	templates.SignPoster = handlers.PosterSigner(signer, plexClient)
+
+	// DATE_FORMAT and WEEK_START localize how pages show dates and where
+	// calendar weeks begin.
+	if v := os.Getenv("DATE_FORMAT"); v != "" {
+		if templates.DateLayout, err = templates.ParseDateFormat(v); err != nil {
+			log.Fatalw("Invalid DATE_FORMAT", zap.Error(err))
+		}
+	}
+	if v := os.Getenv("WEEK_START"); v != "" {
+		if templates.WeekStart, err = templates.ParseWeekStart(v); err != nil {
+			log.Fatalw("Invalid WEEK_START", zap.Error(err))
+		}
+	}
+
+	// DEV_MODE reads templates and static assets from the source tree on
+	// every request instead of the embedded copies, and turns off HTTP
+	// caching, so edits show on refresh. Run from the repository root.
+	devMode := os.Getenv("DEV_MODE") == "true"
+	staticFS := fs.FS(static.Files)
+	if devMode {
+		templates.Dir = filepath.Join("handlers", "templates")
+		for _, dir := range []string{templates.Dir, "static"} {
+			if _, err := os.Stat(dir); err != nil {
+				log.Fatalw("DEV_MODE must be run from the repository root", zap.Error(err))
+			}
+		}
+		staticFS = os.DirFS("static")
+		log.Infow("Development mode: serving templates and static assets from disk")
+	}
+
+	recommender, err := recommend.New(gormDB, recPlex, recTMDb, chat, model, sigCfg, posterDir)
 	if err != nil {
 		log.Fatalw("Failed to create recommender", zap.Error(err))
 	}
+	recommender.UseProvider(llmProvider)
+	if readDB != gormDB {
+		recommender.UseReadReplica(readDB)
+	}
+	if embedder != nil {
+		recommender.UseEmbedder(embedder)
+	}
+	// VECTOR_STORE=pgvector keeps the title vector index in Postgres (needs
+	// the pgvector extension); the default searches in memory. Rebuild it
+	// with POST /admin/vectors/rebuild after switching.
+	switch v := strings.ToLower(os.Getenv("VECTOR_STORE")); v {
+	case "", "memory":
+	case "pgvector":
+		recommender.UseVectorStore(vectors.NewPGVector(gormDB))
+	default:
+		log.Fatalw("VECTOR_STORE must be memory or pgvector", "value", v)
+	}
 
-	r := chi.NewRouter()
+	// Changes made through /admin/* (and config reloads) are recorded for
+	// /admin/audit.
+	auditLog := audit.New(gormDB)
+
+	// Log level, LLM cap, and generation settings are re-read from the
+	// environment and CONFIG_FILE on SIGHUP or POST /admin/reload. A reload
+	// with any invalid value changes nothing. It returns the reloadable
+	// values in force before and after.
+	var reloadMu sync.Mutex
+	var applied map[string]string
+	reloadConfig := func() (map[string]string, map[string]string, error) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		src, err := config.Load(os.Getenv("CONFIG_FILE"))
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg, err := src.Reloadable()
+		if err != nil {
+			return nil, nil, err
+		}
+		logLevel.SetLevel(cfg.LogLevel)
+		if capped != nil {
+			capped.SetLimit(cfg.LLMDailyCap)
+		}
+		recommender.ApplySettings(cfg.Settings)
+		plexClient.SetLibraryFilter(cfg.Libraries)
+		log.Infow("Configuration loaded", "log_level", cfg.LogLevel, "llm_daily_cap", cfg.LLMDailyCap)
+		before := applied
+		applied = src.Values()
+		return before, applied, nil
+	}
+	if _, _, err := reloadConfig(); err != nil {
+		log.Fatalw("Invalid configuration", zap.Error(err))
+	}
+	if smoke != nil {
+		res, err := recommender.Smoke(ctx, smoke.date)
+		if err != nil {
+			log.Fatalw("Smoke test failed", "date", smoke.date, zap.Error(err))
+		}
+		log.Infow("Smoke test passed", "date", smoke.date, "provider", res.Run.Provider, "model", res.Run.Model,
+			"prompt_version", res.Run.PromptVersion, "movies", res.Movies, "tvshows", res.TVShows, "duration_ms", res.Run.DurationMS)
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				before, after, err := reloadConfig()
+				if err != nil {
+					log.Errorw("Config reload failed; keeping the running configuration", zap.Error(err))
+					continue
+				}
+				if err := auditLog.Record(ctx, "SIGHUP", "config.reload", "", before, after); err != nil {
+					log.Errorw("Failed to record audit entry", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	// Maintenance mode (toggled via /admin/maintenance) pauses the job queue
+	// and refuses writes on every replica.
+	maint := maintenance.New(gormDB)
+	if err := maint.Refresh(ctx); err != nil {
+		log.Warnw("Failed to load maintenance state; assuming off", zap.Error(err))
+	}
+	go maint.Run(ctx)
+
+	// NOTIFY_WEBHOOK_URLS, NOTIFY_DISCORD_URLS, and NOTIFY_SLACK_URLS list
+	// the sinks that get each newly generated day's picks; see lib/notify.
+	notifier, err := notify.FromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalw("Invalid notification configuration", zap.Error(err))
+	}
 
-	secureMiddleware := secure.New(secure.Options{
-		SSLRedirect:          false,
-		SSLProxyHeaders:      map[string]string{"X-Forwarded-Proto": "https"},
-		STSSeconds:           63072000,
-		STSIncludeSubdomains: true,
-		STSPreload:           true,
-		FrameDeny:            true,
-		ContentTypeNosniff:   true,
-		BrowserXssFilter:     true,
-		ReferrerPolicy:       "no-referrer",
-		PermissionsPolicy:    "geolocation=(), midi=(), sync-xhr=(), microphone=(), camera=(), magnetometer=(), gyroscope=(), fullscreen=(), payment=(), usb=()",
+	// Background work (cache syncs, generation) runs from the durable job
+	// queue; with leader election on, only the leader claims jobs.
+	queue := jobs.New(gormDB, elector.ID(), func() bool {
+		return elector.IsLeader() && !maint.Enabled()
 	})
+	handlers.RegisterJobs(queue, plexClient, recommender, fileLock, notifier)
+	recommender.RegisterMissingDaysMetric()
+	pipeline := health.NewPipelineMonitor(handlers.PipelineHealth(recommender, queue))
+	pipeline.RegisterMetrics()
+	go queue.Run(ctx)
+
+	// SCHEDULE_CACHE, SCHEDULE_GENERATE, and SCHEDULE_KIDS are cron
+	// expressions (UTC) that queue a cache sync, today's picks, and today's
+	// kids' picks without an external cron; unset leaves it to /cron/*.
+	// SCHEDULE_JITTER delays each run by up to that long. Like the queue,
+	// only the leader schedules, and not in maintenance.
+	jitter := 5 * time.Minute
+	if v := os.Getenv("SCHEDULE_JITTER"); v != "" {
+		jitter, err = time.ParseDuration(v)
+		if err != nil || jitter < 0 || jitter > schedule.MaxJitter {
+			log.Fatalw("SCHEDULE_JITTER must be a duration up to 1h (e.g. 5m)", "value", v)
+		}
+	}
+	scheduler := schedule.New(jitter, func() bool {
+		return elector.IsLeader() && !maint.Enabled()
+	})
+	for _, s := range []struct {
+		env, name string
+		fn        func(context.Context) error
+	}{
+		{"SCHEDULE_CACHE", handlers.JobCacheUpdate, handlers.ScheduledCache(queue)},
+		{"SCHEDULE_GENERATE", handlers.JobGenerate, handlers.ScheduledGenerate(recommender, queue, recommend.DailySlot)},
+		{"SCHEDULE_KIDS", handlers.JobGenerate + ":" + recommend.KidsSlot.Name, handlers.ScheduledGenerate(recommender, queue, recommend.KidsSlot)},
+	} {
+		if expr := os.Getenv(s.env); expr != "" {
+			if err := scheduler.Add(s.name, expr, s.fn); err != nil {
+				log.Fatalw("Invalid "+s.env, zap.Error(err))
+			}
+		}
+	}
+	if scheduler.Len() > 0 {
+		go scheduler.Run(ctx)
+	}
+
+	// PAGE_TIMEOUT and ADMIN_TIMEOUT bound request handling per route group.
+	pageTimeout, adminTimeout := 15*time.Second, 5*time.Minute
+	for _, t := range []struct {
+		env string
+		d   *time.Duration
+	}{{"PAGE_TIMEOUT", &pageTimeout}, {"ADMIN_TIMEOUT", &adminTimeout}} {
+		if v := os.Getenv(t.env); v != "" {
+			*t.d, err = time.ParseDuration(v)
+			if err != nil || *t.d <= 0 || *t.d > time.Hour {
+				log.Fatalw(t.env+" must be a duration up to 1h (e.g. 30s)", "value", v)
+			}
+		}
+	}
+
+	// API keys (managed at /admin/keys) work alongside ADMIN_TOKEN with
+	// narrower scopes. REQUIRE_API_KEYS lists the otherwise open endpoint
+	// groups to guard with them: "read" for /api/*, "cron" for /cron/*.
+	keys := apikey.New(gormDB)
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	requiredScopes := map[string]bool{}
+	for _, s := range strings.FieldsFunc(os.Getenv("REQUIRE_API_KEYS"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if s != apikey.ScopeRead && s != apikey.ScopeCron {
+			log.Fatalw(`REQUIRE_API_KEYS may list only "read" and "cron"`, "value", s)
+		}
+		requiredScopes[s] = true
+	}
+	requireKey := func(scope string) func(http.Handler) http.Handler {
+		if !requiredScopes[scope] {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return handlers.RequireScope(scope, adminToken, keys)
+	}
+
+	// Household members sign in at /login with a password or, when
+	// PLEX_LOGIN_CLIENT_ID is set, their linked Plex account. Users are
+	// managed at /admin/users. LOGIN_REQUIRED puts the pages behind sign-in.
+	authStore := auth.New(gormDB)
+	var plexLogin *plextv.Client
+	if id := os.Getenv("PLEX_LOGIN_CLIENT_ID"); id != "" {
+		plexLogin = plextv.NewClient(id)
+	}
+	requireLogin := func(next http.Handler) http.Handler { return next }
+	if os.Getenv("LOGIN_REQUIRED") == "true" {
+		requireLogin = handlers.RequireLogin(authStore)
+	}
+
+	r := chi.NewRouter()
+
+	// Security headers: CONTENT_SECURITY_POLICY replaces the generated policy,
+	// CSP_IMG_SOURCES adds poster origins to it, HSTS_MAX_AGE (seconds, 0 to
+	// disable) and FRAME_OPTIONS (DENY or SAMEORIGIN) tune the rest.
+	secCfg := handlers.SecurityConfig{
+		CSP:          os.Getenv("CONTENT_SECURITY_POLICY"),
+		ImgSources:   strings.Fields(os.Getenv("CSP_IMG_SOURCES")),
+		HSTSSeconds:  handlers.DefaultHSTSSeconds,
+		FrameOptions: os.Getenv("FRAME_OPTIONS"),
+		Development:  devMode,
+	}
+	if u, err := url.Parse(templates.FallbackPosterURL); err == nil && u.Scheme != "" && u.Host != "" {
+		secCfg.ImgSources = append(secCfg.ImgSources, u.Scheme+"://"+u.Host)
+	}
+	if s := os.Getenv("HSTS_MAX_AGE"); s != "" {
+		secCfg.HSTSSeconds, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			log.Fatalw("HSTS_MAX_AGE must be a number of seconds", zap.Error(err))
+		}
+	}
+	securityHeaders, err := handlers.SecurityHeaders(secCfg)
+	if err != nil {
+		log.Fatalw("Invalid security header configuration", zap.Error(err))
+	}
 
 	r.Use(logging.Middleware(log.Desugar()))
 	r.Use(routeTag)
-	r.Use(secureMiddleware.Handler)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.FS(static.Files))))
-	r.Handle("/posters/*", http.StripPrefix("/posters/", http.FileServer(http.Dir(posterDir))))
-
-	r.Get("/", handlers.HandleHome(recommender))
-	r.Get("/date/{date}", handlers.HandleDate(recommender))
-	r.Get("/dates", handlers.HandleDates(recommender))
-	r.Get("/cron/recommend", handlers.HandleCron(recommender, fileLock))
-	r.Get("/cron/cache", handlers.HandleCache(plexClient, recommender, fileLock))
-	r.Get("/trakt/connect", handlers.HandleTraktConnect(recommender, os.Getenv("TRAKT_CONNECT_TOKEN")))
-	r.Get("/stats", handlers.HandleStats(recommender))
-	r.Get("/health", health.Check(gormDB))
-	r.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	r.Use(handlers.Recover)
+	r.Use(securityHeaders)
+	r.Use(handlers.Compress())
+	r.Use(handlers.PauseWrites(maint))
+	if devMode {
+		r.Use(handlers.NoStore)
+	}
+
+	// Pages, API reads, and assets share a short deadline; operator actions
+	// get a long one. Streaming endpoints belong in a Timeout(0) group.
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.Timeout(pageTimeout))
+
+		r.Handle("/static/*", http.StripPrefix("/static/", handlers.StaticFiles(staticFS)))
+		r.Handle("/posters/*", http.StripPrefix("/posters/", http.FileServer(http.Dir(posterDir))))
+
+		r.Get("/placeholder.svg", handlers.HandlePlaceholder())
+		r.Get("/plex/image/*", handlers.HandlePlexImage(signer, plexClient))
+		r.Get("/share/{date}", handlers.HandleShare(recommender, signer))
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.CSRF) // HTML forms
+			r.Get("/login", handlers.HandleLogin(authStore, plexLogin))
+			r.Post("/login", handlers.HandleLogin(authStore, plexLogin))
+			r.Post("/logout", handlers.HandleLogout(authStore))
+			if plexLogin != nil {
+				r.Get("/login/plex", handlers.HandlePlexLogin(plexLogin))
+				r.Get("/login/plex/callback", handlers.HandlePlexCallback(authStore, plexLogin))
+			}
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.CSRF) // HTML forms
+			r.Use(requireLogin)
+			r.Get("/onboarding", handlers.HandleOnboarding(recommender))
+			r.Post("/onboarding", handlers.HandleOnboarding(recommender))
+			r.Get("/settings", handlers.HandleSettings(recommender))
+			r.Get("/chat", handlers.HandleChat(recommender))
+			r.Post("/chat/clear", handlers.HandleChatClear(recommender))
+			r.Post("/chat/watchlist", handlers.HandleChatAction(recommender, "watchlist"))
+			r.Post("/chat/pin", handlers.HandleChatAction(recommender, "pin"))
+			r.Post("/settings", handlers.HandleSettings(recommender))
+			r.Get("/", handlers.HandleHome(recommender, queue))
+			r.Get("/date/{date}", handlers.HandleDate(recommender))
+			r.Post("/date/{date}/note", handlers.HandleDateNote(recommender))
+			r.Post("/date/{date}/share", handlers.HandleShareDate(signer))
+			r.Post("/picks/{id}/snooze", handlers.HandleSnooze(recommender))
+			r.Get("/vote", handlers.HandleVote(recommender))
+			r.Post("/vote", handlers.HandleVote(recommender))
+			r.Get("/spin", handlers.HandleSpin(recommender))
+			r.Get("/kids", handlers.HandleKids(recommender))
+			r.Post("/spin", handlers.HandleSpin(recommender))
+		})
+
+		r.With(requireLogin).Get("/dates", handlers.HandleDates(recommender))
+		r.With(requireLogin).Get("/jobs", handlers.HandleJobStatus(queue))
+		r.With(requireLogin).Get("/archive", handlers.HandleArchive(recommender))
+		r.With(requireLogin).Get("/similar/{type}/{id}", handlers.HandleMoreLikeThis(recommender))
+		r.With(requireKey(apikey.ScopeRead)).Get("/api/recommendations", handlers.HandleAPIRecommendations(recommender))
+		r.With(requireKey(apikey.ScopeRead)).Get("/api/preferences", handlers.HandlePreferences(recommender))
+		// The versioned JSON API serves the pages' JSON views (lib/api/v1)
+		// for other apps, behind API keys rather than sign-in.
+		r.Route("/api/v1", func(r chi.Router) {
+			r.Use(requireKey(apikey.ScopeRead))
+			r.Get("/export", handlers.HandleExport(recommender))
+			r.Get("/preferences", handlers.HandlePreferences(recommender))
+			r.Group(func(r chi.Router) {
+				r.Use(handlers.JSONOnly)
+				r.Get("/recommendations", handlers.HandleAPIRecommendations(recommender))
+				r.Get("/today", handlers.HandleHome(recommender, queue))
+				r.Get("/jobs", handlers.HandleJobStatus(queue))
+				r.Get("/days/{date}", handlers.HandleDate(recommender))
+				r.Get("/dates", handlers.HandleDates(recommender))
+				r.Get("/stats", handlers.HandleStats(recommender, plexClient))
+				r.Get("/similar/{type}/{id}", handlers.HandleMoreLikeThis(recommender))
+			})
+		})
+		r.Get("/trakt/connect", handlers.HandleTraktConnect(recommender, os.Getenv("TRAKT_CONNECT_TOKEN")))
+		r.With(requireLogin).Get("/stats", handlers.HandleStats(recommender, plexClient))
+		r.Get("/health", health.Check(gormDB, elector, plexClient, pipeline))
+		r.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.Timeout(adminTimeout))
+		r.Group(func(r chi.Router) {
+			r.Use(requireKey(apikey.ScopeCron))
+			r.Use(handlers.RequireLeader(elector))
+			r.Get("/cron/recommend", handlers.HandleCron(recommender, queue))
+			r.Get("/cron/cache", handlers.HandleCache(queue))
+		})
+		// Feedback, preference, and watch-history writes always need the
+		// admin token or a feedback key.
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequireScope(apikey.ScopeFeedback, adminToken, keys))
+			r.Use(handlers.Audit(auditLog))
+			r.Post("/api/v1/feedback/bulk", handlers.HandleBulkFeedback(recommender))
+			r.Post("/api/import/letterboxd", handlers.HandleLetterboxdImport(recommender))
+			r.Post("/api/v1/import/letterboxd", handlers.HandleLetterboxdImport(recommender))
+			r.Put("/api/preferences", handlers.HandlePreferences(recommender))
+			r.Put("/api/v1/preferences", handlers.HandlePreferences(recommender))
+		})
+		// Chat replies wait on the model, so they get the long deadline too.
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.CSRF)
+			r.Use(requireLogin)
+			r.Post("/chat", handlers.HandleChat(recommender))
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequireAdmin(adminToken, keys))
+			r.Use(handlers.Audit(auditLog))
+			r.Post("/admin/reload", handlers.HandleReload(reloadConfig))
+			r.Get("/admin/maintenance", handlers.HandleMaintenance(maint))
+			r.Post("/admin/maintenance", handlers.HandleMaintenance(maint))
+			r.Post("/admin/backfill", handlers.HandleBackfill(recommender, queue))
+			r.Post("/api/admin/recommendations/{date}/regenerate", handlers.HandleRegenerate(recommender, queue))
+			r.Post("/admin/vectors/rebuild", handlers.HandleRebuildVectors(queue))
+			r.Get("/admin/caches", handlers.HandleCaches())
+			r.Get("/admin/pins", handlers.HandlePins(recommender))
+			r.Post("/admin/pins", handlers.HandlePins(recommender))
+			r.Delete("/admin/pins/{id}", handlers.HandleDeletePin(recommender))
+			r.Get("/admin/spotlights", handlers.HandleSpotlights(recommender))
+			r.Post("/admin/spotlights", handlers.HandleSpotlights(recommender))
+			r.Delete("/admin/spotlights/{date}", handlers.HandleDeleteSpotlight(recommender))
+			r.Get("/admin/runs", handlers.HandleRuns(recommender))
+			r.Get("/admin/prompts", handlers.HandlePromptRollouts(recommender))
+			r.Get("/admin/abandoned", handlers.HandleAbandonedShows(recommender))
+			r.Post("/admin/shows/{id}/decision", handlers.HandleShowDecision(recommender))
+			r.Get("/admin/keys", handlers.HandleAPIKeys(keys))
+			r.Post("/admin/keys", handlers.HandleAPIKeys(keys))
+			r.Delete("/admin/keys/{id}", handlers.HandleRevokeAPIKey(keys))
+			r.Get("/admin/audit", handlers.HandleAudit(auditLog))
+			r.Get("/admin/users", handlers.HandleUsers(authStore))
+			r.Post("/admin/users", handlers.HandleUsers(authStore))
+			r.Delete("/admin/users/{id}", handlers.HandleDeleteUser(authStore))
+			r.Delete("/admin/users/{id}/sessions", handlers.HandleEndUserSessions(authStore))
+			r.Get("/admin/data", handlers.HandleDataTables())
+			r.Get("/admin/data/{table}", handlers.HandleDataBrowse(readDB))
+		})
+	})
+	// Streams stay open until the client leaves or the server shuts down.
+	streamsDone := make(chan struct{})
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.Timeout(0))
+		r.With(requireLogin).Get("/vote/stream", handlers.HandleVoteStream(recommender, streamsDone))
+	})
 
 	portStr := os.Getenv("PORT")
 	if portStr == "" {
@@ -219,6 +783,7 @@ func main() {
 		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
+	server.RegisterOnShutdown(func() { close(streamsDone) })
 
 	go func() {
 		log.Infow("Starting server", "port", portNum)
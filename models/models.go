@@ -4,7 +4,10 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Recommendation type values used in Recommendation.Type and SQL `type` filters.
@@ -13,23 +16,43 @@ const (
 	TypeTVShow = "tvshow"
 )
 
+// Household feedback on a pick, for Recommendation.Feedback.
+const (
+	FeedbackWatched = "watched"
+	FeedbackIgnored = "ignored"
+)
+
 // Movie represents a movie from Plex
 type Movie struct {
 	ID            uint       `gorm:"primarykey"`
 	PlexRatingKey string     `gorm:"type:varchar(64);uniqueIndex:idx_movies_plex_rating_key"` // Plex metadata ratingKey (stable per library item)
 	Title         string     `gorm:"type:varchar(500);not null;index:idx_movies_title"`       // Title of the movie
 	Year          int        `gorm:"not null;index:idx_movies_year"`                          // Release year (not unique: Plex can have same title+year for different items)
-	Rating        float64    `gorm:"index:idx_movies_rating"`                                 // Rating (e.g., from IMDB)
+	Rating        *float64   `gorm:"index:idx_movies_rating"`                                 // Rating (e.g., from IMDB); nil = unknown
 	Genre         string     `gorm:"type:varchar(255);index:idx_movies_genre"`                // Genre(s)
+	Director      string     `gorm:"type:varchar(255)"`                                       // Director(s), comma-joined from Plex
+	Actors        string     `gorm:"type:varchar(1000)"`                                      // Top-billed cast, comma-joined from Plex
 	PosterURL     string     `gorm:"type:varchar(1000)"`                                      // URL to the poster image
 	Runtime       int        `gorm:"default:0"`                                               // Runtime in minutes
-	TMDbID        *int       `gorm:"uniqueIndex:idx_movies_tmdb_id"`                          // The Movie Database ID (nullable)
+	TMDbID        *int       `gorm:"uniqueIndex:idx_movies_tmdb_id,where:deleted_at IS NULL"` // The Movie Database ID (nullable)
 	IMDbID        string     `gorm:"type:varchar(32);index:idx_movies_imdb_id"`               // Plex GUID imdb://
 	TVDbID        string     `gorm:"type:varchar(32)"`                                        // Plex GUID tvdb://
 	EnrichedAt    *time.Time `gorm:"index:idx_movies_enriched_at"`                            // last TMDb enrichment; nil = never
 	ViewCount     int        `gorm:"default:0;index:idx_movies_view_count"`                   // Plex view count (0 = unwatched)
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	LastViewedAt  *time.Time // Most recent view; nil = never
+	ContentRating string     `gorm:"type:varchar(32)"`  // Plex age rating, e.g. "PG-13"; "" = unrated
+	Library       string     `gorm:"type:varchar(255)"` // Plex library section it's listed in
+	// TMDb collection (franchise) this movie belongs to; set by the
+	// collection lookup job, which Plex cache syncs don't overwrite.
+	CollectionID        *int       `gorm:"index:idx_movies_collection_id"` // nil = none, or not looked up yet
+	CollectionName      string     `gorm:"type:varchar(255)"`
+	CollectionCheckedAt *time.Time // last TMDb collection lookup; nil = never
+	ReleaseDate         *time.Time // TMDb release date, set by the same lookup; nil = unknown
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	// DeletedAt is set when the title leaves Plex; a later sync that finds
+	// it again clears it, keeping the row's ID and lookups.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	// Relationships
 	Recommendations []Recommendation `gorm:"foreignKey:MovieID"`
@@ -37,21 +60,29 @@ type Movie struct {
 
 // TVShow represents a TV show from Plex
 type TVShow struct {
-	ID            uint       `gorm:"primarykey"`
-	PlexRatingKey string     `gorm:"type:varchar(64);uniqueIndex:idx_tvshows_plex_rating_key"` // Plex metadata ratingKey (stable per library item)
-	Title         string     `gorm:"type:varchar(500);not null;index:idx_tvshows_title"`       // Title of the show
-	Year          int        `gorm:"not null;index:idx_tvshows_year"`                          // Release year
-	Rating        float64    `gorm:"index:idx_tvshows_rating"`                                 // Rating (e.g., from IMDB)
-	Genre         string     `gorm:"type:varchar(255);index:idx_tvshows_genre"`                // Genre(s)
-	PosterURL     string     `gorm:"type:varchar(1000)"`                                       // URL to the poster image
-	Seasons       int        `gorm:"default:0"`                                                // Number of seasons
-	TMDbID        *int       `gorm:"uniqueIndex:idx_tvshows_tmdb_id"`                          // The Movie Database ID (nullable)
-	IMDbID        string     `gorm:"type:varchar(32);index:idx_tvshows_imdb_id"`               // Plex GUID imdb://
-	TVDbID        string     `gorm:"type:varchar(32)"`                                         // Plex GUID tvdb://
-	EnrichedAt    *time.Time `gorm:"index:idx_tvshows_enriched_at"`                            // last TMDb enrichment; nil = never
-	ViewCount     int        `gorm:"default:0;index:idx_tvshows_view_count"`                   // Plex view count (0 = unwatched)
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID              uint       `gorm:"primarykey"`
+	PlexRatingKey   string     `gorm:"type:varchar(64);uniqueIndex:idx_tvshows_plex_rating_key"` // Plex metadata ratingKey (stable per library item)
+	Title           string     `gorm:"type:varchar(500);not null;index:idx_tvshows_title"`       // Title of the show
+	Year            int        `gorm:"not null;index:idx_tvshows_year"`                          // Release year
+	Rating          *float64   `gorm:"index:idx_tvshows_rating"`                                 // Rating (e.g., from IMDB); nil = unknown
+	Genre           string     `gorm:"type:varchar(255);index:idx_tvshows_genre"`                // Genre(s)
+	Actors          string     `gorm:"type:varchar(1000)"`                                       // Top-billed cast, comma-joined from Plex
+	PosterURL       string     `gorm:"type:varchar(1000)"`                                       // URL to the poster image
+	Seasons         int        `gorm:"default:0"`                                                // Number of seasons
+	EpisodeRuntime  int        `gorm:"default:0"`                                                // Typical episode length in minutes, from Plex; 0 = unknown
+	Episodes        int        `gorm:"default:0"`                                                // Episodes in the library (Plex leafCount)
+	WatchedEpisodes int        `gorm:"default:0"`                                                // Episodes watched (Plex viewedLeafCount)
+	LastViewedAt    *time.Time // Most recent episode view; nil = never
+	TMDbID          *int       `gorm:"uniqueIndex:idx_tvshows_tmdb_id,where:deleted_at IS NULL"` // The Movie Database ID (nullable)
+	IMDbID          string     `gorm:"type:varchar(32);index:idx_tvshows_imdb_id"`               // Plex GUID imdb://
+	TVDbID          string     `gorm:"type:varchar(32)"`                                         // Plex GUID tvdb://
+	EnrichedAt      *time.Time `gorm:"index:idx_tvshows_enriched_at"`                            // last TMDb enrichment; nil = never
+	ViewCount       int        `gorm:"default:0;index:idx_tvshows_view_count"`                   // Plex view count (0 = unwatched)
+	ContentRating   string     `gorm:"type:varchar(32)"`                                         // Plex age rating, e.g. "TV-Y7"; "" = unrated
+	Library         string     `gorm:"type:varchar(255)"`                                        // Plex library section it's listed in
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"` // set when the show leaves Plex, like Movie.DeletedAt
 
 	// Relationships
 	Recommendations []Recommendation `gorm:"foreignKey:TVShowID"`
@@ -59,56 +90,145 @@ type TVShow struct {
 
 // Recommendation represents a single recommendation item with its metadata.
 type Recommendation struct {
-	ID          uint      `gorm:"primarykey"`
-	Date        time.Time `gorm:"not null;index:idx_recommendations_date;uniqueIndex:idx_recommendations_date_title"`                    // The date this recommendation was generated
-	Title       string    `gorm:"type:varchar(500);not null;index:idx_recommendations_title;uniqueIndex:idx_recommendations_date_title"` // Title of the content
-	Type        string    `gorm:"type:varchar(20);not null;index:idx_recommendations_type;check:type IN ('movie', 'tvshow')"`            // "movie" or "tvshow"
-	Year        int       `gorm:"not null;index:idx_recommendations_year"`                                                               // Release year
-	Rating      float64   `gorm:"index:idx_recommendations_rating"`                                                                      // Rating (e.g., from IMDB)
-	Genre       string    `gorm:"type:varchar(255);index:idx_recommendations_genre"`                                                     // Genre(s)
-	PosterURL   string    `gorm:"type:varchar(1000)"`                                                                                    // URL to the poster image
-	Explanation string    `gorm:"type:varchar(1000)"`                                                                                    // model's one-line reason for this pick
-	Runtime     int       `gorm:"default:0"`                                                                                             // Runtime in minutes (for movies) or seasons (for TV shows)
-	MovieID     *uint     `gorm:"index:idx_recommendations_movie_id;constraint:OnDelete:CASCADE"`                                        // Reference to Movie if Type is "movie"
-	TVShowID    *uint     `gorm:"index:idx_recommendations_tvshow_id;constraint:OnDelete:CASCADE"`                                       // Reference to TVShow if Type is "tvshow"
-	TMDbID      int       `gorm:"not null;index:idx_recommendations_tmdb_id"`                                                            // The Movie Database ID
-	ViewCount   int       `gorm:"-"`                                                                                                     // Plex views when building prompts only (not stored)
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID             uint            `gorm:"primarykey"`
+	Date           time.Time       `gorm:"not null;index:idx_recommendations_date;uniqueIndex:idx_recommendations_date_slot_title"`                    // The date this recommendation was generated
+	Slot           string          `gorm:"type:varchar(32);not null;default:'daily';uniqueIndex:idx_recommendations_date_slot_title"`                  // generation slot: "daily", "tonight", …
+	Title          string          `gorm:"type:varchar(500);not null;index:idx_recommendations_title;uniqueIndex:idx_recommendations_date_slot_title"` // Title of the content
+	Type           string          `gorm:"type:varchar(20);not null;index:idx_recommendations_type;check:type IN ('movie', 'tvshow')"`                 // "movie" or "tvshow"
+	Year           int             `gorm:"not null;index:idx_recommendations_year"`                                                                    // Release year
+	Rating         *float64        `gorm:"index:idx_recommendations_rating"`                                                                           // Rating (e.g., from IMDB); nil = unknown
+	Genre          string          `gorm:"type:varchar(255);index:idx_recommendations_genre"`                                                          // Genre(s)
+	PosterURL      string          `gorm:"type:varchar(1000)"`                                                                                         // URL to the poster image
+	Explanation    string          `gorm:"type:varchar(1000)"`                                                                                         // model's one-line reason for this pick
+	Runtime        int             `gorm:"default:0"`                                                                                                  // Movies: runtime in minutes; 0 for TV shows
+	Seasons        int             `gorm:"default:0"`                                                                                                  // TV: number of seasons
+	MovieID        *uint           `gorm:"index:idx_recommendations_movie_id;constraint:OnDelete:CASCADE"`                                             // Reference to Movie if Type is "movie"
+	TVShowID       *uint           `gorm:"index:idx_recommendations_tvshow_id;constraint:OnDelete:CASCADE"`                                            // Reference to TVShow if Type is "tvshow"
+	TMDbID         int             `gorm:"not null;index:idx_recommendations_tmdb_id"`                                                                 // The Movie Database ID
+	Score          *ScoreBreakdown `gorm:"serializer:json;type:jsonb"`                                                                                 // scoring engine's breakdown; nil for rows generated before it was stored
+	ViewCount      int             `gorm:"-"`                                                                                                          // Plex views when building prompts only (not stored)
+	Anniversary    int             `gorm:"default:0"`                                                                                                  // years since release when Date is a notable anniversary; 0 otherwise
+	Episodes       int             `gorm:"default:0"`                                                                                                  // TV: episodes that fit the nightly viewing window; 0 = unknown
+	EpisodeRuntime int             `gorm:"default:0"`                                                                                                  // TV: typical episode length in minutes; 0 = unknown
+	Pinned         bool            `gorm:"not null;default:false"`                                                                                     // placed by a Pin rather than chosen by the generator
+	Feedback       string          `gorm:"type:varchar(16);not null;default:''"`                                                                       // FeedbackWatched, FeedbackIgnored, or "" for none yet
+	FeedbackAt     *time.Time      // when Feedback was recorded
+	SnoozedUntil   *time.Time      `gorm:"index:idx_recommendations_snoozed_until"` // "not tonight": UTC midnight the title may be picked again
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 
 	// Relationships
 	Movie  *Movie  `gorm:"foreignKey:MovieID"`
 	TVShow *TVShow `gorm:"foreignKey:TVShowID"`
 }
 
+// ScoreBreakdown is how the scoring engine ranked a recommendation: each
+// feature's weighted contribution, their total, and the title's rank among
+// the day's eligible titles of its type.
+type ScoreBreakdown struct {
+	Rating     float64 `json:"rating"`
+	Recency    float64 `json:"recency"`
+	Affinity   float64 `json:"affinity"`
+	Novelty    float64 `json:"novelty"`
+	RuntimeFit float64 `json:"runtime_fit"`
+	Watchlist  float64 `json:"watchlist"`
+	Total      float64 `json:"total"`
+	Rank       int     `json:"rank"`
+	// DiversitySwap marks a title swapped in by the diversity pass in place
+	// of a model pick that shared a genre, decade, or director.
+	DiversitySwap bool `json:"diversity_swap"`
+	// Anniversary is the boost for a release anniversary on the day.
+	Anniversary float64 `json:"anniversary"`
+	// Snoozed is the boost for a title back from a snooze.
+	Snoozed float64 `json:"snoozed"`
+}
+
 // Run status values for GenerationRun.Status.
 const (
-	RunStatusOK    = "ok"
-	RunStatusError = "error"
+	RunStatusRunning = "running"
+	RunStatusOK      = "ok"
+	RunStatusError   = "error"
 )
 
+// Strategy values for GenerationRun.Strategy: how the picks were chosen.
+const (
+	StrategyModel    = "model"    // the model picked from the scored shortlist
+	StrategyFallback = "fallback" // the model failed; the scorer's top titles were used
+	StrategyScored   = "scored"   // no model configured (LLM_PROVIDER=none)
+)
+
+// RunContextDaily is the GenerationRun.Context (and Recommendation.Slot) of
+// the main per-day set. Time-of-day slots use their slot name instead.
+const RunContextDaily = "daily"
+
 // Signal source + kind values for ExternalSignal.
 const (
 	SourcePlex          = "plex"
 	SourceTrakt         = "trakt"
 	SourceAniList       = "anilist"
+	SourceMAL           = "mal"
+	SourceHousehold     = "household"
+	SourceLetterboxd    = "letterboxd"
 	SignalKindWatched   = "watched"
 	SignalKindRated     = "rated"
 	SignalKindScore     = "score"
 	SignalKindWatchlist = "watchlist"
+	SignalKindVote      = "vote" // household votes for a title; Value is the count
+	SignalKindSpin      = "spin" // spin-the-wheel landings on a title; Value is the count
 )
 
-// GenerationRun records one recommendation-generation attempt for a day.
+// GenerationRun records recommendation generation for a day. There is one row
+// per (Date, Context): a generator claims it (status "running") before calling
+// the LLM, so concurrent replicas can't both generate the same day. Failed
+// runs are reclaimed in place, with Attempts counting tries.
 type GenerationRun struct {
 	ID          uint      `gorm:"primarykey"`
-	Date        time.Time `gorm:"not null;index:idx_generation_runs_date"` // UTC midnight of the target day
-	Status      string    `gorm:"type:varchar(20);not null"`               // "ok" or "error"
+	Date        time.Time `gorm:"not null;uniqueIndex:idx_generation_runs_date_context"`                                  // UTC midnight of the target day
+	Context     string    `gorm:"type:varchar(64);not null;default:'daily';uniqueIndex:idx_generation_runs_date_context"` // which set: "daily", …
+	Status      string    `gorm:"type:varchar(20);not null"`                                                              // "running", "ok", or "error"
+	Attempts    int       `gorm:"default:0"`
 	MovieCount  int       `gorm:"default:0"`
 	TVShowCount int       `gorm:"default:0"`
 	Model       string    `gorm:"type:varchar(64)"`
 	DurationMS  int64     `gorm:"default:0"`
 	Error       string    `gorm:"type:varchar(1000)"`
-	CreatedAt   time.Time
+	// PromptContext is the situational context (e.g. holiday, weather) given
+	// to the model, kept so picks can be evaluated against it later.
+	PromptContext string `gorm:"type:varchar(1000)"`
+	// Theme is the holiday the day's picks were themed for (e.g.
+	// "Thanksgiving"), shown alongside them; empty on ordinary days.
+	Theme string `gorm:"type:varchar(128)"`
+	// Provenance of the last attempt, so a change in the picks can be traced
+	// to an upgrade, a new model or prompt, or a settings change.
+	Provider      string `gorm:"type:varchar(32)"`       // LLM_PROVIDER, e.g. "gemini"; "none" without a model
+	Build         string `gorm:"type:varchar(64)"`       // VCS revision or module version of the binary
+	PromptVersion string `gorm:"type:varchar(16)"`       // hash of the prompt templates; empty when no model was asked
+	Strategy      string `gorm:"type:varchar(16)"`       // a Strategy* value
+	PromptCanary  string `gorm:"type:varchar(16);index"` // PromptRollout.Version when the canary prompts were used
+	// ValidationFailed marks a model reply that couldn't be parsed or named
+	// a title that wasn't on the shortlist.
+	ValidationFailed bool       `gorm:"not null;default:false"`
+	Config           *RunConfig `gorm:"serializer:json;type:jsonb"` // the settings the run read
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// RunConfig is the snapshot of generation settings a GenerationRun used.
+type RunConfig struct {
+	Movies          int      `json:"movies"` // slot composition after DAILY_MOVIES/DAILY_TVSHOWS
+	TVShows         int      `json:"tv_shows"`
+	MaxRuntime      int      `json:"max_runtime,omitempty"` // slot movie runtime cap, minutes
+	Shortlist       int      `json:"shortlist"`             // titles per type offered to the model
+	Diversity       []string `json:"diversity"`             // enforced rules: "genre", "decade", "director"
+	MinRating       float64  `json:"min_rating,omitempty"`
+	MinYear         int      `json:"min_year,omitempty"`
+	MinRuntime      int      `json:"min_runtime,omitempty"`
+	RequireMetadata bool     `json:"require_metadata,omitempty"`
+	NightlyMinutes  int      `json:"nightly_minutes"`
+	Weather         bool     `json:"weather"`  // weather context enabled
+	Holidays        bool     `json:"holidays"` // holiday themes enabled
+	RetryInterval   string   `json:"retry_interval,omitempty"`
+	RetryUntil      int      `json:"retry_until,omitempty"`
 }
 
 // ExternalSignal is a per-title or per-user signal from a source (Plex, Trakt, …)
@@ -133,3 +253,277 @@ type OAuthToken struct {
 	ExpiresAt    time.Time
 	UpdatedAt    time.Time
 }
+
+// LeaderLease is a time-bounded claim on a named role (e.g. running scheduled
+// jobs) so only one replica performs it at a time.
+type LeaderLease struct {
+	Name      string    `gorm:"type:varchar(64);primarykey"`
+	Holder    string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// Job status values for Job.Status.
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// Job is a durable unit of background work (see lib/jobs). Pending jobs whose
+// RunAt has passed are claimed by a worker; failures retry with backoff until
+// MaxAttempts is reached.
+type Job struct {
+	ID          uint      `gorm:"primarykey"`
+	Kind        string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_jobs_active_key,where:(status = 'pending' OR status = 'running') AND unique_key <> ''"`
+	UniqueKey   string    `gorm:"type:varchar(128);uniqueIndex:idx_jobs_active_key"` // de-duplicates active jobs of a kind
+	Payload     string    `gorm:"type:text"`                                         // JSON handler input
+	Status      string    `gorm:"type:varchar(20);not null;index:idx_jobs_status_run_at"`
+	Attempts    int       `gorm:"default:0"`
+	MaxAttempts int       `gorm:"default:5"`
+	RunAt       time.Time `gorm:"not null;index:idx_jobs_status_run_at"`
+	LockedBy    string    `gorm:"type:varchar(255)"`
+	LockedAt    *time.Time
+	LastError   string `gorm:"type:varchar(1000)"`
+	Progress    int    `gorm:"default:0"` // percent done reported by the running handler; reset when claimed
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// MaintenanceState is the single-row maintenance-mode switch (ID is always
+// 1). While Enabled, background jobs are paused and writes are refused.
+type MaintenanceState struct {
+	ID        uint   `gorm:"primarykey"`
+	Enabled   bool   `gorm:"not null;default:false"`
+	Reason    string `gorm:"type:varchar(255)"`
+	UpdatedAt time.Time
+}
+
+// LLMUsage counts LLM invocations per UTC day, backing the daily call cap.
+type LLMUsage struct {
+	Date      time.Time `gorm:"primarykey"` // UTC midnight
+	Calls     int       `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// TasteProfile keys: the profile learned from watch history, and the seed
+// from the cold-start onboarding quiz.
+const (
+	TasteProfileDefault    = "default"
+	TasteProfileOnboarding = "onboarding"
+)
+
+// TasteProfile is a natural-language summary of viewing taste fed to the
+// prompt: either learned from Plex watch history after each cache sync, or
+// stated by the user in the onboarding quiz.
+type TasteProfile struct {
+	Key          string `gorm:"primarykey;type:varchar(64)"`
+	Summary      string `gorm:"type:text"`
+	WatchedCount int    `gorm:"default:0"` // watched titles the summary was built from
+	UpdatedAt    time.Time
+}
+
+// UserPreference is what the household says it wants, edited on the
+// settings page or through /api/preferences and given to the model with
+// every prompt. There is a single row.
+type UserPreference struct {
+	ID             uint     `gorm:"primarykey"`
+	FavoriteGenres []string `gorm:"serializer:json;type:jsonb"`
+	Moods          []string `gorm:"serializer:json;type:jsonb"` // from recommend.OnboardingMoods
+	Lengths        []string `gorm:"serializer:json;type:jsonb"` // recommend.PreferenceLengths: "short", "standard", "long"
+	Languages      []string `gorm:"serializer:json;type:jsonb"`
+	UpdatedAt      time.Time
+}
+
+// Roles of a ChatMessage.
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage is one turn of a conversation on the chat page. Owner is
+// "user:<id>" for a signed-in user, or "guest:<token>" from the chat cookie
+// when sign-in is off, so each person keeps their own history.
+type ChatMessage struct {
+	ID        uint        `gorm:"primarykey;index:idx_chat_messages_owner_id,priority:2"`
+	Owner     string      `gorm:"type:varchar(80);not null;index:idx_chat_messages_owner_id,priority:1"`
+	Role      string      `gorm:"type:varchar(16);not null"` // ChatRoleUser or ChatRoleAssistant
+	Content   string      `gorm:"type:text;not null"`
+	Titles    []ChatTitle `gorm:"serializer:json;type:jsonb"` // assistant suggestions, in order
+	CreatedAt time.Time
+}
+
+// ChatTitle is a library title suggested in a chat reply.
+type ChatTitle struct {
+	Type   string `json:"type"` // TypeMovie or TypeTVShow
+	ID     uint   `json:"id"`   // Movie or TVShow ID
+	Title  string `json:"title"`
+	Year   int    `json:"year"`
+	Reason string `json:"reason"`
+}
+
+// Rollout status values for PromptRollout.Status.
+const (
+	RolloutCanary     = "canary"
+	RolloutRolledBack = "rolled_back"
+)
+
+// PromptRollout is a canary prompt set (PROMPT_CANARY_DIR), keyed by a hash
+// of its files. A rolled-back set is never used again; editing the files
+// makes a new version that starts over as a canary.
+type PromptRollout struct {
+	ID        uint   `gorm:"primarykey"`
+	Version   string `gorm:"type:varchar(16);not null;uniqueIndex"`
+	Status    string `gorm:"type:varchar(16);not null"` // RolloutCanary or RolloutRolledBack
+	Reason    string `gorm:"type:varchar(1000)"`        // why it was rolled back
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TitleEmbedding is the embedding vector of a library title's description,
+// for "Because you watched …" rows. TextHash lets a refresh skip titles
+// whose description hasn't changed since they were embedded with Model.
+type TitleEmbedding struct {
+	ID        uint      `gorm:"primarykey"`
+	Type      string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_title_embeddings_title,priority:1"` // TypeMovie or TypeTVShow
+	TitleID   uint      `gorm:"not null;uniqueIndex:idx_title_embeddings_title,priority:2"`                  // Movie or TVShow ID
+	Model     string    `gorm:"type:varchar(64);not null"`                                                   // embedding model; vectors from different models aren't compared
+	TextHash  string    `gorm:"type:varchar(64);not null"`                                                   // hex SHA-256 of the embedded text
+	Vector    []float32 `gorm:"serializer:json;type:jsonb"`
+	UpdatedAt time.Time
+}
+
+// BecauseRow is a "Because you watched …" row on the home page: the
+// unwatched titles closest to a recently watched one. The job that computes
+// them replaces every row at once.
+type BecauseRow struct {
+	ID        uint       `gorm:"primarykey"`
+	Position  int        `gorm:"not null"` // order on the page, from 0
+	SeedType  string     `gorm:"type:varchar(16);not null"`
+	SeedID    uint       `gorm:"not null"`
+	SeedTitle string     `gorm:"type:varchar(500);not null"`
+	Titles    []TitleRef `gorm:"serializer:json;type:jsonb"` // closest first
+	CreatedAt time.Time
+}
+
+// TitleRef points at a library title in a BecauseRow.
+type TitleRef struct {
+	Type       string  `json:"type"` // TypeMovie or TypeTVShow
+	ID         uint    `json:"id"`   // Movie or TVShow ID
+	Similarity float64 `json:"similarity"`
+}
+
+// Pin asks for a specific library title on a future day ("Die Hard on Dec
+// 24"). Daily generation includes the day's pins as Pinned recommendations,
+// counting them against the slot's movie and TV targets.
+type Pin struct {
+	ID        uint      `gorm:"primarykey"`
+	Date      time.Time `gorm:"not null;uniqueIndex:idx_pins_date_title"`                    // UTC midnight of the day to show it
+	Type      string    `gorm:"type:varchar(20);not null;check:type IN ('movie', 'tvshow')"` // "movie" or "tvshow"
+	Title     string    `gorm:"type:varchar(500);not null;uniqueIndex:idx_pins_date_title"`  // library title, copied when pinned
+	Year      int       `gorm:"not null"`                                                    // library year, copied when pinned
+	MovieID   *uint     `gorm:"index:idx_pins_movie_id"`                                     // Reference to Movie if Type is "movie"
+	TVShowID  *uint     `gorm:"index:idx_pins_tvshow_id"`                                    // Reference to TVShow if Type is "tvshow"
+	Note      string    `gorm:"type:varchar(1000)"`                                          // shown as the recommendation's explanation
+	CreatedAt time.Time
+}
+
+// DateNote is a freeform journal entry for a day ("watched the thriller,
+// loved it"), shown with the day's picks and fed to later prompts.
+type DateNote struct {
+	Date      time.Time `gorm:"primarykey"` // UTC midnight
+	Body      string    `gorm:"type:varchar(2000);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Spotlight themes a day around one person ("Denzel day"): the daily picks
+// are drawn from titles crediting them as director or cast. Rows are either
+// requested for a day or recorded when the weekly rotation picks someone.
+type Spotlight struct {
+	Date      time.Time `gorm:"primarykey"`                 // UTC midnight
+	Person    string    `gorm:"type:varchar(255);not null"` // as credited in Plex
+	Auto      bool      `gorm:"not null;default:false"`     // chosen by rotation rather than requested
+	CreatedAt time.Time
+}
+
+// Show decisions for ShowDecision.Decision.
+const (
+	ShowDropped = "dropped"
+	ShowKept    = "kept"
+)
+
+// ShowDecision records what was decided about a show that was started and
+// then left alone: dropped shows are no longer recommended or counted as
+// liked, and kept shows aren't suggested for dropping again for a while.
+type ShowDecision struct {
+	TVShowID  uint   `gorm:"primarykey"`
+	Decision  string `gorm:"type:varchar(16);not null;check:chk_show_decisions_decision,decision IN ('dropped','kept')"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Vote is one household member's favorite among a day's picks. Each voter
+// has one vote per day; voting again moves it. When the day is regenerated,
+// votes move to the same title's new pick, and are deleted if it's gone.
+type Vote struct {
+	ID               uint      `gorm:"primarykey"`
+	Date             time.Time `gorm:"not null;uniqueIndex:idx_votes_date_voter"` // UTC midnight
+	Voter            string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_votes_date_voter"`
+	RecommendationID uint      `gorm:"not null;index"`
+	MovieID          *uint     `gorm:"index"`
+	TVShowID         *uint     `gorm:"index"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// APIKey is an operator-issued key for the API. Only the key's SHA-256 hash
+// is stored; Prefix, its first characters, tells keys apart in listings.
+// Scopes is a comma-separated list of what the key may do (read, feedback,
+// cron, admin).
+type APIKey struct {
+	ID         uint   `gorm:"primarykey"`
+	Name       string `gorm:"type:varchar(64);not null"`
+	Prefix     string `gorm:"type:varchar(16);not null"`
+	Hash       string `gorm:"type:char(64);not null;uniqueIndex"`
+	Scopes     string `gorm:"type:varchar(64);not null"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// AuditEntry records a change made through an operator endpoint: who made
+// it, what it was, and the affected record before and after as JSON (null
+// when there was none, e.g. Before for something created).
+type AuditEntry struct {
+	ID        uint            `gorm:"primarykey"`
+	CreatedAt time.Time       `gorm:"index"`
+	Actor     string          `gorm:"type:varchar(128);not null"`
+	Action    string          `gorm:"type:varchar(64);not null;index"`
+	Target    string          `gorm:"type:varchar(255)"`
+	Before    json.RawMessage `gorm:"serializer:json;type:jsonb"`
+	After     json.RawMessage `gorm:"serializer:json;type:jsonb"`
+}
+
+// User is someone who can sign in to the web UI, with a local password, a
+// linked Plex account, or both.
+type User struct {
+	ID           uint    `gorm:"primarykey"`
+	Name         string  `gorm:"type:varchar(64);not null;uniqueIndex"`
+	PasswordHash string  `gorm:"type:varchar(72)"`              // bcrypt; empty disables password sign-in
+	PlexUsername *string `gorm:"type:varchar(128);uniqueIndex"` // plex.tv account allowed to sign in as this user
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Session is a signed-in browser. ID is the SHA-256 hash of the session
+// cookie, so the table alone can't be used to sign in.
+type Session struct {
+	ID         string    `gorm:"type:char(64);primarykey"`
+	UserID     uint      `gorm:"not null;index"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	Remember   bool      `gorm:"not null;default:false"` // "remember me": the cookie outlives the browser session
+	UserAgent  string    `gorm:"type:varchar(255)"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
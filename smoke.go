@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/icco/recommender/lib/recommend"
+)
+
+// smokeOptions are the flags of `recommender smoke`.
+type smokeOptions struct {
+	date time.Time
+	fake bool // stub the model and leave Plex and TMDb out
+}
+
+// parseSmokeFlags reads `recommender smoke [--date 1999-01-01] [--fake]`.
+func parseSmokeFlags(args []string) (smokeOptions, error) {
+	fs := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	date := fs.String("date", recommend.DefaultSmokeDate.Format(time.DateOnly), "sentinel day to generate, before "+recommend.SmokeCutoff.Format(time.DateOnly))
+	fake := fs.Bool("fake", false, "stub the model and skip Plex and TMDb")
+	if err := fs.Parse(args); err != nil {
+		return smokeOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return smokeOptions{}, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	d, err := time.Parse(time.DateOnly, *date)
+	if err != nil {
+		return smokeOptions{}, fmt.Errorf("--date must be YYYY-MM-DD")
+	}
+	if !d.Before(recommend.SmokeCutoff) {
+		return smokeOptions{}, fmt.Errorf("--date must be before %s so no real day is touched", recommend.SmokeCutoff.Format(time.DateOnly))
+	}
+	return smokeOptions{date: d, fake: *fake}, nil
+}
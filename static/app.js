@@ -0,0 +1,152 @@
+// Page behaviour, kept out of the templates so the Content-Security-Policy
+// can forbid inline scripts.
+
+// Posters that fail to load fall back to the image in their data-fallback
+// attribute. Error events don't bubble, so listen in the capture phase.
+document.addEventListener("error", (e) => {
+  const img = e.target;
+  if (img.tagName !== "IMG" || !img.dataset.fallback) return;
+  img.src = img.dataset.fallback;
+  delete img.dataset.fallback;
+}, true);
+
+// "Show more" appends a day-page section's next page in place rather than
+// navigating; without JavaScript the link opens that page on its own.
+document.addEventListener("click", async (e) => {
+  const link = e.target.closest("a[data-more]");
+  if (!link) return;
+  e.preventDefault();
+  const res = await fetch(link.href + "&partial=1");
+  if (!res.ok) {
+    location.href = link.href;
+    return;
+  }
+  const next = new DOMParser().parseFromString(await res.text(), "text/html").querySelector("section");
+  link.closest("section").querySelector(".grid").append(...next.querySelector(".grid").children);
+  const more = next.querySelector("a[data-more]");
+  more ? link.replaceWith(more) : link.remove();
+});
+
+// The voting page follows the live tally from its server-sent events; the
+// counts rendered with the page stand if the stream is unavailable. This
+// script loads in the head, so wait for the page before looking for it.
+document.addEventListener("DOMContentLoaded", () => {
+  const voteStream = document.querySelector("[data-vote-stream]");
+  if (!voteStream) return;
+  const events = new EventSource(voteStream.dataset.voteStream);
+  events.addEventListener("tally", (e) => {
+    const tally = JSON.parse(e.data);
+    document.querySelectorAll("[data-votes-for]").forEach((el) => {
+      el.textContent = tally.votes[el.dataset.votesFor] || 0;
+    });
+    const total = document.querySelector("[data-votes-total]");
+    if (total) total.textContent = tally.total;
+  });
+});
+
+// After a spin, the highlight runs around the picks, slowing until it stops
+// on the one the server chose, and then the result appears. Without
+// JavaScript, or with reduced motion, the result simply shows.
+document.addEventListener("DOMContentLoaded", () => {
+  const wheel = document.querySelector("[data-spin-landed]");
+  if (!wheel || matchMedia("(prefers-reduced-motion: reduce)").matches) return;
+  const slots = [...wheel.querySelectorAll("[data-spin-slot]")];
+  const landed = slots.findIndex((el) => el.dataset.spinSlot === wheel.dataset.spinLanded);
+  const result = wheel.querySelector("[data-spin-result]");
+  if (landed < 0) return;
+  const ring = ["ring-4", "ring-indigo-500"];
+  slots[landed].classList.remove(...ring);
+  if (result) result.classList.add("invisible");
+
+  // Two full laps, then on to the landed slot.
+  const steps = 2 * slots.length + landed;
+  let step = 0;
+  const tick = () => {
+    slots[step % slots.length].classList.remove(...ring);
+    step++;
+    slots[step % slots.length].classList.add(...ring);
+    if (step < steps) {
+      setTimeout(tick, 60 + 240 * (step / steps) ** 2);
+    } else if (result) {
+      result.classList.remove("invisible");
+    }
+  };
+  slots[0].classList.add(...ring);
+  setTimeout(tick, 60);
+});
+
+// The chat page streams the reply into the conversation as it is written,
+// then reloads to show the suggestions with their actions. Without
+// JavaScript the form posts normally and the page returns with the reply.
+document.addEventListener("submit", async (e) => {
+  const form = e.target.closest("form[data-chat]");
+  if (!form) return;
+  e.preventDefault();
+  const log = document.querySelector("[data-chat-log]");
+  const body = new FormData(form);
+  const asked = document.createElement("div");
+  asked.className = "ml-12 p-3 rounded-lg bg-indigo-600 text-white whitespace-pre-line";
+  asked.textContent = body.get("message");
+  const reply = document.createElement("div");
+  reply.className = "mr-12 p-3 rounded-lg bg-white shadow whitespace-pre-line text-gray-600";
+  reply.textContent = "…";
+  log.append(asked, reply);
+  form.querySelector("button").disabled = true;
+  form.reset();
+
+  const res = await fetch(form.action, { method: "POST", body, headers: { Accept: "text/event-stream" } });
+  if (!res.ok || !res.body) {
+    reply.textContent = "We couldn't answer that. Please try again later.";
+    form.querySelector("button").disabled = false;
+    return;
+  }
+  const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buf = "";
+  let text = "";
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) break;
+    buf += value;
+    let end;
+    while ((end = buf.indexOf("\n\n")) >= 0) {
+      const block = buf.slice(0, end);
+      buf = buf.slice(end + 2);
+      const event = /^event: (.*)$/m.exec(block)?.[1];
+      const data = JSON.parse(/^data: (.*)$/m.exec(block)?.[1] || "{}");
+      if (event === "text") {
+        text += data.text;
+        reply.textContent = text;
+      } else if (event === "done") {
+        location.reload();
+        return;
+      } else if (event === "error") {
+        reply.textContent = data.error;
+      }
+    }
+  }
+  form.querySelector("button").disabled = false;
+});
+
+// The home page's banner follows library syncs and generation as they run.
+// Once they finish it goes away, or, when today's picks were still on their
+// way, the page reloads to show them. Without JavaScript the banner is as of
+// page load.
+document.addEventListener("DOMContentLoaded", () => {
+  const banner = document.querySelector("[data-job-status]");
+  if (!banner) return;
+  const poll = async () => {
+    const res = await fetch(banner.dataset.jobStatus, { headers: { Accept: "application/json" } }).catch(() => null);
+    if (res?.ok) {
+      const { jobs } = await res.json();
+      if (jobs.length === 0) {
+        "jobReload" in banner.dataset ? location.reload() : banner.remove();
+        return;
+      }
+      banner.textContent = jobs
+        .map((j) => `${j.label}… ${j.status === "running" ? `${j.progress}%` : "queued"}`)
+        .join(" · ");
+    }
+    setTimeout(poll, 5000);
+  };
+  setTimeout(poll, 5000);
+});
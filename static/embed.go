@@ -1,10 +1,10 @@
-// Package static provides the embedded static assets (favicon, etc.) that
-// the recommender service serves under /static/.
+// Package static provides the embedded static assets (favicon, placeholder
+// poster, page script, etc.) that the recommender service serves under /static/.
 package static
 
 import "embed"
 
 // Files holds embedded static assets served under /static/.
 //
-//go:embed favicon.svg
+//go:embed app.js favicon.svg placeholder.svg
 var Files embed.FS